@@ -0,0 +1,226 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"os"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"text/tabwriter"
+	"time"
+)
+
+// benchTarget is one endpoint requested by the bench subcommand
+type benchTarget struct {
+	name string // e.g. /ls/logs
+	url  string
+}
+
+// benchResult holds the outcome of the requests sent to one target
+type benchResult struct {
+	latencies []time.Duration // of successful requests
+	errors    int
+	err       error // first error
+}
+
+// runBench implements the bench subcommand. It sends GET requests from
+// concurrent workers to the /ls and /cat targets in turn and reports
+// latency percentiles and throughput per target:
+//
+//	cat-server bench --server http://files:8080 -c 20 -d 30s --ls logs --cat logs/app.log
+//
+// With --max-p99 it fails when a target's 99th percentile latency exceeds
+// the limit, e.g. --max-p99 100ms for the directory listing target.
+func runBench(args []string) error {
+	fs := flag.NewFlagSet("bench", flag.ContinueOnError)
+	c := newAPIClient(fs)
+	concurrency := fs.Int("c", 10, "Number of concurrent workers")
+	requests := fs.Int("n", 1000, "Total number of requests, ignored with -d")
+	duration := fs.Duration("d", 0, "Send requests for this long instead of -n requests")
+	maxP99 := fs.Duration("max-p99", 0, "Fail when a target's p99 latency exceeds this")
+	var lsPaths, catFiles []string
+	fs.Func("ls", "Directory to list, repeatable (default: .)", func(v string) error {
+		lsPaths = append(lsPaths, v)
+		return nil
+	})
+	fs.Func("cat", "File to read, repeatable", func(v string) error {
+		catFiles = append(catFiles, v)
+		return nil
+	})
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *concurrency < 1 {
+		return errors.New("-c must be at least 1")
+	}
+	if *duration <= 0 && *requests < 1 {
+		return errors.New("-n must be at least 1")
+	}
+	if len(lsPaths) == 0 && len(catFiles) == 0 {
+		lsPaths = []string{"."}
+	}
+
+	var targets []benchTarget
+	for _, path := range lsPaths {
+		targets = append(targets, benchTarget{name: "/ls/" + path, url: c.url(nil, "ls", path)})
+	}
+	for _, file := range catFiles {
+		targets = append(targets, benchTarget{name: "/cat/" + file, url: c.url(nil, "cat", file)})
+	}
+
+	// Keep an idle connection per worker so requests reuse connections
+	c.http.Transport = &http.Transport{
+		Proxy:               http.ProxyFromEnvironment,
+		MaxIdleConnsPerHost: *concurrency,
+	}
+
+	results, elapsed := benchmark(c, targets, *concurrency, *requests, *duration)
+	return reportBench(os.Stdout, targets, results, elapsed, *maxP99)
+}
+
+// benchmark sends requests to targets round-robin from concurrency
+// workers, either n requests in total or as many as fit in duration, and
+// returns the results by target with the elapsed time
+func benchmark(c *apiClient, targets []benchTarget, concurrency, n int, duration time.Duration) ([]benchResult, time.Duration) {
+	var (
+		next     atomic.Int64
+		wg       sync.WaitGroup
+		deadline = time.Now().Add(duration)
+		perWork  = make([][]benchResult, concurrency)
+	)
+
+	start := time.Now()
+	for w := range concurrency {
+		perWork[w] = make([]benchResult, len(targets))
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				i := int(next.Add(1) - 1)
+				if duration > 0 {
+					if time.Now().After(deadline) {
+						return
+					}
+				} else if i >= n {
+					return
+				}
+
+				t := i % len(targets)
+				requestStart := time.Now()
+				if err := benchRequest(c, targets[t].url); err != nil {
+					if perWork[w][t].errors == 0 {
+						perWork[w][t].err = err
+					}
+					perWork[w][t].errors++
+					continue
+				}
+				perWork[w][t].latencies = append(perWork[w][t].latencies, time.Since(requestStart))
+			}
+		}()
+	}
+	wg.Wait()
+	elapsed := time.Since(start)
+
+	results := make([]benchResult, len(targets))
+	for _, worker := range perWork {
+		for t, result := range worker {
+			results[t].latencies = append(results[t].latencies, result.latencies...)
+			results[t].errors += result.errors
+			if results[t].err == nil {
+				results[t].err = result.err
+			}
+		}
+	}
+	return results, elapsed
+}
+
+// benchRequest sends one request and reads the whole response, so the
+// latency includes the transfer of the body
+func benchRequest(c *apiClient, url string) error {
+	resp, err := c.get(url)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if _, err := io.Copy(io.Discard, resp.Body); err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return nil
+}
+
+// reportBench prints a table of the results and returns an error when
+// requests failed or a p99 latency exceeds maxP99
+func reportBench(w io.Writer, targets []benchTarget, results []benchResult, elapsed time.Duration, maxP99 time.Duration) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "target\trequests\terrors\tp50\tp90\tp99\tmax")
+
+	var total benchResult
+	var slow []string
+	for t, result := range results {
+		slices.Sort(result.latencies)
+		writeBenchRow(tw, targets[t].name, result)
+		total.latencies = append(total.latencies, result.latencies...)
+		total.errors += result.errors
+		if total.err == nil && result.err != nil {
+			total.err = fmt.Errorf("%s: %w", targets[t].name, result.err)
+		}
+		if maxP99 > 0 && percentile(result.latencies, 0.99) > maxP99 {
+			slow = append(slow, targets[t].name)
+		}
+	}
+	if len(results) > 1 {
+		slices.Sort(total.latencies)
+		writeBenchRow(tw, "total", total)
+	}
+	tw.Flush()
+
+	count := len(total.latencies) + total.errors
+	fmt.Fprintf(w, "\n%d requests in %s, %.1f req/s\n", count, elapsed.Round(time.Millisecond), float64(count)/elapsed.Seconds())
+
+	switch {
+	case total.errors > 0:
+		return fmt.Errorf("%d request(s) failed, first: %w", total.errors, total.err)
+	case len(slow) > 0:
+		return fmt.Errorf("p99 latency above %s for %v", maxP99, slow)
+	}
+	return nil
+}
+
+// writeBenchRow writes the table row of one result with sorted latencies
+func writeBenchRow(w io.Writer, name string, result benchResult) {
+	fmt.Fprintf(w, "%s\t%d\t%d\t%s\t%s\t%s\t%s\n", name,
+		len(result.latencies)+result.errors, result.errors,
+		formatLatency(percentile(result.latencies, 0.50)),
+		formatLatency(percentile(result.latencies, 0.90)),
+		formatLatency(percentile(result.latencies, 0.99)),
+		formatLatency(percentile(result.latencies, 1)))
+}
+
+// percentile returns the p-th quantile of sorted latencies using the
+// nearest-rank method, or 0 when there are none
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := int(math.Ceil(p*float64(len(sorted)))) - 1
+	return sorted[max(0, min(rank, len(sorted)-1))]
+}
+
+// formatLatency formats d with a precision suited to request latencies
+func formatLatency(d time.Duration) string {
+	switch {
+	case d >= time.Second:
+		return d.Round(time.Millisecond).String()
+	case d >= time.Millisecond:
+		return d.Round(10 * time.Microsecond).String()
+	}
+	return d.Round(time.Microsecond).String()
+}
@@ -0,0 +1,279 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sh05/cat-server/pkg/application/services"
+)
+
+// defaultServerURL is the server the client talks to unless --server or
+// CAT_SERVER_URL names another
+const defaultServerURL = "http://localhost:8080"
+
+// clientCommands are the commands of the client subcommand
+var clientCommands = map[string]func(c *apiClient, fs *flag.FlagSet, args []string) error{
+	"ls":   clientLs,
+	"cat":  clientCat,
+	"grep": clientGrep,
+}
+
+// runClient implements the client subcommand, which calls a running
+// server's API and prints the results like the Unix tool of the same name:
+//
+//	cat-server client ls --server http://files:8080 -l logs
+//	cat-server client grep -i -C 2 timeout app.log
+func runClient(args []string) error {
+	if len(args) == 0 {
+		return errors.New("usage: cat-server client ls|cat|grep [flags] [args]")
+	}
+	command, ok := clientCommands[args[0]]
+	if !ok {
+		return fmt.Errorf("unknown command %q, expected ls, cat or grep", args[0])
+	}
+
+	fs := flag.NewFlagSet("client "+args[0], flag.ContinueOnError)
+	return command(newAPIClient(fs), fs, args[1:])
+}
+
+// clientLs lists directories like ls, one name per line or, with -l, with
+// permissions, size and modification time
+func clientLs(c *apiClient, fs *flag.FlagSet, args []string) error {
+	long := fs.Bool("l", false, "Use a long listing format")
+	paths, err := parseInterspersed(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(paths) == 0 {
+		paths = []string{"."}
+	}
+
+	for i, path := range paths {
+		var listing services.ListDirectoryResponse
+		if err := c.getJSON(c.url(nil, "ls", path), &listing); err != nil {
+			return err
+		}
+		if len(paths) > 1 {
+			if i > 0 {
+				fmt.Println()
+			}
+			fmt.Printf("%s:\n", path)
+		}
+		for _, entry := range listing.Files {
+			name := entry.Name
+			if entry.IsDir {
+				name += "/"
+			}
+			if *long {
+				fmt.Printf("%s %10d %s %s\n", entry.Permissions, entry.Size, entry.ModTime.Local().Format("Jan _2 15:04"), name)
+			} else {
+				fmt.Println(name)
+			}
+		}
+	}
+	return nil
+}
+
+// clientCat writes the content of each file to stdout
+func clientCat(c *apiClient, fs *flag.FlagSet, args []string) error {
+	files, err := parseInterspersed(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(files) == 0 {
+		return errors.New("usage: cat-server client cat FILE...")
+	}
+
+	for _, file := range files {
+		var content services.ReadFileResponse
+		if err := c.getJSON(c.url(nil, "cat", file), &content); err != nil {
+			return err
+		}
+		if _, err := io.WriteString(os.Stdout, content.Content); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// clientGrep prints the matching lines of each file with their line
+// numbers, like grep -n. Context lines are marked with "-" instead of ":"
+// and non-adjacent groups are separated by "--".
+func clientGrep(c *apiClient, fs *flag.FlagSet, args []string) error {
+	ignoreCase := fs.Bool("i", false, "Ignore case")
+	contextLines := fs.Int("C", 0, "Print this many lines of context")
+	maxMatches := fs.Int("m", 0, "Stop after this many matches per file")
+	operands, err := parseInterspersed(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(operands) < 2 {
+		return errors.New("usage: cat-server client grep [-i] [-C n] [-m n] PATTERN FILE...")
+	}
+	pattern, files := operands[0], operands[1:]
+
+	query := url.Values{"pattern": {pattern}}
+	if *ignoreCase {
+		query.Set("ignore_case", "true")
+	}
+	if *contextLines > 0 {
+		query.Set("context", strconv.Itoa(*contextLines))
+	}
+	if *maxMatches > 0 {
+		query.Set("max_matches", strconv.Itoa(*maxMatches))
+	}
+
+	matched := false
+	for _, file := range files {
+		var result services.GrepResponse
+		if err := c.getJSON(c.url(query, "grep", file), &result); err != nil {
+			return err
+		}
+
+		prefix := ""
+		if len(files) > 1 {
+			prefix = file + ":"
+		}
+		last := 0
+		for _, match := range result.Matches {
+			matched = true
+			if *contextLines > 0 && last > 0 && firstLine(match) > last+1 {
+				fmt.Println("--")
+			}
+			for _, line := range match.Before {
+				fmt.Printf("%s%d-%s\n", prefix, line.LineNumber, line.Line)
+			}
+			fmt.Printf("%s%d:%s\n", prefix, match.LineNumber, match.Line)
+			for _, line := range match.After {
+				fmt.Printf("%s%d-%s\n", prefix, line.LineNumber, line.Line)
+			}
+			last = lastLine(match)
+		}
+		if result.Truncated {
+			fmt.Fprintf(os.Stderr, "%s: stopped after %d matches\n", file, result.MatchCount)
+		}
+	}
+	if !matched {
+		return errors.New("no matches")
+	}
+	return nil
+}
+
+// firstLine returns the first line number printed for match
+func firstLine(match services.GrepMatchDTO) int {
+	if len(match.Before) > 0 {
+		return match.Before[0].LineNumber
+	}
+	return match.LineNumber
+}
+
+// lastLine returns the last line number printed for match
+func lastLine(match services.GrepMatchDTO) int {
+	if len(match.After) > 0 {
+		return match.After[len(match.After)-1].LineNumber
+	}
+	return match.LineNumber
+}
+
+// apiClient calls the cat-server API
+type apiClient struct {
+	http   *http.Client
+	server string
+	token  string
+	user   string // user:password for basic auth
+}
+
+// newAPIClient returns a client configured by the --server, --token and
+// --user flags it defines on fs
+func newAPIClient(fs *flag.FlagSet) *apiClient {
+	c := &apiClient{http: &http.Client{Timeout: time.Minute}}
+	fs.StringVar(&c.server, "server", envOr("CAT_SERVER_URL", defaultServerURL), "Server URL (env: CAT_SERVER_URL)")
+	fs.StringVar(&c.token, "token", os.Getenv("CAT_SERVER_TOKEN"), "Bearer token (env: CAT_SERVER_TOKEN)")
+	fs.StringVar(&c.user, "user", "", "Basic auth credentials as user:password")
+	return c
+}
+
+// url returns the URL of an endpoint for path with query
+func (c *apiClient) url(query url.Values, endpoint, path string) string {
+	u, err := url.Parse(c.server)
+	if err != nil || u.Host == "" {
+		// Let the request report the malformed server URL
+		return c.server
+	}
+	u = u.JoinPath(endpoint, path)
+	u.RawQuery = query.Encode()
+	return u.String()
+}
+
+// get sends an authenticated GET request for rawURL
+func (c *apiClient) get(rawURL string) (*http.Response, error) {
+	req, err := http.NewRequest(http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	} else if user, password, ok := strings.Cut(c.user, ":"); ok {
+		req.SetBasicAuth(user, password)
+	}
+	return c.http.Do(req)
+}
+
+// getJSON fetches rawURL and decodes the JSON response into v. Responses
+// other than 200 OK are returned as errors carrying the server's message.
+func (c *apiClient) getJSON(rawURL string, v any) error {
+	resp, err := c.get(rawURL)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	path := resp.Request.URL.Path
+	if resp.StatusCode != http.StatusOK {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("%s: %s: %s", path, resp.Status, strings.TrimSpace(string(message)))
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("%s: invalid response: %w", path, err)
+	}
+	return nil
+}
+
+// parseInterspersed parses flags that may follow the positional arguments,
+// as in `ls logs -l`, and returns the positional arguments
+func parseInterspersed(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		rest := fs.Args()
+		if parsed := len(args) - len(rest); parsed > 0 && args[parsed-1] == "--" {
+			return append(positional, rest...), nil
+		}
+		args = rest
+		if len(args) == 0 {
+			return positional, nil
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
+// envOr returns the environment variable key, or fallback when it is unset
+func envOr(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
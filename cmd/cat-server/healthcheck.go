@@ -0,0 +1,69 @@
+package main
+
+import (
+	"crypto/tls"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/sh05/cat-server/internal/config"
+)
+
+// runHealthcheck implements the healthcheck subcommand for container
+// health probes. It requests /health and fails unless the server answers
+// 200 OK. Without --url, the address comes from the same configuration the
+// server loads, so PORT and CAT_SERVER_* variables apply to both.
+func runHealthcheck(args []string) error {
+	fs := flag.NewFlagSet("healthcheck", flag.ContinueOnError)
+	rawURL := fs.String("url", "", "Health endpoint to probe (default: derived from the server configuration)")
+	timeout := fs.Duration("timeout", 3*time.Second, "Time allowed for the probe")
+	cfg, err := config.ParseFlagSet(fs, args)
+	if err != nil {
+		return err
+	}
+
+	client := &http.Client{Timeout: *timeout}
+	url := *rawURL
+	if url == "" {
+		url = healthURL(cfg)
+		if cfg.Server.TLS.Enabled() && cfg.Server.AdminAddr == "" {
+			// The certificate names the public host, not localhost
+			client.Transport = &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}}
+		}
+	}
+
+	resp, err := client.Get(url)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s: %s", url, resp.Status)
+	}
+	return nil
+}
+
+// healthURL returns the /health URL of the server configured by cfg: the
+// admin listener when there is one, otherwise the first public address
+func healthURL(cfg *config.Config) string {
+	scheme, addr := "http", cfg.Server.AdminAddr
+	if addr == "" {
+		addr = cfg.GetServerAddrs()[0]
+		if cfg.Server.TLS.Enabled() {
+			scheme = "https"
+		}
+	}
+
+	// Probe wildcard addresses over loopback
+	if host, port, err := net.SplitHostPort(addr); err == nil {
+		if ip := net.ParseIP(host); host == "" || ip != nil && ip.IsUnspecified() {
+			addr = net.JoinHostPort("localhost", port)
+		}
+	}
+	return scheme + "://" + addr + "/health"
+}
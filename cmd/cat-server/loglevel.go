@@ -0,0 +1,28 @@
+//go:build !windows && !plan9
+
+package main
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/sh05/cat-server/pkg/infrastructure/logging"
+)
+
+// toggleDebugOnSIGUSR1 switches the log level between info and debug
+// whenever the process receives SIGUSR1
+func toggleDebugOnSIGUSR1(logger *logging.Logger) {
+	usr1 := make(chan os.Signal, 1)
+	signal.Notify(usr1, syscall.SIGUSR1)
+	go func() {
+		for range usr1 {
+			level, name := logging.LevelDebug, "debug"
+			if logger.IsDebugEnabled() {
+				level, name = logging.LevelInfo, "info"
+			}
+			logger.SetLevel(level)
+			logger.Info("log level changed", "level", name, "signal", "SIGUSR1")
+		}
+	}()
+}
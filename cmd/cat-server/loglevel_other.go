@@ -0,0 +1,8 @@
+//go:build windows || plan9
+
+package main
+
+import "github.com/sh05/cat-server/pkg/infrastructure/logging"
+
+// toggleDebugOnSIGUSR1 is only implemented on Unix
+func toggleDebugOnSIGUSR1(logger *logging.Logger) {}
@@ -2,234 +2,288 @@ package main
 
 import (
 	"context"
-	"encoding/json"
+	"crypto/tls"
+	"flag"
 	"fmt"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
+	"syscall"
 	"time"
 
+	"github.com/sh05/cat-server/internal/buildinfo"
 	"github.com/sh05/cat-server/internal/config"
-	"github.com/sh05/cat-server/pkg/application/services"
-	"github.com/sh05/cat-server/pkg/infrastructure/filesystem"
+	"github.com/sh05/cat-server/pkg/cat"
+	cathttp "github.com/sh05/cat-server/pkg/infrastructure/http"
 	"github.com/sh05/cat-server/pkg/infrastructure/logging"
+	"golang.org/x/crypto/acme"
 )
 
+// subcommands run instead of the server when named by the first argument
+var subcommands = map[string]func(args []string) error{
+	"bench":          runBench,
+	"client":         runClient,
+	"healthcheck":    runHealthcheck,
+	"sign":           runSign,
+	"support-bundle": runSupportBundle,
+	"validate":       runValidate,
+}
+
 func main() {
-	// Load configuration
+	os.Exit(run())
+}
+
+// run starts the server, or the subcommand named by the first argument,
+// and returns the exit code. Returning instead of exiting lets the deferred
+// cleanups run.
+func run() int {
+	if len(os.Args) > 1 {
+		if runSubcommand, ok := subcommands[os.Args[1]]; ok {
+			if err := runSubcommand(os.Args[2:]); err != nil {
+				fmt.Fprintf(os.Stderr, "%s: %v\n", os.Args[1], err)
+				return 1
+			}
+			return 0
+		}
+	}
+
+	// Load configuration. The version is printed even when the configuration
+	// fails to load, as the flags are parsed before it is read.
+	version := flag.Bool("version", false, "Print version information and exit")
 	cfg, err := config.LoadFromFlags()
+	if *version {
+		fmt.Println(buildinfo.Get())
+		return 0
+	}
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Failed to load configuration: %v\n", err)
-		os.Exit(1)
+		return 1
 	}
 
-	// Initialize logger
-	var logLevel logging.LogLevel
-	switch cfg.Logging.Level {
-	case "debug":
-		logLevel = logging.LevelDebug
-	case "warn":
-		logLevel = logging.LevelWarn
-	case "error":
-		logLevel = logging.LevelError
-	default:
-		logLevel = logging.LevelInfo
+	// Initialize logger, retaining recent lines for support bundles
+	recentLogs := logging.NewRecentLogBuffer(recentLogLines)
+	logger, logOutputs, err := cat.OpenLogger(cfg, logging.WriterSink(recentLogs))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to open log outputs: %v\n", err)
+		return 1
 	}
-
-	logger := logging.NewLogger(logLevel, cfg.Logging.Format)
+	defer logOutputs.Close()
 	logger.SetAsDefault()
 
-	// Log startup
-	logger.LogStartup("cat-server", "1.0.0", cfg.Server.Port, "production")
-
-	// Initialize filesystem repository
-	fsRepo := filesystem.NewFileSystemRepository(cfg.FileSystem.BaseDirectory, cfg.FileSystem.MaxFileSize)
-
-	// Initialize services
-	healthService := services.NewHealthService(fsRepo, logger, "1.0.0")
-	directoryService := services.NewDirectoryService(fsRepo, logger)
-	fileService := services.NewFileService(fsRepo, logger)
-
-	// Create HTTP server
-	mux := http.NewServeMux()
-
-	// Register handlers
-	registerHealthHandler(mux, healthService, logger)
-	registerListHandler(mux, directoryService, logger)
-	registerCatHandler(mux, fileService, logger)
-
-	// Apply middleware
-	handler := addMiddleware(mux, logger)
+	// Record security events in a separate audit log when configured
+	audit, err := openAuditLog(cfg)
+	if err != nil {
+		logger.LogError(err, "failed to open audit log")
+		return 1
+	}
+	if audit != nil {
+		defer audit.Close()
+		logger.SetAuditLog(audit)
+		logger.LogAuditEvent("audit_log_opened", "pid", os.Getpid())
+	}
 
-	server := &http.Server{
-		Addr:         cfg.GetServerAddr(),
-		Handler:      handler,
-		ReadTimeout:  cfg.Server.ReadTimeout,
-		WriteTimeout: cfg.Server.WriteTimeout,
-		IdleTimeout:  cfg.Server.IdleTimeout,
+	// Log startup
+	build := buildinfo.Get()
+	logger.LogStartup("cat-server", build.Version, cfg.Server.Port, "production")
+	logger.Info("build info", "commit", build.Commit, "date", build.Date, "modified", build.Modified, "go_version", build.GoVersion)
+
+	// Wire services, routes, authentication and middleware
+	srv, err := cat.New(cfg, cat.Options{
+		Logger:     logger,
+		RecentLogs: recentLogs,
+		LoadConfig: reloadConfig,
+	})
+	if err != nil {
+		logger.LogError(err, "failed to create server")
+		return 1
 	}
+	defer srv.Close()
 
-	// Setup graceful shutdown
-	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
-	defer stop()
+	// Report unready until the listeners are serving
+	srv.SetReady(false)
 
-	// Start server in goroutine
-	go func() {
-		logger.Info("server started successfully", "addr", cfg.GetServerAddr())
-		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			logger.LogError(err, "server failed to start", "addr", cfg.GetServerAddr())
-			os.Exit(1)
-		}
-	}()
+	// Reload settings on SIGHUP
+	reloadOnSIGHUP(srv, logger)
 
-	// Wait for interrupt signal
-	<-ctx.Done()
+	// Toggle debug logging on SIGUSR1
+	toggleDebugOnSIGUSR1(logger)
 
-	// Shutdown server with timeout
-	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
+	// Track in-flight requests so shutdown can drain them
+	drain := cathttp.NewDrain()
 
-	logger.Info("shutting down server")
-	if err := server.Shutdown(shutdownCtx); err != nil {
-		logger.LogError(err, "server shutdown failed")
-		os.Exit(1)
+	// One server serves every configured address
+	httpServer := &http.Server{
+		Handler:           drain.Middleware(srv),
+		ReadTimeout:       cfg.Server.ReadTimeout,
+		WriteTimeout:      cfg.Server.WriteTimeout,
+		IdleTimeout:       cfg.Server.IdleTimeout,
+		MaxHeaderBytes:    cfg.Server.MaxHeaderBytes,
+		ReadHeaderTimeout: cfg.Server.ReadHeaderTimeout,
 	}
 
-	logger.LogShutdown("cat-server", healthService.GetUptime())
-}
-
-// registerHealthHandler registers the health check handler
-func registerHealthHandler(mux *http.ServeMux, healthService *services.HealthService, logger *logging.Logger) {
-	mux.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
-		if r.Method != http.MethodGet {
-			http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
-			return
-		}
-
-		health, err := healthService.GetSystemHealth()
-		if err != nil {
-			logger.LogError(err, "health check failed")
-			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
-			return
-		}
+	// Let clients that know the server speaks HTTP/2 multiplex requests
+	// without TLS
+	if cfg.Server.EnableH2C {
+		httpServer.Protocols = new(http.Protocols)
+		httpServer.Protocols.SetHTTP1(true)
+		httpServer.Protocols.SetUnencryptedHTTP2(true)
+	}
 
-		// Set content type based on Accept header
-		acceptHeader := r.Header.Get("Accept")
-		if acceptHeader == "text/html" {
-			w.Header().Set("Content-Type", "text/html")
-			fmt.Fprintf(w, "<html><body><h1>Health Status: %s</h1><p>Uptime: %s</p><p>Version: %s</p></body></html>",
-				health.Status, health.Uptime, health.Version)
-			return
-		} else if acceptHeader == "text/plain" {
-			w.Header().Set("Content-Type", "text/plain")
-			fmt.Fprintf(w, "Status: %s\nUptime: %s\nVersion: %s\n",
-				health.Status, health.Uptime, health.Version)
-			return
+	// Terminate TLS when a certificate or ACME domain is configured,
+	// optionally redirecting plain HTTP clients to HTTPS
+	var redirectServer *http.Server
+	if cfg.Server.TLS.Enabled() {
+		httpServer.TLSConfig = newTLSConfig()
+		redirect := httpsRedirectHandler(cfg.Server.Port)
+
+		if cfg.Server.TLS.CertFile != "" {
+			cert, err := tls.LoadX509KeyPair(cfg.Server.TLS.CertFile, cfg.Server.TLS.KeyFile)
+			if err != nil {
+				logger.LogError(err, "failed to load TLS certificate")
+				return 1
+			}
+			httpServer.TLSConfig.Certificates = []tls.Certificate{cert}
 		}
 
-		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(health)
-	})
-}
-
-// registerListHandler registers the file list handler
-func registerListHandler(mux *http.ServeMux, directoryService *services.DirectoryService, logger *logging.Logger) {
-	mux.HandleFunc("/ls", func(w http.ResponseWriter, r *http.Request) {
-		if r.Method != http.MethodGet {
-			http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
-			return
+		if cfg.Server.TLS.ACMEEnabled() {
+			manager := newACMEManager(cfg)
+			httpServer.TLSConfig.GetCertificate = manager.GetCertificate
+			httpServer.TLSConfig.NextProtos = []string{"h2", "http/1.1", acme.ALPNProto}
+			redirect = manager.HTTPHandler(redirect)
 		}
-
-		request := &services.ListDirectoryRequest{
-			Path:          ".",
-			IncludeHidden: false,
-			SortBy:        "name",
-			SortOrder:     "asc",
-			FilterType:    "all",
+		if cfg.Server.TLS.RedirectAddr != "" {
+			redirectServer = newRedirectServer(cfg, redirect)
 		}
+	}
 
-		listing, err := directoryService.ListDirectory(request)
+	// Bind listeners before sandboxing so startup needs nothing the
+	// sandbox forbids. After a restart they are inherited from the previous
+	// process instead.
+	inherited, err := inheritedListeners()
+	if err != nil {
+		logger.LogError(err, "failed to inherit listeners")
+		return 1
+	}
+	servers := cathttp.NewServer()
+	addListener := func(name, addr string, server *http.Server, useTLS bool, opts cathttp.ListenerOptions) bool {
+		listener, err := listen(inherited, name, addr)
 		if err != nil {
-			logger.LogError(err, "failed to list directory")
-			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
-			return
+			logger.LogError(err, "listener failed to start", "listener", name, "addr", addr)
+			return false
 		}
-
-		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(listing)
-	})
-}
-
-// registerCatHandler registers the file content handler
-func registerCatHandler(mux *http.ServeMux, fileService *services.FileService, logger *logging.Logger) {
-	mux.HandleFunc("/cat/", func(w http.ResponseWriter, r *http.Request) {
-		if r.Method != http.MethodGet {
-			http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
-			return
+		logger.Info("listening", "listener", name, "addr", listener.Addr().String())
+		servers.Add(name, server, cathttp.TuneListener(listener, opts), useTLS)
+		return true
+	}
+	for _, addr := range cfg.GetServerAddrs() {
+		if !addListener("http/"+addr, addr, httpServer, cfg.Server.TLS.Enabled(), cathttp.ListenerOptions{
+			MaxConnsPerIP: cfg.Server.MaxConnsPerIP,
+			KeepAlive:     cfg.Server.KeepAlive,
+		}) {
+			return 1
 		}
+	}
+	if redirectServer != nil && !addListener("redirect", redirectServer.Addr, redirectServer, false, cathttp.ListenerOptions{}) {
+		return 1
+	}
+	if admin := srv.AdminHandler(); admin != nil && !addListener("admin", cfg.Server.AdminAddr, newAdminServer(cfg, admin), false, cathttp.ListenerOptions{}) {
+		return 1
+	}
 
-		// Extract filename from path
-		filename := r.URL.Path[5:] // Remove "/cat/" prefix
-		if filename == "" {
-			http.Error(w, "Filename required", http.StatusBadRequest)
-			return
+	if cfg.Security.Sandbox {
+		if err := applySandbox(cfg, logger); err != nil {
+			logger.LogError(err, "failed to apply sandbox")
+			return 1
 		}
+	}
 
-		request := &services.ReadFileRequest{
-			Filename:    filename,
-			MaxSize:     10 * 1024 * 1024, // 10MB limit
-			PreviewOnly: false,
-		}
+	// Setup graceful shutdown on Ctrl-C and on SIGTERM from orchestrators
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
-		fileContent, err := fileService.ReadFile(request)
-		if err != nil {
-			logger.LogError(err, "failed to read file", "filename", filename)
-			if err.Error() == "file not found: "+filename {
-				http.Error(w, "File not found", http.StatusNotFound)
-			} else {
-				http.Error(w, "Internal Server Error", http.StatusInternalServerError)
-			}
-			return
+	serveErrs := servers.Serve()
+	srv.SetReady(true)
+	logger.Info("server started successfully", "addr", cfg.GetServerAddr(), "tls", cfg.Server.TLS.Enabled(),
+		"redirect_addr", cfg.Server.TLS.RedirectAddr, "admin_addr", cfg.Server.AdminAddr)
+
+	// Hand the listeners to a new process on SIGUSR2, and tell the previous
+	// process, if any, that this one is serving
+	handedOver := restartOnSIGUSR2(servers.Listeners(), cfg.Security.Sandbox, logger)
+	notifyReady()
+
+	// Wait for interrupt signal or a completed restart
+	select {
+	case <-ctx.Done():
+		// Fail /readyz but keep serving for the shutdown delay, so load
+		// balancers stop routing new requests before connections close. A
+		// second signal exits immediately.
+		stop()
+		srv.SetReady(false)
+		if delay := cfg.Server.ShutdownDelay; delay > 0 {
+			logger.Info("waiting before shutdown", "delay", delay)
+			time.Sleep(delay)
 		}
+	case <-handedOver:
+	case err := <-serveErrs:
+		logger.LogError(err, "server failed")
+		return 1
+	}
 
-		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(fileContent)
-	})
-}
-
-// addMiddleware adds common middleware to the handler
-func addMiddleware(handler http.Handler, logger *logging.Logger) http.Handler {
-	// Add security headers
-	securityHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("X-Content-Type-Options", "nosniff")
-		w.Header().Set("X-Frame-Options", "DENY")
-		w.Header().Set("X-XSS-Protection", "1; mode=block")
-		handler.ServeHTTP(w, r)
-	})
-
-	// Add logging middleware
-	loggingHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		start := time.Now()
-		logger.LogHTTPRequest(r.Method, r.URL.Path, r.UserAgent(), r.RemoteAddr)
+	// Stop accepting and give in-flight requests the drain time to finish;
+	// requests still running afterwards are aborted
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
+	defer cancel()
 
-		// Wrap response writer to capture status code
-		wrapper := &responseWriter{ResponseWriter: w, statusCode: http.StatusOK}
-		securityHandler.ServeHTTP(wrapper, r)
+	logger.Info("shutting down server", "in_flight", drain.Start(), "timeout", cfg.Server.ShutdownTimeout)
+	shutdownErr := servers.Shutdown(shutdownCtx)
+	completed, aborted := drain.Completed(), drain.InFlight()
+	if shutdownErr != nil {
+		servers.Close()
+	}
+	logger.Info("drained requests", "completed", completed, "aborted", aborted)
+	if shutdownErr != nil {
+		logger.LogError(shutdownErr, "server shutdown failed")
+		return 1
+	}
 
-		duration := time.Since(start)
-		logger.LogHTTPResponse(r.Method, r.URL.Path, wrapper.statusCode, duration, 0)
-	})
+	logger.LogShutdown("cat-server", srv.Uptime())
+	return 0
+}
 
-	return loggingHandler
+// listen returns the inherited listener named name, or a new one bound to
+// addr when none was inherited
+func listen(inherited map[string]net.Listener, name, addr string) (net.Listener, error) {
+	if listener, ok := inherited[name]; ok {
+		return listener, nil
+	}
+	return net.Listen("tcp", addr)
 }
 
-// responseWriter wraps http.ResponseWriter to capture status code
-type responseWriter struct {
-	http.ResponseWriter
-	statusCode int
+// newAdminServer creates the listener for health, version, metrics, profiling and
+// admin endpoints. It has no write timeout so CPU profiles and traces can
+// run for longer than file requests may.
+func newAdminServer(cfg *config.Config, handler http.Handler) *http.Server {
+	return &http.Server{
+		Addr:           cfg.Server.AdminAddr,
+		Handler:        handler,
+		ReadTimeout:    cfg.Server.ReadTimeout,
+		IdleTimeout:    cfg.Server.IdleTimeout,
+		MaxHeaderBytes: cfg.Server.MaxHeaderBytes,
+	}
 }
 
-func (rw *responseWriter) WriteHeader(code int) {
-	rw.statusCode = code
-	rw.ResponseWriter.WriteHeader(code)
+// recentLogLines is the number of log lines retained for support bundles
+const recentLogLines = 1000
+
+// openAuditLog opens the configured audit log sink, or returns nil when
+// neither a file nor a syslog facility is set
+func openAuditLog(cfg *config.Config) (*logging.AuditLog, error) {
+	switch {
+	case cfg.Logging.AuditFile != "":
+		return logging.OpenAuditFile(cfg.Logging.AuditFile)
+	case cfg.Logging.AuditSyslog != "":
+		return logging.OpenAuditSyslog(cfg.Logging.AuditSyslog, "cat-server-audit")
+	}
+	return nil, nil
 }
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"flag"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/sh05/cat-server/internal/config"
+	"github.com/sh05/cat-server/pkg/cat"
+	"github.com/sh05/cat-server/pkg/infrastructure/logging"
+)
+
+// reloadConfig re-reads the configuration from the command line,
+// environment and config file the way main does
+func reloadConfig() (*config.Config, error) {
+	fs := flag.NewFlagSet(os.Args[0], flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	return config.LoadFromFlagSet(fs, os.Args[1:])
+}
+
+// reloadOnSIGHUP reloads the configuration whenever the process receives
+// SIGHUP
+func reloadOnSIGHUP(srv *cat.Server, logger *logging.Logger) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	go func() {
+		for range hup {
+			if err := srv.Reload(); err != nil {
+				logger.LogError(err, "configuration reload failed")
+			}
+		}
+	}()
+}
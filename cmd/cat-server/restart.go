@@ -0,0 +1,164 @@
+//go:build !windows && !plan9
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"net"
+	"os"
+	"os/exec"
+	"os/signal"
+	"slices"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/sh05/cat-server/pkg/infrastructure/logging"
+)
+
+// Environment variables describing the file descriptors a restarted process
+// inherits from its parent: listenFDsEnv names the listeners starting at
+// fd 3, followed by the pipe written to once the new process serves requests
+const (
+	listenFDsEnv = "CAT_SERVER_LISTEN_FDS"
+	readyFDEnv   = "CAT_SERVER_READY_FD"
+)
+
+// restartReadyTimeout bounds how long the new process may take to start
+// serving before the restart is abandoned
+const restartReadyTimeout = 30 * time.Second
+
+// inheritedListeners returns the listeners by name handed over by the
+// process that started this one on SIGUSR2, or nil on a normal start
+func inheritedListeners() (map[string]net.Listener, error) {
+	value := os.Getenv(listenFDsEnv)
+	if value == "" {
+		return nil, nil
+	}
+	os.Unsetenv(listenFDsEnv)
+
+	listeners := make(map[string]net.Listener)
+	for i, name := range strings.Split(value, ",") {
+		file := os.NewFile(uintptr(3+i), name)
+		listener, err := net.FileListener(file)
+		file.Close()
+		if err != nil {
+			return nil, fmt.Errorf("inherited %s listener: %w", name, err)
+		}
+		listeners[name] = listener
+	}
+	return listeners, nil
+}
+
+// notifyReady tells the parent of a restarted process that it is serving
+// requests, so the parent can start draining
+func notifyReady() {
+	value := os.Getenv(readyFDEnv)
+	if value == "" {
+		return
+	}
+	os.Unsetenv(readyFDEnv)
+
+	if fd, err := strconv.Atoi(value); err == nil {
+		ready := os.NewFile(uintptr(fd), "ready")
+		ready.Write([]byte{1})
+		ready.Close()
+	}
+}
+
+// restartOnSIGUSR2 starts a new copy of the binary on SIGUSR2, handing it
+// the listeners so no connection is refused while it starts. The returned
+// channel is closed once the new process serves requests; this process
+// should then stop accepting and drain. If the new process fails to start,
+// this one keeps serving.
+func restartOnSIGUSR2(listeners map[string]net.Listener, sandboxed bool, logger *logging.Logger) <-chan struct{} {
+	handedOver := make(chan struct{})
+	usr2 := make(chan os.Signal, 1)
+	signal.Notify(usr2, syscall.SIGUSR2)
+	go func() {
+		for range usr2 {
+			// The sandbox denies execve
+			if sandboxed {
+				logger.Warn("restart is unavailable in sandbox mode")
+				continue
+			}
+			pid, err := startChild(listeners)
+			if err != nil {
+				logger.LogError(err, "restart failed")
+				continue
+			}
+			logger.Info("handed listeners over to new process", "pid", pid)
+			signal.Stop(usr2)
+			close(handedOver)
+			return
+		}
+	}()
+	return handedOver
+}
+
+// startChild runs the current binary with the same arguments and the
+// listeners as inherited files, and waits until it is ready
+func startChild(listeners map[string]net.Listener) (int, error) {
+	executable, err := os.Executable()
+	if err != nil {
+		return 0, err
+	}
+
+	files := make([]*os.File, 0, len(listeners)+1)
+	defer func() {
+		for _, file := range files {
+			file.Close()
+		}
+	}()
+	names := slices.Sorted(maps.Keys(listeners))
+	for _, name := range names {
+		filer, ok := listeners[name].(interface{ File() (*os.File, error) })
+		if !ok {
+			return 0, fmt.Errorf("cannot hand over %s listener of type %T", name, listeners[name])
+		}
+		file, err := filer.File()
+		if err != nil {
+			return 0, err
+		}
+		files = append(files, file)
+	}
+
+	ready, readyWriter, err := os.Pipe()
+	if err != nil {
+		return 0, err
+	}
+	defer ready.Close()
+	files = append(files, readyWriter)
+
+	cmd := exec.Command(executable, os.Args[1:]...)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	cmd.ExtraFiles = files
+	cmd.Env = append(os.Environ(),
+		listenFDsEnv+"="+strings.Join(names, ","),
+		fmt.Sprintf("%s=%d", readyFDEnv, 3+len(listeners)),
+	)
+	if err := cmd.Start(); err != nil {
+		return 0, err
+	}
+	// Only the child may hold the write end, so a child exiting before it
+	// is ready ends the read below with EOF
+	readyWriter.Close()
+	files = files[:len(files)-1]
+	go cmd.Wait()
+
+	ready.SetReadDeadline(time.Now().Add(restartReadyTimeout))
+	n, err := ready.Read(make([]byte, 1))
+	switch {
+	case n == 1:
+		return cmd.Process.Pid, nil
+	case errors.Is(err, os.ErrDeadlineExceeded):
+		cmd.Process.Kill()
+		return 0, errors.New("new process did not become ready in time")
+	default:
+		return 0, errors.New("new process exited during startup")
+	}
+}
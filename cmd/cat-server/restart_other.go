@@ -0,0 +1,23 @@
+//go:build windows || plan9
+
+package main
+
+import (
+	"net"
+
+	"github.com/sh05/cat-server/pkg/infrastructure/logging"
+)
+
+// inheritedListeners is only implemented on Unix
+func inheritedListeners() (map[string]net.Listener, error) {
+	return nil, nil
+}
+
+// notifyReady is only implemented on Unix
+func notifyReady() {}
+
+// restartOnSIGUSR2 is only implemented on Unix; the returned channel is
+// never closed
+func restartOnSIGUSR2(listeners map[string]net.Listener, sandboxed bool, logger *logging.Logger) <-chan struct{} {
+	return make(chan struct{})
+}
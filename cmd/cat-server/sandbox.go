@@ -0,0 +1,57 @@
+package main
+
+import (
+	"crypto/x509"
+	"os"
+
+	"github.com/sh05/cat-server/internal/config"
+	"github.com/sh05/cat-server/pkg/infrastructure/logging"
+	"github.com/sh05/cat-server/pkg/infrastructure/sandbox"
+)
+
+// resolverFiles are read by the DNS resolver for outbound requests to
+// identity providers, JWKS endpoints and the ACME CA
+var resolverFiles = []string{"/etc/resolv.conf", "/etc/hosts", "/etc/nsswitch.conf"}
+
+// applySandbox confines the process to the files the server still needs
+// once running: the served directory and mounts, the htpasswd file, which is reloaded
+// when it changes, and the ACME cache
+func applySandbox(cfg *config.Config, logger *logging.Logger) error {
+	policy := sandbox.Policy{
+		ReadOnly: []string{cfg.FileSystem.BaseDirectory},
+	}
+	for _, mount := range cfg.FileSystem.Mounts {
+		policy.ReadOnly = append(policy.ReadOnly, mount.Path)
+	}
+	if cfg.Security.AuthFile != "" {
+		policy.ReadOnly = append(policy.ReadOnly, cfg.Security.AuthFile)
+	}
+	for _, path := range resolverFiles {
+		if _, err := os.Stat(path); err == nil {
+			policy.ReadOnly = append(policy.ReadOnly, path)
+		}
+	}
+	if cfg.Server.TLS.ACMEEnabled() {
+		if err := os.MkdirAll(cfg.Server.TLS.ACMECacheDir, 0o700); err != nil {
+			return err
+		}
+		policy.ReadWrite = append(policy.ReadWrite, cfg.Server.TLS.ACMECacheDir)
+	}
+
+	// Load the system roots now; they are cached for later TLS clients
+	if _, err := x509.SystemCertPool(); err != nil {
+		logger.Warn("failed to load system certificate roots", "error", err)
+	}
+
+	status, err := sandbox.Apply(policy)
+	if err != nil {
+		return err
+	}
+	logger.Info("sandbox applied",
+		"landlock_abi", status.LandlockABI,
+		"denied_syscalls", status.DeniedSyscalls,
+		"read_only", policy.ReadOnly,
+		"read_write", policy.ReadWrite,
+	)
+	return nil
+}
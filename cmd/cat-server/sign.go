@@ -0,0 +1,51 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/sh05/cat-server/internal/config"
+	"github.com/sh05/cat-server/pkg/cat"
+)
+
+// runSign implements the sign subcommand. It prints a signed URL that grants
+// read access to the given path and query until the TTL has elapsed, using
+// the signing keys of the configuration.
+func runSign(args []string) error {
+	fs := flag.NewFlagSet("sign", flag.ContinueOnError)
+	ttl := fs.Duration("ttl", time.Hour, "How long the signed URL stays valid")
+	path := fs.String("path", "", "URL path to sign with its query, e.g. /cat/report.txt or /search/logs?q=error")
+
+	cfg, err := config.LoadFromFlagSet(fs, args)
+	if err != nil {
+		return err
+	}
+	if *path == "" {
+		return errors.New("-path is required")
+	}
+	target, err := url.Parse(*path)
+	if err != nil {
+		return fmt.Errorf("invalid -path: %w", err)
+	}
+	if *ttl <= 0 {
+		return errors.New("-ttl must be positive")
+	}
+
+	signer, err := cat.NewSigner(cfg)
+	if err != nil {
+		return err
+	}
+	if signer == nil {
+		return errors.New("no signing keys configured; set -signing-keys or CAT_SERVER_SIGNING_KEYS")
+	}
+
+	query, err := signer.Sign(target.Path, target.Query(), *ttl)
+	if err != nil {
+		return err
+	}
+	fmt.Println(target.Path + "?" + query.Encode())
+	return nil
+}
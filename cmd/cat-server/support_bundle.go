@@ -0,0 +1,48 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/sh05/cat-server/internal/config"
+	"github.com/sh05/cat-server/internal/supportbundle"
+	"github.com/sh05/cat-server/pkg/cat"
+	"github.com/sh05/cat-server/pkg/infrastructure/logging"
+)
+
+// runSupportBundle implements the support-bundle subcommand. It collects
+// diagnostics for the given configuration into a tar.gz file.
+func runSupportBundle(args []string) error {
+	fs := flag.NewFlagSet("support-bundle", flag.ContinueOnError)
+	output := fs.String("o", "", "Output file (default: cat-server-support-<timestamp>.tar.gz)")
+
+	cfg, err := config.LoadFromFlagSet(fs, args)
+	if err != nil {
+		return err
+	}
+
+	// Capture the logs produced while collecting diagnostics
+	recentLogs := logging.NewRecentLogBuffer(recentLogLines)
+	logger := cat.NewLogger(cfg, recentLogs)
+
+	path := *output
+	if path == "" {
+		path = supportbundle.Filename(time.Now())
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", path, err)
+	}
+	defer file.Close()
+
+	if err := cat.WriteSupportBundle(file, cfg, cat.Options{Logger: logger, RecentLogs: recentLogs}); err != nil {
+		os.Remove(path)
+		return err
+	}
+
+	fmt.Println(path)
+	return nil
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"crypto/tls"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/sh05/cat-server/internal/config"
+	"golang.org/x/crypto/acme"
+	"golang.org/x/crypto/acme/autocert"
+)
+
+// newTLSConfig returns the TLS settings for the HTTPS listener: TLS 1.2 or
+// later with forward-secret AEAD cipher suites only. TLS 1.3 suites are not
+// configurable and are all acceptable.
+func newTLSConfig() *tls.Config {
+	return &tls.Config{
+		MinVersion:       tls.VersionTLS12,
+		CurvePreferences: []tls.CurveID{tls.X25519, tls.CurveP256},
+		CipherSuites: []uint16{
+			tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256,
+			tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,
+			tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384,
+			tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384,
+			tls.TLS_ECDHE_ECDSA_WITH_CHACHA20_POLY1305_SHA256,
+			tls.TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305_SHA256,
+		},
+	}
+}
+
+// newACMEManager creates the certificate manager for --acme-domain. It
+// obtains certificates for the configured domains only, on their first TLS
+// handshake, keeps them in the cache directory and renews them ahead of
+// expiry.
+func newACMEManager(cfg *config.Config) *autocert.Manager {
+	return &autocert.Manager{
+		Prompt:     autocert.AcceptTOS,
+		Cache:      autocert.DirCache(cfg.Server.TLS.ACMECacheDir),
+		HostPolicy: autocert.HostWhitelist(cfg.Server.TLS.ACMEDomains...),
+		Email:      cfg.Server.TLS.ACMEEmail,
+		Client:     &acme.Client{DirectoryURL: cfg.Server.TLS.ACMEDirectoryURL},
+	}
+}
+
+// newRedirectServer creates the plain HTTP listener serving handler,
+// normally the redirect to HTTPS
+func newRedirectServer(cfg *config.Config, handler http.Handler) *http.Server {
+	return &http.Server{
+		Addr:              cfg.Server.TLS.RedirectAddr,
+		Handler:           handler,
+		ReadHeaderTimeout: cfg.Server.ReadTimeout,
+		IdleTimeout:       cfg.Server.IdleTimeout,
+		MaxHeaderBytes:    cfg.Server.MaxHeaderBytes,
+	}
+}
+
+// httpsRedirectHandler redirects every request to the same host and path on
+// the HTTPS port
+func httpsRedirectHandler(httpsPort string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host := r.Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		host = strings.Trim(host, "[]")
+		if httpsPort != "443" {
+			host = net.JoinHostPort(host, httpsPort)
+		} else if strings.Contains(host, ":") {
+			// IPv6 literals keep their brackets without a port
+			host = "[" + host + "]"
+		}
+
+		http.Redirect(w, r, "https://"+host+r.URL.RequestURI(), http.StatusMovedPermanently)
+	})
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/sh05/cat-server/internal/config"
+	"github.com/sh05/cat-server/pkg/security"
+)
+
+// certExpiryWarning is how long before expiry validate warns about a
+// certificate
+const certExpiryWarning = 14 * 24 * time.Hour
+
+// preflightCheck is one check run by the validate subcommand
+type preflightCheck struct {
+	name string
+	run  func() (string, error) // returns a detail shown on success
+}
+
+// runValidate implements the validate subcommand. It loads the
+// configuration the way the server does and checks that the files it
+// needs can be used, reporting every problem before exiting non-zero.
+func runValidate(args []string) error {
+	fs := flag.NewFlagSet("validate", flag.ContinueOnError)
+	cfg, err := config.LoadFromFlagSet(fs, args)
+	if err != nil {
+		return err
+	}
+	fmt.Println("ok    configuration")
+
+	failed := 0
+	for _, check := range preflightChecks(cfg) {
+		detail, err := check.run()
+		if err != nil {
+			failed++
+			fmt.Printf("FAIL  %s: %v\n", check.name, err)
+			continue
+		}
+		if detail != "" {
+			fmt.Printf("ok    %s (%s)\n", check.name, detail)
+		} else {
+			fmt.Printf("ok    %s\n", check.name)
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d check(s) failed", failed)
+	}
+	return nil
+}
+
+// preflightChecks returns the checks that apply to cfg
+func preflightChecks(cfg *config.Config) []preflightCheck {
+	checks := []preflightCheck{{
+		name: "base directory " + cfg.FileSystem.BaseDirectory,
+		run:  func() (string, error) { return "", readableDir(cfg.FileSystem.BaseDirectory) },
+	}}
+	for _, mount := range cfg.FileSystem.Mounts {
+		checks = append(checks, preflightCheck{
+			name: "mount " + mount.Name + " at " + mount.Path,
+			run:  func() (string, error) { return "", readableDir(mount.Path) },
+		})
+	}
+
+	tlsConfig := cfg.Server.TLS
+	if tlsConfig.CertFile != "" {
+		checks = append(checks, preflightCheck{
+			name: "tls certificate " + tlsConfig.CertFile,
+			run:  func() (string, error) { return checkCertificate(tlsConfig.CertFile, tlsConfig.KeyFile) },
+		})
+	}
+	if tlsConfig.ACMEEnabled() {
+		checks = append(checks, preflightCheck{
+			name: "acme cache directory " + tlsConfig.ACMECacheDir,
+			run:  func() (string, error) { return "", writableDir(tlsConfig.ACMECacheDir) },
+		})
+	}
+
+	if path := cfg.Security.AuthFile; path != "" {
+		checks = append(checks, preflightCheck{
+			name: "htpasswd file " + path,
+			run: func() (string, error) {
+				_, err := security.LoadHtpasswd(path)
+				return "", err
+			},
+		})
+	}
+	if path := cfg.Security.RequestPolicyFile; path != "" {
+		checks = append(checks, preflightCheck{
+			name: "request policy " + path,
+			run: func() (string, error) {
+				_, err := security.LoadRequestPolicy(path)
+				return "", err
+			},
+		})
+	}
+	if path := cfg.Security.JWT.PublicKeyFile; path != "" {
+		checks = append(checks, preflightCheck{
+			name: "jwt public key " + path,
+			run: func() (string, error) {
+				data, err := os.ReadFile(path)
+				if err != nil {
+					return "", err
+				}
+				_, err = security.ParseRSAPublicKeyPEM(data)
+				return "", err
+			},
+		})
+	}
+	return checks
+}
+
+// readableDir checks that the server can list dir
+func readableDir(dir string) error {
+	f, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if _, err := f.ReadDir(1); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("cannot list directory: %w", err)
+	}
+	return nil
+}
+
+// writableDir checks that dir exists or can be created, and accepts files
+func writableDir(dir string) error {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(dir, ".validate-*")
+	if err != nil {
+		return fmt.Errorf("directory is not writable: %w", err)
+	}
+	f.Close()
+	return os.Remove(f.Name())
+}
+
+// checkCertificate loads a certificate and key pair and checks that the
+// certificate is currently valid
+func checkCertificate(certFile, keyFile string) (string, error) {
+	pair, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return "", err
+	}
+	leaf, err := x509.ParseCertificate(pair.Certificate[0])
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+	switch {
+	case now.Before(leaf.NotBefore):
+		return "", fmt.Errorf("certificate is not valid until %s", leaf.NotBefore.Format(time.RFC3339))
+	case now.After(leaf.NotAfter):
+		return "", fmt.Errorf("certificate expired on %s", leaf.NotAfter.Format(time.RFC3339))
+	case leaf.NotAfter.Sub(now) < certExpiryWarning:
+		return "expires soon, on " + leaf.NotAfter.Format(time.RFC3339), nil
+	}
+	return "valid until " + leaf.NotAfter.Format(time.RFC3339), nil
+}
@@ -0,0 +1,86 @@
+// Package buildinfo reports the version of the running binary. Release
+// builds set it with linker flags:
+//
+//	go build -ldflags "-X github.com/sh05/cat-server/internal/buildinfo.version=v1.2.0
+//	  -X github.com/sh05/cat-server/internal/buildinfo.commit=$(git rev-parse HEAD)
+//	  -X github.com/sh05/cat-server/internal/buildinfo.date=$(date -u +%Y-%m-%dT%H:%M:%SZ)"
+//
+// Values left unset fall back to the module version and version control
+// stamps recorded by the Go toolchain.
+package buildinfo
+
+import (
+	"runtime"
+	"runtime/debug"
+	"sync"
+)
+
+// Set with -ldflags "-X ..."
+var (
+	version string
+	commit  string
+	date    string
+)
+
+// devVersion is reported when no version is known, e.g. for go run
+const devVersion = "dev"
+
+// Info describes a build
+type Info struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit,omitempty"`
+	Date      string `json:"date,omitempty"`
+	Modified  bool   `json:"modified,omitempty"` // built from a tree with uncommitted changes
+	GoVersion string `json:"goVersion"`
+}
+
+// Get returns the build information of the running binary
+var Get = sync.OnceValue(func() Info {
+	return read(debug.ReadBuildInfo)
+})
+
+// read combines the linker flags with the toolchain's build information
+func read(readBuildInfo func() (*debug.BuildInfo, bool)) Info {
+	info := Info{Version: version, Commit: commit, Date: date, GoVersion: runtime.Version()}
+
+	if build, ok := readBuildInfo(); ok {
+		if info.Version == "" && build.Main.Version != "(devel)" {
+			info.Version = build.Main.Version
+		}
+		for _, setting := range build.Settings {
+			switch setting.Key {
+			case "vcs.revision":
+				if info.Commit == "" {
+					info.Commit = setting.Value
+				}
+			case "vcs.time":
+				if info.Date == "" {
+					info.Date = setting.Value
+				}
+			case "vcs.modified":
+				info.Modified = setting.Value == "true"
+			}
+		}
+	}
+
+	if info.Version == "" {
+		info.Version = devVersion
+	}
+	return info
+}
+
+// String formats the information for --version
+func (i Info) String() string {
+	s := "cat-server " + i.Version
+	if i.Commit != "" {
+		s += " (" + i.Commit
+		if i.Modified {
+			s += ", modified"
+		}
+		s += ")"
+	}
+	if i.Date != "" {
+		s += " built " + i.Date
+	}
+	return s + " " + i.GoVersion
+}
@@ -0,0 +1,46 @@
+package buildinfo
+
+import (
+	"runtime/debug"
+	"testing"
+)
+
+func TestRead(t *testing.T) {
+	stamped := func() (*debug.BuildInfo, bool) {
+		return &debug.BuildInfo{
+			Main: debug.Module{Version: "v1.2.0"},
+			Settings: []debug.BuildSetting{
+				{Key: "vcs.revision", Value: "abc123"},
+				{Key: "vcs.time", Value: "2026-01-02T03:04:05Z"},
+				{Key: "vcs.modified", Value: "true"},
+			},
+		}, true
+	}
+	devel := func() (*debug.BuildInfo, bool) {
+		return &debug.BuildInfo{Main: debug.Module{Version: "(devel)"}}, true
+	}
+
+	tests := []struct {
+		name                  string
+		flagVersion, flagDate string
+		readBuildInfo         func() (*debug.BuildInfo, bool)
+		expected              Info
+	}{
+		{"toolchain", "", "", stamped, Info{Version: "v1.2.0", Commit: "abc123", Date: "2026-01-02T03:04:05Z", Modified: true}},
+		{"linker flags win", "v2.0.0", "2026-06-01", stamped, Info{Version: "v2.0.0", Commit: "abc123", Date: "2026-06-01", Modified: true}},
+		{"devel build", "", "", devel, Info{Version: devVersion}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			version, date = tt.flagVersion, tt.flagDate
+			defer func() { version, date = "", "" }()
+
+			info := read(tt.readBuildInfo)
+			info.GoVersion = ""
+			if info != tt.expected {
+				t.Errorf("read() = %+v, expected %+v", info, tt.expected)
+			}
+		})
+	}
+}
@@ -1,10 +1,20 @@
 package config
 
 import (
+	"cmp"
+	"errors"
 	"flag"
 	"fmt"
+	"maps"
+	"math"
+	"net"
+	"net/url"
 	"os"
+	"path"
+	"regexp"
+	"slices"
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -13,6 +23,7 @@ type Config struct {
 	Server     ServerConfig     `json:"server"`
 	FileSystem FileSystemConfig `json:"filesystem"`
 	Logging    LoggingConfig    `json:"logging"`
+	Metrics    MetricsConfig    `json:"metrics"`
 	Security   SecurityConfig   `json:"security"`
 }
 
@@ -23,6 +34,78 @@ type ServerConfig struct {
 	ReadTimeout  time.Duration `json:"read_timeout"`
 	WriteTimeout time.Duration `json:"write_timeout"`
 	IdleTimeout  time.Duration `json:"idle_timeout"`
+	// ReadHeaderTimeout bounds reading request headers; ReadTimeout applies
+	// when it is 0
+	ReadHeaderTimeout time.Duration `json:"read_header_timeout"`
+	// KeepAlive is the TCP keep-alive period of client connections; 0 keeps
+	// the Go default and a negative value disables keep-alive probes
+	KeepAlive time.Duration `json:"keep_alive"`
+	// MaxConnsPerIP limits concurrent connections from one remote address,
+	// 0 for no limit
+	MaxConnsPerIP int `json:"max_conns_per_ip"`
+	// EnableH2C serves HTTP/2 without TLS to clients with prior knowledge
+	EnableH2C bool `json:"enable_h2c"`
+	// ShutdownTimeout bounds how long in-flight requests may take to finish
+	// on shutdown before their connections are closed
+	ShutdownTimeout time.Duration `json:"shutdown_timeout"`
+	// ShutdownDelay keeps serving after a shutdown signal while /readyz
+	// reports 503, so load balancers stop routing before connections close
+	ShutdownDelay         time.Duration `json:"shutdown_delay"`
+	MaxConcurrentRequests int           `json:"max_concurrent_requests"`
+	MaxHeaderBytes        int           `json:"max_header_bytes"`
+	MaxURLLength          int           `json:"max_url_length"`
+	MaxBodyBytes          int64         `json:"max_body_bytes"`
+	// Compression lists the content encodings responses may be compressed
+	// with, in order of preference; empty disables compression
+	Compression []string `json:"compression"`
+	// CompressionMinBytes is the smallest response body that is compressed
+	CompressionMinBytes int `json:"compression_min_bytes"`
+	// WorkerPoolSize bounds the goroutines scanning and hashing files for
+	// searches and manifests across all requests; 0 uses GOMAXPROCS
+	WorkerPoolSize int `json:"worker_pool_size"`
+	// MemoryLimit is the soft memory limit of the Go runtime in bytes, as
+	// GOMEMLIMIT sets it; 0 keeps the limit from the environment
+	MemoryLimit int64 `json:"memory_limit"`
+	// GCPercent is the garbage collection target percentage, as GOGC sets
+	// it, with -1 turning the collector off; 0 keeps it from the environment
+	GCPercent int `json:"gc_percent"`
+	// MemoryRejectPercent of the memory limit is the heap size above which
+	// reads of files of at least LargeReadBytes into memory are refused with
+	// 503; 0 disables this, as does running without a memory limit
+	MemoryRejectPercent float64 `json:"memory_reject_percent"`
+	LargeReadBytes      int64   `json:"large_read_bytes"`
+	// AdminAddr moves /health, /version, /metrics, /debug/pprof and the /admin
+	// endpoints to a separate listener, e.g. 127.0.0.1:9090
+	AdminAddr string `json:"admin_addr"`
+	// EnablePprof serves the net/http/pprof profiles under /debug/pprof and
+	// expvar variables at /debug/vars on the admin listener, behind
+	// the admin token when one is configured
+	EnablePprof bool      `json:"enable_pprof"`
+	TLS         TLSConfig `json:"tls"`
+}
+
+// TLSConfig holds HTTPS settings. Certificates come either from files or,
+// for ACMEDomains, from an ACME CA such as Let's Encrypt. RedirectAddr
+// optionally runs a plain HTTP listener that redirects every request to
+// HTTPS and answers ACME http-01 challenges.
+type TLSConfig struct {
+	CertFile         string   `json:"cert_file"`
+	KeyFile          string   `json:"key_file"`
+	RedirectAddr     string   `json:"redirect_addr"`
+	ACMEDomains      []string `json:"acme_domains"`
+	ACMECacheDir     string   `json:"acme_cache_dir"`
+	ACMEEmail        string   `json:"acme_email"`
+	ACMEDirectoryURL string   `json:"acme_directory_url"`
+}
+
+// Enabled reports whether the server terminates TLS itself
+func (t TLSConfig) Enabled() bool {
+	return t.CertFile != "" || t.KeyFile != "" || t.ACMEEnabled()
+}
+
+// ACMEEnabled reports whether certificates are obtained automatically
+func (t TLSConfig) ACMEEnabled() bool {
+	return len(t.ACMEDomains) > 0
 }
 
 // FileSystemConfig holds filesystem-related configuration
@@ -30,117 +113,833 @@ type FileSystemConfig struct {
 	BaseDirectory string `json:"base_directory"`
 	MaxFileSize   int64  `json:"max_file_size"`
 	AllowHidden   bool   `json:"allow_hidden"`
+	ReadOnly      bool   `json:"read_only"`
+	// Mounts serve further directories under aliases, e.g. /ls/logs/ and
+	// /cat/logs/app.log for a mount named "logs"
+	Mounts []MountConfig `json:"mounts"`
+	// Overrides change the file limits below path prefixes, e.g. a mount
+	// name or "docs/private"; the longest matching prefix wins
+	Overrides []PathOverride `json:"overrides"`
+	// Disk usage of the base directory's filesystem, as a percentage of
+	// space or inodes, at which health reports a warning or turns
+	// unhealthy; 0 disables the threshold
+	DiskWarningPercent  float64 `json:"disk_warning_percent"`
+	DiskCriticalPercent float64 `json:"disk_critical_percent"`
+	// ContentCacheBytes bounds the in-memory cache of hot file contents,
+	// holding files of at most ContentCacheEntryBytes; 0 disables it
+	ContentCacheBytes      int64 `json:"content_cache_bytes"`
+	ContentCacheEntryBytes int64 `json:"content_cache_entry_bytes"`
+	// ListingCacheTTL is how long a directory listing is served from
+	// memory while the directory is unchanged; 0 disables the cache
+	ListingCacheTTL time.Duration `json:"listing_cache_ttl"`
+	// Watch selects how changes below the base directory are detected to
+	// invalidate the caches: "inotify", "poll" every WatchPollInterval,
+	// "auto" for inotify except on network filesystems, or "off"
+	Watch             string        `json:"watch"`
+	WatchPollInterval time.Duration `json:"watch_poll_interval"`
+	// IndexMaxEntries bounds the in-memory index of the directory tree,
+	// which answers listings and tree walks from memory and is kept up to
+	// date by the watcher and rebuilt every IndexRefreshInterval; 0
+	// disables it
+	IndexMaxEntries      int           `json:"index_max_entries"`
+	IndexRefreshInterval time.Duration `json:"index_refresh_interval"`
+	// StatTimeout, ReadTimeout and ListTimeout bound single stat, file read
+	// and directory listing operations, so a hung filesystem such as an
+	// unreachable NFS mount fails requests with 503; 0 leaves them unbounded
+	StatTimeout time.Duration `json:"stat_timeout"`
+	ReadTimeout time.Duration `json:"read_timeout"`
+	ListTimeout time.Duration `json:"list_timeout"`
+	// BreakerThreshold consecutive timeouts in the base directory or a mount
+	// fail its operations at once for BreakerCooldown; 0 disables this
+	BreakerThreshold int           `json:"breaker_threshold"`
+	BreakerCooldown  time.Duration `json:"breaker_cooldown"`
+}
+
+// MountConfig exposes a directory under an alias with its own limits
+type MountConfig struct {
+	Name string `json:"name"`
+	Path string `json:"path"`
+	// MaxFileSize overrides filesystem.max_file_size when positive
+	MaxFileSize       int64    `json:"max_file_size"`
+	AllowHidden       bool     `json:"allow_hidden"`
+	AllowedExtensions []string `json:"allowed_extensions"`
+	DeniedExtensions  []string `json:"denied_extensions"`
 }
 
+// PathOverride overrides file limits for the paths at or below Prefix.
+// Unset fields inherit from shorter matching prefixes, the mount or the
+// filesystem settings.
+type PathOverride struct {
+	Prefix      string `json:"prefix"`
+	MaxFileSize int64  `json:"max_file_size"`
+	AllowHidden *bool  `json:"allow_hidden"`
+	// AllowedExtensions restricts files to these suffixes, e.g. .log or
+	// .tar.gz; an empty list lifts an inherited restriction
+	AllowedExtensions []string `json:"allowed_extensions"`
+	DeniedExtensions  []string `json:"denied_extensions"`
+}
+
+// validMountName matches mount aliases: a single path element that is not
+// hidden
+var validMountName = regexp.MustCompile(`^[A-Za-z0-9_-][A-Za-z0-9._-]*$`)
+
 // LoggingConfig holds logging configuration
 type LoggingConfig struct {
 	Level  string `json:"level"`
 	Format string `json:"format"`
+	// Outputs lists where logs are written, each record to all of them:
+	// stdout, stderr, file, syslog, journald or otlp
+	Outputs []string `json:"outputs"`
+	// File is the path the file output appends to
+	File string `json:"file"`
+	// SyslogFacility is the facility of the syslog output, e.g. daemon
+	SyslogFacility string `json:"syslog_facility"`
+	// OTLPEndpoint is the OTLP/HTTP logs endpoint of the otlp output, e.g.
+	// http://localhost:4318/v1/logs
+	OTLPEndpoint string `json:"otlp_endpoint"`
+	// SampleSuccess logs the request and response lines of only 1 in
+	// SampleSuccess requests answered below 400, while errors are always
+	// logged; 0 or 1 logs every request
+	SampleSuccess int `json:"sample_success"`
+	// LargeResponseBytes exempts responses with at least this many body
+	// bytes from sampling; 0 disables the exemption
+	LargeResponseBytes int64 `json:"large_response_bytes"`
+	// SlowRequests maps route prefixes such as /ls to the latency above
+	// which a request is logged as a slow_request warning with its timing
+	// breakdown; 0 disables the route
+	SlowRequests map[string]time.Duration `json:"slow_requests"`
+	// AuditFile and AuditSyslog select the audit log sink for security
+	// events, auth failures and admin actions; at most one may be set
+	AuditFile   string `json:"audit_file"`
+	AuditSyslog string `json:"audit_syslog"`
+}
+
+// MetricsConfig holds metrics export configuration
+type MetricsConfig struct {
+	// StatsDHost and StatsDPort address a StatsD or DogStatsD agent sent
+	// request counts, latencies and filesystem errors; disabled when
+	// StatsDHost is empty
+	StatsDHost string `json:"statsd_host"`
+	StatsDPort int    `json:"statsd_port"`
+	// StatsDPrefix is prepended to every metric name, e.g. cat_server.
+	StatsDPrefix string `json:"statsd_prefix"`
+	// StatsDTags are DogStatsD tags added to every metric, e.g. env:prod
+	StatsDTags []string `json:"statsd_tags"`
+}
+
+// StatsDEnabled reports whether metrics are sent to a StatsD agent
+func (m MetricsConfig) StatsDEnabled() bool {
+	return m.StatsDHost != ""
+}
+
+// StatsDAddr returns the host:port of the StatsD agent
+func (m MetricsConfig) StatsDAddr() string {
+	return net.JoinHostPort(m.StatsDHost, strconv.Itoa(m.StatsDPort))
 }
 
 // SecurityConfig holds security-related configuration
 type SecurityConfig struct {
-	EnableCORS            bool `json:"enable_cors"`
-	EnableSecurityHeaders bool `json:"enable_security_headers"`
-	EnableRateLimit       bool `json:"enable_rate_limit"`
-	MaxPathLength         int  `json:"max_path_length"`
+	EnableCORS            bool            `json:"enable_cors"`
+	EnableRecovery        bool            `json:"enable_recovery"`
+	RequestTimeout        time.Duration   `json:"request_timeout"`
+	EnableSecurityHeaders bool            `json:"enable_security_headers"`
+	EnableRateLimit       bool            `json:"enable_rate_limit"`
+	MaxPathLength         int             `json:"max_path_length"`
+	Headers               HeadersConfig   `json:"headers"`
+	AdminToken            string          `json:"admin_token"`
+	AuthFile              string          `json:"auth_file"`
+	RequestPolicyFile     string          `json:"request_policy_file"`
+	JWT                   JWTConfig       `json:"jwt"`
+	OIDC                  OIDCConfig      `json:"oidc"`
+	AllowedCIDRs          []string        `json:"allowed_cidrs"`
+	DeniedCIDRs           []string        `json:"denied_cidrs"`
+	TrustedProxies        []string        `json:"trusted_proxies"`
+	BanThreshold          int             `json:"ban_threshold"`
+	BanWindow             time.Duration   `json:"ban_window"`
+	BanCooldown           time.Duration   `json:"ban_cooldown"`
+	RateLimit             RateLimitConfig `json:"rate_limit"`
+	DailyByteQuota        int64           `json:"daily_byte_quota"`
+	Sandbox               bool            `json:"sandbox"`
+	Redaction             RedactionConfig `json:"redaction"`
+	SignedURLs            SignedURLConfig `json:"signed_urls"`
+}
+
+// SignedURLConfig holds the HMAC keys accepted in signed URLs, which grant
+// read access to a single path until they expire. All keys verify links so
+// secrets can be rotated; new links are signed with CurrentKey, or the first
+// key when it is empty.
+type SignedURLConfig struct {
+	Keys       []SigningKeyConfig `json:"keys"`
+	CurrentKey string             `json:"current_key"`
+	ClockSkew  time.Duration      `json:"clock_skew"`
+}
+
+// SigningKeyConfig is a named signed URL secret
+type SigningKeyConfig struct {
+	ID     string `json:"id"`
+	Secret string `json:"secret"`
+}
+
+// Enabled reports whether any signed URL key is configured
+func (s SignedURLConfig) Enabled() bool {
+	return len(s.Keys) > 0
+}
+
+// RedactionConfig holds the rules masking secrets in the served contents of
+// files matching Files, unless the caller's token grants BypassScope
+type RedactionConfig struct {
+	Enabled     bool     `json:"enabled"`
+	Files       []string `json:"files"`
+	Keys        string   `json:"keys"`
+	Pattern     string   `json:"pattern"`
+	BypassScope string   `json:"bypass_scope"`
+}
+
+// HeadersConfig holds the security headers sent when EnableSecurityHeaders
+// is set. Empty values omit the header; HSTS is only sent over TLS.
+type HeadersConfig struct {
+	ContentSecurityPolicy     string        `json:"content_security_policy"`
+	CrossOriginResourcePolicy string        `json:"cross_origin_resource_policy"`
+	HSTSMaxAge                time.Duration `json:"hsts_max_age"`
+	HSTSIncludeSubdomains     bool          `json:"hsts_include_subdomains"`
+}
+
+// RateLimitConfig holds the token-bucket limits applied when
+// EnableRateLimit is set. A GlobalRPS of zero disables the global limit.
+type RateLimitConfig struct {
+	RPS         float64 `json:"rps"`
+	Burst       int     `json:"burst"`
+	GlobalRPS   float64 `json:"global_rps"`
+	GlobalBurst int     `json:"global_burst"`
+}
+
+// JWTConfig holds bearer-token validation settings. Tokens are accepted when
+// a secret (HS256), public key file or JWKS URL (RS256) is configured.
+type JWTConfig struct {
+	Secret        string `json:"secret"`
+	PublicKeyFile string `json:"public_key_file"`
+	JWKSURL       string `json:"jwks_url"`
+	Issuer        string `json:"issuer"`
+	Audience      string `json:"audience"`
+}
+
+// Enabled reports whether any token signing key is configured
+func (j JWTConfig) Enabled() bool {
+	return j.Secret != "" || j.PublicKeyFile != "" || j.JWKSURL != ""
+}
+
+// OIDCConfig holds the OpenID Connect login settings for browser users
+type OIDCConfig struct {
+	IssuerURL     string        `json:"issuer_url"`
+	ClientID      string        `json:"client_id"`
+	ClientSecret  string        `json:"client_secret"`
+	RedirectURL   string        `json:"redirect_url"`
+	SessionSecret string        `json:"session_secret"`
+	SessionTTL    time.Duration `json:"session_ttl"`
+}
+
+// Enabled reports whether the OIDC login flow is configured
+func (o OIDCConfig) Enabled() bool {
+	return o.IssuerURL != ""
 }
 
 // DefaultConfig returns a configuration with default values
 func DefaultConfig() *Config {
 	return &Config{
 		Server: ServerConfig{
-			Port:         "8080",
-			Host:         "",
-			ReadTimeout:  15 * time.Second,
-			WriteTimeout: 15 * time.Second,
-			IdleTimeout:  60 * time.Second,
+			Port:                "8080",
+			Host:                "",
+			ReadTimeout:         15 * time.Second,
+			WriteTimeout:        15 * time.Second,
+			IdleTimeout:         60 * time.Second,
+			ShutdownTimeout:     10 * time.Second,
+			MaxHeaderBytes:      64 * 1024,
+			MaxURLLength:        8192,
+			MaxBodyBytes:        1024 * 1024, // 1MB
+			Compression:         []string{"gzip", "deflate"},
+			CompressionMinBytes: 1024,
+			MemoryRejectPercent: 90,
+			LargeReadBytes:      1024 * 1024, // 1MB
+			TLS: TLSConfig{
+				ACMECacheDir:     "acme-cache",
+				ACMEDirectoryURL: "https://acme-v02.api.letsencrypt.org/directory",
+			},
 		},
 		FileSystem: FileSystemConfig{
 			BaseDirectory: "./files/",
 			MaxFileSize:   10 * 1024 * 1024, // 10MB
 			AllowHidden:   false,
+
+			DiskWarningPercent:  90,
+			DiskCriticalPercent: 95,
+
+			ContentCacheBytes:      64 * 1024 * 1024, // 64MB
+			ContentCacheEntryBytes: 1024 * 1024,      // 1MB
+			ListingCacheTTL:        2 * time.Second,
+			Watch:                  "auto",
+			WatchPollInterval:      10 * time.Second,
+			IndexRefreshInterval:   15 * time.Minute,
+			StatTimeout:            5 * time.Second,
+			ReadTimeout:            30 * time.Second,
+			ListTimeout:            30 * time.Second,
+			BreakerThreshold:       5,
+			BreakerCooldown:        30 * time.Second,
 		},
 		Logging: LoggingConfig{
-			Level:  "info",
-			Format: "json",
+			Level:          "info",
+			Format:         "json",
+			Outputs:        []string{"stdout"},
+			SyslogFacility: "daemon",
+			SlowRequests: map[string]time.Duration{
+				"/ls":  100 * time.Millisecond,
+				"/cat": 200 * time.Millisecond,
+			},
+		},
+		Metrics: MetricsConfig{
+			StatsDPort:   8125,
+			StatsDPrefix: "cat_server.",
 		},
 		Security: SecurityConfig{
 			EnableCORS:            true,
+			EnableRecovery:        true,
 			EnableSecurityHeaders: true,
 			EnableRateLimit:       false,
 			MaxPathLength:         1000,
+			Headers: HeadersConfig{
+				ContentSecurityPolicy:     "default-src 'none'; frame-ancestors 'none'",
+				CrossOriginResourcePolicy: "same-origin",
+				HSTSMaxAge:                365 * 24 * time.Hour,
+			},
+			OIDC: OIDCConfig{
+				SessionTTL: 8 * time.Hour,
+			},
+			BanThreshold: 0,
+			BanWindow:    time.Minute,
+			BanCooldown:  15 * time.Minute,
+			RateLimit: RateLimitConfig{
+				RPS:   10,
+				Burst: 20,
+			},
+			Redaction: RedactionConfig{
+				Enabled:     true,
+				Files:       []string{".env", ".env.*", "*.env", "*.pem", "*.key"},
+				Keys:        `(?i)(secret|passw(or)?d|token|api[_-]?key|private[_-]?key|credential)`,
+				Pattern:     `-----BEGIN [A-Z ]*PRIVATE KEY-----[\s\S]*?-----END [A-Z ]*PRIVATE KEY-----`,
+				BypassScope: "secrets:read",
+			},
+			SignedURLs: SignedURLConfig{
+				ClockSkew: 30 * time.Second,
+			},
 		},
 	}
 }
 
 // LoadFromFlags loads configuration from command line flags
 func LoadFromFlags() (*Config, error) {
-	config := DefaultConfig()
+	return LoadFromFlagSet(flag.CommandLine, os.Args[1:])
+}
 
-	// Define command line flags
-	var (
-		port         = flag.String("port", config.Server.Port, "HTTP server port")
-		host         = flag.String("host", config.Server.Host, "HTTP server host")
-		dir          = flag.String("dir", config.FileSystem.BaseDirectory, "Base directory to serve files from")
-		maxFileSize  = flag.Int64("max-file-size", config.FileSystem.MaxFileSize, "Maximum file size in bytes")
-		allowHidden  = flag.Bool("allow-hidden", config.FileSystem.AllowHidden, "Allow access to hidden files")
-		logLevel     = flag.String("log-level", config.Logging.Level, "Logging level (debug, info, warn, error)")
-		logFormat    = flag.String("log-format", config.Logging.Format, "Logging format (json, text)")
-		enableCORS   = flag.Bool("enable-cors", config.Security.EnableCORS, "Enable CORS headers")
-		readTimeout  = flag.Duration("read-timeout", config.Server.ReadTimeout, "HTTP read timeout")
-		writeTimeout = flag.Duration("write-timeout", config.Server.WriteTimeout, "HTTP write timeout")
-		idleTimeout  = flag.Duration("idle-timeout", config.Server.IdleTimeout, "HTTP idle timeout")
-	)
+// LoadFromFlagSet defines the configuration flags on fs, parses args and
+// loads the resulting configuration. Subcommands use it to add their own
+// flags alongside the server configuration flags. Each source overrides
+// the ones before it:
+//
+//  1. defaults
+//  2. the --config file
+//  3. the --env-file dotenv file, .env by default
+//  4. CAT_SERVER_* environment variables
+//  5. the flags given in args
+func LoadFromFlagSet(fs *flag.FlagSet, args []string) (*Config, error) {
+	config, err := ParseFlagSet(fs, args)
+	if err != nil {
+		return nil, err
+	}
 
-	flag.Parse()
+	// Validate configuration
+	if err := config.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
 
-	// Apply flag values to config
-	config.Server.Port = *port
-	config.Server.Host = *host
-	config.Server.ReadTimeout = *readTimeout
-	config.Server.WriteTimeout = *writeTimeout
-	config.Server.IdleTimeout = *idleTimeout
+	return config, nil
+}
 
-	config.FileSystem.BaseDirectory = *dir
-	config.FileSystem.MaxFileSize = *maxFileSize
-	config.FileSystem.AllowHidden = *allowHidden
+// ParseFlagSet is LoadFromFlagSet without validation, for subcommands that
+// only read a few settings, such as the address probed by healthcheck
+func ParseFlagSet(fs *flag.FlagSet, args []string) (*Config, error) {
+	configFile := fs.String("config", os.Getenv("CAT_SERVER_CONFIG"), "YAML, TOML or JSON configuration file; environment variables and flags take precedence")
+	envFile := fs.String("env-file", os.Getenv("CAT_SERVER_ENV_FILE"), "File of KEY=VALUE environment variables (default: "+DefaultEnvFile+" if present); the environment takes precedence")
+	bindFlags(fs, DefaultConfig())
 
-	config.Logging.Level = *logLevel
-	config.Logging.Format = *logFormat
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
 
-	config.Security.EnableCORS = *enableCORS
+	// A missing .env is only an error when the file was named explicitly
+	dotenv, envErr := readEnvFile(cmp.Or(*envFile, DefaultEnvFile))
+	if envErr != nil && (*envFile != "" || !errors.Is(envErr, os.ErrNotExist)) {
+		return nil, fmt.Errorf("failed to load env file: %w", envErr)
+	}
 
-	// Load additional configuration from environment variables
+	config := DefaultConfig()
+	if path := cmp.Or(*configFile, dotenv["CAT_SERVER_CONFIG"]); path != "" {
+		if err := config.LoadFromFile(path); err != nil {
+			return nil, err
+		}
+	}
+
+	// Load additional configuration from the env file, then from
+	// environment variables, which override it
+	if err := config.loadEnv(func(key string) string { return dotenv[key] }); err != nil {
+		return nil, fmt.Errorf("failed to load env file: %w", err)
+	}
 	if err := config.LoadFromEnv(); err != nil {
 		return nil, fmt.Errorf("failed to load config from environment: %w", err)
 	}
 
-	// Validate configuration
-	if err := config.Validate(); err != nil {
-		return nil, fmt.Errorf("invalid configuration: %w", err)
+	// Replay only the flags given in args onto the loaded configuration, so
+	// unset flags do not reset file and environment settings to defaults
+	overrides := flag.NewFlagSet(fs.Name(), flag.ContinueOnError)
+	apply := bindFlags(overrides, config)
+	var err error
+	fs.Visit(func(f *flag.Flag) {
+		if err == nil && overrides.Lookup(f.Name) != nil {
+			err = overrides.Set(f.Name, f.Value.String())
+		}
+	})
+	if err != nil {
+		return nil, err
 	}
+	apply()
 
 	return config, nil
 }
 
+// bindFlags defines the configuration flags on fs with config's values as
+// defaults, and returns a function copying the flag values into config
+func bindFlags(fs *flag.FlagSet, config *Config) func() {
+	var (
+		port         = fs.String("port", config.Server.Port, "HTTP server port")
+		host         = fs.String("host", config.Server.Host, "HTTP server host, or comma-separated hosts to listen on all of, e.g. 127.0.0.1,::1")
+		dir          = fs.String("dir", config.FileSystem.BaseDirectory, "Base directory to serve files from")
+		maxFileSize  = fs.Int64("max-file-size", config.FileSystem.MaxFileSize, "Maximum file size in bytes")
+		allowHidden  = fs.Bool("allow-hidden", config.FileSystem.AllowHidden, "Allow access to hidden files")
+		mounts       = fs.String("mount", formatMounts(config.FileSystem.Mounts), "Comma-separated name=path directories served under /ls/{name}/ and /cat/{name}/, e.g. logs=/var/log/app")
+		readOnly     = fs.Bool("read-only", config.FileSystem.ReadOnly, "Guarantee read-only operation: endpoints that modify files are never registered")
+		diskWarning  = fs.Float64("disk-warning-percent", config.FileSystem.DiskWarningPercent, "Disk space or inode usage in percent at which filesystem health reports a warning (disabled when 0)")
+		diskCritical = fs.Float64("disk-critical-percent", config.FileSystem.DiskCriticalPercent, "Disk space or inode usage in percent at which filesystem health is unhealthy (disabled when 0)")
+		cacheBytes   = fs.Int64("content-cache-bytes", config.FileSystem.ContentCacheBytes, "Total size in bytes of the in-memory cache of file contents (disabled when 0)")
+		cacheEntry   = fs.Int64("content-cache-entry-bytes", config.FileSystem.ContentCacheEntryBytes, "Largest file in bytes held by the content cache")
+		listingTTL   = fs.Duration("listing-cache-ttl", config.FileSystem.ListingCacheTTL, "How long unchanged directory listings are served from memory (disabled when 0)")
+		watch        = fs.String("watch", config.FileSystem.Watch, "How file changes invalidating the caches are detected (auto, inotify, poll, off)")
+		watchPoll    = fs.Duration("watch-poll-interval", config.FileSystem.WatchPollInterval, "Interval between scans of the base directory when polling for changes")
+		indexMax     = fs.Int("index-max-entries", config.FileSystem.IndexMaxEntries, "Directory entries held by the in-memory index of the base directory (disabled when 0)")
+		indexRefresh = fs.Duration("index-refresh-interval", config.FileSystem.IndexRefreshInterval, "Interval between full rebuilds of the directory index (only on lost change events when 0)")
+		statTimeout  = fs.Duration("fs-stat-timeout", config.FileSystem.StatTimeout, "Answer 503 when a file stat takes longer than this (unbounded when 0)")
+		fsRead       = fs.Duration("fs-read-timeout", config.FileSystem.ReadTimeout, "Answer 503 when reading or opening a file takes longer than this (unbounded when 0)")
+		listTimeout  = fs.Duration("fs-list-timeout", config.FileSystem.ListTimeout, "Answer 503 when listing a directory takes longer than this (unbounded when 0)")
+		breakerLimit = fs.Int("fs-breaker-threshold", config.FileSystem.BreakerThreshold, "Consecutive filesystem timeouts after which operations fail at once for the breaker cooldown (disabled when 0)")
+		breakerCool  = fs.Duration("fs-breaker-cooldown", config.FileSystem.BreakerCooldown, "How long operations fail at once before the filesystem is probed again")
+		logLevel     = fs.String("log-level", config.Logging.Level, "Logging level (debug, info, warn, error)")
+		logFormat    = fs.String("log-format", config.Logging.Format, "Logging format (json, text)")
+		logOutput    = fs.String("log-output", strings.Join(config.Logging.Outputs, ","), "Comma-separated log outputs: stdout, stderr, file, syslog, journald, otlp")
+		logFile      = fs.String("log-file", config.Logging.File, "File the file log output appends to")
+		logFacility  = fs.String("log-syslog-facility", config.Logging.SyslogFacility, "Syslog facility of the syslog log output, e.g. daemon or local0")
+		logOTLP      = fs.String("log-otlp-endpoint", config.Logging.OTLPEndpoint, "OTLP/HTTP logs endpoint of the otlp log output, e.g. http://localhost:4318/v1/logs")
+		logSample    = fs.Int("log-sample-success", config.Logging.SampleSuccess, "Log only 1 in N requests answered below 400; errors are always logged (all when 0 or 1)")
+		logLarge     = fs.Int64("log-large-response-bytes", config.Logging.LargeResponseBytes, "Always log responses of at least this many bytes despite sampling (disabled when 0)")
+		logSlow      = fs.String("log-slow-requests", formatSlowRequests(config.Logging.SlowRequests), "Comma-separated route=latency thresholds above which requests are logged as slow, e.g. /ls=100ms,/cat=200ms")
+		auditFile    = fs.String("audit-log", config.Logging.AuditFile, "Append security audit records to this file (disabled when empty)")
+		auditSyslog  = fs.String("audit-syslog", config.Logging.AuditSyslog, "Send security audit records to syslog under this facility, e.g. authpriv or local0")
+		enableCORS   = fs.Bool("enable-cors", config.Security.EnableCORS, "Enable CORS headers")
+		recovery     = fs.Bool("enable-recovery", config.Security.EnableRecovery, "Recover from handler panics with a 500 response")
+		reqTimeout   = fs.Duration("request-timeout", config.Security.RequestTimeout, "Answer 503 when a handler runs longer than this; buffers responses (disabled when 0)")
+		secHeaders   = fs.Bool("security-headers", config.Security.EnableSecurityHeaders, "Enable security response headers")
+		csp          = fs.String("content-security-policy", config.Security.Headers.ContentSecurityPolicy, "Content-Security-Policy header value (omitted when empty)")
+		corp         = fs.String("cross-origin-resource-policy", config.Security.Headers.CrossOriginResourcePolicy, "Cross-Origin-Resource-Policy header value: same-origin, same-site or cross-origin (omitted when empty)")
+		hstsMaxAge   = fs.Duration("hsts-max-age", config.Security.Headers.HSTSMaxAge, "Strict-Transport-Security max-age sent over TLS (disabled when 0)")
+		hstsSubdoms  = fs.Bool("hsts-include-subdomains", config.Security.Headers.HSTSIncludeSubdomains, "Add includeSubDomains to Strict-Transport-Security")
+		readTimeout  = fs.Duration("read-timeout", config.Server.ReadTimeout, "HTTP read timeout")
+		writeTimeout = fs.Duration("write-timeout", config.Server.WriteTimeout, "HTTP write timeout")
+		idleTimeout  = fs.Duration("idle-timeout", config.Server.IdleTimeout, "HTTP idle timeout")
+		shutdownTime = fs.Duration("shutdown-timeout", config.Server.ShutdownTimeout, "Time in-flight requests may take to finish on shutdown")
+		shutdownWait = fs.Duration("shutdown-delay", config.Server.ShutdownDelay, "Time to keep serving after a shutdown signal while /readyz reports 503")
+		tlsCert      = fs.String("tls-cert", config.Server.TLS.CertFile, "PEM certificate file enabling HTTPS (chain included)")
+		tlsKey       = fs.String("tls-key", config.Server.TLS.KeyFile, "PEM private key file for --tls-cert")
+		adminAddr    = fs.String("admin-addr", config.Server.AdminAddr, "Address of a separate listener for /health, /version, /metrics, /debug/pprof and /admin, e.g. 127.0.0.1:9090 (served on the main port when empty)")
+		enablePprof  = fs.Bool("enable-pprof", config.Server.EnablePprof, "Serve /debug/pprof profiles and /debug/vars on the admin listener (requires --admin-addr)")
+		tlsRedirect  = fs.String("tls-redirect-addr", config.Server.TLS.RedirectAddr, "Address of a plain HTTP listener redirecting to HTTPS, e.g. :80 (disabled when empty)")
+		acmeDomains  = fs.String("acme-domain", strings.Join(config.Server.TLS.ACMEDomains, ","), "Comma-separated domains to obtain certificates for from an ACME CA (disabled when empty)")
+		acmeCache    = fs.String("acme-cache-dir", config.Server.TLS.ACMECacheDir, "Directory storing the ACME account key and certificates")
+		acmeEmail    = fs.String("acme-email", config.Server.TLS.ACMEEmail, "Contact email registered with the ACME CA")
+		acmeURL      = fs.String("acme-directory-url", config.Server.TLS.ACMEDirectoryURL, "ACME directory URL, e.g. a staging CA")
+		readHeader   = fs.Duration("read-header-timeout", config.Server.ReadHeaderTimeout, "HTTP request header read timeout (read timeout when 0)")
+		keepAlive    = fs.Duration("tcp-keep-alive", config.Server.KeepAlive, "TCP keep-alive period of client connections (Go default when 0, disabled when negative)")
+		maxConnsIP   = fs.Int("max-conns-per-ip", config.Server.MaxConnsPerIP, "Maximum concurrent connections from one remote address (unlimited when 0)")
+		enableH2C    = fs.Bool("enable-h2c", config.Server.EnableH2C, "Serve HTTP/2 over cleartext connections to clients with prior knowledge")
+		maxInFlight  = fs.Int("max-concurrent-requests", config.Server.MaxConcurrentRequests, "Maximum in-flight requests before shedding load with 503 (unlimited when 0)")
+		maxHeader    = fs.Int("max-header-bytes", config.Server.MaxHeaderBytes, "Maximum size of request headers, including the request line, in bytes")
+		maxURL       = fs.Int("max-url-length", config.Server.MaxURLLength, "Maximum request URL length; longer URLs are refused with 414")
+		maxBody      = fs.Int64("max-body-bytes", config.Server.MaxBodyBytes, "Maximum request body size in bytes; larger bodies are refused with 413")
+		compression  = fs.String("compression", strings.Join(config.Server.Compression, ","), "Comma-separated response encodings in order of preference: gzip, deflate, br, zstd (off to disable)")
+		compressMin  = fs.Int("compression-min-bytes", config.Server.CompressionMinBytes, "Smallest response body in bytes that is compressed")
+		workerPool   = fs.Int("worker-pool-size", config.Server.WorkerPoolSize, "Goroutines shared by searches and manifests for scanning and hashing files (GOMAXPROCS when 0)")
+		memoryLimit  = fs.String("memory-limit", strconv.FormatInt(config.Server.MemoryLimit, 10), "Soft memory limit of the runtime in bytes with an optional KiB, MiB, GiB or TiB suffix, as for GOMEMLIMIT (GOMEMLIMIT when 0)")
+		gcPercent    = fs.Int("gc-percent", config.Server.GCPercent, "Garbage collection target percentage as for GOGC, -1 to turn the collector off (GOGC when 0)")
+		memReject    = fs.Float64("memory-reject-percent", config.Server.MemoryRejectPercent, "Heap size in percent of the memory limit above which large files are not read into memory (disabled when 0)")
+		largeRead    = fs.Int64("large-read-bytes", config.Server.LargeReadBytes, "Smallest file in bytes whose read is refused while memory is short")
+		maxPath      = fs.Int("max-path-length", config.Security.MaxPathLength, "Maximum decoded URL path length; longer paths are refused with 414")
+		adminToken   = fs.String("admin-token", config.Security.AdminToken, "Bearer token required by admin endpoints (disabled when empty)")
+		policyFile   = fs.String("request-policy", config.Security.RequestPolicyFile, "JSON file of User-Agent and Referer rules that block or tag requests (disabled when empty)")
+		authFile     = fs.String("auth-file", config.Security.AuthFile, "htpasswd file with bcrypt hashes enabling HTTP Basic auth (disabled when empty)")
+		jwtSecret    = fs.String("jwt-secret", config.Security.JWT.Secret, "HS256 secret for bearer token validation")
+		jwtKeyFile   = fs.String("jwt-public-key", config.Security.JWT.PublicKeyFile, "PEM RSA public key file for RS256 bearer token validation")
+		jwtJWKSURL   = fs.String("jwt-jwks-url", config.Security.JWT.JWKSURL, "JWKS URL publishing RS256 keys for bearer token validation")
+		jwtIssuer    = fs.String("jwt-issuer", config.Security.JWT.Issuer, "Required bearer token issuer (iss)")
+		jwtAudience  = fs.String("jwt-audience", config.Security.JWT.Audience, "Required bearer token audience (aud)")
+		oidcIssuer   = fs.String("oidc-issuer", config.Security.OIDC.IssuerURL, "OpenID Connect issuer URL enabling browser login (disabled when empty)")
+		oidcClientID = fs.String("oidc-client-id", config.Security.OIDC.ClientID, "OpenID Connect client ID")
+		oidcSecret   = fs.String("oidc-client-secret", config.Security.OIDC.ClientSecret, "OpenID Connect client secret")
+		oidcRedirect = fs.String("oidc-redirect-url", config.Security.OIDC.RedirectURL, "External URL of /auth/callback registered with the provider")
+		sessionKey   = fs.String("session-secret", config.Security.OIDC.SessionSecret, "Secret for signing login session cookies (random per process when empty)")
+		sessionTTL   = fs.Duration("session-ttl", config.Security.OIDC.SessionTTL, "Lifetime of login sessions")
+		allowedCIDRs = fs.String("allowed-cidrs", strings.Join(config.Security.AllowedCIDRs, ","), "Comma-separated CIDRs or IPs allowed to connect (all when empty)")
+		deniedCIDRs  = fs.String("denied-cidrs", strings.Join(config.Security.DeniedCIDRs, ","), "Comma-separated CIDRs or IPs refused access")
+		trustedProxy = fs.String("trusted-proxies", strings.Join(config.Security.TrustedProxies, ","), "Comma-separated proxy CIDRs or IPs whose X-Forwarded-For header is honoured")
+		banThreshold = fs.Int("ban-threshold", config.Security.BanThreshold, "Blocked security events within the ban window that get a client banned (disabled when 0)")
+		banWindow    = fs.Duration("ban-window", config.Security.BanWindow, "Sliding window for counting blocked security events")
+		banCooldown  = fs.Duration("ban-cooldown", config.Security.BanCooldown, "How long banned clients are refused")
+		rateLimit    = fs.Bool("rate-limit", config.Security.EnableRateLimit, "Enable request rate limiting")
+		rateRPS      = fs.Float64("rate-limit-rps", config.Security.RateLimit.RPS, "Requests per second allowed per client IP")
+		rateBurst    = fs.Int("rate-limit-burst", config.Security.RateLimit.Burst, "Request burst allowed per client IP")
+		globalRPS    = fs.Float64("global-rate-limit-rps", config.Security.RateLimit.GlobalRPS, "Requests per second allowed across all clients (disabled when 0)")
+		globalBurst  = fs.Int("global-rate-limit-burst", config.Security.RateLimit.GlobalBurst, "Request burst allowed across all clients")
+		byteQuota    = fs.Int64("daily-byte-quota", config.Security.DailyByteQuota, "Response bytes each user or client IP may receive per UTC day (disabled when 0)")
+		redact       = fs.Bool("redact", config.Security.Redaction.Enabled, "Mask secrets in served contents of files matching --redact-files")
+		redactFiles  = fs.String("redact-files", strings.Join(config.Security.Redaction.Files, ","), "Comma-separated glob patterns of files whose secrets are redacted")
+		redactKeys   = fs.String("redact-keys", config.Security.Redaction.Keys, "Regular expression of key names whose values are redacted, e.g. in KEY=value lines")
+		redactRegexp = fs.String("redact-pattern", config.Security.Redaction.Pattern, "Regular expression of text redacted wherever it appears")
+		redactScope  = fs.String("redact-bypass-scope", config.Security.Redaction.BypassScope, "Token scope that is served unredacted content")
+		signingKeys  = fs.String("signing-keys", formatSigningKeys(config.Security.SignedURLs.Keys), "Comma-separated id:secret keys verifying signed URLs (disabled when empty)")
+		signingKID   = fs.String("signing-current-key", config.Security.SignedURLs.CurrentKey, "ID of the key signing new URLs (the first key when empty)")
+		signingSkew  = fs.Duration("signing-clock-skew", config.Security.SignedURLs.ClockSkew, "Clock skew tolerated when checking signed URL expiry")
+		sandbox      = fs.Bool("sandbox", config.Security.Sandbox, "Confine the process with Landlock and seccomp after binding the listener (Linux only)")
+		statsdHost   = fs.String("statsd-host", config.Metrics.StatsDHost, "StatsD or DogStatsD agent host sent request and filesystem error metrics (disabled when empty)")
+		statsdPort   = fs.Int("statsd-port", config.Metrics.StatsDPort, "StatsD agent UDP port")
+		statsdPrefix = fs.String("statsd-prefix", config.Metrics.StatsDPrefix, "Prefix of StatsD metric names")
+		statsdTags   = fs.String("statsd-tags", strings.Join(config.Metrics.StatsDTags, ","), "Comma-separated DogStatsD tags added to every metric, e.g. env:prod,region:eu")
+	)
+
+	return func() {
+		config.Server.Port = *port
+		config.Server.Host = *host
+		config.Server.ReadTimeout = *readTimeout
+		config.Server.WriteTimeout = *writeTimeout
+		config.Server.IdleTimeout = *idleTimeout
+		config.Server.ShutdownTimeout = *shutdownTime
+		config.Server.ShutdownDelay = *shutdownWait
+		config.Server.AdminAddr = *adminAddr
+		config.Server.EnablePprof = *enablePprof
+		config.Server.ReadHeaderTimeout = *readHeader
+		config.Server.KeepAlive = *keepAlive
+		config.Server.MaxConnsPerIP = *maxConnsIP
+		config.Server.EnableH2C = *enableH2C
+		config.Server.MaxConcurrentRequests = *maxInFlight
+		config.Server.MaxHeaderBytes = *maxHeader
+		config.Server.MaxURLLength = *maxURL
+		config.Server.MaxBodyBytes = *maxBody
+		config.Server.Compression = parseCompression(*compression)
+		config.Server.CompressionMinBytes = *compressMin
+		config.Server.WorkerPoolSize = *workerPool
+		config.Server.MemoryLimit = parseMemoryLimit(*memoryLimit)
+		config.Server.GCPercent = *gcPercent
+		config.Server.MemoryRejectPercent = *memReject
+		config.Server.LargeReadBytes = *largeRead
+		config.Security.MaxPathLength = *maxPath
+		config.Server.TLS = TLSConfig{
+			CertFile:         *tlsCert,
+			KeyFile:          *tlsKey,
+			RedirectAddr:     *tlsRedirect,
+			ACMEDomains:      splitList(*acmeDomains),
+			ACMECacheDir:     *acmeCache,
+			ACMEEmail:        *acmeEmail,
+			ACMEDirectoryURL: *acmeURL,
+		}
+
+		config.FileSystem.BaseDirectory = *dir
+		config.FileSystem.MaxFileSize = *maxFileSize
+		config.FileSystem.AllowHidden = *allowHidden
+		config.FileSystem.ReadOnly = *readOnly
+		config.FileSystem.DiskWarningPercent = *diskWarning
+		config.FileSystem.DiskCriticalPercent = *diskCritical
+		config.FileSystem.ContentCacheBytes = *cacheBytes
+		config.FileSystem.ContentCacheEntryBytes = *cacheEntry
+		config.FileSystem.ListingCacheTTL = *listingTTL
+		config.FileSystem.Watch = *watch
+		config.FileSystem.WatchPollInterval = *watchPoll
+		config.FileSystem.IndexMaxEntries = *indexMax
+		config.FileSystem.IndexRefreshInterval = *indexRefresh
+		config.FileSystem.StatTimeout = *statTimeout
+		config.FileSystem.ReadTimeout = *fsRead
+		config.FileSystem.ListTimeout = *listTimeout
+		config.FileSystem.BreakerThreshold = *breakerLimit
+		config.FileSystem.BreakerCooldown = *breakerCool
+		config.FileSystem.Mounts = parseMounts(*mounts, config.FileSystem.Mounts)
+
+		config.Logging.Level = *logLevel
+		config.Logging.Format = *logFormat
+		config.Logging.Outputs = splitList(*logOutput)
+		config.Logging.File = *logFile
+		config.Logging.SyslogFacility = *logFacility
+		config.Logging.OTLPEndpoint = *logOTLP
+		config.Logging.SampleSuccess = *logSample
+		config.Logging.LargeResponseBytes = *logLarge
+		config.Logging.SlowRequests = parseSlowRequests(*logSlow)
+		config.Logging.AuditFile = *auditFile
+		config.Logging.AuditSyslog = *auditSyslog
+
+		config.Metrics = MetricsConfig{
+			StatsDHost:   *statsdHost,
+			StatsDPort:   *statsdPort,
+			StatsDPrefix: *statsdPrefix,
+			StatsDTags:   splitList(*statsdTags),
+		}
+
+		config.Security.EnableCORS = *enableCORS
+		config.Security.EnableRecovery = *recovery
+		config.Security.RequestTimeout = *reqTimeout
+		config.Security.EnableSecurityHeaders = *secHeaders
+		config.Security.Headers = HeadersConfig{
+			ContentSecurityPolicy:     *csp,
+			CrossOriginResourcePolicy: *corp,
+			HSTSMaxAge:                *hstsMaxAge,
+			HSTSIncludeSubdomains:     *hstsSubdoms,
+		}
+		config.Security.AdminToken = *adminToken
+		config.Security.AuthFile = *authFile
+		config.Security.RequestPolicyFile = *policyFile
+		config.Security.JWT = JWTConfig{
+			Secret:        *jwtSecret,
+			PublicKeyFile: *jwtKeyFile,
+			JWKSURL:       *jwtJWKSURL,
+			Issuer:        *jwtIssuer,
+			Audience:      *jwtAudience,
+		}
+		config.Security.OIDC = OIDCConfig{
+			IssuerURL:     *oidcIssuer,
+			ClientID:      *oidcClientID,
+			ClientSecret:  *oidcSecret,
+			RedirectURL:   *oidcRedirect,
+			SessionSecret: *sessionKey,
+			SessionTTL:    *sessionTTL,
+		}
+		config.Security.AllowedCIDRs = splitList(*allowedCIDRs)
+		config.Security.DeniedCIDRs = splitList(*deniedCIDRs)
+		config.Security.TrustedProxies = splitList(*trustedProxy)
+		config.Security.BanThreshold = *banThreshold
+		config.Security.BanWindow = *banWindow
+		config.Security.BanCooldown = *banCooldown
+		config.Security.EnableRateLimit = *rateLimit
+		config.Security.RateLimit = RateLimitConfig{
+			RPS:         *rateRPS,
+			Burst:       *rateBurst,
+			GlobalRPS:   *globalRPS,
+			GlobalBurst: *globalBurst,
+		}
+		config.Security.DailyByteQuota = *byteQuota
+		config.Security.Sandbox = *sandbox
+		config.Security.Redaction = RedactionConfig{
+			Enabled:     *redact,
+			Files:       splitList(*redactFiles),
+			Keys:        *redactKeys,
+			Pattern:     *redactRegexp,
+			BypassScope: *redactScope,
+		}
+		config.Security.SignedURLs = SignedURLConfig{
+			Keys:       parseSigningKeys(*signingKeys),
+			CurrentKey: *signingKID,
+			ClockSkew:  *signingSkew,
+		}
+	}
+}
+
 // LoadFromEnv loads configuration from environment variables
 func (c *Config) LoadFromEnv() error {
+	return c.loadEnv(os.Getenv)
+}
+
+// loadEnv loads configuration from the variables returned by getenv
+func (c *Config) loadEnv(getenv func(string) string) error {
 	// Server configuration
-	if port := os.Getenv("CAT_SERVER_PORT"); port != "" {
+	// PORT and HOST are injected by platforms such as Cloud Run and Heroku;
+	// the CAT_SERVER_ variables take precedence when both are set
+	if port := getenv("PORT"); port != "" {
+		c.Server.Port = port
+	}
+	if port := getenv("CAT_SERVER_PORT"); port != "" {
 		c.Server.Port = port
 	}
 
-	if host := os.Getenv("CAT_SERVER_HOST"); host != "" {
+	if host := getenv("HOST"); host != "" {
+		c.Server.Host = host
+	}
+	if host := getenv("CAT_SERVER_HOST"); host != "" {
 		c.Server.Host = host
 	}
 
+	if cert := getenv("CAT_SERVER_TLS_CERT"); cert != "" {
+		c.Server.TLS.CertFile = cert
+	}
+
+	if key := getenv("CAT_SERVER_TLS_KEY"); key != "" {
+		c.Server.TLS.KeyFile = key
+	}
+
+	if addr := getenv("CAT_SERVER_ADMIN_ADDR"); addr != "" {
+		c.Server.AdminAddr = addr
+	}
+
+	if pprofStr := getenv("CAT_SERVER_ENABLE_PPROF"); pprofStr != "" {
+		enablePprof, err := strconv.ParseBool(pprofStr)
+		if err != nil {
+			return fmt.Errorf("invalid CAT_SERVER_ENABLE_PPROF: %w", err)
+		}
+		c.Server.EnablePprof = enablePprof
+	}
+
+	if addr := getenv("CAT_SERVER_TLS_REDIRECT_ADDR"); addr != "" {
+		c.Server.TLS.RedirectAddr = addr
+	}
+
+	if domains := getenv("CAT_SERVER_ACME_DOMAIN"); domains != "" {
+		c.Server.TLS.ACMEDomains = splitList(domains)
+	}
+
+	if dir := getenv("CAT_SERVER_ACME_CACHE_DIR"); dir != "" {
+		c.Server.TLS.ACMECacheDir = dir
+	}
+
+	if email := getenv("CAT_SERVER_ACME_EMAIL"); email != "" {
+		c.Server.TLS.ACMEEmail = email
+	}
+
+	if maxStr := getenv("CAT_SERVER_MAX_CONCURRENT_REQUESTS"); maxStr != "" {
+		maxInFlight, err := strconv.Atoi(maxStr)
+		if err != nil {
+			return fmt.Errorf("invalid CAT_SERVER_MAX_CONCURRENT_REQUESTS: %w", err)
+		}
+		c.Server.MaxConcurrentRequests = maxInFlight
+	}
+
+	if timeoutStr := getenv("CAT_SERVER_READ_HEADER_TIMEOUT"); timeoutStr != "" {
+		timeout, err := time.ParseDuration(timeoutStr)
+		if err != nil {
+			return fmt.Errorf("invalid CAT_SERVER_READ_HEADER_TIMEOUT: %w", err)
+		}
+		c.Server.ReadHeaderTimeout = timeout
+	}
+
+	if keepAliveStr := getenv("CAT_SERVER_TCP_KEEP_ALIVE"); keepAliveStr != "" {
+		keepAlive, err := time.ParseDuration(keepAliveStr)
+		if err != nil {
+			return fmt.Errorf("invalid CAT_SERVER_TCP_KEEP_ALIVE: %w", err)
+		}
+		c.Server.KeepAlive = keepAlive
+	}
+
+	if maxStr := getenv("CAT_SERVER_MAX_CONNS_PER_IP"); maxStr != "" {
+		maxConns, err := strconv.Atoi(maxStr)
+		if err != nil {
+			return fmt.Errorf("invalid CAT_SERVER_MAX_CONNS_PER_IP: %w", err)
+		}
+		c.Server.MaxConnsPerIP = maxConns
+	}
+
+	if h2cStr := getenv("CAT_SERVER_ENABLE_H2C"); h2cStr != "" {
+		enableH2C, err := strconv.ParseBool(h2cStr)
+		if err != nil {
+			return fmt.Errorf("invalid CAT_SERVER_ENABLE_H2C: %w", err)
+		}
+		c.Server.EnableH2C = enableH2C
+	}
+
+	if maxStr := getenv("CAT_SERVER_MAX_HEADER_BYTES"); maxStr != "" {
+		maxHeader, err := strconv.Atoi(maxStr)
+		if err != nil {
+			return fmt.Errorf("invalid CAT_SERVER_MAX_HEADER_BYTES: %w", err)
+		}
+		c.Server.MaxHeaderBytes = maxHeader
+	}
+
+	if maxStr := getenv("CAT_SERVER_MAX_URL_LENGTH"); maxStr != "" {
+		maxURL, err := strconv.Atoi(maxStr)
+		if err != nil {
+			return fmt.Errorf("invalid CAT_SERVER_MAX_URL_LENGTH: %w", err)
+		}
+		c.Server.MaxURLLength = maxURL
+	}
+
+	if maxStr := getenv("CAT_SERVER_MAX_BODY_BYTES"); maxStr != "" {
+		maxBody, err := strconv.ParseInt(maxStr, 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid CAT_SERVER_MAX_BODY_BYTES: %w", err)
+		}
+		c.Server.MaxBodyBytes = maxBody
+	}
+
+	if encodings := getenv("CAT_SERVER_COMPRESSION"); encodings != "" {
+		c.Server.Compression = parseCompression(encodings)
+	}
+
+	if minStr := getenv("CAT_SERVER_COMPRESSION_MIN_BYTES"); minStr != "" {
+		compressMin, err := strconv.Atoi(minStr)
+		if err != nil {
+			return fmt.Errorf("invalid CAT_SERVER_COMPRESSION_MIN_BYTES: %w", err)
+		}
+		c.Server.CompressionMinBytes = compressMin
+	}
+
+	if sizeStr := getenv("CAT_SERVER_WORKER_POOL_SIZE"); sizeStr != "" {
+		size, err := strconv.Atoi(sizeStr)
+		if err != nil {
+			return fmt.Errorf("invalid CAT_SERVER_WORKER_POOL_SIZE: %w", err)
+		}
+		c.Server.WorkerPoolSize = size
+	}
+
+	if limit := getenv("CAT_SERVER_MEMORY_LIMIT"); limit != "" {
+		c.Server.MemoryLimit = parseMemoryLimit(limit)
+	}
+
+	if percentStr := getenv("CAT_SERVER_GC_PERCENT"); percentStr != "" {
+		percent, err := strconv.Atoi(percentStr)
+		if err != nil {
+			return fmt.Errorf("invalid CAT_SERVER_GC_PERCENT: %w", err)
+		}
+		c.Server.GCPercent = percent
+	}
+
+	if percentStr := getenv("CAT_SERVER_MEMORY_REJECT_PERCENT"); percentStr != "" {
+		percent, err := strconv.ParseFloat(percentStr, 64)
+		if err != nil {
+			return fmt.Errorf("invalid CAT_SERVER_MEMORY_REJECT_PERCENT: %w", err)
+		}
+		c.Server.MemoryRejectPercent = percent
+	}
+
+	if bytesStr := getenv("CAT_SERVER_LARGE_READ_BYTES"); bytesStr != "" {
+		largeRead, err := strconv.ParseInt(bytesStr, 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid CAT_SERVER_LARGE_READ_BYTES: %w", err)
+		}
+		c.Server.LargeReadBytes = largeRead
+	}
+
 	// FileSystem configuration
-	if dir := os.Getenv("CAT_SERVER_DIR"); dir != "" {
+	if dir := getenv("CAT_SERVER_DIR"); dir != "" {
 		c.FileSystem.BaseDirectory = dir
 	}
 
-	if maxSizeStr := os.Getenv("CAT_SERVER_MAX_FILE_SIZE"); maxSizeStr != "" {
+	if maxSizeStr := getenv("CAT_SERVER_MAX_FILE_SIZE"); maxSizeStr != "" {
 		maxSize, err := strconv.ParseInt(maxSizeStr, 10, 64)
 		if err != nil {
 			return fmt.Errorf("invalid CAT_SERVER_MAX_FILE_SIZE: %w", err)
@@ -148,7 +947,7 @@ func (c *Config) LoadFromEnv() error {
 		c.FileSystem.MaxFileSize = maxSize
 	}
 
-	if allowHiddenStr := os.Getenv("CAT_SERVER_ALLOW_HIDDEN"); allowHiddenStr != "" {
+	if allowHiddenStr := getenv("CAT_SERVER_ALLOW_HIDDEN"); allowHiddenStr != "" {
 		allowHidden, err := strconv.ParseBool(allowHiddenStr)
 		if err != nil {
 			return fmt.Errorf("invalid CAT_SERVER_ALLOW_HIDDEN: %w", err)
@@ -156,17 +955,181 @@ func (c *Config) LoadFromEnv() error {
 		c.FileSystem.AllowHidden = allowHidden
 	}
 
+	if mounts := getenv("CAT_SERVER_MOUNTS"); mounts != "" {
+		c.FileSystem.Mounts = parseMounts(mounts, c.FileSystem.Mounts)
+	}
+
+	if readOnlyStr := getenv("CAT_SERVER_READ_ONLY"); readOnlyStr != "" {
+		readOnly, err := strconv.ParseBool(readOnlyStr)
+		if err != nil {
+			return fmt.Errorf("invalid CAT_SERVER_READ_ONLY: %w", err)
+		}
+		c.FileSystem.ReadOnly = readOnly
+	}
+
+	if warningStr := getenv("CAT_SERVER_DISK_WARNING_PERCENT"); warningStr != "" {
+		warning, err := strconv.ParseFloat(warningStr, 64)
+		if err != nil {
+			return fmt.Errorf("invalid CAT_SERVER_DISK_WARNING_PERCENT: %w", err)
+		}
+		c.FileSystem.DiskWarningPercent = warning
+	}
+
+	if criticalStr := getenv("CAT_SERVER_DISK_CRITICAL_PERCENT"); criticalStr != "" {
+		critical, err := strconv.ParseFloat(criticalStr, 64)
+		if err != nil {
+			return fmt.Errorf("invalid CAT_SERVER_DISK_CRITICAL_PERCENT: %w", err)
+		}
+		c.FileSystem.DiskCriticalPercent = critical
+	}
+
+	if cacheStr := getenv("CAT_SERVER_CONTENT_CACHE_BYTES"); cacheStr != "" {
+		cacheBytes, err := strconv.ParseInt(cacheStr, 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid CAT_SERVER_CONTENT_CACHE_BYTES: %w", err)
+		}
+		c.FileSystem.ContentCacheBytes = cacheBytes
+	}
+
+	if entryStr := getenv("CAT_SERVER_CONTENT_CACHE_ENTRY_BYTES"); entryStr != "" {
+		entryBytes, err := strconv.ParseInt(entryStr, 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid CAT_SERVER_CONTENT_CACHE_ENTRY_BYTES: %w", err)
+		}
+		c.FileSystem.ContentCacheEntryBytes = entryBytes
+	}
+
+	if ttlStr := getenv("CAT_SERVER_LISTING_CACHE_TTL"); ttlStr != "" {
+		ttl, err := time.ParseDuration(ttlStr)
+		if err != nil {
+			return fmt.Errorf("invalid CAT_SERVER_LISTING_CACHE_TTL: %w", err)
+		}
+		c.FileSystem.ListingCacheTTL = ttl
+	}
+
+	if watch := getenv("CAT_SERVER_WATCH"); watch != "" {
+		c.FileSystem.Watch = watch
+	}
+
+	if intervalStr := getenv("CAT_SERVER_WATCH_POLL_INTERVAL"); intervalStr != "" {
+		interval, err := time.ParseDuration(intervalStr)
+		if err != nil {
+			return fmt.Errorf("invalid CAT_SERVER_WATCH_POLL_INTERVAL: %w", err)
+		}
+		c.FileSystem.WatchPollInterval = interval
+	}
+
+	if maxStr := getenv("CAT_SERVER_INDEX_MAX_ENTRIES"); maxStr != "" {
+		maxEntries, err := strconv.Atoi(maxStr)
+		if err != nil {
+			return fmt.Errorf("invalid CAT_SERVER_INDEX_MAX_ENTRIES: %w", err)
+		}
+		c.FileSystem.IndexMaxEntries = maxEntries
+	}
+
+	if intervalStr := getenv("CAT_SERVER_INDEX_REFRESH_INTERVAL"); intervalStr != "" {
+		interval, err := time.ParseDuration(intervalStr)
+		if err != nil {
+			return fmt.Errorf("invalid CAT_SERVER_INDEX_REFRESH_INTERVAL: %w", err)
+		}
+		c.FileSystem.IndexRefreshInterval = interval
+	}
+
+	if timeoutStr := getenv("CAT_SERVER_FS_STAT_TIMEOUT"); timeoutStr != "" {
+		timeout, err := time.ParseDuration(timeoutStr)
+		if err != nil {
+			return fmt.Errorf("invalid CAT_SERVER_FS_STAT_TIMEOUT: %w", err)
+		}
+		c.FileSystem.StatTimeout = timeout
+	}
+
+	if timeoutStr := getenv("CAT_SERVER_FS_READ_TIMEOUT"); timeoutStr != "" {
+		timeout, err := time.ParseDuration(timeoutStr)
+		if err != nil {
+			return fmt.Errorf("invalid CAT_SERVER_FS_READ_TIMEOUT: %w", err)
+		}
+		c.FileSystem.ReadTimeout = timeout
+	}
+
+	if timeoutStr := getenv("CAT_SERVER_FS_LIST_TIMEOUT"); timeoutStr != "" {
+		timeout, err := time.ParseDuration(timeoutStr)
+		if err != nil {
+			return fmt.Errorf("invalid CAT_SERVER_FS_LIST_TIMEOUT: %w", err)
+		}
+		c.FileSystem.ListTimeout = timeout
+	}
+
+	if cooldownStr := getenv("CAT_SERVER_FS_BREAKER_COOLDOWN"); cooldownStr != "" {
+		cooldown, err := time.ParseDuration(cooldownStr)
+		if err != nil {
+			return fmt.Errorf("invalid CAT_SERVER_FS_BREAKER_COOLDOWN: %w", err)
+		}
+		c.FileSystem.BreakerCooldown = cooldown
+	}
+
+	if thresholdStr := getenv("CAT_SERVER_FS_BREAKER_THRESHOLD"); thresholdStr != "" {
+		threshold, err := strconv.Atoi(thresholdStr)
+		if err != nil {
+			return fmt.Errorf("invalid CAT_SERVER_FS_BREAKER_THRESHOLD: %w", err)
+		}
+		c.FileSystem.BreakerThreshold = threshold
+	}
+
 	// Logging configuration
-	if level := os.Getenv("CAT_SERVER_LOG_LEVEL"); level != "" {
+	if level := getenv("CAT_SERVER_LOG_LEVEL"); level != "" {
 		c.Logging.Level = level
 	}
 
-	if format := os.Getenv("CAT_SERVER_LOG_FORMAT"); format != "" {
+	if format := getenv("CAT_SERVER_LOG_FORMAT"); format != "" {
 		c.Logging.Format = format
 	}
 
+	if outputs := getenv("CAT_SERVER_LOG_OUTPUT"); outputs != "" {
+		c.Logging.Outputs = splitList(outputs)
+	}
+
+	if file := getenv("CAT_SERVER_LOG_FILE"); file != "" {
+		c.Logging.File = file
+	}
+
+	if facility := getenv("CAT_SERVER_LOG_SYSLOG_FACILITY"); facility != "" {
+		c.Logging.SyslogFacility = facility
+	}
+
+	if endpoint := getenv("CAT_SERVER_LOG_OTLP_ENDPOINT"); endpoint != "" {
+		c.Logging.OTLPEndpoint = endpoint
+	}
+
+	if sampleStr := getenv("CAT_SERVER_LOG_SAMPLE_SUCCESS"); sampleStr != "" {
+		sample, err := strconv.Atoi(sampleStr)
+		if err != nil {
+			return fmt.Errorf("invalid CAT_SERVER_LOG_SAMPLE_SUCCESS: %w", err)
+		}
+		c.Logging.SampleSuccess = sample
+	}
+
+	if largeStr := getenv("CAT_SERVER_LOG_LARGE_RESPONSE_BYTES"); largeStr != "" {
+		large, err := strconv.ParseInt(largeStr, 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid CAT_SERVER_LOG_LARGE_RESPONSE_BYTES: %w", err)
+		}
+		c.Logging.LargeResponseBytes = large
+	}
+
+	if slow := getenv("CAT_SERVER_LOG_SLOW_REQUESTS"); slow != "" {
+		c.Logging.SlowRequests = parseSlowRequests(slow)
+	}
+
+	if auditFile := getenv("CAT_SERVER_AUDIT_LOG"); auditFile != "" {
+		c.Logging.AuditFile = auditFile
+	}
+
+	if facility := getenv("CAT_SERVER_AUDIT_SYSLOG"); facility != "" {
+		c.Logging.AuditSyslog = facility
+	}
+
 	// Security configuration
-	if corsStr := os.Getenv("CAT_SERVER_ENABLE_CORS"); corsStr != "" {
+	if corsStr := getenv("CAT_SERVER_ENABLE_CORS"); corsStr != "" {
 		enableCORS, err := strconv.ParseBool(corsStr)
 		if err != nil {
 			return fmt.Errorf("invalid CAT_SERVER_ENABLE_CORS: %w", err)
@@ -174,9 +1137,276 @@ func (c *Config) LoadFromEnv() error {
 		c.Security.EnableCORS = enableCORS
 	}
 
+	if recoveryStr := getenv("CAT_SERVER_ENABLE_RECOVERY"); recoveryStr != "" {
+		enableRecovery, err := strconv.ParseBool(recoveryStr)
+		if err != nil {
+			return fmt.Errorf("invalid CAT_SERVER_ENABLE_RECOVERY: %w", err)
+		}
+		c.Security.EnableRecovery = enableRecovery
+	}
+
+	if timeoutStr := getenv("CAT_SERVER_SHUTDOWN_TIMEOUT"); timeoutStr != "" {
+		timeout, err := time.ParseDuration(timeoutStr)
+		if err != nil {
+			return fmt.Errorf("invalid CAT_SERVER_SHUTDOWN_TIMEOUT: %w", err)
+		}
+		c.Server.ShutdownTimeout = timeout
+	}
+
+	if delayStr := getenv("CAT_SERVER_SHUTDOWN_DELAY"); delayStr != "" {
+		delay, err := time.ParseDuration(delayStr)
+		if err != nil {
+			return fmt.Errorf("invalid CAT_SERVER_SHUTDOWN_DELAY: %w", err)
+		}
+		c.Server.ShutdownDelay = delay
+	}
+
+	if timeoutStr := getenv("CAT_SERVER_REQUEST_TIMEOUT"); timeoutStr != "" {
+		timeout, err := time.ParseDuration(timeoutStr)
+		if err != nil {
+			return fmt.Errorf("invalid CAT_SERVER_REQUEST_TIMEOUT: %w", err)
+		}
+		c.Security.RequestTimeout = timeout
+	}
+
+	if headersStr := getenv("CAT_SERVER_ENABLE_SECURITY_HEADERS"); headersStr != "" {
+		enableHeaders, err := strconv.ParseBool(headersStr)
+		if err != nil {
+			return fmt.Errorf("invalid CAT_SERVER_ENABLE_SECURITY_HEADERS: %w", err)
+		}
+		c.Security.EnableSecurityHeaders = enableHeaders
+	}
+
+	if csp := getenv("CAT_SERVER_CONTENT_SECURITY_POLICY"); csp != "" {
+		c.Security.Headers.ContentSecurityPolicy = csp
+	}
+
+	if token := getenv("CAT_SERVER_ADMIN_TOKEN"); token != "" {
+		c.Security.AdminToken = token
+	}
+
+	if authFile := getenv("CAT_SERVER_AUTH_FILE"); authFile != "" {
+		c.Security.AuthFile = authFile
+	}
+
+	if policyFile := getenv("CAT_SERVER_REQUEST_POLICY"); policyFile != "" {
+		c.Security.RequestPolicyFile = policyFile
+	}
+
+	if secret := getenv("CAT_SERVER_JWT_SECRET"); secret != "" {
+		c.Security.JWT.Secret = secret
+	}
+
+	if jwksURL := getenv("CAT_SERVER_JWT_JWKS_URL"); jwksURL != "" {
+		c.Security.JWT.JWKSURL = jwksURL
+	}
+
+	if secret := getenv("CAT_SERVER_OIDC_CLIENT_SECRET"); secret != "" {
+		c.Security.OIDC.ClientSecret = secret
+	}
+
+	if secret := getenv("CAT_SERVER_SESSION_SECRET"); secret != "" {
+		c.Security.OIDC.SessionSecret = secret
+	}
+
+	if keys := getenv("CAT_SERVER_SIGNING_KEYS"); keys != "" {
+		c.Security.SignedURLs.Keys = parseSigningKeys(keys)
+	}
+
+	if rateLimitStr := getenv("CAT_SERVER_ENABLE_RATE_LIMIT"); rateLimitStr != "" {
+		enableRateLimit, err := strconv.ParseBool(rateLimitStr)
+		if err != nil {
+			return fmt.Errorf("invalid CAT_SERVER_ENABLE_RATE_LIMIT: %w", err)
+		}
+		c.Security.EnableRateLimit = enableRateLimit
+	}
+
+	if cidrs := getenv("CAT_SERVER_ALLOWED_CIDRS"); cidrs != "" {
+		c.Security.AllowedCIDRs = splitList(cidrs)
+	}
+
+	if cidrs := getenv("CAT_SERVER_DENIED_CIDRS"); cidrs != "" {
+		c.Security.DeniedCIDRs = splitList(cidrs)
+	}
+
+	if proxies := getenv("CAT_SERVER_TRUSTED_PROXIES"); proxies != "" {
+		c.Security.TrustedProxies = splitList(proxies)
+	}
+
+	if quotaStr := getenv("CAT_SERVER_DAILY_BYTE_QUOTA"); quotaStr != "" {
+		quota, err := strconv.ParseInt(quotaStr, 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid CAT_SERVER_DAILY_BYTE_QUOTA: %w", err)
+		}
+		c.Security.DailyByteQuota = quota
+	}
+
+	if redactStr := getenv("CAT_SERVER_REDACT"); redactStr != "" {
+		redact, err := strconv.ParseBool(redactStr)
+		if err != nil {
+			return fmt.Errorf("invalid CAT_SERVER_REDACT: %w", err)
+		}
+		c.Security.Redaction.Enabled = redact
+	}
+
+	if files := getenv("CAT_SERVER_REDACT_FILES"); files != "" {
+		c.Security.Redaction.Files = splitList(files)
+	}
+
+	if sandboxStr := getenv("CAT_SERVER_SANDBOX"); sandboxStr != "" {
+		sandbox, err := strconv.ParseBool(sandboxStr)
+		if err != nil {
+			return fmt.Errorf("invalid CAT_SERVER_SANDBOX: %w", err)
+		}
+		c.Security.Sandbox = sandbox
+	}
+
+	if host := getenv("CAT_SERVER_STATSD_HOST"); host != "" {
+		c.Metrics.StatsDHost = host
+	}
+
+	if portStr := getenv("CAT_SERVER_STATSD_PORT"); portStr != "" {
+		port, err := strconv.Atoi(portStr)
+		if err != nil {
+			return fmt.Errorf("invalid CAT_SERVER_STATSD_PORT: %w", err)
+		}
+		c.Metrics.StatsDPort = port
+	}
+
+	if prefix := getenv("CAT_SERVER_STATSD_PREFIX"); prefix != "" {
+		c.Metrics.StatsDPrefix = prefix
+	}
+
+	if tags := getenv("CAT_SERVER_STATSD_TAGS"); tags != "" {
+		c.Metrics.StatsDTags = splitList(tags)
+	}
+
 	return nil
 }
 
+// splitList splits a comma-separated flag value, dropping empty entries
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// parseCompression parses a comma-separated list of content encodings,
+// where off disables compression
+func parseCompression(value string) []string {
+	if value == "off" {
+		return nil
+	}
+	return splitList(value)
+}
+
+// memoryLimitUnits are the suffixes of a memory limit, as for GOMEMLIMIT
+var memoryLimitUnits = []struct {
+	suffix     string
+	multiplier int64
+}{
+	{"TiB", 1 << 40},
+	{"GiB", 1 << 30},
+	{"MiB", 1 << 20},
+	{"KiB", 1 << 10},
+	{"B", 1},
+}
+
+// parseMemoryLimit parses a byte count with an optional B, KiB, MiB, GiB
+// or TiB suffix, e.g. 512MiB. Invalid limits are kept as -1 for Validate
+// to report.
+func parseMemoryLimit(value string) int64 {
+	value = strings.TrimSpace(value)
+	multiplier := int64(1)
+	for _, unit := range memoryLimitUnits {
+		if number, ok := strings.CutSuffix(value, unit.suffix); ok {
+			value, multiplier = number, unit.multiplier
+			break
+		}
+	}
+	n, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || n < 0 || n > math.MaxInt64/multiplier {
+		return -1
+	}
+	return n * multiplier
+}
+
+// parseMounts parses a comma-separated list of name=path mounts. Entries
+// without a path are kept for Validate to report. Mounts already in current
+// keep their size limit and hidden-file policy when their path is unchanged.
+func parseMounts(value string, current []MountConfig) []MountConfig {
+	var mounts []MountConfig
+	for _, item := range splitList(value) {
+		name, dir, _ := strings.Cut(item, "=")
+		mount := MountConfig{Name: strings.TrimSpace(name), Path: strings.TrimSpace(dir)}
+		for _, existing := range current {
+			if existing.Name == mount.Name && existing.Path == mount.Path {
+				mount = existing
+			}
+		}
+		mounts = append(mounts, mount)
+	}
+	return mounts
+}
+
+// formatMounts formats mounts as parsed by parseMounts
+func formatMounts(mounts []MountConfig) string {
+	items := make([]string, len(mounts))
+	for i, mount := range mounts {
+		items[i] = mount.Name + "=" + mount.Path
+	}
+	return strings.Join(items, ",")
+}
+
+// parseSigningKeys parses a comma-separated list of id:secret signing keys.
+// Keys without a secret are kept for Validate to report.
+func parseSigningKeys(value string) []SigningKeyConfig {
+	var keys []SigningKeyConfig
+	for _, item := range splitList(value) {
+		id, secret, _ := strings.Cut(item, ":")
+		keys = append(keys, SigningKeyConfig{ID: strings.TrimSpace(id), Secret: strings.TrimSpace(secret)})
+	}
+	return keys
+}
+
+// formatSigningKeys formats keys as parsed by parseSigningKeys
+func formatSigningKeys(keys []SigningKeyConfig) string {
+	items := make([]string, len(keys))
+	for i, key := range keys {
+		items[i] = key.ID + ":" + key.Secret
+	}
+	return strings.Join(items, ",")
+}
+
+// parseSlowRequests parses a comma-separated list of route=latency
+// thresholds, e.g. /ls=100ms. Thresholds that are not durations are kept
+// as -1 for Validate to report.
+func parseSlowRequests(value string) map[string]time.Duration {
+	thresholds := make(map[string]time.Duration)
+	for _, item := range splitList(value) {
+		route, latency, _ := strings.Cut(item, "=")
+		threshold, err := time.ParseDuration(strings.TrimSpace(latency))
+		if err != nil {
+			threshold = -1
+		}
+		thresholds[strings.TrimSpace(route)] = threshold
+	}
+	return thresholds
+}
+
+// formatSlowRequests formats thresholds as parsed by parseSlowRequests
+func formatSlowRequests(thresholds map[string]time.Duration) string {
+	items := make([]string, 0, len(thresholds))
+	for _, route := range slices.Sorted(maps.Keys(thresholds)) {
+		items = append(items, route+"="+thresholds[route].String())
+	}
+	return strings.Join(items, ",")
+}
+
 // Validate validates the configuration
 func (c *Config) Validate() error {
 	// Validate server configuration
@@ -188,6 +1418,13 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("invalid server port: %w", err)
 	}
 
+	addrs := c.GetServerAddrs()
+	for i, addr := range addrs {
+		if slices.Contains(addrs[:i], addr) {
+			return fmt.Errorf("server address %s is listed twice", addr)
+		}
+	}
+
 	if c.Server.ReadTimeout <= 0 {
 		return fmt.Errorf("read timeout must be positive")
 	}
@@ -200,6 +1437,108 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("idle timeout must be positive")
 	}
 
+	if c.Server.ShutdownTimeout <= 0 {
+		return fmt.Errorf("shutdown timeout must be positive")
+	}
+
+	if c.Server.ShutdownDelay < 0 {
+		return fmt.Errorf("shutdown delay cannot be negative")
+	}
+
+	if c.Server.ReadHeaderTimeout < 0 {
+		return fmt.Errorf("read header timeout cannot be negative")
+	}
+
+	if c.Server.MaxConnsPerIP < 0 {
+		return fmt.Errorf("max connections per ip cannot be negative")
+	}
+
+	if c.Server.EnableH2C && c.Server.TLS.Enabled() {
+		return fmt.Errorf("h2c cannot be combined with tls, which negotiates http/2 itself")
+	}
+
+	if c.Server.MaxConcurrentRequests < 0 {
+		return fmt.Errorf("max concurrent requests cannot be negative")
+	}
+
+	if c.Server.MaxHeaderBytes <= 0 {
+		return fmt.Errorf("max header bytes must be positive")
+	}
+
+	if c.Server.MaxURLLength <= 0 {
+		return fmt.Errorf("max url length must be positive")
+	}
+
+	if c.Server.MaxBodyBytes <= 0 {
+		return fmt.Errorf("max body bytes must be positive")
+	}
+
+	seenEncodings := make(map[string]bool, len(c.Server.Compression))
+	for _, encoding := range c.Server.Compression {
+		switch encoding {
+		case "gzip", "deflate", "br", "zstd":
+		default:
+			return fmt.Errorf("invalid compression encoding: %q (must be gzip, deflate, br or zstd)", encoding)
+		}
+		if seenEncodings[encoding] {
+			return fmt.Errorf("compression encoding %s listed twice", encoding)
+		}
+		seenEncodings[encoding] = true
+	}
+
+	if c.Server.CompressionMinBytes < 0 {
+		return fmt.Errorf("compression min bytes cannot be negative")
+	}
+
+	if c.Server.WorkerPoolSize < 0 {
+		return fmt.Errorf("worker pool size cannot be negative")
+	}
+
+	if c.Server.MemoryLimit < 0 {
+		return fmt.Errorf("invalid memory limit (must be a byte count with an optional KiB, MiB, GiB or TiB suffix)")
+	}
+	if c.Server.GCPercent < -1 {
+		return fmt.Errorf("gc percent cannot be below -1")
+	}
+	if c.Server.MemoryRejectPercent < 0 || c.Server.MemoryRejectPercent > 100 {
+		return fmt.Errorf("memory reject percent must be between 0 and 100")
+	}
+	if c.Server.LargeReadBytes < 0 {
+		return fmt.Errorf("large read bytes cannot be negative")
+	}
+
+	if tls := c.Server.TLS; tls.ACMEEnabled() {
+		if tls.CertFile != "" || tls.KeyFile != "" {
+			return fmt.Errorf("acme domains and tls certificate files cannot be combined")
+		}
+		if tls.ACMECacheDir == "" || tls.ACMEDirectoryURL == "" {
+			return fmt.Errorf("acme requires a cache directory and directory url")
+		}
+	} else if tls.Enabled() {
+		if tls.CertFile == "" || tls.KeyFile == "" {
+			return fmt.Errorf("tls requires both a certificate and a key file")
+		}
+		for _, file := range []string{tls.CertFile, tls.KeyFile} {
+			if _, err := os.Stat(file); err != nil {
+				return fmt.Errorf("cannot access tls file: %w", err)
+			}
+		}
+	} else if tls.RedirectAddr != "" {
+		return fmt.Errorf("tls redirect listener requires tls to be enabled")
+	}
+
+	if c.Server.AdminAddr != "" {
+		if _, _, err := net.SplitHostPort(c.Server.AdminAddr); err != nil {
+			return fmt.Errorf("invalid admin address: %w", err)
+		}
+		if slices.Contains(c.GetServerAddrs(), c.Server.AdminAddr) || c.Server.AdminAddr == c.Server.TLS.RedirectAddr {
+			return fmt.Errorf("admin address must differ from the other listeners")
+		}
+	} else if c.Server.EnablePprof {
+		// Profiles expose internals and cost CPU, so keep them off the public listener
+		return fmt.Errorf("pprof requires a separate admin listener")
+	}
+
 	// Validate filesystem configuration
 	if c.FileSystem.BaseDirectory == "" {
 		return fmt.Errorf("base directory cannot be empty")
@@ -209,6 +1548,51 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("max file size must be positive")
 	}
 
+	warning, critical := c.FileSystem.DiskWarningPercent, c.FileSystem.DiskCriticalPercent
+	if warning < 0 || warning > 100 || critical < 0 || critical > 100 {
+		return fmt.Errorf("disk warning and critical percent must be between 0 and 100")
+	}
+	if warning > 0 && critical > 0 && warning > critical {
+		return fmt.Errorf("disk warning percent cannot exceed the critical percent")
+	}
+
+	if c.FileSystem.ContentCacheBytes < 0 || c.FileSystem.ContentCacheEntryBytes < 0 {
+		return fmt.Errorf("content cache sizes cannot be negative")
+	}
+	if c.FileSystem.ContentCacheBytes > 0 && c.FileSystem.ContentCacheEntryBytes == 0 {
+		return fmt.Errorf("content cache entry bytes must be positive when the content cache is enabled")
+	}
+	if c.FileSystem.ListingCacheTTL < 0 {
+		return fmt.Errorf("listing cache ttl cannot be negative")
+	}
+	switch c.FileSystem.Watch {
+	case "off", "inotify":
+	case "auto", "poll":
+		if c.FileSystem.WatchPollInterval <= 0 {
+			return fmt.Errorf("watch poll interval must be positive")
+		}
+	default:
+		return fmt.Errorf("invalid watch mode: %q (must be auto, inotify, poll or off)", c.FileSystem.Watch)
+	}
+	if c.FileSystem.IndexMaxEntries < 0 {
+		return fmt.Errorf("index max entries cannot be negative")
+	}
+	if c.FileSystem.IndexRefreshInterval < 0 {
+		return fmt.Errorf("index refresh interval cannot be negative")
+	}
+	if c.FileSystem.IndexMaxEntries > 0 && c.FileSystem.Watch == "off" && c.FileSystem.IndexRefreshInterval == 0 {
+		return fmt.Errorf("the directory index needs watching for changes or a refresh interval")
+	}
+	if c.FileSystem.StatTimeout < 0 || c.FileSystem.ReadTimeout < 0 || c.FileSystem.ListTimeout < 0 {
+		return fmt.Errorf("filesystem timeouts cannot be negative")
+	}
+	if c.FileSystem.BreakerThreshold < 0 {
+		return fmt.Errorf("filesystem breaker threshold cannot be negative")
+	}
+	if c.FileSystem.BreakerThreshold > 0 && c.FileSystem.BreakerCooldown <= 0 {
+		return fmt.Errorf("filesystem breaker cooldown must be positive when the breaker is enabled")
+	}
+
 	// Check if base directory exists
 	if info, err := os.Stat(c.FileSystem.BaseDirectory); err != nil {
 		if os.IsNotExist(err) {
@@ -219,6 +1603,46 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("base directory is not a directory: %s", c.FileSystem.BaseDirectory)
 	}
 
+	seenMounts := make(map[string]bool, len(c.FileSystem.Mounts))
+	for _, mount := range c.FileSystem.Mounts {
+		if !validMountName.MatchString(mount.Name) {
+			return fmt.Errorf("invalid mount name: %q", mount.Name)
+		}
+		if seenMounts[mount.Name] {
+			return fmt.Errorf("duplicate mount name: %s", mount.Name)
+		}
+		seenMounts[mount.Name] = true
+		if mount.Path == "" {
+			return fmt.Errorf("mount %s: path cannot be empty", mount.Name)
+		}
+		if mount.MaxFileSize < 0 {
+			return fmt.Errorf("mount %s: max file size cannot be negative", mount.Name)
+		}
+		if info, err := os.Stat(mount.Path); err != nil {
+			return fmt.Errorf("mount %s: cannot access directory: %w", mount.Name, err)
+		} else if !info.IsDir() {
+			return fmt.Errorf("mount %s: not a directory: %s", mount.Name, mount.Path)
+		}
+	}
+
+	seenPrefixes := make(map[string]bool, len(c.FileSystem.Overrides))
+	for _, override := range c.FileSystem.Overrides {
+		if override.Prefix == "" {
+			return fmt.Errorf("override prefix cannot be empty")
+		}
+		if slices.Contains(strings.Split(override.Prefix, "/"), "..") {
+			return fmt.Errorf("invalid override prefix: %s", override.Prefix)
+		}
+		prefix := path.Clean("/" + override.Prefix)
+		if seenPrefixes[prefix] {
+			return fmt.Errorf("duplicate override prefix: %s", override.Prefix)
+		}
+		seenPrefixes[prefix] = true
+		if override.MaxFileSize < 0 {
+			return fmt.Errorf("override %s: max file size cannot be negative", override.Prefix)
+		}
+	}
+
 	// Validate logging configuration
 	validLogLevels := map[string]bool{
 		"debug": true,
@@ -238,20 +1662,183 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("invalid log format: %s", c.Logging.Format)
 	}
 
+	if len(c.Logging.Outputs) == 0 {
+		return fmt.Errorf("at least one log output is required")
+	}
+	seenOutputs := make(map[string]bool, len(c.Logging.Outputs))
+	for _, output := range c.Logging.Outputs {
+		switch output {
+		case "stdout", "stderr", "syslog", "journald":
+		case "file":
+			if c.Logging.File == "" {
+				return fmt.Errorf("file log output requires a log file")
+			}
+		case "otlp":
+			if u, err := url.Parse(c.Logging.OTLPEndpoint); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+				return fmt.Errorf("otlp log output requires an http or https endpoint, got %q", c.Logging.OTLPEndpoint)
+			}
+		default:
+			return fmt.Errorf("invalid log output: %s", output)
+		}
+		if seenOutputs[output] {
+			return fmt.Errorf("log output %s listed twice", output)
+		}
+		seenOutputs[output] = true
+	}
+
+	if c.Logging.SampleSuccess < 0 || c.Logging.LargeResponseBytes < 0 {
+		return fmt.Errorf("log sampling settings cannot be negative")
+	}
+
+	for route, threshold := range c.Logging.SlowRequests {
+		if !strings.HasPrefix(route, "/") {
+			return fmt.Errorf("slow request route %q must start with /", route)
+		}
+		if threshold < 0 {
+			return fmt.Errorf("invalid slow request threshold for %s", route)
+		}
+	}
+
+	if c.Logging.AuditFile != "" && c.Logging.AuditSyslog != "" {
+		return fmt.Errorf("audit log file and audit syslog facility are mutually exclusive")
+	}
+
+	// Validate metrics configuration
+	if c.Metrics.StatsDEnabled() {
+		if c.Metrics.StatsDPort <= 0 || c.Metrics.StatsDPort > 65535 {
+			return fmt.Errorf("invalid statsd port: %d", c.Metrics.StatsDPort)
+		}
+		if strings.ContainsAny(c.Metrics.StatsDPrefix, ":|@#,\n ") {
+			return fmt.Errorf("invalid statsd prefix: %q", c.Metrics.StatsDPrefix)
+		}
+		for _, tag := range c.Metrics.StatsDTags {
+			if strings.ContainsAny(tag, "|@#,\n ") {
+				return fmt.Errorf("invalid statsd tag: %q", tag)
+			}
+		}
+	}
+
 	// Validate security configuration
 	if c.Security.MaxPathLength <= 0 {
 		return fmt.Errorf("max path length must be positive")
 	}
 
+	if c.Security.AuthFile != "" {
+		if _, err := os.Stat(c.Security.AuthFile); err != nil {
+			return fmt.Errorf("cannot access auth file: %w", err)
+		}
+	}
+
+	if c.Security.RequestPolicyFile != "" {
+		if _, err := os.Stat(c.Security.RequestPolicyFile); err != nil {
+			return fmt.Errorf("cannot access request policy file: %w", err)
+		}
+	}
+
+	if (c.Security.JWT.Issuer != "" || c.Security.JWT.Audience != "") && !c.Security.JWT.Enabled() {
+		return fmt.Errorf("jwt issuer and audience require a jwt secret, public key or JWKS URL")
+	}
+
+	if c.Security.RequestTimeout < 0 {
+		return fmt.Errorf("request timeout cannot be negative")
+	}
+
+	if c.Security.BanThreshold < 0 {
+		return fmt.Errorf("ban threshold cannot be negative")
+	}
+
+	if c.Security.BanThreshold > 0 && (c.Security.BanWindow <= 0 || c.Security.BanCooldown <= 0) {
+		return fmt.Errorf("ban window and cooldown must be positive")
+	}
+
+	if c.Security.EnableRateLimit {
+		limits := c.Security.RateLimit
+		if limits.RPS <= 0 || limits.Burst <= 0 {
+			return fmt.Errorf("rate limit rps and burst must be positive")
+		}
+		if limits.GlobalRPS < 0 || (limits.GlobalRPS > 0 && limits.GlobalBurst <= 0) {
+			return fmt.Errorf("global rate limit rps cannot be negative and needs a positive burst")
+		}
+	}
+
+	switch c.Security.Headers.CrossOriginResourcePolicy {
+	case "", "same-origin", "same-site", "cross-origin":
+	default:
+		return fmt.Errorf("invalid cross-origin resource policy: %s", c.Security.Headers.CrossOriginResourcePolicy)
+	}
+
+	if c.Security.Headers.HSTSMaxAge < 0 {
+		return fmt.Errorf("hsts max age cannot be negative")
+	}
+
+	for name, expr := range map[string]string{"keys": c.Security.Redaction.Keys, "pattern": c.Security.Redaction.Pattern} {
+		if _, err := regexp.Compile(expr); err != nil {
+			return fmt.Errorf("invalid redaction %s: %w", name, err)
+		}
+	}
+	for _, glob := range c.Security.Redaction.Files {
+		if _, err := path.Match(glob, ""); err != nil {
+			return fmt.Errorf("invalid redaction file pattern %q: %w", glob, err)
+		}
+	}
+
+	if c.Security.DailyByteQuota < 0 {
+		return fmt.Errorf("daily byte quota cannot be negative")
+	}
+
+	if signed := c.Security.SignedURLs; signed.Enabled() || signed.CurrentKey != "" {
+		ids := make(map[string]bool, len(signed.Keys))
+		for _, key := range signed.Keys {
+			if key.ID == "" || key.Secret == "" {
+				return fmt.Errorf("signing keys need an id and a secret")
+			}
+			if ids[key.ID] {
+				return fmt.Errorf("duplicate signing key id: %s", key.ID)
+			}
+			ids[key.ID] = true
+		}
+		if signed.CurrentKey != "" && !ids[signed.CurrentKey] {
+			return fmt.Errorf("current signing key %s is not configured", signed.CurrentKey)
+		}
+		if signed.ClockSkew < 0 {
+			return fmt.Errorf("signing clock skew cannot be negative")
+		}
+	}
+
+	if oidc := c.Security.OIDC; oidc.Enabled() {
+		if oidc.ClientID == "" || oidc.RedirectURL == "" {
+			return fmt.Errorf("oidc requires a client id and redirect url")
+		}
+		if oidc.SessionSecret != "" && len(oidc.SessionSecret) < 16 {
+			return fmt.Errorf("session secret must be at least 16 characters")
+		}
+		if oidc.SessionTTL <= 0 {
+			return fmt.Errorf("session ttl must be positive")
+		}
+	}
+
 	return nil
 }
 
-// GetServerAddr returns the complete server address
-func (c *Config) GetServerAddr() string {
-	if c.Server.Host == "" {
-		return ":" + c.Server.Port
+// GetServerAddrs returns the addresses to listen on, one for each host in
+// the comma-separated Server.Host, or all interfaces when it is empty
+func (c *Config) GetServerAddrs() []string {
+	hosts := splitList(c.Server.Host)
+	if len(hosts) == 0 {
+		return []string{":" + c.Server.Port}
 	}
-	return c.Server.Host + ":" + c.Server.Port
+	addrs := make([]string, 0, len(hosts))
+	for _, host := range hosts {
+		// IPv6 literals may be given with or without brackets
+		addrs = append(addrs, net.JoinHostPort(strings.Trim(host, "[]"), c.Server.Port))
+	}
+	return addrs
+}
+
+// GetServerAddr returns the complete server address, or the comma-separated
+// addresses when several hosts are configured
+func (c *Config) GetServerAddr() string {
+	return strings.Join(c.GetServerAddrs(), ",")
 }
 
 // IsDebugMode returns true if debug logging is enabled
@@ -269,10 +1856,38 @@ func (c *Config) GetBaseDirectory() string {
 	return c.FileSystem.BaseDirectory
 }
 
+// redactedValue replaces secrets in redacted output
+const redactedValue = "[REDACTED]"
+
+// Redacted returns a copy of the configuration with secrets masked
+func (c *Config) Redacted() *Config {
+	redacted := *c
+	if redacted.Security.AdminToken != "" {
+		redacted.Security.AdminToken = redactedValue
+	}
+	if redacted.Security.JWT.Secret != "" {
+		redacted.Security.JWT.Secret = redactedValue
+	}
+	if redacted.Security.OIDC.ClientSecret != "" {
+		redacted.Security.OIDC.ClientSecret = redactedValue
+	}
+	if redacted.Security.OIDC.SessionSecret != "" {
+		redacted.Security.OIDC.SessionSecret = redactedValue
+	}
+	if keys := redacted.Security.SignedURLs.Keys; len(keys) > 0 {
+		redacted.Security.SignedURLs.Keys = make([]SigningKeyConfig, len(keys))
+		for i, key := range keys {
+			redacted.Security.SignedURLs.Keys[i] = SigningKeyConfig{ID: key.ID, Secret: redactedValue}
+		}
+	}
+	return &redacted
+}
+
 // String returns a string representation of the configuration
 func (c *Config) String() string {
+	r := c.Redacted()
 	return fmt.Sprintf("Config{Server: %+v, FileSystem: %+v, Logging: %+v, Security: %+v}",
-		c.Server, c.FileSystem, c.Logging, c.Security)
+		r.Server, r.FileSystem, r.Logging, r.Security)
 }
 
 // PrintConfig prints the configuration (excluding sensitive information)
@@ -282,18 +1897,77 @@ func (c *Config) PrintConfig() {
 	fmt.Printf("  Read Timeout: %v\n", c.Server.ReadTimeout)
 	fmt.Printf("  Write Timeout: %v\n", c.Server.WriteTimeout)
 	fmt.Printf("  Idle Timeout: %v\n", c.Server.IdleTimeout)
+	fmt.Printf("  Shutdown Timeout: %v\n", c.Server.ShutdownTimeout)
+	fmt.Printf("  Shutdown Delay: %v\n", c.Server.ShutdownDelay)
+	fmt.Printf("  Read Header Timeout: %v\n", c.Server.ReadHeaderTimeout)
+	fmt.Printf("  TCP Keep-Alive: %v\n", c.Server.KeepAlive)
+	fmt.Printf("  Max Connections Per IP: %d\n", c.Server.MaxConnsPerIP)
+	fmt.Printf("  H2C: %v\n", c.Server.EnableH2C)
+	fmt.Printf("  Max Concurrent Requests: %d\n", c.Server.MaxConcurrentRequests)
+	fmt.Printf("  Max Header Bytes: %d\n", c.Server.MaxHeaderBytes)
+	fmt.Printf("  Max URL Length: %d\n", c.Server.MaxURLLength)
+	fmt.Printf("  Max Body Bytes: %d\n", c.Server.MaxBodyBytes)
+	fmt.Printf("  Compression: %v (min bytes: %d)\n", c.Server.Compression, c.Server.CompressionMinBytes)
+	fmt.Printf("  Worker Pool Size: %d\n", c.Server.WorkerPoolSize)
+	fmt.Printf("  Memory Limit: %d bytes (gc percent: %d)\n", c.Server.MemoryLimit, c.Server.GCPercent)
+	fmt.Printf("  Large Reads: refused from %d bytes above %g%% of the memory limit\n", c.Server.LargeReadBytes, c.Server.MemoryRejectPercent)
+	fmt.Printf("  Admin Listener: %s\n", c.Server.AdminAddr)
+	fmt.Printf("  Pprof: %v\n", c.Server.EnablePprof)
+	fmt.Printf("  TLS: %v (redirect listener: %s)\n", c.Server.TLS.Enabled(), c.Server.TLS.RedirectAddr)
+	fmt.Printf("  ACME Domains: %v (cache: %s)\n", c.Server.TLS.ACMEDomains, c.Server.TLS.ACMECacheDir)
 
 	fmt.Printf("FileSystem Configuration:\n")
 	fmt.Printf("  Base Directory: %s\n", c.FileSystem.BaseDirectory)
 	fmt.Printf("  Max File Size: %d bytes\n", c.FileSystem.MaxFileSize)
 	fmt.Printf("  Allow Hidden: %v\n", c.FileSystem.AllowHidden)
+	fmt.Printf("  Read Only: %v\n", c.FileSystem.ReadOnly)
+	fmt.Printf("  Disk Warning/Critical: %g%%/%g%%\n", c.FileSystem.DiskWarningPercent, c.FileSystem.DiskCriticalPercent)
+	fmt.Printf("  Content Cache: %d bytes (max entry: %d bytes)\n", c.FileSystem.ContentCacheBytes, c.FileSystem.ContentCacheEntryBytes)
+	fmt.Printf("  Listing Cache TTL: %v\n", c.FileSystem.ListingCacheTTL)
+	fmt.Printf("  Watch: %s (poll interval: %v)\n", c.FileSystem.Watch, c.FileSystem.WatchPollInterval)
+	fmt.Printf("  Directory Index: %d entries (refresh interval: %v)\n", c.FileSystem.IndexMaxEntries, c.FileSystem.IndexRefreshInterval)
+	fmt.Printf("  Timeouts: stat %v, read %v, list %v\n", c.FileSystem.StatTimeout, c.FileSystem.ReadTimeout, c.FileSystem.ListTimeout)
+	fmt.Printf("  Breaker: %d timeouts (cooldown: %v)\n", c.FileSystem.BreakerThreshold, c.FileSystem.BreakerCooldown)
+	for _, mount := range c.FileSystem.Mounts {
+		fmt.Printf("  Mount %s: %s (max file size: %d, allow hidden: %v)\n", mount.Name, mount.Path, mount.MaxFileSize, mount.AllowHidden)
+	}
 
 	fmt.Printf("Logging Configuration:\n")
 	fmt.Printf("  Level: %s\n", c.Logging.Level)
 	fmt.Printf("  Format: %s\n", c.Logging.Format)
+	fmt.Printf("  Outputs: %v (file: %s, syslog facility: %s, otlp endpoint: %s)\n",
+		c.Logging.Outputs, c.Logging.File, c.Logging.SyslogFacility, c.Logging.OTLPEndpoint)
+	fmt.Printf("  Sample Success: 1 in %d (large responses: %d bytes)\n", max(c.Logging.SampleSuccess, 1), c.Logging.LargeResponseBytes)
+	fmt.Printf("  Slow Requests: %s\n", formatSlowRequests(c.Logging.SlowRequests))
+	fmt.Printf("  Audit Log: %s\n", c.Logging.AuditFile)
+	fmt.Printf("  Audit Syslog: %s\n", c.Logging.AuditSyslog)
+
+	fmt.Printf("Metrics Configuration:\n")
+	fmt.Printf("  StatsD: %v (addr: %s, prefix: %s, tags: %v)\n", c.Metrics.StatsDEnabled(),
+		c.Metrics.StatsDAddr(), c.Metrics.StatsDPrefix, c.Metrics.StatsDTags)
 
 	fmt.Printf("Security Configuration:\n")
 	fmt.Printf("  Enable CORS: %v\n", c.Security.EnableCORS)
+	fmt.Printf("  Enable Recovery: %v\n", c.Security.EnableRecovery)
+	fmt.Printf("  Request Timeout: %v\n", c.Security.RequestTimeout)
 	fmt.Printf("  Enable Security Headers: %v\n", c.Security.EnableSecurityHeaders)
+	fmt.Printf("  Content Security Policy: %s\n", c.Security.Headers.ContentSecurityPolicy)
+	fmt.Printf("  Cross-Origin Resource Policy: %s\n", c.Security.Headers.CrossOriginResourcePolicy)
+	fmt.Printf("  HSTS Max Age: %v (include subdomains: %v)\n", c.Security.Headers.HSTSMaxAge, c.Security.Headers.HSTSIncludeSubdomains)
 	fmt.Printf("  Max Path Length: %d\n", c.Security.MaxPathLength)
+	fmt.Printf("  Admin Endpoints: %v\n", c.Security.AdminToken != "")
+	fmt.Printf("  Basic Auth File: %s\n", c.Security.AuthFile)
+	fmt.Printf("  Request Policy File: %s\n", c.Security.RequestPolicyFile)
+	fmt.Printf("  JWT Auth: %v\n", c.Security.JWT.Enabled())
+	fmt.Printf("  OIDC Issuer: %s\n", c.Security.OIDC.IssuerURL)
+	fmt.Printf("  Allowed CIDRs: %v\n", c.Security.AllowedCIDRs)
+	fmt.Printf("  Denied CIDRs: %v\n", c.Security.DeniedCIDRs)
+	fmt.Printf("  Trusted Proxies: %v\n", c.Security.TrustedProxies)
+	fmt.Printf("  Rate Limit: %v (%g rps, burst %d per client; %g rps, burst %d global)\n", c.Security.EnableRateLimit,
+		c.Security.RateLimit.RPS, c.Security.RateLimit.Burst, c.Security.RateLimit.GlobalRPS, c.Security.RateLimit.GlobalBurst)
+	fmt.Printf("  Ban Threshold: %d in %v (cooldown %v)\n", c.Security.BanThreshold, c.Security.BanWindow, c.Security.BanCooldown)
+	fmt.Printf("  Daily Byte Quota: %d\n", c.Security.DailyByteQuota)
+	fmt.Printf("  Sandbox: %v\n", c.Security.Sandbox)
+	fmt.Printf("  Redaction: %v (files %v, bypass scope %q)\n", c.Security.Redaction.Enabled, c.Security.Redaction.Files, c.Security.Redaction.BypassScope)
+	fmt.Printf("  Signed URLs: %v (%d keys)\n", c.Security.SignedURLs.Enabled(), len(c.Security.SignedURLs.Keys))
 }
@@ -0,0 +1,267 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sh05/cat-server/pkg/infrastructure/dataformat"
+)
+
+// Load returns the configuration in the YAML, TOML or JSON file at path, layered
+// over the defaults and overridden by CAT_SERVER_* environment variables.
+// Programs embedding the server call it through cat.LoadConfig.
+func Load(path string) (*Config, error) {
+	config := DefaultConfig()
+	if err := config.LoadFromFile(path); err != nil {
+		return nil, err
+	}
+	if err := config.LoadFromEnv(); err != nil {
+		return nil, fmt.Errorf("failed to load config from environment: %w", err)
+	}
+	if err := config.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+	return config, nil
+}
+
+// LoadFromFile loads settings from a YAML file, or a TOML or JSON file
+// when the name ends in .toml or .json. Keys are the json names of the
+// Config fields, e.g.
+//
+//	server:
+//	  port: 8080
+//	  read_timeout: 30s
+//	security:
+//	  allowed_cidrs: [10.0.0.0/8]
+//
+// or in TOML
+//
+//	[server]
+//	port = 8080
+//	read_timeout = "30s"
+//
+// Durations are strings such as "30s" and lists are sequences or
+// comma-separated strings. Strings that look like numbers, such as 1.10,
+// must be quoted to keep their digits. Settings missing from the file keep
+// their current values; unknown keys are errors.
+func (c *Config) LoadFromFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	// YAML and TOML are converted to JSON, then decoded like JSON files
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+	case ".toml":
+		data, err = dataformat.TOMLToJSON(data)
+	default:
+		data, err = dataformat.YAMLToJSON(data)
+	}
+	if err != nil {
+		return fmt.Errorf("config file %s: %w", path, err)
+	}
+
+	var doc interface{}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&doc); err != nil {
+		return fmt.Errorf("config file %s: %w", path, err)
+	}
+	// A YAML file without documents, e.g. only comments, sets nothing
+	if items, ok := doc.([]interface{}); ok && len(items) == 0 {
+		return nil
+	}
+
+	if err := decodeValue(reflect.ValueOf(c).Elem(), doc, ""); err != nil {
+		return fmt.Errorf("config file %s: %w", path, err)
+	}
+	return nil
+}
+
+// durationType is the reflect type of time.Duration
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// decodeValue stores node, a tree of maps, slices and scalars decoded from a
+// JSON document, in v. Null leaves v unchanged; name is the dotted
+// key path used in errors.
+func decodeValue(v reflect.Value, node interface{}, name string) error {
+	if node == nil {
+		return nil
+	}
+	if n, ok := node.(json.Number); ok {
+		node = n.String()
+	}
+
+	if v.Type() == durationType {
+		s, ok := scalarString(node)
+		if !ok {
+			return fmt.Errorf("%s: expected a duration, got %s", name, describeNode(node))
+		}
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		v.SetInt(int64(d))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.Struct:
+		m, ok := node.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("%s: expected a mapping, got %s", name, describeNode(node))
+		}
+		fields := structFields(v.Type())
+		for key, value := range m {
+			index, ok := fields[key]
+			if !ok {
+				return fmt.Errorf("%s: unknown setting", joinKey(name, key))
+			}
+			if err := decodeValue(v.Field(index), value, joinKey(name, key)); err != nil {
+				return err
+			}
+		}
+		return nil
+
+	case reflect.Slice:
+		items, ok := node.([]interface{})
+		if !ok {
+			// Lists may be written comma-separated, as for flags
+			s, isString := node.(string)
+			if !isString || v.Type().Elem().Kind() != reflect.String {
+				return fmt.Errorf("%s: expected a list, got %s", name, describeNode(node))
+			}
+			v.Set(reflect.ValueOf(splitList(s)).Convert(v.Type()))
+			return nil
+		}
+		slice := reflect.MakeSlice(v.Type(), len(items), len(items))
+		for i, item := range items {
+			if err := decodeValue(slice.Index(i), item, fmt.Sprintf("%s[%d]", name, i)); err != nil {
+				return err
+			}
+		}
+		v.Set(slice)
+		return nil
+
+	case reflect.Map:
+		m, ok := node.(map[string]interface{})
+		if !ok || v.Type().Key().Kind() != reflect.String {
+			return fmt.Errorf("%s: expected a mapping, got %s", name, describeNode(node))
+		}
+		if v.IsNil() {
+			v.Set(reflect.MakeMapWithSize(v.Type(), len(m)))
+		}
+		for key, value := range m {
+			elem := reflect.New(v.Type().Elem()).Elem()
+			if err := decodeValue(elem, value, joinKey(name, key)); err != nil {
+				return err
+			}
+			v.SetMapIndex(reflect.ValueOf(key).Convert(v.Type().Key()), elem)
+		}
+		return nil
+
+	case reflect.Pointer:
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		return decodeValue(v.Elem(), node, name)
+
+	case reflect.Interface:
+		v.Set(reflect.ValueOf(node))
+		return nil
+	}
+
+	s, ok := scalarString(node)
+	if !ok {
+		return fmt.Errorf("%s: expected a %s, got %s", name, v.Kind(), describeNode(node))
+	}
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return fmt.Errorf("%s: invalid boolean %q", name, s)
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, 64)
+		if err != nil || v.OverflowInt(n) {
+			return fmt.Errorf("%s: invalid integer %q", name, s)
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(s, 10, 64)
+		if err != nil || v.OverflowUint(n) {
+			return fmt.Errorf("%s: invalid unsigned integer %q", name, s)
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil || v.OverflowFloat(f) {
+			return fmt.Errorf("%s: invalid number %q", name, s)
+		}
+		v.SetFloat(f)
+	default:
+		return fmt.Errorf("%s: unsupported setting type %s", name, v.Type())
+	}
+	return nil
+}
+
+// scalarString returns the text of a scalar node
+func scalarString(node interface{}) (string, bool) {
+	switch n := node.(type) {
+	case string:
+		return n, true
+	case bool:
+		return strconv.FormatBool(n), true
+	}
+	return "", false
+}
+
+// describeNode names the kind of a node for error messages
+func describeNode(node interface{}) string {
+	switch node.(type) {
+	case map[string]interface{}:
+		return "a mapping"
+	case []interface{}:
+		return "a list"
+	}
+	return fmt.Sprintf("%q", node)
+}
+
+// structFields maps the json names of a struct's fields to their indexes
+func structFields(t reflect.Type) map[string]int {
+	fields := make(map[string]int, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		fields[name] = i
+	}
+	return fields
+}
+
+// joinKey appends key to a dotted key path
+func joinKey(name, key string) string {
+	if name == "" {
+		return key
+	}
+	return name + "." + key
+}
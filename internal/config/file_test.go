@@ -0,0 +1,762 @@
+package config
+
+import (
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeConfigFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadFromFile(t *testing.T) {
+	yamlFile := writeConfigFile(t, "cat-server.yaml", `
+server:
+  port: 9000
+  read_timeout: 30s
+  tls:
+    acme_domains: [a.example.com, b.example.com]
+filesystem:
+  allow_hidden: true
+security:
+  allowed_cidrs: 10.0.0.0/8, 192.168.0.0/16
+  rate_limit:
+    rps: 2.5
+`)
+	tomlFile := writeConfigFile(t, "cat-server.toml", `
+[server]
+port = 9000
+read_timeout = "30s"
+
+[server.tls]
+acme_domains = ["a.example.com", "b.example.com"]
+
+[filesystem]
+allow_hidden = true
+
+[security]
+allowed_cidrs = "10.0.0.0/8, 192.168.0.0/16"
+rate_limit = { rps = 2.5 }
+`)
+	jsonFile := writeConfigFile(t, "cat-server.json", `{
+  "server": {"port": 9000, "read_timeout": "30s", "tls": {"acme_domains": ["a.example.com", "b.example.com"]}},
+  "filesystem": {"allow_hidden": true},
+  "security": {"allowed_cidrs": ["10.0.0.0/8", "192.168.0.0/16"], "rate_limit": {"rps": 2.5}}
+}`)
+
+	for _, path := range []string{yamlFile, tomlFile, jsonFile} {
+		c := DefaultConfig()
+		if err := c.LoadFromFile(path); err != nil {
+			t.Fatalf("LoadFromFile(%s) returned error: %v", path, err)
+		}
+		if c.Server.Port != "9000" || c.Server.ReadTimeout != 30*time.Second || !c.FileSystem.AllowHidden || c.Security.RateLimit.RPS != 2.5 {
+			t.Errorf("%s: unexpected settings %+v %+v", path, c.Server, c.FileSystem)
+		}
+		if !reflect.DeepEqual(c.Server.TLS.ACMEDomains, []string{"a.example.com", "b.example.com"}) ||
+			!reflect.DeepEqual(c.Security.AllowedCIDRs, []string{"10.0.0.0/8", "192.168.0.0/16"}) {
+			t.Errorf("%s: unexpected lists %v %v", path, c.Server.TLS.ACMEDomains, c.Security.AllowedCIDRs)
+		}
+		if c.Server.WriteTimeout != DefaultConfig().Server.WriteTimeout || c.Logging.Level != "info" {
+			t.Errorf("%s: expected settings missing from the file to keep their defaults", path)
+		}
+	}
+
+	for _, tt := range []struct {
+		name, doc, expected string
+	}{
+		{"bad.yaml", "server:\n  prot: 80\n", "server.prot: unknown setting"},
+		{"bad.yaml", "server:\n  read_timeout: 30\n", "server.read_timeout: time: missing unit"},
+		{"bad.yaml", "filesystem:\n  allow_hidden: maybe", "filesystem.allow_hidden: invalid boolean"},
+		{"bad.yaml", "server: 8080\n", "server: expected a mapping"},
+		{"bad.yaml", "a: 1\na: 2\n", "yaml: line 2: duplicate key"},
+		{"bad.yaml", "a: \"open\n", "yaml: line 2: found unexpected end of stream"},
+		{"bad.yaml", "---\nserver: {}\n---\nlogging: {}\n", "expected a mapping, got a list"},
+		{"bad.toml", "[server]\nprot = 80\n", "server.prot: unknown setting"},
+		{"bad.toml", "[server]\nport = 1\nport = 2\n", "toml: line 3: Key 'server.port' has already been defined"},
+		{"bad.toml", "[server\n", "toml: line 2"},
+	} {
+		err := DefaultConfig().LoadFromFile(writeConfigFile(t, tt.name, tt.doc))
+		if err == nil || !strings.Contains(err.Error(), tt.expected) {
+			t.Errorf("LoadFromFile(%s %q) returned %v, expected error containing %q", tt.name, tt.doc, err, tt.expected)
+		}
+	}
+
+	// Files without settings leave the configuration unchanged
+	for name, doc := range map[string]string{"empty.yaml": "# nothing\n", "empty.toml": "# nothing\n"} {
+		c := DefaultConfig()
+		if err := c.LoadFromFile(writeConfigFile(t, name, doc)); err != nil {
+			t.Errorf("LoadFromFile(%s) returned error: %v", name, err)
+		}
+		if !reflect.DeepEqual(c, DefaultConfig()) {
+			t.Errorf("LoadFromFile(%s) changed the configuration", name)
+		}
+	}
+}
+
+func TestLoadFromFlagSetPrecedence(t *testing.T) {
+	dir := t.TempDir()
+	path := writeConfigFile(t, "cat-server.yaml", `
+server:
+  port: 9000
+  host: 127.0.0.1
+filesystem:
+  base_directory: `+dir+`
+logging:
+  level: debug
+security:
+  allowed_cidrs: [10.0.0.0/8]
+`)
+	t.Setenv("CAT_SERVER_LOG_LEVEL", "warn")
+	t.Setenv("CAT_SERVER_HOST", "")
+	t.Setenv("HOST", "")
+
+	fs := flag.NewFlagSet("cat-server", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	c, err := LoadFromFlagSet(fs, []string{"--config", path, "--port", "9100"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if c.Server.Port != "9100" {
+		t.Errorf("Expected the flag to override the file port, got %s", c.Server.Port)
+	}
+	if c.Logging.Level != "warn" {
+		t.Errorf("Expected the environment to override the file log level, got %s", c.Logging.Level)
+	}
+	if c.Server.Host != "127.0.0.1" || !reflect.DeepEqual(c.Security.AllowedCIDRs, []string{"10.0.0.0/8"}) {
+		t.Errorf("Expected unset flags to keep file settings, got host %q and cidrs %v", c.Server.Host, c.Security.AllowedCIDRs)
+	}
+	if c.Server.ReadTimeout != DefaultConfig().Server.ReadTimeout {
+		t.Errorf("Expected defaults for settings set nowhere, got %v", c.Server.ReadTimeout)
+	}
+}
+
+func TestParseEnvFile(t *testing.T) {
+	vars, err := parseEnvFile([]byte(`# comment
+CAT_SERVER_PORT=9000
+export CAT_SERVER_HOST = 127.0.0.1 # trailing comment
+
+CAT_SERVER_ADMIN_TOKEN='s3cr#t $HOME'
+CAT_SERVER_REDACT_PATTERNS="a\tb" # quoted
+EMPTY=
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]string{
+		"CAT_SERVER_PORT":            "9000",
+		"CAT_SERVER_HOST":            "127.0.0.1",
+		"CAT_SERVER_ADMIN_TOKEN":     "s3cr#t $HOME",
+		"CAT_SERVER_REDACT_PATTERNS": "a\tb",
+		"EMPTY":                      "",
+	}
+	if !reflect.DeepEqual(vars, expected) {
+		t.Errorf("parseEnvFile() = %v, expected %v", vars, expected)
+	}
+
+	for doc, expected := range map[string]string{
+		"NOEQUALS\n":  "line 1: expected KEY=VALUE",
+		"\nA B=1\n":   "line 2: expected KEY=VALUE",
+		"A='open\n":   "line 1: unterminated quote",
+		"A=\"x\" y\n": "line 1: unexpected",
+	} {
+		if _, err := parseEnvFile([]byte(doc)); err == nil || !strings.Contains(err.Error(), expected) {
+			t.Errorf("parseEnvFile(%q) returned %v, expected error containing %q", doc, err, expected)
+		}
+	}
+}
+
+func TestLoadFromFlagSetEnvFile(t *testing.T) {
+	dir := t.TempDir()
+	configPath := writeConfigFile(t, "cat-server.yaml", "server:\n  port: 9000\n  host: 127.0.0.1\n")
+	envPath := writeConfigFile(t, ".env", `CAT_SERVER_CONFIG=`+configPath+`
+CAT_SERVER_DIR=`+dir+`
+CAT_SERVER_HOST=127.0.0.2
+CAT_SERVER_LOG_LEVEL=debug
+CAT_SERVER_PORT=9100
+`)
+	t.Setenv("CAT_SERVER_LOG_LEVEL", "warn")
+	t.Setenv("CAT_SERVER_HOST", "")
+	t.Setenv("HOST", "")
+	t.Setenv("CAT_SERVER_PORT", "")
+	t.Setenv("PORT", "")
+
+	fs := flag.NewFlagSet("cat-server", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	c, err := LoadFromFlagSet(fs, []string{"--env-file", envPath, "--port", "9200"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if c.FileSystem.BaseDirectory != dir {
+		t.Errorf("Expected the env file to set the base directory, got %s", c.FileSystem.BaseDirectory)
+	}
+	if c.Server.Host != "127.0.0.2" {
+		t.Errorf("Expected the env file to override the config file it names, got host %s", c.Server.Host)
+	}
+	if c.Logging.Level != "warn" {
+		t.Errorf("Expected the environment to override the env file, got %s", c.Logging.Level)
+	}
+	if c.Server.Port != "9200" {
+		t.Errorf("Expected the flag to override the env file, got %s", c.Server.Port)
+	}
+
+	fs = flag.NewFlagSet("cat-server", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	if _, err := LoadFromFlagSet(fs, []string{"--env-file", filepath.Join(dir, "missing.env")}); err == nil {
+		t.Error("Expected a missing --env-file to be an error")
+	}
+}
+
+func TestLoadFromEnvPlatformPort(t *testing.T) {
+	tests := []struct {
+		name         string
+		port, catEnv string
+		expected     string
+	}{
+		{"default", "", "", DefaultConfig().Server.Port},
+		{"PORT", "9090", "", "9090"},
+		{"CAT_SERVER_PORT wins", "9090", "9100", "9100"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("PORT", tt.port)
+			t.Setenv("CAT_SERVER_PORT", tt.catEnv)
+
+			c := DefaultConfig()
+			if err := c.LoadFromEnv(); err != nil {
+				t.Fatal(err)
+			}
+			if c.Server.Port != tt.expected {
+				t.Errorf("Expected port %s, got %s", tt.expected, c.Server.Port)
+			}
+		})
+	}
+}
+
+func TestGetServerAddrs(t *testing.T) {
+	tests := []struct {
+		host     string
+		expected []string
+	}{
+		{"", []string{":8080"}},
+		{"127.0.0.1", []string{"127.0.0.1:8080"}},
+		{"127.0.0.1, ::1", []string{"127.0.0.1:8080", "[::1]:8080"}},
+		{"[::1]", []string{"[::1]:8080"}},
+	}
+
+	for _, tt := range tests {
+		c := DefaultConfig()
+		c.Server.Host = tt.host
+		if addrs := c.GetServerAddrs(); !reflect.DeepEqual(addrs, tt.expected) {
+			t.Errorf("GetServerAddrs() with host %q = %v, expected %v", tt.host, addrs, tt.expected)
+		}
+	}
+
+	c := DefaultConfig()
+	c.FileSystem.BaseDirectory = t.TempDir()
+	c.Server.Host = "::1,[::1]"
+	if err := c.Validate(); err == nil || !strings.Contains(err.Error(), "listed twice") {
+		t.Errorf("Expected duplicate hosts to be rejected, got %v", err)
+	}
+}
+
+func TestMounts(t *testing.T) {
+	base, logs, etc := t.TempDir(), t.TempDir(), t.TempDir()
+	path := writeConfigFile(t, "cat-server.yaml", `
+filesystem:
+  base_directory: `+base+`
+  mounts:
+    - name: logs
+      path: `+logs+`
+      max_file_size: 1024
+      allow_hidden: true
+`)
+
+	fs := flag.NewFlagSet("cat-server", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	c, err := LoadFromFlagSet(fs, []string{"--config", path, "--mount", "logs=" + logs + ",cfg=" + etc})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []MountConfig{
+		{Name: "logs", Path: logs, MaxFileSize: 1024, AllowHidden: true},
+		{Name: "cfg", Path: etc},
+	}
+	if !reflect.DeepEqual(c.FileSystem.Mounts, expected) {
+		t.Errorf("Expected mounts %+v, got %+v", expected, c.FileSystem.Mounts)
+	}
+
+	for mounts, expected := range map[string]string{
+		"logs":                          "mount logs: path cannot be empty",
+		".git=" + logs:                  "invalid mount name",
+		"a/b=" + logs:                   "invalid mount name",
+		"logs=" + logs + ",logs=" + etc: "duplicate mount name",
+		"logs=" + base + "/missing":     "mount logs: cannot access directory",
+	} {
+		c := DefaultConfig()
+		c.FileSystem.BaseDirectory = base
+		c.FileSystem.Mounts = parseMounts(mounts, nil)
+		if err := c.Validate(); err == nil || !strings.Contains(err.Error(), expected) {
+			t.Errorf("Validate() with mounts %q returned %v, expected error containing %q", mounts, err, expected)
+		}
+	}
+}
+
+func TestOverrides(t *testing.T) {
+	c := DefaultConfig()
+	err := c.LoadFromFile(writeConfigFile(t, "cat-server.yaml", `
+filesystem:
+  overrides:
+    - prefix: logs
+      max_file_size: 52428800
+      allowed_extensions: [.log, .gz]
+    - prefix: logs/debug
+      allow_hidden: true
+      allowed_extensions: []
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	overrides := c.FileSystem.Overrides
+	if len(overrides) != 2 || overrides[0].MaxFileSize != 52428800 || overrides[0].AllowHidden != nil ||
+		!reflect.DeepEqual(overrides[0].AllowedExtensions, []string{".log", ".gz"}) {
+		t.Fatalf("unexpected overrides %+v", overrides)
+	}
+	if overrides[1].AllowHidden == nil || !*overrides[1].AllowHidden || overrides[1].AllowedExtensions == nil || overrides[1].DeniedExtensions != nil {
+		t.Errorf("Expected set and unset fields of %+v to stay apart", overrides[1])
+	}
+
+	for _, tt := range []struct {
+		overrides []PathOverride
+		expected  string
+	}{
+		{[]PathOverride{{Prefix: ""}}, "override prefix cannot be empty"},
+		{[]PathOverride{{Prefix: "logs/../etc"}}, "invalid override prefix"},
+		{[]PathOverride{{Prefix: "logs"}, {Prefix: "/logs/"}}, "duplicate override prefix"},
+		{[]PathOverride{{Prefix: "logs", MaxFileSize: -1}}, "max file size cannot be negative"},
+	} {
+		c := DefaultConfig()
+		c.FileSystem.BaseDirectory = t.TempDir()
+		c.FileSystem.Overrides = tt.overrides
+		if err := c.Validate(); err == nil || !strings.Contains(err.Error(), tt.expected) {
+			t.Errorf("Validate() with overrides %+v returned %v, expected error containing %q", tt.overrides, err, tt.expected)
+		}
+	}
+}
+
+func TestDiskThresholds(t *testing.T) {
+	for _, tt := range []struct {
+		warning, critical float64
+		expected          string // empty when valid
+	}{
+		{90, 95, ""},
+		{0, 0, ""},
+		{97, 0, ""},
+		{-1, 95, "between 0 and 100"},
+		{90, 101, "between 0 and 100"},
+		{96, 95, "cannot exceed"},
+	} {
+		c := DefaultConfig()
+		c.FileSystem.BaseDirectory = t.TempDir()
+		c.FileSystem.DiskWarningPercent = tt.warning
+		c.FileSystem.DiskCriticalPercent = tt.critical
+		err := c.Validate()
+		if tt.expected == "" && err != nil || tt.expected != "" && (err == nil || !strings.Contains(err.Error(), tt.expected)) {
+			t.Errorf("Validate() with thresholds %g/%g returned %v, expected %q", tt.warning, tt.critical, err, tt.expected)
+		}
+	}
+}
+
+func TestContentCacheSizes(t *testing.T) {
+	for _, tt := range []struct {
+		total, entry int64
+		expected     string // empty when valid
+	}{
+		{64 << 20, 1 << 20, ""},
+		{0, 0, ""},
+		{-1, 1 << 20, "cannot be negative"},
+		{64 << 20, -1, "cannot be negative"},
+		{64 << 20, 0, "must be positive"},
+	} {
+		c := DefaultConfig()
+		c.FileSystem.BaseDirectory = t.TempDir()
+		c.FileSystem.ContentCacheBytes = tt.total
+		c.FileSystem.ContentCacheEntryBytes = tt.entry
+		err := c.Validate()
+		if tt.expected == "" && err != nil || tt.expected != "" && (err == nil || !strings.Contains(err.Error(), tt.expected)) {
+			t.Errorf("Validate() with content cache %d/%d returned %v, expected %q", tt.total, tt.entry, err, tt.expected)
+		}
+	}
+}
+
+func TestListingCacheTTL(t *testing.T) {
+	c := DefaultConfig()
+	c.FileSystem.BaseDirectory = t.TempDir()
+	c.FileSystem.ListingCacheTTL = -time.Second
+	if err := c.Validate(); err == nil || !strings.Contains(err.Error(), "cannot be negative") {
+		t.Errorf("Expected a negative listing cache ttl to be rejected, got %v", err)
+	}
+
+	c.FileSystem.ListingCacheTTL = 0
+	if err := c.Validate(); err != nil {
+		t.Errorf("Expected a disabled listing cache to be valid, got %v", err)
+	}
+}
+
+func TestWatchMode(t *testing.T) {
+	for _, tt := range []struct {
+		mode     string
+		interval time.Duration
+		expected string // empty when valid
+	}{
+		{"auto", time.Second, ""},
+		{"inotify", 0, ""},
+		{"poll", time.Second, ""},
+		{"off", 0, ""},
+		{"auto", 0, "must be positive"},
+		{"poll", -time.Second, "must be positive"},
+		{"fsnotify", time.Second, "invalid watch mode"},
+	} {
+		c := DefaultConfig()
+		c.FileSystem.BaseDirectory = t.TempDir()
+		c.FileSystem.Watch = tt.mode
+		c.FileSystem.WatchPollInterval = tt.interval
+		err := c.Validate()
+		if tt.expected == "" && err != nil {
+			t.Errorf("%s every %s: expected valid, got %v", tt.mode, tt.interval, err)
+		}
+		if tt.expected != "" && (err == nil || !strings.Contains(err.Error(), tt.expected)) {
+			t.Errorf("%s every %s: expected %q, got %v", tt.mode, tt.interval, tt.expected, err)
+		}
+	}
+}
+
+func TestDirectoryIndex(t *testing.T) {
+	for _, tt := range []struct {
+		maxEntries int
+		watch      string
+		refresh    time.Duration
+		expected   string // empty when valid
+	}{
+		{0, "off", 0, ""},
+		{100_000, "auto", 15 * time.Minute, ""},
+		{100_000, "auto", 0, ""},
+		{100_000, "off", time.Minute, ""},
+		{100_000, "off", 0, "needs watching for changes or a refresh interval"},
+		{-1, "auto", time.Minute, "cannot be negative"},
+		{100_000, "auto", -time.Minute, "cannot be negative"},
+	} {
+		c := DefaultConfig()
+		c.FileSystem.BaseDirectory = t.TempDir()
+		c.FileSystem.IndexMaxEntries = tt.maxEntries
+		c.FileSystem.Watch = tt.watch
+		c.FileSystem.IndexRefreshInterval = tt.refresh
+		err := c.Validate()
+		if tt.expected == "" && err != nil {
+			t.Errorf("%d entries, watch %s, refresh %s: expected valid, got %v", tt.maxEntries, tt.watch, tt.refresh, err)
+		}
+		if tt.expected != "" && (err == nil || !strings.Contains(err.Error(), tt.expected)) {
+			t.Errorf("%d entries, watch %s, refresh %s: expected %q, got %v", tt.maxEntries, tt.watch, tt.refresh, tt.expected, err)
+		}
+	}
+}
+
+func TestCompression(t *testing.T) {
+	for _, tt := range []struct {
+		encodings []string
+		minBytes  int
+		expected  string // empty when valid
+	}{
+		{[]string{"gzip", "deflate"}, 1024, ""},
+		{nil, 0, ""},
+		{[]string{"br"}, 1024, ""},
+		{[]string{"zstd", "br", "gzip"}, 1024, ""},
+		{[]string{"lz4"}, 1024, "invalid compression encoding"},
+		{[]string{"gzip", "gzip"}, 1024, "listed twice"},
+		{[]string{"gzip"}, -1, "cannot be negative"},
+	} {
+		c := DefaultConfig()
+		c.FileSystem.BaseDirectory = t.TempDir()
+		c.Server.Compression = tt.encodings
+		c.Server.CompressionMinBytes = tt.minBytes
+		err := c.Validate()
+		if tt.expected == "" && err != nil {
+			t.Errorf("%v: expected valid, got %v", tt.encodings, err)
+		}
+		if tt.expected != "" && (err == nil || !strings.Contains(err.Error(), tt.expected)) {
+			t.Errorf("%v: expected %q, got %v", tt.encodings, tt.expected, err)
+		}
+	}
+
+	if encodings := parseCompression("off"); encodings != nil {
+		t.Errorf("Expected off to disable compression, got %v", encodings)
+	}
+}
+
+func TestWorkerPoolSize(t *testing.T) {
+	c := DefaultConfig()
+	c.FileSystem.BaseDirectory = t.TempDir()
+	c.Server.WorkerPoolSize = -1
+	if err := c.Validate(); err == nil || !strings.Contains(err.Error(), "worker pool size") {
+		t.Errorf("Expected a negative worker pool size to be rejected, got %v", err)
+	}
+
+	c.Server.WorkerPoolSize = 0
+	if err := c.Validate(); err != nil {
+		t.Errorf("Expected the default worker pool size to be valid, got %v", err)
+	}
+}
+
+func TestEnablePprofRequiresAdminAddr(t *testing.T) {
+	c := DefaultConfig()
+	c.FileSystem.BaseDirectory = t.TempDir()
+	c.Server.EnablePprof = true
+	if err := c.Validate(); err == nil || !strings.Contains(err.Error(), "separate admin listener") {
+		t.Errorf("Expected pprof without an admin address to be rejected, got %v", err)
+	}
+
+	c.Server.AdminAddr = "127.0.0.1:9090"
+	if err := c.Validate(); err != nil {
+		t.Errorf("Expected pprof on the admin listener to be valid, got %v", err)
+	}
+}
+
+func TestLogOutputs(t *testing.T) {
+	for _, tt := range []struct {
+		logging  LoggingConfig
+		expected string // empty when valid
+	}{
+		{LoggingConfig{Outputs: []string{"stdout"}}, ""},
+		{LoggingConfig{Outputs: []string{"stderr", "file", "otlp"}, File: "cat-server.log", OTLPEndpoint: "http://collector:4318/v1/logs"}, ""},
+		{LoggingConfig{}, "at least one log output"},
+		{LoggingConfig{Outputs: []string{"kafka"}}, "invalid log output"},
+		{LoggingConfig{Outputs: []string{"stdout", "stdout"}}, "listed twice"},
+		{LoggingConfig{Outputs: []string{"file"}}, "requires a log file"},
+		{LoggingConfig{Outputs: []string{"otlp"}, OTLPEndpoint: "collector:4318"}, "requires an http or https endpoint"},
+	} {
+		c := DefaultConfig()
+		c.FileSystem.BaseDirectory = t.TempDir()
+		tt.logging.Level, tt.logging.Format = "info", "json"
+		c.Logging = tt.logging
+		err := c.Validate()
+		if tt.expected == "" && err != nil || tt.expected != "" && (err == nil || !strings.Contains(err.Error(), tt.expected)) {
+			t.Errorf("Validate() with logging %+v returned %v, expected %q", tt.logging, err, tt.expected)
+		}
+	}
+
+	t.Setenv("CAT_SERVER_LOG_OUTPUT", "stdout, journald")
+	c := DefaultConfig()
+	if err := c.LoadFromEnv(); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(c.Logging.Outputs, []string{"stdout", "journald"}) {
+		t.Errorf("Expected outputs from CAT_SERVER_LOG_OUTPUT, got %v", c.Logging.Outputs)
+	}
+}
+
+func TestSlowRequests(t *testing.T) {
+	base := t.TempDir()
+	path := writeConfigFile(t, "cat-server.yaml", `
+filesystem:
+  base_directory: `+base+`
+logging:
+  slow_requests:
+    /search: 1s
+    /ls: 0s
+`)
+
+	fs := flag.NewFlagSet("cat-server", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	c, err := LoadFromFlagSet(fs, []string{"--config", path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]time.Duration{"/ls": 0, "/cat": 200 * time.Millisecond, "/search": time.Second}
+	if !reflect.DeepEqual(c.Logging.SlowRequests, expected) {
+		t.Errorf("Expected the file to add to and disable default thresholds, got %v", c.Logging.SlowRequests)
+	}
+
+	t.Setenv("CAT_SERVER_LOG_SLOW_REQUESTS", "/cat=1s, /ls=50ms")
+	c = DefaultConfig()
+	if err := c.LoadFromEnv(); err != nil {
+		t.Fatal(err)
+	}
+	expected = map[string]time.Duration{"/cat": time.Second, "/ls": 50 * time.Millisecond}
+	if !reflect.DeepEqual(c.Logging.SlowRequests, expected) {
+		t.Errorf("Expected thresholds from CAT_SERVER_LOG_SLOW_REQUESTS, got %v", c.Logging.SlowRequests)
+	}
+	if formatted := formatSlowRequests(c.Logging.SlowRequests); formatted != "/cat=1s,/ls=50ms" {
+		t.Errorf("Expected thresholds formatted as parsed, got %q", formatted)
+	}
+
+	for slow, expected := range map[string]string{
+		"/ls=fast": "invalid slow request threshold for /ls",
+		"/ls":      "invalid slow request threshold for /ls",
+		"ls=100ms": "must start with /",
+	} {
+		c := DefaultConfig()
+		c.FileSystem.BaseDirectory = base
+		c.Logging.SlowRequests = parseSlowRequests(slow)
+		if err := c.Validate(); err == nil || !strings.Contains(err.Error(), expected) {
+			t.Errorf("Validate() with slow requests %q returned %v, expected error containing %q", slow, err, expected)
+		}
+	}
+}
+
+func TestStatsDConfig(t *testing.T) {
+	for _, tt := range []struct {
+		metrics  MetricsConfig
+		expected string // empty when valid
+	}{
+		{MetricsConfig{}, ""},
+		{MetricsConfig{StatsDHost: "localhost", StatsDPort: 8125, StatsDPrefix: "cat_server.", StatsDTags: []string{"env:prod"}}, ""},
+		{MetricsConfig{StatsDHost: "localhost", StatsDPort: 0}, "invalid statsd port"},
+		{MetricsConfig{StatsDHost: "localhost", StatsDPort: 8125, StatsDPrefix: "cat|server"}, "invalid statsd prefix"},
+		{MetricsConfig{StatsDHost: "localhost", StatsDPort: 8125, StatsDTags: []string{"env:prod,region:eu"}}, "invalid statsd tag"},
+	} {
+		c := DefaultConfig()
+		c.FileSystem.BaseDirectory = t.TempDir()
+		c.Metrics = tt.metrics
+		err := c.Validate()
+		if tt.expected == "" && err != nil || tt.expected != "" && (err == nil || !strings.Contains(err.Error(), tt.expected)) {
+			t.Errorf("Validate() with metrics %+v returned %v, expected %q", tt.metrics, err, tt.expected)
+		}
+	}
+
+	t.Setenv("CAT_SERVER_STATSD_HOST", "agent")
+	t.Setenv("CAT_SERVER_STATSD_TAGS", "env:prod, region:eu")
+	c := DefaultConfig()
+	if err := c.LoadFromEnv(); err != nil {
+		t.Fatal(err)
+	}
+	if c.Metrics.StatsDAddr() != "agent:8125" || !reflect.DeepEqual(c.Metrics.StatsDTags, []string{"env:prod", "region:eu"}) {
+		t.Errorf("Expected the agent from the environment, got %+v", c.Metrics)
+	}
+}
+
+func TestFilesystemTimeouts(t *testing.T) {
+	for _, tt := range []struct {
+		name     string
+		modify   func(*FileSystemConfig)
+		expected string // empty when valid
+	}{
+		{"defaults", func(*FileSystemConfig) {}, ""},
+		{"unbounded", func(fs *FileSystemConfig) { fs.StatTimeout, fs.ReadTimeout, fs.ListTimeout = 0, 0, 0 }, ""},
+		{"negative timeout", func(fs *FileSystemConfig) { fs.ListTimeout = -time.Second }, "cannot be negative"},
+		{"negative threshold", func(fs *FileSystemConfig) { fs.BreakerThreshold = -1 }, "cannot be negative"},
+		{"no cooldown", func(fs *FileSystemConfig) { fs.BreakerCooldown = 0 }, "must be positive"},
+		{"breaker disabled", func(fs *FileSystemConfig) { fs.BreakerThreshold, fs.BreakerCooldown = 0, 0 }, ""},
+	} {
+		c := DefaultConfig()
+		c.FileSystem.BaseDirectory = t.TempDir()
+		tt.modify(&c.FileSystem)
+		err := c.Validate()
+		if tt.expected == "" && err != nil {
+			t.Errorf("%s: expected valid, got %v", tt.name, err)
+		}
+		if tt.expected != "" && (err == nil || !strings.Contains(err.Error(), tt.expected)) {
+			t.Errorf("%s: expected %q, got %v", tt.name, tt.expected, err)
+		}
+	}
+}
+
+func TestParseMemoryLimit(t *testing.T) {
+	for _, tt := range []struct {
+		value    string
+		expected int64
+	}{
+		{"0", 0},
+		{"1048576", 1 << 20},
+		{"512MiB", 512 << 20},
+		{"2GiB", 2 << 30},
+		{"64KiB", 64 << 10},
+		{"1TiB", 1 << 40},
+		{"100B", 100},
+		{"512MB", -1},
+		{"-1", -1},
+		{"lots", -1},
+		{"9999999TiB", -1},
+	} {
+		if limit := parseMemoryLimit(tt.value); limit != tt.expected {
+			t.Errorf("parseMemoryLimit(%q) = %d, expected %d", tt.value, limit, tt.expected)
+		}
+	}
+}
+
+func TestMemorySettings(t *testing.T) {
+	for _, tt := range []struct {
+		name     string
+		modify   func(*ServerConfig)
+		expected string // empty when valid
+	}{
+		{"defaults", func(*ServerConfig) {}, ""},
+		{"limit", func(s *ServerConfig) { s.MemoryLimit, s.GCPercent = 512<<20, 50 }, ""},
+		{"gc off", func(s *ServerConfig) { s.GCPercent = -1 }, ""},
+		{"invalid limit", func(s *ServerConfig) { s.MemoryLimit = -1 }, "invalid memory limit"},
+		{"invalid gc percent", func(s *ServerConfig) { s.GCPercent = -2 }, "below -1"},
+		{"reject percent too high", func(s *ServerConfig) { s.MemoryRejectPercent = 120 }, "between 0 and 100"},
+		{"negative large read", func(s *ServerConfig) { s.LargeReadBytes = -1 }, "cannot be negative"},
+	} {
+		c := DefaultConfig()
+		c.FileSystem.BaseDirectory = t.TempDir()
+		tt.modify(&c.Server)
+		err := c.Validate()
+		if tt.expected == "" && err != nil {
+			t.Errorf("%s: expected valid, got %v", tt.name, err)
+		}
+		if tt.expected != "" && (err == nil || !strings.Contains(err.Error(), tt.expected)) {
+			t.Errorf("%s: expected %q, got %v", tt.name, tt.expected, err)
+		}
+	}
+}
+
+func TestSignedURLConfig(t *testing.T) {
+	fs := flag.NewFlagSet("cat-server", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	c, err := LoadFromFlagSet(fs, []string{"--dir", t.TempDir(), "--signing-keys", "old:s3cret, new:n3w", "--signing-current-key", "new"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := SignedURLConfig{
+		Keys:       []SigningKeyConfig{{ID: "old", Secret: "s3cret"}, {ID: "new", Secret: "n3w"}},
+		CurrentKey: "new",
+		ClockSkew:  30 * time.Second,
+	}
+	if !reflect.DeepEqual(c.Security.SignedURLs, expected) {
+		t.Errorf("Expected signed URLs %+v, got %+v", expected, c.Security.SignedURLs)
+	}
+	if redacted := c.Redacted(); redacted.Security.SignedURLs.Keys[0].Secret != redactedValue || c.Security.SignedURLs.Keys[0].Secret != "s3cret" {
+		t.Errorf("Expected only the redacted copy to mask secrets, got %+v and %+v", redacted.Security.SignedURLs.Keys, c.Security.SignedURLs.Keys)
+	}
+
+	for _, tt := range []struct {
+		signed   SignedURLConfig
+		expected string
+	}{
+		{SignedURLConfig{Keys: parseSigningKeys("k1")}, "need an id and a secret"},
+		{SignedURLConfig{Keys: parseSigningKeys("k1:a,k1:b")}, "duplicate signing key id"},
+		{SignedURLConfig{Keys: parseSigningKeys("k1:a"), CurrentKey: "k2"}, "current signing key k2 is not configured"},
+		{SignedURLConfig{CurrentKey: "k1"}, "current signing key k1 is not configured"},
+		{SignedURLConfig{Keys: parseSigningKeys("k1:a"), ClockSkew: -time.Second}, "clock skew cannot be negative"},
+	} {
+		c := DefaultConfig()
+		c.FileSystem.BaseDirectory = t.TempDir()
+		c.Security.SignedURLs = tt.signed
+		if err := c.Validate(); err == nil || !strings.Contains(err.Error(), tt.expected) {
+			t.Errorf("Validate() with signed URLs %+v returned %v, expected error containing %q", tt.signed, err, tt.expected)
+		}
+	}
+}
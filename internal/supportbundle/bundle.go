@@ -0,0 +1,148 @@
+package supportbundle
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/sh05/cat-server/internal/config"
+)
+
+// Sources holds the diagnostics collected into a support bundle. Nil or
+// empty sources are recorded as unavailable rather than omitted.
+type Sources struct {
+	Config  *config.Config
+	Version string
+	Health  interface{}
+	Metrics func(io.Writer) error
+	Logs    []byte
+	Routes  []string
+}
+
+// sensitiveEnvMarkers identify environment variables whose values are redacted
+var sensitiveEnvMarkers = []string{"TOKEN", "SECRET", "PASSWORD", "KEY", "CREDENTIAL"}
+
+// Write writes a tar.gz support bundle built from sources to w
+func Write(w io.Writer, sources *Sources) error {
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+	now := time.Now()
+
+	files := []struct {
+		name    string
+		content func() ([]byte, error)
+	}{
+		{"config.json", func() ([]byte, error) { return marshal(redactedConfig(sources.Config)) }},
+		{"environment.json", func() ([]byte, error) { return marshal(environment(sources.Version, now)) }},
+		{"health.json", func() ([]byte, error) { return marshal(sources.Health) }},
+		{"metrics.txt", func() ([]byte, error) { return metrics(sources.Metrics) }},
+		{"routes.txt", func() ([]byte, error) { return []byte(strings.Join(sources.Routes, "\n") + "\n"), nil }},
+		{"logs.jsonl", func() ([]byte, error) { return sources.Logs, nil }},
+	}
+
+	for _, file := range files {
+		content, err := file.content()
+		if err != nil {
+			content = []byte(fmt.Sprintf("unavailable: %v\n", err))
+		}
+		if err := addFile(tw, file.name, content, now); err != nil {
+			return fmt.Errorf("failed to add %s: %w", file.name, err)
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return fmt.Errorf("failed to finalize tar: %w", err)
+	}
+	if err := gz.Close(); err != nil {
+		return fmt.Errorf("failed to finalize gzip: %w", err)
+	}
+	return nil
+}
+
+// Filename returns the suggested file name for a bundle created at t
+func Filename(t time.Time) string {
+	return "cat-server-support-" + t.UTC().Format("20060102-150405") + ".tar.gz"
+}
+
+func addFile(tw *tar.Writer, name string, content []byte, modTime time.Time) error {
+	header := &tar.Header{
+		Name:    name,
+		Mode:    0644,
+		Size:    int64(len(content)),
+		ModTime: modTime,
+	}
+	if err := tw.WriteHeader(header); err != nil {
+		return err
+	}
+	_, err := tw.Write(content)
+	return err
+}
+
+func marshal(v interface{}) ([]byte, error) {
+	if v == nil {
+		return nil, fmt.Errorf("not collected")
+	}
+	return json.MarshalIndent(v, "", "  ")
+}
+
+func metrics(write func(io.Writer) error) ([]byte, error) {
+	if write == nil {
+		return nil, fmt.Errorf("not collected")
+	}
+	var sb strings.Builder
+	if err := write(&sb); err != nil {
+		return nil, err
+	}
+	return []byte(sb.String()), nil
+}
+
+func redactedConfig(cfg *config.Config) interface{} {
+	if cfg == nil {
+		return nil
+	}
+	return cfg.Redacted()
+}
+
+func environment(version string, now time.Time) map[string]interface{} {
+	hostname, _ := os.Hostname()
+
+	env := make(map[string]string)
+	for _, kv := range os.Environ() {
+		key, value, _ := strings.Cut(kv, "=")
+		if !strings.HasPrefix(key, "CAT_SERVER_") {
+			continue
+		}
+		if isSensitive(key) {
+			value = "[REDACTED]"
+		}
+		env[key] = value
+	}
+
+	return map[string]interface{}{
+		"version":     version,
+		"goVersion":   runtime.Version(),
+		"os":          runtime.GOOS,
+		"arch":        runtime.GOARCH,
+		"numCPU":      runtime.NumCPU(),
+		"hostname":    hostname,
+		"pid":         os.Getpid(),
+		"generatedAt": now,
+		"env":         env,
+	}
+}
+
+func isSensitive(key string) bool {
+	upper := strings.ToUpper(key)
+	for _, marker := range sensitiveEnvMarkers {
+		if strings.Contains(upper, marker) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,85 @@
+package supportbundle
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/sh05/cat-server/internal/config"
+)
+
+func TestWrite(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.Security.AdminToken = "super-secret"
+
+	sources := &Sources{
+		Config:  cfg,
+		Version: "1.0.0",
+		Health:  map[string]string{"status": "healthy"},
+		Metrics: func(w io.Writer) error {
+			_, err := io.WriteString(w, "metric 1\n")
+			return err
+		},
+		Logs:   []byte(`{"msg":"hello"}` + "\n"),
+		Routes: []string{"/health", "/ls"},
+	}
+
+	var buf bytes.Buffer
+	if err := Write(&buf, sources); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+
+	files := readBundle(t, &buf)
+	for _, name := range []string{"config.json", "environment.json", "health.json", "metrics.txt", "routes.txt", "logs.jsonl"} {
+		if _, ok := files[name]; !ok {
+			t.Errorf("Expected bundle to contain %s", name)
+		}
+	}
+
+	if strings.Contains(files["config.json"], "super-secret") {
+		t.Error("Expected admin token to be redacted from config.json")
+	}
+	if files["routes.txt"] != "/health\n/ls\n" {
+		t.Errorf("Unexpected routes.txt: %q", files["routes.txt"])
+	}
+}
+
+func TestIsSensitive(t *testing.T) {
+	tests := map[string]bool{
+		"CAT_SERVER_ADMIN_TOKEN": true,
+		"CAT_SERVER_API_KEY":     true,
+		"CAT_SERVER_PORT":        false,
+	}
+	for key, want := range tests {
+		if got := isSensitive(key); got != want {
+			t.Errorf("isSensitive(%q) = %v, want %v", key, got, want)
+		}
+	}
+}
+
+func readBundle(t *testing.T, r io.Reader) map[string]string {
+	t.Helper()
+
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		t.Fatalf("Failed to open gzip: %v", err)
+	}
+	tr := tar.NewReader(gz)
+
+	files := make(map[string]string)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Failed to read tar: %v", err)
+		}
+		content, _ := io.ReadAll(tr)
+		files[header.Name] = string(content)
+	}
+	return files
+}
@@ -0,0 +1,333 @@
+package services
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/sh05/cat-server/pkg/domain/entities"
+	"github.com/sh05/cat-server/pkg/domain/repositories"
+	"github.com/sh05/cat-server/pkg/domain/valueobjects"
+	"github.com/sh05/cat-server/pkg/infrastructure/logging"
+)
+
+// ErrUnsupportedArchiveFormat is returned for unknown archive formats
+var ErrUnsupportedArchiveFormat = errors.New("unsupported archive format")
+
+// Supported archive formats
+const (
+	ArchiveFormatTarGz = "tar.gz"
+	ArchiveFormatZip   = "zip"
+)
+
+// ArchiveService provides use cases for building archives of files
+type ArchiveService struct {
+	fileSystemRepo repositories.FileSystemRepository
+	logger         *logging.Logger
+	redactor       Redactor
+}
+
+// NewArchiveService creates a new ArchiveService
+func NewArchiveService(fileSystemRepo repositories.FileSystemRepository, logger *logging.Logger) *ArchiveService {
+	return &ArchiveService{
+		fileSystemRepo: fileSystemRepo,
+		logger:         logger,
+	}
+}
+
+// WithLogger returns a copy of the service that logs through the given logger.
+// Handlers use it to attach request-scoped fields to filesystem operation logs.
+func (s *ArchiveService) WithLogger(logger *logging.Logger) *ArchiveService {
+	clone := *s
+	clone.logger = logger
+	return &clone
+}
+
+// WithRedactor returns a copy of the service that masks secrets in the
+// contents of matching files, see FileService.WithRedactor
+func (s *ArchiveService) WithRedactor(redactor Redactor) *ArchiveService {
+	clone := *s
+	clone.redactor = redactor
+	return &clone
+}
+
+// redacts reports whether filename's contents are redacted
+func (s *ArchiveService) redacts(filename string) bool {
+	return s.redactor != nil && s.redactor.Matches(filename)
+}
+
+// ArchiveDirectoryRequest represents a request to archive a directory
+type ArchiveDirectoryRequest struct {
+	Path          string
+	IncludeHidden bool
+	Exclude       []string // glob patterns matched against names and relative paths
+}
+
+// ArchiveEntry is a file to be written into an archive
+type ArchiveEntry struct {
+	SourcePath  string // path relative to the base directory
+	ArchivePath string // path inside the archive
+	Size        int64
+	ModTime     time.Time
+}
+
+// NormalizeArchiveFormat validates an archive format name and returns its
+// canonical form
+func NormalizeArchiveFormat(format string) (string, error) {
+	switch format {
+	case "", ArchiveFormatTarGz, "tgz":
+		return ArchiveFormatTarGz, nil
+	case ArchiveFormatZip:
+		return ArchiveFormatZip, nil
+	default:
+		return "", fmt.Errorf("%w: %s", ErrUnsupportedArchiveFormat, format)
+	}
+}
+
+// CollectDirectory walks a directory and returns the files to archive.
+// Entries are collected up front so errors can be reported before any
+// archive bytes are streamed.
+func (s *ArchiveService) CollectDirectory(ctx context.Context, request *ArchiveDirectoryRequest) ([]ArchiveEntry, error) {
+	start := time.Now()
+
+	for _, pattern := range request.Exclude {
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("%w: bad exclude pattern %q", ErrInvalidPattern, pattern)
+		}
+	}
+
+	root, err := valueobjects.NewFilePath(request.Path)
+	if err != nil {
+		s.logger.LogFileSystemOperation("collect_archive", request.Path, false, time.Since(start), 0)
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPath, err)
+	}
+
+	var entries []ArchiveEntry
+	var totalSize int64
+	err = walkFiles(ctx, s.fileSystemRepo, root, request.IncludeHidden, func(rel string, entry entities.FileSystemEntry) error {
+		if isExcluded(rel, request.Exclude) {
+			return nil
+		}
+		entries = append(entries, ArchiveEntry{
+			SourcePath:  entry.Path(),
+			ArchivePath: filepath.ToSlash(rel),
+			Size:        entry.Size(),
+			ModTime:     entry.ModTime(),
+		})
+		totalSize += entry.Size()
+		return nil
+	})
+	if err != nil {
+		s.logger.LogFileSystemOperation("collect_archive", request.Path, false, time.Since(start), 0)
+		return nil, fmt.Errorf("failed to walk directory: %w", err)
+	}
+
+	s.logger.LogFileSystemOperation("collect_archive", request.Path, true, time.Since(start), totalSize)
+
+	return entries, nil
+}
+
+// MaxArchiveFiles is the maximum number of files accepted by CollectFiles
+const MaxArchiveFiles = 1000
+
+// CollectFiles validates an explicit list of relative file paths and returns
+// them as archive entries. Every path goes through the same traversal checks
+// as single-file reads; duplicates are ignored.
+func (s *ArchiveService) CollectFiles(ctx context.Context, paths []string) ([]ArchiveEntry, error) {
+	start := time.Now()
+
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("%w: no files requested", ErrInvalidPath)
+	}
+	if len(paths) > MaxArchiveFiles {
+		return nil, fmt.Errorf("%w: too many files requested (max: %d)", ErrInvalidPath, MaxArchiveFiles)
+	}
+
+	seen := make(map[string]bool, len(paths))
+	entries := make([]ArchiveEntry, 0, len(paths))
+	var totalSize int64
+
+	for _, p := range paths {
+		filePath, err := valueobjects.NewFilePath(p)
+		if err != nil {
+			s.logger.LogFileSystemOperation("collect_archive", p, false, time.Since(start), 0)
+			s.logger.LogSecurityEvent("invalid_path", p, "", "", true)
+			return nil, fmt.Errorf("%w: %s: %v", ErrInvalidPath, p, err)
+		}
+
+		if err := s.fileSystemRepo.ValidatePath(filePath); err != nil {
+			s.logger.LogFileSystemOperation("collect_archive", p, false, time.Since(start), 0)
+			s.logger.LogSecurityEvent("path_traversal", p, "", "", true)
+			return nil, err
+		}
+
+		if seen[filePath.String()] {
+			continue
+		}
+		seen[filePath.String()] = true
+
+		info, err := s.fileSystemRepo.GetFileInfo(ctx, filePath)
+		if err != nil {
+			s.logger.LogFileSystemOperation("collect_archive", p, false, time.Since(start), 0)
+			return nil, err
+		}
+		if info.IsDir() {
+			s.logger.LogFileSystemOperation("collect_archive", p, false, time.Since(start), 0)
+			return nil, fmt.Errorf("%w: %s is a directory", ErrInvalidPath, p)
+		}
+
+		entries = append(entries, ArchiveEntry{
+			SourcePath:  filePath.String(),
+			ArchivePath: filepath.ToSlash(filePath.String()),
+			Size:        info.Size(),
+			ModTime:     info.ModTime(),
+		})
+		totalSize += info.Size()
+	}
+
+	s.logger.LogFileSystemOperation("collect_archive", fmt.Sprintf("%d files", len(entries)), true, time.Since(start), totalSize)
+
+	return entries, nil
+}
+
+// WriteArchive streams the given entries to w in the requested format.
+// Files that cannot be read are skipped and logged, as are redacted files
+// that are not text; redacted text files are archived masked.
+func (s *ArchiveService) WriteArchive(ctx context.Context, w io.Writer, format string, entries []ArchiveEntry) error {
+	start := time.Now()
+
+	var aw archiveWriter
+	switch format {
+	case ArchiveFormatTarGz:
+		aw = newTarGzWriter(w)
+	case ArchiveFormatZip:
+		aw = &zipArchiveWriter{zw: zip.NewWriter(w)}
+	default:
+		return fmt.Errorf("%w: %s", ErrUnsupportedArchiveFormat, format)
+	}
+
+	var written int64
+	for _, entry := range entries {
+		filePath, err := valueobjects.NewFilePath(entry.SourcePath)
+		if err != nil {
+			s.logger.LogFileSystemOperation("archive_file", entry.SourcePath, false, 0, 0)
+			continue
+		}
+
+		fileContent, err := s.fileSystemRepo.ReadFile(ctx, filePath)
+		if err != nil {
+			s.logger.LogError(err, "skipping file in archive", "path", entry.SourcePath)
+			continue
+		}
+
+		content := fileContent.Content()
+		if s.redacts(entry.SourcePath) {
+			if !fileContent.IsTextContent() {
+				s.logger.LogError(ErrRedacted, "skipping file in archive", "path", entry.SourcePath)
+				continue
+			}
+			content = []byte(s.redactor.Redact(fileContent.ContentAsString()))
+		}
+		if err := aw.add(entry, content); err != nil {
+			s.logger.LogFileSystemOperation("write_archive", format, false, time.Since(start), written)
+			return fmt.Errorf("failed to write %s: %w", entry.ArchivePath, err)
+		}
+		written += int64(len(content))
+	}
+
+	if err := aw.close(); err != nil {
+		s.logger.LogFileSystemOperation("write_archive", format, false, time.Since(start), written)
+		return fmt.Errorf("failed to finalize archive: %w", err)
+	}
+
+	s.logger.LogFileSystemOperation("write_archive", format, true, time.Since(start), written)
+
+	return nil
+}
+
+// Helper types and functions
+
+type archiveWriter interface {
+	add(entry ArchiveEntry, content []byte) error
+	close() error
+}
+
+type tarGzArchiveWriter struct {
+	gz *gzip.Writer
+	tw *tar.Writer
+}
+
+func newTarGzWriter(w io.Writer) *tarGzArchiveWriter {
+	gz := gzip.NewWriter(w)
+	return &tarGzArchiveWriter{gz: gz, tw: tar.NewWriter(gz)}
+}
+
+func (a *tarGzArchiveWriter) add(entry ArchiveEntry, content []byte) error {
+	header := &tar.Header{
+		Name:    entry.ArchivePath,
+		Mode:    0644,
+		Size:    int64(len(content)),
+		ModTime: entry.ModTime,
+	}
+	if err := a.tw.WriteHeader(header); err != nil {
+		return err
+	}
+	_, err := a.tw.Write(content)
+	return err
+}
+
+func (a *tarGzArchiveWriter) close() error {
+	if err := a.tw.Close(); err != nil {
+		return err
+	}
+	return a.gz.Close()
+}
+
+type zipArchiveWriter struct {
+	zw *zip.Writer
+}
+
+func (a *zipArchiveWriter) add(entry ArchiveEntry, content []byte) error {
+	header := &zip.FileHeader{
+		Name:     entry.ArchivePath,
+		Method:   zip.Deflate,
+		Modified: entry.ModTime,
+	}
+	header.SetMode(0644)
+	fw, err := a.zw.CreateHeader(header)
+	if err != nil {
+		return err
+	}
+	_, err = fw.Write(content)
+	return err
+}
+
+func (a *zipArchiveWriter) close() error {
+	return a.zw.Close()
+}
+
+// isExcluded reports whether the relative path, or any directory along it,
+// matches one of the exclude patterns
+func isExcluded(rel string, patterns []string) bool {
+	rel = filepath.ToSlash(rel)
+	segments := strings.Split(rel, "/")
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, rel); ok {
+			return true
+		}
+		for _, segment := range segments {
+			if ok, _ := path.Match(pattern, segment); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,78 @@
+package services
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseColumns(t *testing.T) {
+	tests := []struct {
+		spec     string
+		expected []int
+		wantErr  bool
+	}{
+		{"1", []int{0}, false},
+		{"3,1", []int{2, 0}, false},
+		{"2-4", []int{1, 2, 3}, false},
+		{"1, 3-4", []int{0, 2, 3}, false},
+		{"", nil, true},
+		{"0", nil, true},
+		{"a", nil, true},
+		{"4-2", nil, true},
+		{"1-100000", nil, true},
+	}
+
+	for _, tt := range tests {
+		columns, err := ParseColumns(tt.spec)
+		if tt.wantErr {
+			if !errors.Is(err, ErrInvalidColumns) {
+				t.Errorf("ParseColumns(%q): expected ErrInvalidColumns, got %v", tt.spec, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParseColumns(%q) returned error: %v", tt.spec, err)
+			continue
+		}
+		if len(columns) != len(tt.expected) {
+			t.Errorf("ParseColumns(%q): expected %v, got %v", tt.spec, tt.expected, columns)
+			continue
+		}
+		for i := range columns {
+			if columns[i] != tt.expected[i] {
+				t.Errorf("ParseColumns(%q): expected %v, got %v", tt.spec, tt.expected, columns)
+				break
+			}
+		}
+	}
+}
+
+func TestParseDelimiter(t *testing.T) {
+	tests := []struct {
+		value    string
+		filename string
+		expected rune
+		wantErr  bool
+	}{
+		{"", "export.csv", ',', false},
+		{"", "export.TSV", '\t', false},
+		{"tab", "export.csv", '\t', false},
+		{";", "export.csv", ';', false},
+		{"|", "export.txt", '|', false},
+		{"ab", "export.csv", 0, true},
+		{"\"", "export.csv", 0, true},
+	}
+
+	for _, tt := range tests {
+		delimiter, err := ParseDelimiter(tt.value, tt.filename)
+		if tt.wantErr {
+			if !errors.Is(err, ErrInvalidColumns) {
+				t.Errorf("ParseDelimiter(%q): expected ErrInvalidColumns, got %v", tt.value, err)
+			}
+			continue
+		}
+		if err != nil || delimiter != tt.expected {
+			t.Errorf("ParseDelimiter(%q, %q) = %q, %v; expected %q", tt.value, tt.filename, delimiter, err, tt.expected)
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/sh05/cat-server/pkg/domain/entities"
+	"github.com/sh05/cat-server/pkg/domain/repositories"
+	"github.com/sh05/cat-server/pkg/domain/valueobjects"
+	"github.com/sh05/cat-server/pkg/infrastructure/cache"
+)
+
+// ErrInvalidContentTypeFilter is returned for malformed content_type filters
+var ErrInvalidContentTypeFilter = errors.New("invalid content type filter")
+
+// Content sniffing limits
+const (
+	contentSniffSize = 512
+	// DefaultContentTypeCacheSize bounds the cached MIME types; at roughly
+	// 16 bytes per value this holds tens of thousands of files
+	DefaultContentTypeCacheSize = 512 * 1024
+)
+
+// ParseContentTypeFilter parses a comma-separated list of MIME types or
+// families such as "text/*,application/json"
+func ParseContentTypeFilter(spec string) ([]string, error) {
+	if spec == "" {
+		return nil, nil
+	}
+
+	patterns := strings.Split(spec, ",")
+	for i, pattern := range patterns {
+		pattern = strings.ToLower(strings.TrimSpace(pattern))
+		major, minor, ok := strings.Cut(pattern, "/")
+		if !ok || major == "" || minor == "" || major == "*" || strings.ContainsAny(pattern, " ;") {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidContentTypeFilter, pattern)
+		}
+		patterns[i] = pattern
+	}
+
+	return patterns, nil
+}
+
+// matchContentType reports whether a MIME type, parameters ignored, matches
+// any of the patterns
+func matchContentType(patterns []string, mimeType string) bool {
+	mimeType, _, _ = strings.Cut(mimeType, ";")
+	mimeType = strings.ToLower(strings.TrimSpace(mimeType))
+	major, _, _ := strings.Cut(mimeType, "/")
+
+	for _, pattern := range patterns {
+		if pattern == mimeType || pattern == major+"/*" {
+			return true
+		}
+	}
+	return false
+}
+
+// contentSniffer detects file types from their first bytes, caching results
+// by path, modification time and size
+type contentSniffer struct {
+	fileSystemRepo repositories.FileSystemRepository
+	cache          *cache.LRU
+}
+
+// sniff returns the MIME type of a file and whether it is text. ok is false
+// when the file cannot be read.
+func (c *contentSniffer) sniff(ctx context.Context, entry entities.FileSystemEntry) (mimeType string, isText bool, ok bool) {
+	key := fmt.Sprintf("%s|%d|%d", entry.Path(), entry.ModTime().UnixNano(), entry.Size())
+	if cached, hit := c.cache.Get(key); hit && len(cached) > 0 {
+		return string(cached[1:]), cached[0] == 't', true
+	}
+
+	filePath, err := valueobjects.NewFilePath(entry.Path())
+	if err != nil {
+		return "", false, false
+	}
+
+	f, err := c.fileSystemRepo.OpenFile(ctx, filePath)
+	if err != nil {
+		return "", false, false
+	}
+	defer f.Close()
+
+	head := make([]byte, contentSniffSize)
+	n, err := io.ReadFull(f, head)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return "", false, false
+	}
+
+	fileType := valueobjects.DetectFileType(head[:n])
+	kind := byte('b')
+	if fileType.IsText() {
+		kind = 't'
+	}
+	c.cache.Add(key, append([]byte{kind}, fileType.MimeType()...))
+
+	return fileType.MimeType(), fileType.IsText(), true
+}
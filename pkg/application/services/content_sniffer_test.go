@@ -0,0 +1,43 @@
+package services
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseContentTypeFilter(t *testing.T) {
+	patterns, err := ParseContentTypeFilter("text/*, Image/PNG")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(patterns) != 2 || patterns[0] != "text/*" || patterns[1] != "image/png" {
+		t.Errorf("Expected [text/* image/png], got %q", patterns)
+	}
+
+	for _, spec := range []string{"text", "*/*", "/plain", "text/", "text/plain; charset=utf-8"} {
+		if _, err := ParseContentTypeFilter(spec); !errors.Is(err, ErrInvalidContentTypeFilter) {
+			t.Errorf("Expected ErrInvalidContentTypeFilter for %q, got %v", spec, err)
+		}
+	}
+}
+
+func TestMatchContentType(t *testing.T) {
+	tests := []struct {
+		patterns []string
+		mimeType string
+		expected bool
+	}{
+		{[]string{"text/*"}, "text/plain; charset=utf-8", true},
+		{[]string{"text/plain"}, "text/plain", true},
+		{[]string{"text/plain"}, "text/html", false},
+		{[]string{"image/*"}, "image/png", true},
+		{[]string{"image/*"}, "application/pdf", false},
+		{[]string{"image/png", "application/*"}, "application/zip", true},
+	}
+
+	for _, tt := range tests {
+		if result := matchContentType(tt.patterns, tt.mimeType); result != tt.expected {
+			t.Errorf("Expected %v for %q against %q, got %v", tt.expected, tt.mimeType, tt.patterns, result)
+		}
+	}
+}
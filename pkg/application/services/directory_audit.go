@@ -0,0 +1,183 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/sh05/cat-server/pkg/domain/entities"
+	"github.com/sh05/cat-server/pkg/domain/repositories"
+	"github.com/sh05/cat-server/pkg/domain/valueobjects"
+)
+
+// Audit problem kinds
+const (
+	AuditPermissionDenied = "permission_denied"
+	AuditSymlink          = "symlink"
+	AuditSkipped          = "skipped"
+	AuditSpecialFile      = "special_file"
+)
+
+// MaxAuditProblems bounds the problems reported by one audit
+const MaxAuditProblems = 1000
+
+// AuditRequest represents a request to audit a directory tree
+type AuditRequest struct {
+	Path          string
+	IncludeHidden bool
+}
+
+// AuditResponse lists entries under a directory that the server can see
+// but cannot serve
+type AuditResponse struct {
+	Path        string            `json:"path"`
+	Problems    []AuditProblemDTO `json:"problems"`
+	Counts      map[string]int    `json:"counts"`
+	Scanned     int               `json:"scanned"`
+	Truncated   bool              `json:"truncated,omitempty"`
+	GeneratedAt time.Time         `json:"generatedAt"`
+}
+
+// AuditProblemDTO describes one entry that cannot be served
+type AuditProblemDTO struct {
+	Path   string `json:"path"`
+	Kind   string `json:"kind"`
+	Reason string `json:"reason"`
+}
+
+// Audit walks a directory tree and reports entries that cannot be served:
+// files and directories without read permission, symlinks (which are never
+// followed), special files such as sockets and FIFOs, and entries the listing
+// skipped because their metadata could not be read. Unlike other walks, an
+// unreadable subdirectory is reported rather than failing the audit.
+func (s *DirectoryService) Audit(ctx context.Context, request *AuditRequest) (*AuditResponse, error) {
+	start := time.Now()
+	operation := "audit"
+
+	root, err := valueobjects.NewFilePath(request.Path)
+	if err != nil {
+		s.logger.LogFileSystemOperation(operation, request.Path, false, time.Since(start), 0)
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPath, err)
+	}
+
+	response := &AuditResponse{
+		Path:     request.Path,
+		Problems: []AuditProblemDTO{},
+		Counts: map[string]int{
+			AuditPermissionDenied: 0,
+			AuditSymlink:          0,
+			AuditSkipped:          0,
+			AuditSpecialFile:      0,
+		},
+	}
+	report := func(rel, kind, reason string) {
+		response.Counts[kind]++
+		if len(response.Problems) >= MaxAuditProblems {
+			response.Truncated = true
+			return
+		}
+		response.Problems = append(response.Problems, AuditProblemDTO{Path: filepath.ToSlash(rel), Kind: kind, Reason: reason})
+	}
+
+	var walk func(dir *valueobjects.FilePath, prefix string) error
+	walk = func(dir *valueobjects.FilePath, prefix string) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		listing, err := s.fileSystemRepo.ListDirectory(ctx, dir)
+		if err != nil {
+			var fsErr *repositories.FileSystemError
+			if prefix != "" && errors.As(err, &fsErr) && fsErr.Code == repositories.ErrorPermissionDenied {
+				report(prefix, AuditPermissionDenied, "directory cannot be listed")
+				return nil
+			}
+			return err
+		}
+
+		for _, skipped := range listing.Skipped() {
+			report(filepath.Join(prefix, skipped.Name), AuditSkipped, skipped.Reason)
+		}
+
+		for _, entry := range listing.Entries() {
+			if !request.IncludeHidden && entry.IsHidden() {
+				continue
+			}
+			response.Scanned++
+
+			rel := filepath.Join(prefix, entry.Name())
+			child, err := dir.Join(entry.Name())
+			if err != nil {
+				report(rel, AuditSkipped, err.Error())
+				continue
+			}
+
+			if entry.IsDir() {
+				if err := walk(child, rel); err != nil {
+					return err
+				}
+				continue
+			}
+
+			if kind, reason := s.auditEntry(ctx, child, entry); kind != "" {
+				report(rel, kind, reason)
+			}
+		}
+
+		return nil
+	}
+
+	if err := walk(root, ""); err != nil {
+		s.logger.LogFileSystemOperation(operation, request.Path, false, time.Since(start), 0)
+		return nil, fmt.Errorf("failed to scan directory: %w", err)
+	}
+
+	sort.SliceStable(response.Problems, func(i, j int) bool {
+		return response.Problems[i].Path < response.Problems[j].Path
+	})
+	response.GeneratedAt = time.Now()
+
+	s.logger.LogFileSystemOperation(operation, request.Path, true, time.Since(start), 0)
+
+	return response, nil
+}
+
+// auditEntry returns the problem kind and reason for a non-directory entry,
+// or "" if it can be served. Special files are never opened, since opening a
+// FIFO blocks until a writer appears.
+func (s *DirectoryService) auditEntry(ctx context.Context, path *valueobjects.FilePath, entry entities.FileSystemEntry) (string, string) {
+	mode := entry.Permissions()
+
+	if mode&os.ModeSymlink != 0 {
+		return AuditSymlink, "symlinks are not served"
+	}
+
+	if !mode.IsRegular() && !mode.IsDir() {
+		return AuditSpecialFile, fmt.Sprintf("%s is not a regular file", fileModeKind(mode))
+	}
+
+	if !s.fileSystemRepo.IsReadable(ctx, path) {
+		return AuditPermissionDenied, "file cannot be opened for reading"
+	}
+
+	return "", ""
+}
+
+// fileModeKind names the type of a non-regular file
+func fileModeKind(mode os.FileMode) string {
+	switch {
+	case mode&os.ModeNamedPipe != 0:
+		return "named pipe"
+	case mode&os.ModeSocket != 0:
+		return "socket"
+	case mode&os.ModeCharDevice != 0:
+		return "character device"
+	case mode&os.ModeDevice != 0:
+		return "device"
+	}
+	return "special file"
+}
@@ -0,0 +1,62 @@
+//go:build unix
+
+package services
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+
+	"github.com/sh05/cat-server/pkg/infrastructure/filesystem"
+	"github.com/sh05/cat-server/pkg/infrastructure/logging"
+)
+
+func TestAudit(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "sub"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "ok.txt"), []byte("ok\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink("ok.txt", filepath.Join(dir, "good-link")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink("missing.txt", filepath.Join(dir, "sub", "dangling")); err != nil {
+		t.Fatal(err)
+	}
+	if err := syscall.Mkfifo(filepath.Join(dir, "pipe"), 0644); err != nil {
+		t.Skipf("mkfifo not supported: %v", err)
+	}
+
+	repo := filesystem.NewFileSystemRepository(dir, 1024*1024)
+	service := NewDirectoryService(repo, logging.NewLoggerWithWriter(logging.LevelError, "json", io.Discard))
+
+	response, err := service.Audit(context.Background(), &AuditRequest{Path: "."})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	expected := []AuditProblemDTO{
+		{Path: "good-link", Kind: AuditSymlink},
+		{Path: "pipe", Kind: AuditSpecialFile},
+		{Path: "sub/dangling", Kind: AuditSymlink},
+	}
+	if len(response.Problems) != len(expected) {
+		t.Fatalf("Expected %d problems, got %+v", len(expected), response.Problems)
+	}
+	for i, problem := range expected {
+		if response.Problems[i].Path != problem.Path || response.Problems[i].Kind != problem.Kind {
+			t.Errorf("Expected %s %s, got %+v", problem.Path, problem.Kind, response.Problems[i])
+		}
+	}
+	if response.Counts[AuditSymlink] != 2 || response.Counts[AuditSpecialFile] != 1 {
+		t.Errorf("Unexpected counts %v", response.Counts)
+	}
+	if response.Scanned != 5 {
+		t.Errorf("Expected 5 scanned entries, got %d", response.Scanned)
+	}
+}
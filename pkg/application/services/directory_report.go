@@ -0,0 +1,225 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/sh05/cat-server/pkg/domain/entities"
+	"github.com/sh05/cat-server/pkg/domain/valueobjects"
+)
+
+// Report limits
+const (
+	DefaultReportTopN = 10
+	MaxReportTopN     = 100
+)
+
+// reportBuckets are the upper bounds (exclusive) of the size histogram
+// buckets; the last bucket is open-ended
+var reportBuckets = []struct {
+	label string
+	max   int64
+}{
+	{"empty", 1},
+	{"<1KB", 1 << 10},
+	{"1KB-10KB", 10 << 10},
+	{"10KB-100KB", 100 << 10},
+	{"100KB-1MB", 1 << 20},
+	{"1MB-10MB", 10 << 20},
+	{"10MB-100MB", 100 << 20},
+	{"100MB-1GB", 1 << 30},
+	{">=1GB", 0},
+}
+
+// ReportRequest represents a request for directory analytics
+type ReportRequest struct {
+	Path          string
+	IncludeHidden bool
+	TopN          int
+}
+
+// ReportResponse summarizes the files under a directory
+type ReportResponse struct {
+	Path        string              `json:"path"`
+	TotalFiles  int                 `json:"totalFiles"`
+	TotalSize   int64               `json:"totalSize"`
+	Text        FileGroupStatsDTO   `json:"text"`
+	Binary      FileGroupStatsDTO   `json:"binary"`
+	Unreadable  int                 `json:"unreadable"`
+	Histogram   []SizeBucketDTO     `json:"histogram"`
+	Extensions  []ExtensionStatsDTO `json:"extensions"`
+	Largest     []LargestFileDTO    `json:"largest"`
+	GeneratedAt time.Time           `json:"generatedAt"`
+}
+
+// FileGroupStatsDTO counts the files in a group and their combined size
+type FileGroupStatsDTO struct {
+	Count     int   `json:"count"`
+	TotalSize int64 `json:"totalSize"`
+}
+
+// SizeBucketDTO is one bucket of the file size histogram
+type SizeBucketDTO struct {
+	Label     string `json:"label"`
+	Count     int    `json:"count"`
+	TotalSize int64  `json:"totalSize"`
+}
+
+// ExtensionStatsDTO counts the files sharing a lowercase extension.
+// Files without an extension are grouped under "".
+type ExtensionStatsDTO struct {
+	Extension string `json:"extension"`
+	Count     int    `json:"count"`
+	TotalSize int64  `json:"totalSize"`
+}
+
+// LargestFileDTO is a file path relative to the report root and its size
+type LargestFileDTO struct {
+	Path string `json:"path"`
+	Size int64  `json:"size"`
+}
+
+// Report walks a directory and aggregates file sizes, extensions and the
+// text/binary split. Content type is sniffed from the first bytes of each
+// file; files that cannot be opened are counted as unreadable.
+func (s *DirectoryService) Report(ctx context.Context, request *ReportRequest) (*ReportResponse, error) {
+	start := time.Now()
+	operation := "report"
+
+	root, err := valueobjects.NewFilePath(request.Path)
+	if err != nil {
+		s.logger.LogFileSystemOperation(operation, request.Path, false, time.Since(start), 0)
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPath, err)
+	}
+
+	builder := newReportBuilder(request.TopN)
+	err = walkFiles(ctx, s.fileSystemRepo, root, request.IncludeHidden, func(rel string, entry entities.FileSystemEntry) error {
+		_, isText, ok := s.sniffer.sniff(ctx, entry)
+		builder.add(filepath.ToSlash(rel), entry.Size(), isText, ok)
+		return nil
+	})
+	if err != nil {
+		s.logger.LogFileSystemOperation(operation, request.Path, false, time.Since(start), 0)
+		return nil, fmt.Errorf("failed to scan directory: %w", err)
+	}
+
+	response := builder.response(request.Path)
+	s.logger.LogFileSystemOperation(operation, request.Path, true, time.Since(start), response.TotalSize)
+
+	return response, nil
+}
+
+// reportBuilder accumulates report statistics one file at a time
+type reportBuilder struct {
+	topN       int
+	report     ReportResponse
+	extensions map[string]*ExtensionStatsDTO
+}
+
+func newReportBuilder(topN int) *reportBuilder {
+	if topN <= 0 {
+		topN = DefaultReportTopN
+	}
+	if topN > MaxReportTopN {
+		topN = MaxReportTopN
+	}
+
+	b := &reportBuilder{
+		topN:       topN,
+		extensions: make(map[string]*ExtensionStatsDTO),
+	}
+	b.report.Histogram = make([]SizeBucketDTO, len(reportBuckets))
+	for i, bucket := range reportBuckets {
+		b.report.Histogram[i].Label = bucket.label
+	}
+	b.report.Largest = []LargestFileDTO{}
+	return b
+}
+
+func (b *reportBuilder) add(rel string, size int64, isText, readable bool) {
+	b.report.TotalFiles++
+	b.report.TotalSize += size
+
+	switch {
+	case !readable:
+		b.report.Unreadable++
+	case isText:
+		b.report.Text.Count++
+		b.report.Text.TotalSize += size
+	default:
+		b.report.Binary.Count++
+		b.report.Binary.TotalSize += size
+	}
+
+	bucket := &b.report.Histogram[sizeBucket(size)]
+	bucket.Count++
+	bucket.TotalSize += size
+
+	ext := strings.ToLower(filepath.Ext(rel))
+	stats, ok := b.extensions[ext]
+	if !ok {
+		stats = &ExtensionStatsDTO{Extension: ext}
+		b.extensions[ext] = stats
+	}
+	stats.Count++
+	stats.TotalSize += size
+
+	b.addLargest(LargestFileDTO{Path: rel, Size: size})
+}
+
+// addLargest keeps the topN largest files sorted by size descending, with
+// ties broken by path so the output is stable across walks
+func (b *reportBuilder) addLargest(file LargestFileDTO) {
+	largest := b.report.Largest
+	i := sort.Search(len(largest), func(i int) bool {
+		if largest[i].Size != file.Size {
+			return largest[i].Size < file.Size
+		}
+		return largest[i].Path > file.Path
+	})
+	if i >= b.topN {
+		return
+	}
+
+	largest = append(largest, LargestFileDTO{})
+	copy(largest[i+1:], largest[i:])
+	largest[i] = file
+	if len(largest) > b.topN {
+		largest = largest[:b.topN]
+	}
+	b.report.Largest = largest
+}
+
+func (b *reportBuilder) response(path string) *ReportResponse {
+	report := b.report
+	report.Path = path
+	report.GeneratedAt = time.Now()
+
+	report.Extensions = make([]ExtensionStatsDTO, 0, len(b.extensions))
+	for _, stats := range b.extensions {
+		report.Extensions = append(report.Extensions, *stats)
+	}
+	sort.Slice(report.Extensions, func(i, j int) bool {
+		a, c := report.Extensions[i], report.Extensions[j]
+		if a.Count != c.Count {
+			return a.Count > c.Count
+		}
+		return a.Extension < c.Extension
+	})
+
+	return &report
+}
+
+// sizeBucket returns the histogram bucket index for a file size
+func sizeBucket(size int64) int {
+	for i, bucket := range reportBuckets {
+		if bucket.max == 0 || size < bucket.max {
+			return i
+		}
+	}
+	return len(reportBuckets) - 1
+}
@@ -0,0 +1,63 @@
+package services
+
+import "testing"
+
+func TestSizeBucket(t *testing.T) {
+	tests := []struct {
+		size     int64
+		expected string
+	}{
+		{0, "empty"},
+		{1, "<1KB"},
+		{1023, "<1KB"},
+		{1024, "1KB-10KB"},
+		{5 << 20, "1MB-10MB"},
+		{1 << 30, ">=1GB"},
+		{50 << 30, ">=1GB"},
+	}
+
+	for _, tt := range tests {
+		if label := reportBuckets[sizeBucket(tt.size)].label; label != tt.expected {
+			t.Errorf("Expected bucket %q for %d bytes, got %q", tt.expected, tt.size, label)
+		}
+	}
+}
+
+func TestReportBuilder(t *testing.T) {
+	b := newReportBuilder(2)
+	b.add("README.md", 100, true, true)
+	b.add("bin/app", 5000, false, true)
+	b.add("docs/guide.MD", 300, true, true)
+	b.add("img/logo.png", 300, false, true)
+	b.add("secret.key", 10, false, false)
+
+	report := b.response(".")
+
+	if report.TotalFiles != 5 || report.TotalSize != 5710 {
+		t.Errorf("Expected 5 files totalling 5710 bytes, got %d files, %d bytes", report.TotalFiles, report.TotalSize)
+	}
+	if report.Text.Count != 2 || report.Text.TotalSize != 400 {
+		t.Errorf("Expected 2 text files of 400 bytes, got %+v", report.Text)
+	}
+	if report.Binary.Count != 2 || report.Binary.TotalSize != 5300 {
+		t.Errorf("Expected 2 binary files of 5300 bytes, got %+v", report.Binary)
+	}
+	if report.Unreadable != 1 {
+		t.Errorf("Expected 1 unreadable file, got %d", report.Unreadable)
+	}
+
+	if len(report.Largest) != 2 || report.Largest[0].Path != "bin/app" || report.Largest[1].Path != "docs/guide.MD" {
+		t.Errorf("Expected largest [bin/app docs/guide.MD], got %+v", report.Largest)
+	}
+
+	if len(report.Extensions) != 4 || report.Extensions[0].Extension != ".md" || report.Extensions[0].Count != 2 {
+		t.Errorf("Expected .md first with 2 files, got %+v", report.Extensions)
+	}
+	if report.Extensions[1].Extension != "" {
+		t.Errorf("Expected files without extension second, got %+v", report.Extensions[1])
+	}
+
+	if report.Histogram[sizeBucket(300)].Count != 4 {
+		t.Errorf("Expected 4 files under 1KB, got %+v", report.Histogram)
+	}
+}
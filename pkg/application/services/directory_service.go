@@ -1,19 +1,39 @@
 package services
 
 import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"io"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/sh05/cat-server/pkg/domain/entities"
 	"github.com/sh05/cat-server/pkg/domain/repositories"
 	"github.com/sh05/cat-server/pkg/domain/valueobjects"
+	"github.com/sh05/cat-server/pkg/infrastructure/cache"
 	"github.com/sh05/cat-server/pkg/infrastructure/logging"
+	"github.com/sh05/cat-server/pkg/infrastructure/metrics"
+	"github.com/sh05/cat-server/pkg/infrastructure/workpool"
 )
 
+// ErrInvalidPath is returned when a requested path fails validation
+var ErrInvalidPath = errors.New("invalid path")
+
 // DirectoryService provides use cases for directory operations
 type DirectoryService struct {
 	fileSystemRepo repositories.FileSystemRepository
 	logger         *logging.Logger
+	sniffer        *contentSniffer
+	redactor       Redactor
+	timings        *metrics.Timings // nil when phases are not timed
+	workers        *workpool.Pool   // hashes files; nil hashes them one by one
 }
 
 // NewDirectoryService creates a new DirectoryService
@@ -21,16 +41,65 @@ func NewDirectoryService(fileSystemRepo repositories.FileSystemRepository, logge
 	return &DirectoryService{
 		fileSystemRepo: fileSystemRepo,
 		logger:         logger,
+		sniffer: &contentSniffer{
+			fileSystemRepo: fileSystemRepo,
+			cache:          cache.NewLRU(DefaultContentTypeCacheSize, nil),
+		},
 	}
 }
 
+// SetContentTypeCache replaces the cache of sniffed content types, e.g. with
+// one reporting metrics
+func (s *DirectoryService) SetContentTypeCache(contentTypes *cache.LRU) {
+	s.sniffer.cache = contentTypes
+}
+
+// SetWorkerPool hashes the files of manifests on workers, shared with the
+// other services
+func (s *DirectoryService) SetWorkerPool(workers *workpool.Pool) {
+	s.workers = workers
+}
+
+// WithLogger returns a copy of the service that logs through the given logger.
+// Handlers use it to attach request-scoped fields to filesystem operation logs.
+func (s *DirectoryService) WithLogger(logger *logging.Logger) *DirectoryService {
+	clone := *s
+	clone.logger = logger
+	return &clone
+}
+
+// WithTimings returns a copy of the service that records the time
+// ListDirectory spends validating, reading and statting in timings
+func (s *DirectoryService) WithTimings(timings *metrics.Timings) *DirectoryService {
+	clone := *s
+	clone.timings = timings
+	return &clone
+}
+
+// WithRedactor returns a copy of the service that leaves matching files out
+// of manifests, since their checksums would fingerprint the secrets
+func (s *DirectoryService) WithRedactor(redactor Redactor) *DirectoryService {
+	clone := *s
+	clone.redactor = redactor
+	return &clone
+}
+
+// redacts reports whether filename's contents are redacted
+func (s *DirectoryService) redacts(filename string) bool {
+	return s.redactor != nil && s.redactor.Matches(filename)
+}
+
 // ListDirectoryRequest represents a request to list directory contents
 type ListDirectoryRequest struct {
 	Path          string
 	IncludeHidden bool
-	SortBy        string // "name", "size", "modtime"
-	SortOrder     string // "asc", "desc"
-	FilterType    string // "all", "files", "directories"
+	SortBy        string   // "name", "size", "modtime"
+	SortOrder     string   // "asc", "desc"
+	FilterType    string   // "all", "files", "directories"
+	ContentTypes  []string // MIME types or families, see ParseContentTypeFilter
+	// KnownVersions are listing versions the client already has; when the
+	// directory still has one of them the response is NotModified
+	KnownVersions []string
 }
 
 // ListDirectoryResponse represents the response from listing directory contents
@@ -43,6 +112,12 @@ type ListDirectoryResponse struct {
 	TotalSize  int64                   `json:"totalSize"`
 	ScannedAt  time.Time               `json:"scannedAt"`
 	Statistics *DirectoryStatisticsDTO `json:"statistics,omitempty"`
+	// ListingVersion changes whenever an entry is added, removed or
+	// modified; /ls serves it as the ETag
+	ListingVersion string `json:"listingVersion"`
+	// NotModified reports that ListingVersion is one of the request's
+	// KnownVersions; only Path and ListingVersion are set then
+	NotModified bool `json:"-"`
 }
 
 // FileEntryDTO represents a file entry for API responses
@@ -67,30 +142,40 @@ type DirectoryStatisticsDTO struct {
 }
 
 // ListDirectory lists the contents of a directory
-func (s *DirectoryService) ListDirectory(request *ListDirectoryRequest) (*ListDirectoryResponse, error) {
+func (s *DirectoryService) ListDirectory(ctx context.Context, request *ListDirectoryRequest) (*ListDirectoryResponse, error) {
 	start := time.Now()
 
 	// Validate and create file path
 	filePath, err := valueobjects.NewFilePath(request.Path)
 	if err != nil {
 		s.logger.LogFileSystemOperation("list_directory", request.Path, false, time.Since(start), 0)
-		return nil, fmt.Errorf("invalid path: %w", err)
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPath, err)
 	}
 
 	// Log the operation
 	s.logger.LogFileSystemOperation("list_directory", request.Path, true, 0, 0)
+	phase := s.timings.Since("validate", start)
 
 	// Get directory listing from repository
-	listing, err := s.fileSystemRepo.ListDirectory(filePath)
+	listing, err := s.fileSystemRepo.ListDirectory(ctx, filePath)
 	if err != nil {
 		duration := time.Since(start)
 		s.logger.LogFileSystemOperation("list_directory", request.Path, false, duration, 0)
 		return nil, fmt.Errorf("failed to list directory: %w", err)
 	}
 
-	// Apply filters and sorting
+	// The copy of the entries is filtered and sorted in place
 	entries := listing.Entries()
 
+	// Hidden entries count too, so the version of a directory is the same
+	// whatever the filters
+	version := listingVersion(entries)
+	if slices.Contains(request.KnownVersions, version) {
+		// Skip filtering and the statistics scan
+		s.logger.LogFileSystemOperation("list_directory", request.Path, true, time.Since(start), 0)
+		return &ListDirectoryResponse{Path: request.Path, ListingVersion: version, NotModified: true}, nil
+	}
+
 	// Filter hidden files if requested
 	if !request.IncludeHidden {
 		entries = s.filterHiddenFiles(entries)
@@ -105,6 +190,11 @@ func (s *DirectoryService) ListDirectory(request *ListDirectoryRequest) (*ListDi
 		// "all" or default: no additional filtering
 	}
 
+	// Filter by sniffed content type; directories have none and are dropped
+	if len(request.ContentTypes) > 0 {
+		entries = s.filterByContentType(ctx, entries, request.ContentTypes)
+	}
+
 	// Sort entries
 	entries = s.sortEntries(entries, request.SortBy, request.SortOrder)
 
@@ -114,22 +204,23 @@ func (s *DirectoryService) ListDirectory(request *ListDirectoryRequest) (*ListDi
 		fileEntries[i] = s.convertToFileEntryDTO(entry)
 	}
 
-	// Calculate statistics
-	stats, err := s.fileSystemRepo.GetDirectoryStats(filePath)
-	var statisticsDTO *DirectoryStatisticsDTO
-	if err == nil && stats != nil {
-		statisticsDTO = s.convertToDirectoryStatisticsDTO(stats)
-	}
+	phase = s.timings.Since("read", phase)
+
+	// Calculate statistics from the same listing instead of listing the
+	// directory again
+	statisticsDTO := s.convertToDirectoryStatisticsDTO(repositories.NewDirectoryStats(listing))
+	s.timings.Since("stat", phase)
 
 	response := &ListDirectoryResponse{
-		Path:       request.Path,
-		Files:      fileEntries,
-		TotalCount: len(fileEntries),
-		FileCount:  s.countFilesByType(fileEntries, false),
-		DirCount:   s.countFilesByType(fileEntries, true),
-		TotalSize:  s.calculateTotalSize(fileEntries),
-		ScannedAt:  listing.ScannedAt(),
-		Statistics: statisticsDTO,
+		Path:           request.Path,
+		Files:          fileEntries,
+		TotalCount:     len(fileEntries),
+		FileCount:      s.countFilesByType(fileEntries, false),
+		DirCount:       s.countFilesByType(fileEntries, true),
+		TotalSize:      s.calculateTotalSize(fileEntries),
+		ScannedAt:      listing.ScannedAt(),
+		Statistics:     statisticsDTO,
+		ListingVersion: version,
 	}
 
 	duration := time.Since(start)
@@ -138,6 +229,18 @@ func (s *DirectoryService) ListDirectory(request *ListDirectoryRequest) (*ListDi
 	return response, nil
 }
 
+// listingVersion hashes the names, sizes and modification times of
+// entries, which it sorts in place
+func listingVersion(entries []entities.FileSystemEntry) string {
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name() < entries[j].Name() })
+
+	h := sha256.New()
+	for _, entry := range entries {
+		fmt.Fprintf(h, "%s\x00%d\x00%d\n", entry.Name(), entry.Size(), entry.ModTime().UnixNano())
+	}
+	return hex.EncodeToString(h.Sum(nil)[:16])
+}
+
 // ValidateDirectoryAccess validates if a directory can be accessed
 func (s *DirectoryService) ValidateDirectoryAccess(path string) error {
 	filePath, err := valueobjects.NewFilePath(path)
@@ -149,22 +252,22 @@ func (s *DirectoryService) ValidateDirectoryAccess(path string) error {
 }
 
 // GetDirectoryInfo returns basic information about a directory
-func (s *DirectoryService) GetDirectoryInfo(path string) (*DirectoryInfoDTO, error) {
+func (s *DirectoryService) GetDirectoryInfo(ctx context.Context, path string) (*DirectoryInfoDTO, error) {
 	filePath, err := valueobjects.NewFilePath(path)
 	if err != nil {
 		return nil, fmt.Errorf("invalid path: %w", err)
 	}
 
-	if !s.fileSystemRepo.IsDirectory(filePath) {
+	if !s.fileSystemRepo.IsDirectory(ctx, filePath) {
 		return nil, fmt.Errorf("path is not a directory")
 	}
 
-	info, err := s.fileSystemRepo.GetFileInfo(filePath)
+	info, err := s.fileSystemRepo.GetFileInfo(ctx, filePath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get directory info: %w", err)
 	}
 
-	stats, err := s.fileSystemRepo.GetDirectoryStats(filePath)
+	stats, err := s.fileSystemRepo.GetDirectoryStats(ctx, filePath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get directory stats: %w", err)
 	}
@@ -191,24 +294,31 @@ type DirectoryInfoDTO struct {
 
 // Helper methods
 
+// filterHiddenFiles drops hidden entries in place
 func (s *DirectoryService) filterHiddenFiles(entries []entities.FileSystemEntry) []entities.FileSystemEntry {
+	return slices.DeleteFunc(entries, func(entry entities.FileSystemEntry) bool {
+		return entry.IsHidden()
+	})
+}
+
+func (s *DirectoryService) filterByContentType(ctx context.Context, entries []entities.FileSystemEntry, patterns []string) []entities.FileSystemEntry {
 	var filtered []entities.FileSystemEntry
 	for _, entry := range entries {
-		if !entry.IsHidden() {
+		if entry.IsDir() {
+			continue
+		}
+		if mimeType, _, ok := s.sniffer.sniff(ctx, entry); ok && matchContentType(patterns, mimeType) {
 			filtered = append(filtered, entry)
 		}
 	}
 	return filtered
 }
 
+// filterByType keeps only directories or only files, in place
 func (s *DirectoryService) filterByType(entries []entities.FileSystemEntry, isDir bool) []entities.FileSystemEntry {
-	var filtered []entities.FileSystemEntry
-	for _, entry := range entries {
-		if entry.IsDir() == isDir {
-			filtered = append(filtered, entry)
-		}
-	}
-	return filtered
+	return slices.DeleteFunc(entries, func(entry entities.FileSystemEntry) bool {
+		return entry.IsDir() != isDir
+	})
 }
 
 func (s *DirectoryService) sortEntries(entries []entities.FileSystemEntry, sortBy, sortOrder string) []entities.FileSystemEntry {
@@ -295,3 +405,455 @@ func (s *DirectoryService) calculateTotalSize(entries []FileEntryDTO) int64 {
 	}
 	return total
 }
+
+// CompareDirectoriesRequest represents a request to compare two directories
+type CompareDirectoriesRequest struct {
+	PathA         string
+	PathB         string
+	IncludeHidden bool
+}
+
+// CompareDirectoriesResponse represents the differences between two directories
+type CompareDirectoriesResponse struct {
+	PathA      string         `json:"a"`
+	PathB      string         `json:"b"`
+	Added      []FileDiffDTO  `json:"added"`
+	Removed    []FileDiffDTO  `json:"removed"`
+	Changed    []FileDiffDTO  `json:"changed"`
+	Summary    DiffSummaryDTO `json:"summary"`
+	ComparedAt time.Time      `json:"comparedAt"`
+}
+
+// FileDiffDTO represents a single file difference between two directories
+type FileDiffDTO struct {
+	Path     string    `json:"path"`
+	Reason   string    `json:"reason,omitempty"` // "size", "content"
+	SizeA    int64     `json:"sizeA,omitempty"`
+	SizeB    int64     `json:"sizeB,omitempty"`
+	ModTimeA time.Time `json:"modTimeA,omitzero"`
+	ModTimeB time.Time `json:"modTimeB,omitzero"`
+}
+
+// DiffSummaryDTO summarizes a directory comparison
+type DiffSummaryDTO struct {
+	Added     int `json:"added"`
+	Removed   int `json:"removed"`
+	Changed   int `json:"changed"`
+	Unchanged int `json:"unchanged"`
+}
+
+// CompareDirectories compares two directories recursively and reports added,
+// removed and changed files. Files are considered changed when their sizes
+// differ, or when their modification times differ and their content hashes
+// do not match.
+func (s *DirectoryService) CompareDirectories(ctx context.Context, request *CompareDirectoriesRequest) (*CompareDirectoriesResponse, error) {
+	start := time.Now()
+	operation := "compare_directories"
+	logPath := request.PathA + " <> " + request.PathB
+
+	pathA, err := valueobjects.NewFilePath(request.PathA)
+	if err != nil {
+		s.logger.LogFileSystemOperation(operation, logPath, false, time.Since(start), 0)
+		return nil, fmt.Errorf("%w a: %v", ErrInvalidPath, err)
+	}
+
+	pathB, err := valueobjects.NewFilePath(request.PathB)
+	if err != nil {
+		s.logger.LogFileSystemOperation(operation, logPath, false, time.Since(start), 0)
+		return nil, fmt.Errorf("%w b: %v", ErrInvalidPath, err)
+	}
+
+	filesA, err := s.collectFiles(ctx, pathA, request.IncludeHidden)
+	if err != nil {
+		s.logger.LogFileSystemOperation(operation, logPath, false, time.Since(start), 0)
+		return nil, fmt.Errorf("failed to scan directory a: %w", err)
+	}
+
+	filesB, err := s.collectFiles(ctx, pathB, request.IncludeHidden)
+	if err != nil {
+		s.logger.LogFileSystemOperation(operation, logPath, false, time.Since(start), 0)
+		return nil, fmt.Errorf("failed to scan directory b: %w", err)
+	}
+
+	response := &CompareDirectoriesResponse{
+		PathA:      request.PathA,
+		PathB:      request.PathB,
+		Added:      []FileDiffDTO{},
+		Removed:    []FileDiffDTO{},
+		Changed:    []FileDiffDTO{},
+		ComparedAt: time.Now(),
+	}
+
+	for rel, entryA := range filesA {
+		entryB, ok := filesB[rel]
+		if !ok {
+			response.Removed = append(response.Removed, FileDiffDTO{
+				Path:     rel,
+				SizeA:    entryA.Size(),
+				ModTimeA: entryA.ModTime(),
+			})
+			continue
+		}
+
+		reason, err := s.compareEntries(ctx, entryA, entryB)
+		if err != nil {
+			s.logger.LogFileSystemOperation(operation, logPath, false, time.Since(start), 0)
+			return nil, fmt.Errorf("failed to compare %s: %w", rel, err)
+		}
+
+		if reason == "" {
+			response.Summary.Unchanged++
+			continue
+		}
+
+		response.Changed = append(response.Changed, FileDiffDTO{
+			Path:     rel,
+			Reason:   reason,
+			SizeA:    entryA.Size(),
+			SizeB:    entryB.Size(),
+			ModTimeA: entryA.ModTime(),
+			ModTimeB: entryB.ModTime(),
+		})
+	}
+
+	for rel, entryB := range filesB {
+		if _, ok := filesA[rel]; !ok {
+			response.Added = append(response.Added, FileDiffDTO{
+				Path:     rel,
+				SizeB:    entryB.Size(),
+				ModTimeB: entryB.ModTime(),
+			})
+		}
+	}
+
+	sortFileDiffs(response.Added)
+	sortFileDiffs(response.Removed)
+	sortFileDiffs(response.Changed)
+
+	response.Summary.Added = len(response.Added)
+	response.Summary.Removed = len(response.Removed)
+	response.Summary.Changed = len(response.Changed)
+
+	s.logger.LogFileSystemOperation(operation, logPath, true, time.Since(start), 0)
+
+	return response, nil
+}
+
+// ManifestRequest represents a request for a checksum manifest of a directory
+type ManifestRequest struct {
+	Path          string
+	IncludeHidden bool
+}
+
+// ManifestResponse lists the SHA-256 checksum of every file under a directory
+type ManifestResponse struct {
+	Path        string             `json:"path"`
+	Files       []ManifestEntryDTO `json:"files"`
+	Skipped     []string           `json:"skipped"`
+	TotalSize   int64              `json:"totalSize"`
+	GeneratedAt time.Time          `json:"generatedAt"`
+}
+
+// ManifestEntryDTO is the checksum of a single file, relative to the manifest root
+type ManifestEntryDTO struct {
+	Path   string `json:"path"`
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"`
+}
+
+// Manifest computes the SHA-256 checksum of every file under a directory.
+// Files that cannot be read (e.g. larger than the configured limit) or are
+// redacted are listed in Skipped rather than failing the whole manifest.
+func (s *DirectoryService) Manifest(ctx context.Context, request *ManifestRequest) (*ManifestResponse, error) {
+	start := time.Now()
+	operation := "manifest"
+
+	root, err := valueobjects.NewFilePath(request.Path)
+	if err != nil {
+		s.logger.LogFileSystemOperation(operation, request.Path, false, time.Since(start), 0)
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPath, err)
+	}
+
+	response := &ManifestResponse{
+		Path:        request.Path,
+		Files:       []ManifestEntryDTO{},
+		Skipped:     []string{},
+		GeneratedAt: time.Now(),
+	}
+
+	// Hash the files on the shared worker pool while walking
+	var mu sync.Mutex
+	group := s.workers.Group()
+	err = walkFiles(ctx, s.fileSystemRepo, root, request.IncludeHidden, func(rel string, entry entities.FileSystemEntry) error {
+		filePath, err := valueobjects.NewFilePath(entry.Path())
+		if err != nil {
+			return err
+		}
+		if s.redacts(entry.Path()) {
+			mu.Lock()
+			response.Skipped = append(response.Skipped, filepath.ToSlash(rel))
+			mu.Unlock()
+			return nil
+		}
+
+		group.Go(func() {
+			content, err := s.fileSystemRepo.ReadFile(ctx, filePath)
+			var sum [sha256.Size]byte
+			if err == nil {
+				sum = sha256.Sum256(content.Content())
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				s.logger.LogFileSystemOperation(operation, entry.Path(), false, 0, entry.Size())
+				response.Skipped = append(response.Skipped, filepath.ToSlash(rel))
+				return
+			}
+			response.Files = append(response.Files, ManifestEntryDTO{
+				Path:   filepath.ToSlash(rel),
+				Size:   content.Size(),
+				SHA256: hex.EncodeToString(sum[:]),
+			})
+			response.TotalSize += content.Size()
+		})
+		return nil
+	})
+	group.Wait()
+	if err == nil {
+		// Hashes cut short by a cancelled request are not skipped files
+		err = ctx.Err()
+	}
+	if err != nil {
+		s.logger.LogFileSystemOperation(operation, request.Path, false, time.Since(start), 0)
+		return nil, fmt.Errorf("failed to scan directory: %w", err)
+	}
+
+	sort.Slice(response.Files, func(i, j int) bool {
+		return response.Files[i].Path < response.Files[j].Path
+	})
+	sort.Strings(response.Skipped)
+
+	s.logger.LogFileSystemOperation(operation, request.Path, true, time.Since(start), response.TotalSize)
+
+	return response, nil
+}
+
+// WriteSHA256Sum writes the manifest in the format read by `sha256sum -c`.
+// Names containing a backslash or newline are escaped the way GNU coreutils
+// does, with a leading backslash on the line.
+func (m *ManifestResponse) WriteSHA256Sum(w io.Writer) error {
+	for _, file := range m.Files {
+		name, prefix := file.Path, ""
+		if strings.ContainsAny(name, "\\\n") {
+			name = strings.NewReplacer("\\", "\\\\", "\n", "\\n").Replace(name)
+			prefix = "\\"
+		}
+		if _, err := fmt.Fprintf(w, "%s%s  %s\n", prefix, file.SHA256, name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Recent feed limits
+const (
+	DefaultRecentLimit = 50
+	MaxRecentLimit     = 1000
+)
+
+// RecentFilesRequest represents a request for the most recently modified files
+type RecentFilesRequest struct {
+	Path          string
+	IncludeHidden bool
+	Limit         int
+}
+
+// RecentFilesResponse lists files under a directory, newest first
+type RecentFilesResponse struct {
+	Path        string          `json:"path"`
+	Files       []RecentFileDTO `json:"files"`
+	Scanned     int             `json:"scanned"`
+	GeneratedAt time.Time       `json:"generatedAt"`
+}
+
+// RecentFileDTO is a file path relative to the feed root with its modification time
+type RecentFileDTO struct {
+	Path    string    `json:"path"`
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"modTime"`
+}
+
+// RecentFiles walks a directory and returns the Limit most recently modified
+// files across the whole tree, ordered by modification time descending
+func (s *DirectoryService) RecentFiles(ctx context.Context, request *RecentFilesRequest) (*RecentFilesResponse, error) {
+	start := time.Now()
+	operation := "recent_files"
+
+	root, err := valueobjects.NewFilePath(request.Path)
+	if err != nil {
+		s.logger.LogFileSystemOperation(operation, request.Path, false, time.Since(start), 0)
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPath, err)
+	}
+
+	limit := request.Limit
+	if limit <= 0 {
+		limit = DefaultRecentLimit
+	}
+	if limit > MaxRecentLimit {
+		limit = MaxRecentLimit
+	}
+
+	response := &RecentFilesResponse{
+		Path:  request.Path,
+		Files: []RecentFileDTO{},
+	}
+
+	err = walkFiles(ctx, s.fileSystemRepo, root, request.IncludeHidden, func(rel string, entry entities.FileSystemEntry) error {
+		response.Scanned++
+		response.Files = insertRecent(response.Files, RecentFileDTO{
+			Path:    filepath.ToSlash(rel),
+			Size:    entry.Size(),
+			ModTime: entry.ModTime(),
+		}, limit)
+		return nil
+	})
+	if err != nil {
+		s.logger.LogFileSystemOperation(operation, request.Path, false, time.Since(start), 0)
+		return nil, fmt.Errorf("failed to scan directory: %w", err)
+	}
+
+	response.GeneratedAt = time.Now()
+	s.logger.LogFileSystemOperation(operation, request.Path, true, time.Since(start), 0)
+
+	return response, nil
+}
+
+// insertRecent adds file to files, which is kept sorted newest first (ties by
+// path) and truncated to limit entries
+func insertRecent(files []RecentFileDTO, file RecentFileDTO, limit int) []RecentFileDTO {
+	i := sort.Search(len(files), func(i int) bool {
+		if !files[i].ModTime.Equal(file.ModTime) {
+			return files[i].ModTime.Before(file.ModTime)
+		}
+		return files[i].Path > file.Path
+	})
+	if i >= limit {
+		return files
+	}
+
+	files = append(files, RecentFileDTO{})
+	copy(files[i+1:], files[i:])
+	files[i] = file
+	if len(files) > limit {
+		files = files[:limit]
+	}
+	return files
+}
+
+// collectFiles walks a directory recursively and returns its files keyed by
+// their path relative to root
+func (s *DirectoryService) collectFiles(ctx context.Context, root *valueobjects.FilePath, includeHidden bool) (map[string]entities.FileSystemEntry, error) {
+	files := make(map[string]entities.FileSystemEntry)
+
+	err := walkFiles(ctx, s.fileSystemRepo, root, includeHidden, func(rel string, entry entities.FileSystemEntry) error {
+		files[rel] = entry
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return files, nil
+}
+
+// walkFiles visits every file below root, calling fn with the path relative
+// to root. Hidden entries are skipped unless includeHidden is set.
+func walkFiles(ctx context.Context, repo repositories.FileSystemRepository, root *valueobjects.FilePath, includeHidden bool, fn func(rel string, entry entities.FileSystemEntry) error) error {
+	var walk func(dir *valueobjects.FilePath, prefix string) error
+	walk = func(dir *valueobjects.FilePath, prefix string) error {
+		// Listings may come from a cache, so check for cancellation here
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		listing, err := repo.ListDirectory(ctx, dir)
+		if err != nil {
+			return err
+		}
+
+		for _, entry := range listing.Entries() {
+			if !includeHidden && entry.IsHidden() {
+				continue
+			}
+
+			rel := filepath.Join(prefix, entry.Name())
+			if !entry.IsDir() {
+				if err := fn(rel, entry); err != nil {
+					return err
+				}
+				continue
+			}
+
+			child, err := dir.Join(entry.Name())
+			if err != nil {
+				return err
+			}
+			if err := walk(child, rel); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	return walk(root, "")
+}
+
+// compareEntries returns the reason two entries differ, or "" if they match
+func (s *DirectoryService) compareEntries(ctx context.Context, a, b entities.FileSystemEntry) (string, error) {
+	if a.Size() != b.Size() {
+		return "size", nil
+	}
+
+	// Same size and modification time: treat as unchanged without hashing
+	if a.ModTime().Equal(b.ModTime()) {
+		return "", nil
+	}
+
+	hashA, err := s.contentHash(ctx, a.Path())
+	if err != nil {
+		return "", err
+	}
+
+	hashB, err := s.contentHash(ctx, b.Path())
+	if err != nil {
+		return "", err
+	}
+
+	if hashA != hashB {
+		return "content", nil
+	}
+
+	return "", nil
+}
+
+func (s *DirectoryService) contentHash(ctx context.Context, p string) (uint32, error) {
+	filePath, err := valueobjects.NewFilePath(p)
+	if err != nil {
+		return 0, err
+	}
+
+	content, err := s.fileSystemRepo.ReadFile(ctx, filePath)
+	if err != nil {
+		return 0, err
+	}
+
+	return content.GetContentHash(), nil
+}
+
+func sortFileDiffs(diffs []FileDiffDTO) {
+	sort.Slice(diffs, func(i, j int) bool {
+		return diffs[i].Path < diffs[j].Path
+	})
+}
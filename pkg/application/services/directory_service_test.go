@@ -0,0 +1,215 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/sh05/cat-server/pkg/infrastructure/filesystem"
+	"github.com/sh05/cat-server/pkg/infrastructure/logging"
+	"github.com/sh05/cat-server/pkg/infrastructure/workpool"
+)
+
+func TestInsertRecent(t *testing.T) {
+	base := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	var files []RecentFileDTO
+	for i, name := range []string{"b", "d", "a", "c", "e"} {
+		modTime := base.Add(time.Duration(i) * time.Hour)
+		if name == "a" {
+			modTime = base.Add(time.Hour) // same time as "d"
+		}
+		files = insertRecent(files, RecentFileDTO{Path: name, ModTime: modTime}, 3)
+	}
+
+	expected := []string{"e", "c", "a"}
+	if len(files) != len(expected) {
+		t.Fatalf("Expected %d files, got %d", len(expected), len(files))
+	}
+	for i, name := range expected {
+		if files[i].Path != name {
+			t.Errorf("Expected %q at position %d, got %q", name, i, files[i].Path)
+		}
+	}
+}
+
+func TestManifestWorkerPool(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{"a.txt": "a\n", "logs/app.log": "started\n", "logs/big.log": "0123456789abcdef"} {
+		if err := os.MkdirAll(filepath.Dir(filepath.Join(dir, name)), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	repo := filesystem.NewFileSystemRepository(dir, 10)
+
+	// Hashing on a pool gives the same manifest as hashing one by one
+	var manifests []*ManifestResponse
+	for _, workers := range []*workpool.Pool{nil, workpool.New(2)} {
+		service := NewDirectoryService(repo, logging.NewLoggerWithWriter(logging.LevelError, "json", io.Discard))
+		service.SetWorkerPool(workers)
+		manifest, err := service.Manifest(context.Background(), &ManifestRequest{Path: "."})
+		workers.Close()
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		manifest.GeneratedAt = time.Time{}
+		manifests = append(manifests, manifest)
+	}
+
+	if files := manifests[0].Files; len(files) != 2 || files[0].Path != "a.txt" || files[1].Path != "logs/app.log" {
+		t.Errorf("Expected a.txt and logs/app.log, got %+v", files)
+	}
+	if skipped := manifests[0].Skipped; len(skipped) != 1 || skipped[0] != "logs/big.log" {
+		t.Errorf("Expected the file over the size limit to be skipped, got %v", skipped)
+	}
+	if !reflect.DeepEqual(manifests[0], manifests[1]) {
+		t.Errorf("Expected the same manifest on a pool, got %+v and %+v", manifests[0], manifests[1])
+	}
+}
+
+func TestCancelledRequests(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"a.txt", "logs/app.log"} {
+		if err := os.MkdirAll(filepath.Dir(filepath.Join(dir, name)), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("started\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	service := NewDirectoryService(filesystem.NewFileSystemRepository(dir, 1024), logging.NewLoggerWithWriter(logging.LevelError, "json", io.Discard))
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	for _, tt := range []struct {
+		name string
+		call func() error
+	}{
+		{"ListDirectory", func() error {
+			_, err := service.ListDirectory(ctx, &ListDirectoryRequest{Path: "."})
+			return err
+		}},
+		{"Manifest", func() error {
+			_, err := service.Manifest(ctx, &ManifestRequest{Path: "."})
+			return err
+		}},
+		{"RecentFiles", func() error {
+			_, err := service.RecentFiles(ctx, &RecentFilesRequest{Path: "."})
+			return err
+		}},
+		{"Audit", func() error {
+			_, err := service.Audit(ctx, &AuditRequest{Path: "."})
+			return err
+		}},
+		{"DiskUsage", func() error {
+			_, err := service.DiskUsage(ctx, &DiskUsageRequest{Path: "."})
+			return err
+		}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.call(); !errors.Is(err, context.Canceled) {
+				t.Errorf("Expected context.Canceled, got %v", err)
+			}
+		})
+	}
+}
+
+func TestListingVersion(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "a.txt"), []byte("a\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	service := NewDirectoryService(filesystem.NewFileSystemRepository(dir, 1024), logging.NewLoggerWithWriter(logging.LevelError, "json", io.Discard))
+	list := func(known ...string) *ListDirectoryResponse {
+		t.Helper()
+		listing, err := service.ListDirectory(context.Background(), &ListDirectoryRequest{Path: ".", KnownVersions: known})
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		return listing
+	}
+
+	first := list()
+	if first.ListingVersion == "" || first.NotModified {
+		t.Fatalf("Expected a full listing with a version, got %+v", first)
+	}
+	if again := list("stale", first.ListingVersion); !again.NotModified || again.ListingVersion != first.ListingVersion || again.Files != nil {
+		t.Errorf("Expected an unchanged directory to be not modified, got %+v", again)
+	}
+
+	versions := map[string]string{"": first.ListingVersion}
+	for _, tt := range []struct {
+		name   string
+		change func() error
+	}{
+		{"modified", func() error {
+			return os.Chtimes(filepath.Join(dir, "a.txt"), time.Time{}, time.Now().Add(time.Hour))
+		}},
+		{"added", func() error { return os.WriteFile(filepath.Join(dir, "b.txt"), nil, 0644) }},
+		{"hidden file added", func() error { return os.WriteFile(filepath.Join(dir, ".env"), nil, 0644) }},
+		{"removed", func() error { return os.Remove(filepath.Join(dir, "b.txt")) }},
+	} {
+		if err := tt.change(); err != nil {
+			t.Fatal(err)
+		}
+		listing := list(first.ListingVersion)
+		if listing.NotModified {
+			t.Errorf("%s: expected a new version", tt.name)
+		}
+		for previous, version := range versions {
+			if listing.ListingVersion == version {
+				t.Errorf("%s: expected a version different from %q", tt.name, previous)
+			}
+		}
+		versions[tt.name] = listing.ListingVersion
+	}
+}
+
+func TestDiskUsage(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"a.txt":              "a\n",
+		"logs/app.log":       "started\n",
+		"logs/old/app.1.log": "0123456789abcdef",
+		"docs/readme.md":     "# docs\n",
+		"docs/.draft.md":     "draft",
+		".cache/blob":        "0123456789abcdef0123456789abcdef",
+	} {
+		if err := os.MkdirAll(filepath.Dir(filepath.Join(dir, name)), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	repo := filesystem.NewFileSystemRepository(dir, 1024)
+
+	// Walking the subdirectories on a pool gives the same result as one by one
+	for _, workers := range []*workpool.Pool{nil, workpool.New(2)} {
+		service := NewDirectoryService(repo, logging.NewLoggerWithWriter(logging.LevelError, "json", io.Discard))
+		service.SetWorkerPool(workers)
+		usage, err := service.DiskUsage(context.Background(), &DiskUsageRequest{Path: "."})
+		workers.Close()
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		expected := []DiskUsageDTO{
+			{Name: "logs", TotalSize: 24, TotalFiles: 2, TotalDirectories: 1},
+			{Name: "docs", TotalSize: 7, TotalFiles: 1},
+		}
+		if !reflect.DeepEqual(usage.Directories, expected) {
+			t.Errorf("Expected %+v, got %+v", expected, usage.Directories)
+		}
+		if usage.TotalSize != 33 || usage.TotalFiles != 4 || usage.TotalDirectories != 3 {
+			t.Errorf("Expected 33 bytes in 4 files and 3 directories, got %+v", usage)
+		}
+	}
+}
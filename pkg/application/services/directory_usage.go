@@ -0,0 +1,145 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/sh05/cat-server/pkg/domain/valueobjects"
+)
+
+// DiskUsageRequest represents a request for the disk usage of a directory
+type DiskUsageRequest struct {
+	Path          string
+	IncludeHidden bool
+}
+
+// DiskUsageResponse sums the files below a directory, in total and for
+// each of its subdirectories, like `du -d 1`
+type DiskUsageResponse struct {
+	Path             string         `json:"path"`
+	TotalSize        int64          `json:"totalSize"`
+	TotalFiles       int            `json:"totalFiles"`
+	TotalDirectories int            `json:"totalDirectories"`
+	Directories      []DiskUsageDTO `json:"directories"` // largest first
+	GeneratedAt      time.Time      `json:"generatedAt"`
+}
+
+// DiskUsageDTO sums the files below a subdirectory. TotalDirectories
+// counts the directories below it, not itself.
+type DiskUsageDTO struct {
+	Name             string `json:"name"`
+	TotalSize        int64  `json:"totalSize"`
+	TotalFiles       int    `json:"totalFiles"`
+	TotalDirectories int    `json:"totalDirectories"`
+}
+
+// DiskUsage sums the sizes of the files below a directory. The
+// subdirectories are walked in parallel on the shared worker pool.
+func (s *DirectoryService) DiskUsage(ctx context.Context, request *DiskUsageRequest) (*DiskUsageResponse, error) {
+	start := time.Now()
+	operation := "disk_usage"
+
+	root, err := valueobjects.NewFilePath(request.Path)
+	if err != nil {
+		s.logger.LogFileSystemOperation(operation, request.Path, false, time.Since(start), 0)
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPath, err)
+	}
+
+	listing, err := s.fileSystemRepo.ListDirectory(ctx, root)
+	if err != nil {
+		s.logger.LogFileSystemOperation(operation, request.Path, false, time.Since(start), 0)
+		return nil, fmt.Errorf("failed to list directory: %w", err)
+	}
+
+	response := &DiskUsageResponse{Path: request.Path, Directories: []DiskUsageDTO{}}
+	var subdirs []*valueobjects.FilePath
+	for _, entry := range listing.Entries() {
+		if !request.IncludeHidden && entry.IsHidden() {
+			continue
+		}
+		if !entry.IsDir() {
+			response.TotalFiles++
+			response.TotalSize += entry.Size()
+			continue
+		}
+		dir, err := root.Join(entry.Name())
+		if err != nil {
+			s.logger.LogFileSystemOperation(operation, request.Path, false, time.Since(start), 0)
+			return nil, fmt.Errorf("failed to scan directory: %w", err)
+		}
+		subdirs = append(subdirs, dir)
+		response.Directories = append(response.Directories, DiskUsageDTO{Name: entry.Name()})
+	}
+
+	// Each subdirectory is walked by one task
+	errs := make([]error, len(subdirs))
+	group := s.workers.Group()
+	for i, dir := range subdirs {
+		if ctx.Err() != nil {
+			break
+		}
+		group.Go(func() {
+			errs[i] = s.addDiskUsage(ctx, dir, request.IncludeHidden, &response.Directories[i])
+		})
+	}
+	group.Wait()
+	if err := ctx.Err(); err != nil {
+		s.logger.LogFileSystemOperation(operation, request.Path, false, time.Since(start), 0)
+		return nil, err
+	}
+	if err := errors.Join(errs...); err != nil {
+		s.logger.LogFileSystemOperation(operation, request.Path, false, time.Since(start), 0)
+		return nil, fmt.Errorf("failed to scan directory: %w", err)
+	}
+
+	for _, usage := range response.Directories {
+		response.TotalSize += usage.TotalSize
+		response.TotalFiles += usage.TotalFiles
+		response.TotalDirectories += usage.TotalDirectories + 1
+	}
+	sort.SliceStable(response.Directories, func(i, j int) bool {
+		return response.Directories[i].TotalSize > response.Directories[j].TotalSize
+	})
+	response.GeneratedAt = time.Now()
+
+	s.logger.LogFileSystemOperation(operation, request.Path, true, time.Since(start), response.TotalSize)
+
+	return response, nil
+}
+
+// addDiskUsage adds the files and directories below dir to usage
+func (s *DirectoryService) addDiskUsage(ctx context.Context, dir *valueobjects.FilePath, includeHidden bool, usage *DiskUsageDTO) error {
+	// Listings may come from a cache or the index, so check for
+	// cancellation here
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	listing, err := s.fileSystemRepo.ListDirectory(ctx, dir)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range listing.Entries() {
+		if !includeHidden && entry.IsHidden() {
+			continue
+		}
+		if !entry.IsDir() {
+			usage.TotalFiles++
+			usage.TotalSize += entry.Size()
+			continue
+		}
+		usage.TotalDirectories++
+		child, err := dir.Join(entry.Name())
+		if err != nil {
+			return err
+		}
+		if err := s.addDiskUsage(ctx, child, includeHidden, usage); err != nil {
+			return err
+		}
+	}
+	return nil
+}
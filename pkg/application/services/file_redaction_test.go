@@ -0,0 +1,86 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/sh05/cat-server/pkg/infrastructure/filesystem"
+	"github.com/sh05/cat-server/pkg/infrastructure/logging"
+)
+
+// envRedactor masks every value in files named .env
+type envRedactor struct{}
+
+func (envRedactor) Matches(filename string) bool { return filepath.Base(filename) == ".env" }
+
+func (envRedactor) Redact(content string) string {
+	lines := strings.Split(content, "\n")
+	for i, line := range lines {
+		if key, _, ok := strings.Cut(line, "="); ok {
+			lines[i] = key + "=***"
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+func TestFileServiceRedaction(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		".env":      "SECRET_KEY=abc123\nDEBUG=true\n",
+		"env/.env":  "a,b\n1,2\n",
+		"notes.txt": "SECRET_KEY=visible\n",
+	}
+	for name, content := range files {
+		if err := os.MkdirAll(filepath.Dir(filepath.Join(dir, name)), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	repo := filesystem.NewFileSystemRepository(dir, 1024*1024)
+	logger := logging.NewLoggerWithWriter(logging.LevelError, "json", io.Discard)
+	plain := NewFileService(repo, logger)
+	redacted := plain.WithRedactor(envRedactor{})
+
+	tests := []struct {
+		service  *FileService
+		request  ReadFileRequest
+		content  string
+		redacted bool
+	}{
+		{redacted, ReadFileRequest{Filename: ".env"}, "SECRET_KEY=***\nDEBUG=***\n", true},
+		{redacted, ReadFileRequest{Filename: ".env", Transforms: []string{"sort"}}, "DEBUG=***\nSECRET_KEY=***\n", true},
+		{redacted, ReadFileRequest{Filename: ".env", PreviewOnly: true, PreviewSize: 12}, "SECRET_KEY=*...", true},
+		{redacted, ReadFileRequest{Filename: "notes.txt"}, "SECRET_KEY=visible\n", false},
+		{plain, ReadFileRequest{Filename: ".env"}, "SECRET_KEY=abc123\nDEBUG=true\n", false},
+	}
+	for _, tt := range tests {
+		response, err := tt.service.ReadFile(context.Background(), &tt.request)
+		if err != nil {
+			t.Errorf("ReadFile(%+v) returned error: %v", tt.request, err)
+			continue
+		}
+		if response.Content != tt.content || response.Redacted != tt.redacted {
+			t.Errorf("ReadFile(%+v) = %q (redacted %v), expected %q (redacted %v)",
+				tt.request, response.Content, response.Redacted, tt.content, tt.redacted)
+		}
+		if response.Redacted && response.Hash != 0 {
+			t.Errorf("Expected no hash for redacted content, got %d", response.Hash)
+		}
+	}
+
+	err := redacted.ExtractColumns(context.Background(), &ExtractColumnsRequest{Filename: "env/.env", Columns: []int{0}, Delimiter: ','}, io.Discard)
+	if !errors.Is(err, ErrRedacted) {
+		t.Errorf("Expected ErrRedacted extracting columns, got %v", err)
+	}
+	if err := plain.ExtractColumns(context.Background(), &ExtractColumnsRequest{Filename: "env/.env", Columns: []int{0}, Delimiter: ','}, io.Discard); err != nil {
+		t.Errorf("ExtractColumns without redaction returned error: %v", err)
+	}
+}
@@ -0,0 +1,68 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/sh05/cat-server/pkg/domain/repositories"
+	"github.com/sh05/cat-server/pkg/domain/valueobjects"
+)
+
+// FileStream is an open file served as is, without reading it into memory.
+// It is seekable so it can answer range and conditional requests.
+type FileStream struct {
+	io.ReadSeekCloser
+	Name    string
+	Size    int64
+	ModTime time.Time
+}
+
+// OpenFile opens a file for streaming its raw content; the caller must
+// close the stream. Unlike ReadFile it has no size limit, and it refuses
+// redacted files since their secrets cannot be masked in a raw stream.
+func (s *FileService) OpenFile(ctx context.Context, filename string) (*FileStream, error) {
+	start := time.Now()
+	operation := "open_file"
+
+	if err := s.ValidateFileAccess(filename); err != nil {
+		s.logger.LogFileSystemOperation(operation, filename, false, time.Since(start), 0)
+		return nil, fmt.Errorf("file access validation failed: %w", err)
+	}
+
+	if s.redacts(filename) {
+		s.logger.LogFileSystemOperation(operation, filename, false, time.Since(start), 0)
+		return nil, fmt.Errorf("%w: %s", ErrRedacted, filename)
+	}
+
+	filePath, err := valueobjects.NewFilePath(filename)
+	if err != nil {
+		s.logger.LogFileSystemOperation(operation, filename, false, time.Since(start), 0)
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPath, err)
+	}
+
+	fileInfo, err := s.fileSystemRepo.GetFileInfo(ctx, filePath)
+	if err != nil {
+		s.logger.LogFileSystemOperation(operation, filename, false, time.Since(start), 0)
+		return nil, fmt.Errorf("failed to get file info: %w", err)
+	}
+	if fileInfo.IsDir() {
+		s.logger.LogFileSystemOperation(operation, filename, false, time.Since(start), 0)
+		return nil, repositories.NewFileSystemError("OpenFile", filename, "path is a directory, not a file", repositories.ErrorInvalidPath)
+	}
+
+	file, err := s.fileSystemRepo.OpenFile(ctx, filePath)
+	if err != nil {
+		s.logger.LogFileSystemOperation(operation, filename, false, time.Since(start), 0)
+		return nil, fmt.Errorf("failed to open file: %w", err)
+	}
+
+	s.logger.LogFileSystemOperation(operation, filename, true, time.Since(start), fileInfo.Size())
+	return &FileStream{
+		ReadSeekCloser: file,
+		Name:           filename,
+		Size:           fileInfo.Size(),
+		ModTime:        fileInfo.ModTime(),
+	}, nil
+}
@@ -0,0 +1,76 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sh05/cat-server/pkg/domain/repositories"
+	"github.com/sh05/cat-server/pkg/infrastructure/filesystem"
+	"github.com/sh05/cat-server/pkg/infrastructure/logging"
+)
+
+func TestFileServiceOpenFile(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "app.log"), []byte("0123456789"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, ".env"), []byte("SECRET_KEY=abc123\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Mkdir(filepath.Join(dir, "logs"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "huge.log"), make([]byte, 1025), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	repo := filesystem.NewFileSystemRepository(dir, 1024)
+	logger := logging.NewLoggerWithWriter(logging.LevelError, "json", io.Discard)
+	service := NewFileService(repo, logger).WithRedactor(envRedactor{})
+
+	stream, err := service.OpenFile(context.Background(), "app.log")
+	if err != nil {
+		t.Fatalf("OpenFile returned error: %v", err)
+	}
+	defer stream.Close()
+	if stream.Name != "app.log" || stream.Size != 10 || stream.ModTime.IsZero() {
+		t.Errorf("Unexpected stream %q, size %d, modified %v", stream.Name, stream.Size, stream.ModTime)
+	}
+	if _, err := stream.Seek(6, io.SeekStart); err != nil {
+		t.Fatal(err)
+	}
+	if rest, _ := io.ReadAll(stream); string(rest) != "6789" {
+		t.Errorf("Expected %q after seeking, got %q", "6789", rest)
+	}
+
+	errorTests := []struct {
+		filename string
+		code     repositories.ErrorCode
+		err      error
+	}{
+		{filename: "missing.log", code: repositories.ErrorNotFound},
+		{filename: "logs", code: repositories.ErrorInvalidPath},
+		{filename: "huge.log", code: repositories.ErrorFileTooLarge},
+		{filename: ".env", err: ErrRedacted},
+	}
+	for _, tt := range errorTests {
+		t.Run(tt.filename, func(t *testing.T) {
+			_, err := service.OpenFile(context.Background(), tt.filename)
+			if tt.err != nil {
+				if !errors.Is(err, tt.err) {
+					t.Errorf("Expected %v, got %v", tt.err, err)
+				}
+				return
+			}
+			var fsErr *repositories.FileSystemError
+			if !errors.As(err, &fsErr) || fsErr.Code != tt.code {
+				t.Errorf("Expected file system error %v, got %v", tt.code, err)
+			}
+		})
+	}
+}
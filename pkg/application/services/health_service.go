@@ -1,29 +1,63 @@
 package services
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
 	"runtime"
+	"sync/atomic"
 	"time"
 
 	"github.com/sh05/cat-server/pkg/domain/repositories"
+	"github.com/sh05/cat-server/pkg/domain/valueobjects"
+	"github.com/sh05/cat-server/pkg/infrastructure/filesystem"
 	"github.com/sh05/cat-server/pkg/infrastructure/logging"
+	"github.com/sh05/cat-server/pkg/infrastructure/memguard"
+	"github.com/sh05/cat-server/pkg/infrastructure/metrics"
 )
 
+// Health modes
+const (
+	ModeReadOnly  = "read-only"
+	ModeReadWrite = "read-write"
+)
+
+// ErrUnknownComponent is returned for health checks of components that do
+// not exist
+var ErrUnknownComponent = errors.New("unknown health component")
+
 // HealthService provides use cases for health checking operations
 type HealthService struct {
 	fileSystemRepo repositories.FileSystemRepository
 	logger         *logging.Logger
 	startTime      time.Time
 	version        string
+	metrics        *metrics.Registry
+	readOnly       bool
+	serving        atomic.Bool // listeners bound and not shutting down
+	disk           diskCheck
+	memory         *memguard.Guard // nil without a memory limit
+}
+
+// diskCheck reports the disk usage of the base directory's filesystem
+// against usage thresholds in percent, where 0 disables a threshold
+type diskCheck struct {
+	usage    func() (filesystem.DiskUsage, error)
+	warning  float64
+	critical float64
 }
 
 // NewHealthService creates a new HealthService
 func NewHealthService(fileSystemRepo repositories.FileSystemRepository, logger *logging.Logger, version string) *HealthService {
-	return &HealthService{
+	s := &HealthService{
 		fileSystemRepo: fileSystemRepo,
 		logger:         logger,
 		startTime:      time.Now(),
 		version:        version,
 	}
+	s.serving.Store(true)
+	return s
 }
 
 // HealthResponse represents the health check response
@@ -33,6 +67,7 @@ type HealthResponse struct {
 	Version    string                     `json:"version"`
 	Uptime     string                     `json:"uptime"`
 	UptimeMs   int64                      `json:"uptimeMs"`
+	Mode       string                     `json:"mode"`
 	System     *SystemHealthInfo          `json:"system,omitempty"`
 	Components map[string]ComponentHealth `json:"components,omitempty"`
 	Metrics    *HealthMetrics             `json:"metrics,omitempty"`
@@ -81,6 +116,13 @@ type ComponentHealth struct {
 	Details     interface{}   `json:"details,omitempty"`
 }
 
+// ReadinessResponse reports whether the server should receive traffic and
+// the result of each readiness check
+type ReadinessResponse struct {
+	Ready  bool              `json:"ready"`
+	Checks map[string]string `json:"checks"`
+}
+
 // HealthMetrics represents key health metrics
 type HealthMetrics struct {
 	RequestCount    int64         `json:"requestCount"`
@@ -100,6 +142,7 @@ func (s *HealthService) GetSystemHealth() (*HealthResponse, error) {
 		Version:   s.version,
 		Uptime:    s.getUptime(),
 		UptimeMs:  time.Since(s.startTime).Milliseconds(),
+		Mode:      s.mode(),
 	}
 
 	// Log health check
@@ -110,7 +153,7 @@ func (s *HealthService) GetSystemHealth() (*HealthResponse, error) {
 }
 
 // GetDetailedHealth returns comprehensive health information
-func (s *HealthService) GetDetailedHealth() (*HealthResponse, error) {
+func (s *HealthService) GetDetailedHealth(ctx context.Context) (*HealthResponse, error) {
 	start := time.Now()
 
 	// Get basic health first
@@ -126,13 +169,18 @@ func (s *HealthService) GetDetailedHealth() (*HealthResponse, error) {
 	components := make(map[string]ComponentHealth)
 
 	// Check filesystem component
-	fsHealth := s.checkFileSystemHealth()
+	fsHealth := s.checkFileSystemHealth(ctx)
 	components["filesystem"] = fsHealth
 
 	// Check memory component
 	memHealth := s.checkMemoryHealth()
 	components["memory"] = memHealth
 
+	// Summarize cache and index subsystems
+	if s.metrics != nil {
+		components["caches"] = s.checkCacheHealth()
+	}
+
 	response.Components = components
 
 	// Add metrics
@@ -149,31 +197,76 @@ func (s *HealthService) GetDetailedHealth() (*HealthResponse, error) {
 	return response, nil
 }
 
+// Readiness checks that the server is serving and its base directory is
+// accessible. Unlike health, readiness turns false during graceful
+// shutdown so load balancers stop routing traffic to the server.
+func (s *HealthService) Readiness(ctx context.Context) *ReadinessResponse {
+	response := &ReadinessResponse{Ready: true, Checks: map[string]string{"serving": "ok", "filesystem": "ok"}}
+	if !s.serving.Load() {
+		response.Ready = false
+		response.Checks["serving"] = "not accepting traffic"
+	}
+	if err := s.checkBaseDirectory(ctx); err != nil {
+		response.Ready = false
+		response.Checks["filesystem"] = err.Error()
+	}
+	return response
+}
+
+// SetServing records whether the server's listeners are bound and it is
+// not shutting down, as reported by Readiness
+func (s *HealthService) SetServing(serving bool) {
+	s.serving.Store(serving)
+}
+
+// checkBaseDirectory checks that the base directory can be read
+func (s *HealthService) checkBaseDirectory(ctx context.Context) error {
+	root, err := valueobjects.NewFilePath(".")
+	if err != nil {
+		return err
+	}
+	if !s.fileSystemRepo.IsDirectory(ctx, root) {
+		return errors.New("base directory is not accessible")
+	}
+	if !s.fileSystemRepo.IsReadable(ctx, root) {
+		return errors.New("base directory is not readable")
+	}
+	return nil
+}
+
+// readBaseDirectory lists the base directory and returns its entry count
+func (s *HealthService) readBaseDirectory(ctx context.Context) (int, error) {
+	root, err := valueobjects.NewFilePath(".")
+	if err != nil {
+		return 0, err
+	}
+	listing, err := s.fileSystemRepo.ListDirectory(ctx, root)
+	if err != nil {
+		return 0, err
+	}
+	return listing.TotalCount(), nil
+}
+
 // GetHealthMetrics returns performance metrics
 func (s *HealthService) GetHealthMetrics() (*HealthMetrics, error) {
 	return s.getHealthMetrics(), nil
 }
 
 // CheckComponent checks the health of a specific component
-func (s *HealthService) CheckComponent(component string) (*ComponentHealth, error) {
-	start := time.Now()
-
+func (s *HealthService) CheckComponent(ctx context.Context, component string) (*ComponentHealth, error) {
 	var health ComponentHealth
 
 	switch component {
 	case "filesystem":
-		health = s.checkFileSystemHealth()
+		health = s.checkFileSystemHealth(ctx)
 	case "memory":
 		health = s.checkMemoryHealth()
 	case "goroutines":
 		health = s.checkGoroutineHealth()
+	case "caches":
+		health = s.checkCacheHealth()
 	default:
-		health = ComponentHealth{
-			Status:      "unknown",
-			Message:     "unknown component",
-			LastChecked: time.Now(),
-			Duration:    time.Since(start),
-		}
+		return nil, fmt.Errorf("%w: %q", ErrUnknownComponent, component)
 	}
 
 	// Log component check
@@ -190,61 +283,105 @@ func (s *HealthService) getUptime() string {
 }
 
 func (s *HealthService) getSystemHealthInfo() *SystemHealthInfo {
-	var m runtime.MemStats
-	runtime.ReadMemStats(&m)
+	// The same statistics are exported as gauges by metrics.Registry
+	rt := metrics.ReadRuntime()
 
 	var gcStats *GCInfo
-	if m.NumGC > 0 {
-		lastGC := time.Unix(0, int64(m.LastGC))
+	if rt.NumGC > 0 {
 		gcStats = &GCInfo{
-			NumGC:      m.NumGC,
-			PauseTotal: time.Duration(m.PauseTotalNs),
-			LastPause:  time.Duration(m.PauseNs[(m.NumGC+255)%256]),
-			NextGC:     m.NextGC,
-			LastGC:     lastGC,
+			NumGC:      rt.NumGC,
+			PauseTotal: rt.PauseTotal,
+			LastPause:  rt.LastPause,
+			NextGC:     rt.NextGC,
+			LastGC:     rt.LastGC,
 		}
 	}
 
 	return &SystemHealthInfo{
 		Memory: &MemoryInfo{
-			Allocated:   m.Alloc,
-			TotalAlloc:  m.TotalAlloc,
-			System:      m.Sys,
-			GCCycles:    m.NumGC,
-			HeapObjects: m.HeapObjects,
-			HeapInuse:   m.HeapInuse,
-			StackInuse:  m.StackInuse,
+			Allocated:   rt.Alloc,
+			TotalAlloc:  rt.TotalAlloc,
+			System:      rt.Sys,
+			GCCycles:    rt.NumGC,
+			HeapObjects: rt.HeapObjects,
+			HeapInuse:   rt.HeapInuse,
+			StackInuse:  rt.StackInuse,
 		},
-		Goroutines: runtime.NumGoroutine(),
+		Goroutines: rt.Goroutines,
 		GCStats:    gcStats,
 		LoadAverage: &LoadInfo{
-			NumCPU:       runtime.NumCPU(),
-			NumGoroutine: runtime.NumGoroutine(),
+			NumCPU:       rt.NumCPU,
+			NumGoroutine: rt.Goroutines,
 		},
 	}
 }
 
-func (s *HealthService) checkFileSystemHealth() ComponentHealth {
+func (s *HealthService) checkFileSystemHealth(ctx context.Context) ComponentHealth {
 	start := time.Now()
 
-	// Try to validate a simple path to check filesystem access
-	// This is a basic check - in a real implementation you might want to
-	// check disk space, read/write permissions, etc.
-	status := "healthy"
-	message := "filesystem accessible"
+	status, message := "healthy", "filesystem accessible"
+	details := map[string]interface{}{}
 
-	// Here you could add more comprehensive filesystem checks
-	// For example, checking if base directory is accessible,
-	// checking disk space, etc.
+	if err := s.checkBaseDirectory(ctx); err != nil {
+		status, message = "unhealthy", err.Error()
+	} else if entries, err := s.readBaseDirectory(ctx); err != nil {
+		status, message = "unhealthy", fmt.Sprintf("cannot list base directory: %v", err)
+	} else {
+		details["entries"] = entries
+	}
+
+	if s.disk.usage != nil {
+		usage, err := s.disk.usage()
+		switch {
+		case errors.Is(err, errors.ErrUnsupported):
+			// Disk usage is only reported where it can be measured
+		case err != nil:
+			details["diskError"] = err.Error()
+		default:
+			details["totalBytes"] = usage.TotalBytes
+			details["freeBytes"] = usage.FreeBytes
+			details["usedPercent"] = roundPercent(usage.UsedPercent())
+			if usage.TotalInodes > 0 {
+				details["totalInodes"] = usage.TotalInodes
+				details["freeInodes"] = usage.FreeInodes
+				details["inodesUsedPercent"] = roundPercent(usage.InodesUsedPercent())
+			}
+			if status == "healthy" {
+				status, message = s.disk.status(usage)
+			}
+		}
+	}
 
 	return ComponentHealth{
 		Status:      status,
 		Message:     message,
 		LastChecked: time.Now(),
 		Duration:    time.Since(start),
+		Details:     details,
 	}
 }
 
+// status rates usage against the thresholds, taking the fuller of space
+// and inodes
+func (c diskCheck) status(usage filesystem.DiskUsage) (status, message string) {
+	resource, used := "disk space", usage.UsedPercent()
+	if inodes := usage.InodesUsedPercent(); inodes > used {
+		resource, used = "inode", inodes
+	}
+	switch {
+	case c.critical > 0 && used >= c.critical:
+		return "unhealthy", fmt.Sprintf("%s usage %.1f%% at or above critical threshold %g%%", resource, used, c.critical)
+	case c.warning > 0 && used >= c.warning:
+		return "warning", fmt.Sprintf("%s usage %.1f%% at or above warning threshold %g%%", resource, used, c.warning)
+	}
+	return "healthy", "filesystem accessible"
+}
+
+// roundPercent rounds a percentage to one decimal place for display
+func roundPercent(p float64) float64 {
+	return math.Round(p*10) / 10
+}
+
 func (s *HealthService) checkMemoryHealth() ComponentHealth {
 	start := time.Now()
 
@@ -259,11 +396,6 @@ func (s *HealthService) checkMemoryHealth() ComponentHealth {
 	const maxMemoryMB = 500 // 500MB threshold
 	memoryUsageMB := m.Alloc / 1024 / 1024
 
-	if memoryUsageMB > maxMemoryMB {
-		status = "warning"
-		message = "high memory usage"
-	}
-
 	details := map[string]interface{}{
 		"allocatedMB": memoryUsageMB,
 		"systemMB":    m.Sys / 1024 / 1024,
@@ -271,6 +403,19 @@ func (s *HealthService) checkMemoryHealth() ComponentHealth {
 		"heapObjects": m.HeapObjects,
 	}
 
+	// With a memory limit, judge by the threshold of the memory guard
+	if s.memory != nil {
+		details["limitMB"] = s.memory.Limit() / 1024 / 1024
+		details["largeReadThresholdMB"] = s.memory.Threshold() / 1024 / 1024
+		if s.memory.Pressure() {
+			status = "warning"
+			message = "heap near the memory limit, refusing large reads"
+		}
+	} else if memoryUsageMB > maxMemoryMB {
+		status = "warning"
+		message = "high memory usage"
+	}
+
 	return ComponentHealth{
 		Status:      status,
 		Message:     message,
@@ -308,15 +453,59 @@ func (s *HealthService) checkGoroutineHealth() ComponentHealth {
 	}
 }
 
+func (s *HealthService) checkCacheHealth() ComponentHealth {
+	start := time.Now()
+
+	var snapshots []metrics.CacheSnapshot
+	if s.metrics != nil {
+		snapshots = s.metrics.CacheSnapshots()
+	}
+
+	details := make(map[string]interface{}, len(snapshots))
+	for _, snapshot := range snapshots {
+		details[snapshot.Name] = map[string]interface{}{
+			"entries":             snapshot.Entries,
+			"bytes":               snapshot.Bytes,
+			"hitRatio":            snapshot.HitRatio,
+			"evictions":           snapshot.Evictions,
+			"rebuilds":            snapshot.Rebuilds,
+			"lastRebuildDuration": snapshot.LastRebuildDuration.String(),
+			"events":              snapshot.Events,
+		}
+
+		s.logger.LogMetrics(map[string]interface{}{
+			"cache":     snapshot.Name,
+			"entries":   snapshot.Entries,
+			"bytes":     snapshot.Bytes,
+			"hit_ratio": snapshot.HitRatio,
+			"evictions": snapshot.Evictions,
+			"rebuilds":  snapshot.Rebuilds,
+			"events":    snapshot.Events,
+		})
+	}
+
+	return ComponentHealth{
+		Status:      "healthy",
+		Message:     fmt.Sprintf("%d cache subsystems registered", len(snapshots)),
+		LastChecked: time.Now(),
+		Duration:    time.Since(start),
+		Details:     details,
+	}
+}
+
+// getHealthMetrics reports the requests recorded by the HTTP middleware in
+// the metrics registry
 func (s *HealthService) getHealthMetrics() *HealthMetrics {
-	// In a real implementation, these would be collected from actual metrics
-	// This is a simplified version
+	if s.metrics == nil {
+		return &HealthMetrics{SuccessRate: 100.0}
+	}
+	requests := s.metrics.Requests().Snapshot()
 	return &HealthMetrics{
-		RequestCount:    0, // Would be tracked by middleware
-		ErrorCount:      0, // Would be tracked by error handling
-		AverageResponse: 0, // Would be calculated from request logs
-		SuccessRate:     100.0,
-		LastActivity:    time.Now(),
+		RequestCount:    requests.Requests,
+		ErrorCount:      requests.Errors,
+		AverageResponse: requests.AverageResponse,
+		SuccessRate:     requests.SuccessRate,
+		LastActivity:    requests.LastActivity,
 	}
 }
 
@@ -342,7 +531,46 @@ func (s *HealthService) calculateOverallStatus(components map[string]ComponentHe
 	return "healthy"
 }
 
-// SetStartTime sets the application start time (useful for testing)
+// SetMetricsRegistry attaches the registry whose cache and index metrics are
+// summarized in detailed health output
+func (s *HealthService) SetMetricsRegistry(registry *metrics.Registry) {
+	s.metrics = registry
+}
+
+// SetMemoryGuard makes memory health report a warning while guard refuses
+// large reads, instead of above a fixed heap size
+func (s *HealthService) SetMemoryGuard(guard *memguard.Guard) {
+	s.memory = guard
+}
+
+// SetDiskCheck makes filesystem health report the disk usage of the
+// filesystem holding baseDir, as a warning from warningPercent and
+// unhealthy from criticalPercent of space or inodes used. A threshold of 0
+// is disabled.
+func (s *HealthService) SetDiskCheck(baseDir string, warningPercent, criticalPercent float64) {
+	s.disk = diskCheck{
+		usage:    func() (filesystem.DiskUsage, error) { return filesystem.StatDisk(baseDir) },
+		warning:  warningPercent,
+		critical: criticalPercent,
+	}
+}
+
+// SetReadOnly records that the server runs in read-only mode, which is
+// reported as the health mode
+func (s *HealthService) SetReadOnly(readOnly bool) {
+	s.readOnly = readOnly
+}
+
+// mode reports whether the server may modify the served directory
+func (s *HealthService) mode() string {
+	if s.readOnly {
+		return ModeReadOnly
+	}
+	return ModeReadWrite
+}
+
+// SetStartTime sets the time uptime is measured from, by default when the
+// service was created
 func (s *HealthService) SetStartTime(startTime time.Time) {
 	s.startTime = startTime
 }
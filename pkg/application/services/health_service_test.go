@@ -0,0 +1,34 @@
+package services
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/sh05/cat-server/pkg/infrastructure/filesystem"
+)
+
+func TestDiskCheckStatus(t *testing.T) {
+	check := diskCheck{warning: 90, critical: 95}
+	tests := []struct {
+		name        string
+		check       diskCheck
+		usage       filesystem.DiskUsage
+		wantStatus  string
+		wantMessage string
+	}{
+		{"below thresholds", check, filesystem.DiskUsage{TotalBytes: 100, FreeBytes: 50}, "healthy", "filesystem accessible"},
+		{"space warning", check, filesystem.DiskUsage{TotalBytes: 100, FreeBytes: 10}, "warning", "disk space usage 90.0%"},
+		{"space critical", check, filesystem.DiskUsage{TotalBytes: 100, FreeBytes: 2}, "unhealthy", "critical threshold 95%"},
+		{"inodes critical", check, filesystem.DiskUsage{TotalBytes: 100, FreeBytes: 50, TotalInodes: 100, FreeInodes: 1}, "unhealthy", "inode usage 99.0%"},
+		{"warning disabled", diskCheck{critical: 95}, filesystem.DiskUsage{TotalBytes: 100, FreeBytes: 10}, "healthy", "filesystem accessible"},
+		{"all disabled", diskCheck{}, filesystem.DiskUsage{TotalBytes: 100}, "healthy", "filesystem accessible"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, message := tt.check.status(tt.usage)
+			if status != tt.wantStatus || !strings.Contains(message, tt.wantMessage) {
+				t.Errorf("status() = %q, %q, want %q with %q", status, message, tt.wantStatus, tt.wantMessage)
+			}
+		})
+	}
+}
@@ -0,0 +1,145 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/sh05/cat-server/pkg/domain/valueobjects"
+)
+
+// ErrInvalidByteRange is returned for negative offsets or lengths
+var ErrInvalidByteRange = errors.New("invalid byte range")
+
+// Hexdump limits
+const (
+	DefaultHexDumpLength = 256
+	MaxHexDumpLength     = 64 * 1024 // 64KB
+	hexDumpWidth         = 16
+)
+
+// HexDumpRequest represents a request to dump a byte range of a file
+type HexDumpRequest struct {
+	Filename string
+	Offset   int64
+	Length   int64
+}
+
+// HexDumpResponse holds the bytes read for a hexdump
+type HexDumpResponse struct {
+	Filename string
+	Offset   int64
+	Size     int64 // size of the whole file
+	Data     []byte
+}
+
+// HexDump reads up to Length bytes starting at Offset. Length defaults to
+// DefaultHexDumpLength and is capped at MaxHexDumpLength; an offset past the
+// end of the file yields no data.
+func (s *FileService) HexDump(ctx context.Context, request *HexDumpRequest) (*HexDumpResponse, error) {
+	start := time.Now()
+	operation := "hexdump"
+
+	if request.Offset < 0 || request.Length < 0 {
+		s.logger.LogFileSystemOperation(operation, request.Filename, false, time.Since(start), 0)
+		return nil, fmt.Errorf("%w: offset and length must not be negative", ErrInvalidByteRange)
+	}
+	length := request.Length
+	if length == 0 {
+		length = DefaultHexDumpLength
+	}
+	if length > MaxHexDumpLength {
+		length = MaxHexDumpLength
+	}
+
+	// Raw bytes cannot be masked
+	if s.redacts(request.Filename) {
+		s.logger.LogFileSystemOperation(operation, request.Filename, false, time.Since(start), 0)
+		return nil, fmt.Errorf("%w: %s", ErrRedacted, request.Filename)
+	}
+
+	filePath, err := valueobjects.NewFilePath(request.Filename)
+	if err != nil {
+		s.logger.LogFileSystemOperation(operation, request.Filename, false, time.Since(start), 0)
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPath, err)
+	}
+
+	fileInfo, err := s.fileSystemRepo.GetFileInfo(ctx, filePath)
+	if err != nil {
+		s.logger.LogFileSystemOperation(operation, request.Filename, false, time.Since(start), 0)
+		return nil, fmt.Errorf("failed to get file info: %w", err)
+	}
+
+	f, err := s.fileSystemRepo.OpenFile(ctx, filePath)
+	if err != nil {
+		s.logger.LogFileSystemOperation(operation, request.Filename, false, time.Since(start), 0)
+		return nil, fmt.Errorf("failed to open file: %w", err)
+	}
+	defer f.Close()
+
+	if seeker, ok := f.(io.Seeker); ok {
+		_, err = seeker.Seek(request.Offset, io.SeekStart)
+	} else {
+		_, err = io.CopyN(io.Discard, f, request.Offset)
+		if err == io.EOF {
+			err = nil
+		}
+	}
+	if err != nil {
+		s.logger.LogFileSystemOperation(operation, request.Filename, false, time.Since(start), 0)
+		return nil, fmt.Errorf("failed to seek: %w", err)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(f, length))
+	if err != nil {
+		s.logger.LogFileSystemOperation(operation, request.Filename, false, time.Since(start), int64(len(data)))
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+
+	s.logger.LogFileSystemOperation(operation, request.Filename, true, time.Since(start), int64(len(data)))
+
+	return &HexDumpResponse{
+		Filename: request.Filename,
+		Offset:   request.Offset,
+		Size:     fileInfo.Size(),
+		Data:     data,
+	}, nil
+}
+
+// WriteXXD writes the data in the format of `xxd`: an offset column, sixteen
+// bytes as two-byte hex groups, and their printable ASCII characters
+func (r *HexDumpResponse) WriteXXD(w io.Writer) error {
+	const hexdigits = "0123456789abcdef"
+	line := make([]byte, 0, 80)
+
+	for pos := 0; pos < len(r.Data); pos += hexDumpWidth {
+		chunk := r.Data[pos:min(pos+hexDumpWidth, len(r.Data))]
+
+		line = fmt.Appendf(line[:0], "%08x: ", r.Offset+int64(pos))
+		for i := 0; i < hexDumpWidth; i++ {
+			if i < len(chunk) {
+				line = append(line, hexdigits[chunk[i]>>4], hexdigits[chunk[i]&0x0f])
+			} else {
+				line = append(line, ' ', ' ')
+			}
+			if i%2 == 1 {
+				line = append(line, ' ')
+			}
+		}
+		line = append(line, ' ')
+		for _, b := range chunk {
+			if b < 0x20 || b > 0x7e {
+				b = '.'
+			}
+			line = append(line, b)
+		}
+		line = append(line, '\n')
+
+		if _, err := w.Write(line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,38 @@
+package services
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestWriteXXD(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     string
+		offset   int64
+		expected string
+	}{
+		{"empty", "", 0, ""},
+		{
+			"matches xxd",
+			"Hello, world!\n\x00\x01\x02\xff binary tail",
+			0,
+			"00000000: 4865 6c6c 6f2c 2077 6f72 6c64 210a 0001  Hello, world!...\n" +
+				"00000010: 02ff 2062 696e 6172 7920 7461 696c       .. binary tail\n",
+		},
+		{"odd length with offset", "abc", 0x1f0, "000001f0: 6162 63                                  abc\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var b strings.Builder
+			response := &HexDumpResponse{Offset: tt.offset, Data: []byte(tt.data)}
+			if err := response.WriteXXD(&b); err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if b.String() != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, b.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,234 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"time"
+
+	"github.com/sh05/cat-server/pkg/domain/repositories"
+	"github.com/sh05/cat-server/pkg/domain/valueobjects"
+	"github.com/sh05/cat-server/pkg/infrastructure/cache"
+	"github.com/sh05/cat-server/pkg/infrastructure/imaging"
+	"github.com/sh05/cat-server/pkg/infrastructure/logging"
+)
+
+// ErrNotImage is returned when a file is not a supported image
+var ErrNotImage = errors.New("file is not a supported image")
+
+// ErrImageTooLarge is returned when decoding an image would use too much memory
+var ErrImageTooLarge = errors.New("image dimensions too large")
+
+// Image limits
+const (
+	DefaultThumbnailWidth     = 200
+	MaxThumbnailWidth         = 1024
+	MaxImagePixels            = 50 * 1000 * 1000
+	DefaultThumbnailCacheSize = 32 * 1024 * 1024 // 32MB
+	thumbnailJPEGQuality      = 85
+)
+
+// ImageService provides use cases for image previews and metadata
+type ImageService struct {
+	fileSystemRepo repositories.FileSystemRepository
+	logger         *logging.Logger
+	thumbnails     *cache.LRU
+}
+
+// NewImageService creates a new ImageService with an unmetered thumbnail cache
+func NewImageService(fileSystemRepo repositories.FileSystemRepository, logger *logging.Logger) *ImageService {
+	return &ImageService{
+		fileSystemRepo: fileSystemRepo,
+		logger:         logger,
+		thumbnails:     cache.NewLRU(DefaultThumbnailCacheSize, nil),
+	}
+}
+
+// SetThumbnailCache replaces the thumbnail cache, e.g. with one reporting metrics
+func (s *ImageService) SetThumbnailCache(thumbnails *cache.LRU) {
+	s.thumbnails = thumbnails
+}
+
+// WithLogger returns a copy of the service that logs through the given logger.
+// Handlers use it to attach request-scoped fields to filesystem operation logs.
+func (s *ImageService) WithLogger(logger *logging.Logger) *ImageService {
+	clone := *s
+	clone.logger = logger
+	return &clone
+}
+
+// ThumbnailRequest represents a request for a downscaled image
+type ThumbnailRequest struct {
+	Filename string
+	Width    int
+}
+
+// ThumbnailResponse holds an encoded thumbnail
+type ThumbnailResponse struct {
+	Data        []byte
+	ContentType string
+	Cached      bool
+}
+
+// ImageMetadataResponse represents the dimensions and EXIF tags of an image
+type ImageMetadataResponse struct {
+	Filename string                 `json:"filename"`
+	Format   string                 `json:"format"`
+	Width    int                    `json:"width"`
+	Height   int                    `json:"height"`
+	Size     int64                  `json:"size"`
+	ModTime  time.Time              `json:"modTime"`
+	EXIF     map[string]interface{} `json:"exif,omitempty"`
+}
+
+// Thumbnail returns the image scaled down to at most Width pixels wide.
+// JPEG sources produce JPEG thumbnails; PNG and GIF sources produce PNG.
+// Results are cached by path, modification time, size and width.
+func (s *ImageService) Thumbnail(ctx context.Context, request *ThumbnailRequest) (*ThumbnailResponse, error) {
+	start := time.Now()
+	operation := "thumbnail"
+
+	width := request.Width
+	if width <= 0 {
+		width = DefaultThumbnailWidth
+	}
+	if width > MaxThumbnailWidth {
+		width = MaxThumbnailWidth
+	}
+
+	filePath, err := valueobjects.NewFilePath(request.Filename)
+	if err != nil {
+		s.logger.LogFileSystemOperation(operation, request.Filename, false, time.Since(start), 0)
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPath, err)
+	}
+
+	fileInfo, err := s.fileSystemRepo.GetFileInfo(ctx, filePath)
+	if err != nil {
+		s.logger.LogFileSystemOperation(operation, request.Filename, false, time.Since(start), 0)
+		return nil, fmt.Errorf("failed to get file info: %w", err)
+	}
+
+	key := fmt.Sprintf("%s|%d|%d|%d", filePath.String(), fileInfo.ModTime().UnixNano(), fileInfo.Size(), width)
+	if data, ok := s.thumbnails.Get(key); ok {
+		s.logger.LogFileSystemOperation(operation, request.Filename, true, time.Since(start), int64(len(data)))
+		return &ThumbnailResponse{
+			Data:        data,
+			ContentType: valueobjects.DetectFileType(data).MimeType(),
+			Cached:      true,
+		}, nil
+	}
+
+	img, format, err := s.decodeImage(ctx, filePath)
+	if err != nil {
+		s.logger.LogFileSystemOperation(operation, request.Filename, false, time.Since(start), fileInfo.Size())
+		return nil, err
+	}
+
+	thumb := imaging.Thumbnail(img, width)
+
+	var buf bytes.Buffer
+	contentType := "image/png"
+	if format == "jpeg" {
+		contentType = "image/jpeg"
+		err = jpeg.Encode(&buf, thumb, &jpeg.Options{Quality: thumbnailJPEGQuality})
+	} else {
+		err = png.Encode(&buf, thumb)
+	}
+	if err != nil {
+		s.logger.LogFileSystemOperation(operation, request.Filename, false, time.Since(start), fileInfo.Size())
+		return nil, fmt.Errorf("failed to encode thumbnail: %w", err)
+	}
+
+	s.thumbnails.Add(key, buf.Bytes())
+	s.logger.LogFileSystemOperation(operation, request.Filename, true, time.Since(start), int64(buf.Len()))
+
+	return &ThumbnailResponse{
+		Data:        buf.Bytes(),
+		ContentType: contentType,
+	}, nil
+}
+
+// Metadata returns the format, dimensions and, for JPEG files, EXIF tags of an image
+func (s *ImageService) Metadata(ctx context.Context, filename string) (*ImageMetadataResponse, error) {
+	start := time.Now()
+	operation := "image_metadata"
+
+	filePath, err := valueobjects.NewFilePath(filename)
+	if err != nil {
+		s.logger.LogFileSystemOperation(operation, filename, false, time.Since(start), 0)
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPath, err)
+	}
+
+	fileContent, err := s.fileSystemRepo.ReadFile(ctx, filePath)
+	if err != nil {
+		s.logger.LogFileSystemOperation(operation, filename, false, time.Since(start), 0)
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+
+	config, format, err := image.DecodeConfig(bytes.NewReader(fileContent.Content()))
+	if err != nil {
+		s.logger.LogFileSystemOperation(operation, filename, false, time.Since(start), fileContent.Size())
+		return nil, fmt.Errorf("%w: %s", ErrNotImage, filename)
+	}
+
+	response := &ImageMetadataResponse{
+		Filename: filename,
+		Format:   format,
+		Width:    config.Width,
+		Height:   config.Height,
+		Size:     fileContent.Size(),
+		ModTime:  fileContent.Entry().ModTime(),
+	}
+
+	if format == "jpeg" {
+		tags, err := imaging.ReadEXIF(fileContent.Content())
+		if err != nil && !errors.Is(err, imaging.ErrNoEXIF) {
+			s.logger.Debug("ignoring malformed EXIF data", "filename", filename, "error", err)
+		}
+		response.EXIF = tags
+	}
+
+	s.logger.LogFileSystemOperation(operation, filename, true, time.Since(start), fileContent.Size())
+
+	return response, nil
+}
+
+// decodeImage reads and decodes a JPEG, PNG or GIF file, refusing images
+// whose decoded size would exceed MaxImagePixels
+func (s *ImageService) decodeImage(ctx context.Context, filePath *valueobjects.FilePath) (image.Image, string, error) {
+	fileContent, err := s.fileSystemRepo.ReadFile(ctx, filePath)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read file: %w", err)
+	}
+	data := fileContent.Content()
+
+	config, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", fmt.Errorf("%w: %s", ErrNotImage, filePath.String())
+	}
+	if int64(config.Width)*int64(config.Height) > MaxImagePixels {
+		return nil, "", fmt.Errorf("%w: %dx%d", ErrImageTooLarge, config.Width, config.Height)
+	}
+
+	var img image.Image
+	switch format {
+	case "jpeg":
+		img, err = jpeg.Decode(bytes.NewReader(data))
+	case "png":
+		img, err = png.Decode(bytes.NewReader(data))
+	case "gif":
+		img, err = gif.Decode(bytes.NewReader(data))
+	default:
+		return nil, "", fmt.Errorf("%w: %s", ErrNotImage, filePath.String())
+	}
+	if err != nil {
+		return nil, "", fmt.Errorf("%w: %v", ErrNotImage, err)
+	}
+
+	return img, format, nil
+}
@@ -0,0 +1,431 @@
+package services
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sh05/cat-server/pkg/domain/repositories"
+	"github.com/sh05/cat-server/pkg/domain/valueobjects"
+	"github.com/sh05/cat-server/pkg/infrastructure/logging"
+)
+
+// ErrInvalidLogQuery is returned for malformed log filters
+var ErrInvalidLogQuery = errors.New("invalid log query")
+
+// Log query limits
+const (
+	DefaultLogEntries = 1000
+	MaxLogEntries     = 10000
+	maxLogLineLength  = 1024 * 1024 // 1MB
+)
+
+// Recognised log line formats
+const (
+	LogFormatJSON   = "json"
+	LogFormatLogfmt = "logfmt"
+	LogFormatPlain  = "plain"
+)
+
+// Well-known field names, checked in order
+var (
+	logTimeKeys    = []string{"time", "ts", "timestamp", "@timestamp", "t"}
+	logLevelKeys   = []string{"level", "lvl", "severity", "@level"}
+	logMessageKeys = []string{"msg", "message"}
+)
+
+// logLevelRanks orders normalized levels by severity
+var logLevelRanks = map[string]int{
+	"trace": 0,
+	"debug": 1,
+	"info":  2,
+	"warn":  3,
+	"error": 4,
+	"fatal": 5,
+}
+
+// LogService provides use cases for reading structured log files
+type LogService struct {
+	fileSystemRepo repositories.FileSystemRepository
+	logger         *logging.Logger
+	redactor       Redactor
+}
+
+// NewLogService creates a new LogService
+func NewLogService(fileSystemRepo repositories.FileSystemRepository, logger *logging.Logger) *LogService {
+	return &LogService{
+		fileSystemRepo: fileSystemRepo,
+		logger:         logger,
+	}
+}
+
+// WithLogger returns a copy of the service that logs through the given logger.
+// Handlers use it to attach request-scoped fields to filesystem operation logs.
+func (s *LogService) WithLogger(logger *logging.Logger) *LogService {
+	clone := *s
+	clone.logger = logger
+	return &clone
+}
+
+// WithRedactor returns a copy of the service that masks secrets in the
+// contents of matching files, see FileService.WithRedactor
+func (s *LogService) WithRedactor(redactor Redactor) *LogService {
+	clone := *s
+	clone.redactor = redactor
+	return &clone
+}
+
+// redacts reports whether filename's contents are redacted
+func (s *LogService) redacts(filename string) bool {
+	return s.redactor != nil && s.redactor.Matches(filename)
+}
+
+// QueryLogsRequest represents a request to filter a log file
+type QueryLogsRequest struct {
+	Filename string
+	Since    time.Time // zero means no lower bound
+	MinLevel string    // normalized level, "" means all entries
+	Limit    int
+}
+
+// QueryLogsResponse represents the matching entries of a log file. When more
+// entries match than Limit, the most recent ones are returned.
+type QueryLogsResponse struct {
+	Filename  string        `json:"filename"`
+	Entries   []LogEntryDTO `json:"entries"`
+	Matched   int           `json:"matched"`
+	Scanned   int           `json:"scanned"`
+	Truncated bool          `json:"truncated"`
+	QueriedAt time.Time     `json:"queriedAt"`
+	Since     time.Time     `json:"since,omitzero"`
+	MinLevel  string        `json:"level,omitempty"`
+	Formats   []string      `json:"formats"`
+}
+
+// LogEntryDTO represents a single parsed log line
+type LogEntryDTO struct {
+	Line    int                    `json:"line"`
+	Format  string                 `json:"format"`
+	Time    time.Time              `json:"time,omitzero"`
+	Level   string                 `json:"level,omitempty"`
+	Message string                 `json:"message"`
+	Fields  map[string]interface{} `json:"fields,omitempty"`
+}
+
+// ParseLogLevel normalizes a level filter such as "ERROR" or "warning"
+func ParseLogLevel(level string) (string, error) {
+	if level == "" {
+		return "", nil
+	}
+	normalized := normalizeLogLevel(level)
+	if _, ok := logLevelRanks[normalized]; !ok {
+		return "", fmt.Errorf("%w: unknown level %q", ErrInvalidLogQuery, level)
+	}
+	return normalized, nil
+}
+
+// ParseSince parses an absolute RFC 3339 timestamp or a duration such as
+// "15m", which is taken relative to now
+func ParseSince(since string, now time.Time) (time.Time, error) {
+	if since == "" {
+		return time.Time{}, nil
+	}
+	if d, err := time.ParseDuration(since); err == nil && d >= 0 {
+		return now.Add(-d), nil
+	}
+	if t, ok := parseLogTime(since); ok {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("%w: invalid since %q", ErrInvalidLogQuery, since)
+}
+
+// QueryLogs streams a log file and returns the entries matching the filters.
+// JSON lines and logfmt are parsed per line, so mixed files work; other
+// lines are returned as plain messages.
+func (s *LogService) QueryLogs(ctx context.Context, request *QueryLogsRequest) (*QueryLogsResponse, error) {
+	start := time.Now()
+	operation := "query_logs"
+
+	limit := request.Limit
+	if limit <= 0 {
+		limit = DefaultLogEntries
+	}
+	if limit > MaxLogEntries {
+		limit = MaxLogEntries
+	}
+
+	filePath, err := valueobjects.NewFilePath(request.Filename)
+	if err != nil {
+		s.logger.LogFileSystemOperation(operation, request.Filename, false, time.Since(start), 0)
+		s.logger.LogSecurityEvent("invalid_path", request.Filename, "", "", true)
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPath, err)
+	}
+
+	// Lines are parsed one at a time, so secrets spanning lines, such as
+	// private keys, cannot be masked
+	if s.redacts(request.Filename) {
+		s.logger.LogFileSystemOperation(operation, request.Filename, false, time.Since(start), 0)
+		return nil, fmt.Errorf("%w: %s", ErrRedacted, request.Filename)
+	}
+
+	file, err := s.fileSystemRepo.OpenFile(ctx, filePath)
+	if err != nil {
+		s.logger.LogFileSystemOperation(operation, request.Filename, false, time.Since(start), 0)
+		return nil, fmt.Errorf("failed to open file: %w", err)
+	}
+	defer file.Close()
+
+	reader := bufio.NewReader(file)
+	if head, _ := reader.Peek(512); len(head) > 0 && !valueobjects.DetectFileType(head).IsText() {
+		s.logger.LogFileSystemOperation(operation, request.Filename, false, time.Since(start), 0)
+		return nil, fmt.Errorf("%w: %s", ErrNotTextFile, request.Filename)
+	}
+
+	response := &QueryLogsResponse{
+		Filename:  request.Filename,
+		Since:     request.Since,
+		MinLevel:  request.MinLevel,
+		QueriedAt: time.Now(),
+	}
+
+	// Keep only the most recent matches in a ring buffer
+	ring := make([]LogEntryDTO, 0, min(limit, 256))
+	next := 0
+	formats := make(map[string]bool)
+	var size int64
+
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 64*1024), maxLogLineLength)
+	for scanner.Scan() {
+		response.Scanned++
+		line := scanner.Bytes()
+		size += int64(len(line)) + 1
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+
+		entry := parseLogLine(line)
+		entry.Line = response.Scanned
+		if !logEntryMatches(&entry, request) {
+			continue
+		}
+
+		formats[entry.Format] = true
+		response.Matched++
+		if len(ring) < limit {
+			ring = append(ring, entry)
+		} else {
+			ring[next] = entry
+			next = (next + 1) % limit
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		s.logger.LogFileSystemOperation(operation, request.Filename, false, time.Since(start), size)
+		return nil, fmt.Errorf("failed to read log file: %w", err)
+	}
+
+	response.Entries = make([]LogEntryDTO, 0, len(ring))
+	response.Entries = append(response.Entries, ring[next:]...)
+	response.Entries = append(response.Entries, ring[:next]...)
+	response.Truncated = response.Matched > len(response.Entries)
+	response.Formats = []string{}
+	for _, format := range []string{LogFormatJSON, LogFormatLogfmt, LogFormatPlain} {
+		if formats[format] {
+			response.Formats = append(response.Formats, format)
+		}
+	}
+
+	s.logger.LogFileSystemOperation(operation, request.Filename, true, time.Since(start), size)
+
+	return response, nil
+}
+
+// logEntryMatches applies the since and level filters. Entries without a
+// timestamp or level cannot satisfy the corresponding filter.
+func logEntryMatches(entry *LogEntryDTO, request *QueryLogsRequest) bool {
+	if !request.Since.IsZero() && (entry.Time.IsZero() || entry.Time.Before(request.Since)) {
+		return false
+	}
+	if request.MinLevel != "" {
+		rank, ok := logLevelRanks[entry.Level]
+		if !ok || rank < logLevelRanks[request.MinLevel] {
+			return false
+		}
+	}
+	return true
+}
+
+// parseLogLine parses a JSON or logfmt line, falling back to plain text
+func parseLogLine(line []byte) LogEntryDTO {
+	trimmed := bytes.TrimSpace(line)
+
+	if trimmed[0] == '{' {
+		decoder := json.NewDecoder(bytes.NewReader(trimmed))
+		decoder.UseNumber()
+		var fields map[string]interface{}
+		if err := decoder.Decode(&fields); err == nil {
+			return newLogEntry(LogFormatJSON, fields)
+		}
+	}
+
+	if fields, ok := parseLogfmt(string(trimmed)); ok {
+		return newLogEntry(LogFormatLogfmt, fields)
+	}
+
+	return LogEntryDTO{Format: LogFormatPlain, Message: strings.TrimRight(string(line), "\r")}
+}
+
+// newLogEntry lifts the well-known time, level and message fields out of fields
+func newLogEntry(format string, fields map[string]interface{}) LogEntryDTO {
+	entry := LogEntryDTO{Format: format}
+
+	if key, value, ok := takeLogField(fields, logTimeKeys); ok {
+		if t, ok := parseLogTimeValue(value); ok {
+			entry.Time = t
+			delete(fields, key)
+		}
+	}
+	if key, value, ok := takeLogField(fields, logLevelKeys); ok {
+		if level, ok := value.(string); ok {
+			entry.Level = normalizeLogLevel(level)
+			delete(fields, key)
+		}
+	}
+	if key, value, ok := takeLogField(fields, logMessageKeys); ok {
+		entry.Message = fmt.Sprint(value)
+		delete(fields, key)
+	}
+
+	if len(fields) > 0 {
+		entry.Fields = fields
+	}
+	return entry
+}
+
+func takeLogField(fields map[string]interface{}, keys []string) (string, interface{}, bool) {
+	for _, key := range keys {
+		if value, ok := fields[key]; ok {
+			return key, value, true
+		}
+	}
+	return "", nil, false
+}
+
+func normalizeLogLevel(level string) string {
+	level = strings.ToLower(strings.TrimSpace(level))
+	switch level {
+	case "warning":
+		return "warn"
+	case "err":
+		return "error"
+	case "panic", "critical", "crit", "emergency", "alert":
+		return "fatal"
+	}
+	return level
+}
+
+// parseLogTimeValue parses a timestamp string or Unix time in seconds or milliseconds
+func parseLogTimeValue(value interface{}) (time.Time, bool) {
+	switch v := value.(type) {
+	case string:
+		return parseLogTime(v)
+	case json.Number:
+		f, err := v.Float64()
+		if err != nil {
+			return time.Time{}, false
+		}
+		return unixLogTime(f), true
+	}
+	return time.Time{}, false
+}
+
+func parseLogTime(s string) (time.Time, bool) {
+	for _, layout := range []string{time.RFC3339Nano, "2006-01-02 15:04:05.999999999Z07:00", "2006-01-02 15:04:05.999999999", "2006-01-02T15:04:05.999999999"} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, true
+		}
+	}
+	if f, err := strconv.ParseFloat(s, 64); err == nil {
+		return unixLogTime(f), true
+	}
+	return time.Time{}, false
+}
+
+// unixLogTime treats values too large to be seconds as milliseconds
+func unixLogTime(f float64) time.Time {
+	if f > 1e12 {
+		f /= 1000
+	}
+	sec, frac := math.Modf(f)
+	return time.Unix(int64(sec), int64(frac*1e9)).UTC()
+}
+
+// parseLogfmt parses key=value pairs with optional double-quoted values.
+// ok is false unless key=value pairs outnumber bare words, which keeps
+// plain text such as "GET /?q=1 200" from being read as logfmt.
+func parseLogfmt(line string) (map[string]interface{}, bool) {
+	fields := make(map[string]interface{})
+	pairs, bare := 0, 0
+
+	for i := 0; i < len(line); {
+		for i < len(line) && line[i] == ' ' {
+			i++
+		}
+		if i >= len(line) {
+			break
+		}
+
+		keyStart := i
+		for i < len(line) && line[i] != '=' && line[i] != ' ' && line[i] != '"' {
+			i++
+		}
+		key := line[keyStart:i]
+		if key == "" {
+			return nil, false
+		}
+		if i >= len(line) || line[i] != '=' {
+			if i < len(line) && line[i] == '"' {
+				return nil, false
+			}
+			fields[key] = true // a bare key is a boolean flag
+			bare++
+			continue
+		}
+		i++ // '='
+
+		if i < len(line) && line[i] == '"' {
+			end := i + 1
+			for end < len(line) && line[end] != '"' {
+				if line[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			if end >= len(line) {
+				return nil, false
+			}
+			value, err := strconv.Unquote(line[i : end+1])
+			if err != nil {
+				return nil, false
+			}
+			fields[key] = value
+			i = end + 1
+		} else {
+			valueStart := i
+			for i < len(line) && line[i] != ' ' {
+				i++
+			}
+			fields[key] = line[valueStart:i]
+		}
+		pairs++
+	}
+
+	return fields, pairs > 0 && pairs >= bare
+}
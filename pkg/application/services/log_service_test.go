@@ -0,0 +1,99 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParseLogLine(t *testing.T) {
+	tests := []struct {
+		name    string
+		line    string
+		format  string
+		level   string
+		message string
+		time    string
+		fields  int
+	}{
+		{"json", `{"time":"2025-01-02T03:04:05Z","level":"INFO","msg":"hello","port":80}`, LogFormatJSON, "info", "hello", "2025-01-02T03:04:05Z", 1},
+		{"json unix millis", `{"ts":1735787045000,"severity":"warning","message":"slow"}`, LogFormatJSON, "warn", "slow", "2025-01-02T03:04:05Z", 0},
+		{"logfmt", `time=2025-01-02T03:04:05Z level=err msg="disk full" path=/var retry`, LogFormatLogfmt, "error", "disk full", "2025-01-02T03:04:05Z", 2},
+		{"plain", "panic: something went wrong", LogFormatPlain, "", "panic: something went wrong", "", 0},
+		{"url is not logfmt", "GET /search?q=1 200 OK", LogFormatPlain, "", "GET /search?q=1 200 OK", "", 0},
+		{"broken json", `{"level":"info"`, LogFormatPlain, "", `{"level":"info"`, "", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entry := parseLogLine([]byte(tt.line))
+			if entry.Format != tt.format {
+				t.Errorf("Expected format %q, got %q", tt.format, entry.Format)
+			}
+			if entry.Level != tt.level {
+				t.Errorf("Expected level %q, got %q", tt.level, entry.Level)
+			}
+			if entry.Message != tt.message {
+				t.Errorf("Expected message %q, got %q", tt.message, entry.Message)
+			}
+			if tt.time != "" && entry.Time.Format(time.RFC3339) != tt.time {
+				t.Errorf("Expected time %s, got %s", tt.time, entry.Time.Format(time.RFC3339))
+			}
+			if len(entry.Fields) != tt.fields {
+				t.Errorf("Expected %d fields, got %v", tt.fields, entry.Fields)
+			}
+		})
+	}
+}
+
+func TestLogEntryMatches(t *testing.T) {
+	since := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	entry := LogEntryDTO{Time: since.Add(time.Minute), Level: "warn"}
+
+	tests := []struct {
+		name     string
+		entry    LogEntryDTO
+		request  QueryLogsRequest
+		expected bool
+	}{
+		{"no filters", LogEntryDTO{}, QueryLogsRequest{}, true},
+		{"after since", entry, QueryLogsRequest{Since: since}, true},
+		{"before since", entry, QueryLogsRequest{Since: since.Add(time.Hour)}, false},
+		{"no time with since", LogEntryDTO{Level: "error"}, QueryLogsRequest{Since: since}, false},
+		{"level above minimum", entry, QueryLogsRequest{MinLevel: "info"}, true},
+		{"level below minimum", entry, QueryLogsRequest{MinLevel: "error"}, false},
+		{"unknown level", LogEntryDTO{Level: "notice"}, QueryLogsRequest{MinLevel: "debug"}, false},
+	}
+
+	for _, tt := range tests {
+		if result := logEntryMatches(&tt.entry, &tt.request); result != tt.expected {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.expected, result)
+		}
+	}
+}
+
+func TestParseSince(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	if got, err := ParseSince("15m", now); err != nil || !got.Equal(now.Add(-15*time.Minute)) {
+		t.Errorf("Expected 15 minutes ago, got %v, %v", got, err)
+	}
+	if got, err := ParseSince("2025-01-01T10:00:00Z", now); err != nil || got.Hour() != 10 {
+		t.Errorf("Expected absolute time, got %v, %v", got, err)
+	}
+	if got, err := ParseSince("", now); err != nil || !got.IsZero() {
+		t.Errorf("Expected zero time, got %v, %v", got, err)
+	}
+	if _, err := ParseSince("yesterday", now); !errors.Is(err, ErrInvalidLogQuery) {
+		t.Errorf("Expected ErrInvalidLogQuery, got %v", err)
+	}
+}
+
+func TestParseLogLevel(t *testing.T) {
+	if level, err := ParseLogLevel("WARNING"); err != nil || level != "warn" {
+		t.Errorf("Expected warn, got %q, %v", level, err)
+	}
+	if _, err := ParseLogLevel("loud"); !errors.Is(err, ErrInvalidLogQuery) {
+		t.Errorf("Expected ErrInvalidLogQuery, got %v", err)
+	}
+}
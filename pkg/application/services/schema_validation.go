@@ -0,0 +1,72 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/sh05/cat-server/pkg/infrastructure/jsonschema"
+)
+
+// ErrInvalidSchema is returned when a JSON Schema cannot be compiled
+var ErrInvalidSchema = errors.New("invalid schema")
+
+// MaxSchemaSize is the largest schema accepted in a validation request
+const MaxSchemaSize = 1024 * 1024 // 1MB
+
+// ValidateSchemaRequest represents a request to validate a file against a JSON Schema
+type ValidateSchemaRequest struct {
+	Filename string
+	Schema   []byte
+}
+
+// ValidateSchemaResponse represents the outcome of a schema validation
+type ValidateSchemaResponse struct {
+	Filename    string                       `json:"filename"`
+	Valid       bool                         `json:"valid"`
+	Errors      []jsonschema.ValidationError `json:"errors"`
+	Truncated   bool                         `json:"truncated,omitempty"`
+	ValidatedAt time.Time                    `json:"validatedAt"`
+}
+
+// ValidateSchema validates a JSON, YAML or TOML file against a JSON Schema.
+// The file is converted with ConvertToJSON first, so the same formats and
+// size limits apply.
+func (s *FileService) ValidateSchema(ctx context.Context, request *ValidateSchemaRequest) (*ValidateSchemaResponse, error) {
+	start := time.Now()
+	operation := "validate_schema"
+
+	schema, err := jsonschema.Compile(request.Schema)
+	if err != nil {
+		s.logger.LogFileSystemOperation(operation, request.Filename, false, time.Since(start), 0)
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSchema, err)
+	}
+
+	converted, err := s.ConvertToJSON(ctx, request.Filename)
+	if err != nil {
+		s.logger.LogFileSystemOperation(operation, request.Filename, false, time.Since(start), 0)
+		return nil, err
+	}
+
+	document, err := jsonschema.Decode(converted)
+	if err != nil {
+		s.logger.LogFileSystemOperation(operation, request.Filename, false, time.Since(start), int64(len(converted)))
+		return nil, fmt.Errorf("%w: %v", ErrConversionFailed, err)
+	}
+
+	validationErrors := schema.Validate(document)
+	if validationErrors == nil {
+		validationErrors = []jsonschema.ValidationError{}
+	}
+
+	s.logger.LogFileSystemOperation(operation, request.Filename, true, time.Since(start), int64(len(converted)))
+
+	return &ValidateSchemaResponse{
+		Filename:    request.Filename,
+		Valid:       len(validationErrors) == 0,
+		Errors:      validationErrors,
+		Truncated:   len(validationErrors) >= jsonschema.MaxErrors,
+		ValidatedAt: time.Now(),
+	}, nil
+}
@@ -0,0 +1,423 @@
+package services
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/sh05/cat-server/pkg/domain/entities"
+	"github.com/sh05/cat-server/pkg/domain/repositories"
+	"github.com/sh05/cat-server/pkg/domain/valueobjects"
+	"github.com/sh05/cat-server/pkg/infrastructure/logging"
+	"github.com/sh05/cat-server/pkg/infrastructure/workpool"
+)
+
+// ErrInvalidPattern is returned when a search pattern cannot be compiled
+var ErrInvalidPattern = errors.New("invalid search pattern")
+
+// ErrNotTextFile is returned when a text-only operation targets a binary file
+var ErrNotTextFile = errors.New("file is not a text file")
+
+// Search limits
+const (
+	DefaultMaxMatches      = 100
+	MaxMatchesLimit        = 1000
+	MaxContextLines        = 10
+	DefaultSearchMaxSize   = 1024 * 1024 // 1MB
+	maxSearchSnippetLength = 200
+)
+
+// SearchService provides use cases for searching file contents
+type SearchService struct {
+	fileSystemRepo repositories.FileSystemRepository
+	logger         *logging.Logger
+	redactor       Redactor
+	workers        *workpool.Pool // scans files; nil scans them one by one
+}
+
+// NewSearchService creates a new SearchService
+func NewSearchService(fileSystemRepo repositories.FileSystemRepository, logger *logging.Logger) *SearchService {
+	return &SearchService{
+		fileSystemRepo: fileSystemRepo,
+		logger:         logger,
+	}
+}
+
+// SetWorkerPool scans the files of searches on workers, shared with the
+// other services
+func (s *SearchService) SetWorkerPool(workers *workpool.Pool) {
+	s.workers = workers
+}
+
+// WithLogger returns a copy of the service that logs through the given logger.
+// Handlers use it to attach request-scoped fields to filesystem operation logs.
+func (s *SearchService) WithLogger(logger *logging.Logger) *SearchService {
+	clone := *s
+	clone.logger = logger
+	return &clone
+}
+
+// WithRedactor returns a copy of the service that masks secrets in the
+// contents of matching files, see FileService.WithRedactor
+func (s *SearchService) WithRedactor(redactor Redactor) *SearchService {
+	clone := *s
+	clone.redactor = redactor
+	return &clone
+}
+
+// redacts reports whether filename's contents are redacted
+func (s *SearchService) redacts(filename string) bool {
+	return s.redactor != nil && s.redactor.Matches(filename)
+}
+
+// GrepRequest represents a request to search a single file
+type GrepRequest struct {
+	Filename     string
+	Pattern      string
+	ContextLines int
+	IgnoreCase   bool
+	MaxMatches   int
+}
+
+// GrepResponse represents the matches found in a file
+type GrepResponse struct {
+	Filename   string         `json:"filename"`
+	Pattern    string         `json:"pattern"`
+	Matches    []GrepMatchDTO `json:"matches"`
+	MatchCount int            `json:"matchCount"`
+	Truncated  bool           `json:"truncated"`
+	SearchedAt time.Time      `json:"searchedAt"`
+}
+
+// GrepMatchDTO represents a matching line with its surrounding context
+type GrepMatchDTO struct {
+	LineNumber int              `json:"lineNumber"`
+	Line       string           `json:"line"`
+	Before     []ContextLineDTO `json:"before,omitempty"`
+	After      []ContextLineDTO `json:"after,omitempty"`
+}
+
+// ContextLineDTO represents a non-matching line shown for context
+type ContextLineDTO struct {
+	LineNumber int    `json:"lineNumber"`
+	Line       string `json:"line"`
+}
+
+// Grep scans a file line by line and returns the lines matching the pattern
+func (s *SearchService) Grep(ctx context.Context, request *GrepRequest) (*GrepResponse, error) {
+	start := time.Now()
+
+	re, err := compileSearchPattern(request.Pattern, request.IgnoreCase)
+	if err != nil {
+		s.logger.LogFileSystemOperation("grep", request.Filename, false, time.Since(start), 0)
+		return nil, err
+	}
+
+	filePath, err := valueobjects.NewFilePath(request.Filename)
+	if err != nil {
+		s.logger.LogFileSystemOperation("grep", request.Filename, false, time.Since(start), 0)
+		s.logger.LogSecurityEvent("invalid_path", request.Filename, "", "", true)
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPath, err)
+	}
+
+	fileContent, err := s.fileSystemRepo.ReadFile(ctx, filePath)
+	if err != nil {
+		s.logger.LogFileSystemOperation("grep", request.Filename, false, time.Since(start), 0)
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+
+	if !fileContent.IsTextContent() {
+		s.logger.LogFileSystemOperation("grep", request.Filename, false, time.Since(start), fileContent.Size())
+		return nil, fmt.Errorf("%w: %s", ErrNotTextFile, request.Filename)
+	}
+
+	// Secrets are masked before matching, so patterns cannot probe them
+	content := fileContent.Content()
+	if s.redacts(request.Filename) {
+		content = []byte(s.redactor.Redact(fileContent.ContentAsString()))
+	}
+
+	matches, truncated := grepLines(content, re, clampContextLines(request.ContextLines), clampMaxMatches(request.MaxMatches))
+
+	response := &GrepResponse{
+		Filename:   request.Filename,
+		Pattern:    request.Pattern,
+		Matches:    matches,
+		MatchCount: len(matches),
+		Truncated:  truncated,
+		SearchedAt: time.Now(),
+	}
+
+	s.logger.LogFileSystemOperation("grep", request.Filename, true, time.Since(start), fileContent.Size())
+
+	return response, nil
+}
+
+// SearchFilesRequest represents a request to search across files
+type SearchFilesRequest struct {
+	Query         string
+	Glob          string
+	IgnoreCase    bool
+	IncludeHidden bool
+	MaxMatches    int
+	MaxFileSize   int64
+}
+
+// SearchFilesResponse represents the hits found across files
+type SearchFilesResponse struct {
+	Query        string         `json:"query"`
+	Glob         string         `json:"glob,omitempty"`
+	Hits         []SearchHitDTO `json:"hits"`
+	HitCount     int            `json:"hitCount"`
+	FilesScanned int            `json:"filesScanned"`
+	FilesSkipped int            `json:"filesSkipped"`
+	Truncated    bool           `json:"truncated"`
+	SearchedAt   time.Time      `json:"searchedAt"`
+}
+
+// SearchHitDTO represents a single matching line in a file
+type SearchHitDTO struct {
+	File       string `json:"file"`
+	LineNumber int    `json:"lineNumber"`
+	Snippet    string `json:"snippet"`
+}
+
+// SearchFiles searches all text files below the base directory for the query.
+// Files larger than MaxFileSize, binary files and files not matching Glob are
+// skipped. Files are scanned by a bounded pool of workers.
+func (s *SearchService) SearchFiles(ctx context.Context, request *SearchFilesRequest) (*SearchFilesResponse, error) {
+	start := time.Now()
+
+	re, err := compileSearchPattern(request.Query, request.IgnoreCase)
+	if err != nil {
+		s.logger.LogFileSystemOperation("search_files", ".", false, time.Since(start), 0)
+		return nil, err
+	}
+
+	if request.Glob != "" {
+		if _, err := filepath.Match(request.Glob, ""); err != nil {
+			s.logger.LogFileSystemOperation("search_files", ".", false, time.Since(start), 0)
+			return nil, fmt.Errorf("%w: bad glob: %v", ErrInvalidPattern, err)
+		}
+	}
+
+	maxSize := request.MaxFileSize
+	if maxSize <= 0 {
+		maxSize = DefaultSearchMaxSize
+	}
+	maxMatches := clampMaxMatches(request.MaxMatches)
+
+	root, _ := valueobjects.NewFilePath(".")
+
+	// Collect candidate files
+	var candidates []string
+	skipped := 0
+	err = walkFiles(ctx, s.fileSystemRepo, root, request.IncludeHidden, func(rel string, entry entities.FileSystemEntry) error {
+		if request.Glob != "" {
+			if ok, _ := filepath.Match(request.Glob, entry.Name()); !ok {
+				return nil
+			}
+		}
+		if entry.Size() > maxSize {
+			skipped++
+			return nil
+		}
+		candidates = append(candidates, rel)
+		return nil
+	})
+	if err != nil {
+		s.logger.LogFileSystemOperation("search_files", ".", false, time.Since(start), 0)
+		return nil, fmt.Errorf("failed to walk directory: %w", err)
+	}
+
+	// Scan candidates on the shared worker pool
+	var mu sync.Mutex
+	hits := []SearchHitDTO{}
+	scanned := 0
+	group := s.workers.Group()
+	for _, rel := range candidates {
+		if ctx.Err() != nil {
+			break
+		}
+		group.Go(func() {
+			fileHits, ok := s.searchFile(ctx, rel, re, maxMatches)
+			mu.Lock()
+			defer mu.Unlock()
+			if !ok {
+				skipped++
+				return
+			}
+			scanned++
+			hits = append(hits, fileHits...)
+		})
+	}
+	group.Wait()
+	if err := ctx.Err(); err != nil {
+		s.logger.LogFileSystemOperation("search_files", ".", false, time.Since(start), 0)
+		return nil, err
+	}
+
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].File != hits[j].File {
+			return hits[i].File < hits[j].File
+		}
+		return hits[i].LineNumber < hits[j].LineNumber
+	})
+
+	truncated := false
+	if len(hits) > maxMatches {
+		hits = hits[:maxMatches]
+		truncated = true
+	}
+
+	response := &SearchFilesResponse{
+		Query:        request.Query,
+		Glob:         request.Glob,
+		Hits:         hits,
+		HitCount:     len(hits),
+		FilesScanned: scanned,
+		FilesSkipped: skipped,
+		Truncated:    truncated,
+		SearchedAt:   time.Now(),
+	}
+
+	s.logger.LogFileSystemOperation("search_files", ".", true, time.Since(start), 0)
+
+	return response, nil
+}
+
+// searchFile returns up to limit+1 hits in a single file, or false if the
+// file could not be read or is not text
+func (s *SearchService) searchFile(ctx context.Context, rel string, re *regexp.Regexp, limit int) ([]SearchHitDTO, bool) {
+	filePath, err := valueobjects.NewFilePath(rel)
+	if err != nil {
+		return nil, false
+	}
+
+	fileContent, err := s.fileSystemRepo.ReadFile(ctx, filePath)
+	if err != nil || !fileContent.IsTextContent() {
+		return nil, false
+	}
+
+	content := fileContent.Content()
+	if s.redacts(rel) {
+		content = []byte(s.redactor.Redact(fileContent.ContentAsString()))
+	}
+
+	matches, _ := grepLines(content, re, 0, limit+1)
+	hits := make([]SearchHitDTO, len(matches))
+	for i, match := range matches {
+		hits[i] = SearchHitDTO{
+			File:       filepath.ToSlash(rel),
+			LineNumber: match.LineNumber,
+			Snippet:    snippet(match.Line),
+		}
+	}
+	return hits, true
+}
+
+// Helper functions
+
+func snippet(line string) string {
+	line = strings.TrimSpace(line)
+	if len(line) <= maxSearchSnippetLength {
+		return line
+	}
+	cut := maxSearchSnippetLength
+	for cut > 0 && !utf8.RuneStart(line[cut]) {
+		cut--
+	}
+	return line[:cut] + "..."
+}
+
+func compileSearchPattern(pattern string, ignoreCase bool) (*regexp.Regexp, error) {
+	if pattern == "" {
+		return nil, fmt.Errorf("%w: pattern cannot be empty", ErrInvalidPattern)
+	}
+	if ignoreCase {
+		pattern = "(?i)" + pattern
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPattern, err)
+	}
+	return re, nil
+}
+
+func clampContextLines(n int) int {
+	if n < 0 {
+		return 0
+	}
+	if n > MaxContextLines {
+		return MaxContextLines
+	}
+	return n
+}
+
+func clampMaxMatches(n int) int {
+	if n <= 0 {
+		return DefaultMaxMatches
+	}
+	if n > MaxMatchesLimit {
+		return MaxMatchesLimit
+	}
+	return n
+}
+
+// grepLines scans content line by line, keeping a ring of the previous
+// contextLines lines and filling trailing context as later lines arrive
+func grepLines(content []byte, re *regexp.Regexp, contextLines, maxMatches int) ([]GrepMatchDTO, bool) {
+	matches := []GrepMatchDTO{}
+	var before []ContextLineDTO
+	pendingAfter := 0
+	truncated := false
+
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	scanner.Buffer(make([]byte, 0, 64*1024), len(content)+1)
+
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := scanner.Text()
+
+		if re.MatchString(line) {
+			if len(matches) >= maxMatches {
+				truncated = true
+				break
+			}
+			matches = append(matches, GrepMatchDTO{
+				LineNumber: lineNumber,
+				Line:       line,
+				Before:     before,
+			})
+			before = nil
+			pendingAfter = contextLines
+			continue
+		}
+
+		if pendingAfter > 0 {
+			last := &matches[len(matches)-1]
+			last.After = append(last.After, ContextLineDTO{LineNumber: lineNumber, Line: line})
+			pendingAfter--
+			continue
+		}
+
+		if contextLines > 0 {
+			before = append(before, ContextLineDTO{LineNumber: lineNumber, Line: line})
+			if len(before) > contextLines {
+				before = before[1:]
+			}
+		}
+	}
+
+	return matches, truncated
+}
@@ -0,0 +1,87 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	"github.com/sh05/cat-server/pkg/infrastructure/filesystem"
+	"github.com/sh05/cat-server/pkg/infrastructure/logging"
+	"github.com/sh05/cat-server/pkg/infrastructure/workpool"
+)
+
+func TestGrepLines(t *testing.T) {
+	content := []byte("alpha\nbeta\ngamma\ndelta\nbeta2\n")
+	re := regexp.MustCompile("beta")
+
+	t.Run("matches with context", func(t *testing.T) {
+		matches, truncated := grepLines(content, re, 1, 10)
+		if truncated {
+			t.Error("Expected result not to be truncated")
+		}
+		if len(matches) != 2 {
+			t.Fatalf("Expected 2 matches, got %d", len(matches))
+		}
+		if matches[0].LineNumber != 2 || matches[1].LineNumber != 5 {
+			t.Errorf("Unexpected line numbers: %d, %d", matches[0].LineNumber, matches[1].LineNumber)
+		}
+		if len(matches[0].Before) != 1 || matches[0].Before[0].Line != "alpha" {
+			t.Errorf("Unexpected before context: %+v", matches[0].Before)
+		}
+		if len(matches[0].After) != 1 || matches[0].After[0].Line != "gamma" {
+			t.Errorf("Unexpected after context: %+v", matches[0].After)
+		}
+		if len(matches[1].Before) != 1 || matches[1].Before[0].Line != "delta" {
+			t.Errorf("Unexpected before context: %+v", matches[1].Before)
+		}
+	})
+
+	t.Run("caps matches", func(t *testing.T) {
+		matches, truncated := grepLines(content, re, 0, 1)
+		if len(matches) != 1 || !truncated {
+			t.Errorf("Expected 1 truncated match, got %d (truncated=%v)", len(matches), truncated)
+		}
+	})
+}
+
+func TestCompileSearchPattern(t *testing.T) {
+	if _, err := compileSearchPattern("", false); !errors.Is(err, ErrInvalidPattern) {
+		t.Errorf("Expected ErrInvalidPattern for empty pattern, got %v", err)
+	}
+	if _, err := compileSearchPattern("(", false); !errors.Is(err, ErrInvalidPattern) {
+		t.Errorf("Expected ErrInvalidPattern for bad regex, got %v", err)
+	}
+	re, err := compileSearchPattern("hello", true)
+	if err != nil || !re.MatchString("HELLO") {
+		t.Errorf("Expected case-insensitive match, got err=%v", err)
+	}
+}
+
+func TestSearchFilesWorkerPool(t *testing.T) {
+	dir := t.TempDir()
+	for i, content := range []string{"error: disk full\nok\n", "ok\n", "error: timeout\n", "\x00\x01binary error"} {
+		if err := os.WriteFile(filepath.Join(dir, fmt.Sprintf("%d.log", i)), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	workers := workpool.New(2)
+	defer workers.Close()
+	service := NewSearchService(filesystem.NewFileSystemRepository(dir, 1024), logging.NewLoggerWithWriter(logging.LevelError, "json", io.Discard))
+	service.SetWorkerPool(workers)
+
+	response, err := service.SearchFiles(context.Background(), &SearchFilesRequest{Query: "error"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if response.HitCount != 2 || response.Hits[0].File != "0.log" || response.Hits[1].File != "2.log" {
+		t.Errorf("Expected hits in 0.log and 2.log, got %+v", response.Hits)
+	}
+	if response.FilesScanned != 3 || response.FilesSkipped != 1 {
+		t.Errorf("Expected 3 files scanned and the binary one skipped, got %d and %d", response.FilesScanned, response.FilesSkipped)
+	}
+}
@@ -0,0 +1,217 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrDiffTooComplex is returned when two files differ too much to diff
+// within the edit distance limit
+var ErrDiffTooComplex = errors.New("files differ too much to diff")
+
+// maxDiffEditDistance bounds the work and memory used by the diff algorithm
+const maxDiffEditDistance = 2000
+
+// diffOp identifies the kind of a diff edit
+type diffOp int
+
+const (
+	diffEqual diffOp = iota
+	diffDelete
+	diffInsert
+)
+
+// diffEdit is a single line-level edit
+type diffEdit struct {
+	op   diffOp
+	line string
+	aIdx int // 0-based index into a (valid for equal and delete)
+	bIdx int // 0-based index into b (valid for equal and insert)
+}
+
+// splitLinesKeepEnds splits text into lines, keeping line terminators so a
+// missing final newline is detected as a difference
+func splitLinesKeepEnds(text string) []string {
+	lines := strings.SplitAfter(text, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// myersDiff computes the shortest edit script between a and b using the
+// Myers O(ND) algorithm
+func myersDiff(a, b []string) ([]diffEdit, error) {
+	n, m := len(a), len(b)
+	var trace [][]int
+
+	found := false
+	for d := 0; d <= n+m; d++ {
+		if d > maxDiffEditDistance {
+			return nil, ErrDiffTooComplex
+		}
+
+		v := make([]int, 2*d+1)
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if d == 0 {
+				x = 0
+			} else {
+				prev := trace[d-1]
+				if k == -d || (k != d && prev[k-1+d-1] < prev[k+1+d-1]) {
+					x = prev[k+1+d-1]
+				} else {
+					x = prev[k-1+d-1] + 1
+				}
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[k+d] = x
+			if x >= n && y >= m {
+				found = true
+			}
+		}
+		trace = append(trace, v)
+		if found {
+			break
+		}
+	}
+
+	// Backtrack through the trace to recover the edits
+	var edits []diffEdit
+	x, y := n, m
+	for d := len(trace) - 1; d > 0; d-- {
+		prev := trace[d-1]
+		k := x - y
+
+		var prevK int
+		if k == -d || (k != d && prev[k-1+d-1] < prev[k+1+d-1]) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := prev[prevK+d-1]
+		prevY := prevX - prevK
+
+		for x > prevX && y > prevY {
+			x--
+			y--
+			edits = append(edits, diffEdit{op: diffEqual, line: a[x], aIdx: x, bIdx: y})
+		}
+
+		if x == prevX {
+			y--
+			edits = append(edits, diffEdit{op: diffInsert, line: b[y], aIdx: x, bIdx: y})
+		} else {
+			x--
+			edits = append(edits, diffEdit{op: diffDelete, line: a[x], aIdx: x, bIdx: y})
+		}
+	}
+	for x > 0 && y > 0 {
+		x--
+		y--
+		edits = append(edits, diffEdit{op: diffEqual, line: a[x], aIdx: x, bIdx: y})
+	}
+
+	// Edits were collected in reverse
+	for i, j := 0, len(edits)-1; i < j; i, j = i+1, j-1 {
+		edits[i], edits[j] = edits[j], edits[i]
+	}
+	return edits, nil
+}
+
+// unifiedDiff renders edits in unified diff format with the given number of
+// context lines. It returns an empty string when there are no changes.
+func unifiedDiff(nameA, nameB string, edits []diffEdit, context int) string {
+	var sb strings.Builder
+
+	i := 0
+	for i < len(edits) {
+		// Find the next change
+		for i < len(edits) && edits[i].op == diffEqual {
+			i++
+		}
+		if i >= len(edits) {
+			break
+		}
+
+		start := i - context
+		if start < 0 {
+			start = 0
+		}
+
+		// Extend the hunk while changes are within 2*context lines of each other
+		end := i
+		for end < len(edits) {
+			if edits[end].op != diffEqual {
+				end++
+				continue
+			}
+			run := end
+			for run < len(edits) && edits[run].op == diffEqual {
+				run++
+			}
+			if run >= len(edits) || run-end > 2*context {
+				end += min(context, run-end)
+				break
+			}
+			end = run
+		}
+
+		if sb.Len() == 0 {
+			fmt.Fprintf(&sb, "--- %s\n+++ %s\n", nameA, nameB)
+		}
+		writeHunk(&sb, edits[start:end])
+		i = end
+	}
+
+	return sb.String()
+}
+
+func writeHunk(sb *strings.Builder, hunk []diffEdit) {
+	aStart, bStart := hunk[0].aIdx, hunk[0].bIdx
+	aCount, bCount := 0, 0
+	for _, e := range hunk {
+		switch e.op {
+		case diffEqual:
+			aCount++
+			bCount++
+		case diffDelete:
+			aCount++
+		case diffInsert:
+			bCount++
+		}
+	}
+
+	fmt.Fprintf(sb, "@@ -%s +%s @@\n", hunkRange(aStart, aCount), hunkRange(bStart, bCount))
+	for _, e := range hunk {
+		prefix := " "
+		switch e.op {
+		case diffDelete:
+			prefix = "-"
+		case diffInsert:
+			prefix = "+"
+		}
+		sb.WriteString(prefix)
+		sb.WriteString(e.line)
+		if !strings.HasSuffix(e.line, "\n") {
+			sb.WriteString("\n\\ No newline at end of file\n")
+		}
+	}
+}
+
+// hunkRange formats a hunk range the way GNU diff does
+func hunkRange(start, count int) string {
+	switch count {
+	case 0:
+		return fmt.Sprintf("%d,0", start)
+	case 1:
+		return fmt.Sprintf("%d", start+1)
+	default:
+		return fmt.Sprintf("%d,%d", start+1, count)
+	}
+}
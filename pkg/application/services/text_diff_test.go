@@ -0,0 +1,49 @@
+package services
+
+import "testing"
+
+func TestUnifiedDiff(t *testing.T) {
+	tests := []struct {
+		name string
+		a    string
+		b    string
+		want string
+	}{
+		{
+			name: "identical",
+			a:    "one\ntwo\n",
+			b:    "one\ntwo\n",
+			want: "",
+		},
+		{
+			name: "changed and appended lines",
+			a:    "port=1\nhost=a\nx=1\n",
+			b:    "port=2\nhost=a\nx=1\ny=2\n",
+			want: "--- a\n+++ b\n@@ -1,3 +1,4 @@\n-port=1\n+port=2\n host=a\n x=1\n+y=2\n",
+		},
+		{
+			name: "missing final newline",
+			a:    "one\n",
+			b:    "one",
+			want: "--- a\n+++ b\n@@ -1 +1 @@\n-one\n+one\n\\ No newline at end of file\n",
+		},
+		{
+			name: "from empty file",
+			a:    "",
+			b:    "new\n",
+			want: "--- a\n+++ b\n@@ -0,0 +1 @@\n+new\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			edits, err := myersDiff(splitLinesKeepEnds(tt.a), splitLinesKeepEnds(tt.b))
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if got := unifiedDiff("a", "b", edits, 3); got != tt.want {
+				t.Errorf("Expected:\n%s\nGot:\n%s", tt.want, got)
+			}
+		})
+	}
+}
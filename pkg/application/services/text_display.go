@@ -0,0 +1,134 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ErrInvalidDisplayOption is returned for out-of-range display options
+var ErrInvalidDisplayOption = errors.New("invalid display option")
+
+// Display option limits
+const (
+	MaxTabWidth     = 16
+	MaxWrapWidth    = 10000
+	defaultTabWidth = 8
+)
+
+// DisplayOptions control how text content is laid out for readers without a
+// terminal, e.g. dashboards rendering the content verbatim
+type DisplayOptions struct {
+	ExpandTabs int // tab stop width, like `expand -t`; 0 leaves tabs untouched
+	Wrap       int // maximum line width in characters, like `fold -s`; 0 disables wrapping
+}
+
+// ParseDisplayOptions parses the expand_tabs and wrap query values. Empty
+// values disable the corresponding option.
+func ParseDisplayOptions(expandTabs, wrap string) (DisplayOptions, error) {
+	var opts DisplayOptions
+	var err error
+
+	if expandTabs != "" {
+		opts.ExpandTabs, err = strconv.Atoi(expandTabs)
+		if err != nil || opts.ExpandTabs < 1 || opts.ExpandTabs > MaxTabWidth {
+			return DisplayOptions{}, fmt.Errorf("%w: expand_tabs must be between 1 and %d", ErrInvalidDisplayOption, MaxTabWidth)
+		}
+	}
+
+	if wrap != "" {
+		opts.Wrap, err = strconv.Atoi(wrap)
+		if err != nil || opts.Wrap < 1 || opts.Wrap > MaxWrapWidth {
+			return DisplayOptions{}, fmt.Errorf("%w: wrap must be between 1 and %d", ErrInvalidDisplayOption, MaxWrapWidth)
+		}
+	}
+
+	return opts, nil
+}
+
+// Enabled reports whether any display option is set
+func (o DisplayOptions) Enabled() bool {
+	return o.ExpandTabs > 0 || o.Wrap > 0
+}
+
+// apply lays out text according to the options. Wrapping needs known column
+// positions, so tabs are expanded to 8-column stops when only wrap is set.
+// Line endings, including a missing final newline, are preserved.
+func (o DisplayOptions) apply(text string) string {
+	if !o.Enabled() || text == "" {
+		return text
+	}
+
+	tabWidth := o.ExpandTabs
+	if tabWidth == 0 {
+		tabWidth = defaultTabWidth
+	}
+
+	var b strings.Builder
+	b.Grow(len(text))
+	for _, line := range strings.SplitAfter(text, "\n") {
+		body, eol := splitLineEnding(line)
+		body = expandTabs(body, tabWidth)
+		if o.Wrap > 0 {
+			wrapLine(&b, body, o.Wrap)
+		} else {
+			b.WriteString(body)
+		}
+		b.WriteString(eol)
+	}
+
+	return b.String()
+}
+
+// splitLineEnding separates a line from its "\n" or "\r\n" terminator
+func splitLineEnding(line string) (string, string) {
+	if strings.HasSuffix(line, "\r\n") {
+		return line[:len(line)-2], "\r\n"
+	}
+	if strings.HasSuffix(line, "\n") {
+		return line[:len(line)-1], "\n"
+	}
+	return line, ""
+}
+
+// expandTabs replaces tabs with spaces up to the next tab stop
+func expandTabs(line string, tabWidth int) string {
+	if !strings.Contains(line, "\t") {
+		return line
+	}
+
+	var b strings.Builder
+	col := 0
+	for _, r := range line {
+		if r == '\t' {
+			spaces := tabWidth - col%tabWidth
+			b.WriteString(strings.Repeat(" ", spaces))
+			col += spaces
+			continue
+		}
+		b.WriteRune(r)
+		col++
+	}
+	return b.String()
+}
+
+// wrapLine writes line broken into segments of at most width characters,
+// breaking after the last space that fits and mid-word only when a word is
+// longer than width
+func wrapLine(b *strings.Builder, line string, width int) {
+	runes := []rune(line)
+	for len(runes) > width {
+		cut := width
+		for i := width - 1; i > 0; i-- {
+			if runes[i] == ' ' {
+				cut = i + 1
+				break
+			}
+		}
+		b.WriteString(string(runes[:cut]))
+		b.WriteByte('\n')
+		runes = runes[cut:]
+	}
+	b.WriteString(string(runes))
+}
@@ -0,0 +1,55 @@
+package services
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseDisplayOptions(t *testing.T) {
+	opts, err := ParseDisplayOptions("4", "80")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if opts.ExpandTabs != 4 || opts.Wrap != 80 {
+		t.Errorf("Expected expand_tabs=4 wrap=80, got %+v", opts)
+	}
+
+	opts, err = ParseDisplayOptions("", "")
+	if err != nil || opts.Enabled() {
+		t.Errorf("Expected disabled options, got %+v (%v)", opts, err)
+	}
+
+	invalid := [][2]string{{"0", ""}, {"17", ""}, {"x", ""}, {"", "0"}, {"", "-5"}, {"", "10001"}}
+	for _, values := range invalid {
+		if _, err := ParseDisplayOptions(values[0], values[1]); !errors.Is(err, ErrInvalidDisplayOption) {
+			t.Errorf("Expected ErrInvalidDisplayOption for %q, got %v", values, err)
+		}
+	}
+}
+
+func TestDisplayOptionsApply(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     DisplayOptions
+		input    string
+		expected string
+	}{
+		{"disabled", DisplayOptions{}, "a\tb\n", "a\tb\n"},
+		{"expand to tab stops", DisplayOptions{ExpandTabs: 4}, "a\tbc\td\n\te\n", "a   bc  d\n    e\n"},
+		{"expand counts runes", DisplayOptions{ExpandTabs: 4}, "é\tx", "é   x"},
+		{"wrap at last space", DisplayOptions{Wrap: 10}, "the quick brown fox\n", "the quick \nbrown fox\n"},
+		{"wrap long word", DisplayOptions{Wrap: 4}, "abcdefghij", "abcd\nefgh\nij"},
+		{"wrap keeps short lines", DisplayOptions{Wrap: 80}, "short\n\nlines\n", "short\n\nlines\n"},
+		{"wrap expands tabs by default", DisplayOptions{Wrap: 10}, "x\tabc", "x       \nabc"},
+		{"crlf preserved", DisplayOptions{Wrap: 3}, "abcd\r\nef\r\n", "abc\nd\r\nef\r\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := tt.opts.apply(tt.input)
+			if result != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, result)
+			}
+		})
+	}
+}
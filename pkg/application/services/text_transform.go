@@ -0,0 +1,94 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ErrUnsupportedTransform is returned for unknown text transforms
+var ErrUnsupportedTransform = errors.New("unsupported transform")
+
+// Supported text transforms, named after the coreutils they emulate
+const (
+	TransformSort = "sort"
+	TransformUniq = "uniq"
+	TransformNl   = "nl"
+)
+
+// DefaultTransformMaxSize is the largest file transformed server-side
+const DefaultTransformMaxSize = 1 * 1024 * 1024 // 1MB
+
+// nlWidth matches the default line number width of `nl`
+const nlWidth = 6
+
+// ParseTransforms splits a comma-separated transform pipeline such as
+// "sort,uniq" and validates each step
+func ParseTransforms(spec string) ([]string, error) {
+	if spec == "" {
+		return nil, nil
+	}
+
+	steps := strings.Split(spec, ",")
+	for i, step := range steps {
+		step = strings.TrimSpace(strings.ToLower(step))
+		switch step {
+		case TransformSort, TransformUniq, TransformNl:
+			steps[i] = step
+		default:
+			return nil, fmt.Errorf("%w: %q", ErrUnsupportedTransform, step)
+		}
+	}
+
+	return steps, nil
+}
+
+// applyTransforms runs text through each transform in order. Output lines are
+// always newline terminated, like the coreutils equivalents.
+func applyTransforms(text string, transforms []string) string {
+	if text == "" {
+		return ""
+	}
+
+	lines := strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+	for _, transform := range transforms {
+		switch transform {
+		case TransformSort:
+			sort.Strings(lines)
+		case TransformUniq:
+			lines = uniqLines(lines)
+		case TransformNl:
+			lines = numberLines(lines)
+		}
+	}
+
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// uniqLines collapses adjacent duplicate lines
+func uniqLines(lines []string) []string {
+	result := lines[:0:0]
+	for i, line := range lines {
+		if i > 0 && line == lines[i-1] {
+			continue
+		}
+		result = append(result, line)
+	}
+	return result
+}
+
+// numberLines numbers non-empty lines the way `nl` does by default
+func numberLines(lines []string) []string {
+	result := make([]string, len(lines))
+	n := 0
+	for i, line := range lines {
+		if line == "" {
+			result[i] = strings.Repeat(" ", nlWidth+1)
+			continue
+		}
+		n++
+		result[i] = fmt.Sprintf("%*d\t%s", nlWidth, n, line)
+	}
+	return result
+}
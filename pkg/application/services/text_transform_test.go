@@ -0,0 +1,51 @@
+package services
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseTransforms(t *testing.T) {
+	steps, err := ParseTransforms("sort, UNIQ,nl")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	expected := []string{TransformSort, TransformUniq, TransformNl}
+	if len(steps) != len(expected) {
+		t.Fatalf("Expected %d steps, got %d", len(expected), len(steps))
+	}
+	for i := range expected {
+		if steps[i] != expected[i] {
+			t.Errorf("Expected step %q, got %q", expected[i], steps[i])
+		}
+	}
+
+	if _, err := ParseTransforms("sort,rev"); !errors.Is(err, ErrUnsupportedTransform) {
+		t.Errorf("Expected ErrUnsupportedTransform, got %v", err)
+	}
+}
+
+func TestApplyTransforms(t *testing.T) {
+	tests := []struct {
+		name       string
+		input      string
+		transforms []string
+		expected   string
+	}{
+		{"empty", "", []string{TransformSort}, ""},
+		{"sort", "b\na\nc\n", []string{TransformSort}, "a\nb\nc\n"},
+		{"sort adds final newline", "b\na", []string{TransformSort}, "a\nb\n"},
+		{"uniq adjacent only", "a\na\nb\na\n", []string{TransformUniq}, "a\nb\na\n"},
+		{"sort then uniq", "a\nb\na\n", []string{TransformSort, TransformUniq}, "a\nb\n"},
+		{"nl skips blank lines", "x\n\ny\n", []string{TransformNl}, "     1\tx\n       \n     2\ty\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := applyTransforms(tt.input, tt.transforms)
+			if result != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, result)
+			}
+		})
+	}
+}
@@ -0,0 +1,190 @@
+package cat
+
+import (
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/sh05/cat-server/internal/config"
+	cathttp "github.com/sh05/cat-server/pkg/infrastructure/http"
+	"github.com/sh05/cat-server/pkg/infrastructure/logging"
+	"github.com/sh05/cat-server/pkg/infrastructure/signing"
+	"github.com/sh05/cat-server/pkg/security"
+)
+
+// Authentication challenges sent in WWW-Authenticate
+const (
+	basicAuthRealm  = `Basic realm="cat-server", charset="UTF-8"`
+	bearerAuthRealm = `Bearer realm="cat-server"`
+)
+
+// authenticator holds the configured authentication methods. A request is
+// accepted if it passes any one of them.
+type authenticator struct {
+	basic  *security.Htpasswd
+	jwt    *security.JWTVerifier
+	oidc   *oidcLogin
+	signer *signing.Signer
+}
+
+// newAuthenticator loads the htpasswd file, JWT keys, OIDC login flow and
+// signed URL keys from configuration.
+// It returns nil when no authentication is configured.
+func newAuthenticator(cfg *config.Config, logger *logging.Logger) (*authenticator, error) {
+	auth := &authenticator{}
+
+	if cfg.Security.AuthFile != "" {
+		users, err := security.LoadHtpasswd(cfg.Security.AuthFile)
+		if err != nil {
+			return nil, err
+		}
+		if skipped := users.Skipped(); len(skipped) > 0 {
+			logger.Warn("auth file entries without bcrypt hashes ignored", "users", skipped)
+		}
+		auth.basic = users
+	}
+
+	if jwtConfig := cfg.Security.JWT; jwtConfig.Enabled() {
+		verifierConfig := security.JWTConfig{
+			Secret:   []byte(jwtConfig.Secret),
+			Issuer:   jwtConfig.Issuer,
+			Audience: jwtConfig.Audience,
+		}
+		if jwtConfig.PublicKeyFile != "" {
+			data, err := os.ReadFile(jwtConfig.PublicKeyFile)
+			if err != nil {
+				return nil, err
+			}
+			if verifierConfig.PublicKey, err = security.ParseRSAPublicKeyPEM(data); err != nil {
+				return nil, err
+			}
+		}
+		if jwtConfig.JWKSURL != "" {
+			verifierConfig.JWKS = security.NewJWKS(jwtConfig.JWKSURL)
+		}
+
+		verifier, err := security.NewJWTVerifier(verifierConfig)
+		if err != nil {
+			return nil, err
+		}
+		auth.jwt = verifier
+	}
+
+	if cfg.Security.OIDC.Enabled() {
+		login, err := newOIDCLogin(cfg.Security.OIDC, logger)
+		if err != nil {
+			return nil, err
+		}
+		auth.oidc = login
+	}
+
+	signer, err := NewSigner(cfg)
+	if err != nil {
+		return nil, err
+	}
+	auth.signer = signer
+
+	if auth.basic == nil && auth.jwt == nil && auth.oidc == nil && auth.signer == nil {
+		return nil, nil
+	}
+	return auth, nil
+}
+
+// isAdminPath reports whether path is an admin endpoint, which is guarded
+// by the admin token instead of user authentication and is not accounted
+func isAdminPath(path string) bool {
+	return strings.HasPrefix(path, "/admin/") || strings.HasPrefix(path, "/health/")
+}
+
+// authExempt reports whether a path is served without authentication.
+// Health probes stay open, /auth/ hosts the login flow, and admin
+// endpoints, including detailed health, use their own bearer token in the
+// Authorization header.
+func authExempt(path string) bool {
+	return isProbe(path) || strings.HasPrefix(path, "/auth/") || isAdminPath(path)
+}
+
+// requireAuth rejects requests without valid credentials. Bearer tokens are
+// checked against the JWT configuration and Basic credentials against the
+// htpasswd file, which is reloaded when it changes; if the new contents
+// cannot be loaded the previous users stay in effect. With OIDC configured,
+// a session cookie is accepted too and browsers without credentials are
+// redirected to the login flow. GET and HEAD requests carrying a signature
+// are accepted only if it matches the path and has not expired.
+func requireAuth(next http.Handler, auth *authenticator, logger *logging.Logger) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if authExempt(r.URL.Path) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		reqLogger := logging.FromContext(r.Context(), logger)
+
+		if auth.signer != nil && isSignedRequest(r) {
+			claims, ok := verifySignedURL(w, r, auth.signer, reqLogger)
+			if !ok {
+				return
+			}
+			ctx := security.NewClaimsContext(r.Context(), claims)
+			ctx = logging.NewContext(ctx, reqLogger.With("user", claims.Subject()))
+			next.ServeHTTP(w, r.WithContext(ctx))
+			return
+		}
+
+		if auth.oidc != nil {
+			if claims, ok := auth.oidc.session(r); ok {
+				ctx := security.NewClaimsContext(r.Context(), claims)
+				ctx = logging.NewContext(ctx, reqLogger.With("user", claims.Subject(), "issuer", claims.Issuer()))
+				next.ServeHTTP(w, r.WithContext(ctx))
+				return
+			}
+		}
+
+		if token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok && auth.jwt != nil {
+			claims, err := auth.jwt.Verify(r.Context(), strings.TrimSpace(token))
+			if err != nil {
+				reqLogger.Warn("bearer token rejected", "error", err)
+				reqLogger.LogSecurityEvent("jwt_auth_failed", r.URL.Path, requestClientIP(r), r.UserAgent(), true)
+				w.Header().Set("WWW-Authenticate", bearerAuthRealm+`, error="invalid_token"`)
+				cathttp.WriteProblem(w, r, http.StatusUnauthorized, "")
+				return
+			}
+
+			// Expose the claims to handlers and tag downstream log lines
+			ctx := security.NewClaimsContext(r.Context(), claims)
+			ctx = logging.NewContext(ctx, reqLogger.With("user", claims.Subject(), "issuer", claims.Issuer()))
+			next.ServeHTTP(w, r.WithContext(ctx))
+			return
+		}
+
+		if auth.basic != nil {
+			if reloaded, err := auth.basic.ReloadIfChanged(); err != nil {
+				reqLogger.LogError(err, "failed to reload auth file")
+			} else if reloaded {
+				reqLogger.Info("auth file reloaded", "users", auth.basic.Users(), "skipped", auth.basic.Skipped())
+			}
+
+			if user, password, ok := r.BasicAuth(); ok && auth.basic.Authenticate(user, password) {
+				// Tag downstream log lines with the authenticated user
+				ctx := security.NewClaimsContext(r.Context(), security.Claims{"sub": user})
+				r = r.WithContext(logging.NewContext(ctx, reqLogger.With("user", user)))
+				next.ServeHTTP(w, r)
+				return
+			}
+		}
+
+		if auth.oidc != nil && r.Header.Get("Authorization") == "" && wantsHTML(r) {
+			loginRedirect(w, r)
+			return
+		}
+
+		reqLogger.LogSecurityEvent("auth_failed", r.URL.Path, requestClientIP(r), r.UserAgent(), true)
+		if auth.basic != nil {
+			w.Header().Add("WWW-Authenticate", basicAuthRealm)
+		}
+		if auth.jwt != nil {
+			w.Header().Add("WWW-Authenticate", bearerAuthRealm)
+		}
+		cathttp.WriteProblem(w, r, http.StatusUnauthorized, "")
+	})
+}
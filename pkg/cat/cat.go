@@ -0,0 +1,225 @@
+// Package cat embeds cat-server in other Go programs. NewHandler returns the
+// fully wired server, with every route and middleware the standalone binary
+// uses, as a *Server handler that can be mounted under a path prefix and
+// is closed once it no longer serves:
+//
+//	cfg := cat.DefaultConfig()
+//	cfg.FileSystem.BaseDirectory = "/srv/files"
+//	handler, err := cat.NewHandler(cfg)
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	defer handler.Close()
+//	mux.Handle("/files/", http.StripPrefix("/files", handler))
+package cat
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/sh05/cat-server/internal/config"
+	"github.com/sh05/cat-server/pkg/infrastructure/idgen"
+	"github.com/sh05/cat-server/pkg/infrastructure/logging"
+	"github.com/sh05/cat-server/pkg/infrastructure/metrics"
+)
+
+// Config is the cat-server configuration
+type Config = config.Config
+
+// DefaultConfig returns the configuration the binary starts from before
+// applying the config file, environment and flags
+func DefaultConfig() *Config {
+	return config.DefaultConfig()
+}
+
+// LoadConfig returns the configuration in the YAML, TOML or JSON file at
+// path, layered over the defaults and overridden by CAT_SERVER_*
+// environment variables. It also suits Options.LoadConfig:
+//
+//	opts.LoadConfig = func() (*cat.Config, error) { return cat.LoadConfig(path) }
+func LoadConfig(path string) (*Config, error) {
+	return config.Load(path)
+}
+
+// Options customizes an embedded server
+type Options struct {
+	// Logger receives the server logs. By default they are written to
+	// stdout at the configured level and format.
+	Logger *logging.Logger
+
+	// RecentLogs, when set, supplies the log lines included in support
+	// bundles
+	RecentLogs *logging.RecentLogBuffer
+
+	// LoadConfig loads the configuration applied by Reload and
+	// /admin/reload. Reloading is disabled when it is nil.
+	LoadConfig func() (*Config, error)
+
+	// RequestIDs generates the IDs of requests without a valid
+	// X-Request-ID header. By default they are random.
+	RequestIDs idgen.Generator
+}
+
+// Server is an embedded cat-server
+type Server struct {
+	handler  http.Handler
+	admin    http.Handler // nil unless Server.AdminAddr is configured
+	svc      *appServices
+	reloader *reloader       // nil when reloading is disabled
+	statsd   *metrics.StatsD // nil unless Metrics.StatsDHost is configured
+	watcher  *cacheWatcher   // nil unless file changes are watched
+}
+
+// NewHandler returns a Server for cfg with the default options. It is nil
+// when cfg is invalid or the server cannot be set up.
+func NewHandler(cfg *Config) (*Server, error) {
+	srv, err := New(cfg, Options{})
+	if err != nil {
+		return nil, err
+	}
+	return srv, nil
+}
+
+// New validates cfg and wires the services, routes, authentication and
+// middleware of a cat-server
+func New(cfg *Config, opts Options) (*Server, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	logger := opts.Logger
+	if logger == nil {
+		logger = NewLogger(cfg, os.Stdout)
+	}
+
+	applyMemorySettings(cfg, logger)
+	svc := newAppServices(cfg, logger)
+	muxes := newRegistries(cfg, svc, logger, opts.RecentLogs)
+	mux := muxes.public
+	if cfg.FileSystem.ReadOnly {
+		logReadOnlyMode(cfg, mux, logger)
+	}
+
+	// Account usage per user or client, endpoint and directory, enforcing
+	// the daily byte quota
+	var handler http.Handler = trackUsage(mux, svc.usage, svc.metrics, logger)
+
+	// Require authentication when an htpasswd file, JWT keys, OIDC or signed
+	// URL keys are configured
+	auth, err := newAuthenticator(cfg, logger)
+	if err != nil {
+		return nil, fmt.Errorf("failed to configure authentication: %w", err)
+	}
+	if auth != nil {
+		if auth.oidc != nil {
+			registerOIDCHandlers(mux, auth.oidc, logger)
+		}
+		handler = requireAuth(handler, auth, logger)
+
+		// Browsers send session cookies with cross-site requests, so
+		// mutating endpoints need CSRF tokens when sessions are in use
+		if auth.oidc != nil && len(mux.Mutating()) > 0 {
+			handler = protectCSRF(handler, auth.oidc, logger)
+			logger.Info("csrf protection enabled", "routes", mux.Mutating())
+		}
+	}
+
+	// Apply middleware
+	middleware, err := newMiddlewareOptions(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to configure network policy: %w", err)
+	}
+	banOnSecurityEvents(logger, middleware.bans)
+	logger.SetSecurityEventBuffer(svc.securityEvents)
+	middleware.requests = svc.metrics.Requests()
+	if opts.RequestIDs != nil {
+		middleware.ids = opts.RequestIDs
+	}
+
+	// Send request and filesystem error metrics to StatsD when configured
+	statsd, err := openStatsD(cfg, logger)
+	if err != nil {
+		return nil, fmt.Errorf("failed to configure statsd: %w", err)
+	}
+	middleware.statsd, middleware.routes = statsd, routeNames(muxes.public, muxes.admin)
+
+	// Drop cached listings and contents as soon as files change
+	watcher, err := newCacheWatcher(cfg, svc, logger)
+	if err != nil {
+		return nil, fmt.Errorf("failed to watch for file changes: %w", err)
+	}
+
+	s := &Server{svc: svc, statsd: statsd, watcher: watcher}
+
+	// Reload settings on demand and, with an admin token, POST /admin/reload
+	if opts.LoadConfig != nil {
+		s.reloader = newReloader(opts.LoadConfig, logger, svc, watcher, middleware)
+		if cfg.Security.AdminToken != "" {
+			registerReloadHandler(muxes.admin, cfg, s.reloader, logger)
+		}
+	}
+
+	s.handler = addMiddleware(handler, middleware, logger)
+	if muxes.separateAdmin() {
+		s.admin = addMiddleware(muxes.admin, middleware, logger)
+	}
+	return s, nil
+}
+
+// ServeHTTP serves a cat-server request
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.handler.ServeHTTP(w, r)
+}
+
+// AdminHandler returns the handler for the admin listener serving health, version,
+// metrics, profiling and admin endpoints, or nil when Server.AdminAddr is
+// empty and they are served by the Server itself
+func (s *Server) AdminHandler() http.Handler {
+	return s.admin
+}
+
+// SetReady sets whether /readyz reports the server ready. A new Server is
+// ready; programs serving it mark it unready until their listeners are
+// bound and again when graceful shutdown begins.
+func (s *Server) SetReady(ready bool) {
+	s.svc.health.SetServing(ready)
+}
+
+// Reload loads the configuration with Options.LoadConfig and applies the
+// settings that can change without a restart
+func (s *Server) Reload() error {
+	if s.reloader == nil {
+		return errors.New("reloading requires Options.LoadConfig")
+	}
+	_, err := s.reloader.Reload()
+	return err
+}
+
+// Close stops watching for file changes, the worker pool and the directory
+// index, and sends the buffered StatsD metrics. Call it once the server has
+// stopped serving.
+func (s *Server) Close() error {
+	s.svc.close()
+	var errs []error
+	if s.watcher != nil {
+		errs = append(errs, s.watcher.Close())
+	}
+	if s.statsd != nil {
+		errs = append(errs, s.statsd.Close())
+	}
+	return errors.Join(errs...)
+}
+
+// Uptime returns the time since the server was created
+func (s *Server) Uptime() time.Duration {
+	return s.svc.health.GetUptime()
+}
+
+// NewLogger creates the application logger from configuration
+func NewLogger(cfg *Config, w io.Writer) *logging.Logger {
+	return logging.NewLoggerWithWriter(parseLogLevel(cfg.Logging.Level), cfg.Logging.Format, w)
+}
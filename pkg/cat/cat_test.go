@@ -0,0 +1,1075 @@
+package cat
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sh05/cat-server/internal/config"
+	"github.com/sh05/cat-server/pkg/infrastructure/idgen"
+	"github.com/sh05/cat-server/pkg/infrastructure/logging"
+	"github.com/sh05/cat-server/pkg/infrastructure/signing"
+)
+
+func TestNewHandlerUnderPrefix(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "readme.txt"), []byte("hello\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := DefaultConfig()
+	cfg.FileSystem.BaseDirectory = dir
+	srv, err := New(cfg, Options{Logger: logging.NewLoggerWithWriter(logging.LevelError, "json", io.Discard)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/files/", http.StripPrefix("/files", srv))
+
+	tests := []struct {
+		target string
+		status int
+	}{
+		{"/files/health", http.StatusOK},
+		{"/files/ls", http.StatusOK},
+		{"/files/cat/readme.txt", http.StatusOK},
+		{"/files/cat/missing.txt", http.StatusNotFound},
+		{"/cat/readme.txt", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.target, nil))
+		if w.Code != tt.status {
+			t.Errorf("GET %s: expected status %d, got %d", tt.target, tt.status, w.Code)
+		}
+	}
+
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/files/cat/readme.txt", nil))
+	var response struct {
+		Content string `json:"content"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatal(err)
+	}
+	if response.Content != "hello\n" {
+		t.Errorf("Expected content %q, got %q", "hello\n", response.Content)
+	}
+
+	if err := srv.Reload(); err == nil {
+		t.Error("Expected Reload to fail without Options.LoadConfig")
+	}
+}
+
+func TestRequestID(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.FileSystem.BaseDirectory = t.TempDir()
+	srv, err := New(cfg, Options{
+		Logger:     logging.NewLoggerWithWriter(logging.LevelError, "json", io.Discard),
+		RequestIDs: idgen.NewSequential("req-"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		incoming string
+		kept     bool
+	}{
+		{"generated", "", false},
+		{"accepted", "abc-123_x.y:z", true},
+		{"too long", strings.Repeat("a", maxRequestIDLength+1), false},
+		{"unsafe characters", "id\" injected=1", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/health", nil)
+			if tt.incoming != "" {
+				r.Header.Set(requestIDHeader, tt.incoming)
+			}
+			w := httptest.NewRecorder()
+			srv.ServeHTTP(w, r)
+
+			id := w.Header().Get(requestIDHeader)
+			if id == "" {
+				t.Fatal("Expected a request ID in the response")
+			}
+			if (id == tt.incoming) != tt.kept {
+				t.Errorf("Incoming ID %q: got response ID %q, expected kept=%v", tt.incoming, id, tt.kept)
+			}
+			if !tt.kept && !strings.HasPrefix(id, "req-") {
+				t.Errorf("Incoming ID %q: expected an ID from the configured generator, got %q", tt.incoming, id)
+			}
+		})
+	}
+}
+
+func TestSignedURLs(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "report.txt"), []byte("quarterly"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cfg := DefaultConfig()
+	cfg.FileSystem.BaseDirectory = dir
+	cfg.Security.SignedURLs.Keys = []config.SigningKeyConfig{{ID: "k1", Secret: "s3cret"}}
+	cfg.Security.SignedURLs.ClockSkew = 0
+	srv, err := New(cfg, Options{Logger: logging.NewLoggerWithWriter(logging.LevelError, "json", io.Discard)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer, err := NewSigner(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sign := func(path string, ttl time.Duration) url.Values {
+		query, err := signer.Sign(path, url.Values{"expand_tabs": {"4"}}, ttl)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return query
+	}
+	tampered := sign("/cat/report.txt", time.Hour)
+	tampered.Set(signing.ParamExpires, strconv.FormatInt(time.Now().Add(24*time.Hour).Unix(), 10))
+	otherKey := sign("/cat/report.txt", time.Hour)
+	otherKey.Set(signing.ParamKeyID, "k2")
+	otherQuery := sign("/cat/report.txt", time.Hour)
+	otherQuery.Set("include_hidden", "true")
+
+	tests := []struct {
+		name   string
+		method string
+		target string
+		status int
+		code   string
+	}{
+		{"valid", http.MethodGet, "/cat/report.txt?" + sign("/cat/report.txt", time.Hour).Encode(), http.StatusOK, ""},
+		{"unsigned", http.MethodGet, "/cat/report.txt", http.StatusUnauthorized, ""},
+		{"other path", http.MethodGet, "/cat/other.txt?" + sign("/cat/report.txt", time.Hour).Encode(), http.StatusForbidden, "CAT-3012"},
+		{"other query", http.MethodGet, "/cat/report.txt?" + otherQuery.Encode(), http.StatusForbidden, "CAT-3012"},
+		{"tampered expiry", http.MethodGet, "/cat/report.txt?" + tampered.Encode(), http.StatusForbidden, "CAT-3012"},
+		{"unknown key", http.MethodGet, "/cat/report.txt?" + otherKey.Encode(), http.StatusForbidden, "CAT-3012"},
+		{"expired", http.MethodGet, "/cat/report.txt?" + sign("/cat/report.txt", -time.Hour).Encode(), http.StatusForbidden, "CAT-3013"},
+		{"not a read", http.MethodPost, "/cat/report.txt?" + sign("/cat/report.txt", time.Hour).Encode(), http.StatusUnauthorized, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			srv.ServeHTTP(w, httptest.NewRequest(tt.method, tt.target, nil))
+			if w.Code != tt.status {
+				t.Fatalf("Expected status %d, got %d: %s", tt.status, w.Code, w.Body)
+			}
+			if tt.code != "" && !strings.Contains(w.Body.String(), tt.code) {
+				t.Errorf("Expected error code %s, got %s", tt.code, w.Body)
+			}
+			if tt.status == http.StatusOK && !strings.Contains(w.Body.String(), "quarterly") {
+				t.Errorf("Expected the file contents, got %s", w.Body)
+			}
+		})
+	}
+}
+
+func TestLogSampling(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "big.txt"), []byte(strings.Repeat("x", 4096)), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// responses returns the response log lines written for targets
+	responses := func(cfg *Config, level logging.LogLevel, targets ...string) []map[string]any {
+		var buf strings.Builder
+		srv, err := New(cfg, Options{Logger: logging.NewLoggerWithWriter(level, "json", &buf)})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, target := range targets {
+			srv.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, target, nil))
+		}
+
+		var lines []map[string]any
+		for line := range strings.Lines(buf.String()) {
+			var record map[string]any
+			if err := json.Unmarshal([]byte(line), &record); err != nil {
+				t.Fatal(err)
+			}
+			if record["msg"] == "http response" {
+				lines = append(lines, record)
+			}
+		}
+		return lines
+	}
+
+	cfg := DefaultConfig()
+	cfg.FileSystem.BaseDirectory = dir
+	lines := responses(cfg, logging.LevelInfo, "/cat/big.txt")
+	if len(lines) != 1 || lines[0]["response_size"].(float64) < 4096 {
+		t.Errorf("Expected the response size to be logged, got %v", lines)
+	}
+
+	cfg.Logging.SampleSuccess = 3
+	targets := []string{"/health", "/health", "/health", "/health", "/missing", "/health"}
+	if lines := responses(cfg, logging.LevelInfo, targets...); len(lines) != 3 {
+		t.Errorf("Expected 2 of 5 successful and the failed request to be logged, got %d", len(lines))
+	}
+	if lines := responses(cfg, logging.LevelDebug, targets...); len(lines) != len(targets) {
+		t.Errorf("Expected every request to be logged at debug level, got %d", len(lines))
+	}
+
+	cfg.Logging.LargeResponseBytes = 1024
+	if lines := responses(cfg, logging.LevelInfo, "/health", "/cat/big.txt", "/cat/big.txt"); len(lines) != 3 {
+		t.Errorf("Expected large responses to be logged despite sampling, got %d", len(lines))
+	}
+}
+
+func TestSlowRequests(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "readme.txt"), []byte("hello\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := DefaultConfig()
+	cfg.FileSystem.BaseDirectory = dir
+	cfg.Logging.SlowRequests = map[string]time.Duration{"/cat": time.Nanosecond, "/ls": 0}
+	var buf strings.Builder
+	srv, err := New(cfg, Options{Logger: logging.NewLoggerWithWriter(logging.LevelInfo, "json", &buf)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, target := range []string{"/cat/readme.txt", "/ls", "/health"} {
+		srv.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, target, nil))
+	}
+
+	var warnings []map[string]any
+	for line := range strings.Lines(buf.String()) {
+		var record map[string]any
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Fatal(err)
+		}
+		if record["msg"] == "slow_request" {
+			warnings = append(warnings, record)
+		}
+	}
+	if len(warnings) != 1 || warnings[0]["path"] != "/cat/readme.txt" || warnings[0]["route"] != "/cat" || warnings[0]["level"] != "WARN" {
+		t.Fatalf("Expected one slow_request warning for /cat, got %v", warnings)
+	}
+	timings, _ := warnings[0]["timings"].(map[string]any)
+	for _, phase := range []string{"validate", "stat", "read", "encode", "other"} {
+		if _, ok := timings[phase]; !ok {
+			t.Errorf("Expected the %s phase in the timing breakdown, got %v", phase, timings)
+		}
+	}
+}
+
+func TestMatchSlowRequestThreshold(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Logging.SlowRequests = map[string]time.Duration{"/cat": time.Second, "/cat/logs/": time.Minute, "/ls": time.Millisecond}
+	thresholds := newSlowRequestThresholds(cfg)
+
+	tests := []struct {
+		path  string
+		route string // empty when no threshold applies
+	}{
+		{"/cat/readme.txt", "/cat"},
+		{"/cat/logs/app.log", "/cat/logs/"},
+		{"/cat/logs", "/cat"},
+		{"/ls", "/ls"},
+		{"/lsx", ""},
+		{"/health", ""},
+	}
+	for _, tt := range tests {
+		threshold, ok := matchSlowRequestThreshold(thresholds, tt.path)
+		if ok != (tt.route != "") || threshold.route != tt.route {
+			t.Errorf("matchSlowRequestThreshold(%q) = %q, %v, expected %q", tt.path, threshold.route, ok, tt.route)
+		}
+	}
+}
+
+func TestStatsD(t *testing.T) {
+	agent, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer agent.Close()
+
+	cfg := DefaultConfig()
+	cfg.FileSystem.BaseDirectory = t.TempDir()
+	cfg.Metrics.StatsDHost = "127.0.0.1"
+	cfg.Metrics.StatsDPort = agent.LocalAddr().(*net.UDPAddr).Port
+	srv, err := New(cfg, Options{Logger: logging.NewLoggerWithWriter(logging.LevelError, "json", io.Discard)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, target := range []string{"/ls", "/cat/missing.txt", "/no/such/route"} {
+		srv.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, target, nil))
+	}
+	if err := srv.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	packet := make([]byte, 65536)
+	agent.SetReadDeadline(time.Now().Add(5 * time.Second))
+	n, _, err := agent.ReadFrom(packet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, expected := range []string{
+		"cat_server.http.requests:1|c|#method:GET,status:200,route:/ls\n",
+		"cat_server.http.requests:1|c|#method:GET,status:404,route:/cat\n",
+		"cat_server.http.requests:1|c|#method:GET,status:404,route:other\n",
+		"cat_server.http.request_duration:",
+		"cat_server.filesystem.errors:1|c|#operation:read_file\n",
+	} {
+		if !strings.Contains(string(packet[:n]), expected) {
+			t.Errorf("Expected %q in the metrics, got %q", expected, packet[:n])
+		}
+	}
+}
+
+func TestProblemResponses(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.FileSystem.BaseDirectory = t.TempDir()
+	var logs strings.Builder
+	srv, err := New(cfg, Options{Logger: logging.NewLoggerWithWriter(logging.LevelInfo, "json", &logs)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		method string
+		target string
+		status int
+		code   string
+		detail string
+	}{
+		{http.MethodGet, "/cat/missing.txt", http.StatusNotFound, "CAT-1404", "File not found"},
+		{http.MethodGet, "/ls?content_type=bogus", http.StatusBadRequest, "CAT-2008", "invalid content type filter"},
+		{http.MethodGet, "/ls?path=../etc", http.StatusBadRequest, "CAT-1001", "Invalid path"},
+		{http.MethodDelete, "/ls", http.StatusMethodNotAllowed, "CAT-9405", ""},
+		{http.MethodGet, "/no/such/route", http.StatusNotFound, "CAT-9404", ""},
+	}
+	for _, tt := range tests {
+		logs.Reset()
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, httptest.NewRequest(tt.method, tt.target, nil))
+		if w.Code != tt.status || w.Header().Get("Content-Type") != "application/problem+json" {
+			t.Errorf("%s %s: expected a %d problem, got %d %q", tt.method, tt.target, tt.status, w.Code, w.Header().Get("Content-Type"))
+			continue
+		}
+		var problem struct {
+			Type      string `json:"type"`
+			Title     string `json:"title"`
+			Status    int    `json:"status"`
+			Code      string `json:"code"`
+			Detail    string `json:"detail"`
+			Instance  string `json:"instance"`
+			RequestID string `json:"requestId"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &problem); err != nil {
+			t.Fatalf("%s %s: %v", tt.method, tt.target, err)
+		}
+		if problem.Type != "about:blank" || problem.Title != http.StatusText(tt.status) || problem.Status != tt.status || problem.Code != tt.code ||
+			!strings.Contains(problem.Detail, tt.detail) || problem.Instance != strings.Split(tt.target, "?")[0] ||
+			problem.RequestID != w.Header().Get("X-Request-ID") {
+			t.Errorf("%s %s: unexpected problem %+v", tt.method, tt.target, problem)
+		}
+		if !strings.Contains(logs.String(), `"error_code":"`+tt.code+`"`) {
+			t.Errorf("%s %s: expected error_code %s in the response log, got %s", tt.method, tt.target, tt.code, logs.String())
+		}
+	}
+}
+
+func TestReadiness(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "files")
+	if err := os.Mkdir(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	cfg := DefaultConfig()
+	cfg.FileSystem.BaseDirectory = dir
+	srv, err := New(cfg, Options{Logger: logging.NewLoggerWithWriter(logging.LevelError, "json", io.Discard)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	status := func(target string) int {
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, httptest.NewRequest(http.MethodGet, target, nil))
+		return w.Code
+	}
+
+	if code := status("/readyz"); code != http.StatusOK {
+		t.Errorf("Expected a new server to be ready, got %d", code)
+	}
+	srv.SetReady(false)
+	if code := status("/readyz"); code != http.StatusServiceUnavailable {
+		t.Errorf("Expected 503 after SetReady(false), got %d", code)
+	}
+	srv.SetReady(true)
+
+	if err := os.Remove(dir); err != nil {
+		t.Fatal(err)
+	}
+	if code := status("/readyz"); code != http.StatusServiceUnavailable {
+		t.Errorf("Expected 503 without the base directory, got %d", code)
+	}
+	if code := status("/healthz"); code != http.StatusOK {
+		t.Errorf("Expected liveness to ignore the base directory, got %d", code)
+	}
+}
+
+func TestDetailedHealth(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.FileSystem.BaseDirectory = t.TempDir()
+	cfg.FileSystem.DiskWarningPercent = 0 // independent of the test machine's disk
+	cfg.FileSystem.DiskCriticalPercent = 0
+	cfg.Security.AdminToken = "secret"
+	srv, err := New(cfg, Options{Logger: logging.NewLoggerWithWriter(logging.LevelError, "json", io.Discard)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		target   string
+		token    string
+		accept   string
+		status   int
+		contains string
+	}{
+		{"/health/detailed", "", "", http.StatusUnauthorized, ""},
+		{"/health/detailed", "wrong", "", http.StatusUnauthorized, ""},
+		{"/health/detailed", "secret", "", http.StatusOK, `"filesystem":{"status":"healthy"`},
+		{"/health/detailed", "secret", "text/plain", http.StatusOK, "memory: healthy"},
+		{"/health/detailed", "secret", "text/html", http.StatusOK, "<td>filesystem</td><td>healthy</td>"},
+		{"/health/components/filesystem", "secret", "", http.StatusOK, `"entries":0`},
+		{"/health/components/goroutines", "secret", "text/plain", http.StatusOK, "Component: goroutines\nStatus: healthy\n"},
+		{"/health/components/disk", "secret", "", http.StatusNotFound, "unknown health component"},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, tt.target, nil)
+		if tt.token != "" {
+			req.Header.Set("Authorization", "Bearer "+tt.token)
+		}
+		if tt.accept != "" {
+			req.Header.Set("Accept", tt.accept)
+		}
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, req)
+		if w.Code != tt.status || !strings.Contains(w.Body.String(), tt.contains) {
+			t.Errorf("GET %s with token %q and Accept %q: got %d %q, expected %d containing %q",
+				tt.target, tt.token, tt.accept, w.Code, w.Body.String(), tt.status, tt.contains)
+		}
+	}
+}
+
+func TestTrafficAccounting(t *testing.T) {
+	dir, mounted := t.TempDir(), t.TempDir()
+	for name, content := range map[string]string{
+		filepath.Join(dir, "readme.txt"):      "hello\n",
+		filepath.Join(dir, "logs", "app.log"): "started\n",
+		filepath.Join(mounted, "data.csv"):    "a,b\n",
+	} {
+		if err := os.MkdirAll(filepath.Dir(name), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(name, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cfg := DefaultConfig()
+	cfg.FileSystem.BaseDirectory = dir
+	cfg.FileSystem.Mounts = []config.MountConfig{{Name: "data", Path: mounted}}
+	cfg.Security.AdminToken = "secret"
+	srv, err := New(cfg, Options{Logger: logging.NewLoggerWithWriter(logging.LevelError, "json", io.Discard)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var rootBytes int
+	for _, target := range []string{"/cat/readme.txt", "/cat/logs/app.log", "/ls?path=logs", "/ls/data", "/cat/data/data.csv", "/cat/missing/a.txt", "/no/such/route"} {
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, httptest.NewRequest(http.MethodGet, target, nil))
+		if target == "/cat/readme.txt" {
+			rootBytes = w.Body.Len()
+		}
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/admin/usage", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, req)
+	var usage struct {
+		Endpoints   []struct{ Key string } `json:"endpoints"`
+		Directories []struct {
+			Key      string
+			Requests int64
+			Bytes    int64
+		} `json:"directories"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &usage); err != nil {
+		t.Fatalf("Expected usage, got %d %q: %v", w.Code, w.Body.String(), err)
+	}
+
+	var endpoints, directories []string
+	for _, e := range usage.Endpoints {
+		endpoints = append(endpoints, e.Key)
+	}
+	for _, d := range usage.Directories {
+		directories = append(directories, fmt.Sprintf("%s:%d", d.Key, d.Requests))
+		if d.Key == "." && d.Bytes != int64(rootBytes) {
+			t.Errorf("Expected %d bytes served from ., got %d", rootBytes, d.Bytes)
+		}
+	}
+	if expected := []string{"/cat", "/ls", "other"}; !slices.Equal(endpoints, expected) {
+		t.Errorf("Expected endpoints %v, got %v", expected, endpoints)
+	}
+	if expected := []string{".:1", "data:2", "logs:2"}; !slices.Equal(directories, expected) {
+		t.Errorf("Expected directories %v, got %v", expected, directories)
+	}
+
+	w = httptest.NewRecorder()
+	srv.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	for _, expected := range []string{`cat_server_endpoint_requests_total{endpoint="/cat"} 4`, `cat_server_directory_bytes_total{directory="data"} `} {
+		if !strings.Contains(w.Body.String(), expected) {
+			t.Errorf("Expected %q in the metrics", expected)
+		}
+	}
+}
+
+func TestSecurityEventsHandler(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.FileSystem.BaseDirectory = t.TempDir()
+	cfg.Security.AdminToken = "secret"
+	srv, err := New(cfg, Options{Logger: logging.NewLoggerWithWriter(logging.LevelError, "json", io.Discard)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	traversal := httptest.NewRecorder()
+	srv.ServeHTTP(traversal, httptest.NewRequest(http.MethodGet, "/ls?path=../etc", nil))
+
+	tests := []struct {
+		query  string
+		status int
+		events int
+	}{
+		{"", http.StatusOK, 1},
+		{"?since=1h", http.StatusOK, 1},
+		{"?since=" + time.Now().Add(time.Hour).UTC().Format(time.RFC3339), http.StatusOK, 0},
+		{"?since=yesterday", http.StatusBadRequest, 0},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/admin/security-events"+tt.query, nil)
+		req.Header.Set("Authorization", "Bearer secret")
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, req)
+		if w.Code != tt.status {
+			t.Errorf("%s: expected status %d, got %d %q", tt.query, tt.status, w.Code, w.Body.String())
+			continue
+		}
+		if w.Code != http.StatusOK {
+			continue
+		}
+		var response struct {
+			Events []logging.SecurityEvent `json:"events"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil || len(response.Events) != tt.events {
+			t.Errorf("%s: expected %d events, got %q (%v)", tt.query, tt.events, w.Body.String(), err)
+			continue
+		}
+		if tt.events > 0 {
+			if e := response.Events[0]; e.Event != "path_traversal" || !e.Blocked || e.RequestID != traversal.Header().Get("X-Request-ID") {
+				t.Errorf("%s: unexpected event %+v", tt.query, e)
+			}
+		}
+	}
+}
+
+func TestListingCacheHandler(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.FileSystem.BaseDirectory = t.TempDir()
+	cfg.Security.AdminToken = "secret"
+	if err := os.Mkdir(filepath.Join(cfg.FileSystem.BaseDirectory, "logs"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	srv, err := New(cfg, Options{Logger: logging.NewLoggerWithWriter(logging.LevelError, "json", io.Discard)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, target := range []string{"/ls", "/ls?path=logs"} {
+		srv.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, target, nil))
+	}
+
+	for _, tt := range []struct {
+		query       string
+		invalidated int
+	}{
+		{"?path=logs/app.log", 1},
+		{"?path=logs", 1},
+		{"", 0},
+	} {
+		req := httptest.NewRequest(http.MethodPost, "/admin/listings/invalidate"+tt.query, nil)
+		req.Header.Set("Authorization", "Bearer secret")
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, req)
+		var response struct {
+			Invalidated int `json:"invalidated"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil || w.Code != http.StatusOK || response.Invalidated != tt.invalidated {
+			t.Errorf("%s: expected %d listings invalidated, got %d %q", tt.query, tt.invalidated, w.Code, w.Body.String())
+		}
+	}
+}
+
+func TestWatchInvalidatesCaches(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.FileSystem.BaseDirectory = t.TempDir()
+	cfg.FileSystem.ListingCacheTTL = time.Hour
+	cfg.FileSystem.Watch = "poll"
+	cfg.FileSystem.WatchPollInterval = 10 * time.Millisecond
+	logPath := filepath.Join(cfg.FileSystem.BaseDirectory, "app.log")
+	if err := os.WriteFile(logPath, []byte("a"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	srv, err := New(cfg, Options{Logger: logging.NewLoggerWithWriter(logging.LevelError, "json", io.Discard)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer srv.Close()
+
+	list := func() string {
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/ls", nil))
+		return w.Body.String()
+	}
+	if body := list(); !strings.Contains(body, `"size":1,`) {
+		t.Fatalf("Expected the listing to show the log, got %s", body)
+	}
+
+	// Growing the log leaves the directory's modification time unchanged,
+	// so only the watcher drops the cached listing
+	if err := os.WriteFile(logPath, []byte("abc"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	for deadline := time.Now().Add(5 * time.Second); !strings.Contains(list(), `"size":3,`); {
+		if time.Now().After(deadline) {
+			t.Fatal("Expected the watcher to invalidate the cached listing")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestWatchUpdatesIndex(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.FileSystem.BaseDirectory = t.TempDir()
+	cfg.FileSystem.ContentCacheBytes = 0
+	cfg.FileSystem.ListingCacheTTL = 0
+	cfg.FileSystem.IndexMaxEntries = 1000
+	cfg.FileSystem.Watch = "poll"
+	cfg.FileSystem.WatchPollInterval = 10 * time.Millisecond
+	logPath := filepath.Join(cfg.FileSystem.BaseDirectory, "logs", "app.log")
+	if err := os.MkdirAll(filepath.Dir(logPath), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(logPath, []byte("a"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	srv, err := New(cfg, Options{Logger: logging.NewLoggerWithWriter(logging.LevelError, "json", io.Discard)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer srv.Close()
+
+	list := func() string {
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/ls/logs", nil))
+		return w.Body.String()
+	}
+	if body := list(); !strings.Contains(body, `"size":1,`) {
+		t.Fatalf("Expected the listing to show the log, got %s", body)
+	}
+
+	// The watcher has the index list the directory again
+	if err := os.WriteFile(logPath, []byte("abc"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	for deadline := time.Now().Add(5 * time.Second); !strings.Contains(list(), `"size":3,`); {
+		if time.Now().After(deadline) {
+			t.Fatal("Expected the watcher to update the directory index")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestLogLevelHandler(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.FileSystem.BaseDirectory = t.TempDir()
+	cfg.Security.AdminToken = "secret"
+	logger := logging.NewLoggerWithWriter(logging.LevelInfo, "json", io.Discard)
+	srv, err := New(cfg, Options{Logger: logger})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		method string
+		token  string
+		body   string
+		status int
+		level  string // the level afterwards
+	}{
+		{http.MethodGet, "secret", "", http.StatusOK, "info"},
+		{http.MethodPut, "", `{"level":"debug"}`, http.StatusUnauthorized, "info"},
+		{http.MethodPut, "secret", `{"level":"verbose"}`, http.StatusBadRequest, "info"},
+		{http.MethodPut, "secret", `debug`, http.StatusBadRequest, "info"},
+		{http.MethodPut, "secret", `{"level":"debug"}`, http.StatusOK, "debug"},
+		{http.MethodGet, "secret", "", http.StatusOK, "debug"},
+		{http.MethodPut, "secret", `{"level":"INFO"}`, http.StatusOK, "info"},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(tt.method, "/admin/loglevel", strings.NewReader(tt.body))
+		if tt.token != "" {
+			req.Header.Set("Authorization", "Bearer "+tt.token)
+		}
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, req)
+		if w.Code != tt.status {
+			t.Errorf("%s %s: expected status %d, got %d %q", tt.method, tt.body, tt.status, w.Code, w.Body.String())
+		}
+		if w.Code == http.StatusOK {
+			var body logLevelBody
+			if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil || body.Level != tt.level {
+				t.Errorf("%s %s: expected level %q, got %q (%v)", tt.method, tt.body, tt.level, w.Body.String(), err)
+			}
+		}
+		if got := logLevelName(logger.LogLevel()); got != tt.level {
+			t.Errorf("%s %s: expected the logger at %s, got %s", tt.method, tt.body, tt.level, got)
+		}
+	}
+}
+
+func TestNewHandlerInvalidConfig(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.FileSystem.BaseDirectory = filepath.Join(t.TempDir(), "missing")
+	handler, err := NewHandler(cfg)
+	if err == nil {
+		t.Error("Expected an error for a missing base directory")
+	}
+	if handler != nil {
+		t.Errorf("Expected no handler, got %#v", handler)
+	}
+}
+
+func TestLoadConfig(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "cat-server.yaml")
+	doc := "server:\n  port: 9001\nfilesystem:\n  base_directory: " + dir + "\n"
+	if err := os.WriteFile(path, []byte(doc), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	if cfg.Server.Port != "9001" || cfg.FileSystem.BaseDirectory != dir {
+		t.Errorf("Expected the file settings, got port %q and directory %q", cfg.Server.Port, cfg.FileSystem.BaseDirectory)
+	}
+
+	if _, err := LoadConfig(filepath.Join(dir, "missing.yaml")); err == nil {
+		t.Error("Expected an error for a missing file")
+	}
+}
+
+func TestNewHandlerClose(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.FileSystem.BaseDirectory = t.TempDir()
+	cfg.FileSystem.Watch = "poll"
+	handler, err := NewHandler(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if handler.watcher == nil {
+		t.Fatal("Expected the server to watch the base directory")
+	}
+	if err := handler.Close(); err != nil {
+		t.Errorf("Expected Close to succeed, got %v", err)
+	}
+}
+
+func TestAdminHandler(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "readme.txt"), []byte("hello\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := DefaultConfig()
+	cfg.FileSystem.BaseDirectory = dir
+	logger := logging.NewLoggerWithWriter(logging.LevelError, "json", io.Discard)
+
+	srv, err := New(cfg, Options{Logger: logger})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if srv.AdminHandler() != nil {
+		t.Error("Expected no admin handler without an admin address")
+	}
+
+	cfg.Server.AdminAddr = "127.0.0.1:9090"
+	cfg.Security.AdminToken = "secret"
+	srv, err = New(cfg, Options{Logger: logger})
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := httptest.NewRecorder()
+	srv.AdminHandler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/debug/pprof/", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected no profiling without EnablePprof, got %d", w.Code)
+	}
+
+	cfg.Server.EnablePprof = true
+	srv, err = New(cfg, Options{Logger: logger})
+	if err != nil {
+		t.Fatal(err)
+	}
+	admin := srv.AdminHandler()
+	if admin == nil {
+		t.Fatal("Expected an admin handler")
+	}
+
+	tests := []struct {
+		handler http.Handler
+		target  string
+		token   string
+		status  int
+	}{
+		{srv, "/ls", "", http.StatusOK},
+		{srv, "/health", "", http.StatusNotFound},
+		{srv, "/metrics", "", http.StatusNotFound},
+		{srv, "/debug/pprof/", "", http.StatusNotFound},
+		{srv, "/debug/vars", "", http.StatusNotFound},
+		{srv, "/admin/usage", "", http.StatusNotFound},
+		{srv, "/health/detailed", "", http.StatusNotFound},
+		{admin, "/health", "", http.StatusOK},
+		{admin, "/metrics", "", http.StatusOK},
+		{admin, "/debug/pprof/", "", http.StatusUnauthorized},
+		{admin, "/debug/pprof/", "secret", http.StatusOK},
+		{admin, "/debug/pprof/cmdline", "secret", http.StatusNotFound},
+		{admin, "/debug/vars", "", http.StatusUnauthorized},
+		{admin, "/debug/vars", "secret", http.StatusOK},
+		{admin, "/admin/usage", "", http.StatusUnauthorized},
+		{admin, "/health/detailed", "", http.StatusUnauthorized},
+		{admin, "/ls", "", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, tt.target, nil)
+		if tt.token != "" {
+			req.Header.Set("Authorization", "Bearer "+tt.token)
+		}
+		tt.handler.ServeHTTP(w, req)
+		if w.Code != tt.status {
+			t.Errorf("GET %s: expected status %d, got %d", tt.target, tt.status, w.Code)
+		}
+	}
+
+	// The command line may carry secrets such as the admin token
+	w = httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/debug/vars", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	admin.ServeHTTP(w, req)
+	var vars map[string]json.RawMessage
+	if err := json.Unmarshal(w.Body.Bytes(), &vars); err != nil {
+		t.Fatalf("Expected JSON variables, got %v: %s", err, w.Body)
+	}
+	if _, ok := vars["cmdline"]; ok {
+		t.Error("Expected /debug/vars to leave out the command line")
+	}
+	if _, ok := vars["memstats"]; !ok {
+		t.Error("Expected /debug/vars to include memstats")
+	}
+}
+
+func TestRedactionAcrossEndpoints(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.FileSystem.BaseDirectory = t.TempDir()
+	cfg.FileSystem.AllowHidden = true
+	cfg.Security.Redaction.Files = append(cfg.Security.Redaction.Files, "secrets.json")
+	files := map[string]string{
+		".env":         "SECRET_KEY=hunter2\n",
+		"a.txt":        "DEBUG=true\n",
+		"secrets.json": "{\n  \"password\": \"hunter2\"\n}\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(cfg.FileSystem.BaseDirectory, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	srv, err := New(cfg, Options{Logger: logging.NewLoggerWithWriter(logging.LevelError, "json", io.Discard)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer srv.Close()
+
+	tests := []struct {
+		method string
+		target string
+		body   string
+		status int
+		want   string
+	}{
+		{http.MethodGet, "/cat/.env", "", http.StatusOK, "SECRET_KEY=***"},
+		{http.MethodGet, "/grep/.env?pattern=SECRET", "", http.StatusOK, "SECRET_KEY=***"},
+		{http.MethodGet, "/search?q=password", "", http.StatusOK, `\"password\": \"***\"`},
+		{http.MethodGet, "/hexdump/.env", "", http.StatusForbidden, "CAT-1004"},
+		{http.MethodGet, "/diff?a=.env&b=a.txt", "", http.StatusOK, "-SECRET_KEY=***"},
+		{http.MethodGet, "/logs/.env", "", http.StatusForbidden, "CAT-1004"},
+		// "***" is short enough, the real password is not
+		{http.MethodPost, "/validate/secrets.json", `{"properties":{"password":{"maxLength":3}}}`, http.StatusOK, `"valid":true`},
+		{http.MethodGet, "/manifest/?format=json", "", http.StatusOK, `"skipped":[".env","secrets.json"]`},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, httptest.NewRequest(tt.method, tt.target, strings.NewReader(tt.body)))
+		if w.Code != tt.status || !strings.Contains(w.Body.String(), tt.want) || strings.Contains(w.Body.String(), "hunter2") {
+			t.Errorf("%s %s: expected %d with %q and no secret, got %d %s", tt.method, tt.target, tt.status, tt.want, w.Code, w.Body.String())
+		}
+	}
+
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/archive/?format=zip", nil))
+	archive, err := zip.NewReader(bytes.NewReader(w.Body.Bytes()), int64(w.Body.Len()))
+	if err != nil {
+		t.Fatalf("GET /archive/?format=zip: %d %v", w.Code, err)
+	}
+	for _, f := range archive.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		content, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if f.Name == ".env" && string(content) != "SECRET_KEY=***\n" || bytes.Contains(content, []byte("hunter2")) {
+			t.Errorf("Expected %s to be redacted in the archive, got %q", f.Name, content)
+		}
+	}
+}
+
+// readFromRecorder records what the server would send with sendfile
+type readFromRecorder struct {
+	*httptest.ResponseRecorder
+	src io.Reader
+}
+
+func (w *readFromRecorder) ReadFrom(src io.Reader) (int64, error) {
+	w.src = src
+	return io.Copy(w.ResponseRecorder, src)
+}
+
+func TestRawFilesReachSendfile(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.FileSystem.BaseDirectory = t.TempDir()
+	cfg.Security.DailyByteQuota = 1 << 30
+	if err := os.WriteFile(filepath.Join(cfg.FileSystem.BaseDirectory, "app.bin"), make([]byte, 4096), 0644); err != nil {
+		t.Fatal(err)
+	}
+	srv, err := New(cfg, Options{Logger: logging.NewLoggerWithWriter(logging.LevelError, "json", io.Discard)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Every wrapper of the response writer must pass the open file on, as
+	// the server only uses sendfile for an *os.File
+	w := &readFromRecorder{ResponseRecorder: httptest.NewRecorder()}
+	srv.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/cat/app.bin?raw=true", nil))
+	src := w.src
+	if limited, ok := src.(*io.LimitedReader); ok {
+		src = limited.R
+	}
+	if _, ok := src.(*os.File); !ok || w.Code != http.StatusOK || w.Body.Len() != 4096 {
+		t.Errorf("Expected the file to be passed to ReadFrom, got %T, %d with %d bytes", w.src, w.Code, w.Body.Len())
+	}
+}
+
+// BenchmarkListDirectory lists directories of 1k and 10k files, guarding
+// the allocations of building and encoding large listings
+func BenchmarkListDirectory(b *testing.B) {
+	for _, files := range []int{1_000, 10_000} {
+		b.Run(fmt.Sprintf("%d", files), func(b *testing.B) {
+			cfg := DefaultConfig()
+			cfg.FileSystem.BaseDirectory = b.TempDir()
+			for i := range files {
+				if err := os.WriteFile(filepath.Join(cfg.FileSystem.BaseDirectory, fmt.Sprintf("file-%05d.log", i)), []byte("x"), 0644); err != nil {
+					b.Fatal(err)
+				}
+			}
+			srv, err := New(cfg, Options{Logger: logging.NewLoggerWithWriter(logging.LevelError, "json", io.Discard)})
+			if err != nil {
+				b.Fatal(err)
+			}
+			defer srv.Close()
+
+			b.ReportAllocs()
+			for b.Loop() {
+				w := httptest.NewRecorder()
+				srv.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/ls", nil))
+				if w.Code != http.StatusOK {
+					b.Fatalf("Expected status 200, got %d", w.Code)
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkRawDownload downloads a multi-GB sparse file over TCP, where
+// Linux serves it with sendfile
+func BenchmarkRawDownload(b *testing.B) {
+	const size = 4 << 30
+	cfg := DefaultConfig()
+	cfg.FileSystem.BaseDirectory = b.TempDir()
+	cfg.FileSystem.MaxFileSize = size
+	file, err := os.Create(filepath.Join(cfg.FileSystem.BaseDirectory, "disk.img"))
+	if err != nil {
+		b.Fatal(err)
+	}
+	if err := file.Truncate(size); err != nil {
+		b.Fatal(err)
+	}
+	file.Close()
+
+	srv, err := New(cfg, Options{Logger: logging.NewLoggerWithWriter(logging.LevelError, "json", io.Discard)})
+	if err != nil {
+		b.Fatal(err)
+	}
+	ts := httptest.NewServer(srv)
+	defer ts.Close()
+
+	b.SetBytes(size)
+	b.ResetTimer()
+	for b.Loop() {
+		resp, err := http.Get(ts.URL + "/cat/disk.img?raw=true")
+		if err != nil {
+			b.Fatal(err)
+		}
+		n, err := io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+		if err != nil || n != size {
+			b.Fatalf("Expected %d bytes, got %d: %v", size, n, err)
+		}
+	}
+}
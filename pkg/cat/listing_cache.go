@@ -0,0 +1,58 @@
+package cat
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/sh05/cat-server/internal/config"
+	"github.com/sh05/cat-server/pkg/infrastructure/logging"
+	"github.com/sh05/cat-server/pkg/server"
+)
+
+// registerListingCacheHandler registers the admin endpoint dropping cached
+// directory listings, and those of the directory index, e.g. after files
+// were changed in place by a deploy: /admin/listings/invalidate?path=logs
+// drops the listings of logs and its parent, and without a path every
+// listing is dropped
+func registerListingCacheHandler(mux *server.Registry, cfg *config.Config, svc *appServices, logger *logging.Logger) {
+	mux.HandleFunc("POST /admin/listings/invalidate", requireAdminToken(cfg, logger, func(w http.ResponseWriter, r *http.Request) {
+		path := r.URL.Query().Get("path")
+		var invalidated int
+		for _, listings := range svc.listingCaches() {
+			if path == "" {
+				invalidated += listings.InvalidateAll()
+			} else {
+				invalidated += listings.Invalidate(path)
+			}
+		}
+		logging.FromContext(r.Context(), logger).Info("directory listings invalidated", "path", path, "invalidated", invalidated)
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(struct {
+			Invalidated int `json:"invalidated"`
+		}{invalidated})
+	}))
+	mux.Describe("POST /admin/listings/invalidate", server.RouteDoc{
+		Summary:  "Drop cached directory listings",
+		Query:    []server.Param{{Name: "path", Description: "Directory or changed file; every listing when empty"}},
+		Produces: []string{"application/json"},
+	})
+}
+
+// listingInvalidator drops directory listings held in memory
+type listingInvalidator interface {
+	Invalidate(path string) int
+	InvalidateAll() int
+}
+
+// listingCaches returns the enabled holders of directory listings
+func (svc *appServices) listingCaches() []listingInvalidator {
+	var caches []listingInvalidator
+	if svc.listings != nil {
+		caches = append(caches, svc.listings)
+	}
+	if svc.index != nil {
+		caches = append(caches, svc.index)
+	}
+	return caches
+}
@@ -0,0 +1,74 @@
+package cat
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/sh05/cat-server/internal/buildinfo"
+	"github.com/sh05/cat-server/pkg/infrastructure/logging"
+)
+
+// OpenLogger creates the application logger writing every record to each
+// output in cfg.Logging.Outputs and to extra sinks, e.g. a buffer of recent
+// lines. Close the returned closer on exit to flush and release the
+// outputs.
+func OpenLogger(cfg *Config, extra ...logging.Sink) (*logging.Logger, io.Closer, error) {
+	var (
+		sinks   []logging.Sink
+		outputs closers
+	)
+	for _, output := range cfg.Logging.Outputs {
+		sink, closer, err := openLogOutput(cfg, output)
+		if err != nil {
+			outputs.Close()
+			return nil, nil, fmt.Errorf("log output %s: %w", output, err)
+		}
+		sinks = append(sinks, sink)
+		if closer != nil {
+			outputs = append(outputs, closer)
+		}
+	}
+	sinks = append(sinks, extra...)
+	return logging.NewLogger(parseLogLevel(cfg.Logging.Level), cfg.Logging.Format, sinks...), outputs, nil
+}
+
+// openLogOutput opens one of the outputs named in LoggingConfig.Outputs
+func openLogOutput(cfg *Config, output string) (logging.Sink, io.Closer, error) {
+	switch output {
+	case "stdout":
+		return logging.WriterSink(os.Stdout), nil, nil
+	case "stderr":
+		return logging.WriterSink(os.Stderr), nil, nil
+	case "file":
+		file, err := os.OpenFile(cfg.Logging.File, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0640)
+		if err != nil {
+			return nil, nil, err
+		}
+		return logging.WriterSink(file), file, nil
+	case "syslog":
+		return logging.OpenSyslogSink(cfg.Logging.SyslogFacility, "cat-server")
+	case "journald":
+		return logging.OpenJournaldSink("cat-server")
+	case "otlp":
+		build := buildinfo.Get()
+		sink, closer := logging.NewOTLPSink(cfg.Logging.OTLPEndpoint, map[string]string{
+			"service.name":    "cat-server",
+			"service.version": build.Version,
+		})
+		return sink, closer, nil
+	}
+	return nil, nil, fmt.Errorf("unknown log output %q", output)
+}
+
+// closers closes several outputs, reporting every error
+type closers []io.Closer
+
+func (c closers) Close() error {
+	var errs []error
+	for _, closer := range c {
+		errs = append(errs, closer.Close())
+	}
+	return errors.Join(errs...)
+}
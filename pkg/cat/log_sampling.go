@@ -0,0 +1,33 @@
+package cat
+
+import (
+	"sync/atomic"
+
+	"github.com/sh05/cat-server/internal/config"
+)
+
+// logSampler picks the requests logged when the access log is sampled:
+// every error and large response, and 1 in every successful ones
+type logSampler struct {
+	every      uint64
+	largeBytes int64 // 0 when size does not matter
+	count      atomic.Uint64
+}
+
+// newLogSampler returns the sampler configured by cfg, or nil when every
+// request is logged
+func newLogSampler(cfg *config.Config) *logSampler {
+	if cfg.Logging.SampleSuccess <= 1 {
+		return nil
+	}
+	return &logSampler{every: uint64(cfg.Logging.SampleSuccess), largeBytes: cfg.Logging.LargeResponseBytes}
+}
+
+// keep reports whether a request answered with status and size body
+// bytes is logged
+func (s *logSampler) keep(status int, size int64) bool {
+	if status >= 400 || s.largeBytes > 0 && size >= s.largeBytes {
+		return true
+	}
+	return s.count.Add(1)%s.every == 1
+}
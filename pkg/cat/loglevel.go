@@ -0,0 +1,60 @@
+package cat
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/sh05/cat-server/internal/config"
+	cathttp "github.com/sh05/cat-server/pkg/infrastructure/http"
+	"github.com/sh05/cat-server/pkg/infrastructure/logging"
+	"github.com/sh05/cat-server/pkg/server"
+)
+
+// logLevels are the level names accepted by PUT /admin/loglevel, as in
+// logging.level
+var logLevels = []string{"debug", "info", "warn", "error"}
+
+// logLevelBody is the request and response body of /admin/loglevel
+type logLevelBody struct {
+	Level string `json:"level"`
+}
+
+// logLevelName returns the configuration name of level, e.g. "debug"
+func logLevelName(level slog.Level) string {
+	return strings.ToLower(level.String())
+}
+
+// registerLogLevelHandler registers the admin endpoints reading and
+// changing the log level at runtime. The change lasts until the process
+// exits or a reload changes logging.level.
+func registerLogLevelHandler(mux *server.Registry, cfg *config.Config, logger *logging.Logger) {
+	mux.HandleFunc("GET /admin/loglevel", requireAdminToken(cfg, logger, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(logLevelBody{Level: logLevelName(logger.LogLevel())})
+	}))
+	mux.Describe("GET /admin/loglevel", server.RouteDoc{Summary: "Show the current log level", Produces: []string{"application/json"}})
+
+	mux.HandleFunc("PUT /admin/loglevel", requireAdminToken(cfg, logger, func(w http.ResponseWriter, r *http.Request) {
+		var body logLevelBody
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1024)).Decode(&body); err != nil {
+			cathttp.WriteProblem(w, r, http.StatusBadRequest, "Invalid request body: "+err.Error())
+			return
+		}
+		level := strings.ToLower(strings.TrimSpace(body.Level))
+		if !slices.Contains(logLevels, level) {
+			cathttp.WriteProblem(w, r, http.StatusBadRequest, "Invalid log level: must be one of "+strings.Join(logLevels, ", "))
+			return
+		}
+
+		previous := logLevelName(logger.LogLevel())
+		logger.SetLevel(parseLogLevel(level))
+		logging.FromContext(r.Context(), logger).Info("log level changed", "level", level, "previous", previous)
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(logLevelBody{Level: level})
+	}))
+	mux.Describe("PUT /admin/loglevel", server.RouteDoc{Summary: "Change the log level until restart", Produces: []string{"application/json"}})
+}
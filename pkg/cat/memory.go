@@ -0,0 +1,35 @@
+package cat
+
+import (
+	"math"
+	"runtime/debug"
+
+	"github.com/sh05/cat-server/internal/config"
+	"github.com/sh05/cat-server/pkg/infrastructure/logging"
+	"github.com/sh05/cat-server/pkg/infrastructure/memguard"
+)
+
+// applyMemorySettings sets the memory limit and garbage collection target
+// of the runtime when configured, overriding GOMEMLIMIT and GOGC. They are
+// process-wide and not reloaded.
+func applyMemorySettings(cfg *config.Config, logger *logging.Logger) {
+	if cfg.Server.MemoryLimit > 0 {
+		debug.SetMemoryLimit(cfg.Server.MemoryLimit)
+	}
+	if cfg.Server.GCPercent != 0 {
+		debug.SetGCPercent(cfg.Server.GCPercent)
+	}
+	if limit := debug.SetMemoryLimit(-1); limit != math.MaxInt64 {
+		logger.Info("memory limit in effect", "bytes", limit, "reject_large_reads_percent", cfg.Server.MemoryRejectPercent)
+	}
+}
+
+// newMemoryGuard returns the guard refusing large reads near the runtime's
+// memory limit, or nil without a limit or when disabled
+func newMemoryGuard(cfg *config.Config) *memguard.Guard {
+	limit := debug.SetMemoryLimit(-1)
+	if limit == math.MaxInt64 || cfg.Server.MemoryRejectPercent == 0 {
+		return nil
+	}
+	return memguard.New(uint64(limit), cfg.Server.MemoryRejectPercent, cfg.Server.LargeReadBytes)
+}
@@ -0,0 +1,401 @@
+package cat
+
+import (
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/sh05/cat-server/internal/config"
+	cathttp "github.com/sh05/cat-server/pkg/infrastructure/http"
+	"github.com/sh05/cat-server/pkg/infrastructure/idgen"
+	"github.com/sh05/cat-server/pkg/infrastructure/logging"
+	"github.com/sh05/cat-server/pkg/infrastructure/metrics"
+	"github.com/sh05/cat-server/pkg/security"
+)
+
+// middlewareOptions holds the network policy applied by addMiddleware
+type middlewareOptions struct {
+	clientIPs *security.ClientIPResolver
+	settings  atomic.Pointer[runtimeSettings] // IP filter and rate limiter, swapped on reload
+	bans      *security.BanList
+	inFlight  chan struct{}           // semaphore, nil when concurrency is unlimited
+	headers   *security.HeaderPolicy  // nil when security headers are disabled
+	policy    *security.RequestPolicy // nil when no request policy is configured
+	requests  *metrics.RequestMetrics // nil when requests are not counted
+	sampler   *logSampler             // nil when every request is logged
+	ids       idgen.Generator         // request IDs assigned when the client sends none
+
+	slowRequests []slowRequestThreshold // latency thresholds for slow_request warnings
+	statsd       *metrics.StatsD        // nil when metrics are not sent to StatsD
+	routes       map[string]bool        // route names tagged in StatsD metrics
+
+	recovery       bool          // recover from handler panics
+	cors           bool          // send CORS headers and answer preflights
+	requestTimeout time.Duration // handler timeout, 0 when disabled
+
+	compression         []string // response encodings by preference, empty when disabled
+	compressionMinBytes int      // smallest response body compressed
+
+	maxURLLength  int   // limit on the raw request URI
+	maxPathLength int   // limit on the decoded URL path
+	maxBodyBytes  int64 // limit on request bodies
+}
+
+// newMiddlewareOptions builds the client IP resolver and IP filter from
+// configuration
+func newMiddlewareOptions(cfg *config.Config) (*middlewareOptions, error) {
+	clientIPs, err := security.NewClientIPResolver(cfg.Security.TrustedProxies)
+	if err != nil {
+		return nil, err
+	}
+	settings, err := newRuntimeSettings(cfg, nil)
+	if err != nil {
+		return nil, err
+	}
+	opts := &middlewareOptions{
+		clientIPs: clientIPs,
+		bans:      security.NewBanList(cfg.Security.BanThreshold, cfg.Security.BanWindow, cfg.Security.BanCooldown),
+		sampler:   newLogSampler(cfg),
+		ids:       idgen.Default,
+
+		slowRequests: newSlowRequestThresholds(cfg),
+
+		recovery:       cfg.Security.EnableRecovery,
+		cors:           cfg.Security.EnableCORS,
+		requestTimeout: cfg.Security.RequestTimeout,
+
+		compression:         cfg.Server.Compression,
+		compressionMinBytes: cfg.Server.CompressionMinBytes,
+
+		maxURLLength:  cfg.Server.MaxURLLength,
+		maxPathLength: cfg.Security.MaxPathLength,
+		maxBodyBytes:  cfg.Server.MaxBodyBytes,
+	}
+	opts.settings.Store(settings)
+	if cfg.Security.EnableSecurityHeaders {
+		opts.headers = &security.HeaderPolicy{
+			ContentSecurityPolicy:     cfg.Security.Headers.ContentSecurityPolicy,
+			CrossOriginResourcePolicy: cfg.Security.Headers.CrossOriginResourcePolicy,
+			HSTSMaxAge:                cfg.Security.Headers.HSTSMaxAge,
+			HSTSIncludeSubdomains:     cfg.Security.Headers.HSTSIncludeSubdomains,
+		}
+	}
+	if cfg.Server.MaxConcurrentRequests > 0 {
+		opts.inFlight = make(chan struct{}, cfg.Server.MaxConcurrentRequests)
+	}
+	if cfg.Security.RequestPolicyFile != "" {
+		if opts.policy, err = security.LoadRequestPolicy(cfg.Security.RequestPolicyFile); err != nil {
+			return nil, err
+		}
+	}
+	return opts, nil
+}
+
+// banOnSecurityEvents feeds blocked security events into the ban list.
+// Rejections by the IP filter, the rate limiter and the ban list itself are
+// not counted, so a ban lasts exactly one cooldown.
+func banOnSecurityEvents(logger *logging.Logger, bans *security.BanList) {
+	if !bans.Enabled() {
+		return
+	}
+	logger.SetSecurityEventHook(func(event, remoteAddr string, blocked bool) {
+		if !blocked || event == "ip_denied" || event == "ip_banned" || event == "rate_limited" {
+			return
+		}
+		addr, err := netip.ParseAddr(remoteAddr)
+		if err != nil {
+			return
+		}
+		if until, banned := bans.Record(addr); banned {
+			logger.Warn("client banned", "client_ip", addr.String(), "until", until, "last_event", event)
+		}
+	})
+}
+
+// ceilSeconds rounds a duration up to whole seconds for HTTP headers
+func ceilSeconds(d time.Duration) int {
+	return int((d + time.Second - 1) / time.Second)
+}
+
+// maxLoggedPathLength bounds how much of an oversized path is logged
+const maxLoggedPathLength = 256
+
+// truncatePath shortens an oversized path for logging
+func truncatePath(p string) string {
+	if len(p) <= maxLoggedPathLength {
+		return p
+	}
+	return p[:maxLoggedPathLength] + "..."
+}
+
+// requestClientIP returns the resolved client address of a request for
+// security event logging
+func requestClientIP(r *http.Request) string {
+	if addr, ok := security.ClientIPFromContext(r.Context()); ok {
+		return addr.String()
+	}
+	return clientIP(r)
+}
+
+// addMiddleware wraps handler in the middleware chain. From the outside in:
+// panic recovery, request IDs, request logging, slow request warnings,
+// StatsD metrics, the network and request policy, the method allowlist,
+// CORS, response compression and the request timeout.
+func addMiddleware(handler http.Handler, opts *middlewareOptions, logger *logging.Logger) http.Handler {
+	var chain []cathttp.Middleware
+	if opts.recovery {
+		chain = append(chain, cathttp.RecoveryMiddleware(logger))
+	}
+	chain = append(chain,
+		requestIDMiddleware(opts, logger),
+		loggingMiddleware(logger, opts.requests, opts.sampler),
+		slowRequestMiddleware(opts.slowRequests, logger),
+		statsDMiddleware(opts.statsd, opts.routes),
+		securityMiddleware(opts, logger),
+		cathttp.MethodMiddleware(http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodOptions),
+	)
+	if opts.cors {
+		chain = append(chain, cathttp.CORSMiddleware)
+	}
+	if len(opts.compression) > 0 {
+		chain = append(chain, cathttp.CompressionMiddleware(opts.compression, opts.compressionMinBytes))
+	}
+	if opts.requestTimeout > 0 {
+		chain = append(chain, cathttp.TimeoutMiddleware(opts.requestTimeout))
+	}
+	return cathttp.ChainMiddleware(chain...)(handler)
+}
+
+// isProbe reports whether path is one of the health probes, which are
+// exempt from authentication, rate limits, load shedding and usage quotas
+func isProbe(path string) bool {
+	return path == "/health" || path == "/healthz" || path == "/readyz"
+}
+
+// requestIDHeader carries the request ID in both directions, so clients
+// and proxies can correlate their logs with the server's
+const requestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength bounds request IDs accepted from clients
+const maxRequestIDLength = 128
+
+// requestIDMiddleware resolves the client address, honouring
+// X-Forwarded-For from trusted proxies, and assigns the request an ID: the
+// client's X-Request-ID when it is well-formed, or a new one. The ID is
+// returned in the response, stored in the context and added to a
+// request-scoped logger so downstream log lines can be correlated.
+func requestIDMiddleware(opts *middlewareOptions, logger *logging.Logger) cathttp.Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			client := opts.clientIPs.ClientIP(r)
+			ip := client.String()
+			if !client.IsValid() {
+				ip = clientIP(r)
+			}
+
+			requestID := r.Header.Get(requestIDHeader)
+			if !validRequestID(requestID) {
+				requestID = opts.ids.NewID()
+			}
+			w.Header().Set(requestIDHeader, requestID)
+
+			reqLogger := logger.ForRequest(requestID, ip, r.URL.Path)
+			ctx := security.NewClientIPContext(r.Context(), client)
+			ctx = logging.NewRequestIDContext(ctx, requestID)
+			next.ServeHTTP(w, r.WithContext(logging.NewContext(ctx, reqLogger)))
+		})
+	}
+}
+
+// validRequestID reports whether a client-supplied request ID is safe to
+// log and echo: short and limited to letters, digits and -_.:
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for _, c := range id {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		case c == '-', c == '_', c == '.', c == ':':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// loggingMiddleware logs each request and its response status, duration,
+// size and error code, and records them in requests unless it is nil. With a sampler,
+// both lines are written once the response is done and only for the
+// requests the sampler keeps, unless debug logging is enabled.
+func loggingMiddleware(logger *logging.Logger, requests *metrics.RequestMetrics, sampler *logSampler) cathttp.Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			reqLogger := logging.FromContext(r.Context(), logger)
+			sampled := sampler != nil && !reqLogger.IsDebugEnabled()
+			if !sampled {
+				reqLogger.LogHTTPRequest(r.Method, r.URL.Path, r.UserAgent(), r.RemoteAddr)
+			}
+
+			// Wrap response writer to capture status code and size
+			wrapper := &responseWriter{ResponseWriter: w, statusCode: http.StatusOK}
+			next.ServeHTTP(wrapper, r)
+
+			duration := time.Since(start)
+			if !sampled || sampler.keep(wrapper.statusCode, wrapper.bytes) {
+				if sampled {
+					reqLogger.LogHTTPRequest(r.Method, r.URL.Path, r.UserAgent(), r.RemoteAddr)
+				}
+				if code := wrapper.Header().Get(cathttp.ErrorCodeHeader); code != "" {
+					reqLogger = reqLogger.With("error_code", code)
+				}
+				reqLogger.LogHTTPResponse(r.Method, r.URL.Path, wrapper.statusCode, duration, wrapper.bytes)
+			}
+			if requests != nil {
+				requests.Record(wrapper.statusCode, duration)
+			}
+		})
+	}
+}
+
+// securityMiddleware applies the security headers, IP filter, ban list,
+// request policy, rate limits, size limits, traversal checks and load
+// shedding
+func securityMiddleware(opts *middlewareOptions, logger *logging.Logger) cathttp.Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if opts.headers != nil {
+				opts.headers.Apply(w, r)
+			}
+
+			// Refuse clients outside the allowed or inside the denied ranges
+			settings := opts.settings.Load()
+			client, _ := security.ClientIPFromContext(r.Context())
+			if !settings.ipFilter.Allowed(client) {
+				logging.FromContext(r.Context(), logger).LogSecurityEvent("ip_denied", r.URL.Path, requestClientIP(r), r.UserAgent(), true)
+				cathttp.WriteProblemCode(w, r, cathttp.CodeIPDenied, "")
+				return
+			}
+
+			// Refuse clients banned for repeated blocked requests
+			if until, banned := opts.bans.Banned(client); banned {
+				logging.FromContext(r.Context(), logger).LogSecurityEvent("ip_banned", r.URL.Path, requestClientIP(r), r.UserAgent(), true)
+				w.Header().Set("Retry-After", strconv.Itoa(ceilSeconds(time.Until(until))))
+				cathttp.WriteProblemCode(w, r, cathttp.CodeClientBanned, "")
+				return
+			}
+
+			// Block or tag requests by their User-Agent and Referer, e.g. scrapers
+			// and hotlinks. Tags are added to downstream log lines.
+			if opts.policy != nil {
+				rule, tags := opts.policy.Evaluate(r)
+				reqLogger := logging.FromContext(r.Context(), logger)
+				if len(tags) > 0 {
+					reqLogger = reqLogger.With("policy_tags", tags)
+					r = r.WithContext(logging.NewContext(r.Context(), reqLogger))
+					reqLogger.LogSecurityEvent("request_policy_tagged", r.URL.Path, requestClientIP(r), r.UserAgent(), false)
+				}
+				if rule != nil {
+					reqLogger.With("policy_rule", rule.Name).LogSecurityEvent("request_policy_blocked", r.URL.Path, requestClientIP(r), r.UserAgent(), true)
+					cathttp.WriteProblemCode(w, r, cathttp.CodeRequestBlocked, "")
+					return
+				}
+			}
+
+			// Apply per-client and global rate limits; health probes are exempt
+			if settings.limiter != nil && !isProbe(r.URL.Path) {
+				decision := settings.limiter.Allow(client)
+				w.Header().Set("X-RateLimit-Limit", strconv.Itoa(decision.Limit))
+				w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(decision.Remaining))
+				w.Header().Set("X-RateLimit-Reset", strconv.Itoa(ceilSeconds(decision.Reset)))
+				if !decision.Allowed {
+					logging.FromContext(r.Context(), logger).LogSecurityEvent("rate_limited", r.URL.Path, requestClientIP(r), r.UserAgent(), true)
+					w.Header().Set("Retry-After", strconv.Itoa(ceilSeconds(decision.RetryAfter)))
+					cathttp.WriteProblemCode(w, r, cathttp.CodeRateLimited, "")
+					return
+				}
+			}
+
+			// Refuse oversized URLs and bodies before any handler parses them
+			if len(r.RequestURI) > opts.maxURLLength || len(r.URL.Path) > opts.maxPathLength {
+				logging.FromContext(r.Context(), logger).LogSecurityEvent("url_too_long", truncatePath(r.URL.Path), requestClientIP(r), r.UserAgent(), true)
+				cathttp.WriteProblem(w, r, http.StatusRequestURITooLong, "")
+				return
+			}
+			if r.ContentLength > opts.maxBodyBytes {
+				cathttp.WriteProblem(w, r, http.StatusRequestEntityTooLarge, "")
+				return
+			}
+			r.Body = http.MaxBytesReader(w, r.Body, opts.maxBodyBytes)
+
+			// Reject traversal in the URL path or path-carrying query parameters,
+			// including encoded, Unicode and Windows-style forms
+			if value, found := security.FindTraversal(r, "path", "a", "b"); found {
+				logging.FromContext(r.Context(), logger).LogSecurityEvent("path_traversal", value, requestClientIP(r), r.UserAgent(), true)
+				cathttp.WriteProblemCode(w, r, cathttp.CodePathTraversal, "Invalid path")
+				return
+			}
+
+			// Shed load instead of queueing when too many requests are in flight;
+			// health probes are exempt
+			if opts.inFlight != nil && !isProbe(r.URL.Path) {
+				select {
+				case opts.inFlight <- struct{}{}:
+					defer func() { <-opts.inFlight }()
+				default:
+					logging.FromContext(r.Context(), logger).Warn("request shed, too many concurrent requests", "limit", cap(opts.inFlight))
+					w.Header().Set("Retry-After", "1")
+					cathttp.WriteProblemCode(w, r, cathttp.CodeOverloaded, "")
+					return
+				}
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// clientIP returns the client IP address without the port
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// responseWriter wraps http.ResponseWriter to capture status code and
+// body size
+type responseWriter struct {
+	http.ResponseWriter
+	statusCode int
+	bytes      int64
+}
+
+func (rw *responseWriter) WriteHeader(code int) {
+	rw.statusCode = code
+	rw.ResponseWriter.WriteHeader(code)
+}
+
+func (rw *responseWriter) Write(p []byte) (int, error) {
+	n, err := rw.ResponseWriter.Write(p)
+	rw.bytes += int64(n)
+	return n, err
+}
+
+// ReadFrom passes files on to the server, which sends them with sendfile
+func (rw *responseWriter) ReadFrom(src io.Reader) (int64, error) {
+	n, err := io.Copy(rw.ResponseWriter, src)
+	rw.bytes += n
+	return n, err
+}
+
+// Unwrap exposes the underlying writer to http.ResponseController
+func (rw *responseWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
@@ -0,0 +1,274 @@
+package cat
+
+import (
+	"crypto/rand"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/sh05/cat-server/internal/config"
+	cathttp "github.com/sh05/cat-server/pkg/infrastructure/http"
+	"github.com/sh05/cat-server/pkg/infrastructure/logging"
+	"github.com/sh05/cat-server/pkg/security"
+	"github.com/sh05/cat-server/pkg/server"
+)
+
+// Cookies used by the OIDC login flow
+const (
+	sessionCookieName    = "cat_session"
+	loginStateCookieName = "cat_oidc_state"
+	// loginStateTTL bounds how long a user may take at the provider's login page
+	loginStateTTL = 10 * time.Minute
+)
+
+// oidcLogin runs the authorization-code flow for browser users and issues
+// signed session cookies
+type oidcLogin struct {
+	provider *security.OIDCProvider
+	sessions *security.SessionCodec
+	ttl      time.Duration
+	secure   bool
+}
+
+// loginSession is stored in the session cookie
+type loginSession struct {
+	Subject string `json:"sub"`
+	Issuer  string `json:"iss"`
+	Email   string `json:"email,omitempty"`
+	Name    string `json:"name,omitempty"`
+}
+
+// loginState ties a callback to the browser that started the login
+type loginState struct {
+	State    string `json:"state"`
+	Nonce    string `json:"nonce"`
+	ReturnTo string `json:"return_to"`
+}
+
+// newOIDCLogin creates the login flow from configuration. Without a session
+// secret a random key is used, so sessions do not survive a restart.
+func newOIDCLogin(cfg config.OIDCConfig, logger *logging.Logger) (*oidcLogin, error) {
+	provider, err := security.NewOIDCProvider(security.OIDCConfig{
+		IssuerURL:    cfg.IssuerURL,
+		ClientID:     cfg.ClientID,
+		ClientSecret: cfg.ClientSecret,
+		RedirectURL:  cfg.RedirectURL,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	key := []byte(cfg.SessionSecret)
+	if len(key) == 0 {
+		key = make([]byte, 32)
+		if _, err := rand.Read(key); err != nil {
+			return nil, err
+		}
+		logger.Warn("no session secret configured, login sessions will not survive a restart")
+	}
+	sessions, err := security.NewSessionCodec(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return &oidcLogin{
+		provider: provider,
+		sessions: sessions,
+		ttl:      cfg.SessionTTL,
+		secure:   strings.HasPrefix(cfg.RedirectURL, "https://"),
+	}, nil
+}
+
+// session returns the claims of a valid session cookie
+func (l *oidcLogin) session(r *http.Request) (security.Claims, bool) {
+	cookie, err := r.Cookie(sessionCookieName)
+	if err != nil {
+		return nil, false
+	}
+	var session loginSession
+	if err := l.sessions.Decode(cookie.Value, &session); err != nil {
+		return nil, false
+	}
+
+	claims := security.Claims{"sub": session.Subject, "iss": session.Issuer}
+	if session.Email != "" {
+		claims["email"] = session.Email
+	}
+	if session.Name != "" {
+		claims["name"] = session.Name
+	}
+	return claims, true
+}
+
+// setCookie writes an HttpOnly cookie scoped to the whole server
+func (l *oidcLogin) setCookie(w http.ResponseWriter, name, value string, ttl time.Duration) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     "/",
+		MaxAge:   int(ttl.Seconds()),
+		HttpOnly: true,
+		Secure:   l.secure,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+// clearCookie expires a cookie
+func (l *oidcLogin) clearCookie(w http.ResponseWriter, name string) {
+	http.SetCookie(w, &http.Cookie{Name: name, Value: "", Path: "/", MaxAge: -1, HttpOnly: true, Secure: l.secure})
+}
+
+// wantsHTML reports whether a request comes from a browser navigating to a
+// page rather than from an API client
+func wantsHTML(r *http.Request) bool {
+	return r.Method == http.MethodGet && strings.Contains(r.Header.Get("Accept"), "text/html")
+}
+
+// localReturnPath only allows redirects back to a path on this server
+func localReturnPath(target string) string {
+	if !strings.HasPrefix(target, "/") || strings.HasPrefix(target, "//") || strings.HasPrefix(target, "/\\") {
+		return "/"
+	}
+	return target
+}
+
+// loginRedirect sends a browser to the login endpoint, returning to the
+// current URL afterwards
+func loginRedirect(w http.ResponseWriter, r *http.Request) {
+	http.Redirect(w, r, "/auth/login?return_to="+url.QueryEscape(r.URL.RequestURI()), http.StatusFound)
+}
+
+// registerOIDCHandlers registers the login, callback and logout endpoints
+func registerOIDCHandlers(mux *server.Registry, login *oidcLogin, logger *logging.Logger) {
+	mux.HandleFunc("GET /auth/login", func(w http.ResponseWriter, r *http.Request) {
+		reqLogger := logging.FromContext(r.Context(), logger)
+
+		state, err := security.RandomToken()
+		if err != nil {
+			reqLogger.LogError(err, "failed to generate login state")
+			cathttp.WriteProblem(w, r, http.StatusInternalServerError, "")
+			return
+		}
+		nonce, err := security.RandomToken()
+		if err != nil {
+			reqLogger.LogError(err, "failed to generate login nonce")
+			cathttp.WriteProblem(w, r, http.StatusInternalServerError, "")
+			return
+		}
+
+		authURL, err := login.provider.AuthCodeURL(r.Context(), state, nonce)
+		if err != nil {
+			reqLogger.LogError(err, "failed to build login URL")
+			cathttp.WriteProblem(w, r, http.StatusBadGateway, "")
+			return
+		}
+
+		value, err := login.sessions.Encode(loginState{
+			State:    state,
+			Nonce:    nonce,
+			ReturnTo: localReturnPath(r.URL.Query().Get("return_to")),
+		}, loginStateTTL)
+		if err != nil {
+			reqLogger.LogError(err, "failed to encode login state")
+			cathttp.WriteProblem(w, r, http.StatusInternalServerError, "")
+			return
+		}
+		login.setCookie(w, loginStateCookieName, value, loginStateTTL)
+		http.Redirect(w, r, authURL, http.StatusFound)
+	})
+
+	mux.HandleFunc("GET /auth/callback", func(w http.ResponseWriter, r *http.Request) {
+		reqLogger := logging.FromContext(r.Context(), logger)
+		query := r.URL.Query()
+
+		if errCode := query.Get("error"); errCode != "" {
+			reqLogger.LogAuditEvent("login_failed", "error", errCode, "description", query.Get("error_description"))
+			cathttp.WriteProblem(w, r, http.StatusUnauthorized, "Login failed")
+			return
+		}
+
+		// The state must match the cookie set by /auth/login in this browser
+		var state loginState
+		cookie, err := r.Cookie(loginStateCookieName)
+		if err == nil {
+			err = login.sessions.Decode(cookie.Value, &state)
+		}
+		if err != nil || query.Get("state") == "" || query.Get("state") != state.State {
+			reqLogger.LogSecurityEvent("oidc_state_mismatch", r.URL.Path, requestClientIP(r), r.UserAgent(), true)
+			cathttp.WriteProblem(w, r, http.StatusBadRequest, "Invalid login state")
+			return
+		}
+		login.clearCookie(w, loginStateCookieName)
+
+		claims, err := login.provider.Exchange(r.Context(), query.Get("code"), state.Nonce)
+		if err != nil {
+			reqLogger.LogError(err, "failed to complete login")
+			reqLogger.LogAuditEvent("login_failed", "error", err)
+			cathttp.WriteProblem(w, r, http.StatusUnauthorized, "Login failed")
+			return
+		}
+
+		session := loginSession{Subject: claims.Subject(), Issuer: claims.Issuer()}
+		session.Email, _ = claims["email"].(string)
+		session.Name, _ = claims["name"].(string)
+		value, err := login.sessions.Encode(session, login.ttl)
+		if err != nil {
+			reqLogger.LogError(err, "failed to encode session")
+			cathttp.WriteProblem(w, r, http.StatusInternalServerError, "")
+			return
+		}
+		login.setCookie(w, sessionCookieName, value, login.ttl)
+
+		reqLogger.LogAuditEvent("login", "user", session.Subject, "issuer", session.Issuer)
+		http.Redirect(w, r, state.ReturnTo, http.StatusFound)
+	})
+
+	logout := func(w http.ResponseWriter, r *http.Request) {
+		login.clearCookie(w, sessionCookieName)
+		http.Redirect(w, r, "/", http.StatusFound)
+	}
+	mux.HandleFunc("GET /auth/logout", logout)
+	mux.HandleFunc("POST /auth/logout", logout)
+
+	mux.Describe("GET /auth/login", server.RouteDoc{Summary: "Start the OIDC login flow", Query: []server.Param{{Name: "return_to"}}})
+	mux.Describe("GET /auth/callback", server.RouteDoc{Summary: "OIDC login callback", Query: []server.Param{{Name: "code"}, {Name: "state"}}})
+	mux.Describe("GET /auth/logout", server.RouteDoc{Summary: "End the login session"})
+	mux.Describe("POST /auth/logout", server.RouteDoc{Summary: "End the login session"})
+}
+
+// protectCSRF gives every browser a double-submit CSRF cookie and rejects
+// unsafe requests that carry a session cookie without echoing the token.
+// Clients authenticating with bearer tokens or Basic credentials send no
+// session cookie and are unaffected, as are the exempt /auth/, /admin/ and
+// /health/ paths.
+func protectCSRF(next http.Handler, login *oidcLogin, logger *logging.Logger) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		reqLogger := logging.FromContext(r.Context(), logger)
+
+		if _, err := r.Cookie(security.CSRFCookieName); err != nil {
+			token, err := security.NewCSRFToken()
+			if err != nil {
+				reqLogger.LogError(err, "failed to generate csrf token")
+				cathttp.WriteProblem(w, r, http.StatusInternalServerError, "")
+				return
+			}
+			http.SetCookie(w, &http.Cookie{
+				Name:     security.CSRFCookieName,
+				Value:    token,
+				Path:     "/",
+				Secure:   login.secure,
+				SameSite: http.SameSiteStrictMode,
+			})
+		}
+
+		if !security.CSRFSafeMethod(r.Method) && !authExempt(r.URL.Path) {
+			if _, err := r.Cookie(sessionCookieName); err == nil && !security.ValidCSRFToken(r) {
+				reqLogger.LogSecurityEvent("csrf_rejected", r.URL.Path, requestClientIP(r), r.UserAgent(), true)
+				cathttp.WriteProblem(w, r, http.StatusForbidden, "")
+				return
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
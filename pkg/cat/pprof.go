@@ -0,0 +1,52 @@
+package cat
+
+import (
+	"expvar"
+	"fmt"
+	"net/http"
+	"net/http/pprof"
+
+	"github.com/sh05/cat-server/internal/config"
+	"github.com/sh05/cat-server/pkg/infrastructure/logging"
+	"github.com/sh05/cat-server/pkg/server"
+)
+
+// registerPprofHandlers registers the runtime profiling endpoints and the
+// expvar variables, behind the admin token when one is configured. The
+// command line is not served by either, as flags may carry secrets.
+func registerPprofHandlers(mux *server.Registry, cfg *config.Config, logger *logging.Logger) {
+	protect := func(next http.HandlerFunc) http.HandlerFunc { return next }
+	if cfg.Security.AdminToken != "" {
+		protect = func(next http.HandlerFunc) http.HandlerFunc {
+			return requireAdminToken(cfg, logger, next)
+		}
+	}
+
+	mux.HandleFunc("GET /debug/pprof/", protect(pprof.Index))
+	mux.HandleFunc("GET /debug/pprof/profile", protect(pprof.Profile))
+	mux.HandleFunc("GET /debug/pprof/symbol", protect(pprof.Symbol))
+	mux.HandleFunc("POST /debug/pprof/symbol", protect(pprof.Symbol))
+	mux.HandleFunc("GET /debug/pprof/trace", protect(pprof.Trace))
+	mux.Describe("GET /debug/pprof/", server.RouteDoc{Summary: "Runtime profiles for go tool pprof", Produces: []string{"text/html", "application/octet-stream"}})
+	mux.HandleFunc("GET /debug/vars", protect(serveExpvars))
+	mux.Describe("GET /debug/vars", server.RouteDoc{Summary: "Runtime variables published with expvar", Produces: []string{"application/json"}})
+}
+
+// serveExpvars writes the expvar variables like expvar.Handler, leaving out
+// the cmdline variable
+func serveExpvars(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	fmt.Fprint(w, "{\n")
+	first := true
+	expvar.Do(func(kv expvar.KeyValue) {
+		if kv.Key == "cmdline" {
+			return
+		}
+		if !first {
+			fmt.Fprint(w, ",\n")
+		}
+		first = false
+		fmt.Fprintf(w, "%q: %s", kv.Key, kv.Value)
+	})
+	fmt.Fprint(w, "\n}\n")
+}
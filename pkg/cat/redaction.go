@@ -0,0 +1,71 @@
+package cat
+
+import (
+	"net/http"
+	"regexp"
+
+	"github.com/sh05/cat-server/internal/config"
+	"github.com/sh05/cat-server/pkg/application/services"
+	"github.com/sh05/cat-server/pkg/infrastructure/logging"
+	"github.com/sh05/cat-server/pkg/security"
+)
+
+// redactionPolicy decides which callers get redacted file contents. A nil
+// policy disables redaction.
+type redactionPolicy struct {
+	redactor    *security.Redactor
+	bypassScope string
+}
+
+// newRedactionPolicy creates the policy from the configuration, or returns
+// nil when redaction is disabled. The expressions were checked by
+// config.Validate.
+func newRedactionPolicy(cfg *config.Config) *redactionPolicy {
+	redaction := cfg.Security.Redaction
+	if !redaction.Enabled || len(redaction.Files) == 0 {
+		return nil
+	}
+
+	var keys, pattern *regexp.Regexp
+	if redaction.Keys != "" {
+		keys = regexp.MustCompile(redaction.Keys)
+	}
+	if redaction.Pattern != "" {
+		pattern = regexp.MustCompile(redaction.Pattern)
+	}
+	return &redactionPolicy{
+		redactor:    security.NewRedactor(redaction.Files, keys, pattern),
+		bypassScope: redaction.BypassScope,
+	}
+}
+
+// redactorFor returns the redactor for r's services: the policy's, or one that
+// redacts nothing but logs unredacted reads of matching files when the
+// caller's token grants the bypass scope. It returns nil when redaction is
+// disabled.
+func (p *redactionPolicy) redactorFor(r *http.Request, logger *logging.Logger) services.Redactor {
+	if p == nil {
+		return nil
+	}
+	if claims, ok := security.ClaimsFromContext(r.Context()); ok && p.bypassScope != "" && claims.HasScope(p.bypassScope) {
+		return auditingRedactor{redactor: p.redactor, logger: logger}
+	}
+	return p.redactor
+}
+
+// auditingRedactor serves matching files unredacted, logging each read
+type auditingRedactor struct {
+	redactor *security.Redactor
+	logger   *logging.Logger
+}
+
+func (a auditingRedactor) Matches(filename string) bool {
+	if a.redactor.Matches(filename) {
+		a.logger.LogAuditEvent("unredacted_read", "filename", filename)
+	}
+	return false
+}
+
+func (a auditingRedactor) Redact(content string) string {
+	return content
+}
@@ -0,0 +1,176 @@
+package cat
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"reflect"
+	"slices"
+	"sync"
+
+	"github.com/sh05/cat-server/internal/config"
+	cathttp "github.com/sh05/cat-server/pkg/infrastructure/http"
+	"github.com/sh05/cat-server/pkg/infrastructure/logging"
+	"github.com/sh05/cat-server/pkg/security"
+	"github.com/sh05/cat-server/pkg/server"
+)
+
+// runtimeSettings is the snapshot of reloadable settings consumed by the
+// middleware. A reload builds a new snapshot and swaps it in atomically, so
+// requests in flight finish with the settings they started with.
+type runtimeSettings struct {
+	cfg      *config.Config
+	ipFilter *security.IPFilter
+	limiter  *security.RateLimiter // nil when rate limiting is disabled
+}
+
+// newRuntimeSettings builds the snapshot for cfg. An unchanged rate limiter
+// is carried over from previous, which may be nil, so a reload does not
+// refill every client's bucket.
+func newRuntimeSettings(cfg *config.Config, previous *runtimeSettings) (*runtimeSettings, error) {
+	ipFilter, err := security.NewIPFilter(cfg.Security.AllowedCIDRs, cfg.Security.DeniedCIDRs)
+	if err != nil {
+		return nil, err
+	}
+	settings := &runtimeSettings{cfg: cfg, ipFilter: ipFilter}
+
+	if cfg.Security.EnableRateLimit {
+		limits := cfg.Security.RateLimit
+		if previous != nil && previous.limiter != nil && previous.cfg.Security.RateLimit == limits {
+			settings.limiter = previous.limiter
+		} else {
+			settings.limiter = security.NewRateLimiter(limits.RPS, limits.Burst, limits.GlobalRPS, limits.GlobalBurst)
+		}
+	}
+	return settings, nil
+}
+
+// reloader re-reads the configuration and applies the log level, rate
+// limits, IP allow and deny lists and base directory without restarting. Other changed settings are
+// reported as requiring a restart.
+type reloader struct {
+	mu     sync.Mutex // serializes reloads
+	load   func() (*config.Config, error)
+	logger *logging.Logger
+	// svc holds the base repository and the caches, which are keyed by
+	// path and purged when the base directory changes
+	svc *appServices
+	// watcher follows the base directory; nil when disabled
+	watcher *cacheWatcher
+	opts    *middlewareOptions
+}
+
+// reloadResult describes an applied reload
+type reloadResult struct {
+	Changed         []string `json:"changed"`
+	RestartRequired bool     `json:"restart_required"`
+}
+
+// newReloader creates a reloader applying the configuration returned by load
+func newReloader(load func() (*config.Config, error), logger *logging.Logger, svc *appServices, watcher *cacheWatcher, opts *middlewareOptions) *reloader {
+	return &reloader{load: load, logger: logger, svc: svc, watcher: watcher, opts: opts}
+}
+
+// Reload loads and applies the configuration. On error nothing changes.
+func (r *reloader) Reload() (*reloadResult, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	next, err := r.load()
+	if err != nil {
+		return nil, err
+	}
+	current := r.opts.settings.Load()
+	previous := current.cfg
+
+	// Landlock only grants access to the base directory given at startup
+	if previous.Security.Sandbox && next.FileSystem.BaseDirectory != previous.FileSystem.BaseDirectory {
+		return nil, errors.New("the base directory cannot change while sandboxed")
+	}
+
+	// Apply only the reloadable settings of next
+	applied := *previous
+	applied.Logging.Level = next.Logging.Level
+	applied.FileSystem.BaseDirectory = next.FileSystem.BaseDirectory
+	applied.Security.EnableRateLimit = next.Security.EnableRateLimit
+	applied.Security.RateLimit = next.Security.RateLimit
+	applied.Security.AllowedCIDRs = next.Security.AllowedCIDRs
+	applied.Security.DeniedCIDRs = next.Security.DeniedCIDRs
+
+	settings, err := newRuntimeSettings(&applied, current)
+	if err != nil {
+		return nil, err
+	}
+
+	// Keep a level set at runtime unless the configured level changed
+	if applied.Logging.Level != previous.Logging.Level {
+		r.logger.SetLevel(parseLogLevel(applied.Logging.Level))
+	}
+	r.svc.files.SetBasePath(applied.FileSystem.BaseDirectory)
+	if applied.FileSystem.BaseDirectory != previous.FileSystem.BaseDirectory {
+		if r.svc.contents != nil {
+			r.svc.contents.Purge()
+		}
+		if r.svc.listings != nil {
+			r.svc.listings.InvalidateAll()
+		}
+		if r.svc.index != nil {
+			r.svc.index.InvalidateAll()
+		}
+		if r.watcher != nil {
+			if err := r.watcher.watch(applied.FileSystem.BaseDirectory); err != nil {
+				r.logger.LogError(err, "failed to watch the new base directory for changes")
+			}
+		}
+	}
+	r.opts.settings.Store(settings)
+
+	result := &reloadResult{
+		Changed:         changedSettings(previous, &applied),
+		RestartRequired: !reflect.DeepEqual(&applied, next),
+	}
+	r.logger.Info("configuration reloaded", "changed", result.Changed)
+	if result.RestartRequired {
+		r.logger.Warn("some changed settings only take effect after a restart")
+	}
+	return result, nil
+}
+
+// changedSettings names the reloadable settings that differ, using the
+// config file keys
+func changedSettings(previous, next *config.Config) []string {
+	changed := []string{}
+	if previous.Logging.Level != next.Logging.Level {
+		changed = append(changed, "logging.level")
+	}
+	if previous.FileSystem.BaseDirectory != next.FileSystem.BaseDirectory {
+		changed = append(changed, "filesystem.base_directory")
+	}
+	if previous.Security.EnableRateLimit != next.Security.EnableRateLimit || previous.Security.RateLimit != next.Security.RateLimit {
+		changed = append(changed, "security.rate_limit")
+	}
+	if !slices.Equal(previous.Security.AllowedCIDRs, next.Security.AllowedCIDRs) {
+		changed = append(changed, "security.allowed_cidrs")
+	}
+	if !slices.Equal(previous.Security.DeniedCIDRs, next.Security.DeniedCIDRs) {
+		changed = append(changed, "security.denied_cidrs")
+	}
+	return changed
+}
+
+// registerReloadHandler registers the admin endpoint reloading the
+// configuration
+func registerReloadHandler(mux *server.Registry, cfg *config.Config, reloader *reloader, logger *logging.Logger) {
+	mux.HandleFunc("POST /admin/reload", requireAdminToken(cfg, logger, func(w http.ResponseWriter, r *http.Request) {
+		result, err := reloader.Reload()
+		if err != nil {
+			logging.FromContext(r.Context(), logger).LogError(err, "configuration reload failed")
+			cathttp.WriteProblem(w, r, http.StatusUnprocessableEntity, "Reload failed: "+err.Error())
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(result)
+	}))
+	mux.Describe("POST /admin/reload", server.RouteDoc{Summary: "Reload the configuration", Produces: []string{"application/json"}})
+}
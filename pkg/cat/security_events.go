@@ -0,0 +1,42 @@
+package cat
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/sh05/cat-server/internal/config"
+	"github.com/sh05/cat-server/pkg/application/services"
+	cathttp "github.com/sh05/cat-server/pkg/infrastructure/http"
+	"github.com/sh05/cat-server/pkg/infrastructure/logging"
+	"github.com/sh05/cat-server/pkg/server"
+)
+
+// registerSecurityEventsHandler registers the admin endpoint listing the
+// recent security events, such as path traversal attempts and denied
+// clients, e.g. /admin/security-events?since=15m
+func registerSecurityEventsHandler(mux *server.Registry, cfg *config.Config, events *logging.SecurityEventBuffer, logger *logging.Logger) {
+	mux.HandleFunc("GET /admin/security-events", requireAdminToken(cfg, logger, func(w http.ResponseWriter, r *http.Request) {
+		since, err := services.ParseSince(r.URL.Query().Get("since"), time.Now())
+		if err != nil {
+			cathttp.WriteProblemCode(w, r, cathttp.CodeInvalidLogQuery, err.Error())
+			return
+		}
+
+		response := struct {
+			Capacity int                     `json:"capacity"`
+			Events   []logging.SecurityEvent `json:"events"`
+		}{
+			Capacity: events.Capacity(),
+			Events:   events.Since(since),
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(response)
+	}))
+	mux.Describe("GET /admin/security-events", server.RouteDoc{
+		Summary:  "Recent security events, oldest first",
+		Query:    []server.Param{{Name: "since", Description: "RFC 3339 time or duration, e.g. 15m"}},
+		Produces: []string{"application/json"},
+	})
+}
@@ -0,0 +1,334 @@
+package cat
+
+import (
+	"net/http"
+	"runtime"
+
+	"github.com/sh05/cat-server/internal/buildinfo"
+	"github.com/sh05/cat-server/internal/config"
+	"github.com/sh05/cat-server/pkg/application/services"
+	"github.com/sh05/cat-server/pkg/domain/repositories"
+	"github.com/sh05/cat-server/pkg/infrastructure/cache"
+	"github.com/sh05/cat-server/pkg/infrastructure/filesystem"
+	"github.com/sh05/cat-server/pkg/infrastructure/logging"
+	"github.com/sh05/cat-server/pkg/infrastructure/metrics"
+	"github.com/sh05/cat-server/pkg/infrastructure/workpool"
+	"github.com/sh05/cat-server/pkg/interfaces/http/handlers"
+	"github.com/sh05/cat-server/pkg/server"
+)
+
+// appServices holds the application services shared by handlers and subcommands
+type appServices struct {
+	health    *services.HealthService
+	directory *services.DirectoryService
+	file      *services.FileService
+	search    *services.SearchService
+	archive   *services.ArchiveService
+	logs      *services.LogService
+	images    *services.ImageService
+	metrics   *metrics.Registry
+	usage     *metrics.UsageTracker
+	redaction *redactionPolicy
+	files     *filesystem.FileSystemRepositoryImpl // base repository, for reloading the base directory
+	contents  *filesystem.CachedRepository         // file content cache, nil when disabled
+	listings  *filesystem.CachedListingRepository  // directory listing cache, nil when disabled
+	index     *filesystem.IndexedRepository        // directory tree index, nil when disabled
+	workers   *workpool.Pool                       // scans and hashes files for searches and manifests
+	// securityEvents retains recent security events for /admin/security-events
+	securityEvents *logging.SecurityEventBuffer
+}
+
+// securityEventCapacity is the number of recent security events retained
+const securityEventCapacity = 1000
+
+// listingCacheEntries bounds the directory entries held by the listing
+// cache, across all directories
+const listingCacheEntries = 100_000
+
+// newAppServices wires the filesystem repository and application services
+func newAppServices(cfg *config.Config, logger *logging.Logger) *appServices {
+	// Initialize filesystem repository. The size limits of mounts and
+	// overrides are enforced by the rule repository, so the repositories
+	// underneath allow the largest of them.
+	maxFileSize := largestMaxFileSize(cfg)
+	baseRepo := filesystem.NewFileSystemRepository(cfg.FileSystem.BaseDirectory, maxFileSize)
+
+	// Initialize metrics registry shared by cache and index subsystems
+	metricsRegistry := metrics.NewRegistry()
+
+	// Answer listings and walks of large trees in the base directory from
+	// memory
+	baseFS := guardRepository(cfg, baseRepo)
+	var index *filesystem.IndexedRepository
+	if cfg.FileSystem.IndexMaxEntries > 0 {
+		index = filesystem.NewIndexedRepository(baseFS, cfg.FileSystem.IndexMaxEntries, cfg.FileSystem.IndexRefreshInterval, metricsRegistry.Cache("directory_index"))
+		baseFS = index
+	}
+
+	// Expose the contents of .zip and .tar.gz files as virtual directories,
+	// and the configured mounts as top-level directories
+	var fsRepo repositories.FileSystemRepository = filesystem.NewMountRepository(
+		filesystem.NewArchiveRepository(baseFS, maxFileSize),
+		newMounts(cfg, maxFileSize),
+	)
+
+	// Let concurrent requests for the same file or directory share one read
+	fsRepo = filesystem.NewCoalescingRepository(fsRepo, metricsRegistry.Cache("coalesced_reads"))
+
+	// Refuse to read large files into memory close to the memory limit
+	memoryGuard := newMemoryGuard(cfg)
+	if memoryGuard != nil {
+		fsRepo = filesystem.NewMemoryGuardedRepository(fsRepo, memoryGuard)
+	}
+
+	// Keep hot files in memory; the rules are still checked on every read
+	var contents *filesystem.CachedRepository
+	if cfg.FileSystem.ContentCacheBytes > 0 {
+		lru := cache.NewLRU(cfg.FileSystem.ContentCacheBytes, metricsRegistry.Cache("file_contents"))
+		contents = filesystem.NewCachedRepository(fsRepo, lru, cfg.FileSystem.ContentCacheEntryBytes)
+		fsRepo = contents
+	}
+
+	// Serve listings of large, busy directories from memory while unchanged
+	var listings *filesystem.CachedListingRepository
+	if cfg.FileSystem.ListingCacheTTL > 0 {
+		listings = filesystem.NewCachedListingRepository(fsRepo, cfg.FileSystem.ListingCacheTTL, listingCacheEntries, metricsRegistry.Cache("listings"))
+		fsRepo = listings
+	}
+
+	fsRepo = filesystem.NewRuleRepository(
+		fsRepo,
+		filesystem.PathRule{MaxFileSize: cfg.FileSystem.MaxFileSize},
+		newPathRules(cfg),
+	)
+
+	healthService := services.NewHealthService(fsRepo, logger, buildinfo.Get().Version)
+	healthService.SetMetricsRegistry(metricsRegistry)
+	// Report the same uptime in /health and /metrics
+	healthService.SetStartTime(metricsRegistry.StartTime())
+	healthService.SetReadOnly(cfg.FileSystem.ReadOnly)
+	healthService.SetDiskCheck(cfg.FileSystem.BaseDirectory, cfg.FileSystem.DiskWarningPercent, cfg.FileSystem.DiskCriticalPercent)
+	healthService.SetMemoryGuard(memoryGuard)
+
+	// Bound the goroutines of heavy requests across all of them
+	workerPoolSize := cfg.Server.WorkerPoolSize
+	if workerPoolSize == 0 {
+		workerPoolSize = runtime.GOMAXPROCS(0)
+	}
+	workers := workpool.New(workerPoolSize)
+
+	directoryService := services.NewDirectoryService(fsRepo, logger)
+	directoryService.SetWorkerPool(workers)
+	directoryService.SetContentTypeCache(cache.NewLRU(services.DefaultContentTypeCacheSize, metricsRegistry.Cache("content_types")))
+
+	searchService := services.NewSearchService(fsRepo, logger)
+	searchService.SetWorkerPool(workers)
+
+	imageService := services.NewImageService(fsRepo, logger)
+	imageService.SetThumbnailCache(cache.NewLRU(services.DefaultThumbnailCacheSize, metricsRegistry.Cache("thumbnails")))
+
+	return &appServices{
+		health:    healthService,
+		directory: directoryService,
+		file:      services.NewFileService(fsRepo, logger),
+		search:    searchService,
+		archive:   services.NewArchiveService(fsRepo, logger),
+		logs:      services.NewLogService(fsRepo, logger),
+		images:    imageService,
+		metrics:   metricsRegistry,
+		usage:     metrics.NewUsageTracker(cfg.Security.DailyByteQuota),
+		redaction: newRedactionPolicy(cfg),
+		files:     baseRepo,
+		contents:  contents,
+		listings:  listings,
+		index:     index,
+		workers:   workers,
+
+		securityEvents: logging.NewSecurityEventBuffer(securityEventCapacity),
+	}
+}
+
+// close stops the worker pool and the directory index
+func (svc *appServices) close() {
+	svc.workers.Close()
+	if svc.index != nil {
+		svc.index.Close()
+	}
+}
+
+// newMounts creates a repository for each configured mount
+func newMounts(cfg *config.Config, maxFileSize int64) []filesystem.Mount {
+	mounts := make([]filesystem.Mount, 0, len(cfg.FileSystem.Mounts))
+	for _, mount := range cfg.FileSystem.Mounts {
+		repo := filesystem.NewFileSystemRepository(mount.Path, maxFileSize)
+		mounts = append(mounts, filesystem.Mount{
+			Name:       mount.Name,
+			Repository: filesystem.NewArchiveRepository(guardRepository(cfg, repo), maxFileSize),
+		})
+	}
+	return mounts
+}
+
+// guardRepository bounds the operations of one filesystem, the base
+// directory or a mount, with a circuit breaker of its own so a hung mount
+// does not fail requests for the others
+func guardRepository(cfg *config.Config, repo repositories.FileSystemRepository) repositories.FileSystemRepository {
+	timeouts := filesystem.OperationTimeouts{
+		Stat: cfg.FileSystem.StatTimeout,
+		Read: cfg.FileSystem.ReadTimeout,
+		List: cfg.FileSystem.ListTimeout,
+	}
+	return filesystem.NewGuardedRepository(repo, timeouts, cfg.FileSystem.BreakerThreshold, cfg.FileSystem.BreakerCooldown)
+}
+
+// newPathRules converts the limits of mounts and overrides to path rules.
+// Mounts serve hidden files only when they allow them.
+func newPathRules(cfg *config.Config) []filesystem.PathRule {
+	var rules []filesystem.PathRule
+	for _, mount := range cfg.FileSystem.Mounts {
+		allowHidden := mount.AllowHidden
+		rules = append(rules, filesystem.PathRule{
+			Prefix:            mount.Name,
+			MaxFileSize:       mount.MaxFileSize,
+			AllowHidden:       &allowHidden,
+			AllowedExtensions: mount.AllowedExtensions,
+			DeniedExtensions:  mount.DeniedExtensions,
+		})
+	}
+	for _, override := range cfg.FileSystem.Overrides {
+		rules = append(rules, filesystem.PathRule{
+			Prefix:            override.Prefix,
+			MaxFileSize:       override.MaxFileSize,
+			AllowHidden:       override.AllowHidden,
+			AllowedExtensions: override.AllowedExtensions,
+			DeniedExtensions:  override.DeniedExtensions,
+		})
+	}
+	return rules
+}
+
+// largestMaxFileSize returns the largest file size limit configured
+func largestMaxFileSize(cfg *config.Config) int64 {
+	largest := cfg.FileSystem.MaxFileSize
+	for _, mount := range cfg.FileSystem.Mounts {
+		largest = max(largest, mount.MaxFileSize)
+	}
+	for _, override := range cfg.FileSystem.Overrides {
+		largest = max(largest, override.MaxFileSize)
+	}
+	return largest
+}
+
+// parseLogLevel converts a configured level name to a logging.LogLevel
+func parseLogLevel(level string) logging.LogLevel {
+	switch level {
+	case "debug":
+		return logging.LevelDebug
+	case "warn":
+		return logging.LevelWarn
+	case "error":
+		return logging.LevelError
+	default:
+		return logging.LevelInfo
+	}
+}
+
+// logReadOnlyMode reports the read-only guarantee at startup, warning when
+// the base directory's filesystem is itself writable
+func logReadOnlyMode(cfg *config.Config, mux *server.Registry, logger *logging.Logger) {
+	mountReadOnly, err := filesystem.IsReadOnlyMount(cfg.FileSystem.BaseDirectory)
+	logger.Info("read-only mode enabled", "refused_routes", mux.Refused(), "read_only_mount", mountReadOnly)
+	if err == nil && !mountReadOnly {
+		logger.Warn("base directory is on a writable filesystem; mount it read-only to guard against other writers",
+			"base_directory", cfg.FileSystem.BaseDirectory)
+	}
+}
+
+// registries holds the handler registry of the public listener and the one
+// for health, version, metrics and admin endpoints, which is the same registry unless
+// a separate admin listener is configured
+type registries struct {
+	public *server.Registry
+	admin  *server.Registry
+}
+
+// separateAdmin reports whether admin endpoints have their own listener
+func (r registries) separateAdmin() bool {
+	return r.admin != r.public
+}
+
+// routes returns the patterns registered on every registry
+func (r registries) routes() []string {
+	routes := r.public.Routes()
+	if r.separateAdmin() {
+		routes = append(routes, r.admin.Routes()...)
+	}
+	return routes
+}
+
+// newRegistries creates the handler registries with all HTTP handlers
+func newRegistries(cfg *config.Config, svc *appServices, logger *logging.Logger, recentLogs *logging.RecentLogBuffer) registries {
+	muxes := registries{public: server.NewRegistry(cfg.FileSystem.ReadOnly)}
+	muxes.admin = muxes.public
+	if cfg.Server.AdminAddr != "" {
+		muxes.admin = server.NewRegistry(cfg.FileSystem.ReadOnly)
+	}
+	registerRoutes(muxes, cfg, svc, logger, recentLogs)
+	return muxes
+}
+
+// registerRoutes registers all HTTP handlers. The public registry gets the
+// file-serving routes and the admin registry health, version, metrics, admin and,
+// when enabled on a separate admin listener, profiling endpoints.
+func registerRoutes(muxes registries, cfg *config.Config, svc *appServices, logger *logging.Logger, recentLogs *logging.RecentLogBuffer) {
+	includeHidden := cfg.FileSystem.AllowHidden
+	mux := muxes.public
+
+	health := handlers.NewHealthHandler(svc.health, logger)
+	health.Register(muxes.admin)
+	handlers.NewVersionHandler(buildinfo.Get()).Register(muxes.admin)
+	handlers.NewDirectoryHandler(func(r *http.Request, l *logging.Logger) handlers.DirectoryService {
+		return svc.directory.WithLogger(l).WithRedactor(svc.redaction.redactorFor(r, l)).WithTimings(metrics.TimingsFromContext(r.Context()))
+	}, includeHidden, logger).Register(mux)
+	handlers.NewCatHandler(func(r *http.Request, l *logging.Logger) handlers.ContentService {
+		// Secrets in files such as .env are masked unless the token allows
+		// them, by every service reading file contents
+		return svc.file.WithLogger(l).WithRedactor(svc.redaction.redactorFor(r, l)).WithTimings(metrics.TimingsFromContext(r.Context()))
+	}, logger).Register(mux)
+	handlers.NewFileHandler(func(r *http.Request, l *logging.Logger) handlers.FileService {
+		return svc.file.WithLogger(l).WithRedactor(svc.redaction.redactorFor(r, l))
+	}, logger).Register(mux)
+	handlers.NewArchiveHandler(func(r *http.Request, l *logging.Logger) handlers.ArchiveService {
+		return svc.archive.WithLogger(l).WithRedactor(svc.redaction.redactorFor(r, l))
+	}, includeHidden, logger).Register(mux)
+	handlers.NewSearchHandler(func(r *http.Request, l *logging.Logger) handlers.SearchService {
+		return svc.search.WithLogger(l).WithRedactor(svc.redaction.redactorFor(r, l))
+	}, logger).Register(mux)
+	handlers.NewLogsHandler(func(r *http.Request, l *logging.Logger) handlers.LogService {
+		return svc.logs.WithLogger(l).WithRedactor(svc.redaction.redactorFor(r, l))
+	}, logger).Register(mux)
+	handlers.NewImageHandler(func(r *http.Request, l *logging.Logger) handlers.ImageService {
+		return svc.images.WithLogger(l)
+	}, logger).Register(mux)
+	handlers.NewMetricsHandler(svc.metrics, logger).Register(muxes.admin)
+	handlers.NewDocsHandler(mux, "cat-server", buildinfo.Get().Version, logger).Register(mux)
+
+	// Profiling is never exposed on the public listener
+	if cfg.Server.EnablePprof && muxes.separateAdmin() {
+		registerPprofHandlers(muxes.admin, cfg, logger)
+	}
+
+	// Admin endpoints are only available when an admin token is configured
+	if cfg.Security.AdminToken != "" {
+		health.RegisterDetailed(muxes.admin, func(next http.HandlerFunc) http.HandlerFunc {
+			return requireAdminToken(cfg, logger, next)
+		})
+		registerSupportBundleHandler(muxes, cfg, svc, recentLogs, logger)
+		registerUsageHandler(muxes.admin, cfg, svc.usage, svc.metrics, logger)
+		registerLogLevelHandler(muxes.admin, cfg, logger)
+		registerSecurityEventsHandler(muxes.admin, cfg, svc.securityEvents, logger)
+		if len(svc.listingCaches()) > 0 {
+			registerListingCacheHandler(muxes.admin, cfg, svc, logger)
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package cat
+
+import (
+	"cmp"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/sh05/cat-server/internal/config"
+	cathttp "github.com/sh05/cat-server/pkg/infrastructure/http"
+	"github.com/sh05/cat-server/pkg/infrastructure/logging"
+	"github.com/sh05/cat-server/pkg/infrastructure/metrics"
+)
+
+// slowRequestThreshold is the latency above which requests to route and
+// the paths below it are logged as slow
+type slowRequestThreshold struct {
+	route     string
+	threshold time.Duration
+}
+
+// newSlowRequestThresholds returns the enabled thresholds of cfg, longest
+// route first so the most specific one matches
+func newSlowRequestThresholds(cfg *config.Config) []slowRequestThreshold {
+	var thresholds []slowRequestThreshold
+	for route, threshold := range cfg.Logging.SlowRequests {
+		if threshold > 0 {
+			thresholds = append(thresholds, slowRequestThreshold{route: route, threshold: threshold})
+		}
+	}
+	slices.SortFunc(thresholds, func(a, b slowRequestThreshold) int {
+		return cmp.Or(cmp.Compare(len(b.route), len(a.route)), strings.Compare(a.route, b.route))
+	})
+	return thresholds
+}
+
+// matchSlowRequestThreshold returns the threshold of the route path is
+// at or below
+func matchSlowRequestThreshold(thresholds []slowRequestThreshold, path string) (slowRequestThreshold, bool) {
+	for _, t := range thresholds {
+		if path == t.route || strings.HasPrefix(path, strings.TrimSuffix(t.route, "/")+"/") {
+			return t, true
+		}
+	}
+	return slowRequestThreshold{}, false
+}
+
+// slowRequestMiddleware times the phases of requests to routes with a
+// latency threshold and logs a slow_request warning with the breakdown,
+// e.g. validate, stat, read and encode, when a request exceeds it. Time
+// outside the recorded phases is reported as other.
+func slowRequestMiddleware(thresholds []slowRequestThreshold, logger *logging.Logger) cathttp.Middleware {
+	return func(next http.Handler) http.Handler {
+		if len(thresholds) == 0 {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			threshold, ok := matchSlowRequestThreshold(thresholds, r.URL.Path)
+			if !ok {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			start := time.Now()
+			timings := metrics.NewTimings()
+			wrapper := &responseWriter{ResponseWriter: w, statusCode: http.StatusOK}
+			next.ServeHTTP(wrapper, r.WithContext(metrics.NewTimingsContext(r.Context(), timings)))
+
+			duration := time.Since(start)
+			if duration <= threshold.threshold {
+				return
+			}
+			var breakdown []any
+			other := duration
+			for _, phase := range timings.Phases() {
+				breakdown = append(breakdown, slog.Duration(phase.Name, phase.Duration))
+				other -= phase.Duration
+			}
+			breakdown = append(breakdown, slog.Duration("other", max(other, 0)))
+
+			logging.FromContext(r.Context(), logger).Warn("slow_request",
+				"method", r.Method,
+				"path", r.URL.Path,
+				"route", threshold.route,
+				"status_code", wrapper.statusCode,
+				"duration", duration,
+				"threshold", threshold.threshold,
+				slog.Group("timings", breakdown...),
+			)
+		})
+	}
+}
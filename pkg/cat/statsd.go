@@ -0,0 +1,90 @@
+package cat
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sh05/cat-server/internal/config"
+	cathttp "github.com/sh05/cat-server/pkg/infrastructure/http"
+	"github.com/sh05/cat-server/pkg/infrastructure/logging"
+	"github.com/sh05/cat-server/pkg/infrastructure/metrics"
+	"github.com/sh05/cat-server/pkg/server"
+)
+
+// openStatsD connects to the StatsD agent configured in cfg and counts
+// failed filesystem operations logged by logger in it. It returns nil when
+// StatsD is disabled.
+func openStatsD(cfg *config.Config, logger *logging.Logger) (*metrics.StatsD, error) {
+	if !cfg.Metrics.StatsDEnabled() {
+		return nil, nil
+	}
+	statsd, err := metrics.NewStatsD(cfg.Metrics.StatsDAddr(), cfg.Metrics.StatsDPrefix, cfg.Metrics.StatsDTags)
+	if err != nil {
+		return nil, err
+	}
+	logger.SetFileSystemErrorHook(func(operation string) {
+		statsd.Count("filesystem.errors", 1, "operation:"+operation)
+	})
+	return statsd, nil
+}
+
+// routeNames returns the first path segment of every route registered in
+// registries, e.g. /cat for GET /cat/{filename...}, to tag metrics with
+// a bounded set of routes
+func routeNames(registries ...*server.Registry) map[string]bool {
+	names := make(map[string]bool)
+	for _, registry := range registries {
+		for _, pattern := range registry.Routes() {
+			if _, path, found := strings.Cut(pattern, " "); found {
+				pattern = path
+			}
+			names[routeName(pattern)] = true
+		}
+	}
+	return names
+}
+
+// routeName returns the first segment of path, e.g. /cat for
+// /cat/logs/app.log
+func routeName(path string) string {
+	if i := strings.IndexByte(strings.TrimPrefix(path, "/"), '/'); i >= 0 {
+		return path[:i+1]
+	}
+	return path
+}
+
+// statsDMethods are the methods tagged by name; others are tagged other
+var statsDMethods = map[string]bool{
+	http.MethodGet: true, http.MethodHead: true, http.MethodPost: true, http.MethodPut: true, http.MethodOptions: true,
+}
+
+// statsDMiddleware counts and times requests in StatsD as http.requests
+// and http.request_duration, tagged with the method, status and route.
+// Paths outside the registered routes are tagged route:other, so clients
+// cannot create unbounded tag values.
+func statsDMiddleware(statsd *metrics.StatsD, routes map[string]bool) cathttp.Middleware {
+	return func(next http.Handler) http.Handler {
+		if statsd == nil {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			wrapper := &responseWriter{ResponseWriter: w, statusCode: http.StatusOK}
+			next.ServeHTTP(wrapper, r)
+			duration := time.Since(start)
+
+			method, route := "other", "other"
+			if statsDMethods[r.Method] {
+				method = r.Method
+			}
+			if name := routeName(r.URL.Path); routes[name] {
+				route = name
+			}
+			tags := []string{"method:" + method, "status:" + strconv.Itoa(wrapper.statusCode), "route:" + route}
+			statsd.Count("http.requests", 1, tags...)
+			statsd.Timing("http.request_duration", duration, tags...)
+		})
+	}
+}
@@ -0,0 +1,104 @@
+package cat
+
+import (
+	"bytes"
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/sh05/cat-server/internal/buildinfo"
+	"github.com/sh05/cat-server/internal/config"
+	"github.com/sh05/cat-server/internal/supportbundle"
+	cathttp "github.com/sh05/cat-server/pkg/infrastructure/http"
+	"github.com/sh05/cat-server/pkg/infrastructure/logging"
+	"github.com/sh05/cat-server/pkg/server"
+)
+
+// WriteSupportBundle writes a tar.gz file of diagnostics for cfg to w. It
+// wires the services and routes but not authentication, so it works while
+// an identity provider is unreachable.
+func WriteSupportBundle(w io.Writer, cfg *Config, opts Options) error {
+	logger := opts.Logger
+	if logger == nil {
+		logger = NewLogger(cfg, io.Discard)
+	}
+
+	svc := newAppServices(cfg, logger)
+	defer svc.close()
+	muxes := newRegistries(cfg, svc, logger, opts.RecentLogs)
+
+	sources, err := collectSupportBundle(context.Background(), cfg, svc, muxes.routes(), opts.RecentLogs)
+	if err != nil {
+		return err
+	}
+	return supportbundle.Write(w, sources)
+}
+
+// registerSupportBundleHandler registers the admin support bundle handler
+func registerSupportBundleHandler(muxes registries, cfg *config.Config, svc *appServices, recentLogs *logging.RecentLogBuffer, logger *logging.Logger) {
+	mux := muxes.admin
+	mux.HandleFunc("GET /admin/support-bundle", requireAdminToken(cfg, logger, func(w http.ResponseWriter, r *http.Request) {
+		reqLogger := logging.FromContext(r.Context(), logger)
+		sources, err := collectSupportBundle(r.Context(), cfg, svc, muxes.routes(), recentLogs)
+		if err != nil {
+			reqLogger.LogError(err, "failed to collect support bundle")
+			cathttp.WriteProblem(w, r, http.StatusInternalServerError, "")
+			return
+		}
+
+		// Build in memory so failures can still be reported with a status code
+		var buf bytes.Buffer
+		if err := supportbundle.Write(&buf, sources); err != nil {
+			reqLogger.LogError(err, "failed to write support bundle")
+			cathttp.WriteProblem(w, r, http.StatusInternalServerError, "")
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/gzip")
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", supportbundle.Filename(time.Now())))
+		io.Copy(w, &buf)
+	}))
+	mux.Describe("GET /admin/support-bundle", server.RouteDoc{Summary: "Download a diagnostic support bundle", Produces: []string{"application/gzip"}})
+}
+
+// collectSupportBundle gathers the diagnostics included in a support bundle
+func collectSupportBundle(ctx context.Context, cfg *config.Config, svc *appServices, routes []string, recentLogs *logging.RecentLogBuffer) (*supportbundle.Sources, error) {
+	health, err := svc.health.GetDetailedHealth(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to collect health: %w", err)
+	}
+
+	sources := &supportbundle.Sources{
+		Config:  cfg,
+		Version: buildinfo.Get().Version,
+		Health:  health,
+		Metrics: svc.metrics.WritePrometheus,
+		Routes:  routes,
+	}
+	if recentLogs != nil {
+		sources.Logs = recentLogs.Bytes()
+	}
+
+	return sources, nil
+}
+
+// requireAdminToken rejects requests that do not carry the configured admin
+// bearer token and records authorized ones in the audit log
+func requireAdminToken(cfg *config.Config, logger *logging.Logger, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		reqLogger := logging.FromContext(r.Context(), logger)
+		token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if cfg.Security.AdminToken == "" || subtle.ConstantTimeCompare([]byte(token), []byte(cfg.Security.AdminToken)) != 1 {
+			reqLogger.LogSecurityEvent("admin_unauthorized", r.URL.Path, requestClientIP(r), r.UserAgent(), true)
+			w.Header().Set("WWW-Authenticate", `Bearer realm="cat-server admin"`)
+			cathttp.WriteProblem(w, r, http.StatusUnauthorized, "")
+			return
+		}
+		reqLogger.LogAuditEvent("admin_action", "method", r.Method, "path", r.URL.Path)
+		next(w, r)
+	}
+}
@@ -0,0 +1,148 @@
+package cat
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sh05/cat-server/internal/config"
+	cathttp "github.com/sh05/cat-server/pkg/infrastructure/http"
+	"github.com/sh05/cat-server/pkg/infrastructure/logging"
+	"github.com/sh05/cat-server/pkg/infrastructure/metrics"
+	"github.com/sh05/cat-server/pkg/security"
+	"github.com/sh05/cat-server/pkg/server"
+)
+
+// usageKey identifies who a request is accounted to: the authenticated user
+// when there is one, otherwise the client address
+func usageKey(r *http.Request) string {
+	if claims, ok := security.ClaimsFromContext(r.Context()); ok && claims.Subject() != "" {
+		return "user:" + claims.Subject()
+	}
+	return "ip:" + requestClientIP(r)
+}
+
+// requestEndpoint returns the endpoint a request was routed to, the first
+// segment of its route, e.g. /cat for GET /cat/{filename...}, or other
+// when it matched no route
+func requestEndpoint(r *http.Request) string {
+	_, route, found := strings.Cut(r.Pattern, " ")
+	if !found {
+		return "other"
+	}
+	return routeName(route)
+}
+
+// requestDirectory returns the top-level directory of the served tree a
+// request read, the first segment of the path in its route's wildcard or
+// path query parameter, e.g. logs for /cat/logs/app.log or
+// /ls?path=logs/2024. Mounts are top-level directories. Files at the root
+// and requests naming no path, such as /search, are accounted to ".".
+func requestDirectory(r *http.Request) string {
+	var name, p string
+	if i := strings.IndexByte(r.Pattern, '{'); i >= 0 {
+		name = strings.TrimSuffix(strings.TrimSuffix(r.Pattern[i+1:], "}"), "...")
+		p = r.PathValue(name)
+	} else {
+		p = r.URL.Query().Get("path")
+	}
+	dir, _, found := strings.Cut(strings.TrimPrefix(path.Clean("/"+p), "/"), "/")
+	if dir == "" || (name == "filename" && !found) {
+		return "."
+	}
+	return dir
+}
+
+// trackUsage accounts requests and response bytes per user or client, per
+// endpoint and per directory in traffic, and refuses requests once the
+// daily byte quota is used up. Only successful responses are accounted per
+// directory, so clients cannot add directories that do not exist. Health
+// probes and admin endpoints are not accounted.
+func trackUsage(next http.Handler, usage *metrics.UsageTracker, traffic *metrics.Registry, logger *logging.Logger) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if isProbe(r.URL.Path) || isAdminPath(r.URL.Path) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		key := usageKey(r)
+		if remaining, reset, ok := usage.Remaining(key); ok {
+			w.Header().Set("X-Quota-Limit", strconv.FormatInt(usage.DailyByteQuota(), 10))
+			w.Header().Set("X-Quota-Remaining", strconv.FormatInt(remaining, 10))
+			w.Header().Set("X-Quota-Reset", strconv.FormatInt(reset.Unix(), 10))
+			if remaining == 0 {
+				logging.FromContext(r.Context(), logger).Warn("daily byte quota exceeded", "key", key)
+				w.Header().Set("Retry-After", strconv.Itoa(ceilSeconds(time.Until(reset))))
+				cathttp.WriteProblemCode(w, r, cathttp.CodeQuotaExceeded, "Daily quota exceeded")
+				return
+			}
+		}
+
+		counter := &byteCountingWriter{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(counter, r)
+		usage.Record(key, counter.bytes)
+
+		// The mux sets r.Pattern and the path values while routing r
+		traffic.Endpoints().Record(requestEndpoint(r), counter.bytes)
+		if counter.status < http.StatusBadRequest {
+			traffic.Directories().Record(requestDirectory(r), counter.bytes)
+		}
+	})
+}
+
+// byteCountingWriter wraps http.ResponseWriter to count body bytes written
+// and capture the status code
+type byteCountingWriter struct {
+	http.ResponseWriter
+	status int
+	bytes  int64
+}
+
+func (cw *byteCountingWriter) WriteHeader(code int) {
+	cw.status = code
+	cw.ResponseWriter.WriteHeader(code)
+}
+
+func (cw *byteCountingWriter) Write(p []byte) (int, error) {
+	n, err := cw.ResponseWriter.Write(p)
+	cw.bytes += int64(n)
+	return n, err
+}
+
+// ReadFrom passes files on to the server, which sends them with sendfile
+func (cw *byteCountingWriter) ReadFrom(src io.Reader) (int64, error) {
+	n, err := io.Copy(cw.ResponseWriter, src)
+	cw.bytes += n
+	return n, err
+}
+
+// Unwrap exposes the underlying writer to http.ResponseController
+func (cw *byteCountingWriter) Unwrap() http.ResponseWriter {
+	return cw.ResponseWriter
+}
+
+// registerUsageHandler registers the admin usage accounting handler, which
+// reports usage per user or client, per endpoint and per directory
+func registerUsageHandler(mux *server.Registry, cfg *config.Config, usage *metrics.UsageTracker, traffic *metrics.Registry, logger *logging.Logger) {
+	mux.HandleFunc("GET /admin/usage", requireAdminToken(cfg, logger, func(w http.ResponseWriter, r *http.Request) {
+		response := struct {
+			DailyByteQuota int64                   `json:"daily_byte_quota"`
+			Usage          []metrics.UsageSnapshot `json:"usage"`
+			Endpoints      []metrics.UsageSnapshot `json:"endpoints"`
+			Directories    []metrics.UsageSnapshot `json:"directories"`
+		}{
+			DailyByteQuota: usage.DailyByteQuota(),
+			Usage:          usage.Snapshot(),
+			Endpoints:      traffic.Endpoints().Snapshot(),
+			Directories:    traffic.Directories().Snapshot(),
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(response)
+	}))
+	mux.Describe("GET /admin/usage", server.RouteDoc{Summary: "Usage accounting per client, endpoint and directory", Produces: []string{"application/json"}})
+}
@@ -0,0 +1,128 @@
+package cat
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/sh05/cat-server/internal/config"
+	"github.com/sh05/cat-server/pkg/infrastructure/filesystem"
+	"github.com/sh05/cat-server/pkg/infrastructure/logging"
+	"github.com/sh05/cat-server/pkg/infrastructure/watcher"
+)
+
+// cacheWatcher drops cached listings and file contents as soon as they
+// change below the base directory, instead of when the listing TTL expires
+// or the content is next read, and has the directory index list changed
+// directories again. Mounts are not watched.
+type cacheWatcher struct {
+	mode     string // filesystem.watch
+	interval time.Duration
+	svc      *appServices
+	logger   *logging.Logger
+
+	mu      sync.Mutex
+	watcher *watcher.Watcher
+}
+
+// newCacheWatcher starts watching the base directory, or returns nil when
+// watching is off or there is no cache to invalidate
+func newCacheWatcher(cfg *config.Config, svc *appServices, logger *logging.Logger) (*cacheWatcher, error) {
+	if cfg.FileSystem.Watch == "off" || (svc.contents == nil && svc.listings == nil && svc.index == nil) {
+		return nil, nil
+	}
+	c := &cacheWatcher{
+		mode:     cfg.FileSystem.Watch,
+		interval: cfg.FileSystem.WatchPollInterval,
+		svc:      svc,
+		logger:   logger,
+	}
+	if err := c.watch(cfg.FileSystem.BaseDirectory); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// watch starts watching dir, replacing the current watcher
+func (c *cacheWatcher) watch(dir string) error {
+	w, err := c.open(dir)
+	if err != nil {
+		return err
+	}
+	w.Subscribe(c.invalidate)
+	c.logger.Info("watching for file changes", "dir", dir, "mode", w.Mode())
+
+	c.mu.Lock()
+	previous := c.watcher
+	c.watcher = w
+	c.mu.Unlock()
+	if previous != nil {
+		previous.Close()
+	}
+	return nil
+}
+
+// open watches dir as configured. In auto mode inotify is used unless dir
+// is on a network filesystem, where it would miss changes made by other
+// hosts, or inotify is unavailable.
+func (c *cacheWatcher) open(dir string) (*watcher.Watcher, error) {
+	switch c.mode {
+	case watcher.ModeNotify:
+		return watcher.NewNotify(dir)
+	case watcher.ModePoll:
+		return watcher.NewPoll(dir, c.interval)
+	}
+
+	if fsType, network, _ := filesystem.IsNetworkFilesystem(dir); network {
+		c.logger.Info("polling for file changes on a network filesystem", "dir", dir, "type", fsType)
+		return watcher.NewPoll(dir, c.interval)
+	}
+	w, err := watcher.NewNotify(dir)
+	if err != nil {
+		if !errors.Is(err, errors.ErrUnsupported) {
+			c.logger.Warn("inotify unavailable, polling for file changes", "dir", dir, "error", err)
+		}
+		return watcher.NewPoll(dir, c.interval)
+	}
+	return w, nil
+}
+
+// invalidate drops what e may have made stale. Lost events may have
+// changed anything, so they drop everything.
+func (c *cacheWatcher) invalidate(e watcher.Event) {
+	if e.Op == watcher.Overflow {
+		c.logger.Warn("file change events lost, dropping the caches", "path", e.Path)
+		if c.svc.listings != nil {
+			c.svc.listings.InvalidateAll()
+		}
+		if c.svc.contents != nil {
+			c.svc.contents.Purge()
+		}
+		if c.svc.index != nil {
+			c.svc.index.InvalidateAll()
+		}
+		return
+	}
+
+	if c.svc.listings != nil {
+		c.svc.listings.Invalidate(e.Path)
+	}
+	if c.svc.contents != nil {
+		c.svc.contents.Invalidate(e.Path)
+	}
+	if c.svc.index != nil {
+		c.svc.index.Invalidate(e.Path)
+	}
+}
+
+// Close stops watching
+func (c *cacheWatcher) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.watcher == nil {
+		return nil
+	}
+	err := c.watcher.Close()
+	c.watcher = nil
+	return err
+}
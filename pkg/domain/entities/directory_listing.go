@@ -10,10 +10,18 @@ import (
 type DirectoryListing struct {
 	path       string
 	entries    []FileSystemEntry
+	skipped    []SkippedEntry
 	totalCount int
 	scannedAt  time.Time
 }
 
+// SkippedEntry is a directory entry left out of a listing because its
+// metadata could not be read
+type SkippedEntry struct {
+	Name   string
+	Reason string
+}
+
 // NewDirectoryListing creates a new DirectoryListing with validation
 func NewDirectoryListing(path string, entries []FileSystemEntry) (*DirectoryListing, error) {
 	if path == "" {
@@ -45,6 +53,18 @@ func (d *DirectoryListing) Entries() []FileSystemEntry {
 	return entriesCopy
 }
 
+// AddSkipped records an entry that was left out of the listing
+func (d *DirectoryListing) AddSkipped(name, reason string) {
+	d.skipped = append(d.skipped, SkippedEntry{Name: name, Reason: reason})
+}
+
+// Skipped returns the entries left out of the listing
+func (d *DirectoryListing) Skipped() []SkippedEntry {
+	skippedCopy := make([]SkippedEntry, len(d.skipped))
+	copy(skippedCopy, d.skipped)
+	return skippedCopy
+}
+
 // TotalCount returns the total number of entries
 func (d *DirectoryListing) TotalCount() int {
 	return d.totalCount
@@ -1,7 +1,9 @@
 package repositories
 
 import (
+	"context"
 	"fmt"
+	"io"
 
 	"github.com/sh05/cat-server/pkg/domain/entities"
 	"github.com/sh05/cat-server/pkg/domain/valueobjects"
@@ -10,28 +12,32 @@ import (
 // FileSystemRepository defines the interface for filesystem operations
 type FileSystemRepository interface {
 	// ListDirectory returns a directory listing for the given path
-	ListDirectory(path *valueobjects.FilePath) (*entities.DirectoryListing, error)
+	ListDirectory(ctx context.Context, path *valueobjects.FilePath) (*entities.DirectoryListing, error)
 
 	// ReadFile returns the content of a file at the given path
-	ReadFile(path *valueobjects.FilePath) (*entities.FileContent, error)
+	ReadFile(ctx context.Context, path *valueobjects.FilePath) (*entities.FileContent, error)
+
+	// OpenFile opens a file for streaming and ranged reads; the caller must
+	// close it
+	OpenFile(ctx context.Context, path *valueobjects.FilePath) (io.ReadSeekCloser, error)
 
 	// Exists checks if a file or directory exists at the given path
-	Exists(path *valueobjects.FilePath) bool
+	Exists(ctx context.Context, path *valueobjects.FilePath) bool
 
 	// IsReadable checks if the file/directory at the given path is readable
-	IsReadable(path *valueobjects.FilePath) bool
+	IsReadable(ctx context.Context, path *valueobjects.FilePath) bool
 
 	// IsDirectory checks if the path points to a directory
-	IsDirectory(path *valueobjects.FilePath) bool
+	IsDirectory(ctx context.Context, path *valueobjects.FilePath) bool
 
 	// GetFileInfo returns basic information about a file/directory
-	GetFileInfo(path *valueobjects.FilePath) (*entities.FileSystemEntry, error)
+	GetFileInfo(ctx context.Context, path *valueobjects.FilePath) (*entities.FileSystemEntry, error)
 
 	// ValidatePath performs security and accessibility checks on the path
 	ValidatePath(path *valueobjects.FilePath) error
 
 	// GetDirectoryStats returns statistics about a directory
-	GetDirectoryStats(path *valueobjects.FilePath) (*DirectoryStats, error)
+	GetDirectoryStats(ctx context.Context, path *valueobjects.FilePath) (*DirectoryStats, error)
 }
 
 // DirectoryStats represents statistics about a directory
@@ -44,6 +50,48 @@ type DirectoryStats struct {
 	OldestFile       *entities.FileSystemEntry
 }
 
+// NewDirectoryStats computes the statistics of a directory listing
+func NewDirectoryStats(listing *entities.DirectoryListing) *DirectoryStats {
+	stats := &DirectoryStats{
+		TotalFiles:       listing.GetFileCount(),
+		TotalDirectories: listing.GetDirectoryCount(),
+		TotalSize:        listing.GetTotalSize(),
+	}
+
+	// Find largest, newest, and oldest files
+	entries := listing.Entries()
+	if len(entries) > 0 {
+		var largestFile, newestFile, oldestFile *entities.FileSystemEntry
+
+		for i, entry := range entries {
+			if entry.IsDir() {
+				continue
+			}
+
+			// Check for largest file
+			if largestFile == nil || entry.Size() > largestFile.Size() {
+				largestFile = &entries[i]
+			}
+
+			// Check for newest file
+			if newestFile == nil || entry.ModTime().After(newestFile.ModTime()) {
+				newestFile = &entries[i]
+			}
+
+			// Check for oldest file
+			if oldestFile == nil || entry.ModTime().Before(oldestFile.ModTime()) {
+				oldestFile = &entries[i]
+			}
+		}
+
+		stats.LargestFile = largestFile
+		stats.NewestFile = newestFile
+		stats.OldestFile = oldestFile
+	}
+
+	return stats
+}
+
 // FileFilter defines criteria for filtering files
 type FileFilter struct {
 	IncludeHidden  bool
@@ -113,6 +161,8 @@ const (
 	ErrorDirectoryNotEmpty
 	ErrorDiskFull
 	ErrorTimeout
+	ErrorUnavailable
+	ErrorInsufficientMemory
 	ErrorUnknown
 )
 
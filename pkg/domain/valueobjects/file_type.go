@@ -0,0 +1,260 @@
+package valueobjects
+
+import (
+	"bytes"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+)
+
+// FileTypeSampleSize is the number of leading bytes inspected by DetectFileType
+const FileTypeSampleSize = 64 * 1024
+
+// FileType represents the detected type of a file's content, in the spirit
+// of the Unix file(1) command
+type FileType struct {
+	isText      bool
+	mimeType    string
+	charset     string
+	lineEnding  string
+	bom         string
+	executable  string
+	description string
+}
+
+// magicSignature describes a binary format recognized by its leading bytes
+type magicSignature struct {
+	offset      int
+	magic       []byte
+	mimeType    string
+	executable  string
+	description string
+}
+
+// magicSignatures lists recognized binary formats in match order
+var magicSignatures = []magicSignature{
+	{0, []byte("\x7fELF"), "application/x-executable", "ELF", "ELF executable"},
+	{0, []byte("MZ"), "application/vnd.microsoft.portable-executable", "PE", "PE/MS-DOS executable"},
+	{0, []byte{0xfe, 0xed, 0xfa, 0xce}, "application/x-mach-binary", "Mach-O", "Mach-O executable (32-bit)"},
+	{0, []byte{0xfe, 0xed, 0xfa, 0xcf}, "application/x-mach-binary", "Mach-O", "Mach-O executable (64-bit)"},
+	{0, []byte{0xce, 0xfa, 0xed, 0xfe}, "application/x-mach-binary", "Mach-O", "Mach-O executable (32-bit)"},
+	{0, []byte{0xcf, 0xfa, 0xed, 0xfe}, "application/x-mach-binary", "Mach-O", "Mach-O executable (64-bit)"},
+	{0, []byte{0xca, 0xfe, 0xba, 0xbe}, "application/x-mach-binary", "Mach-O", "Mach-O universal binary or Java class"},
+	{0, []byte("\x00asm"), "application/wasm", "WebAssembly", "WebAssembly binary module"},
+	{0, []byte("\x89PNG\r\n\x1a\n"), "image/png", "", "PNG image data"},
+	{0, []byte{0xff, 0xd8, 0xff}, "image/jpeg", "", "JPEG image data"},
+	{0, []byte("GIF87a"), "image/gif", "", "GIF image data"},
+	{0, []byte("GIF89a"), "image/gif", "", "GIF image data"},
+	{0, []byte("%PDF-"), "application/pdf", "", "PDF document"},
+	{0, []byte("PK\x03\x04"), "application/zip", "", "Zip archive data"},
+	{0, []byte{0x1f, 0x8b}, "application/gzip", "", "gzip compressed data"},
+	{0, []byte("BZh"), "application/x-bzip2", "", "bzip2 compressed data"},
+	{0, []byte{0xfd, '7', 'z', 'X', 'Z', 0x00}, "application/x-xz", "", "XZ compressed data"},
+	{0, []byte{'7', 'z', 0xbc, 0xaf, 0x27, 0x1c}, "application/x-7z-compressed", "", "7-zip archive data"},
+	{0, []byte{0x28, 0xb5, 0x2f, 0xfd}, "application/zstd", "", "Zstandard compressed data"},
+	{257, []byte("ustar"), "application/x-tar", "", "POSIX tar archive"},
+	{0, []byte("SQLite format 3\x00"), "application/vnd.sqlite3", "", "SQLite 3.x database"},
+}
+
+// byteOrderMarks lists recognized byte order marks, longest first
+var byteOrderMarks = []struct {
+	bom     []byte
+	name    string
+	charset string
+}{
+	{[]byte{0x00, 0x00, 0xfe, 0xff}, "UTF-32BE", "utf-32be"},
+	{[]byte{0xff, 0xfe, 0x00, 0x00}, "UTF-32LE", "utf-32le"},
+	{[]byte{0xef, 0xbb, 0xbf}, "UTF-8", "utf-8"},
+	{[]byte{0xfe, 0xff}, "UTF-16BE", "utf-16be"},
+	{[]byte{0xff, 0xfe}, "UTF-16LE", "utf-16le"},
+}
+
+// DetectFileType inspects the leading bytes of a file and reports its type.
+// Only the first FileTypeSampleSize bytes of content are examined.
+func DetectFileType(content []byte) *FileType {
+	if len(content) > FileTypeSampleSize {
+		content = content[:FileTypeSampleSize]
+	}
+
+	ft := &FileType{}
+
+	if len(content) == 0 {
+		ft.isText = true
+		ft.mimeType = "inode/x-empty"
+		ft.charset = "binary"
+		ft.lineEnding = "none"
+		ft.description = "empty"
+		return ft
+	}
+
+	for _, sig := range magicSignatures {
+		if len(content) >= sig.offset+len(sig.magic) && bytes.Equal(content[sig.offset:sig.offset+len(sig.magic)], sig.magic) {
+			ft.mimeType = sig.mimeType
+			ft.charset = "binary"
+			ft.executable = sig.executable
+			ft.description = sig.description
+			return ft
+		}
+	}
+
+	body := content
+	for _, mark := range byteOrderMarks {
+		if bytes.HasPrefix(content, mark.bom) {
+			ft.bom = mark.name
+			ft.charset = mark.charset
+			body = content[len(mark.bom):]
+			break
+		}
+	}
+
+	// UTF-16/32 text legitimately contains NUL bytes
+	if ft.bom != "" && ft.bom != "UTF-8" {
+		ft.isText = true
+		ft.mimeType = "text/plain"
+		ft.lineEnding = "unknown"
+		ft.description = ft.bom + " Unicode text (with BOM)"
+		return ft
+	}
+
+	if bytes.IndexByte(body, 0) >= 0 {
+		ft.mimeType = http.DetectContentType(content)
+		if ft.mimeType == "text/plain; charset=utf-8" {
+			ft.mimeType = "application/octet-stream"
+		}
+		ft.charset = "binary"
+		ft.description = "data"
+		return ft
+	}
+
+	ft.isText = true
+	if ft.charset == "" {
+		ft.charset = textCharset(body)
+	}
+	ft.lineEnding = detectLineEnding(body)
+	ft.mimeType = strings.SplitN(http.DetectContentType(body), ";", 2)[0]
+
+	var desc string
+	switch ft.charset {
+	case "us-ascii":
+		desc = "ASCII text"
+	case "utf-8":
+		desc = "Unicode text, UTF-8 text"
+	default:
+		desc = "Non-ISO extended-ASCII text"
+	}
+	if ft.bom != "" {
+		desc += " (with BOM)"
+	}
+	if bytes.HasPrefix(body, []byte("#!")) {
+		line, _, _ := bytes.Cut(body, []byte("\n"))
+		ft.executable = "script"
+		desc = strings.TrimSpace(string(line[2:])) + " script, " + desc + " executable"
+	}
+	switch ft.lineEnding {
+	case "CRLF", "CR", "mixed":
+		desc += ", with " + ft.lineEnding + " line terminators"
+	case "none":
+		desc += ", with no line terminators"
+	}
+	ft.description = desc
+
+	return ft
+}
+
+// IsText returns true if the content is text
+func (ft *FileType) IsText() bool {
+	return ft.isText
+}
+
+// Kind returns "text" or "binary"
+func (ft *FileType) Kind() string {
+	if ft.isText {
+		return "text"
+	}
+	return "binary"
+}
+
+// MimeType returns the detected MIME type
+func (ft *FileType) MimeType() string {
+	return ft.mimeType
+}
+
+// Charset returns the detected character set ("binary" for non-text content)
+func (ft *FileType) Charset() string {
+	return ft.charset
+}
+
+// LineEnding returns the line ending style: LF, CRLF, CR, mixed, none or unknown
+func (ft *FileType) LineEnding() string {
+	return ft.lineEnding
+}
+
+// BOM returns the byte order mark present, or "" if none
+func (ft *FileType) BOM() string {
+	return ft.bom
+}
+
+// Executable returns the executable format (ELF, PE, Mach-O, WebAssembly, script), or ""
+func (ft *FileType) Executable() string {
+	return ft.executable
+}
+
+// Description returns a human-readable description similar to file(1)
+func (ft *FileType) Description() string {
+	return ft.description
+}
+
+func textCharset(body []byte) string {
+	ascii := true
+	for _, b := range body {
+		if b >= 0x80 {
+			ascii = false
+			break
+		}
+	}
+	if ascii {
+		return "us-ascii"
+	}
+	if utf8.Valid(trimIncompleteRune(body)) {
+		return "utf-8"
+	}
+	return "unknown-8bit"
+}
+
+// trimIncompleteRune drops a multi-byte sequence cut off by sampling
+func trimIncompleteRune(b []byte) []byte {
+	for i := 1; i <= utf8.UTFMax && i <= len(b); i++ {
+		if utf8.RuneStart(b[len(b)-i]) {
+			if !utf8.FullRune(b[len(b)-i:]) {
+				return b[:len(b)-i]
+			}
+			break
+		}
+	}
+	return b
+}
+
+func detectLineEnding(body []byte) string {
+	crlf := bytes.Count(body, []byte("\r\n"))
+	lf := bytes.Count(body, []byte("\n")) - crlf
+	cr := bytes.Count(body, []byte("\r")) - crlf
+
+	styles := 0
+	result := "none"
+	if lf > 0 {
+		styles++
+		result = "LF"
+	}
+	if crlf > 0 {
+		styles++
+		result = "CRLF"
+	}
+	if cr > 0 {
+		styles++
+		result = "CR"
+	}
+	if styles > 1 {
+		return "mixed"
+	}
+	return result
+}
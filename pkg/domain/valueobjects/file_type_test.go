@@ -0,0 +1,51 @@
+package valueobjects
+
+import "testing"
+
+func TestDetectFileType(t *testing.T) {
+	tests := []struct {
+		name        string
+		content     []byte
+		wantKind    string
+		wantMime    string
+		wantLE      string
+		wantBOM     string
+		wantExec    string
+		wantCharset string
+	}{
+		{"empty", []byte{}, "text", "inode/x-empty", "none", "", "", "binary"},
+		{"ascii lf", []byte("hello\nworld\n"), "text", "text/plain", "LF", "", "", "us-ascii"},
+		{"crlf", []byte("a\r\nb\r\n"), "text", "text/plain", "CRLF", "", "", "us-ascii"},
+		{"mixed", []byte("a\r\nb\n"), "text", "text/plain", "mixed", "", "", "us-ascii"},
+		{"utf8 bom", []byte("\xef\xbb\xbfhé\n"), "text", "text/plain", "LF", "UTF-8", "", "utf-8"},
+		{"utf16 bom", []byte{0xff, 0xfe, 'h', 0, 'i', 0}, "text", "text/plain", "unknown", "UTF-16LE", "", "utf-16le"},
+		{"shebang", []byte("#!/bin/sh\necho hi\n"), "text", "text/plain", "LF", "", "script", "us-ascii"},
+		{"elf", []byte("\x7fELF\x02\x01\x01"), "binary", "application/x-executable", "", "", "ELF", "binary"},
+		{"png", []byte("\x89PNG\r\n\x1a\n\x00\x00"), "binary", "image/png", "", "", "", "binary"},
+		{"nul bytes", []byte("abc\x00def"), "binary", "application/octet-stream", "", "", "", "binary"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ft := DetectFileType(tt.content)
+			if ft.Kind() != tt.wantKind {
+				t.Errorf("Kind() = %s, want %s", ft.Kind(), tt.wantKind)
+			}
+			if ft.MimeType() != tt.wantMime {
+				t.Errorf("MimeType() = %s, want %s", ft.MimeType(), tt.wantMime)
+			}
+			if ft.LineEnding() != tt.wantLE {
+				t.Errorf("LineEnding() = %s, want %s", ft.LineEnding(), tt.wantLE)
+			}
+			if ft.BOM() != tt.wantBOM {
+				t.Errorf("BOM() = %s, want %s", ft.BOM(), tt.wantBOM)
+			}
+			if ft.Executable() != tt.wantExec {
+				t.Errorf("Executable() = %s, want %s", ft.Executable(), tt.wantExec)
+			}
+			if ft.Charset() != tt.wantCharset {
+				t.Errorf("Charset() = %s, want %s", ft.Charset(), tt.wantCharset)
+			}
+		})
+	}
+}
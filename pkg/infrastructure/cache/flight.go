@@ -0,0 +1,50 @@
+package cache
+
+import (
+	"errors"
+	"sync"
+)
+
+// errFlightPanicked is returned to callers that joined a call which panicked
+var errFlightPanicked = errors.New("coalesced call panicked")
+
+// Group coalesces concurrent calls with the same key, so that only the
+// first runs and the others wait for its result, like singleflight.
+// Results are not kept once the call returns. The zero Group is ready to
+// use and safe for concurrent use.
+type Group[V any] struct {
+	mu    sync.Mutex
+	calls map[string]*flight[V]
+}
+
+type flight[V any] struct {
+	done chan struct{}
+	val  V
+	err  error
+}
+
+// Do runs fn for key unless a call for key is already running, in which
+// case it waits for that call and returns its result with shared set
+func (g *Group[V]) Do(key string, fn func() (V, error)) (val V, err error, shared bool) {
+	g.mu.Lock()
+	if f, ok := g.calls[key]; ok {
+		g.mu.Unlock()
+		<-f.done
+		return f.val, f.err, true
+	}
+	if g.calls == nil {
+		g.calls = make(map[string]*flight[V])
+	}
+	f := &flight[V]{done: make(chan struct{}), err: errFlightPanicked}
+	g.calls[key] = f
+	g.mu.Unlock()
+
+	defer func() {
+		g.mu.Lock()
+		delete(g.calls, key)
+		g.mu.Unlock()
+		close(f.done)
+	}()
+	f.val, f.err = fn()
+	return f.val, f.err, false
+}
@@ -0,0 +1,107 @@
+package cache
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestGroupDo(t *testing.T) {
+	var g Group[int]
+	var calls atomic.Int32
+	errRead := errors.New("read failed")
+	release := make(chan struct{})
+	started := make(chan struct{})
+
+	type result struct {
+		val    int
+		err    error
+		shared bool
+	}
+	results := make(chan result, 10)
+	go func() {
+		v, err, shared := g.Do("key", func() (int, error) {
+			calls.Add(1)
+			close(started)
+			<-release
+			return 42, errRead
+		})
+		results <- result{v, err, shared}
+	}()
+	<-started
+
+	var wg sync.WaitGroup
+	for range 9 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			v, err, shared := g.Do("key", func() (int, error) {
+				calls.Add(1)
+				return 1, nil
+			})
+			results <- result{v, err, shared}
+		}()
+	}
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	for len(results) < 10 {
+		time.Sleep(time.Millisecond)
+	}
+	close(results)
+
+	// Callers that joined get the running call's result, error included;
+	// callers that were too late to join run their own
+	shared := 0
+	for r := range results {
+		switch {
+		case r.shared && (r.val != 42 || r.err != errRead):
+			t.Errorf("Expected a shared result to be 42 and the read error, got %d %v", r.val, r.err)
+		case r.shared:
+			shared++
+		}
+	}
+	if shared == 0 {
+		t.Error("Expected concurrent calls to be coalesced")
+	}
+	if c := int(calls.Load()); c != 10-shared {
+		t.Errorf("Expected %d calls for %d shared results, got %d", 10-shared, shared, c)
+	}
+
+	// Results are not kept once the call returns
+	if v, _, shared := g.Do("key", func() (int, error) { return 7, nil }); v != 7 || shared {
+		t.Errorf("Expected a later call to run, got %d shared=%v", v, shared)
+	}
+}
+
+func TestGroupDoPanic(t *testing.T) {
+	var g Group[int]
+	started := make(chan struct{})
+	release := make(chan struct{})
+	joined := make(chan error)
+
+	go func() {
+		defer func() { recover() }()
+		g.Do("key", func() (int, error) {
+			close(started)
+			<-release
+			panic("boom")
+		})
+	}()
+	<-started
+	go func() {
+		_, err, _ := g.Do("key", func() (int, error) { return 0, nil })
+		joined <- err
+	}()
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+
+	// A caller that joined gets an error instead of waiting forever
+	select {
+	case <-joined:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected a caller joining a panicking call to return")
+	}
+}
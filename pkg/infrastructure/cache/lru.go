@@ -0,0 +1,151 @@
+// Package cache provides in-memory caches shared by application services
+package cache
+
+import (
+	"container/list"
+	"strings"
+	"sync"
+
+	"github.com/sh05/cat-server/pkg/infrastructure/metrics"
+)
+
+// LRU is a least-recently-used cache of byte slices bounded by total size.
+// It is safe for concurrent use.
+type LRU = SizedLRU[[]byte]
+
+// NewLRU creates an LRU holding at most maxBytes of values. m may be nil.
+func NewLRU(maxBytes int64, m *metrics.CacheMetrics) *LRU {
+	return NewSizedLRU(maxBytes, func(value []byte) int64 { return int64(len(value)) }, m)
+}
+
+// SizedLRU is a least-recently-used cache of values bounded by their total
+// size in bytes, as estimated by its size function. It is safe for
+// concurrent use.
+type SizedLRU[V any] struct {
+	mu       sync.Mutex
+	maxBytes int64
+	bytes    int64
+	sizeOf   func(V) int64
+	order    *list.List // front is most recently used
+	items    map[string]*list.Element
+	metrics  *metrics.CacheMetrics
+}
+
+type lruEntry[V any] struct {
+	key   string
+	value V
+	size  int64
+}
+
+// NewSizedLRU creates a SizedLRU holding values whose sizes, as returned by
+// sizeOf, add up to at most maxBytes. m may be nil.
+func NewSizedLRU[V any](maxBytes int64, sizeOf func(V) int64, m *metrics.CacheMetrics) *SizedLRU[V] {
+	return &SizedLRU[V]{
+		maxBytes: maxBytes,
+		sizeOf:   sizeOf,
+		order:    list.New(),
+		items:    make(map[string]*list.Element),
+		metrics:  m,
+	}
+}
+
+// Get returns the cached value for key and marks it as recently used
+func (c *SizedLRU[V]) Get(key string) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.items[key]
+	if !ok {
+		if c.metrics != nil {
+			c.metrics.RecordMiss()
+		}
+		var zero V
+		return zero, false
+	}
+
+	c.order.MoveToFront(elem)
+	if c.metrics != nil {
+		c.metrics.RecordHit()
+	}
+	return elem.Value.(*lruEntry[V]).value, true
+}
+
+// Add stores value under key, evicting least recently used entries as
+// needed. Values larger than the whole cache are not stored.
+func (c *SizedLRU[V]) Add(key string, value V) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	size := c.sizeOf(value)
+	if size > c.maxBytes {
+		return
+	}
+
+	if elem, ok := c.items[key]; ok {
+		entry := elem.Value.(*lruEntry[V])
+		c.bytes += size - entry.size
+		entry.value, entry.size = value, size
+		c.order.MoveToFront(elem)
+	} else {
+		c.items[key] = c.order.PushFront(&lruEntry[V]{key: key, value: value, size: size})
+		c.bytes += size
+	}
+
+	evicted := 0
+	for c.bytes > c.maxBytes {
+		oldest := c.order.Back()
+		entry := oldest.Value.(*lruEntry[V])
+		c.order.Remove(oldest)
+		delete(c.items, entry.key)
+		c.bytes -= entry.size
+		evicted++
+	}
+
+	if c.metrics != nil {
+		if evicted > 0 {
+			c.metrics.RecordEviction(evicted)
+		}
+		c.metrics.SetSize(len(c.items), c.bytes)
+	}
+}
+
+// RemovePrefix removes the entries whose keys start with prefix and
+// returns how many there were
+func (c *SizedLRU[V]) RemovePrefix(prefix string) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	removed := 0
+	for key, elem := range c.items {
+		if strings.HasPrefix(key, prefix) {
+			c.order.Remove(elem)
+			delete(c.items, key)
+			c.bytes -= elem.Value.(*lruEntry[V]).size
+			removed++
+		}
+	}
+	if c.metrics != nil && removed > 0 {
+		c.metrics.SetSize(len(c.items), c.bytes)
+	}
+	return removed
+}
+
+// Purge removes all entries
+func (c *SizedLRU[V]) Purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.order.Init()
+	clear(c.items)
+	c.bytes = 0
+	if c.metrics != nil {
+		c.metrics.SetSize(0, 0)
+	}
+}
+
+// Len returns the number of cached entries
+func (c *SizedLRU[V]) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.items)
+}
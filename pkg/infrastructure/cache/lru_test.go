@@ -0,0 +1,93 @@
+package cache
+
+import (
+	"testing"
+
+	"github.com/sh05/cat-server/pkg/infrastructure/metrics"
+)
+
+func TestLRUEvictsLeastRecentlyUsed(t *testing.T) {
+	m := metrics.NewCacheMetrics("test")
+	c := NewLRU(10, m)
+
+	c.Add("a", make([]byte, 4))
+	c.Add("b", make([]byte, 4))
+	c.Get("a") // b is now least recently used
+	c.Add("c", make([]byte, 4))
+
+	if _, ok := c.Get("b"); ok {
+		t.Error("Expected b to be evicted")
+	}
+	if _, ok := c.Get("a"); !ok {
+		t.Error("Expected a to be cached")
+	}
+	if _, ok := c.Get("c"); !ok {
+		t.Error("Expected c to be cached")
+	}
+
+	snapshot := m.Snapshot()
+	if snapshot.Evictions != 1 {
+		t.Errorf("Expected 1 eviction, got %d", snapshot.Evictions)
+	}
+	if snapshot.Entries != 2 || snapshot.Bytes != 8 {
+		t.Errorf("Expected 2 entries and 8 bytes, got %d and %d", snapshot.Entries, snapshot.Bytes)
+	}
+	if snapshot.Hits != 3 || snapshot.Misses != 1 {
+		t.Errorf("Expected 3 hits and 1 miss, got %d and %d", snapshot.Hits, snapshot.Misses)
+	}
+}
+
+func TestLRUSkipsOversizedValues(t *testing.T) {
+	c := NewLRU(4, nil)
+	c.Add("big", make([]byte, 5))
+	if c.Len() != 0 {
+		t.Errorf("Expected empty cache, got %d entries", c.Len())
+	}
+
+	c.Add("a", []byte("ab"))
+	c.Add("a", []byte("abcd"))
+	if v, ok := c.Get("a"); !ok || string(v) != "abcd" {
+		t.Errorf("Expected updated value, got %q", v)
+	}
+}
+
+func TestLRUPurge(t *testing.T) {
+	m := metrics.NewCacheMetrics("test")
+	c := NewLRU(10, m)
+	c.Add("a", []byte("ab"))
+	c.Add("b", []byte("cd"))
+
+	c.Purge()
+	if _, ok := c.Get("a"); ok || c.Len() != 0 {
+		t.Errorf("Expected an empty cache, got %d entries", c.Len())
+	}
+	if s := m.Snapshot(); s.Entries != 0 || s.Bytes != 0 {
+		t.Errorf("Expected 0 entries and bytes, got %d and %d", s.Entries, s.Bytes)
+	}
+
+	c.Add("c", make([]byte, 10))
+	if c.Len() != 1 {
+		t.Errorf("Expected the purged space to be reusable, got %d entries", c.Len())
+	}
+}
+
+func TestSizedLRU(t *testing.T) {
+	type index struct{ entries int }
+	c := NewSizedLRU(10, func(v *index) int64 { return int64(v.entries) }, nil)
+
+	c.Add("a", &index{entries: 6})
+	c.Add("b", &index{entries: 3})
+	c.Add("c", &index{entries: 3}) // evicts a
+
+	if _, ok := c.Get("a"); ok {
+		t.Error("Expected a to be evicted")
+	}
+	if v, ok := c.Get("b"); !ok || v.entries != 3 {
+		t.Errorf("Expected b to be cached, got %v", v)
+	}
+
+	c.Add("b", &index{entries: 8}) // growing b leaves no room for c
+	if _, ok := c.Get("c"); ok || c.Len() != 1 {
+		t.Errorf("Expected only b to remain, got %d entries", c.Len())
+	}
+}
@@ -0,0 +1,123 @@
+package dataformat
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"math"
+	"slices"
+	"time"
+
+	"github.com/BurntSushi/toml"
+)
+
+// TOMLToJSON converts a TOML document to JSON. Dates and times are emitted
+// as strings in their TOML form.
+func TOMLToJSON(data []byte) ([]byte, error) {
+	var doc map[string]interface{}
+	md, err := toml.Decode(string(data), &doc)
+	if err != nil {
+		var parseErr toml.ParseError
+		if errors.As(err, &parseErr) {
+			return nil, &SyntaxError{Format: "toml", Line: parseErr.Position.Line, Msg: parseErr.Message}
+		}
+		return nil, err
+	}
+
+	// Keys are ordered as in the document; the elements of an array of
+	// tables share the order of their path
+	order := make(map[string][]string)
+	seen := make(map[string]bool)
+	for _, key := range md.Keys() {
+		parent, name := key[:len(key)-1].String(), key[len(key)-1]
+		if path := key.String(); !seen[path] {
+			seen[path] = true
+			order[parent] = append(order[parent], name)
+		}
+	}
+
+	value, err := tomlValue(doc, "", order)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(value)
+}
+
+// tomlValue converts a decoded TOML value at path to a JSON value
+func tomlValue(value interface{}, path string, order map[string][]string) (interface{}, error) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		// Keys missing from the order, if any, follow in sorted order
+		names := slices.Clone(order[path])
+		for _, name := range slices.Sorted(maps.Keys(v)) {
+			names = append(names, name)
+		}
+
+		obj := newObject()
+		for _, name := range names {
+			child, ok := v[name]
+			if _, done := obj.get(name); !ok || done {
+				continue
+			}
+			converted, err := tomlValue(child, tomlKeyPath(path, name), order)
+			if err != nil {
+				return nil, err
+			}
+			obj.set(name, converted)
+		}
+		return obj, nil
+	case []map[string]interface{}:
+		items := make([]interface{}, 0, len(v))
+		for _, table := range v {
+			converted, err := tomlValue(table, path, order)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, converted)
+		}
+		return items, nil
+	case []interface{}:
+		items := make([]interface{}, 0, len(v))
+		for _, item := range v {
+			converted, err := tomlValue(item, path, order)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, converted)
+		}
+		return items, nil
+	case float64:
+		if math.IsInf(v, 0) || math.IsNaN(v) {
+			return nil, fmt.Errorf("toml: %s: %v cannot be represented in JSON", path, v)
+		}
+		return v, nil
+	case time.Time:
+		return tomlTime(v), nil
+	default:
+		return v, nil
+	}
+}
+
+// tomlKeyPath appends name to path in the form of toml.Key.String
+func tomlKeyPath(path, name string) string {
+	key := toml.Key{name}
+	if path == "" {
+		return key.String()
+	}
+	return path + "." + key.String()
+}
+
+// tomlTime formats a date or time, leaving out the offset of local ones
+func tomlTime(t time.Time) string {
+	switch t.Location().String() {
+	case "date-local":
+		return t.Format(time.DateOnly)
+	case "time-local":
+		return t.Format("15:04:05.999999999")
+	case "datetime-local":
+		return t.Format("2006-01-02T15:04:05.999999999")
+	default:
+		return t.Format(time.RFC3339Nano)
+	}
+}
@@ -0,0 +1,98 @@
+package dataformat
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestTOMLToJSON(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{"empty", "", `{}`},
+		{
+			"key values keep order",
+			"title = \"cat\" # comment\nport = 8_080\nratio = 0.5\nenabled = true\nmask = 0o755\n",
+			`{"title":"cat","port":8080,"ratio":0.5,"enabled":true,"mask":493}`,
+		},
+		{
+			"tables and dotted keys",
+			"[server]\nhost = \"localhost\"\n\n[server.tls]\ncert = 'c.pem'\n\n[log]\nformat.kind = \"json\"\n",
+			`{"server":{"host":"localhost","tls":{"cert":"c.pem"}},"log":{"format":{"kind":"json"}}}`,
+		},
+		{
+			"array of tables",
+			"[[users]]\nname = \"a\"\n[[users]]\nname = \"b\"\n[users.meta]\nage = 1\n",
+			`{"users":[{"name":"a"},{"name":"b","meta":{"age":1}}]}`,
+		},
+		{
+			"arrays and inline tables",
+			"ports = [\n  80,\n  443, # https\n]\npoint = { x = 1, y = \"two\" }\n",
+			`{"ports":[80,443],"point":{"x":1,"y":"two"}}`,
+		},
+		{
+			"strings",
+			"a = \"tab\\tquote\\\" \\u00e9\"\nb = 'C:\\path'\nc = \"\"\"\nline one\nline two\"\"\"\nd = \"\"\"\\\n   joined \\\n   text\"\"\"\ne = '''raw \\n'''\n",
+			`{"a":"tab\tquote\" é","b":"C:\\path","c":"line one\nline two","d":"joined text","e":"raw \\n"}`,
+		},
+		{
+			"dates stay strings",
+			"a = 1979-05-27T07:32:00Z\nb = 1979-05-27 07:32:00\nc = 1979-05-27\nd = 07:32:00\n",
+			`{"a":"1979-05-27T07:32:00Z","b":"1979-05-27T07:32:00","c":"1979-05-27","d":"07:32:00"}`,
+		},
+		{"exponent", "a = 1e3\nb = -2.5E-1\n", `{"a":1000,"b":-0.25}`},
+		{"offset date time", "a = 1979-05-27T00:32:00.5-07:00\n", `{"a":"1979-05-27T00:32:00.5-07:00"}`},
+		{"inline tables in arrays", "points = [{ y = 1, x = 2 }]\n", `{"points":[{"y":1,"x":2}]}`},
+		{"quoted keys", "\"a b\" = 1\n'c.d' = 2\n", `{"a b":1,"c.d":2}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := TOMLToJSON([]byte(tt.input))
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if string(result) != tt.expected {
+				t.Errorf("Expected %s, got %s", tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestTOMLToJSONErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		line  int
+	}{
+		{"duplicate key", "a = 1\na = 2\n", 2},
+		{"missing equals", "a 1\n", 1},
+		{"unterminated string", "a = \"open\n", 1},
+		{"leading zero", "a = 012\n", 1},
+		{"table over value", "a = 1\n[a]\n", 2},
+		{"trailing garbage", "a = 1 2\n", 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := TOMLToJSON([]byte(tt.input))
+			var syntaxErr *SyntaxError
+			if !errors.As(err, &syntaxErr) {
+				t.Fatalf("Expected SyntaxError, got %v", err)
+			}
+			if syntaxErr.Line != tt.line {
+				t.Errorf("Expected error on line %d, got %d (%v)", tt.line, syntaxErr.Line, err)
+			}
+		})
+	}
+}
+
+func TestTOMLToJSONSpecialFloats(t *testing.T) {
+	for _, input := range []string{"a = inf\n", "a = -inf\n", "a = nan\n"} {
+		if _, err := TOMLToJSON([]byte(input)); err == nil {
+			t.Errorf("Expected %q to fail, as JSON has no infinity or NaN", input)
+		}
+	}
+}
@@ -0,0 +1,194 @@
+package dataformat
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"regexp"
+	"strconv"
+
+	"gopkg.in/yaml.v3"
+)
+
+// maxYAMLNodes bounds the nodes converted from one stream, so aliases
+// cannot expand a small document into an enormous one
+const maxYAMLNodes = 1 << 20
+
+// yamlErrorPattern matches the line number in errors from the YAML parser
+var yamlErrorPattern = regexp.MustCompile(`^yaml: line (\d+): (.*)$`)
+
+// YAMLToJSON converts a YAML document to JSON. A stream with several
+// documents is converted to a JSON array with one element per document.
+func YAMLToJSON(data []byte) ([]byte, error) {
+	c := &yamlConverter{}
+	var docs []interface{}
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	for {
+		var node yaml.Node
+		if err := decoder.Decode(&node); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, yamlSyntaxError(err)
+		}
+		doc, err := c.convert(&node)
+		if err != nil {
+			return nil, err
+		}
+		docs = append(docs, doc)
+	}
+
+	if len(docs) == 1 {
+		return json.Marshal(docs[0])
+	}
+	if docs == nil {
+		docs = []interface{}{}
+	}
+	return json.Marshal(docs)
+}
+
+// yamlSyntaxError converts a parser error to a SyntaxError when it names
+// a line
+func yamlSyntaxError(err error) error {
+	m := yamlErrorPattern.FindStringSubmatch(err.Error())
+	if m == nil {
+		return err
+	}
+	line, _ := strconv.Atoi(m[1])
+	return &SyntaxError{Format: "yaml", Line: line, Msg: m[2]}
+}
+
+// yamlConverter converts parsed YAML nodes to JSON values
+type yamlConverter struct {
+	nodes int // nodes converted so far, counting alias expansions
+}
+
+func (c *yamlConverter) convert(node *yaml.Node) (interface{}, error) {
+	if c.nodes++; c.nodes > maxYAMLNodes {
+		return nil, &SyntaxError{Format: "yaml", Line: node.Line, Msg: "document expands to too many nodes"}
+	}
+
+	switch node.Kind {
+	case yaml.DocumentNode:
+		if len(node.Content) == 0 {
+			return nil, nil
+		}
+		return c.convert(node.Content[0])
+	case yaml.AliasNode:
+		return c.convert(node.Alias)
+	case yaml.SequenceNode:
+		items := make([]interface{}, 0, len(node.Content))
+		for _, child := range node.Content {
+			item, err := c.convert(child)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, item)
+		}
+		return items, nil
+	case yaml.MappingNode:
+		return c.mapping(node)
+	default:
+		return c.scalar(node)
+	}
+}
+
+// mapping converts a mapping to an object keeping the key order. Keys
+// merged with << do not override the keys of the mapping itself.
+func (c *yamlConverter) mapping(node *yaml.Node) (interface{}, error) {
+	obj := newObject()
+	explicit := make(map[string]bool)
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		keyNode, valueNode := node.Content[i], node.Content[i+1]
+		if keyNode.Kind == yaml.ScalarNode && keyNode.ShortTag() == "!!merge" {
+			if err := c.merge(obj, valueNode); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		key, err := yamlKey(keyNode)
+		if err != nil {
+			return nil, err
+		}
+		if explicit[key] {
+			return nil, &SyntaxError{Format: "yaml", Line: keyNode.Line, Msg: fmt.Sprintf("duplicate key %q", key)}
+		}
+		explicit[key] = true
+
+		value, err := c.convert(valueNode)
+		if err != nil {
+			return nil, err
+		}
+		obj.set(key, value)
+	}
+	return obj, nil
+}
+
+// merge adds the keys of the mapping, or sequence of mappings, in node to
+// obj unless obj already has them. Keys set later in the mapping itself
+// replace merged ones.
+func (c *yamlConverter) merge(obj *object, node *yaml.Node) error {
+	sources := []*yaml.Node{node}
+	if resolved := resolveAlias(node); resolved.Kind == yaml.SequenceNode {
+		sources = resolved.Content
+	}
+
+	for _, source := range sources {
+		value, err := c.convert(source)
+		if err != nil {
+			return err
+		}
+		merged, ok := value.(*object)
+		if !ok {
+			return &SyntaxError{Format: "yaml", Line: source.Line, Msg: "merge value is not a mapping"}
+		}
+		for _, key := range merged.keys {
+			if _, exists := obj.get(key); !exists {
+				obj.set(key, merged.values[key])
+			}
+		}
+	}
+	return nil
+}
+
+// scalar converts a scalar by its resolved tag. Timestamps and values
+// with application tags stay strings.
+func (c *yamlConverter) scalar(node *yaml.Node) (interface{}, error) {
+	switch node.ShortTag() {
+	case "!!null":
+		return nil, nil
+	case "!!bool", "!!int", "!!float":
+		var value interface{}
+		if err := node.Decode(&value); err != nil {
+			return nil, yamlSyntaxError(err)
+		}
+		if f, ok := value.(float64); ok && (math.IsInf(f, 0) || math.IsNaN(f)) {
+			return nil, &SyntaxError{Format: "yaml", Line: node.Line, Msg: fmt.Sprintf("%s cannot be represented in JSON", node.Value)}
+		}
+		return value, nil
+	default:
+		return node.Value, nil
+	}
+}
+
+// yamlKey returns the JSON object key for a mapping key, which must be
+// a scalar
+func yamlKey(node *yaml.Node) (string, error) {
+	node = resolveAlias(node)
+	if node.Kind != yaml.ScalarNode {
+		return "", &SyntaxError{Format: "yaml", Line: node.Line, Msg: "mapping keys must be scalars"}
+	}
+	return node.Value, nil
+}
+
+// resolveAlias returns the node an alias refers to
+func resolveAlias(node *yaml.Node) *yaml.Node {
+	for node.Kind == yaml.AliasNode {
+		node = node.Alias
+	}
+	return node
+}
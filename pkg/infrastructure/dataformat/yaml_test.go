@@ -0,0 +1,122 @@
+package dataformat
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestYAMLToJSON(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{"empty", "", `[]`},
+		{"scalar", "hello", `"hello"`},
+		{
+			"mapping keeps order",
+			"b: 1\na: two\nc: true\nd: ~\ne: 1.5\nf: 0x1F\n",
+			`{"b":1,"a":"two","c":true,"d":null,"e":1.5,"f":31}`,
+		},
+		{
+			"nested mapping and sequence",
+			"server:\n  port: 8080\n  hosts:\n    - a\n    - b\nlist:\n- x\n- y\n",
+			`{"server":{"port":8080,"hosts":["a","b"]},"list":["x","y"]}`,
+		},
+		{
+			"sequence of mappings",
+			"- name: a\n  value: 1\n- name: b\n  value: 2\n",
+			`[{"name":"a","value":1},{"name":"b","value":2}]`,
+		},
+		{"nested sequences", "- - a\n  - b\n- c\n", `[["a","b"],"c"]`},
+		{
+			"comments and quotes",
+			"# header\na: 'it''s' # note\nb: \"tab\\there\"\nc: it's plain\nd: \"#not a comment\"\n",
+			`{"a":"it's","b":"tab\there","c":"it's plain","d":"#not a comment"}`,
+		},
+		{"quoted keys", "\"a b\": 1\n'c': 2\n", `{"a b":1,"c":2}`},
+		{"url value", "url: http://example.com:8080/x\n", `{"url":"http://example.com:8080/x"}`},
+		{"literal block", "text: |\n  line one\n  line two\nnext: 1\n", `{"text":"line one\nline two\n","next":1}`},
+		{"folded block strip", "text: >-\n  one\n  two\n\n  three\n", `{"text":"one two\nthree"}`},
+		{"keep chomping", "text: |+\n  a\n\n", `{"text":"a\n\n"}`},
+		{"block in sequence", "- |\n  a\n  b\n- c\n", `["a\nb\n","c"]`},
+		{"flow collections", "a: [1, two, {x: 1, y: [true]}]\nb: {}\n", `{"a":[1,"two",{"x":1,"y":[true]}],"b":{}}`},
+		{"multi-line flow", "a: [1,\n  2,\n  3]\n", `{"a":[1,2,3]}`},
+		{"plain continuation", "a: one\n  two\nb: 3\n", `{"a":"one two","b":3}`},
+		{"multiple documents", "---\na: 1\n---\nb: 2\n", `[{"a":1},{"b":2}]`},
+		{"explicit single document", "%YAML 1.1\n---\na: 1\n...\n", `{"a":1}`},
+		{"anchors and aliases", "base: &b {x: 1}\ncopy: *b\nname: &n cat\nalso: *n\n", `{"base":{"x":1},"copy":{"x":1},"name":"cat","also":"cat"}`},
+		{
+			"merge keys",
+			"base: &b\n  x: 1\n  y: 2\nchild:\n  y: 3\n  <<: *b\n  z: 4\n",
+			`{"base":{"x":1,"y":2},"child":{"y":3,"x":1,"z":4}}`,
+		},
+		{"tags", "a: !!str 1\nb: !!float 2\nc: !Ref name\n", `{"a":"1","b":2,"c":"name"}`},
+		{"timestamps stay strings", "a: 2001-12-14\n", `{"a":"2001-12-14"}`},
+		{"crlf line endings", "a: 1\r\nb: 2\r\n", `{"a":1,"b":2}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := YAMLToJSON([]byte(tt.input))
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if string(result) != tt.expected {
+				t.Errorf("Expected %s, got %s", tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestYAMLToJSONErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		line  int // 0 when the parser does not report the line
+	}{
+		{"nested mapping on one line", "a: b: c\n", 0},
+		{"unknown alias", "a: *x\n", 0},
+		{"duplicate key", "a: 1\na: 2\n", 2},
+		{"bad indentation", "a:\n    b: 1\n  c: 2\n", 2},
+		{"unterminated quote", "a: \"open\n", 2},
+		{"unterminated flow", "a: [1, 2\n", 1},
+		{"infinity", "a: .inf\n", 1},
+		{"complex key", "? [a]\n: 1\n", 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := YAMLToJSON([]byte(tt.input))
+			if err == nil {
+				t.Fatal("Expected an error")
+			}
+			if tt.line == 0 {
+				return
+			}
+			var syntaxErr *SyntaxError
+			if !errors.As(err, &syntaxErr) {
+				t.Fatalf("Expected SyntaxError, got %v", err)
+			}
+			if syntaxErr.Line != tt.line {
+				t.Errorf("Expected error on line %d, got %d (%v)", tt.line, syntaxErr.Line, err)
+			}
+		})
+	}
+}
+
+func TestYAMLToJSONAliasExpansion(t *testing.T) {
+	// Each level refers to the previous one ten times, a billion laughs
+	doc := "a0: &a0 [x, x, x, x, x, x, x, x, x, x]\n"
+	for i := 1; i < 9; i++ {
+		doc += fmt.Sprintf("a%d: &a%d [*a%d, *a%d, *a%d, *a%d, *a%d, *a%d, *a%d, *a%d, *a%d, *a%d]\n",
+			i, i, i-1, i-1, i-1, i-1, i-1, i-1, i-1, i-1, i-1, i-1)
+	}
+
+	_, err := YAMLToJSON([]byte(doc))
+	var syntaxErr *SyntaxError
+	if !errors.As(err, &syntaxErr) {
+		t.Fatalf("Expected SyntaxError, got %v", err)
+	}
+}
@@ -0,0 +1,624 @@
+package filesystem
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sh05/cat-server/pkg/domain/entities"
+	"github.com/sh05/cat-server/pkg/domain/repositories"
+	"github.com/sh05/cat-server/pkg/domain/valueobjects"
+	"github.com/sh05/cat-server/pkg/infrastructure/cache"
+)
+
+// ArchiveSeparator separates an archive file from a path inside it,
+// e.g. "logs.tar.gz!/app.log"
+const ArchiveSeparator = "!/"
+
+// Limits on the archives that are indexed, so that an archive with millions
+// of entries or a zip bomb cannot exhaust memory or CPU
+const (
+	// maxArchiveEntries is the most entries an archive may hold
+	maxArchiveEntries = 100_000
+	// maxArchiveUncompressedBytes is the largest total uncompressed size of
+	// the entries of an archive
+	maxArchiveUncompressedBytes = 1 << 30
+	// maxArchiveCompressionRatio bounds the total uncompressed size relative
+	// to the archive size, so indexing a small gzip bomb stays cheap
+	maxArchiveCompressionRatio = 100
+	// maxArchiveEntryBytes caps an entry extracted into memory when the
+	// repository has no maximum file size
+	maxArchiveEntryBytes = 64 << 20
+	// archiveIndexCacheBytes is the memory kept for archive indexes
+	archiveIndexCacheBytes = 32 << 20
+	// archiveIndexEntryBytes estimates the memory of an index entry besides
+	// its name
+	archiveIndexEntryBytes = 96
+)
+
+var (
+	errTooManyArchiveEntries = errors.New("archive has too many entries")
+	errArchiveTooLarge       = errors.New("archive uncompressed size is too large")
+	errArchiveEntryTooLarge  = errors.New("archive entry is too large")
+)
+
+// archiveFormat identifies a supported archive container
+type archiveFormat int
+
+const (
+	archiveNone archiveFormat = iota
+	archiveZip
+	archiveTar
+	archiveTarGz
+)
+
+// ArchiveRepository decorates a FileSystemRepository with a read-only virtual
+// filesystem over .zip, .tar and .tar.gz files. Paths containing
+// ArchiveSeparator are resolved inside the archive; all other paths are
+// delegated to the wrapped repository.
+type ArchiveRepository struct {
+	repositories.FileSystemRepository
+	maxFileSize int64
+	limits      archiveLimits
+
+	indexes *cache.SizedLRU[*archiveIndex] // keyed by archive path
+}
+
+// archiveLimits bounds the archives that are indexed
+type archiveLimits struct {
+	entries           int
+	uncompressedBytes uint64
+	compressionRatio  uint64 // 0 means no ratio limit
+}
+
+// archiveIndex holds the metadata of every entry in an archive
+type archiveIndex struct {
+	modTime time.Time
+	size    int64
+	entries map[string]archiveEntryInfo // keyed by cleaned inner path; "" is the root
+}
+
+// memorySize estimates the memory held by the index
+func (index *archiveIndex) memorySize() int64 {
+	size := int64(0)
+	for name := range index.entries {
+		size += 2*int64(len(name)) + archiveIndexEntryBytes
+	}
+	return size
+}
+
+type archiveEntryInfo struct {
+	name    string
+	size    int64
+	modTime time.Time
+	isDir   bool
+}
+
+// NewArchiveRepository wraps base so archive contents can be browsed
+func NewArchiveRepository(base repositories.FileSystemRepository, maxFileSize int64) *ArchiveRepository {
+	return &ArchiveRepository{
+		FileSystemRepository: base,
+		maxFileSize:          maxFileSize,
+		limits: archiveLimits{
+			entries:           maxArchiveEntries,
+			uncompressedBytes: maxArchiveUncompressedBytes,
+			compressionRatio:  maxArchiveCompressionRatio,
+		},
+		indexes: cache.NewSizedLRU(archiveIndexCacheBytes, (*archiveIndex).memorySize, nil),
+	}
+}
+
+// ListDirectory returns a directory listing, looking inside archives when needed
+func (r *ArchiveRepository) ListDirectory(ctx context.Context, p *valueobjects.FilePath) (*entities.DirectoryListing, error) {
+	archivePath, inner, ok := splitArchivePath(p.String())
+	if !ok {
+		return r.FileSystemRepository.ListDirectory(ctx, p)
+	}
+
+	index, err := r.loadIndex(ctx, archivePath)
+	if err != nil {
+		return nil, err
+	}
+
+	info, found := index.entries[inner]
+	if !found {
+		return nil, repositories.NewFileSystemError("ListDirectory", p.String(), "directory not found", repositories.ErrorNotFound)
+	}
+	if !info.isDir {
+		return nil, repositories.NewFileSystemError("ListDirectory", p.String(), "path is not a directory", repositories.ErrorInvalidPath)
+	}
+
+	children := []entities.FileSystemEntry{}
+	for name, child := range index.entries {
+		if name == "" || path.Dir(name) != innerDir(inner) {
+			continue
+		}
+		entry, err := newArchiveEntity(archivePath, name, child)
+		if err != nil {
+			continue // Skip invalid entries
+		}
+		children = append(children, *entry)
+	}
+	if children == nil {
+		children = []entities.FileSystemEntry{}
+	}
+	sort.Slice(children, func(i, j int) bool { return children[i].Name() < children[j].Name() })
+
+	listing, err := entities.NewDirectoryListing(p.String(), children)
+	if err != nil {
+		return nil, repositories.NewFileSystemError("ListDirectory", p.String(), err.Error(), repositories.ErrorUnknown)
+	}
+	return listing, nil
+}
+
+// ReadFile returns the content of a file, extracting it from an archive when needed
+func (r *ArchiveRepository) ReadFile(ctx context.Context, p *valueobjects.FilePath) (*entities.FileContent, error) {
+	archivePath, inner, ok := splitArchivePath(p.String())
+	if !ok {
+		return r.FileSystemRepository.ReadFile(ctx, p)
+	}
+
+	entry, err := r.GetFileInfo(ctx, p)
+	if err != nil {
+		return nil, err
+	}
+	if entry.IsDir() {
+		return nil, repositories.NewFileSystemError("ReadFile", p.String(), "path is a directory", repositories.ErrorInvalidPath)
+	}
+	maxSize := r.maxEntrySize()
+	if entry.Size() > maxSize {
+		return nil, repositories.NewFileSystemError("ReadFile", p.String(), "file too large", repositories.ErrorFileTooLarge)
+	}
+
+	file, size, format, err := r.openArchive(ctx, archivePath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	content, err := extractArchiveEntry(file, size, format, inner, maxSize)
+	if errors.Is(err, errArchiveEntryTooLarge) {
+		return nil, repositories.NewFileSystemError("ReadFile", p.String(), "file too large", repositories.ErrorFileTooLarge)
+	}
+	if err != nil {
+		return nil, repositories.NewFileSystemError("ReadFile", p.String(), err.Error(), repositories.ErrorUnknown)
+	}
+
+	fileContent, err := entities.NewFileContent(entry, content, "utf-8")
+	if err != nil {
+		return nil, repositories.NewFileSystemError("ReadFile", p.String(), err.Error(), repositories.ErrorUnknown)
+	}
+	return fileContent, nil
+}
+
+// OpenFile opens a file for streaming reads. Archive entries are extracted
+// into memory first, since compressed entries cannot be seeked into, so
+// they are refused above the maximum file size like ReadFile does.
+func (r *ArchiveRepository) OpenFile(ctx context.Context, p *valueobjects.FilePath) (io.ReadSeekCloser, error) {
+	if _, _, ok := splitArchivePath(p.String()); !ok {
+		return r.FileSystemRepository.OpenFile(ctx, p)
+	}
+
+	content, err := r.ReadFile(ctx, p)
+	if err != nil {
+		return nil, err
+	}
+	return nopSeekCloser{bytes.NewReader(content.Content())}, nil
+}
+
+// nopSeekCloser adds a no-op Close method to an in-memory reader
+type nopSeekCloser struct {
+	io.ReadSeeker
+}
+
+func (nopSeekCloser) Close() error { return nil }
+
+// maxEntrySize returns the largest archive entry extracted into memory
+func (r *ArchiveRepository) maxEntrySize() int64 {
+	if r.maxFileSize > 0 {
+		return r.maxFileSize
+	}
+	return maxArchiveEntryBytes
+}
+
+// Exists checks if a file or directory exists, including inside archives
+func (r *ArchiveRepository) Exists(ctx context.Context, p *valueobjects.FilePath) bool {
+	archivePath, inner, ok := splitArchivePath(p.String())
+	if !ok {
+		return r.FileSystemRepository.Exists(ctx, p)
+	}
+
+	index, err := r.loadIndex(ctx, archivePath)
+	if err != nil {
+		return false
+	}
+	_, found := index.entries[inner]
+	return found
+}
+
+// IsReadable checks if the path is readable, including inside archives
+func (r *ArchiveRepository) IsReadable(ctx context.Context, p *valueobjects.FilePath) bool {
+	if _, _, ok := splitArchivePath(p.String()); !ok {
+		return r.FileSystemRepository.IsReadable(ctx, p)
+	}
+	return r.Exists(ctx, p)
+}
+
+// IsDirectory checks if the path is a directory, including inside archives
+func (r *ArchiveRepository) IsDirectory(ctx context.Context, p *valueobjects.FilePath) bool {
+	archivePath, inner, ok := splitArchivePath(p.String())
+	if !ok {
+		return r.FileSystemRepository.IsDirectory(ctx, p)
+	}
+
+	index, err := r.loadIndex(ctx, archivePath)
+	if err != nil {
+		return false
+	}
+	info, found := index.entries[inner]
+	return found && info.isDir
+}
+
+// GetFileInfo returns information about a path, including inside archives
+func (r *ArchiveRepository) GetFileInfo(ctx context.Context, p *valueobjects.FilePath) (*entities.FileSystemEntry, error) {
+	archivePath, inner, ok := splitArchivePath(p.String())
+	if !ok {
+		return r.FileSystemRepository.GetFileInfo(ctx, p)
+	}
+
+	index, err := r.loadIndex(ctx, archivePath)
+	if err != nil {
+		return nil, err
+	}
+
+	info, found := index.entries[inner]
+	if !found {
+		return nil, repositories.NewFileSystemError("GetFileInfo", p.String(), "file not found", repositories.ErrorNotFound)
+	}
+
+	entry, err := newArchiveEntity(archivePath, inner, info)
+	if err != nil {
+		return nil, repositories.NewFileSystemError("GetFileInfo", p.String(), err.Error(), repositories.ErrorUnknown)
+	}
+	return entry, nil
+}
+
+// ValidatePath validates the archive file's location; inner paths are only
+// ever matched against archive entries and never touch the filesystem
+func (r *ArchiveRepository) ValidatePath(p *valueobjects.FilePath) error {
+	archivePath, _, ok := splitArchivePath(p.String())
+	if !ok {
+		return r.FileSystemRepository.ValidatePath(p)
+	}
+
+	if !p.IsSecure() {
+		return repositories.NewFileSystemError("ValidatePath", p.String(), "insecure path detected", repositories.ErrorPathTraversal)
+	}
+
+	archiveFilePath, err := valueobjects.NewFilePath(archivePath)
+	if err != nil {
+		return repositories.NewFileSystemError("ValidatePath", p.String(), err.Error(), repositories.ErrorInvalidPath)
+	}
+	return r.FileSystemRepository.ValidatePath(archiveFilePath)
+}
+
+// GetDirectoryStats returns statistics about a directory, including inside archives
+func (r *ArchiveRepository) GetDirectoryStats(ctx context.Context, p *valueobjects.FilePath) (*repositories.DirectoryStats, error) {
+	if _, _, ok := splitArchivePath(p.String()); !ok {
+		return r.FileSystemRepository.GetDirectoryStats(ctx, p)
+	}
+
+	listing, err := r.ListDirectory(ctx, p)
+	if err != nil {
+		return nil, err
+	}
+	return repositories.NewDirectoryStats(listing), nil
+}
+
+// Helper methods
+
+// loadIndex returns the index of an archive, rebuilding it when the archive changed
+func (r *ArchiveRepository) loadIndex(ctx context.Context, archivePath string) (*archiveIndex, error) {
+	archiveFilePath, err := valueobjects.NewFilePath(archivePath)
+	if err != nil {
+		return nil, repositories.NewFileSystemError("OpenArchive", archivePath, err.Error(), repositories.ErrorInvalidPath)
+	}
+
+	info, err := r.FileSystemRepository.GetFileInfo(ctx, archiveFilePath)
+	if err != nil {
+		return nil, err
+	}
+
+	cached, ok := r.indexes.Get(archivePath)
+	if ok && cached.size == info.Size() && cached.modTime.Equal(info.ModTime()) {
+		return cached, nil
+	}
+
+	file, size, format, err := r.openArchive(ctx, archivePath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	index, err := buildArchiveIndex(file, size, format, r.limits)
+	if errors.Is(err, errTooManyArchiveEntries) || errors.Is(err, errArchiveTooLarge) {
+		return nil, repositories.NewFileSystemError("OpenArchive", archivePath, err.Error(), repositories.ErrorFileTooLarge)
+	}
+	if err != nil {
+		return nil, repositories.NewFileSystemError("OpenArchive", archivePath, err.Error(), repositories.ErrorInvalidPath)
+	}
+	index.size = info.Size()
+	index.modTime = info.ModTime()
+
+	r.indexes.Add(archivePath, index)
+	return index, nil
+}
+
+// openArchive opens an archive through the wrapped repository and returns
+// it with its size
+func (r *ArchiveRepository) openArchive(ctx context.Context, archivePath string) (io.ReadSeekCloser, int64, archiveFormat, error) {
+	format := archiveFormatOf(archivePath)
+	if format == archiveNone {
+		return nil, 0, archiveNone, repositories.NewFileSystemError("OpenArchive", archivePath, "not a supported archive", repositories.ErrorInvalidPath)
+	}
+
+	archiveFilePath, err := valueobjects.NewFilePath(archivePath)
+	if err != nil {
+		return nil, 0, archiveNone, repositories.NewFileSystemError("OpenArchive", archivePath, err.Error(), repositories.ErrorInvalidPath)
+	}
+
+	file, err := r.FileSystemRepository.OpenFile(ctx, archiveFilePath)
+	if err != nil {
+		return nil, 0, archiveNone, err
+	}
+	size, err := file.Seek(0, io.SeekEnd)
+	if err == nil {
+		_, err = file.Seek(0, io.SeekStart)
+	}
+	if err != nil {
+		file.Close()
+		return nil, 0, archiveNone, repositories.NewFileSystemError("OpenArchive", archivePath, err.Error(), repositories.ErrorUnknown)
+	}
+	return file, size, format, nil
+}
+
+// readerAt returns f as an io.ReaderAt for zip.NewReader. Files opened from
+// disk implement it; other readers are read through Seek.
+func readerAt(f io.ReadSeeker) io.ReaderAt {
+	if ra, ok := f.(io.ReaderAt); ok {
+		return ra
+	}
+	return &seekReaderAt{r: f}
+}
+
+// seekReaderAt implements io.ReaderAt by seeking before each read
+type seekReaderAt struct {
+	mu sync.Mutex
+	r  io.ReadSeeker
+}
+
+func (s *seekReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, err := s.r.Seek(off, io.SeekStart); err != nil {
+		return 0, err
+	}
+	return io.ReadFull(s.r, p)
+}
+
+// splitArchivePath splits "a.zip!/dir/file" into ("a.zip", "dir/file").
+// The archive root may be written as "a.zip!" or "a.zip!/".
+func splitArchivePath(p string) (archivePath, inner string, ok bool) {
+	p = strings.ReplaceAll(p, "\\", "/")
+	if i := strings.Index(p, ArchiveSeparator); i >= 0 {
+		archivePath, inner = p[:i], p[i+len(ArchiveSeparator):]
+	} else if strings.HasSuffix(p, "!") {
+		archivePath = strings.TrimSuffix(p, "!")
+	} else {
+		return "", "", false
+	}
+
+	if archiveFormatOf(archivePath) == archiveNone {
+		return "", "", false
+	}
+	return archivePath, cleanInnerPath(inner), true
+}
+
+func archiveFormatOf(name string) archiveFormat {
+	lower := strings.ToLower(name)
+	switch {
+	case strings.HasSuffix(lower, ".zip"):
+		return archiveZip
+	case strings.HasSuffix(lower, ".tar.gz"), strings.HasSuffix(lower, ".tgz"):
+		return archiveTarGz
+	case strings.HasSuffix(lower, ".tar"):
+		return archiveTar
+	default:
+		return archiveNone
+	}
+}
+
+// cleanInnerPath normalizes an archive entry name; the root is ""
+func cleanInnerPath(name string) string {
+	cleaned := path.Clean("/" + strings.ReplaceAll(name, "\\", "/"))
+	return strings.TrimPrefix(cleaned, "/")
+}
+
+// innerDir returns the path.Dir-compatible key for children of dir
+func innerDir(dir string) string {
+	if dir == "" {
+		return "."
+	}
+	return dir
+}
+
+func newArchiveEntity(archivePath, inner string, info archiveEntryInfo) (*entities.FileSystemEntry, error) {
+	name := path.Base(inner)
+	if inner == "" {
+		name = path.Base(archivePath)
+	}
+
+	mode := os.FileMode(0444)
+	if info.isDir {
+		mode = os.ModeDir | 0555
+	}
+
+	return entities.NewFileSystemEntry(name, archivePath+ArchiveSeparator+inner, info.size, info.modTime, info.isDir, mode)
+}
+
+// buildArchiveIndex lists every entry of an archive of the given size,
+// synthesizing parent directories that are not stored explicitly. Archives
+// with more entries or uncompressed contents than limits allow, in total
+// or relative to their size, are refused.
+func buildArchiveIndex(file io.ReadSeeker, size int64, format archiveFormat, limits archiveLimits) (*archiveIndex, error) {
+	index := &archiveIndex{entries: map[string]archiveEntryInfo{"": {isDir: true}}}
+
+	maxTotal := limits.uncompressedBytes
+	if limits.compressionRatio > 0 {
+		maxTotal = min(maxTotal, uint64(max(size, 0))*limits.compressionRatio)
+	}
+	total := uint64(0)
+	add := func(name string, entrySize uint64, modTime time.Time, isDir bool) error {
+		if entrySize > maxTotal {
+			return errArchiveTooLarge
+		}
+		if total += entrySize; total > maxTotal {
+			return errArchiveTooLarge
+		}
+
+		inner := cleanInnerPath(name)
+		if inner == "" {
+			return nil
+		}
+		index.entries[inner] = archiveEntryInfo{name: inner, size: int64(entrySize), modTime: modTime, isDir: isDir}
+		for dir := path.Dir(inner); dir != "."; dir = path.Dir(dir) {
+			if _, ok := index.entries[dir]; !ok {
+				index.entries[dir] = archiveEntryInfo{name: dir, modTime: modTime, isDir: true}
+			}
+		}
+		return nil
+	}
+
+	switch format {
+	case archiveZip:
+		zr, err := zip.NewReader(readerAt(file), size)
+		if err != nil {
+			return nil, err
+		}
+		if len(zr.File) > limits.entries {
+			return nil, errTooManyArchiveEntries
+		}
+		for _, f := range zr.File {
+			if err := add(f.Name, f.UncompressedSize64, f.Modified, f.FileInfo().IsDir()); err != nil {
+				return nil, err
+			}
+		}
+	case archiveTar, archiveTarGz:
+		tr, err := newTarReader(file, format)
+		if err != nil {
+			return nil, err
+		}
+		for entries := 1; ; entries++ {
+			header, err := tr.Next()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return nil, err
+			}
+			if entries > limits.entries {
+				return nil, errTooManyArchiveEntries
+			}
+			// The next header is only reached by reading past this entry,
+			// so the limits are checked before
+			switch header.Typeflag {
+			case tar.TypeDir:
+				err = add(header.Name, 0, header.ModTime, true)
+			case tar.TypeReg:
+				err = add(header.Name, uint64(max(header.Size, 0)), header.ModTime, false)
+			}
+			if err != nil {
+				return nil, err
+			}
+		}
+	default:
+		return nil, errors.New("unsupported archive format")
+	}
+
+	return index, nil
+}
+
+// extractArchiveEntry returns the content of a single file inside an
+// archive of the given size, reading at most maxSize bytes of it
+func extractArchiveEntry(file io.ReadSeeker, size int64, format archiveFormat, inner string, maxSize int64) ([]byte, error) {
+	limit := func(r io.Reader) ([]byte, error) {
+		content, err := io.ReadAll(io.LimitReader(r, maxSize+1))
+		if err != nil {
+			return nil, err
+		}
+		if int64(len(content)) > maxSize {
+			return nil, errArchiveEntryTooLarge
+		}
+		return content, nil
+	}
+
+	switch format {
+	case archiveZip:
+		zr, err := zip.NewReader(readerAt(file), size)
+		if err != nil {
+			return nil, err
+		}
+		for _, f := range zr.File {
+			if cleanInnerPath(f.Name) != inner || f.FileInfo().IsDir() {
+				continue
+			}
+			rc, err := f.Open()
+			if err != nil {
+				return nil, err
+			}
+			defer rc.Close()
+			return limit(rc)
+		}
+	case archiveTar, archiveTarGz:
+		tr, err := newTarReader(file, format)
+		if err != nil {
+			return nil, err
+		}
+		for {
+			header, err := tr.Next()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return nil, err
+			}
+			if header.Typeflag == tar.TypeReg && cleanInnerPath(header.Name) == inner {
+				return limit(tr)
+			}
+		}
+	}
+
+	return nil, fmt.Errorf("entry not found: %s", inner)
+}
+
+func newTarReader(r io.Reader, format archiveFormat) (*tar.Reader, error) {
+	if format == archiveTarGz {
+		gz, err := gzip.NewReader(r)
+		if err != nil {
+			return nil, err
+		}
+		r = gz
+	}
+	return tar.NewReader(r), nil
+}
@@ -0,0 +1,276 @@
+package filesystem
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sh05/cat-server/pkg/domain/repositories"
+	"github.com/sh05/cat-server/pkg/domain/valueobjects"
+)
+
+func writeTestArchives(t *testing.T, dir string) {
+	t.Helper()
+
+	var zipBuf bytes.Buffer
+	zw := zip.NewWriter(&zipBuf)
+	for name, content := range map[string]string{"app.log": "hello\n", "nested/dir/deep.txt": "deep\n"} {
+		fw, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		fw.Write([]byte(content))
+	}
+	zw.Close()
+	if err := os.WriteFile(filepath.Join(dir, "bundle.zip"), zipBuf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var tarBuf bytes.Buffer
+	gw := gzip.NewWriter(&tarBuf)
+	tw := tar.NewWriter(gw)
+	content := []byte("tarred\n")
+	tw.WriteHeader(&tar.Header{Name: "./logs/app.log", Mode: 0644, Size: int64(len(content)), Typeflag: tar.TypeReg})
+	tw.Write(content)
+	tw.Close()
+	gw.Close()
+	if err := os.WriteFile(filepath.Join(dir, "logs.tar.gz"), tarBuf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestArchiveRepository(t *testing.T) {
+	dir := t.TempDir()
+	writeTestArchives(t, dir)
+	repo := NewArchiveRepository(NewFileSystemRepository(dir, 1024*1024), 1024*1024)
+
+	readTests := []struct {
+		path    string
+		content string
+	}{
+		{"bundle.zip!/app.log", "hello\n"},
+		{"bundle.zip!/nested/dir/deep.txt", "deep\n"},
+		{"logs.tar.gz!/logs/app.log", "tarred\n"},
+	}
+	for _, tt := range readTests {
+		p, _ := valueobjects.NewFilePath(tt.path)
+		fc, err := repo.ReadFile(context.Background(), p)
+		if err != nil {
+			t.Errorf("ReadFile(%q) returned error: %v", tt.path, err)
+			continue
+		}
+		if string(fc.Content()) != tt.content {
+			t.Errorf("Expected %q, got %q", tt.content, fc.Content())
+		}
+
+		// Entries are streamed from memory but must still seek, for range requests
+		r, err := repo.OpenFile(context.Background(), p)
+		if err != nil {
+			t.Errorf("OpenFile(%q) returned error: %v", tt.path, err)
+			continue
+		}
+		if _, err := r.Seek(1, io.SeekStart); err != nil {
+			t.Errorf("Seek in %q returned error: %v", tt.path, err)
+		}
+		rest, _ := io.ReadAll(r)
+		r.Close()
+		if string(rest) != tt.content[1:] {
+			t.Errorf("Expected %q after seeking, got %q", tt.content[1:], rest)
+		}
+	}
+
+	listTests := []struct {
+		path  string
+		names []string
+	}{
+		{"bundle.zip!/", []string{"app.log", "nested"}},
+		{"bundle.zip!/nested", []string{"dir"}},
+		{"logs.tar.gz!", []string{"logs"}},
+	}
+	for _, tt := range listTests {
+		p, _ := valueobjects.NewFilePath(tt.path)
+		listing, err := repo.ListDirectory(context.Background(), p)
+		if err != nil {
+			t.Errorf("ListDirectory(%q) returned error: %v", tt.path, err)
+			continue
+		}
+		entries := listing.Entries()
+		if len(entries) != len(tt.names) {
+			t.Errorf("Expected %d entries in %q, got %d", len(tt.names), tt.path, len(entries))
+			continue
+		}
+		for i, name := range tt.names {
+			if entries[i].Name() != name {
+				t.Errorf("Expected entry %q, got %q", name, entries[i].Name())
+			}
+		}
+	}
+
+	missing, _ := valueobjects.NewFilePath("bundle.zip!/missing.txt")
+	if repo.Exists(context.Background(), missing) {
+		t.Error("Expected missing archive entry not to exist")
+	}
+	var fsErr *repositories.FileSystemError
+	if _, err := repo.GetFileInfo(context.Background(), missing); !errors.As(err, &fsErr) || fsErr.Code != repositories.ErrorNotFound {
+		t.Errorf("Expected not found error, got %v", err)
+	}
+
+	nested, _ := valueobjects.NewFilePath("bundle.zip!/nested/dir")
+	if !repo.IsDirectory(context.Background(), nested) {
+		t.Error("Expected implicit archive directory to be a directory")
+	}
+}
+
+func TestArchiveRepositoryLimits(t *testing.T) {
+	dir := t.TempDir()
+	writeTestArchives(t, dir)
+
+	tests := []struct {
+		name    string
+		limits  archiveLimits
+		path    string
+		refused bool
+	}{
+		{"within limits", archiveLimits{entries: 2, uncompressedBytes: 11}, "bundle.zip!/app.log", false},
+		{"too many zip entries", archiveLimits{entries: 1, uncompressedBytes: 1 << 20}, "bundle.zip!/app.log", true},
+		{"zip too large", archiveLimits{entries: 10, uncompressedBytes: 10}, "bundle.zip!/app.log", true},
+		{"tar within limits", archiveLimits{entries: 1, uncompressedBytes: 7}, "logs.tar.gz!/logs/app.log", false},
+		{"tar too large", archiveLimits{entries: 1, uncompressedBytes: 6}, "logs.tar.gz!/logs/app.log", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := NewArchiveRepository(NewFileSystemRepository(dir, 1024*1024), 1024*1024)
+			repo.limits = tt.limits
+
+			p, _ := valueobjects.NewFilePath(tt.path)
+			_, err := repo.GetFileInfo(context.Background(), p)
+			var fsErr *repositories.FileSystemError
+			switch {
+			case tt.refused && (!errors.As(err, &fsErr) || fsErr.Code != repositories.ErrorFileTooLarge):
+				t.Errorf("Expected a file too large error, got %v", err)
+			case !tt.refused && err != nil:
+				t.Errorf("Expected the entry to be found, got %v", err)
+			}
+		})
+	}
+}
+
+func TestArchiveRepositoryCompressionRatio(t *testing.T) {
+	dir := t.TempDir()
+
+	// A megabyte of zeros compresses to about a kilobyte
+	var buf bytes.Buffer
+	gw := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gw)
+	zeros := make([]byte, 1<<20)
+	tw.WriteHeader(&tar.Header{Name: "zeros", Mode: 0644, Size: int64(len(zeros)), Typeflag: tar.TypeReg})
+	tw.Write(zeros)
+	tw.Close()
+	gw.Close()
+	if err := os.WriteFile(filepath.Join(dir, "bomb.tar.gz"), buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	repo := NewArchiveRepository(NewFileSystemRepository(dir, 1<<30), 1<<30)
+	p, _ := valueobjects.NewFilePath("bomb.tar.gz!/zeros")
+	_, err := repo.GetFileInfo(context.Background(), p)
+	var fsErr *repositories.FileSystemError
+	if !errors.As(err, &fsErr) || fsErr.Code != repositories.ErrorFileTooLarge {
+		t.Errorf("Expected a file too large error, got %v", err)
+	}
+}
+
+func TestArchiveRepositoryOpenFileMaxSize(t *testing.T) {
+	dir := t.TempDir()
+	writeTestArchives(t, dir)
+	repo := NewArchiveRepository(NewFileSystemRepository(dir, 1024*1024), 4)
+
+	for _, name := range []string{"bundle.zip!/app.log", "logs.tar.gz!/logs/app.log"} {
+		p, _ := valueobjects.NewFilePath(name)
+		_, err := repo.OpenFile(context.Background(), p)
+		var fsErr *repositories.FileSystemError
+		if !errors.As(err, &fsErr) || fsErr.Code != repositories.ErrorFileTooLarge {
+			t.Errorf("%s: expected a file too large error, got %v", name, err)
+		}
+	}
+}
+
+func TestArchiveIndexCache(t *testing.T) {
+	dir := t.TempDir()
+	writeTestArchives(t, dir)
+	repo := NewArchiveRepository(NewFileSystemRepository(dir, 1024*1024), 1024*1024)
+
+	p, _ := valueobjects.NewFilePath("bundle.zip!/app.log")
+	if !repo.Exists(context.Background(), p) {
+		t.Fatal("Expected the entry to exist")
+	}
+	if repo.indexes.Len() != 1 {
+		t.Fatalf("Expected the index to be cached, got %d indexes", repo.indexes.Len())
+	}
+
+	// A changed archive is indexed again
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	zw.Create("other.log")
+	zw.Close()
+	if err := os.WriteFile(filepath.Join(dir, "bundle.zip"), buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if repo.Exists(context.Background(), p) {
+		t.Error("Expected the entry of the replaced archive to be gone")
+	}
+}
+
+// readSeeker hides the io.ReaderAt of the wrapped reader
+type readSeeker struct {
+	io.ReadSeeker
+}
+
+func TestBuildArchiveIndexWithoutReaderAt(t *testing.T) {
+	dir := t.TempDir()
+	writeTestArchives(t, dir)
+	data, err := os.ReadFile(filepath.Join(dir, "bundle.zip"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	limits := archiveLimits{entries: maxArchiveEntries, uncompressedBytes: maxArchiveUncompressedBytes}
+	index, err := buildArchiveIndex(readSeeker{bytes.NewReader(data)}, int64(len(data)), archiveZip, limits)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := index.entries["nested/dir/deep.txt"]; !ok {
+		t.Errorf("Expected the nested entry in the index, got %v", index.entries)
+	}
+}
+
+func TestSplitArchivePath(t *testing.T) {
+	tests := []struct {
+		input   string
+		archive string
+		inner   string
+		ok      bool
+	}{
+		{"logs.tar.gz!/app.log", "logs.tar.gz", "app.log", true},
+		{"a/b.zip!/x/../y.txt", "a/b.zip", "y.txt", true},
+		{"b.zip!", "b.zip", "", true},
+		{"b.zip!/", "b.zip", "", true},
+		{"notes.txt!/x", "", "", false},
+		{"plain/file.txt", "", "", false},
+	}
+
+	for _, tt := range tests {
+		archive, inner, ok := splitArchivePath(tt.input)
+		if archive != tt.archive || inner != tt.inner || ok != tt.ok {
+			t.Errorf("splitArchivePath(%q) = (%q, %q, %v), expected (%q, %q, %v)",
+				tt.input, archive, inner, ok, tt.archive, tt.inner, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package filesystem
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// errEscapesBase is returned when resolving a path would leave the base
+// directory or pass through a symlink
+var errEscapesBase = errors.New("path escapes the base directory or contains a symlink")
+
+// relativeName converts a repository path to a name relative to the base
+// directory. Leading slashes are dropped, as filepath.Join would; ".."
+// components are kept so that resolution rejects them.
+func relativeName(name string) string {
+	rel := strings.TrimLeft(filepath.FromSlash(name), string(filepath.Separator))
+	if rel == "" {
+		return "."
+	}
+	return rel
+}
+
+// openInRoot is the portable form of openBeneath built on os.Root, which
+// keeps resolution inside base without races. Symlinks are rejected up
+// front to match RESOLVE_NO_SYMLINKS; a symlink swapped in afterwards still
+// cannot lead outside base.
+func openInRoot(base, name string) (*os.File, error) {
+	root, rel, err := openRoot(base, name)
+	if err != nil {
+		return nil, err
+	}
+	defer root.Close()
+	return root.Open(rel)
+}
+
+// statInRoot is the portable form of statBeneath
+func statInRoot(base, name string) (os.FileInfo, error) {
+	root, rel, err := openRoot(base, name)
+	if err != nil {
+		return nil, err
+	}
+	defer root.Close()
+	return root.Stat(rel)
+}
+
+// openRoot opens base as an os.Root after checking that name is local and
+// free of symlinks
+func openRoot(base, name string) (*os.Root, string, error) {
+	rel := relativeName(name)
+	if !filepath.IsLocal(rel) {
+		return nil, "", &os.PathError{Op: "open", Path: rel, Err: errEscapesBase}
+	}
+
+	root, err := os.OpenRoot(base)
+	if err != nil {
+		return nil, "", err
+	}
+	for p := rel; p != "."; p = filepath.Dir(p) {
+		if info, err := root.Lstat(p); err == nil && info.Mode()&os.ModeSymlink != 0 {
+			root.Close()
+			return nil, "", &os.PathError{Op: "open", Path: rel, Err: errEscapesBase}
+		}
+	}
+	return root, rel, nil
+}
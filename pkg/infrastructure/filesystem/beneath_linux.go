@@ -0,0 +1,84 @@
+package filesystem
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+
+	"golang.org/x/sys/unix"
+)
+
+// openat2Retries bounds retries of EAGAIN, which openat2 returns when a
+// concurrent rename may have raced with the resolution
+const openat2Retries = 16
+
+// openat2Unsupported is set once the kernel (before 5.6) or a seccomp
+// policy reports ENOSYS, switching to the os.Root fallback
+var openat2Unsupported atomic.Bool
+
+// errNoOpenat2 makes callers use the os.Root fallback
+var errNoOpenat2 = errors.New("openat2 not supported")
+
+// openBeneath opens name for reading relative to base. The kernel refuses to
+// resolve outside base or through any symlink, so a path checked earlier
+// cannot be redirected by a concurrent rename or symlink swap.
+func openBeneath(base, name string) (*os.File, error) {
+	file, err := openat2(base, name, unix.O_RDONLY)
+	if err == errNoOpenat2 {
+		return openInRoot(base, name)
+	}
+	return file, err
+}
+
+// statBeneath returns the metadata of name relative to base, resolved like
+// openBeneath. It does not need read permission on the file.
+func statBeneath(base, name string) (os.FileInfo, error) {
+	file, err := openat2(base, name, unix.O_PATH)
+	if err == errNoOpenat2 {
+		return statInRoot(base, name)
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return file.Stat()
+}
+
+// openat2 opens name beneath base with the given flags. It returns
+// errNoOpenat2 when the system call is unavailable.
+func openat2(base, name string, flags int) (*os.File, error) {
+	if openat2Unsupported.Load() {
+		return nil, errNoOpenat2
+	}
+
+	dir, err := unix.Open(base, unix.O_PATH|unix.O_DIRECTORY|unix.O_CLOEXEC, 0)
+	if err != nil {
+		return nil, &os.PathError{Op: "open", Path: base, Err: err}
+	}
+	defer unix.Close(dir)
+
+	rel := relativeName(name)
+	how := &unix.OpenHow{
+		Flags:   uint64(flags | unix.O_CLOEXEC),
+		Resolve: unix.RESOLVE_BENEATH | unix.RESOLVE_NO_SYMLINKS | unix.RESOLVE_NO_MAGICLINKS,
+	}
+
+	for attempt := 0; ; attempt++ {
+		fd, err := unix.Openat2(dir, rel, how)
+		switch {
+		case err == nil:
+			return os.NewFile(uintptr(fd), filepath.Join(base, rel)), nil
+		case err == unix.EINTR, err == unix.EAGAIN && attempt < openat2Retries:
+			continue
+		case err == unix.ENOSYS:
+			openat2Unsupported.Store(true)
+			return nil, errNoOpenat2
+		case err == unix.EXDEV, err == unix.ELOOP:
+			// EXDEV: resolution left base; ELOOP: a component is a symlink
+			return nil, &os.PathError{Op: "openat2", Path: rel, Err: errEscapesBase}
+		default:
+			return nil, &os.PathError{Op: "openat2", Path: rel, Err: err}
+		}
+	}
+}
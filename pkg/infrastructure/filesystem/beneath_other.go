@@ -0,0 +1,17 @@
+//go:build !linux
+
+package filesystem
+
+import "os"
+
+// openBeneath opens name for reading relative to base without resolving
+// outside base or through symlinks
+func openBeneath(base, name string) (*os.File, error) {
+	return openInRoot(base, name)
+}
+
+// statBeneath returns the metadata of name relative to base, resolved like
+// openBeneath
+func statBeneath(base, name string) (os.FileInfo, error) {
+	return statInRoot(base, name)
+}
@@ -0,0 +1,141 @@
+package filesystem
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sh05/cat-server/pkg/domain/repositories"
+	"github.com/sh05/cat-server/pkg/domain/valueobjects"
+)
+
+func writeBeneathTree(t *testing.T) (base, outside string) {
+	t.Helper()
+
+	outside = t.TempDir()
+	if err := os.WriteFile(filepath.Join(outside, "secret.txt"), []byte("secret\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	base = t.TempDir()
+	if err := os.MkdirAll(filepath.Join(base, "sub"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(base, "sub", "ok.txt"), []byte("ok\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	links := map[string]string{
+		"escape":      filepath.Join(outside, "secret.txt"),
+		"escape-dir":  outside,
+		"inner-link":  filepath.Join("sub", "ok.txt"),
+		"sub/up-link": "..",
+	}
+	for name, target := range links {
+		if err := os.Symlink(target, filepath.Join(base, name)); err != nil {
+			t.Skipf("symlinks not supported: %v", err)
+		}
+	}
+	return base, outside
+}
+
+func TestFileSystemRepositoryBeneath(t *testing.T) {
+	base, _ := writeBeneathTree(t)
+	repo := NewFileSystemRepository(base, 1024*1024)
+
+	tests := []struct {
+		path string
+		ok   bool
+		code repositories.ErrorCode
+	}{
+		{"sub/ok.txt", true, 0},
+		{"/sub/ok.txt", true, 0},
+		{"escape", false, repositories.ErrorPathTraversal},
+		{"escape-dir/secret.txt", false, repositories.ErrorPathTraversal},
+		{"inner-link", false, repositories.ErrorPathTraversal},
+		{"sub/up-link/sub/ok.txt", false, repositories.ErrorPathTraversal},
+		{"sub/missing.txt", false, repositories.ErrorNotFound},
+		{"sub", false, repositories.ErrorInvalidPath},
+	}
+	for _, tt := range tests {
+		p, err := valueobjects.NewFilePath(tt.path)
+		if err != nil {
+			t.Fatalf("NewFilePath(%q) returned error: %v", tt.path, err)
+		}
+
+		fc, err := repo.ReadFile(context.Background(), p)
+		if tt.ok {
+			if err != nil {
+				t.Errorf("ReadFile(%q) returned error: %v", tt.path, err)
+			} else if string(fc.Content()) != "ok\n" {
+				t.Errorf("ReadFile(%q) returned %q", tt.path, fc.Content())
+			}
+			continue
+		}
+		var fsErr *repositories.FileSystemError
+		if !errors.As(err, &fsErr) || fsErr.Code != tt.code {
+			t.Errorf("ReadFile(%q): expected code %d, got %v", tt.path, tt.code, err)
+		}
+	}
+
+	escapeDir, _ := valueobjects.NewFilePath("escape-dir")
+	if _, err := repo.ListDirectory(context.Background(), escapeDir); err == nil {
+		t.Error("Expected listing through a symlink to fail")
+	}
+	if repo.IsDirectory(context.Background(), escapeDir) {
+		t.Error("Expected symlinked directory not to be reported as a directory")
+	}
+
+	root, _ := valueobjects.NewFilePath("/")
+	listing, err := repo.ListDirectory(context.Background(), root)
+	if err != nil {
+		t.Fatalf("ListDirectory returned error: %v", err)
+	}
+	var names []string
+	for _, entry := range listing.Entries() {
+		names = append(names, entry.Name())
+	}
+	expected := []string{"escape", "escape-dir", "inner-link", "sub"}
+	if len(names) != len(expected) {
+		t.Fatalf("Expected entries %v, got %v", expected, names)
+	}
+	for i := range expected {
+		if names[i] != expected[i] {
+			t.Errorf("Expected entries %v, got %v", expected, names)
+			break
+		}
+	}
+}
+
+func TestOpenInRoot(t *testing.T) {
+	base, _ := writeBeneathTree(t)
+
+	file, err := openInRoot(base, "/sub/ok.txt")
+	if err != nil {
+		t.Fatalf("openInRoot returned error: %v", err)
+	}
+	content, _ := io.ReadAll(file)
+	file.Close()
+	if string(content) != "ok\n" {
+		t.Errorf("Expected %q, got %q", "ok\n", content)
+	}
+
+	if info, err := statInRoot(base, "."); err != nil || !info.IsDir() {
+		t.Errorf("Expected base to stat as a directory, got %v, %v", info, err)
+	}
+
+	for _, name := range []string{"escape", "escape-dir/secret.txt", "inner-link", "sub/up-link/sub/ok.txt", "../secret.txt", "sub/../../secret.txt"} {
+		if _, err := openInRoot(base, name); !errors.Is(err, errEscapesBase) {
+			t.Errorf("openInRoot(%q): expected errEscapesBase, got %v", name, err)
+		}
+		if _, err := statInRoot(base, name); !errors.Is(err, errEscapesBase) {
+			t.Errorf("statInRoot(%q): expected errEscapesBase, got %v", name, err)
+		}
+	}
+
+	if _, err := statInRoot(base, "sub/missing.txt"); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Expected not exist, got %v", err)
+	}
+}
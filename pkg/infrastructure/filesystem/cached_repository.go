@@ -0,0 +1,73 @@
+package filesystem
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/sh05/cat-server/pkg/domain/entities"
+	"github.com/sh05/cat-server/pkg/domain/repositories"
+	"github.com/sh05/cat-server/pkg/domain/valueobjects"
+	"github.com/sh05/cat-server/pkg/infrastructure/cache"
+)
+
+// CachedRepository decorates a FileSystemRepository with an in-memory LRU
+// of file contents, so hot files are not read from disk on every request.
+// Entries are keyed by path, modification time and size: every read stats
+// the file, and a changed file misses the cache and is read again, the
+// stale entry ageing out. Files over the entry limit are never cached.
+type CachedRepository struct {
+	repositories.FileSystemRepository
+	contents      *cache.LRU
+	maxEntryBytes int64
+}
+
+// NewCachedRepository wraps base with contents, caching files of at most
+// maxEntryBytes
+func NewCachedRepository(base repositories.FileSystemRepository, contents *cache.LRU, maxEntryBytes int64) *CachedRepository {
+	return &CachedRepository{FileSystemRepository: base, contents: contents, maxEntryBytes: maxEntryBytes}
+}
+
+// ReadFile returns the content of a file, from the cache when it has not
+// changed since it was cached
+func (r *CachedRepository) ReadFile(ctx context.Context, p *valueobjects.FilePath) (*entities.FileContent, error) {
+	info, err := r.FileSystemRepository.GetFileInfo(ctx, p)
+	if err != nil || info.IsDir() || info.Size() > r.maxEntryBytes {
+		return r.FileSystemRepository.ReadFile(ctx, p)
+	}
+
+	if content, ok := r.contents.Get(contentKey(p, info)); ok {
+		return entities.NewFileContent(info, content, "utf-8")
+	}
+
+	fileContent, err := r.FileSystemRepository.ReadFile(ctx, p)
+	if err != nil {
+		return nil, err
+	}
+	// Key by the entry read, which may be newer than the one stat'ed above
+	if entry := fileContent.Entry(); entry.Size() <= r.maxEntryBytes {
+		r.contents.Add(contentKey(p, entry), fileContent.Content())
+	}
+	return fileContent, nil
+}
+
+// Invalidate drops the cached versions of the file at p, e.g. on a file
+// watcher event, and returns how many there were
+func (r *CachedRepository) Invalidate(p string) int {
+	return r.contents.RemovePrefix(contentKeyPrefix(cleanRulePath(p)))
+}
+
+// Purge drops every cached file
+func (r *CachedRepository) Purge() {
+	r.contents.Purge()
+}
+
+// contentKeyPrefix is the prefix of the keys of the versions of the file
+// at the cleaned path p
+func contentKeyPrefix(p string) string {
+	return p + "\x00"
+}
+
+// contentKey identifies a version of the file at p
+func contentKey(p *valueobjects.FilePath, entry *entities.FileSystemEntry) string {
+	return fmt.Sprintf("%s%d\x00%d", contentKeyPrefix(cleanRulePath(p.String())), entry.ModTime().UnixNano(), entry.Size())
+}
@@ -0,0 +1,65 @@
+package filesystem
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/sh05/cat-server/pkg/domain/valueobjects"
+	"github.com/sh05/cat-server/pkg/infrastructure/cache"
+	"github.com/sh05/cat-server/pkg/infrastructure/metrics"
+)
+
+func TestCachedRepository(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{"app.yaml": "port: 80\n", "big.log": "0123456789abcdef"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	m := metrics.NewCacheMetrics("file_contents")
+	repo := NewCachedRepository(NewFileSystemRepository(dir, 1024*1024), cache.NewLRU(1024, m), 12)
+
+	read := func(name, expected string) {
+		t.Helper()
+		p, _ := valueobjects.NewFilePath(name)
+		fc, err := repo.ReadFile(context.Background(), p)
+		if err != nil {
+			t.Fatalf("ReadFile(%q) returned error: %v", name, err)
+		}
+		if string(fc.Content()) != expected {
+			t.Errorf("Expected %q, got %q", expected, fc.Content())
+		}
+	}
+
+	read("app.yaml", "port: 80\n")
+	read("app.yaml", "port: 80\n")
+	if s := m.Snapshot(); s.Hits != 1 || s.Misses != 1 || s.Entries != 1 {
+		t.Errorf("Expected 1 hit, 1 miss and 1 entry, got %+v", s)
+	}
+
+	// A changed file is read again
+	if err := os.WriteFile(filepath.Join(dir, "app.yaml"), []byte("port: 8080\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	os.Chtimes(filepath.Join(dir, "app.yaml"), time.Now(), time.Now().Add(time.Second))
+	read("app.yaml", "port: 8080\n")
+	if s := m.Snapshot(); s.Misses != 2 {
+		t.Errorf("Expected the changed file to miss, got %+v", s)
+	}
+
+	// Files over the entry limit bypass the cache
+	read("big.log", "0123456789abcdef")
+	read("big.log", "0123456789abcdef")
+	if s := m.Snapshot(); s.Hits != 1 || s.Misses != 2 {
+		t.Errorf("Expected large files not to be looked up, got %+v", s)
+	}
+
+	missing, _ := valueobjects.NewFilePath("missing.txt")
+	if _, err := repo.ReadFile(context.Background(), missing); err == nil {
+		t.Error("Expected an error reading a missing file")
+	}
+}
@@ -0,0 +1,83 @@
+package filesystem
+
+import (
+	"context"
+	"errors"
+
+	"github.com/sh05/cat-server/pkg/domain/entities"
+	"github.com/sh05/cat-server/pkg/domain/repositories"
+	"github.com/sh05/cat-server/pkg/domain/valueobjects"
+	"github.com/sh05/cat-server/pkg/infrastructure/cache"
+	"github.com/sh05/cat-server/pkg/infrastructure/metrics"
+)
+
+// CoalescingRepository decorates a FileSystemRepository so that concurrent
+// reads of the same file or listing of the same directory share one read
+// from disk, e.g. when many clients fetch a file at once or a cache entry
+// has just been invalidated. A read that starts while another is running
+// gets that read's result, errors included, which may predate a change
+// made in between; reads that start later go to disk again. A read that
+// was cancelled with its request is retried by joiners whose requests are
+// still live.
+type CoalescingRepository struct {
+	repositories.FileSystemRepository
+	reads    cache.Group[*entities.FileContent]
+	listings cache.Group[*entities.DirectoryListing]
+	metrics  *metrics.CacheMetrics
+}
+
+// NewCoalescingRepository wraps base. m, which may be nil, counts shared
+// reads as hits and reads from disk as misses.
+func NewCoalescingRepository(base repositories.FileSystemRepository, m *metrics.CacheMetrics) *CoalescingRepository {
+	return &CoalescingRepository{FileSystemRepository: base, metrics: m}
+}
+
+// ReadFile returns the content of a file, shared with a concurrent read of
+// the same file
+func (r *CoalescingRepository) ReadFile(ctx context.Context, p *valueobjects.FilePath) (*entities.FileContent, error) {
+	for {
+		content, err, shared := r.reads.Do(cleanRulePath(p.String()), func() (*entities.FileContent, error) {
+			return r.FileSystemRepository.ReadFile(ctx, p)
+		})
+		if shared && retryable(ctx, err) {
+			continue
+		}
+		r.record(shared)
+		return content, err
+	}
+}
+
+// ListDirectory returns a directory listing, shared with a concurrent
+// listing of the same directory
+func (r *CoalescingRepository) ListDirectory(ctx context.Context, p *valueobjects.FilePath) (*entities.DirectoryListing, error) {
+	for {
+		listing, err, shared := r.listings.Do(cleanRulePath(p.String()), func() (*entities.DirectoryListing, error) {
+			return r.FileSystemRepository.ListDirectory(ctx, p)
+		})
+		if shared && retryable(ctx, err) {
+			continue
+		}
+		r.record(shared)
+		return listing, err
+	}
+}
+
+// retryable reports whether a shared read failed only because the request
+// that ran it went away while ctx is still live
+func retryable(ctx context.Context, err error) bool {
+	if !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	return ctx.Err() == nil
+}
+
+func (r *CoalescingRepository) record(shared bool) {
+	if r.metrics == nil {
+		return
+	}
+	if shared {
+		r.metrics.RecordHit()
+	} else {
+		r.metrics.RecordMiss()
+	}
+}
@@ -0,0 +1,123 @@
+package filesystem
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sh05/cat-server/pkg/domain/entities"
+	"github.com/sh05/cat-server/pkg/domain/repositories"
+	"github.com/sh05/cat-server/pkg/domain/valueobjects"
+	"github.com/sh05/cat-server/pkg/infrastructure/metrics"
+)
+
+// slowRepository counts reads and holds each until released
+type slowRepository struct {
+	repositories.FileSystemRepository
+	reads   atomic.Int32
+	release chan struct{}
+}
+
+func (r *slowRepository) ReadFile(ctx context.Context, p *valueobjects.FilePath) (*entities.FileContent, error) {
+	r.reads.Add(1)
+	<-r.release
+	return r.FileSystemRepository.ReadFile(ctx, p)
+}
+
+func (r *slowRepository) ListDirectory(ctx context.Context, p *valueobjects.FilePath) (*entities.DirectoryListing, error) {
+	r.reads.Add(1)
+	<-r.release
+	return r.FileSystemRepository.ListDirectory(ctx, p)
+}
+
+func TestCoalescingRepository(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "app.log"), []byte("started\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range []struct {
+		name string
+		read func(*CoalescingRepository, *valueobjects.FilePath) error
+		path string
+	}{
+		{"ReadFile", func(r *CoalescingRepository, p *valueobjects.FilePath) error {
+			_, err := r.ReadFile(context.Background(), p)
+			return err
+		}, "app.log"},
+		{"ListDirectory", func(r *CoalescingRepository, p *valueobjects.FilePath) error {
+			_, err := r.ListDirectory(context.Background(), p)
+			return err
+		}, "."},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			base := &slowRepository{FileSystemRepository: NewFileSystemRepository(dir, 1024), release: make(chan struct{})}
+			m := metrics.NewCacheMetrics("coalesced_reads")
+			repo := NewCoalescingRepository(base, m)
+			p, _ := valueobjects.NewFilePath(tt.path)
+
+			var wg sync.WaitGroup
+			for range 10 {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					if err := tt.read(repo, p); err != nil {
+						t.Error(err)
+					}
+				}()
+			}
+			time.Sleep(20 * time.Millisecond)
+			close(base.release)
+			wg.Wait()
+
+			s := m.Snapshot()
+			if reads := int64(base.reads.Load()); reads != s.Misses || s.Hits+s.Misses != 10 || reads == 10 {
+				t.Errorf("Expected concurrent reads to share disk reads, got %d reads and %+v", reads, s)
+			}
+		})
+	}
+}
+
+func TestCoalescingRepositoryCancelledRead(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "app.log"), []byte("started\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	base := &slowRepository{FileSystemRepository: NewFileSystemRepository(dir, 1024), release: make(chan struct{})}
+	repo := NewCoalescingRepository(base, nil)
+	p, _ := valueobjects.NewFilePath("app.log")
+
+	// The first read belongs to a request that goes away while a second
+	// one waits for it
+	ctx, cancel := context.WithCancel(context.Background())
+	leader := make(chan error, 1)
+	go func() {
+		_, err := repo.ReadFile(ctx, p)
+		leader <- err
+	}()
+	for base.reads.Load() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	joiner := make(chan error, 1)
+	go func() {
+		_, err := repo.ReadFile(context.Background(), p)
+		joiner <- err
+	}()
+	time.Sleep(20 * time.Millisecond)
+	cancel()
+	close(base.release)
+
+	if err := <-leader; !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected the cancelled read to fail with context.Canceled, got %v", err)
+	}
+	if err := <-joiner; err != nil {
+		t.Errorf("Expected the live request to read the file again, got %v", err)
+	}
+	if reads := base.reads.Load(); reads != 2 {
+		t.Errorf("Expected 2 reads, got %d", reads)
+	}
+}
@@ -0,0 +1,29 @@
+package filesystem
+
+// DiskUsage describes the space and inodes of a filesystem. Free counts
+// what is available to unprivileged processes.
+type DiskUsage struct {
+	TotalBytes  uint64
+	FreeBytes   uint64
+	TotalInodes uint64 // 0 when the filesystem has no fixed inode count
+	FreeInodes  uint64
+}
+
+// UsedPercent returns the percentage of space in use
+func (u DiskUsage) UsedPercent() float64 {
+	return usedPercent(u.TotalBytes, u.FreeBytes)
+}
+
+// InodesUsedPercent returns the percentage of inodes in use, or 0 when the
+// filesystem does not report inodes
+func (u DiskUsage) InodesUsedPercent() float64 {
+	return usedPercent(u.TotalInodes, u.FreeInodes)
+}
+
+// usedPercent returns the used share of total as a percentage
+func usedPercent(total, free uint64) float64 {
+	if total == 0 || free > total {
+		return 0
+	}
+	return float64(total-free) / float64(total) * 100
+}
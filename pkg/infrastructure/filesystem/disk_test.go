@@ -0,0 +1,28 @@
+package filesystem
+
+import "testing"
+
+func TestDiskUsagePercent(t *testing.T) {
+	tests := []struct {
+		name       string
+		usage      DiskUsage
+		wantSpace  float64
+		wantInodes float64
+	}{
+		{"empty", DiskUsage{TotalBytes: 1000, FreeBytes: 1000, TotalInodes: 10, FreeInodes: 10}, 0, 0},
+		{"partly used", DiskUsage{TotalBytes: 1000, FreeBytes: 250, TotalInodes: 10, FreeInodes: 1}, 75, 90},
+		{"full", DiskUsage{TotalBytes: 1000, TotalInodes: 10}, 100, 100},
+		{"no inodes", DiskUsage{TotalBytes: 1000, FreeBytes: 500}, 50, 0},
+		{"unknown size", DiskUsage{}, 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.usage.UsedPercent(); got != tt.wantSpace {
+				t.Errorf("UsedPercent() = %v, want %v", got, tt.wantSpace)
+			}
+			if got := tt.usage.InodesUsedPercent(); got != tt.wantInodes {
+				t.Errorf("InodesUsedPercent() = %v, want %v", got, tt.wantInodes)
+			}
+		})
+	}
+}
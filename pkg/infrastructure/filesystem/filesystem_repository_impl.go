@@ -1,9 +1,15 @@
 package filesystem
 
 import (
+	"context"
+	"errors"
 	"io"
+	"io/fs"
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
+	"sync/atomic"
 
 	"github.com/sh05/cat-server/pkg/domain/entities"
 	"github.com/sh05/cat-server/pkg/domain/repositories"
@@ -12,29 +18,54 @@ import (
 
 // FileSystemRepositoryImpl implements the FileSystemRepository interface
 type FileSystemRepositoryImpl struct {
-	basePath    string
+	basePath    atomic.Pointer[string]
 	maxFileSize int64
 }
 
+// cancelCheckInterval is how many directory entries are statted between
+// checks for a cancelled request
+const cancelCheckInterval = 256
+
+// contextReader stops reading once its context is done, so a cancelled
+// request does not read a large file to the end
+type contextReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (c contextReader) Read(p []byte) (int, error) {
+	if err := c.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return c.r.Read(p)
+}
+
 // NewFileSystemRepository creates a new filesystem repository implementation
 func NewFileSystemRepository(basePath string, maxFileSize int64) *FileSystemRepositoryImpl {
-	return &FileSystemRepositoryImpl{
-		basePath:    basePath,
-		maxFileSize: maxFileSize,
-	}
+	r := &FileSystemRepositoryImpl{maxFileSize: maxFileSize}
+	r.basePath.Store(&basePath)
+	return r
 }
 
 // ListDirectory returns a directory listing for the given path
-func (r *FileSystemRepositoryImpl) ListDirectory(path *valueobjects.FilePath) (*entities.DirectoryListing, error) {
-	fullPath := filepath.Join(r.basePath, path.String())
+func (r *FileSystemRepositoryImpl) ListDirectory(ctx context.Context, path *valueobjects.FilePath) (*entities.DirectoryListing, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
 
 	// Validate path security
 	if err := r.ValidatePath(path); err != nil {
 		return nil, err
 	}
 
-	// Check if directory exists and is readable
-	if !r.IsDirectory(path) {
+	// Check if directory exists; statting first avoids blocking on a FIFO
+	basePath := r.GetBasePath()
+	info, err := statBeneath(basePath, path.String())
+	if err != nil {
+		return nil, resolveError("ListDirectory", path, err, "directory not found")
+	}
+
+	if !info.IsDir() {
 		return nil, repositories.NewFileSystemError(
 			"ListDirectory",
 			path.String(),
@@ -44,7 +75,13 @@ func (r *FileSystemRepositoryImpl) ListDirectory(path *valueobjects.FilePath) (*
 	}
 
 	// Read directory entries
-	entries, err := os.ReadDir(fullPath)
+	dir, err := openBeneath(basePath, path.String())
+	if err != nil {
+		return nil, resolveError("ListDirectory", path, err, "directory not found")
+	}
+	defer dir.Close()
+
+	entries, err := dir.ReadDir(-1)
 	if err != nil {
 		return nil, repositories.NewFileSystemError(
 			"ListDirectory",
@@ -53,13 +90,26 @@ func (r *FileSystemRepositoryImpl) ListDirectory(path *valueobjects.FilePath) (*
 			repositories.ErrorPermissionDenied,
 		)
 	}
+	slices.SortFunc(entries, func(a, b os.DirEntry) int {
+		return strings.Compare(a.Name(), b.Name())
+	})
 
 	// Convert to domain entities
-	var fileEntries []entities.FileSystemEntry
-	for _, entry := range entries {
+	fileEntries := make([]entities.FileSystemEntry, 0, len(entries))
+	var skipped []entities.SkippedEntry
+	for i, entry := range entries {
+		// Each entry is a stat; stop early in large directories
+		if i%cancelCheckInterval == 0 {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+		}
+
 		info, err := entry.Info()
 		if err != nil {
-			continue // Skip entries we can't read
+			// Skip entries we can't read, but remember them for audits
+			skipped = append(skipped, entities.SkippedEntry{Name: entry.Name(), Reason: err.Error()})
+			continue
 		}
 
 		relativeEntryPath := filepath.Join(path.String(), entry.Name())
@@ -73,6 +123,7 @@ func (r *FileSystemRepositoryImpl) ListDirectory(path *valueobjects.FilePath) (*
 			info.Mode(),
 		)
 		if err != nil {
+			skipped = append(skipped, entities.SkippedEntry{Name: entry.Name(), Reason: err.Error()})
 			continue // Skip invalid entries
 		}
 
@@ -89,110 +140,131 @@ func (r *FileSystemRepositoryImpl) ListDirectory(path *valueobjects.FilePath) (*
 			repositories.ErrorUnknown,
 		)
 	}
+	for _, entry := range skipped {
+		listing.AddSkipped(entry.Name, entry.Reason)
+	}
 
 	return listing, nil
 }
 
 // ReadFile returns the content of a file at the given path
-func (r *FileSystemRepositoryImpl) ReadFile(path *valueobjects.FilePath) (*entities.FileContent, error) {
-	fullPath := filepath.Join(r.basePath, path.String())
+func (r *FileSystemRepositoryImpl) ReadFile(ctx context.Context, path *valueobjects.FilePath) (*entities.FileContent, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
 
-	// Validate path security
-	if err := r.ValidatePath(path); err != nil {
+	file, fileEntry, err := r.openReadableFile("ReadFile", path)
+	if err != nil {
 		return nil, err
 	}
+	defer file.Close()
 
-	// Check if file exists and is readable
-	if !r.Exists(path) {
+	content, err := io.ReadAll(contextReader{ctx: ctx, r: file})
+	if err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, ctxErr
+		}
 		return nil, repositories.NewFileSystemError(
 			"ReadFile",
 			path.String(),
-			"file not found",
-			repositories.ErrorNotFound,
+			err.Error(),
+			repositories.ErrorUnknown,
 		)
 	}
 
-	if !r.IsReadable(path) {
+	// Create file content entity
+	fileContent, err := entities.NewFileContent(fileEntry, content, "utf-8")
+	if err != nil {
 		return nil, repositories.NewFileSystemError(
 			"ReadFile",
 			path.String(),
-			"file not readable",
-			repositories.ErrorPermissionDenied,
+			err.Error(),
+			repositories.ErrorUnknown,
 		)
 	}
 
-	// Get file info
-	fileEntry, err := r.GetFileInfo(path)
+	return fileContent, nil
+}
+
+// OpenFile opens a file for streaming and ranged reads. The caller must
+// close the reader.
+func (r *FileSystemRepositoryImpl) OpenFile(ctx context.Context, path *valueobjects.FilePath) (io.ReadSeekCloser, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	file, _, err := r.openReadableFile("OpenFile", path)
 	if err != nil {
 		return nil, err
 	}
+	return file, nil
+}
 
-	if fileEntry.IsDir() {
-		return nil, repositories.NewFileSystemError(
-			"ReadFile",
-			path.String(),
-			"path is a directory",
-			repositories.ErrorInvalidPath,
-		)
+// openReadableFile opens path and checks that it is a file within the size
+// limit. The checks use the opened file, so they cannot be raced by
+// replacing the path in between.
+func (r *FileSystemRepositoryImpl) openReadableFile(operation string, path *valueobjects.FilePath) (*os.File, *entities.FileSystemEntry, error) {
+	// Validate path security
+	if err := r.ValidatePath(path); err != nil {
+		return nil, nil, err
 	}
 
-	// Check file size limit
-	if r.maxFileSize > 0 && fileEntry.Size() > r.maxFileSize {
-		return nil, repositories.NewFileSystemError(
-			"ReadFile",
-			path.String(),
-			"file too large",
-			repositories.ErrorFileTooLarge,
-		)
+	file, err := openBeneath(r.GetBasePath(), path.String())
+	if err != nil {
+		return nil, nil, resolveError(operation, path, err, "file not found")
+	}
+
+	fileEntry, err := r.openedFileEntry(operation, path, file)
+	if err != nil {
+		file.Close()
+		return nil, nil, err
 	}
+	return file, fileEntry, nil
+}
 
-	// Read file content
-	file, err := os.Open(fullPath)
+// openedFileEntry describes an opened file, rejecting directories and files
+// over the size limit
+func (r *FileSystemRepositoryImpl) openedFileEntry(operation string, path *valueobjects.FilePath, file *os.File) (*entities.FileSystemEntry, error) {
+	info, err := file.Stat()
 	if err != nil {
 		return nil, repositories.NewFileSystemError(
-			"ReadFile",
+			operation,
 			path.String(),
 			err.Error(),
-			repositories.ErrorPermissionDenied,
+			repositories.ErrorUnknown,
 		)
 	}
-	defer file.Close()
 
-	content, err := io.ReadAll(file)
-	if err != nil {
+	if info.IsDir() {
 		return nil, repositories.NewFileSystemError(
-			"ReadFile",
+			operation,
 			path.String(),
-			err.Error(),
-			repositories.ErrorUnknown,
+			"path is a directory",
+			repositories.ErrorInvalidPath,
 		)
 	}
 
-	// Create file content entity
-	fileContent, err := entities.NewFileContent(fileEntry, content, "utf-8")
-	if err != nil {
+	// Check file size limit
+	if r.maxFileSize > 0 && info.Size() > r.maxFileSize {
 		return nil, repositories.NewFileSystemError(
-			"ReadFile",
+			operation,
 			path.String(),
-			err.Error(),
-			repositories.ErrorUnknown,
+			"file too large",
+			repositories.ErrorFileTooLarge,
 		)
 	}
 
-	return fileContent, nil
+	return newFileEntry(operation, path, info)
 }
 
 // Exists checks if a file or directory exists at the given path
-func (r *FileSystemRepositoryImpl) Exists(path *valueobjects.FilePath) bool {
-	fullPath := filepath.Join(r.basePath, path.String())
-	_, err := os.Stat(fullPath)
-	return !os.IsNotExist(err)
+func (r *FileSystemRepositoryImpl) Exists(ctx context.Context, path *valueobjects.FilePath) bool {
+	_, err := statBeneath(r.GetBasePath(), path.String())
+	return !errors.Is(err, fs.ErrNotExist)
 }
 
 // IsReadable checks if the file/directory at the given path is readable
-func (r *FileSystemRepositoryImpl) IsReadable(path *valueobjects.FilePath) bool {
-	fullPath := filepath.Join(r.basePath, path.String())
-	file, err := os.Open(fullPath)
+func (r *FileSystemRepositoryImpl) IsReadable(ctx context.Context, path *valueobjects.FilePath) bool {
+	file, err := openBeneath(r.GetBasePath(), path.String())
 	if err != nil {
 		return false
 	}
@@ -201,9 +273,8 @@ func (r *FileSystemRepositoryImpl) IsReadable(path *valueobjects.FilePath) bool
 }
 
 // IsDirectory checks if the path points to a directory
-func (r *FileSystemRepositoryImpl) IsDirectory(path *valueobjects.FilePath) bool {
-	fullPath := filepath.Join(r.basePath, path.String())
-	info, err := os.Stat(fullPath)
+func (r *FileSystemRepositoryImpl) IsDirectory(ctx context.Context, path *valueobjects.FilePath) bool {
+	info, err := statBeneath(r.GetBasePath(), path.String())
 	if err != nil {
 		return false
 	}
@@ -211,27 +282,21 @@ func (r *FileSystemRepositoryImpl) IsDirectory(path *valueobjects.FilePath) bool
 }
 
 // GetFileInfo returns basic information about a file/directory
-func (r *FileSystemRepositoryImpl) GetFileInfo(path *valueobjects.FilePath) (*entities.FileSystemEntry, error) {
-	fullPath := filepath.Join(r.basePath, path.String())
+func (r *FileSystemRepositoryImpl) GetFileInfo(ctx context.Context, path *valueobjects.FilePath) (*entities.FileSystemEntry, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
 
-	info, err := os.Stat(fullPath)
+	info, err := statBeneath(r.GetBasePath(), path.String())
 	if err != nil {
-		if os.IsNotExist(err) {
-			return nil, repositories.NewFileSystemError(
-				"GetFileInfo",
-				path.String(),
-				"file not found",
-				repositories.ErrorNotFound,
-			)
-		}
-		return nil, repositories.NewFileSystemError(
-			"GetFileInfo",
-			path.String(),
-			err.Error(),
-			repositories.ErrorUnknown,
-		)
+		return nil, resolveError("GetFileInfo", path, err, "file not found")
 	}
 
+	return newFileEntry("GetFileInfo", path, info)
+}
+
+// newFileEntry converts file metadata to a domain entry
+func newFileEntry(operation string, path *valueobjects.FilePath, info os.FileInfo) (*entities.FileSystemEntry, error) {
 	entry, err := entities.NewFileSystemEntry(
 		path.Base(),
 		path.String(),
@@ -242,7 +307,7 @@ func (r *FileSystemRepositoryImpl) GetFileInfo(path *valueobjects.FilePath) (*en
 	)
 	if err != nil {
 		return nil, repositories.NewFileSystemError(
-			"GetFileInfo",
+			operation,
 			path.String(),
 			err.Error(),
 			repositories.ErrorUnknown,
@@ -252,7 +317,23 @@ func (r *FileSystemRepositoryImpl) GetFileInfo(path *valueobjects.FilePath) (*en
 	return entry, nil
 }
 
-// ValidatePath performs security and accessibility checks on the path
+// resolveError converts an error from openBeneath or statBeneath
+func resolveError(operation string, path *valueobjects.FilePath, err error, notFound string) error {
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		return repositories.NewFileSystemError(operation, path.String(), notFound, repositories.ErrorNotFound)
+	case errors.Is(err, errEscapesBase):
+		return repositories.NewFileSystemError(operation, path.String(), "path outside allowed directory", repositories.ErrorPathTraversal)
+	case errors.Is(err, fs.ErrPermission):
+		return repositories.NewFileSystemError(operation, path.String(), "permission denied", repositories.ErrorPermissionDenied)
+	default:
+		return repositories.NewFileSystemError(operation, path.String(), err.Error(), repositories.ErrorUnknown)
+	}
+}
+
+// ValidatePath performs security checks on the path. Containment within the
+// base directory is enforced when the path is resolved: on Linux by
+// openat2 with RESOLVE_BENEATH and RESOLVE_NO_SYMLINKS, elsewhere by os.Root.
 func (r *FileSystemRepositoryImpl) ValidatePath(path *valueobjects.FilePath) error {
 	// Check path security
 	if !path.IsSecure() {
@@ -264,75 +345,28 @@ func (r *FileSystemRepositoryImpl) ValidatePath(path *valueobjects.FilePath) err
 		)
 	}
 
-	// Ensure path is within base directory bounds
-	fullPath := filepath.Join(r.basePath, path.String())
-	cleanFullPath := filepath.Clean(fullPath)
-	cleanBasePath := filepath.Clean(r.basePath)
-
-	// Check if the resolved path is still within the base directory
-	relPath, err := filepath.Rel(cleanBasePath, cleanFullPath)
-	if err != nil || filepath.IsAbs(relPath) || (len(relPath) >= 2 && relPath[0:2] == "..") {
-		return repositories.NewFileSystemError(
-			"ValidatePath",
-			path.String(),
-			"path outside allowed directory",
-			repositories.ErrorPathTraversal,
-		)
-	}
-
 	return nil
 }
 
 // GetDirectoryStats returns statistics about a directory
-func (r *FileSystemRepositoryImpl) GetDirectoryStats(path *valueobjects.FilePath) (*repositories.DirectoryStats, error) {
-	listing, err := r.ListDirectory(path)
+func (r *FileSystemRepositoryImpl) GetDirectoryStats(ctx context.Context, path *valueobjects.FilePath) (*repositories.DirectoryStats, error) {
+	listing, err := r.ListDirectory(ctx, path)
 	if err != nil {
 		return nil, err
 	}
 
-	stats := &repositories.DirectoryStats{
-		TotalFiles:       listing.GetFileCount(),
-		TotalDirectories: listing.GetDirectoryCount(),
-		TotalSize:        listing.GetTotalSize(),
-	}
-
-	// Find largest, newest, and oldest files
-	entries := listing.Entries()
-	if len(entries) > 0 {
-		var largestFile, newestFile, oldestFile *entities.FileSystemEntry
-
-		for i, entry := range entries {
-			if entry.IsDir() {
-				continue
-			}
-
-			// Check for largest file
-			if largestFile == nil || entry.Size() > largestFile.Size() {
-				largestFile = &entries[i]
-			}
-
-			// Check for newest file
-			if newestFile == nil || entry.ModTime().After(newestFile.ModTime()) {
-				newestFile = &entries[i]
-			}
-
-			// Check for oldest file
-			if oldestFile == nil || entry.ModTime().Before(oldestFile.ModTime()) {
-				oldestFile = &entries[i]
-			}
-		}
-
-		stats.LargestFile = largestFile
-		stats.NewestFile = newestFile
-		stats.OldestFile = oldestFile
-	}
-
-	return stats, nil
+	return repositories.NewDirectoryStats(listing), nil
 }
 
 // GetBasePath returns the base path for this repository
 func (r *FileSystemRepositoryImpl) GetBasePath() string {
-	return r.basePath
+	return *r.basePath.Load()
+}
+
+// SetBasePath serves files from a different base path. Operations already
+// in progress complete against the previous one.
+func (r *FileSystemRepositoryImpl) SetBasePath(basePath string) {
+	r.basePath.Store(&basePath)
 }
 
 // SetMaxFileSize sets the maximum file size limit
@@ -0,0 +1,225 @@
+package filesystem
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/sh05/cat-server/pkg/domain/entities"
+	"github.com/sh05/cat-server/pkg/domain/repositories"
+	"github.com/sh05/cat-server/pkg/domain/valueobjects"
+)
+
+// OperationTimeouts bound how long single filesystem operations may take;
+// a zero timeout leaves its operations unbounded
+type OperationTimeouts struct {
+	Stat time.Duration // Exists, IsReadable, IsDirectory and GetFileInfo
+	Read time.Duration // ReadFile, and opening a file with OpenFile
+	List time.Duration // ListDirectory and GetDirectoryStats
+}
+
+// GuardedRepository decorates a FileSystemRepository with per-operation
+// timeouts and a circuit breaker, so a hung filesystem such as an
+// unreachable NFS mount fails requests fast instead of blocking them.
+//
+// Operations run in a goroutine of their own and are abandoned when they
+// time out. A system call blocked on the filesystem cannot be interrupted,
+// so that goroutine lingers until the call returns; the breaker keeps them
+// from piling up. After threshold consecutive timeouts it opens, and
+// operations fail at once with ErrorUnavailable for cooldown. Then one
+// operation probes the filesystem: the breaker closes if it completes and
+// opens again if it times out. Unbounded operations bypass the breaker.
+//
+// Reads from a file returned by OpenFile are not bounded.
+type GuardedRepository struct {
+	repositories.FileSystemRepository
+	timeouts OperationTimeouts
+	breaker  *circuitBreaker // nil when disabled
+}
+
+// NewGuardedRepository wraps base. A threshold of 0 disables the breaker.
+func NewGuardedRepository(base repositories.FileSystemRepository, timeouts OperationTimeouts, threshold int, cooldown time.Duration) *GuardedRepository {
+	r := &GuardedRepository{FileSystemRepository: base, timeouts: timeouts}
+	if threshold > 0 {
+		r.breaker = &circuitBreaker{threshold: threshold, cooldown: cooldown, now: time.Now}
+	}
+	return r
+}
+
+// ListDirectory returns a directory listing within the list timeout
+func (r *GuardedRepository) ListDirectory(ctx context.Context, p *valueobjects.FilePath) (*entities.DirectoryListing, error) {
+	return guard(r, ctx, "ListDirectory", p, r.timeouts.List, nil, func(ctx context.Context) (*entities.DirectoryListing, error) {
+		return r.FileSystemRepository.ListDirectory(ctx, p)
+	})
+}
+
+// GetDirectoryStats returns directory statistics within the list timeout
+func (r *GuardedRepository) GetDirectoryStats(ctx context.Context, p *valueobjects.FilePath) (*repositories.DirectoryStats, error) {
+	return guard(r, ctx, "GetDirectoryStats", p, r.timeouts.List, nil, func(ctx context.Context) (*repositories.DirectoryStats, error) {
+		return r.FileSystemRepository.GetDirectoryStats(ctx, p)
+	})
+}
+
+// ReadFile returns the content of a file within the read timeout
+func (r *GuardedRepository) ReadFile(ctx context.Context, p *valueobjects.FilePath) (*entities.FileContent, error) {
+	return guard(r, ctx, "ReadFile", p, r.timeouts.Read, nil, func(ctx context.Context) (*entities.FileContent, error) {
+		return r.FileSystemRepository.ReadFile(ctx, p)
+	})
+}
+
+// OpenFile opens a file within the read timeout. A file opened after the
+// timeout is closed again.
+func (r *GuardedRepository) OpenFile(ctx context.Context, p *valueobjects.FilePath) (io.ReadSeekCloser, error) {
+	closeFile := func(file io.ReadSeekCloser) { file.Close() }
+	return guard(r, ctx, "OpenFile", p, r.timeouts.Read, closeFile, func(ctx context.Context) (io.ReadSeekCloser, error) {
+		return r.FileSystemRepository.OpenFile(ctx, p)
+	})
+}
+
+// GetFileInfo returns information about a file within the stat timeout
+func (r *GuardedRepository) GetFileInfo(ctx context.Context, p *valueobjects.FilePath) (*entities.FileSystemEntry, error) {
+	return guard(r, ctx, "GetFileInfo", p, r.timeouts.Stat, nil, func(ctx context.Context) (*entities.FileSystemEntry, error) {
+		return r.FileSystemRepository.GetFileInfo(ctx, p)
+	})
+}
+
+// Exists reports whether p exists, or false when the stat timed out
+func (r *GuardedRepository) Exists(ctx context.Context, p *valueobjects.FilePath) bool {
+	return r.check(ctx, "Exists", p, r.FileSystemRepository.Exists)
+}
+
+// IsReadable reports whether p is readable, or false when the stat timed
+// out
+func (r *GuardedRepository) IsReadable(ctx context.Context, p *valueobjects.FilePath) bool {
+	return r.check(ctx, "IsReadable", p, r.FileSystemRepository.IsReadable)
+}
+
+// IsDirectory reports whether p is a directory, or false when the stat
+// timed out
+func (r *GuardedRepository) IsDirectory(ctx context.Context, p *valueobjects.FilePath) bool {
+	return r.check(ctx, "IsDirectory", p, r.FileSystemRepository.IsDirectory)
+}
+
+// check runs a predicate within the stat timeout
+func (r *GuardedRepository) check(ctx context.Context, operation string, p *valueobjects.FilePath, fn func(context.Context, *valueobjects.FilePath) bool) bool {
+	ok, err := guard(r, ctx, operation, p, r.timeouts.Stat, nil, func(ctx context.Context) (bool, error) {
+		return fn(ctx, p), nil
+	})
+	return err == nil && ok
+}
+
+// guard runs fn within timeout, passing it a context that is cancelled on
+// timeout. discard, which may be nil, releases a result that arrives after
+// the caller gave up on it.
+func guard[T any](r *GuardedRepository, ctx context.Context, operation string, p *valueobjects.FilePath, timeout time.Duration, discard func(T), fn func(context.Context) (T, error)) (T, error) {
+	var zero T
+	if timeout <= 0 {
+		return fn(ctx)
+	}
+	probe, ok := r.breaker.allow()
+	if !ok {
+		return zero, repositories.NewFileSystemError(operation, p.String(), "filesystem is not responding", repositories.ErrorUnavailable)
+	}
+
+	opCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	type result struct {
+		value T
+		err   error
+	}
+	results := make(chan result)
+	go func() {
+		value, err := fn(opCtx)
+		select {
+		case results <- result{value, err}:
+		case <-opCtx.Done():
+			if err == nil && discard != nil {
+				discard(value)
+			}
+		}
+	}()
+
+	select {
+	case res := <-results:
+		// fn may notice the deadline itself just before it passes
+		if !errors.Is(res.err, context.DeadlineExceeded) || opCtx.Err() == nil {
+			r.breaker.record(probe, false)
+			return res.value, res.err
+		}
+	case <-opCtx.Done():
+	}
+
+	if err := ctx.Err(); err != nil {
+		// The caller went away, which says nothing about the filesystem
+		r.breaker.release(probe)
+		return zero, err
+	}
+	r.breaker.record(probe, true)
+	return zero, repositories.NewFileSystemError(operation, p.String(), fmt.Sprintf("timed out after %v", timeout), repositories.ErrorTimeout)
+}
+
+// circuitBreaker opens after threshold consecutive failures and stays open
+// for cooldown, then lets one probe through to decide whether to close.
+// A nil breaker never opens.
+type circuitBreaker struct {
+	threshold int
+	cooldown  time.Duration
+	now       func() time.Time
+
+	mu        sync.Mutex
+	failures  int // consecutive
+	openUntil time.Time
+	probing   bool
+}
+
+// allow reports whether an operation may run and whether it is the probe
+// of an open breaker
+func (b *circuitBreaker) allow() (probe, ok bool) {
+	if b == nil {
+		return false, true
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.failures < b.threshold {
+		return false, true
+	}
+	if b.probing || b.now().Before(b.openUntil) {
+		return false, false
+	}
+	b.probing = true
+	return true, true
+}
+
+// record counts the outcome of an operation allowed by allow
+func (b *circuitBreaker) record(probe, failed bool) {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if probe {
+		b.probing = false
+	}
+	if !failed {
+		b.failures = 0
+		return
+	}
+	b.failures++
+	if b.failures >= b.threshold {
+		b.openUntil = b.now().Add(b.cooldown)
+	}
+}
+
+// release ends an operation allowed by allow without counting it
+func (b *circuitBreaker) release(probe bool) {
+	if b == nil || !probe {
+		return
+	}
+	b.mu.Lock()
+	b.probing = false
+	b.mu.Unlock()
+}
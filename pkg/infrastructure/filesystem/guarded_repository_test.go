@@ -0,0 +1,180 @@
+package filesystem
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sh05/cat-server/pkg/domain/entities"
+	"github.com/sh05/cat-server/pkg/domain/repositories"
+	"github.com/sh05/cat-server/pkg/domain/valueobjects"
+)
+
+// hangingRepository blocks every operation while hung is set, like a
+// filesystem behind an unreachable NFS server
+type hangingRepository struct {
+	repositories.FileSystemRepository
+	hung    atomic.Bool
+	release chan struct{}
+	calls   atomic.Int32
+	closed  atomic.Int32
+}
+
+func (r *hangingRepository) wait() {
+	r.calls.Add(1)
+	if r.hung.Load() {
+		<-r.release
+	}
+}
+
+func (r *hangingRepository) ListDirectory(ctx context.Context, p *valueobjects.FilePath) (*entities.DirectoryListing, error) {
+	r.wait()
+	return r.FileSystemRepository.ListDirectory(ctx, p)
+}
+
+func (r *hangingRepository) Exists(ctx context.Context, p *valueobjects.FilePath) bool {
+	r.wait()
+	return r.FileSystemRepository.Exists(ctx, p)
+}
+
+func (r *hangingRepository) OpenFile(ctx context.Context, p *valueobjects.FilePath) (io.ReadSeekCloser, error) {
+	r.wait()
+	// Open for real, ignoring the context that expired meanwhile
+	file, err := r.FileSystemRepository.OpenFile(context.Background(), p)
+	if err != nil {
+		return nil, err
+	}
+	return closeCounter{file, &r.closed}, nil
+}
+
+type closeCounter struct {
+	io.ReadSeekCloser
+	closed *atomic.Int32
+}
+
+func (c closeCounter) Close() error {
+	c.closed.Add(1)
+	return c.ReadSeekCloser.Close()
+}
+
+func newHangingRepository(t *testing.T) *hangingRepository {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "app.log"), []byte("started\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	base := &hangingRepository{FileSystemRepository: NewFileSystemRepository(dir, 1024), release: make(chan struct{})}
+	t.Cleanup(func() { close(base.release) })
+	return base
+}
+
+func fsErrorCode(err error) (repositories.ErrorCode, bool) {
+	var fsErr *repositories.FileSystemError
+	if !errors.As(err, &fsErr) {
+		return 0, false
+	}
+	return fsErr.Code, true
+}
+
+func TestGuardedRepositoryTimeouts(t *testing.T) {
+	base := newHangingRepository(t)
+	timeouts := OperationTimeouts{Stat: 10 * time.Millisecond, Read: 10 * time.Millisecond, List: 10 * time.Millisecond}
+	repo := NewGuardedRepository(base, timeouts, 0, 0)
+	root, _ := valueobjects.NewFilePath(".")
+	file, _ := valueobjects.NewFilePath("app.log")
+
+	if _, err := repo.ListDirectory(context.Background(), root); err != nil {
+		t.Fatalf("Expected a responsive filesystem to be listed, got %v", err)
+	}
+
+	base.hung.Store(true)
+	start := time.Now()
+	_, err := repo.ListDirectory(context.Background(), root)
+	if code, ok := fsErrorCode(err); !ok || code != repositories.ErrorTimeout {
+		t.Errorf("Expected a timeout, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Expected the listing to be abandoned after its timeout, took %v", elapsed)
+	}
+	if repo.Exists(context.Background(), file) {
+		t.Error("Expected a timed out stat to report false")
+	}
+
+	// A file opened after its timeout is closed rather than leaked
+	if _, err := repo.OpenFile(context.Background(), file); err == nil {
+		t.Fatal("Expected opening to time out")
+	}
+	base.hung.Store(false)
+	for range 3 {
+		// The listing, the stat and the open are still blocked
+		base.release <- struct{}{}
+	}
+	for deadline := time.Now().Add(time.Second); base.closed.Load() == 0 && time.Now().Before(deadline); {
+		time.Sleep(time.Millisecond)
+	}
+	if closed := base.closed.Load(); closed != 1 {
+		t.Errorf("Expected the late file to be closed, got %d closes", closed)
+	}
+
+	// A caller going away is not a timeout
+	base.hung.Store(true)
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(time.Millisecond, cancel)
+	repo = NewGuardedRepository(base, OperationTimeouts{List: time.Hour}, 0, 0)
+	if _, err := repo.ListDirectory(ctx, root); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+}
+
+func TestGuardedRepositoryBreaker(t *testing.T) {
+	base := newHangingRepository(t)
+	repo := NewGuardedRepository(base, OperationTimeouts{List: 10 * time.Millisecond}, 2, time.Minute)
+	now := time.Now()
+	repo.breaker.now = func() time.Time { return now }
+	root, _ := valueobjects.NewFilePath(".")
+	list := func() error {
+		_, err := repo.ListDirectory(context.Background(), root)
+		return err
+	}
+
+	base.hung.Store(true)
+	for range 2 {
+		if code, _ := fsErrorCode(list()); code != repositories.ErrorTimeout {
+			t.Fatal("Expected the hung filesystem to time out")
+		}
+	}
+
+	// Open: fail at once without touching the filesystem
+	calls := base.calls.Load()
+	err := list()
+	if code, ok := fsErrorCode(err); !ok || code != repositories.ErrorUnavailable || !strings.Contains(err.Error(), "not responding") {
+		t.Errorf("Expected the open breaker to fail fast, got %v", err)
+	}
+	if base.calls.Load() != calls {
+		t.Error("Expected the open breaker not to call the filesystem")
+	}
+
+	// After the cooldown a probe that times out opens it again
+	now = now.Add(time.Minute)
+	if code, _ := fsErrorCode(list()); code != repositories.ErrorTimeout {
+		t.Error("Expected the probe to reach the filesystem")
+	}
+	if code, _ := fsErrorCode(list()); code != repositories.ErrorUnavailable {
+		t.Error("Expected the failed probe to open the breaker again")
+	}
+
+	// A probe that completes closes it
+	now = now.Add(time.Minute)
+	base.hung.Store(false)
+	if err := list(); err != nil {
+		t.Errorf("Expected the probe to succeed, got %v", err)
+	}
+	if err := list(); err != nil {
+		t.Errorf("Expected the breaker to be closed, got %v", err)
+	}
+}
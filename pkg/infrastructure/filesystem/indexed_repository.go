@@ -0,0 +1,370 @@
+package filesystem
+
+import (
+	"context"
+	"path"
+	"sync"
+	"time"
+
+	"github.com/sh05/cat-server/pkg/domain/entities"
+	"github.com/sh05/cat-server/pkg/domain/repositories"
+	"github.com/sh05/cat-server/pkg/domain/valueobjects"
+	"github.com/sh05/cat-server/pkg/infrastructure/metrics"
+)
+
+// IndexedRepository decorates a FileSystemRepository with an in-memory
+// index of the listings of every directory in the tree, so listings and
+// walks of very large trees, such as those of /search, are answered from
+// memory.
+//
+// A background goroutine builds the index, breadth first, and rebuilds it
+// every refresh interval. Invalidate drops the listings a change may have
+// made stale and has the goroutine list them again, so a file watcher
+// keeps the index up to date incrementally. Directories missing from the
+// index, whether not indexed yet or invalidated and not listed again yet,
+// are listed from the underlying repository.
+//
+// The index holds at most maxEntries entries across all listings;
+// directories beyond that are not indexed.
+type IndexedRepository struct {
+	repositories.FileSystemRepository
+	maxEntries int
+	refresh    time.Duration
+	metrics    *metrics.CacheMetrics
+
+	ctx     context.Context // cancelled by Close
+	cancel  context.CancelFunc
+	wake    chan struct{}
+	stopped chan struct{}
+
+	mu      sync.RWMutex
+	dirs    map[string]*entities.DirectoryListing // by cleaned directory path
+	entries int                                   // entries of all indexed listings
+	bytes   int64
+	// pending holds the directories to list again, with the listings
+	// Invalidate dropped for them, if any
+	pending map[string]*entities.DirectoryListing
+	rebuild bool
+}
+
+// NewIndexedRepository wraps base and starts indexing it in the
+// background. A refresh of 0 only rebuilds the index on InvalidateAll.
+// m may be nil.
+func NewIndexedRepository(base repositories.FileSystemRepository, maxEntries int, refresh time.Duration, m *metrics.CacheMetrics) *IndexedRepository {
+	ctx, cancel := context.WithCancel(context.Background())
+	r := &IndexedRepository{
+		FileSystemRepository: base,
+		maxEntries:           maxEntries,
+		refresh:              refresh,
+		metrics:              m,
+		ctx:                  ctx,
+		cancel:               cancel,
+		wake:                 make(chan struct{}, 1),
+		stopped:              make(chan struct{}),
+		dirs:                 make(map[string]*entities.DirectoryListing),
+		pending:              make(map[string]*entities.DirectoryListing),
+	}
+	go r.run()
+	return r
+}
+
+// ListDirectory returns a directory listing, from the index when the
+// directory is indexed
+func (r *IndexedRepository) ListDirectory(ctx context.Context, p *valueobjects.FilePath) (*entities.DirectoryListing, error) {
+	r.mu.RLock()
+	listing, ok := r.dirs[cleanRulePath(p.String())]
+	r.mu.RUnlock()
+	if r.metrics != nil {
+		if ok {
+			r.metrics.RecordHit()
+		} else {
+			r.metrics.RecordMiss()
+		}
+	}
+	if ok {
+		return listing, nil
+	}
+	return r.FileSystemRepository.ListDirectory(ctx, p)
+}
+
+// Invalidate drops the listings a change to p may have made stale, those
+// of p, if it is a directory, and of its parent, and has them listed again
+// in the background. It returns the number of listings dropped.
+func (r *IndexedRepository) Invalidate(p string) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.metrics != nil {
+		r.metrics.RecordEvent()
+	}
+	key := cleanRulePath(p)
+	dropped := 0
+	if r.drop(key) {
+		dropped++
+	}
+	if key != "." && r.drop(path.Dir(key)) {
+		dropped++
+	}
+	r.updateSize()
+	r.signal()
+	return dropped
+}
+
+// InvalidateAll drops the whole index, e.g. when file change events were
+// lost, and has it rebuilt in the background. It returns the number of
+// listings dropped.
+func (r *IndexedRepository) InvalidateAll() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.metrics != nil {
+		r.metrics.RecordEvent()
+	}
+	dropped := len(r.dirs)
+	clear(r.dirs)
+	clear(r.pending)
+	r.entries = 0
+	r.bytes = 0
+	r.rebuild = true
+	r.updateSize()
+	r.signal()
+	return dropped
+}
+
+// Close stops indexing and waits for the background goroutine to return
+func (r *IndexedRepository) Close() error {
+	r.cancel()
+	<-r.stopped
+	return nil
+}
+
+// drop removes the listing of key from the index, if indexed, and queues
+// key to be listed again. The caller holds r.mu.
+func (r *IndexedRepository) drop(key string) bool {
+	if _, queued := r.pending[key]; !queued {
+		r.pending[key] = nil
+	}
+	listing, ok := r.dirs[key]
+	if !ok {
+		return false
+	}
+	r.pending[key] = listing
+	r.remove(key)
+	return true
+}
+
+// remove drops the listing of key. The caller holds r.mu.
+func (r *IndexedRepository) remove(key string) {
+	listing := r.dirs[key]
+	delete(r.dirs, key)
+	r.entries -= listing.TotalCount()
+	r.bytes -= listingBytes(listing)
+}
+
+// signal wakes the background goroutine. The caller holds r.mu.
+func (r *IndexedRepository) signal() {
+	select {
+	case r.wake <- struct{}{}:
+	default:
+	}
+}
+
+// updateSize reports the index size to the metrics. The caller holds r.mu.
+func (r *IndexedRepository) updateSize() {
+	if r.metrics != nil {
+		r.metrics.SetSize(len(r.dirs), r.bytes)
+	}
+}
+
+// run builds the index and keeps it up to date until Close
+func (r *IndexedRepository) run() {
+	defer close(r.stopped)
+
+	var tick <-chan time.Time
+	if r.refresh > 0 {
+		ticker := time.NewTicker(r.refresh)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	r.build()
+	for {
+		select {
+		case <-r.ctx.Done():
+			return
+		case <-tick:
+			r.build()
+		case <-r.wake:
+			r.update()
+		}
+	}
+}
+
+// build indexes the whole tree and replaces the index with it. Directories
+// changed meanwhile are left out, to be listed again by update, and the
+// result is discarded when InvalidateAll was called meanwhile.
+func (r *IndexedRepository) build() {
+	start := time.Now()
+	r.mu.Lock()
+	r.rebuild = false
+	r.mu.Unlock()
+
+	dirs := make(map[string]*entities.DirectoryListing)
+	entries := 0
+	var bytes int64
+	r.walk(".", func(key string, listing *entities.DirectoryListing) bool {
+		if entries+listing.TotalCount() > r.maxEntries {
+			return false
+		}
+		dirs[key] = listing
+		entries += listing.TotalCount()
+		bytes += listingBytes(listing)
+		return true
+	})
+	if r.ctx.Err() != nil {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.rebuild {
+		return
+	}
+	r.dirs, r.entries, r.bytes = dirs, entries, bytes
+	for key := range r.pending {
+		if _, ok := r.dirs[key]; ok {
+			r.remove(key)
+		}
+	}
+	r.updateSize()
+	if r.metrics != nil {
+		r.metrics.RecordRebuild(time.Since(start))
+	}
+}
+
+// update lists the invalidated directories again, or rebuilds the index
+// after InvalidateAll
+func (r *IndexedRepository) update() {
+	r.mu.Lock()
+	if r.rebuild {
+		r.mu.Unlock()
+		r.build()
+		return
+	}
+	pending := r.pending
+	r.pending = make(map[string]*entities.DirectoryListing)
+	r.mu.Unlock()
+
+	for key, previous := range pending {
+		if r.ctx.Err() != nil {
+			return
+		}
+		r.reindex(key, previous)
+	}
+}
+
+// reindex lists the directory key again, indexing directories that
+// appeared in it and dropping those that disappeared from it, with
+// everything below them. previous is the listing key had, if any.
+func (r *IndexedRepository) reindex(key string, previous *entities.DirectoryListing) {
+	// Only directories inside the index are kept in it
+	r.mu.RLock()
+	_, parentIndexed := r.dirs[path.Dir(key)]
+	r.mu.RUnlock()
+	if key != "." && !parentIndexed && previous == nil {
+		return
+	}
+
+	listing, ok := r.list(key)
+	if !ok {
+		// Removed, or no longer a directory
+		r.mu.Lock()
+		r.dropTree(key)
+		r.updateSize()
+		r.mu.Unlock()
+		return
+	}
+
+	if previous != nil {
+		current := make(map[string]bool)
+		for _, entry := range listing.Entries() {
+			current[entry.Name()] = entry.IsDir()
+		}
+		r.mu.Lock()
+		for _, entry := range previous.Entries() {
+			if entry.IsDir() && !current[entry.Name()] {
+				r.dropTree(path.Join(key, entry.Name()))
+			}
+		}
+		r.mu.Unlock()
+	}
+
+	r.walk(key, func(dir string, listing *entities.DirectoryListing) bool {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		if _, indexed := r.dirs[dir]; indexed && dir != key {
+			// Indexed already, with everything below it
+			return false
+		}
+		if _, changed := r.pending[dir]; changed {
+			// Changed again meanwhile; update lists it once more
+			return false
+		}
+		if r.entries+listing.TotalCount() > r.maxEntries {
+			return false
+		}
+		if _, indexed := r.dirs[dir]; indexed {
+			r.remove(dir)
+		}
+		r.dirs[dir] = listing
+		r.entries += listing.TotalCount()
+		r.bytes += listingBytes(listing)
+		r.updateSize()
+		return true
+	})
+}
+
+// dropTree removes key and every directory below it from the index. The
+// caller holds r.mu.
+func (r *IndexedRepository) dropTree(key string) {
+	for dir := range r.dirs {
+		if rulePrefixMatches(key, dir) {
+			r.remove(dir)
+		}
+	}
+}
+
+// walk lists root and the directories below it breadth first, passing
+// each listing to store. The directories below a listing store refuses
+// are skipped.
+func (r *IndexedRepository) walk(root string, store func(key string, listing *entities.DirectoryListing) bool) {
+	queue := []string{root}
+	for len(queue) > 0 && r.ctx.Err() == nil {
+		key := queue[0]
+		queue = queue[1:]
+
+		listing, ok := r.list(key)
+		if !ok || !store(key, listing) {
+			continue
+		}
+		for _, entry := range listing.Entries() {
+			if entry.IsDir() {
+				queue = append(queue, path.Join(key, entry.Name()))
+			}
+		}
+	}
+}
+
+// list lists the directory key from the underlying repository, reporting
+// false when it cannot be listed
+func (r *IndexedRepository) list(key string) (*entities.DirectoryListing, bool) {
+	p, err := valueobjects.NewFilePath(key)
+	if err != nil {
+		return nil, false
+	}
+	listing, err := r.FileSystemRepository.ListDirectory(r.ctx, p)
+	if err != nil {
+		return nil, false
+	}
+	return listing, true
+}
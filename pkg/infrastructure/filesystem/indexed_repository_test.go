@@ -0,0 +1,121 @@
+package filesystem
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/sh05/cat-server/pkg/domain/valueobjects"
+)
+
+// waitIndexed waits until exactly the directories in expected are indexed
+func waitIndexed(t *testing.T, repo *IndexedRepository, expected ...string) {
+	t.Helper()
+	slices.Sort(expected)
+	var indexed []string
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(time.Millisecond) {
+		repo.mu.RLock()
+		indexed = indexed[:0]
+		for dir := range repo.dirs {
+			indexed = append(indexed, dir)
+		}
+		repo.mu.RUnlock()
+		slices.Sort(indexed)
+		if slices.Equal(indexed, expected) {
+			return
+		}
+	}
+	t.Fatalf("Expected %v to be indexed, got %v", expected, indexed)
+}
+
+func TestIndexedRepository(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"logs/app.log", "logs/old/app.1.log", "docs/readme.md"} {
+		if err := os.MkdirAll(filepath.Dir(filepath.Join(dir, name)), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("x"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	repo := NewIndexedRepository(NewFileSystemRepository(dir, 1024), 100, 0, nil)
+	defer repo.Close()
+	list := func(name string) []string {
+		t.Helper()
+		p, _ := valueobjects.NewFilePath(name)
+		listing, err := repo.ListDirectory(context.Background(), p)
+		if err != nil {
+			return nil
+		}
+		var names []string
+		for _, entry := range listing.Entries() {
+			names = append(names, entry.Name())
+		}
+		return names
+	}
+	waitIndexed(t, repo, ".", "docs", "logs", "logs/old")
+
+	// Listings come from memory until invalidated
+	if err := os.Remove(filepath.Join(dir, "docs", "readme.md")); err != nil {
+		t.Fatal(err)
+	}
+	if names := list("docs"); !slices.Equal(names, []string{"readme.md"}) {
+		t.Errorf("Expected the indexed listing, got %v", names)
+	}
+	if dropped := repo.Invalidate("docs/readme.md"); dropped != 1 {
+		t.Errorf("Expected 1 listing dropped, got %d", dropped)
+	}
+	if names := list("docs"); len(names) != 0 {
+		t.Errorf("Expected an invalidated listing to be read again, got %v", names)
+	}
+	waitIndexed(t, repo, ".", "docs", "logs", "logs/old")
+
+	// New directories are indexed with everything below them
+	if err := os.MkdirAll(filepath.Join(dir, "logs", "new", "deep"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	repo.Invalidate("logs/new")
+	waitIndexed(t, repo, ".", "docs", "logs", "logs/new", "logs/new/deep", "logs/old")
+
+	// Removed directories are dropped with everything below them
+	if err := os.RemoveAll(filepath.Join(dir, "logs")); err != nil {
+		t.Fatal(err)
+	}
+	repo.Invalidate("logs")
+	waitIndexed(t, repo, ".", "docs")
+	if names := list("logs/new/deep"); names != nil {
+		t.Errorf("Expected a removed directory not to be listed, got %v", names)
+	}
+
+	// Everything is indexed again after InvalidateAll
+	if err := os.MkdirAll(filepath.Join(dir, "logs"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	repo.InvalidateAll()
+	waitIndexed(t, repo, ".", "docs", "logs")
+}
+
+func TestIndexedRepositoryLimit(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"a/1", "a/2", "b/1", "b/2", "b/3"} {
+		if err := os.MkdirAll(filepath.Join(dir, name), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// The root and a fit in 4 entries, b does not
+	repo := NewIndexedRepository(NewFileSystemRepository(dir, 1024), 4, 0, nil)
+	defer repo.Close()
+	waitIndexed(t, repo, ".", "a", "a/1", "a/2")
+
+	// Directories outside the index stay out of it
+	if err := os.Remove(filepath.Join(dir, "b", "3")); err != nil {
+		t.Fatal(err)
+	}
+	repo.Invalidate("b/3")
+	waitIndexed(t, repo, ".", "a", "a/1", "a/2")
+}
@@ -0,0 +1,203 @@
+package filesystem
+
+import (
+	"context"
+	"path"
+	"sync"
+	"time"
+	"unsafe"
+
+	"github.com/sh05/cat-server/pkg/domain/entities"
+	"github.com/sh05/cat-server/pkg/domain/repositories"
+	"github.com/sh05/cat-server/pkg/domain/valueobjects"
+	"github.com/sh05/cat-server/pkg/infrastructure/metrics"
+)
+
+// CachedListingRepository decorates a FileSystemRepository with a cache of
+// directory listings, so large directories are not read and stat'ed on
+// every request. A listing is served for at most ttl and only while the
+// directory's modification time is unchanged, so added, removed and
+// renamed entries show up at once; changes to the entries themselves, such
+// as a file growing, show up within ttl. Invalidate and InvalidateAll drop
+// listings early, e.g. on a file watcher event.
+//
+// The cache holds at most maxEntries entries across all listings. When it
+// is full, expired listings are dropped and, if that is not enough, the
+// new listing is not cached.
+type CachedListingRepository struct {
+	repositories.FileSystemRepository
+	ttl        time.Duration
+	maxEntries int
+	metrics    *metrics.CacheMetrics
+	now        func() time.Time
+
+	mu       sync.Mutex
+	listings map[string]cachedListing // by cleaned directory path
+	entries  int                      // entries of all cached listings
+	bytes    int64
+}
+
+type cachedListing struct {
+	listing *entities.DirectoryListing
+	modTime time.Time // of the directory when it was listed
+	expires time.Time
+	bytes   int64
+}
+
+// listingEntrySize is the size of an entry without its strings
+const listingEntrySize = int64(unsafe.Sizeof(entities.FileSystemEntry{}))
+
+// listingBytes estimates the memory held by listing
+func listingBytes(listing *entities.DirectoryListing) int64 {
+	var bytes int64
+	for _, entry := range listing.Entries() {
+		bytes += listingEntrySize + int64(len(entry.Name())+len(entry.Path()))
+	}
+	return bytes
+}
+
+// NewCachedListingRepository wraps base with a listing cache. m may be nil.
+func NewCachedListingRepository(base repositories.FileSystemRepository, ttl time.Duration, maxEntries int, m *metrics.CacheMetrics) *CachedListingRepository {
+	return &CachedListingRepository{
+		FileSystemRepository: base,
+		ttl:                  ttl,
+		maxEntries:           maxEntries,
+		metrics:              m,
+		now:                  time.Now,
+		listings:             make(map[string]cachedListing),
+	}
+}
+
+// ListDirectory returns a directory listing, from the cache when it is
+// fresh. Errors are not cached.
+func (r *CachedListingRepository) ListDirectory(ctx context.Context, p *valueobjects.FilePath) (*entities.DirectoryListing, error) {
+	info, err := r.FileSystemRepository.GetFileInfo(ctx, p)
+	if err != nil || !info.IsDir() {
+		return r.FileSystemRepository.ListDirectory(ctx, p)
+	}
+
+	key := cleanRulePath(p.String())
+	now := r.now()
+	if listing, ok := r.lookup(key, info.ModTime(), now); ok {
+		return listing, nil
+	}
+
+	// A change while listing leaves the listing newer than modTime, so it
+	// is only served until the next request notices the change
+	listing, err := r.FileSystemRepository.ListDirectory(ctx, p)
+	if err != nil {
+		return nil, err
+	}
+	r.store(key, listing, info.ModTime(), now)
+	return listing, nil
+}
+
+// lookup returns the cached listing of key if it is still fresh
+func (r *CachedListingRepository) lookup(key string, modTime, now time.Time) (*entities.DirectoryListing, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	cached, ok := r.listings[key]
+	if ok && now.Before(cached.expires) && cached.modTime.Equal(modTime) {
+		if r.metrics != nil {
+			r.metrics.RecordHit()
+		}
+		return cached.listing, true
+	}
+
+	if ok {
+		r.remove(key)
+	}
+	if r.metrics != nil {
+		r.metrics.RecordMiss()
+	}
+	return nil, false
+}
+
+// store caches listing under key if there is room for it
+func (r *CachedListingRepository) store(key string, listing *entities.DirectoryListing, modTime, now time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.remove(key)
+	size := listing.TotalCount()
+	if r.entries+size > r.maxEntries {
+		evicted := 0
+		for k, cached := range r.listings {
+			if !now.Before(cached.expires) {
+				r.remove(k)
+				evicted++
+			}
+		}
+		if r.metrics != nil && evicted > 0 {
+			r.metrics.RecordEviction(evicted)
+		}
+		if r.entries+size > r.maxEntries {
+			r.updateSize()
+			return
+		}
+	}
+
+	cached := cachedListing{listing: listing, modTime: modTime, expires: now.Add(r.ttl), bytes: listingBytes(listing)}
+	r.listings[key] = cached
+	r.entries += size
+	r.bytes += cached.bytes
+	r.updateSize()
+}
+
+// remove drops the listing of key, if cached. The caller holds r.mu.
+func (r *CachedListingRepository) remove(key string) bool {
+	cached, ok := r.listings[key]
+	if !ok {
+		return false
+	}
+	delete(r.listings, key)
+	r.entries -= cached.listing.TotalCount()
+	r.bytes -= cached.bytes
+	return true
+}
+
+// updateSize reports the cache size to the metrics. The caller holds r.mu.
+func (r *CachedListingRepository) updateSize() {
+	if r.metrics != nil {
+		r.metrics.SetSize(len(r.listings), r.bytes)
+	}
+}
+
+// Invalidate drops the listings a change to p may have made stale: those
+// of p, if it is a directory, and of its parent. It returns the number of
+// listings dropped.
+func (r *CachedListingRepository) Invalidate(p string) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.metrics != nil {
+		r.metrics.RecordEvent()
+	}
+	key := cleanRulePath(p)
+	dropped := 0
+	if r.remove(key) {
+		dropped++
+	}
+	if key != "." && r.remove(path.Dir(key)) {
+		dropped++
+	}
+	r.updateSize()
+	return dropped
+}
+
+// InvalidateAll drops every cached listing and returns how many there were
+func (r *CachedListingRepository) InvalidateAll() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.metrics != nil {
+		r.metrics.RecordEvent()
+	}
+	dropped := len(r.listings)
+	clear(r.listings)
+	r.entries = 0
+	r.bytes = 0
+	r.updateSize()
+	return dropped
+}
@@ -0,0 +1,95 @@
+package filesystem
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/sh05/cat-server/pkg/domain/valueobjects"
+	"github.com/sh05/cat-server/pkg/infrastructure/metrics"
+)
+
+func TestCachedListingRepository(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"logs/app.log", "logs/old/app.1.log", "docs/readme.md"} {
+		if err := os.MkdirAll(filepath.Dir(filepath.Join(dir, name)), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("x"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	m := metrics.NewCacheMetrics("listings")
+	repo := NewCachedListingRepository(NewFileSystemRepository(dir, 1024), time.Minute, 3, m)
+	now := time.Now()
+	repo.now = func() time.Time { return now }
+
+	list := func(name string, expected int) {
+		t.Helper()
+		p, _ := valueobjects.NewFilePath(name)
+		listing, err := repo.ListDirectory(context.Background(), p)
+		if err != nil {
+			t.Fatalf("ListDirectory(%q) returned error: %v", name, err)
+		}
+		if len(listing.Entries()) != expected {
+			t.Errorf("Expected %d entries in %q, got %d", expected, name, len(listing.Entries()))
+		}
+	}
+	expectLookups := func(hits, misses int64) {
+		t.Helper()
+		if s := m.Snapshot(); s.Hits != hits || s.Misses != misses {
+			t.Errorf("Expected %d hits and %d misses, got %d and %d", hits, misses, s.Hits, s.Misses)
+		}
+	}
+
+	list("logs", 2)
+	list("/logs/", 2)
+	expectLookups(1, 1)
+
+	// Adding an entry changes the directory's modification time
+	if err := os.WriteFile(filepath.Join(dir, "logs", "new.log"), []byte("x"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	os.Chtimes(filepath.Join(dir, "logs"), now, now.Add(time.Second))
+	list("logs", 3)
+	expectLookups(1, 2)
+
+	// Listings expire after the TTL
+	list("logs", 3)
+	now = now.Add(time.Minute)
+	list("logs", 3)
+	expectLookups(2, 3)
+
+	// The cache is full with the 3 entries of logs, so docs is not cached
+	// until they expire
+	list("docs", 1)
+	list("docs", 1)
+	expectLookups(2, 5)
+	now = now.Add(time.Minute)
+	list("docs", 1)
+	list("docs", 1)
+	expectLookups(3, 6)
+	if s := m.Snapshot(); s.Entries != 1 || s.Evictions != 1 {
+		t.Errorf("Expected docs to replace the expired logs, got %d listings and %d evictions", s.Entries, s.Evictions)
+	}
+
+	// A change to a file drops the listing of its directory
+	if dropped := repo.Invalidate("docs/readme.md"); dropped != 1 {
+		t.Errorf("Expected 1 listing dropped, got %d", dropped)
+	}
+	list("docs", 1)
+	if dropped := repo.InvalidateAll(); dropped != 1 {
+		t.Errorf("Expected 1 listing dropped, got %d", dropped)
+	}
+	if s := m.Snapshot(); s.Entries != 0 || s.Bytes != 0 || s.Events != 2 {
+		t.Errorf("Expected an empty cache after 2 events, got %+v", s)
+	}
+
+	missing, _ := valueobjects.NewFilePath("missing")
+	if _, err := repo.ListDirectory(context.Background(), missing); err == nil {
+		t.Error("Expected an error listing a missing directory")
+	}
+}
@@ -0,0 +1,36 @@
+package filesystem
+
+import (
+	"context"
+
+	"github.com/sh05/cat-server/pkg/domain/entities"
+	"github.com/sh05/cat-server/pkg/domain/repositories"
+	"github.com/sh05/cat-server/pkg/domain/valueobjects"
+	"github.com/sh05/cat-server/pkg/infrastructure/memguard"
+)
+
+// MemoryGuardedRepository decorates a FileSystemRepository to refuse
+// reading large files into memory while the heap is close to the memory
+// limit, failing with ErrorInsufficientMemory instead of risking the
+// process. Files streamed through OpenFile are not refused.
+type MemoryGuardedRepository struct {
+	repositories.FileSystemRepository
+	guard *memguard.Guard
+}
+
+// NewMemoryGuardedRepository wraps base
+func NewMemoryGuardedRepository(base repositories.FileSystemRepository, guard *memguard.Guard) *MemoryGuardedRepository {
+	return &MemoryGuardedRepository{FileSystemRepository: base, guard: guard}
+}
+
+// ReadFile returns the content of a file unless it is large and memory is
+// short. Files are only stat'ed first while memory is short.
+func (r *MemoryGuardedRepository) ReadFile(ctx context.Context, p *valueobjects.FilePath) (*entities.FileContent, error) {
+	if r.guard.Pressure() {
+		info, err := r.FileSystemRepository.GetFileInfo(ctx, p)
+		if err == nil && !r.guard.Allow(info.Size()) {
+			return nil, repositories.NewFileSystemError("ReadFile", p.String(), "not enough memory to read a file this large", repositories.ErrorInsufficientMemory)
+		}
+	}
+	return r.FileSystemRepository.ReadFile(ctx, p)
+}
@@ -0,0 +1,47 @@
+package filesystem
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/sh05/cat-server/pkg/domain/repositories"
+	"github.com/sh05/cat-server/pkg/domain/valueobjects"
+	"github.com/sh05/cat-server/pkg/infrastructure/memguard"
+)
+
+func TestMemoryGuardedRepository(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "small.txt"), []byte("small\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "large.txt"), []byte(strings.Repeat("large\n", 100)), 0644); err != nil {
+		t.Fatal(err)
+	}
+	base := NewFileSystemRepository(dir, 1024)
+
+	for _, tt := range []struct {
+		name     string
+		limit    uint64 // a limit of 1 byte is always exceeded
+		file     string
+		rejected bool
+	}{
+		{"plenty of memory", 1 << 62, "large.txt", false},
+		{"short of memory", 1, "large.txt", true},
+		{"short of memory, small file", 1, "small.txt", false},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := NewMemoryGuardedRepository(base, memguard.New(tt.limit, 100, 100))
+			p, _ := valueobjects.NewFilePath(tt.file)
+			_, err := repo.ReadFile(context.Background(), p)
+			if code, ok := fsErrorCode(err); tt.rejected && (!ok || code != repositories.ErrorInsufficientMemory) {
+				t.Errorf("Expected the read to be refused, got %v", err)
+			}
+			if !tt.rejected && err != nil {
+				t.Errorf("Expected the read to succeed, got %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,55 @@
+package filesystem
+
+import "syscall"
+
+// stRdonly is ST_RDONLY from statvfs(3), set in Statfs_t.Flags
+const stRdonly = 0x1
+
+// IsReadOnlyMount reports whether path is on a filesystem mounted read-only
+func IsReadOnlyMount(path string) (bool, error) {
+	var stat syscall.Statfs_t
+	if err := syscall.Statfs(path, &stat); err != nil {
+		return false, err
+	}
+	return stat.Flags&stRdonly != 0, nil
+}
+
+// networkFilesystems are the statfs(2) magic numbers of network and FUSE
+// filesystems, where inotify misses changes made by other hosts
+var networkFilesystems = map[uint32]string{
+	0x6969:     "nfs",
+	0x517b:     "smb",
+	0xff534d42: "cifs",
+	0xfe534d42: "smb2",
+	0x00c36400: "ceph",
+	0x01021997: "9p",
+	0x65735546: "fuse",
+	0x47504653: "gpfs",
+	0x0bd00bd0: "lustre",
+}
+
+// IsNetworkFilesystem reports whether path is on a network or FUSE
+// filesystem, and its type if so
+func IsNetworkFilesystem(path string) (string, bool, error) {
+	var stat syscall.Statfs_t
+	if err := syscall.Statfs(path, &stat); err != nil {
+		return "", false, err
+	}
+	name, ok := networkFilesystems[uint32(stat.Type)]
+	return name, ok, nil
+}
+
+// StatDisk returns the usage of the filesystem holding path
+func StatDisk(path string) (DiskUsage, error) {
+	var stat syscall.Statfs_t
+	if err := syscall.Statfs(path, &stat); err != nil {
+		return DiskUsage{}, err
+	}
+	blockSize := uint64(stat.Bsize)
+	return DiskUsage{
+		TotalBytes:  stat.Blocks * blockSize,
+		FreeBytes:   stat.Bavail * blockSize,
+		TotalInodes: stat.Files,
+		FreeInodes:  stat.Ffree,
+	}, nil
+}
@@ -0,0 +1,23 @@
+//go:build !linux
+
+package filesystem
+
+import "errors"
+
+// IsReadOnlyMount reports whether path is on a filesystem mounted read-only.
+// It is only implemented on Linux.
+func IsReadOnlyMount(path string) (bool, error) {
+	return false, errors.ErrUnsupported
+}
+
+// IsNetworkFilesystem reports whether path is on a network or FUSE
+// filesystem, and its type if so. It is only implemented on Linux.
+func IsNetworkFilesystem(path string) (string, bool, error) {
+	return "", false, errors.ErrUnsupported
+}
+
+// StatDisk returns the usage of the filesystem holding path. It is only
+// implemented on Linux.
+func StatDisk(path string) (DiskUsage, error) {
+	return DiskUsage{}, errors.ErrUnsupported
+}
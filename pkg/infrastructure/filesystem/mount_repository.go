@@ -0,0 +1,275 @@
+package filesystem
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/sh05/cat-server/pkg/domain/entities"
+	"github.com/sh05/cat-server/pkg/domain/repositories"
+	"github.com/sh05/cat-server/pkg/domain/valueobjects"
+)
+
+// Mount exposes a repository under an alias in the path namespace, e.g.
+// the mount "logs" serves "logs/app.log" as "app.log" of its repository.
+// Limits specific to a mount are applied by a RuleRepository with a rule
+// for the mount name.
+type Mount struct {
+	Name       string
+	Repository repositories.FileSystemRepository
+}
+
+// MountRepository decorates a FileSystemRepository with mounts. Paths whose
+// first element names a mount are resolved in that mount's repository; all
+// other paths are delegated to the wrapped repository, whose root listing
+// gains a directory entry per mount. A mount shadows a base entry of the
+// same name.
+type MountRepository struct {
+	repositories.FileSystemRepository
+	mounts map[string]*Mount
+}
+
+// NewMountRepository wraps base so the given mounts appear as top-level
+// directories
+func NewMountRepository(base repositories.FileSystemRepository, mounts []Mount) *MountRepository {
+	r := &MountRepository{
+		FileSystemRepository: base,
+		mounts:               make(map[string]*Mount, len(mounts)),
+	}
+	for i := range mounts {
+		r.mounts[mounts[i].Name] = &mounts[i]
+	}
+	return r
+}
+
+// resolve returns the mount p lies in and p relative to the mount root, or
+// a nil mount when p belongs to the wrapped repository
+func (r *MountRepository) resolve(p *valueobjects.FilePath) (*Mount, *valueobjects.FilePath, error) {
+	name, rest, _ := strings.Cut(strings.TrimPrefix(filepath.ToSlash(p.String()), "/"), "/")
+	mount, ok := r.mounts[name]
+	if !ok {
+		return nil, p, nil
+	}
+	if rest == "" {
+		rest = "."
+	}
+	inner, err := valueobjects.NewFilePath(rest)
+	if err != nil {
+		return nil, nil, repositories.NewFileSystemError("resolve", p.String(), err.Error(), repositories.ErrorInvalidPath)
+	}
+	return mount, inner, nil
+}
+
+// ListDirectory returns a directory listing, resolving mounts
+func (r *MountRepository) ListDirectory(ctx context.Context, p *valueobjects.FilePath) (*entities.DirectoryListing, error) {
+	mount, inner, err := r.resolve(p)
+	if err != nil {
+		return nil, err
+	}
+	if mount == nil {
+		listing, err := r.FileSystemRepository.ListDirectory(ctx, p)
+		if err != nil || !isRootPath(p) {
+			return listing, err
+		}
+		return r.withMountEntries(ctx, listing)
+	}
+
+	listing, err := mount.Repository.ListDirectory(ctx, inner)
+	if err != nil {
+		return nil, err
+	}
+
+	children := []entities.FileSystemEntry{}
+	for _, entry := range listing.Entries() {
+		mounted, err := mountedEntry(mount.Name, &entry)
+		if err != nil {
+			continue // Skip invalid entries
+		}
+		children = append(children, *mounted)
+	}
+	if children == nil {
+		children = []entities.FileSystemEntry{}
+	}
+
+	mounted, err := entities.NewDirectoryListing(p.String(), children)
+	if err != nil {
+		return nil, repositories.NewFileSystemError("ListDirectory", p.String(), err.Error(), repositories.ErrorUnknown)
+	}
+	for _, skipped := range listing.Skipped() {
+		mounted.AddSkipped(skipped.Name, skipped.Reason)
+	}
+	return mounted, nil
+}
+
+// withMountEntries adds a directory entry per mount to the root listing,
+// replacing base entries of the same name
+func (r *MountRepository) withMountEntries(ctx context.Context, listing *entities.DirectoryListing) (*entities.DirectoryListing, error) {
+	children := []entities.FileSystemEntry{}
+	for _, entry := range listing.Entries() {
+		if _, shadowed := r.mounts[entry.Name()]; !shadowed {
+			children = append(children, entry)
+		}
+	}
+	for name := range r.mounts {
+		entry, err := entities.NewFileSystemEntry(name, name, 0, time.Time{}, true, r.mountMode(ctx, name))
+		if err != nil {
+			continue // Skip invalid entries
+		}
+		children = append(children, *entry)
+	}
+	sort.Slice(children, func(i, j int) bool { return children[i].Name() < children[j].Name() })
+
+	root, err := entities.NewDirectoryListing(listing.Path(), children)
+	if err != nil {
+		return nil, repositories.NewFileSystemError("ListDirectory", listing.Path(), err.Error(), repositories.ErrorUnknown)
+	}
+	for _, skipped := range listing.Skipped() {
+		root.AddSkipped(skipped.Name, skipped.Reason)
+	}
+	return root, nil
+}
+
+// mountMode returns the permissions of a mount's root directory
+func (r *MountRepository) mountMode(ctx context.Context, name string) os.FileMode {
+	root, _ := valueobjects.NewFilePath(".")
+	if info, err := r.mounts[name].Repository.GetFileInfo(ctx, root); err == nil {
+		return info.Permissions()
+	}
+	return os.ModeDir | 0555
+}
+
+// ReadFile returns the content of a file, resolving mounts
+func (r *MountRepository) ReadFile(ctx context.Context, p *valueobjects.FilePath) (*entities.FileContent, error) {
+	mount, inner, err := r.resolve(p)
+	if err != nil {
+		return nil, err
+	}
+	if mount == nil {
+		return r.FileSystemRepository.ReadFile(ctx, p)
+	}
+
+	content, err := mount.Repository.ReadFile(ctx, inner)
+	if err != nil {
+		return nil, err
+	}
+	entry, err := mountedEntry(mount.Name, content.Entry())
+	if err != nil {
+		return nil, repositories.NewFileSystemError("ReadFile", p.String(), err.Error(), repositories.ErrorUnknown)
+	}
+	mounted, err := entities.NewFileContent(entry, content.Content(), content.Encoding())
+	if err != nil {
+		return nil, repositories.NewFileSystemError("ReadFile", p.String(), err.Error(), repositories.ErrorUnknown)
+	}
+	return mounted, nil
+}
+
+// OpenFile opens a file for streaming reads, resolving mounts
+func (r *MountRepository) OpenFile(ctx context.Context, p *valueobjects.FilePath) (io.ReadSeekCloser, error) {
+	mount, inner, err := r.resolve(p)
+	if err != nil {
+		return nil, err
+	}
+	if mount == nil {
+		return r.FileSystemRepository.OpenFile(ctx, p)
+	}
+	return mount.Repository.OpenFile(ctx, inner)
+}
+
+// Exists checks if a file or directory exists, resolving mounts
+func (r *MountRepository) Exists(ctx context.Context, p *valueobjects.FilePath) bool {
+	mount, inner, err := r.resolve(p)
+	if err != nil {
+		return false
+	}
+	if mount == nil {
+		return r.FileSystemRepository.Exists(ctx, p)
+	}
+	return mount.Repository.Exists(ctx, inner)
+}
+
+// IsReadable checks if a file or directory is readable, resolving mounts
+func (r *MountRepository) IsReadable(ctx context.Context, p *valueobjects.FilePath) bool {
+	mount, inner, err := r.resolve(p)
+	if err != nil {
+		return false
+	}
+	if mount == nil {
+		return r.FileSystemRepository.IsReadable(ctx, p)
+	}
+	return mount.Repository.IsReadable(ctx, inner)
+}
+
+// IsDirectory checks if the path points to a directory, resolving mounts
+func (r *MountRepository) IsDirectory(ctx context.Context, p *valueobjects.FilePath) bool {
+	mount, inner, err := r.resolve(p)
+	if err != nil {
+		return false
+	}
+	if mount == nil {
+		return r.FileSystemRepository.IsDirectory(ctx, p)
+	}
+	return mount.Repository.IsDirectory(ctx, inner)
+}
+
+// GetFileInfo returns information about a file or directory, resolving mounts
+func (r *MountRepository) GetFileInfo(ctx context.Context, p *valueobjects.FilePath) (*entities.FileSystemEntry, error) {
+	mount, inner, err := r.resolve(p)
+	if err != nil {
+		return nil, err
+	}
+	if mount == nil {
+		return r.FileSystemRepository.GetFileInfo(ctx, p)
+	}
+
+	entry, err := mount.Repository.GetFileInfo(ctx, inner)
+	if err != nil {
+		return nil, err
+	}
+	mounted, err := mountedEntry(mount.Name, entry)
+	if err != nil {
+		return nil, repositories.NewFileSystemError("GetFileInfo", p.String(), err.Error(), repositories.ErrorUnknown)
+	}
+	return mounted, nil
+}
+
+// ValidatePath validates a path, resolving mounts
+func (r *MountRepository) ValidatePath(p *valueobjects.FilePath) error {
+	mount, inner, err := r.resolve(p)
+	if err != nil {
+		return err
+	}
+	if mount == nil {
+		return r.FileSystemRepository.ValidatePath(p)
+	}
+	return mount.Repository.ValidatePath(inner)
+}
+
+// GetDirectoryStats returns statistics for a directory, resolving mounts
+func (r *MountRepository) GetDirectoryStats(ctx context.Context, p *valueobjects.FilePath) (*repositories.DirectoryStats, error) {
+	listing, err := r.ListDirectory(ctx, p)
+	if err != nil {
+		return nil, err
+	}
+	return repositories.NewDirectoryStats(listing), nil
+}
+
+// mountedEntry returns entry with its path prefixed by the mount name. The
+// root of a mount is named after the mount.
+func mountedEntry(name string, entry *entities.FileSystemEntry) (*entities.FileSystemEntry, error) {
+	p := filepath.Join(name, entry.Path())
+	entryName := entry.Name()
+	if p == name {
+		entryName = name
+	}
+	return entities.NewFileSystemEntry(entryName, p, entry.Size(), entry.ModTime(), entry.IsDir(), entry.Permissions())
+}
+
+// isRootPath reports whether p names the root directory
+func isRootPath(p *valueobjects.FilePath) bool {
+	s := p.String()
+	return s == "." || s == "/" || s == ""
+}
@@ -0,0 +1,101 @@
+package filesystem
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sh05/cat-server/pkg/domain/repositories"
+	"github.com/sh05/cat-server/pkg/domain/valueobjects"
+)
+
+func TestMountRepository(t *testing.T) {
+	base, logs, cfg := t.TempDir(), t.TempDir(), t.TempDir()
+	for dir, files := range map[string]map[string]string{
+		base: {"readme.txt": "base\n", "logs": "shadowed\n"},
+		logs: {"app.log": "log line\n", "big.log": "0123456789"},
+		cfg:  {".env": "KEY=1\n"},
+	} {
+		for name, content := range files {
+			if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	repo := NewMountRepository(NewFileSystemRepository(base, 1024), []Mount{
+		{Name: "logs", Repository: NewFileSystemRepository(logs, 9)},
+		{Name: "cfg", Repository: NewFileSystemRepository(cfg, 1024)},
+	})
+
+	readTests := []struct {
+		path    string
+		content string
+		code    repositories.ErrorCode
+	}{
+		{"readme.txt", "base\n", -1},
+		{"logs/app.log", "log line\n", -1},
+		{"logs/big.log", "", repositories.ErrorFileTooLarge},
+		{"logs/missing.log", "", repositories.ErrorNotFound},
+		{"cfg/.env", "KEY=1\n", -1},
+	}
+	for _, tt := range readTests {
+		p, _ := valueobjects.NewFilePath(tt.path)
+		fc, err := repo.ReadFile(context.Background(), p)
+		if tt.code >= 0 {
+			var fsErr *repositories.FileSystemError
+			if !errors.As(err, &fsErr) || fsErr.Code != tt.code {
+				t.Errorf("ReadFile(%q): expected error code %d, got %v", tt.path, tt.code, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ReadFile(%q) returned error: %v", tt.path, err)
+			continue
+		}
+		if string(fc.Content()) != tt.content {
+			t.Errorf("ReadFile(%q): expected %q, got %q", tt.path, tt.content, fc.Content())
+		}
+		if fc.Entry().Path() != tt.path {
+			t.Errorf("ReadFile(%q): expected entry path %q, got %q", tt.path, tt.path, fc.Entry().Path())
+		}
+	}
+
+	listTests := []struct {
+		path  string
+		names []string
+	}{
+		{".", []string{"cfg", "logs", "readme.txt"}},
+		{"logs", []string{"app.log", "big.log"}},
+		{"cfg/", []string{".env"}},
+	}
+	for _, tt := range listTests {
+		p, _ := valueobjects.NewFilePath(tt.path)
+		listing, err := repo.ListDirectory(context.Background(), p)
+		if err != nil {
+			t.Errorf("ListDirectory(%q) returned error: %v", tt.path, err)
+			continue
+		}
+		entries := listing.Entries()
+		if len(entries) != len(tt.names) {
+			t.Errorf("Expected %d entries in %q, got %d", len(tt.names), tt.path, len(entries))
+			continue
+		}
+		for i, name := range tt.names {
+			if entries[i].Name() != name {
+				t.Errorf("Expected entry %q, got %q", name, entries[i].Name())
+			}
+		}
+	}
+
+	root, _ := valueobjects.NewFilePath("logs")
+	if !repo.IsDirectory(context.Background(), root) {
+		t.Error("Expected mount root to be a directory")
+	}
+	shadowed, _ := valueobjects.NewFilePath("logs/readme.txt")
+	if repo.Exists(context.Background(), shadowed) {
+		t.Error("Expected base files not to be reachable through a mount")
+	}
+}
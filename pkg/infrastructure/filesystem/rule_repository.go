@@ -0,0 +1,296 @@
+package filesystem
+
+import (
+	"context"
+	"io"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/sh05/cat-server/pkg/domain/entities"
+	"github.com/sh05/cat-server/pkg/domain/repositories"
+	"github.com/sh05/cat-server/pkg/domain/valueobjects"
+)
+
+// PathRule sets file limits for the paths at or below Prefix, e.g. a mount
+// name or "docs/private". Zero and nil fields are unset and inherit the
+// value of the longest shorter prefix setting them.
+type PathRule struct {
+	Prefix string
+	// MaxFileSize limits the size of files read, when positive
+	MaxFileSize int64
+	// AllowHidden, when set, decides whether files and directories whose
+	// names start with a dot are served
+	AllowHidden *bool
+	// AllowedExtensions, when set, restricts files to these name suffixes,
+	// e.g. ".log" or ".tar.gz"; DeniedExtensions excludes suffixes
+	AllowedExtensions []string
+	DeniedExtensions  []string
+}
+
+// RuleRepository decorates a FileSystemRepository with per-path limits.
+// Every request resolves its path against the rules, the longest matching
+// prefix winning for each setting. Hidden files and files with excluded
+// extensions are left out of listings; reading them fails with not found
+// and permission denied errors respectively, and reading a file over the
+// size limit fails with a file too large error.
+//
+// The wrapped repository must allow files as large as the largest limit.
+type RuleRepository struct {
+	repositories.FileSystemRepository
+	defaults PathRule
+	rules    []PathRule // sorted by prefix length
+}
+
+// NewRuleRepository wraps base with rules over the given defaults
+func NewRuleRepository(base repositories.FileSystemRepository, defaults PathRule, rules []PathRule) *RuleRepository {
+	sorted := make([]PathRule, len(rules))
+	for i, rule := range rules {
+		rule.Prefix = cleanRulePath(rule.Prefix)
+		rule.AllowedExtensions = lowerExtensions(rule.AllowedExtensions)
+		rule.DeniedExtensions = lowerExtensions(rule.DeniedExtensions)
+		sorted[i] = rule
+	}
+	sort.SliceStable(sorted, func(i, j int) bool { return len(sorted[i].Prefix) < len(sorted[j].Prefix) })
+
+	defaults.AllowedExtensions = lowerExtensions(defaults.AllowedExtensions)
+	defaults.DeniedExtensions = lowerExtensions(defaults.DeniedExtensions)
+	return &RuleRepository{FileSystemRepository: base, defaults: defaults, rules: sorted}
+}
+
+// Resolve returns the effective rule for p, a slash-separated path relative
+// to the root
+func (r *RuleRepository) Resolve(p string) PathRule {
+	p = cleanRulePath(p)
+	resolved := r.defaults
+	for _, rule := range r.rules {
+		if !rulePrefixMatches(rule.Prefix, p) {
+			continue
+		}
+		if rule.MaxFileSize > 0 {
+			resolved.MaxFileSize = rule.MaxFileSize
+		}
+		if rule.AllowHidden != nil {
+			resolved.AllowHidden = rule.AllowHidden
+		}
+		if rule.AllowedExtensions != nil {
+			resolved.AllowedExtensions = rule.AllowedExtensions
+		}
+		if rule.DeniedExtensions != nil {
+			resolved.DeniedExtensions = rule.DeniedExtensions
+		}
+	}
+	resolved.Prefix = p
+	return resolved
+}
+
+// hiddenAllowed reports whether the rule serves hidden entries
+func (rule PathRule) hiddenAllowed() bool {
+	return rule.AllowHidden == nil || *rule.AllowHidden
+}
+
+// extensionAllowed reports whether the rule serves a file with this name
+func (rule PathRule) extensionAllowed(name string) bool {
+	name = strings.ToLower(name)
+	if rule.AllowedExtensions != nil && !hasAnySuffix(name, rule.AllowedExtensions) {
+		return false
+	}
+	return !hasAnySuffix(name, rule.DeniedExtensions)
+}
+
+// check returns an error when the rules forbid access to p. isDir reports
+// whether p is known to be a directory, which extension rules do not apply to.
+func (r *RuleRepository) check(operation string, p *valueobjects.FilePath, isDir bool) (PathRule, error) {
+	rule := r.Resolve(p.String())
+	if !rule.hiddenAllowed() && hasHiddenElement(rule.Prefix) {
+		return rule, repositories.NewFileSystemError(operation, p.String(), "file not found", repositories.ErrorNotFound)
+	}
+	if !isDir && !rule.extensionAllowed(path.Base(rule.Prefix)) {
+		return rule, repositories.NewFileSystemError(operation, p.String(), "file type not allowed", repositories.ErrorPermissionDenied)
+	}
+	// An archive's contents are as restricted as the archive itself
+	if archivePath, _, ok := splitArchivePath(rule.Prefix); ok && !r.Resolve(archivePath).extensionAllowed(path.Base(archivePath)) {
+		return rule, repositories.NewFileSystemError(operation, p.String(), "file type not allowed", repositories.ErrorPermissionDenied)
+	}
+	return rule, nil
+}
+
+// checkFile checks access to the file at p and its size limit
+func (r *RuleRepository) checkFile(ctx context.Context, operation string, p *valueobjects.FilePath) error {
+	rule, err := r.check(operation, p, false)
+	if err != nil {
+		return err
+	}
+	if rule.MaxFileSize <= 0 {
+		return nil
+	}
+	entry, err := r.FileSystemRepository.GetFileInfo(ctx, p)
+	if err != nil {
+		return err
+	}
+	if !entry.IsDir() && entry.Size() > rule.MaxFileSize {
+		return repositories.NewFileSystemError(operation, p.String(), "file too large", repositories.ErrorFileTooLarge)
+	}
+	return nil
+}
+
+// allowed reports whether an entry may be listed or looked up
+func (r *RuleRepository) allowed(entry *entities.FileSystemEntry) bool {
+	rule := r.Resolve(entry.Path())
+	if !rule.hiddenAllowed() && hasHiddenElement(rule.Prefix) {
+		return false
+	}
+	return entry.IsDir() || rule.extensionAllowed(entry.Name())
+}
+
+// ListDirectory returns a directory listing without the entries the rules
+// forbid
+func (r *RuleRepository) ListDirectory(ctx context.Context, p *valueobjects.FilePath) (*entities.DirectoryListing, error) {
+	if _, err := r.check("ListDirectory", p, true); err != nil {
+		return nil, err
+	}
+	listing, err := r.FileSystemRepository.ListDirectory(ctx, p)
+	if err != nil {
+		return nil, err
+	}
+
+	children := []entities.FileSystemEntry{}
+	for _, entry := range listing.Entries() {
+		if r.allowed(&entry) {
+			children = append(children, entry)
+		}
+	}
+	if len(children) == len(listing.Entries()) {
+		return listing, nil
+	}
+
+	filtered, err := entities.NewDirectoryListing(listing.Path(), children)
+	if err != nil {
+		return nil, repositories.NewFileSystemError("ListDirectory", p.String(), err.Error(), repositories.ErrorUnknown)
+	}
+	for _, skipped := range listing.Skipped() {
+		filtered.AddSkipped(skipped.Name, skipped.Reason)
+	}
+	return filtered, nil
+}
+
+// ReadFile returns the content of a file the rules allow
+func (r *RuleRepository) ReadFile(ctx context.Context, p *valueobjects.FilePath) (*entities.FileContent, error) {
+	if err := r.checkFile(ctx, "ReadFile", p); err != nil {
+		return nil, err
+	}
+	return r.FileSystemRepository.ReadFile(ctx, p)
+}
+
+// OpenFile opens a file the rules allow for streaming reads
+func (r *RuleRepository) OpenFile(ctx context.Context, p *valueobjects.FilePath) (io.ReadSeekCloser, error) {
+	if err := r.checkFile(ctx, "OpenFile", p); err != nil {
+		return nil, err
+	}
+	return r.FileSystemRepository.OpenFile(ctx, p)
+}
+
+// GetFileInfo returns information about a file or directory the rules allow
+func (r *RuleRepository) GetFileInfo(ctx context.Context, p *valueobjects.FilePath) (*entities.FileSystemEntry, error) {
+	if _, err := r.check("GetFileInfo", p, true); err != nil {
+		return nil, err
+	}
+	entry, err := r.FileSystemRepository.GetFileInfo(ctx, p)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := r.check("GetFileInfo", p, entry.IsDir()); err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+// Exists checks if an allowed file or directory exists
+func (r *RuleRepository) Exists(ctx context.Context, p *valueobjects.FilePath) bool {
+	return r.permits(ctx, p) && r.FileSystemRepository.Exists(ctx, p)
+}
+
+// IsReadable checks if an allowed file or directory is readable
+func (r *RuleRepository) IsReadable(ctx context.Context, p *valueobjects.FilePath) bool {
+	return r.permits(ctx, p) && r.FileSystemRepository.IsReadable(ctx, p)
+}
+
+// permits reports whether the rules allow access to p
+func (r *RuleRepository) permits(ctx context.Context, p *valueobjects.FilePath) bool {
+	if _, err := r.check("Access", p, true); err != nil {
+		return false
+	}
+	_, err := r.check("Access", p, r.FileSystemRepository.IsDirectory(ctx, p))
+	return err == nil
+}
+
+// IsDirectory checks if the path points to an allowed directory
+func (r *RuleRepository) IsDirectory(ctx context.Context, p *valueobjects.FilePath) bool {
+	if _, err := r.check("IsDirectory", p, true); err != nil {
+		return false
+	}
+	return r.FileSystemRepository.IsDirectory(ctx, p)
+}
+
+// GetDirectoryStats returns statistics for the allowed entries of a directory
+func (r *RuleRepository) GetDirectoryStats(ctx context.Context, p *valueobjects.FilePath) (*repositories.DirectoryStats, error) {
+	listing, err := r.ListDirectory(ctx, p)
+	if err != nil {
+		return nil, err
+	}
+	return repositories.NewDirectoryStats(listing), nil
+}
+
+// cleanRulePath converts a path to the slash-separated, relative form rules
+// are matched in; the root is "."
+func cleanRulePath(p string) string {
+	p = strings.TrimPrefix(path.Clean("/"+filepath.ToSlash(p)), "/")
+	if p == "" {
+		return "."
+	}
+	return p
+}
+
+// rulePrefixMatches reports whether p is prefix or lies below it
+func rulePrefixMatches(prefix, p string) bool {
+	return prefix == "." || p == prefix || strings.HasPrefix(p, prefix+"/")
+}
+
+// hasHiddenElement reports whether any element of a slash-separated path
+// starts with a dot
+func hasHiddenElement(p string) bool {
+	for _, elem := range strings.Split(p, "/") {
+		if strings.HasPrefix(elem, ".") && elem != "." && elem != ".." {
+			return true
+		}
+	}
+	return false
+}
+
+// lowerExtensions lowercases extensions and adds missing leading dots,
+// keeping nil and empty lists apart
+func lowerExtensions(exts []string) []string {
+	if exts == nil {
+		return nil
+	}
+	lowered := make([]string, len(exts))
+	for i, ext := range exts {
+		ext = strings.ToLower(ext)
+		if !strings.HasPrefix(ext, ".") {
+			ext = "." + ext
+		}
+		lowered[i] = ext
+	}
+	return lowered
+}
+
+// hasAnySuffix reports whether name ends with one of suffixes
+func hasAnySuffix(name string, suffixes []string) bool {
+	for _, suffix := range suffixes {
+		if strings.HasSuffix(name, suffix) {
+			return true
+		}
+	}
+	return false
+}
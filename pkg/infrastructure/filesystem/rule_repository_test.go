@@ -0,0 +1,125 @@
+package filesystem
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/sh05/cat-server/pkg/domain/repositories"
+	"github.com/sh05/cat-server/pkg/domain/valueobjects"
+)
+
+func TestRuleRepositoryResolve(t *testing.T) {
+	deny := false
+	repo := NewRuleRepository(nil, PathRule{MaxFileSize: 100}, []PathRule{
+		{Prefix: "logs/archive", MaxFileSize: 1000},
+		{Prefix: "/logs/", AllowHidden: &deny, AllowedExtensions: []string{"LOG", ".gz"}},
+		{Prefix: "logs/archive/raw", AllowedExtensions: []string{}},
+	})
+
+	tests := []struct {
+		path        string
+		maxFileSize int64
+		allowHidden *bool
+		allowed     []string
+	}{
+		{"readme.txt", 100, nil, nil},
+		{"logs2/app.log", 100, nil, nil},
+		{"logs", 100, &deny, []string{".log", ".gz"}},
+		{"logs/app.log", 100, &deny, []string{".log", ".gz"}},
+		{"logs/archive/2024.gz", 1000, &deny, []string{".log", ".gz"}},
+		{"logs/archive/raw/dump.bin", 1000, &deny, []string{}},
+	}
+	for _, tt := range tests {
+		rule := repo.Resolve(tt.path)
+		if rule.MaxFileSize != tt.maxFileSize || rule.AllowHidden != tt.allowHidden || !reflect.DeepEqual(rule.AllowedExtensions, tt.allowed) {
+			t.Errorf("Resolve(%q) = {%d %v %v}, expected {%d %v %v}", tt.path,
+				rule.MaxFileSize, rule.AllowHidden, rule.AllowedExtensions, tt.maxFileSize, tt.allowHidden, tt.allowed)
+		}
+	}
+
+	if repo.Resolve("logs/x").hiddenAllowed() || !repo.Resolve("other").hiddenAllowed() {
+		t.Error("Expected hidden files to be denied only below logs")
+	}
+}
+
+func TestRuleRepository(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"readme.txt":         "readme\n",
+		".profile":           "base hidden\n",
+		"logs/app.log":       "log line\n",
+		"logs/big.log":       "0123456789",
+		"logs/notes.txt":     "notes\n",
+		"logs/.secret.log":   "hidden\n",
+		"logs/large/big.log": "0123456789",
+	} {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	deny := false
+	repo := NewRuleRepository(NewFileSystemRepository(dir, 1024), PathRule{MaxFileSize: 1024}, []PathRule{
+		{Prefix: "logs", MaxFileSize: 9, AllowHidden: &deny, DeniedExtensions: []string{".txt"}},
+		{Prefix: "logs/large", MaxFileSize: 100},
+	})
+
+	readTests := []struct {
+		path string
+		code repositories.ErrorCode
+	}{
+		{"readme.txt", -1},
+		{".profile", -1},
+		{"logs/app.log", -1},
+		{"logs/big.log", repositories.ErrorFileTooLarge},
+		{"logs/large/big.log", -1},
+		{"logs/notes.txt", repositories.ErrorPermissionDenied},
+		{"logs/.secret.log", repositories.ErrorNotFound},
+	}
+	for _, tt := range readTests {
+		p, _ := valueobjects.NewFilePath(tt.path)
+		_, err := repo.ReadFile(context.Background(), p)
+		if tt.code < 0 {
+			if err != nil {
+				t.Errorf("ReadFile(%q) returned error: %v", tt.path, err)
+			}
+			continue
+		}
+		var fsErr *repositories.FileSystemError
+		if !errors.As(err, &fsErr) || fsErr.Code != tt.code {
+			t.Errorf("ReadFile(%q): expected error code %d, got %v", tt.path, tt.code, err)
+		}
+		if r, err := repo.OpenFile(context.Background(), p); err == nil {
+			r.Close()
+			t.Errorf("OpenFile(%q): expected an error", tt.path)
+		}
+	}
+
+	p, _ := valueobjects.NewFilePath("logs")
+	listing, err := repo.ListDirectory(context.Background(), p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, entry := range listing.Entries() {
+		names = append(names, entry.Name())
+	}
+	if expected := []string{"app.log", "big.log", "large"}; !reflect.DeepEqual(names, expected) {
+		t.Errorf("Expected listing %v, got %v", expected, names)
+	}
+
+	for path, exists := range map[string]bool{"logs/app.log": true, "logs/notes.txt": false, "logs/.secret.log": false, "logs/large": true} {
+		p, _ := valueobjects.NewFilePath(path)
+		if repo.Exists(context.Background(), p) != exists {
+			t.Errorf("Exists(%q) = %v, expected %v", path, !exists, exists)
+		}
+	}
+}
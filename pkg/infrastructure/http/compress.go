@@ -0,0 +1,277 @@
+package http
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/zstd"
+)
+
+// encoder is a pooled compressor of one content encoding
+type encoder interface {
+	io.WriteCloser
+	Flush() error
+	Reset(w io.Writer)
+}
+
+// encoderPools holds the supported content encodings. HTTP's deflate is
+// the zlib format, not raw DEFLATE.
+var encoderPools = map[string]*sync.Pool{
+	"gzip":    {New: func() any { return gzip.NewWriter(nil) }},
+	"deflate": {New: func() any { return zlib.NewWriter(nil) }},
+	"br":      {New: func() any { return brotli.NewWriterLevel(nil, brotli.DefaultCompression) }},
+	"zstd":    {New: func() any { return newZstdEncoder() }},
+}
+
+// newZstdEncoder creates an encoder compressing on the calling goroutine,
+// as each one serves a single response. The options are valid, so
+// zstd.NewWriter cannot fail.
+func newZstdEncoder() *zstd.Encoder {
+	enc, err := zstd.NewWriter(nil, zstd.WithEncoderConcurrency(1))
+	if err != nil {
+		panic(err)
+	}
+	return enc
+}
+
+// compressibleTypes are the textual media types outside text/*. Other
+// types are binary and often already compressed, and large binary
+// downloads are better left to sendfile.
+var compressibleTypes = map[string]bool{
+	"application/json":       true,
+	"application/x-ndjson":   true,
+	"application/javascript": true,
+	"application/xml":        true,
+	"application/yaml":       true,
+	"application/x-yaml":     true,
+	"application/toml":       true,
+	"application/sql":        true,
+	"image/svg+xml":          true,
+}
+
+// compressible reports whether a body of contentType is worth compressing
+func compressible(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	return strings.HasPrefix(mediaType, "text/") || compressibleTypes[mediaType] ||
+		strings.HasSuffix(mediaType, "+json") || strings.HasSuffix(mediaType, "+xml")
+}
+
+// CompressionMiddleware compresses response bodies of at least minBytes
+// with the first of encodings, in order of preference, that the client
+// accepts. Bodies are held back until minBytes have been written, the
+// handler flushes or it returns. Only textual media types are compressed;
+// binary ones, range requests and partial or bodiless responses are sent
+// as they are.
+func CompressionMiddleware(encodings []string, minBytes int) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Add("Vary", "Accept-Encoding")
+			encoding := negotiateEncoding(r.Header.Get("Accept-Encoding"), encodings)
+			if encoding == "" || r.Method == http.MethodHead || r.Header.Get("Range") != "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			// Not deferred: after a panic, the recovery middleware answers
+			// with an error instead of the buffered body
+			cw := &compressWriter{ResponseWriter: w, encoding: encoding, minBytes: minBytes, status: http.StatusOK}
+			next.ServeHTTP(cw, r)
+			cw.close()
+		})
+	}
+}
+
+// negotiateEncoding returns the encoding of offered with the highest
+// quality in an Accept-Encoding header, preferring earlier ones on ties,
+// or "" when the client accepts none of them
+func negotiateEncoding(header string, offered []string) string {
+	if header == "" {
+		return ""
+	}
+	accepted := make(map[string]float64)
+	for _, part := range strings.Split(header, ",") {
+		name, params, _ := strings.Cut(part, ";")
+		quality := 1.0
+		if q, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			var err error
+			if quality, err = strconv.ParseFloat(q, 64); err != nil {
+				continue
+			}
+		}
+		accepted[strings.ToLower(strings.TrimSpace(name))] = quality
+	}
+
+	best, bestQuality := "", 0.0
+	for _, encoding := range offered {
+		quality, ok := accepted[encoding]
+		if !ok {
+			quality = accepted["*"]
+		}
+		if quality > bestQuality {
+			best, bestQuality = encoding, quality
+		}
+	}
+	return best
+}
+
+// compressWriter buffers the start of a response to decide whether to
+// compress it
+type compressWriter struct {
+	http.ResponseWriter
+	encoding string
+	minBytes int
+
+	status      int
+	wroteHeader bool // the handler called WriteHeader
+	decided     bool // the header was sent, compressed when enc is set
+	buf         []byte
+	enc         encoder
+}
+
+func (w *compressWriter) WriteHeader(status int) {
+	if w.wroteHeader || w.decided {
+		return
+	}
+	if status >= 100 && status < 200 {
+		w.ResponseWriter.WriteHeader(status)
+		return
+	}
+	w.status = status
+	w.wroteHeader = true
+
+	// Decide at once when the body cannot or surely will be compressed,
+	// so large responses are not held back
+	h := w.Header()
+	if !w.eligible() {
+		w.decide(false)
+	} else if length, err := strconv.Atoi(h.Get("Content-Length")); err == nil {
+		w.decide(length >= w.minBytes)
+	}
+}
+
+func (w *compressWriter) Write(p []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	if w.decided {
+		if w.enc != nil {
+			return w.enc.Write(p)
+		}
+		return w.ResponseWriter.Write(p)
+	}
+
+	w.buf = append(w.buf, p...)
+	if len(w.buf) >= w.minBytes {
+		if err := w.decide(true); err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
+
+// ReadFrom passes uncompressed files on to the server, which sends them
+// with sendfile
+func (w *compressWriter) ReadFrom(src io.Reader) (int64, error) {
+	switch {
+	case !w.decided:
+		// Hide ReadFrom so io.Copy writes through Write
+		return io.Copy(struct{ io.Writer }{w}, src)
+	case w.enc != nil:
+		return io.Copy(w.enc, src)
+	}
+	return io.Copy(w.ResponseWriter, src)
+}
+
+// Flush sends what was written so far, compressing it when it may be
+// compressed since the rest of the response may be large
+func (w *compressWriter) Flush() {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	if !w.decided {
+		w.decide(true)
+	}
+	if w.enc != nil {
+		w.enc.Flush()
+	}
+	http.NewResponseController(w.ResponseWriter).Flush()
+}
+
+// Unwrap exposes the underlying writer to http.ResponseController
+func (w *compressWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// eligible reports whether the response may be compressed judging by its
+// status and headers
+func (w *compressWriter) eligible() bool {
+	h := w.Header()
+	if w.status < http.StatusOK || w.status == http.StatusNoContent || w.status == http.StatusPartialContent ||
+		w.status == http.StatusNotModified || h.Get("Content-Encoding") != "" {
+		return false
+	}
+	contentType := h.Get("Content-Type")
+	return contentType == "" || compressible(contentType)
+}
+
+// decide sends the header, with the body compressed if compress is set
+// and the response is eligible, followed by the buffered body
+func (w *compressWriter) decide(compress bool) error {
+	w.decided = true
+	h := w.Header()
+	if compress && h.Get("Content-Type") == "" && len(w.buf) > 0 {
+		// Sniff as net/http would, to skip binary content
+		h.Set("Content-Type", http.DetectContentType(w.buf))
+	}
+	if compress && w.eligible() {
+		h.Set("Content-Encoding", w.encoding)
+		h.Del("Content-Length")
+		// Ranges and strong validators refer to the uncompressed body
+		h.Del("Accept-Ranges")
+		if etag := h.Get("ETag"); etag != "" && !strings.HasPrefix(etag, "W/") {
+			h.Set("ETag", "W/"+etag)
+		}
+		w.enc = encoderPools[w.encoding].Get().(encoder)
+		w.enc.Reset(w.ResponseWriter)
+	}
+	w.ResponseWriter.WriteHeader(w.status)
+
+	buf := w.buf
+	w.buf = nil
+	if len(buf) == 0 {
+		return nil
+	}
+	if w.enc != nil {
+		_, err := w.enc.Write(buf)
+		return err
+	}
+	_, err := w.ResponseWriter.Write(buf)
+	return err
+}
+
+// close sends what is still buffered and finishes the compressed stream
+func (w *compressWriter) close() {
+	if !w.decided {
+		if !w.wroteHeader && len(w.buf) == 0 {
+			// Nothing was written; net/http sends an empty 200
+			return
+		}
+		w.decide(false)
+	}
+	if w.enc != nil {
+		w.enc.Close()
+		w.enc.Reset(io.Discard)
+		encoderPools[w.encoding].Put(w.enc)
+		w.enc = nil
+	}
+}
@@ -0,0 +1,182 @@
+package http
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/zstd"
+)
+
+func TestNegotiateEncoding(t *testing.T) {
+	offered := []string{"gzip", "deflate"}
+	for _, tt := range []struct {
+		header   string
+		expected string
+	}{
+		{"", ""},
+		{"gzip", "gzip"},
+		{"deflate, gzip", "gzip"},
+		{"deflate", "deflate"},
+		{"gzip;q=0.5, deflate", "deflate"},
+		{"gzip;q=0, deflate;q=0", ""},
+		{"br", ""},
+		{"*", "gzip"},
+		{"*;q=0.1, gzip;q=0", "deflate"},
+		{"GZIP", "gzip"},
+	} {
+		if got := negotiateEncoding(tt.header, offered); got != tt.expected {
+			t.Errorf("%q: expected %q, got %q", tt.header, tt.expected, got)
+		}
+	}
+
+	// Clients advertising everything get the server's first preference
+	offered = []string{"zstd", "br", "gzip"}
+	for _, tt := range []struct {
+		header   string
+		expected string
+	}{
+		{"gzip, deflate, br, zstd", "zstd"},
+		{"gzip, br", "br"},
+		{"zstd;q=0.5, br", "br"},
+	} {
+		if got := negotiateEncoding(tt.header, offered); got != tt.expected {
+			t.Errorf("%q: expected %q, got %q", tt.header, tt.expected, got)
+		}
+	}
+}
+
+func TestCompressionMiddleware(t *testing.T) {
+	large := strings.Repeat(`{"name":"app.log","size":1024}`, 100)
+	for _, tt := range []struct {
+		name           string
+		acceptEncoding string
+		rangeHeader    string
+		contentType    string
+		status         int
+		body           string
+		expected       string // Content-Encoding
+	}{
+		{"gzip", "gzip", "", "application/json; charset=utf-8", http.StatusOK, large, "gzip"},
+		{"deflate", "deflate", "", "application/json", http.StatusOK, large, "deflate"},
+		{"br", "br", "", "application/json", http.StatusOK, large, "br"},
+		{"zstd", "zstd", "", "application/json", http.StatusOK, large, "zstd"},
+		{"zstd reused", "zstd", "", "text/plain", http.StatusOK, large + large, "zstd"},
+		{"sniffed", "gzip", "", "", http.StatusOK, large, "gzip"},
+		{"not accepted", "", "", "application/json", http.StatusOK, large, ""},
+		{"small", "gzip", "", "application/json", http.StatusOK, `{}`, ""},
+		{"compressed type", "gzip", "", "application/zip", http.StatusOK, large, ""},
+		{"image", "gzip", "", "image/png", http.StatusOK, large, ""},
+		{"binary", "gzip", "", "application/octet-stream", http.StatusOK, large, ""},
+		{"sniffed binary", "gzip", "", "", http.StatusOK, "\x00\x01" + large, ""},
+		{"svg", "gzip", "", "image/svg+xml", http.StatusOK, large, "gzip"},
+		{"range", "gzip", "bytes=0-9", "text/plain", http.StatusOK, large, ""},
+		{"error", "gzip", "", ProblemContentType, http.StatusNotFound, large, "gzip"},
+		{"not modified", "gzip", "", "text/plain", http.StatusNotModified, "", ""},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := CompressionMiddleware([]string{"gzip", "deflate", "br", "zstd"}, 1024)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if tt.contentType != "" {
+					w.Header().Set("Content-Type", tt.contentType)
+				}
+				w.WriteHeader(tt.status)
+				// Write in chunks, as streaming handlers do
+				for chunk := range chunks(tt.body, 100) {
+					io.WriteString(w, chunk)
+				}
+			}))
+			req := httptest.NewRequest(http.MethodGet, "/ls", nil)
+			if tt.acceptEncoding != "" {
+				req.Header.Set("Accept-Encoding", tt.acceptEncoding)
+			}
+			if tt.rangeHeader != "" {
+				req.Header.Set("Range", tt.rangeHeader)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+
+			if w.Code != tt.status {
+				t.Errorf("Expected status %d, got %d", tt.status, w.Code)
+			}
+			if got := w.Header().Get("Content-Encoding"); got != tt.expected {
+				t.Fatalf("Expected Content-Encoding %q, got %q", tt.expected, got)
+			}
+			if w.Header().Get("Vary") != "Accept-Encoding" {
+				t.Errorf("Expected Vary: Accept-Encoding, got %q", w.Header().Get("Vary"))
+			}
+
+			var body io.Reader = w.Body
+			switch tt.expected {
+			case "gzip":
+				body, _ = gzip.NewReader(w.Body)
+			case "deflate":
+				body, _ = zlib.NewReader(w.Body)
+			case "br":
+				body = brotli.NewReader(w.Body)
+			case "zstd":
+				decoder, _ := zstd.NewReader(w.Body)
+				defer decoder.Close()
+				body = decoder
+			}
+			if decoded, err := io.ReadAll(body); err != nil || string(decoded) != tt.body {
+				t.Errorf("Expected the body back, got %d bytes, %v", len(decoded), err)
+			}
+		})
+	}
+}
+
+func TestCompressionMiddlewareFlush(t *testing.T) {
+	for _, encoding := range []string{"gzip", "br", "zstd"} {
+		handler := CompressionMiddleware([]string{encoding}, 1024)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "text/plain")
+			io.WriteString(w, "line 1\n")
+			if err := http.NewResponseController(w).Flush(); err != nil {
+				t.Errorf("%s: expected flushing to be supported, got %v", encoding, err)
+			}
+			if !w.(http.Flusher).(*compressWriter).decided {
+				t.Errorf("%s: expected flushing to send the header", encoding)
+			}
+			io.WriteString(w, "line 2\n")
+		}))
+		req := httptest.NewRequest(http.MethodGet, "/logs?follow=true", nil)
+		req.Header.Set("Accept-Encoding", encoding)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+
+		if !w.Flushed || w.Header().Get("Content-Encoding") != encoding {
+			t.Fatalf("%s: expected a flushed stream, got %v", encoding, w.Header())
+		}
+		var body io.Reader
+		switch encoding {
+		case "gzip":
+			body, _ = gzip.NewReader(w.Body)
+		case "br":
+			body = brotli.NewReader(w.Body)
+		case "zstd":
+			decoder, _ := zstd.NewReader(w.Body)
+			defer decoder.Close()
+			body = decoder
+		}
+		if decoded, _ := io.ReadAll(body); string(decoded) != "line 1\nline 2\n" {
+			t.Errorf("%s: expected both lines, got %q", encoding, decoded)
+		}
+	}
+}
+
+// chunks yields s in chunks of at most n bytes
+func chunks(s string, n int) func(func(string) bool) {
+	return func(yield func(string) bool) {
+		for len(s) > 0 {
+			chunk := s[:min(n, len(s))]
+			s = s[len(chunk):]
+			if !yield(chunk) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package http
+
+import (
+	"net/http"
+	"sync/atomic"
+)
+
+// Drain tracks in-flight requests so shutdown can report how many finished
+// in time. Once draining starts, new requests are refused with 503 and
+// Connection: close so clients reconnect, reaching whichever process now
+// owns the listener.
+type Drain struct {
+	inFlight atomic.Int64
+	draining atomic.Bool
+	// completed counts requests finished since draining started
+	completed atomic.Int64
+}
+
+// NewDrain creates a drain tracker
+func NewDrain() *Drain {
+	return &Drain{}
+}
+
+// Middleware counts requests while they are served and refuses new ones
+// while draining
+func (d *Drain) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if d.draining.Load() {
+			w.Header().Set("Connection", "close")
+			w.Header().Set("Retry-After", "1")
+			WriteProblemCode(w, r, CodeShuttingDown, "Server is shutting down")
+			return
+		}
+
+		d.inFlight.Add(1)
+		defer func() {
+			d.inFlight.Add(-1)
+			if d.draining.Load() {
+				d.completed.Add(1)
+			}
+		}()
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Start begins draining and returns the number of requests in flight
+func (d *Drain) Start() int64 {
+	d.draining.Store(true)
+	return d.inFlight.Load()
+}
+
+// InFlight returns the number of requests being served
+func (d *Drain) InFlight() int64 {
+	return d.inFlight.Load()
+}
+
+// Completed returns the number of requests finished since draining started
+func (d *Drain) Completed() int64 {
+	return d.completed.Load()
+}
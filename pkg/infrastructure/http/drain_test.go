@@ -0,0 +1,43 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestDrain(t *testing.T) {
+	drain := NewDrain()
+	started := make(chan struct{})
+	release := make(chan struct{})
+	handler := drain.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+	}))
+
+	done := make(chan struct{})
+	go func() {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+		close(done)
+	}()
+	<-started
+
+	if pending := drain.Start(); pending != 1 {
+		t.Errorf("Expected 1 request in flight, got %d", pending)
+	}
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if rec.Code != http.StatusServiceUnavailable || rec.Header().Get("Connection") != "close" {
+		t.Errorf("Expected new requests to get 503 with Connection: close, got %d %q", rec.Code, rec.Header().Get("Connection"))
+	}
+
+	close(release)
+	<-done
+	if inFlight := drain.InFlight(); inFlight != 0 {
+		t.Errorf("Expected no requests in flight, got %d", inFlight)
+	}
+	if completed := drain.Completed(); completed != 1 {
+		t.Errorf("Expected 1 completed request, got %d", completed)
+	}
+}
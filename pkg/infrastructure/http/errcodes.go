@@ -0,0 +1,119 @@
+package http
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// ErrorCodeHeader carries the error code of a problem details response,
+// so clients can branch on it without parsing the body, e.g. for HEAD
+const ErrorCodeHeader = "X-Error-Code"
+
+// StatusClientClosedRequest is the nonstandard status, borrowed from nginx,
+// logged for requests the client abandoned before they were answered
+const StatusClientClosedRequest = 499
+
+// statusText is http.StatusText, also naming StatusClientClosedRequest
+func statusText(status int) string {
+	if status == StatusClientClosedRequest {
+		return "Client Closed Request"
+	}
+	return http.StatusText(status)
+}
+
+// ErrorCode is a stable machine-readable error code, returned in problem
+// details and logged with the response so client automation can branch on
+// it instead of parsing messages. IDs are never reused: CAT-1xxx are file
+// and path errors, CAT-2xxx invalid request parameters, CAT-3xxx access
+// and capacity errors and CAT-9xxx generic errors named after their
+// status, e.g. CAT-9404 NOT_FOUND.
+type ErrorCode struct {
+	ID     string // e.g. CAT-1404
+	Name   string // e.g. FILE_NOT_FOUND
+	Status int    // HTTP status answered with the code
+}
+
+// String returns the ID and name of the code, e.g. CAT-1404 FILE_NOT_FOUND
+func (c ErrorCode) String() string {
+	return c.ID + " " + c.Name
+}
+
+// File and path errors
+var (
+	CodePathTraversal    = ErrorCode{"CAT-1001", "PATH_TRAVERSAL", http.StatusBadRequest}
+	CodeInvalidPath      = ErrorCode{"CAT-1002", "INVALID_PATH", http.StatusBadRequest}
+	CodePermissionDenied = ErrorCode{"CAT-1003", "PERMISSION_DENIED", http.StatusForbidden}
+	CodeContentRedacted  = ErrorCode{"CAT-1004", "CONTENT_REDACTED", http.StatusForbidden}
+	CodeNotTextFile      = ErrorCode{"CAT-1005", "NOT_TEXT_FILE", http.StatusUnsupportedMediaType}
+	CodeNotImage         = ErrorCode{"CAT-1006", "NOT_IMAGE", http.StatusUnsupportedMediaType}
+	CodeFileTooLarge     = ErrorCode{"CAT-1007", "FILE_TOO_LARGE", http.StatusRequestEntityTooLarge}
+	CodeImageTooLarge    = ErrorCode{"CAT-1008", "IMAGE_TOO_LARGE", http.StatusRequestEntityTooLarge}
+	CodeDiffTooComplex   = ErrorCode{"CAT-1009", "DIFF_TOO_COMPLEX", http.StatusRequestEntityTooLarge}
+	CodeFileNotFound     = ErrorCode{"CAT-1404", "FILE_NOT_FOUND", http.StatusNotFound}
+)
+
+// Invalid request parameters
+var (
+	CodeInvalidPattern           = ErrorCode{"CAT-2001", "INVALID_PATTERN", http.StatusBadRequest}
+	CodeUnsupportedArchiveFormat = ErrorCode{"CAT-2002", "UNSUPPORTED_ARCHIVE_FORMAT", http.StatusBadRequest}
+	CodeUnsupportedTransform     = ErrorCode{"CAT-2003", "UNSUPPORTED_TRANSFORM", http.StatusBadRequest}
+	CodeInvalidColumns           = ErrorCode{"CAT-2004", "INVALID_COLUMNS", http.StatusBadRequest}
+	CodeInvalidLogQuery          = ErrorCode{"CAT-2005", "INVALID_LOG_QUERY", http.StatusBadRequest}
+	CodeInvalidDisplayOption     = ErrorCode{"CAT-2006", "INVALID_DISPLAY_OPTION", http.StatusBadRequest}
+	CodeInvalidSchema            = ErrorCode{"CAT-2007", "INVALID_SCHEMA", http.StatusBadRequest}
+	CodeInvalidContentTypeFilter = ErrorCode{"CAT-2008", "INVALID_CONTENT_TYPE_FILTER", http.StatusBadRequest}
+	CodeInvalidByteRange         = ErrorCode{"CAT-2009", "INVALID_BYTE_RANGE", http.StatusBadRequest}
+	CodeUnsupportedConversion    = ErrorCode{"CAT-2010", "UNSUPPORTED_CONVERSION", http.StatusUnsupportedMediaType}
+	CodeConversionFailed         = ErrorCode{"CAT-2011", "CONVERSION_FAILED", http.StatusUnprocessableEntity}
+	CodeUnknownComponent         = ErrorCode{"CAT-2012", "UNKNOWN_HEALTH_COMPONENT", http.StatusNotFound}
+)
+
+// Access and capacity errors
+var (
+	CodeIPDenied              = ErrorCode{"CAT-3001", "IP_DENIED", http.StatusForbidden}
+	CodeClientBanned          = ErrorCode{"CAT-3002", "CLIENT_BANNED", http.StatusForbidden}
+	CodeRequestBlocked        = ErrorCode{"CAT-3003", "REQUEST_BLOCKED", http.StatusForbidden}
+	CodeRateLimited           = ErrorCode{"CAT-3004", "RATE_LIMITED", http.StatusTooManyRequests}
+	CodeOverloaded            = ErrorCode{"CAT-3005", "OVERLOADED", http.StatusServiceUnavailable}
+	CodeShuttingDown          = ErrorCode{"CAT-3006", "SHUTTING_DOWN", http.StatusServiceUnavailable}
+	CodeQuotaExceeded         = ErrorCode{"CAT-3007", "QUOTA_EXCEEDED", http.StatusTooManyRequests}
+	CodeRequestCanceled       = ErrorCode{"CAT-3008", "REQUEST_CANCELED", StatusClientClosedRequest}
+	CodeFilesystemTimeout     = ErrorCode{"CAT-3009", "FILESYSTEM_TIMEOUT", http.StatusServiceUnavailable}
+	CodeFilesystemUnavailable = ErrorCode{"CAT-3010", "FILESYSTEM_UNAVAILABLE", http.StatusServiceUnavailable}
+	CodeMemoryPressure        = ErrorCode{"CAT-3011", "MEMORY_PRESSURE", http.StatusServiceUnavailable}
+	CodeInvalidSignature      = ErrorCode{"CAT-3012", "INVALID_SIGNATURE", http.StatusForbidden}
+	CodeSignatureExpired      = ErrorCode{"CAT-3013", "SIGNATURE_EXPIRED", http.StatusForbidden}
+)
+
+// ErrorCodes is the catalogue of specific error codes, in ID order.
+// Other errors get the generic code of their status.
+var ErrorCodes = []ErrorCode{
+	CodePathTraversal, CodeInvalidPath, CodePermissionDenied, CodeContentRedacted, CodeNotTextFile,
+	CodeNotImage, CodeFileTooLarge, CodeImageTooLarge, CodeDiffTooComplex, CodeFileNotFound,
+	CodeInvalidPattern, CodeUnsupportedArchiveFormat, CodeUnsupportedTransform, CodeInvalidColumns,
+	CodeInvalidLogQuery, CodeInvalidDisplayOption, CodeInvalidSchema, CodeInvalidContentTypeFilter,
+	CodeInvalidByteRange, CodeUnsupportedConversion, CodeConversionFailed, CodeUnknownComponent,
+	CodeIPDenied, CodeClientBanned, CodeRequestBlocked, CodeRateLimited, CodeOverloaded, CodeShuttingDown,
+	CodeQuotaExceeded, CodeRequestCanceled, CodeFilesystemTimeout, CodeFilesystemUnavailable,
+	CodeMemoryPressure, CodeInvalidSignature, CodeSignatureExpired,
+}
+
+// CodeForStatus returns the generic code of status, CAT-9 followed by the
+// status and named after its status text, e.g. CAT-9405
+// METHOD_NOT_ALLOWED
+func CodeForStatus(status int) ErrorCode {
+	name := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		case r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			return r
+		}
+		return '_'
+	}, statusText(status))
+	if name == "" {
+		name = "ERROR"
+	}
+	return ErrorCode{ID: fmt.Sprintf("CAT-9%03d", status), Name: name, Status: status}
+}
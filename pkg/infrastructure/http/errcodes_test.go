@@ -0,0 +1,46 @@
+package http
+
+import (
+	"net/http"
+	"regexp"
+	"testing"
+)
+
+func TestErrorCodes(t *testing.T) {
+	format := regexp.MustCompile(`^CAT-[1-3]\d{3}$`)
+	ids := make(map[string]bool)
+	names := make(map[string]bool)
+	for _, code := range ErrorCodes {
+		if !format.MatchString(code.ID) {
+			t.Errorf("Code %s does not match %s", code, format)
+		}
+		if ids[code.ID] || names[code.Name] {
+			t.Errorf("Code %s is not unique", code)
+		}
+		ids[code.ID], names[code.Name] = true, true
+		if statusText(code.Status) == "" || code.Status < http.StatusBadRequest {
+			t.Errorf("Code %s has invalid status %d", code, code.Status)
+		}
+	}
+}
+
+func TestCodeForStatus(t *testing.T) {
+	tests := []struct {
+		status int
+		id     string
+		name   string
+	}{
+		{http.StatusNotFound, "CAT-9404", "NOT_FOUND"},
+		{http.StatusMethodNotAllowed, "CAT-9405", "METHOD_NOT_ALLOWED"},
+		{http.StatusRequestURITooLong, "CAT-9414", "REQUEST_URI_TOO_LONG"},
+		{http.StatusInternalServerError, "CAT-9500", "INTERNAL_SERVER_ERROR"},
+		{StatusClientClosedRequest, "CAT-9499", "CLIENT_CLOSED_REQUEST"},
+		{599, "CAT-9599", "ERROR"},
+	}
+	for _, tt := range tests {
+		code := CodeForStatus(tt.status)
+		if code.ID != tt.id || code.Name != tt.name || code.Status != tt.status {
+			t.Errorf("CodeForStatus(%d) = %+v, expected %s %s", tt.status, code, tt.id, tt.name)
+		}
+	}
+}
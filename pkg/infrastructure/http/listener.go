@@ -0,0 +1,131 @@
+package http
+
+import (
+	"errors"
+	"io"
+	"net"
+	"os"
+	"sync"
+	"time"
+)
+
+// ListenerOptions tunes the connections accepted by a listener
+type ListenerOptions struct {
+	// MaxConnsPerIP limits concurrent connections from one remote address;
+	// further connections are closed as soon as they are accepted. 0 means
+	// no limit.
+	MaxConnsPerIP int
+	// KeepAlive is the TCP keep-alive period; 0 keeps the listener's
+	// default and a negative value disables keep-alive probes
+	KeepAlive time.Duration
+}
+
+// TuneListener applies opts to the connections accepted by ln. The result
+// still hands out its file descriptor, so it can be passed to a restarted
+// process.
+func TuneListener(ln net.Listener, opts ListenerOptions) net.Listener {
+	if opts.MaxConnsPerIP == 0 && opts.KeepAlive == 0 {
+		return ln
+	}
+	return &tunedListener{Listener: ln, opts: opts, conns: make(map[string]int)}
+}
+
+// tunedListener is a listener applying ListenerOptions
+type tunedListener struct {
+	net.Listener
+	opts ListenerOptions
+
+	mu    sync.Mutex
+	conns map[string]int // open connections by remote IP
+}
+
+// Accept waits for the next connection within the per-IP limit
+func (l *tunedListener) Accept() (net.Conn, error) {
+	for {
+		conn, err := l.Listener.Accept()
+		if err != nil {
+			return nil, err
+		}
+
+		if tcp, ok := conn.(*net.TCPConn); ok && l.opts.KeepAlive != 0 {
+			if l.opts.KeepAlive < 0 {
+				tcp.SetKeepAlive(false)
+			} else {
+				tcp.SetKeepAlive(true)
+				tcp.SetKeepAlivePeriod(l.opts.KeepAlive)
+			}
+		}
+
+		if l.opts.MaxConnsPerIP == 0 {
+			return conn, nil
+		}
+		ip := remoteIP(conn)
+		if !l.acquire(ip) {
+			conn.Close()
+			continue
+		}
+		return &trackedConn{Conn: conn, release: func() { l.release(ip) }}, nil
+	}
+}
+
+// File returns a copy of the underlying listener's file descriptor
+func (l *tunedListener) File() (*os.File, error) {
+	filer, ok := l.Listener.(interface{ File() (*os.File, error) })
+	if !ok {
+		return nil, errors.New("listener has no file descriptor")
+	}
+	return filer.File()
+}
+
+// acquire counts a connection from ip unless the limit is reached
+func (l *tunedListener) acquire(ip string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.conns[ip] >= l.opts.MaxConnsPerIP {
+		return false
+	}
+	l.conns[ip]++
+	return true
+}
+
+// release forgets a closed connection from ip
+func (l *tunedListener) release(ip string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.conns[ip]--; l.conns[ip] <= 0 {
+		delete(l.conns, ip)
+	}
+}
+
+// remoteIP returns the remote address of conn without the port
+func remoteIP(conn net.Conn) string {
+	addr := conn.RemoteAddr().String()
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		return host
+	}
+	return addr
+}
+
+// trackedConn releases its per-IP slot once when closed. It forwards
+// ReadFrom so responses served from files keep using sendfile.
+type trackedConn struct {
+	net.Conn
+	once    sync.Once
+	release func()
+}
+
+// Close closes the connection and releases its slot
+func (c *trackedConn) Close() error {
+	err := c.Conn.Close()
+	c.once.Do(c.release)
+	return err
+}
+
+// ReadFrom copies r to the underlying connection, letting a *net.TCPConn
+// use sendfile or splice
+func (c *trackedConn) ReadFrom(r io.Reader) (int64, error) {
+	if rf, ok := c.Conn.(io.ReaderFrom); ok {
+		return rf.ReadFrom(r)
+	}
+	return io.Copy(struct{ io.Writer }{c.Conn}, r)
+}
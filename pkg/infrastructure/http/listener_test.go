@@ -0,0 +1,145 @@
+package http
+
+import (
+	"io"
+	"net"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestTuneListenerMaxConnsPerIP(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tuned := TuneListener(ln, ListenerOptions{MaxConnsPerIP: 1})
+	defer tuned.Close()
+
+	accepted := make(chan net.Conn, 2)
+	go func() {
+		for {
+			conn, err := tuned.Accept()
+			if err != nil {
+				return
+			}
+			accepted <- conn
+		}
+	}()
+
+	dial := func() net.Conn {
+		conn, err := net.Dial("tcp", ln.Addr().String())
+		if err != nil {
+			t.Fatal(err)
+		}
+		return conn
+	}
+	expectAccepted := func(want bool) net.Conn {
+		select {
+		case conn := <-accepted:
+			if !want {
+				t.Fatal("Expected the connection over the limit to be refused")
+			}
+			return conn
+		case <-time.After(200 * time.Millisecond):
+			if want {
+				t.Fatal("Expected the connection to be accepted")
+			}
+			return nil
+		}
+	}
+
+	first := dial()
+	defer first.Close()
+	conn := expectAccepted(true)
+
+	second := dial()
+	defer second.Close()
+	expectAccepted(false)
+	second.SetReadDeadline(time.Now().Add(time.Second))
+	if _, err := second.Read(make([]byte, 1)); err == nil {
+		t.Error("Expected the refused connection to be closed")
+	}
+
+	// Closing the first connection frees its slot
+	conn.Close()
+	third := dial()
+	defer third.Close()
+	expectAccepted(true).Close()
+}
+
+func TestTuneListenerFile(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	if TuneListener(ln, ListenerOptions{}) != ln {
+		t.Error("Expected the listener to be returned unchanged without options")
+	}
+
+	tuned := TuneListener(ln, ListenerOptions{KeepAlive: time.Minute})
+	filer, ok := tuned.(interface{ File() (*os.File, error) })
+	if !ok {
+		t.Fatal("Expected the tuned listener to expose its file descriptor")
+	}
+	file, err := filer.File()
+	if err != nil {
+		t.Fatal(err)
+	}
+	file.Close()
+}
+
+func TestTrackedConnReadFrom(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tuned := TuneListener(ln, ListenerOptions{MaxConnsPerIP: 1})
+	defer tuned.Close()
+
+	client, err := net.Dial("tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	conn, err := tuned.Accept()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	// The tracked connection must keep the TCP fast path for file bodies
+	rf, ok := conn.(io.ReaderFrom)
+	if !ok {
+		t.Fatal("Expected the tracked connection to implement io.ReaderFrom")
+	}
+
+	file, err := os.CreateTemp(t.TempDir(), "body")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	want := "sent from a file"
+	if _, err := file.WriteString(want); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		t.Fatal(err)
+	}
+
+	n, err := rf.ReadFrom(file)
+	if err != nil || n != int64(len(want)) {
+		t.Fatalf("Expected %d bytes copied, got %d (%v)", len(want), n, err)
+	}
+	got := make([]byte, len(want))
+	client.SetReadDeadline(time.Now().Add(time.Second))
+	if _, err := io.ReadFull(client, got); err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != want {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+}
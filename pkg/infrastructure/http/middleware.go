@@ -0,0 +1,105 @@
+package http
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/sh05/cat-server/pkg/infrastructure/logging"
+)
+
+// Middleware wraps an http.Handler
+type Middleware func(http.Handler) http.Handler
+
+// ChainMiddleware chains middleware so the first one is outermost
+func ChainMiddleware(middlewares ...Middleware) Middleware {
+	return func(next http.Handler) http.Handler {
+		for i := len(middlewares) - 1; i >= 0; i-- {
+			next = middlewares[i](next)
+		}
+		return next
+	}
+}
+
+// RecoveryMiddleware recovers from panics in later handlers, logs them and
+// answers 500 so one bad request does not take down the connection
+func RecoveryMiddleware(logger *logging.Logger) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			defer func() {
+				err := recover()
+				if err == nil {
+					return
+				}
+				// Handlers abort responses deliberately with ErrAbortHandler
+				if err == http.ErrAbortHandler {
+					panic(err)
+				}
+
+				logging.FromContext(r.Context(), logger).Error("panic recovered",
+					"error", err,
+					"method", r.Method,
+					"path", r.URL.Path,
+					"remote_addr", r.RemoteAddr,
+				)
+				WriteProblem(w, r, http.StatusInternalServerError, "")
+			}()
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// MethodMiddleware refuses methods outside allowedMethods
+func MethodMiddleware(allowedMethods ...string) Middleware {
+	allowed := make(map[string]bool, len(allowedMethods))
+	for _, method := range allowedMethods {
+		allowed[method] = true
+	}
+	allow := strings.Join(allowedMethods, ", ")
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !allowed[r.Method] {
+				w.Header().Set("Allow", allow)
+				WriteProblem(w, r, http.StatusMethodNotAllowed, "")
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// CORSMiddleware allows cross-origin reads from any origin and answers
+// preflight requests. Credentials are not allowed, so browsers never send
+// cookies cross-origin.
+func CORSMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Accept, Authorization, Content-Type, X-Request-ID, If-None-Match")
+		w.Header().Set("Access-Control-Expose-Headers", "ETag, X-Request-ID, "+ErrorCodeHeader)
+
+		// Handle preflight requests
+		if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// TimeoutMiddleware answers 503 when a handler takes longer than timeout.
+// Responses are buffered until the handler returns, so it does not suit
+// streaming endpoints.
+func TimeoutMiddleware(timeout time.Duration) Middleware {
+	return func(next http.Handler) http.Handler {
+		handler := http.TimeoutHandler(next, timeout, "")
+		// The timeout response is plain text
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ServeProblems(handler, w, r)
+		})
+	}
+}
@@ -0,0 +1,98 @@
+package http
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/sh05/cat-server/pkg/infrastructure/logging"
+)
+
+func TestChainMiddleware(t *testing.T) {
+	var order []string
+	tag := func(name string) Middleware {
+		return func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				order = append(order, name)
+				next.ServeHTTP(w, r)
+			})
+		}
+	}
+
+	handler := ChainMiddleware(tag("a"), tag("b"), tag("c"))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		order = append(order, "handler")
+	}))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if expected := []string{"a", "b", "c", "handler"}; !reflect.DeepEqual(order, expected) {
+		t.Errorf("Expected order %v, got %v", expected, order)
+	}
+}
+
+func TestRecoveryMiddleware(t *testing.T) {
+	logger := logging.NewLoggerWithWriter(logging.LevelError, "json", io.Discard)
+	handler := RecoveryMiddleware(logger)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	}))
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("Expected status 500, got %d", w.Code)
+	}
+}
+
+func TestMethodAndCORSMiddleware(t *testing.T) {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) })
+	handler := ChainMiddleware(MethodMiddleware(http.MethodGet, http.MethodOptions), CORSMiddleware)(ok)
+
+	tests := []struct {
+		name      string
+		method    string
+		preflight bool
+		status    int
+		allow     string
+	}{
+		{"get", http.MethodGet, false, http.StatusOK, ""},
+		{"preflight", http.MethodOptions, true, http.StatusNoContent, ""},
+		{"plain options", http.MethodOptions, false, http.StatusOK, ""},
+		{"refused method", http.MethodDelete, false, http.StatusMethodNotAllowed, "GET, OPTIONS"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(tt.method, "/ls", nil)
+			if tt.preflight {
+				r.Header.Set("Access-Control-Request-Method", http.MethodGet)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+
+			if w.Code != tt.status {
+				t.Errorf("Expected status %d, got %d", tt.status, w.Code)
+			}
+			if w.Header().Get("Allow") != tt.allow {
+				t.Errorf("Expected Allow %q, got %q", tt.allow, w.Header().Get("Allow"))
+			}
+			cors := w.Header().Get("Access-Control-Allow-Origin")
+			if (tt.status != http.StatusMethodNotAllowed) != (cors == "*") {
+				t.Errorf("Unexpected Access-Control-Allow-Origin %q", cors)
+			}
+		})
+	}
+}
+
+func TestTimeoutMiddleware(t *testing.T) {
+	handler := TimeoutMiddleware(10 * time.Millisecond)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected status 503, got %d", w.Code)
+	}
+}
@@ -0,0 +1,110 @@
+package http
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"github.com/sh05/cat-server/pkg/infrastructure/logging"
+)
+
+// ProblemContentType is the media type of problem details responses
+const ProblemContentType = "application/problem+json"
+
+// Problem is an RFC 7807 problem details object, the body of every error
+// response. Type is about:blank, so Title is the status text and Detail
+// explains this occurrence. Code and CodeName identify the error for
+// clients, see ErrorCode.
+type Problem struct {
+	Type      string `json:"type"`
+	Title     string `json:"title"`
+	Status    int    `json:"status"`
+	Code      string `json:"code"`
+	CodeName  string `json:"codeName"`
+	Detail    string `json:"detail,omitempty"`
+	Instance  string `json:"instance,omitempty"`
+	RequestID string `json:"requestId,omitempty"`
+}
+
+// NewProblem describes the error answered to r with code. detail may be
+// empty when the code says it all.
+func NewProblem(r *http.Request, code ErrorCode, detail string) *Problem {
+	problem := &Problem{
+		Type:     "about:blank",
+		Title:    statusText(code.Status),
+		Status:   code.Status,
+		Code:     code.ID,
+		CodeName: code.Name,
+		Detail:   detail,
+		Instance: r.URL.Path,
+	}
+	if requestID, ok := logging.RequestIDFromContext(r.Context()); ok {
+		problem.RequestID = requestID
+	}
+	return problem
+}
+
+// WriteProblem answers r with status and a problem details body carrying
+// the generic code of status. Like http.Error, it leaves other headers
+// alone and the handler should write nothing else.
+func WriteProblem(w http.ResponseWriter, r *http.Request, status int, detail string) {
+	WriteProblemCode(w, r, CodeForStatus(status), detail)
+}
+
+// WriteProblemCode answers r with the status of code and a problem details
+// body carrying code, like WriteProblem
+func WriteProblemCode(w http.ResponseWriter, r *http.Request, code ErrorCode, detail string) {
+	h := w.Header()
+	// Drop headers describing a body the handler meant to send instead
+	h.Del("Content-Length")
+	h.Del("Content-Encoding")
+	h.Set("Content-Type", ProblemContentType)
+	h.Set("X-Content-Type-Options", "nosniff")
+	h.Set(ErrorCodeHeader, code.ID)
+	w.WriteHeader(code.Status)
+	json.NewEncoder(w).Encode(NewProblem(r, code, detail))
+}
+
+// problemWriter replaces the body of error responses that are not problem
+// details, such as the plain text ones net/http writes in http.ServeMux or
+// http.TimeoutHandler, with problem details
+type problemWriter struct {
+	http.ResponseWriter
+	r       *http.Request
+	problem bool // whether the body written so far is being replaced
+}
+
+func (w *problemWriter) WriteHeader(status int) {
+	if status < http.StatusBadRequest || w.Header().Get("Content-Type") == ProblemContentType {
+		w.ResponseWriter.WriteHeader(status)
+		return
+	}
+	w.problem = true
+	WriteProblem(w.ResponseWriter, w.r, status, "")
+}
+
+func (w *problemWriter) Write(p []byte) (int, error) {
+	if w.problem {
+		return len(p), nil
+	}
+	return w.ResponseWriter.Write(p)
+}
+
+// ReadFrom passes files on to the server, which sends them with sendfile
+func (w *problemWriter) ReadFrom(src io.Reader) (int64, error) {
+	if w.problem {
+		return io.Copy(io.Discard, src)
+	}
+	return io.Copy(w.ResponseWriter, src)
+}
+
+func (w *problemWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// ServeProblems serves r with a handler from net/http, such as the
+// not found handler of an http.ServeMux, answering errors with problem
+// details instead of plain text
+func ServeProblems(handler http.Handler, w http.ResponseWriter, r *http.Request) {
+	handler.ServeHTTP(&problemWriter{ResponseWriter: w, r: r}, r)
+}
@@ -0,0 +1,110 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/sh05/cat-server/pkg/infrastructure/logging"
+)
+
+// decodeProblem decodes the problem details answered in w
+func decodeProblem(t *testing.T, w *httptest.ResponseRecorder) Problem {
+	t.Helper()
+	if contentType := w.Header().Get("Content-Type"); contentType != ProblemContentType {
+		t.Fatalf("Expected Content-Type %s, got %q with body %q", ProblemContentType, contentType, w.Body.String())
+	}
+	var problem Problem
+	if err := json.Unmarshal(w.Body.Bytes(), &problem); err != nil {
+		t.Fatalf("Expected a problem details body, got %q: %v", w.Body.String(), err)
+	}
+	return problem
+}
+
+func TestWriteProblem(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/cat/missing.txt?token=secret", nil)
+	r = r.WithContext(logging.NewRequestIDContext(r.Context(), "req-1"))
+	w := httptest.NewRecorder()
+	w.Header().Set("Content-Length", "42")
+	WriteProblem(w, r, http.StatusNotFound, "File not found")
+
+	expected := Problem{
+		Type:      "about:blank",
+		Title:     "Not Found",
+		Status:    http.StatusNotFound,
+		Code:      "CAT-9404",
+		CodeName:  "NOT_FOUND",
+		Detail:    "File not found",
+		Instance:  "/cat/missing.txt",
+		RequestID: "req-1",
+	}
+	if problem := decodeProblem(t, w); problem != expected {
+		t.Errorf("Expected %+v, got %+v", expected, problem)
+	}
+	if w.Code != http.StatusNotFound || w.Header().Get("Content-Length") != "" || w.Header().Get("X-Content-Type-Options") != "nosniff" ||
+		w.Header().Get(ErrorCodeHeader) != "CAT-9404" {
+		t.Errorf("Unexpected status %d or headers %v", w.Code, w.Header())
+	}
+}
+
+func TestWriteProblemCode(t *testing.T) {
+	w := httptest.NewRecorder()
+	WriteProblemCode(w, httptest.NewRequest(http.MethodGet, "/cat/../etc/passwd", nil), CodePathTraversal, "Invalid path")
+
+	problem := decodeProblem(t, w)
+	if w.Code != http.StatusBadRequest || problem.Status != http.StatusBadRequest {
+		t.Errorf("Expected status 400, got %d with %+v", w.Code, problem)
+	}
+	if problem.Code != "CAT-1001" || problem.CodeName != "PATH_TRAVERSAL" || w.Header().Get(ErrorCodeHeader) != "CAT-1001" {
+		t.Errorf("Expected code CAT-1001 PATH_TRAVERSAL, got %+v and header %q", problem, w.Header().Get(ErrorCodeHeader))
+	}
+}
+
+func TestServeProblems(t *testing.T) {
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+		status  int
+		detail  string // expected problem detail, or the body when it is not a problem
+	}{
+		{"plain text error", http.NotFound, http.StatusNotFound, ""},
+		{"problem", func(w http.ResponseWriter, r *http.Request) {
+			WriteProblem(w, r, http.StatusBadRequest, "Invalid width")
+		}, http.StatusBadRequest, "Invalid width"},
+		{"success", func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte("hello"))
+		}, http.StatusOK, "hello"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			ServeProblems(tt.handler, w, httptest.NewRequest(http.MethodGet, "/", nil))
+			if w.Code != tt.status {
+				t.Errorf("Expected status %d, got %d", tt.status, w.Code)
+			}
+			if tt.status < http.StatusBadRequest {
+				if w.Body.String() != tt.detail {
+					t.Errorf("Expected body %q, got %q", tt.detail, w.Body.String())
+				}
+				return
+			}
+			if problem := decodeProblem(t, w); problem.Status != tt.status || problem.Detail != tt.detail {
+				t.Errorf("Expected status %d and detail %q, got %+v", tt.status, tt.detail, problem)
+			}
+		})
+	}
+}
+
+func TestTimeoutMiddlewareProblem(t *testing.T) {
+	handler := TimeoutMiddleware(10 * time.Millisecond)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/ls", nil))
+	if problem := decodeProblem(t, w); problem.Status != http.StatusServiceUnavailable || problem.Instance != "/ls" {
+		t.Errorf("Expected a 503 problem for /ls, got %+v", problem)
+	}
+}
@@ -0,0 +1,49 @@
+package http
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"testing"
+)
+
+func TestServerListeners(t *testing.T) {
+	server := NewServer()
+	for _, name := range []string{"public", "admin"} {
+		ln, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		server.Add(name, &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			io.WriteString(w, name)
+		})}, ln, false)
+	}
+	errs := server.Serve()
+
+	for name, ln := range server.Listeners() {
+		resp, err := http.Get("http://" + ln.Addr().String() + "/")
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if string(body) != name {
+			t.Errorf("Expected the %s listener to answer, got %q", name, body)
+		}
+	}
+
+	if err := server.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown failed: %v", err)
+	}
+	select {
+	case err := <-errs:
+		t.Errorf("Expected no serve errors after shutdown, got %v", err)
+	default:
+	}
+	for _, ln := range server.Listeners() {
+		if _, err := http.Get("http://" + ln.Addr().String() + "/"); err == nil {
+			t.Errorf("Expected %s to be closed", ln.Addr())
+		}
+	}
+}
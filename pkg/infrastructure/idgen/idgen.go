@@ -0,0 +1,66 @@
+package idgen
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+// Generator produces unique identifiers for requests, signatures and other
+// correlated entities
+type Generator interface {
+	NewID() string
+}
+
+// GeneratorFunc adapts an ordinary function to the Generator interface
+type GeneratorFunc func() string
+
+// NewID calls f()
+func (f GeneratorFunc) NewID() string {
+	return f()
+}
+
+// RandomHex generates random hexadecimal identifiers of a fixed byte length
+type RandomHex struct {
+	bytes int
+}
+
+// NewRandomHex creates a RandomHex generator producing IDs of n random bytes
+func NewRandomHex(n int) *RandomHex {
+	if n <= 0 {
+		n = 8
+	}
+	return &RandomHex{bytes: n}
+}
+
+// NewID returns a new random identifier, falling back to a timestamp if the
+// system random source is unavailable
+func (g *RandomHex) NewID() string {
+	b := make([]byte, g.bytes)
+	if _, err := rand.Read(b); err != nil {
+		return strconv.FormatInt(time.Now().UnixNano(), 36)
+	}
+	return hex.EncodeToString(b)
+}
+
+// Sequential generates monotonically increasing identifiers with a prefix.
+// It is mainly useful for deterministic tests.
+type Sequential struct {
+	prefix  string
+	counter atomic.Uint64
+}
+
+// NewSequential creates a Sequential generator
+func NewSequential(prefix string) *Sequential {
+	return &Sequential{prefix: prefix}
+}
+
+// NewID returns the next identifier in the sequence
+func (g *Sequential) NewID() string {
+	return g.prefix + strconv.FormatUint(g.counter.Add(1), 10)
+}
+
+// Default is the generator used when none is configured
+var Default Generator = NewRandomHex(8)
@@ -0,0 +1,74 @@
+package idgen
+
+import (
+	"regexp"
+	"sync"
+	"testing"
+)
+
+func TestRandomHex(t *testing.T) {
+	tests := []struct {
+		name  string
+		bytes int
+		want  int // hex characters
+	}{
+		{"explicit length", 16, 32},
+		{"default length", 0, 16},
+	}
+
+	hexID := regexp.MustCompile(`^[0-9a-f]+$`)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewRandomHex(tt.bytes)
+			id := g.NewID()
+			if len(id) != tt.want || !hexID.MatchString(id) {
+				t.Errorf("Expected %d hex characters, got %q", tt.want, id)
+			}
+			if other := g.NewID(); other == id {
+				t.Errorf("Expected distinct IDs, got %q twice", id)
+			}
+		})
+	}
+}
+
+func TestSequential(t *testing.T) {
+	g := NewSequential("req-")
+	for _, want := range []string{"req-1", "req-2", "req-3"} {
+		if id := g.NewID(); id != want {
+			t.Errorf("Expected %q, got %q", want, id)
+		}
+	}
+}
+
+func TestSequential_Concurrent(t *testing.T) {
+	g := NewSequential("")
+	const workers, perWorker = 8, 100
+
+	var mu sync.Mutex
+	seen := make(map[string]bool)
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range perWorker {
+				id := g.NewID()
+				mu.Lock()
+				seen[id] = true
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if len(seen) != workers*perWorker {
+		t.Errorf("Expected %d unique IDs, got %d", workers*perWorker, len(seen))
+	}
+}
+
+func TestGeneratorFunc(t *testing.T) {
+	var g Generator = GeneratorFunc(func() string { return "fixed" })
+	if id := g.NewID(); id != "fixed" {
+		t.Errorf("Expected %q, got %q", "fixed", id)
+	}
+}
@@ -0,0 +1,304 @@
+package imaging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrNoEXIF is returned when an image carries no EXIF block
+var ErrNoEXIF = errors.New("no EXIF data")
+
+// maxIFDEntries bounds the entries read per directory so corrupt files
+// cannot cause large allocations
+const maxIFDEntries = 512
+
+// exifTagNames lists the tags reported by ReadEXIF, keyed by tag ID
+var exifTagNames = map[uint16]string{
+	0x010F: "Make",
+	0x0110: "Model",
+	0x0112: "Orientation",
+	0x011A: "XResolution",
+	0x011B: "YResolution",
+	0x0131: "Software",
+	0x0132: "DateTime",
+	0x013B: "Artist",
+	0x8298: "Copyright",
+	0x829A: "ExposureTime",
+	0x829D: "FNumber",
+	0x8827: "ISOSpeedRatings",
+	0x9003: "DateTimeOriginal",
+	0x9004: "DateTimeDigitized",
+	0x9209: "Flash",
+	0x920A: "FocalLength",
+	0xA002: "PixelXDimension",
+	0xA003: "PixelYDimension",
+	0xA434: "LensModel",
+}
+
+// Pointers to sub-directories and GPS tags
+const (
+	tagExifIFD         = 0x8769
+	tagGPSIFD          = 0x8825
+	tagGPSLatitudeRef  = 0x0001
+	tagGPSLatitude     = 0x0002
+	tagGPSLongitudeRef = 0x0003
+	tagGPSLongitude    = 0x0004
+	tagGPSAltitude     = 0x0006
+)
+
+// ReadEXIF extracts common EXIF tags from a JPEG file. Rational values are
+// returned as float64, except ExposureTime which keeps its "1/250" form.
+// GPS coordinates are converted to signed decimal degrees.
+func ReadEXIF(data []byte) (map[string]interface{}, error) {
+	tiff, err := findJPEGExif(data)
+	if err != nil {
+		return nil, err
+	}
+
+	var order binary.ByteOrder
+	switch {
+	case len(tiff) >= 8 && string(tiff[:2]) == "II":
+		order = binary.LittleEndian
+	case len(tiff) >= 8 && string(tiff[:2]) == "MM":
+		order = binary.BigEndian
+	default:
+		return nil, errors.New("invalid TIFF header")
+	}
+	if order.Uint16(tiff[2:]) != 42 {
+		return nil, errors.New("invalid TIFF header")
+	}
+
+	r := &tiffReader{data: tiff, order: order}
+	tags := make(map[string]interface{})
+
+	ifd0, err := r.readIFD(order.Uint32(tiff[4:]))
+	if err != nil {
+		return nil, err
+	}
+	r.collect(ifd0, tags)
+
+	if entry, ok := ifd0[tagExifIFD]; ok {
+		if exif, err := r.readIFD(r.uint32Value(entry)); err == nil {
+			r.collect(exif, tags)
+		}
+	}
+
+	if entry, ok := ifd0[tagGPSIFD]; ok {
+		if gps, err := r.readIFD(r.uint32Value(entry)); err == nil {
+			r.collectGPS(gps, tags)
+		}
+	}
+
+	return tags, nil
+}
+
+// findJPEGExif returns the TIFF structure inside a JPEG APP1 Exif segment
+func findJPEGExif(data []byte) ([]byte, error) {
+	if len(data) < 4 || data[0] != 0xFF || data[1] != 0xD8 {
+		return nil, ErrNoEXIF
+	}
+
+	for pos := 2; pos+4 <= len(data); {
+		if data[pos] != 0xFF {
+			return nil, ErrNoEXIF
+		}
+		marker := data[pos+1]
+		if marker == 0xD8 || (marker >= 0xD0 && marker <= 0xD7) || marker == 0x01 || marker == 0xFF {
+			pos++ // standalone marker or fill byte
+			if marker != 0xFF {
+				pos++
+			}
+			continue
+		}
+		if marker == 0xDA || marker == 0xD9 {
+			break // image data starts; metadata segments come before it
+		}
+
+		length := int(binary.BigEndian.Uint16(data[pos+2:]))
+		if length < 2 || pos+2+length > len(data) {
+			break
+		}
+		segment := data[pos+4 : pos+2+length]
+		if marker == 0xE1 && bytes.HasPrefix(segment, []byte("Exif\x00\x00")) {
+			return segment[6:], nil
+		}
+		pos += 2 + length
+	}
+
+	return nil, ErrNoEXIF
+}
+
+// ifdEntry is a raw 12-byte directory entry
+type ifdEntry struct {
+	typ   uint16
+	count uint32
+	value []byte // the 4-byte value/offset field
+}
+
+type tiffReader struct {
+	data  []byte
+	order binary.ByteOrder
+}
+
+func (r *tiffReader) readIFD(offset uint32) (map[uint16]ifdEntry, error) {
+	if uint64(offset)+2 > uint64(len(r.data)) {
+		return nil, errors.New("IFD offset out of range")
+	}
+
+	count := int(r.order.Uint16(r.data[offset:]))
+	if count > maxIFDEntries {
+		return nil, errors.New("too many IFD entries")
+	}
+
+	entries := make(map[uint16]ifdEntry, count)
+	for i := 0; i < count; i++ {
+		start := int(offset) + 2 + i*12
+		if start+12 > len(r.data) {
+			break
+		}
+		raw := r.data[start : start+12]
+		entries[r.order.Uint16(raw)] = ifdEntry{
+			typ:   r.order.Uint16(raw[2:]),
+			count: r.order.Uint32(raw[4:]),
+			value: raw[8:12],
+		}
+	}
+	return entries, nil
+}
+
+// typeSize returns the byte size of one value of a TIFF field type
+func typeSize(typ uint16) int {
+	switch typ {
+	case 1, 2, 6, 7:
+		return 1
+	case 3, 8:
+		return 2
+	case 4, 9, 11:
+		return 4
+	case 5, 10, 12:
+		return 8
+	}
+	return 0
+}
+
+// payload returns the bytes of an entry's values, which live inline when
+// they fit in four bytes and at an offset otherwise
+func (r *tiffReader) payload(e ifdEntry) ([]byte, bool) {
+	size := typeSize(e.typ)
+	if size == 0 || e.count == 0 || e.count > 1<<16 {
+		return nil, false
+	}
+	total := size * int(e.count)
+	if total <= 4 {
+		return e.value[:total], true
+	}
+	offset := int(r.order.Uint32(e.value))
+	if offset < 0 || offset+total > len(r.data) {
+		return nil, false
+	}
+	return r.data[offset : offset+total], true
+}
+
+func (r *tiffReader) uint32Value(e ifdEntry) uint32 {
+	if e.typ == 3 {
+		return uint32(r.order.Uint16(e.value))
+	}
+	return r.order.Uint32(e.value)
+}
+
+// value decodes an entry into a string, integer, float or rational string
+func (r *tiffReader) value(name string, e ifdEntry) (interface{}, bool) {
+	data, ok := r.payload(e)
+	if !ok {
+		return nil, false
+	}
+
+	switch e.typ {
+	case 2: // ASCII
+		s := strings.TrimRight(string(data), "\x00 ")
+		return s, s != ""
+	case 1, 7: // BYTE, UNDEFINED
+		if e.count == 1 {
+			return int64(data[0]), true
+		}
+		return nil, false
+	case 3: // SHORT
+		return int64(r.order.Uint16(data)), true
+	case 4: // LONG
+		return int64(r.order.Uint32(data)), true
+	case 9: // SLONG
+		return int64(int32(r.order.Uint32(data))), true
+	case 5, 10: // RATIONAL, SRATIONAL
+		num, den := r.rational(e.typ, data)
+		if den == 0 {
+			return nil, false
+		}
+		if name == "ExposureTime" && num < den {
+			return fmt.Sprintf("%d/%d", num, den), true
+		}
+		return float64(num) / float64(den), true
+	}
+	return nil, false
+}
+
+func (r *tiffReader) rational(typ uint16, data []byte) (int64, int64) {
+	if typ == 10 {
+		return int64(int32(r.order.Uint32(data))), int64(int32(r.order.Uint32(data[4:])))
+	}
+	return int64(r.order.Uint32(data)), int64(r.order.Uint32(data[4:]))
+}
+
+func (r *tiffReader) collect(entries map[uint16]ifdEntry, tags map[string]interface{}) {
+	for id, e := range entries {
+		name, ok := exifTagNames[id]
+		if !ok {
+			continue
+		}
+		if v, ok := r.value(name, e); ok {
+			tags[name] = v
+		}
+	}
+}
+
+func (r *tiffReader) collectGPS(entries map[uint16]ifdEntry, tags map[string]interface{}) {
+	if lat, ok := r.coordinate(entries[tagGPSLatitude], entries[tagGPSLatitudeRef], "S"); ok {
+		tags["GPSLatitude"] = lat
+	}
+	if lon, ok := r.coordinate(entries[tagGPSLongitude], entries[tagGPSLongitudeRef], "W"); ok {
+		tags["GPSLongitude"] = lon
+	}
+	if e, ok := entries[tagGPSAltitude]; ok {
+		if v, ok := r.value("GPSAltitude", e); ok {
+			tags["GPSAltitude"] = v
+		}
+	}
+}
+
+// coordinate converts degrees/minutes/seconds rationals to decimal degrees
+func (r *tiffReader) coordinate(e, ref ifdEntry, negative string) (float64, bool) {
+	if e.typ != 5 || e.count != 3 {
+		return 0, false
+	}
+	data, ok := r.payload(e)
+	if !ok {
+		return 0, false
+	}
+
+	var parts [3]float64
+	for i := range parts {
+		num, den := r.rational(5, data[i*8:])
+		if den == 0 {
+			return 0, false
+		}
+		parts[i] = float64(num) / float64(den)
+	}
+	value := parts[0] + parts[1]/60 + parts[2]/3600
+
+	if refData, ok := r.payload(ref); ok && ref.typ == 2 && strings.HasPrefix(string(refData), negative) {
+		value = -value
+	}
+	return value, true
+}
@@ -0,0 +1,185 @@
+package imaging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"math"
+	"testing"
+)
+
+func TestThumbnail(t *testing.T) {
+	src := image.NewRGBA(image.Rect(0, 0, 100, 50))
+	for y := 0; y < 50; y++ {
+		for x := 0; x < 100; x++ {
+			// Left half black, right half white
+			if x >= 50 {
+				src.Set(x, y, color.White)
+			} else {
+				src.Set(x, y, color.Black)
+			}
+		}
+	}
+
+	thumb := Thumbnail(src, 10)
+	if thumb.Bounds().Dx() != 10 || thumb.Bounds().Dy() != 5 {
+		t.Fatalf("Expected 10x5 thumbnail, got %v", thumb.Bounds())
+	}
+	if r, _, _, _ := thumb.At(0, 0).RGBA(); r != 0 {
+		t.Errorf("Expected black on the left, got %d", r)
+	}
+	if r, _, _, _ := thumb.At(9, 4).RGBA(); r != 0xffff {
+		t.Errorf("Expected white on the right, got %d", r)
+	}
+
+	if small := Thumbnail(src, 200); small != image.Image(src) {
+		t.Error("Expected images narrower than the target to be returned unscaled")
+	}
+}
+
+// tiffBuilder assembles a little-endian TIFF structure for tests
+type tiffBuilder struct {
+	buf bytes.Buffer
+}
+
+type testEntry struct {
+	tag, typ uint16
+	count    uint32
+	data     []byte
+}
+
+// writeIFD appends a directory at the current offset with out-of-line data
+// following it, and returns the directory's offset
+func (b *tiffBuilder) writeIFD(entries []testEntry) uint32 {
+	offset := uint32(b.buf.Len())
+	dataOffset := offset + 2 + uint32(len(entries))*12 + 4
+
+	var dir, extra bytes.Buffer
+	binary.Write(&dir, binary.LittleEndian, uint16(len(entries)))
+	for _, e := range entries {
+		binary.Write(&dir, binary.LittleEndian, e.tag)
+		binary.Write(&dir, binary.LittleEndian, e.typ)
+		binary.Write(&dir, binary.LittleEndian, e.count)
+		if len(e.data) <= 4 {
+			field := make([]byte, 4)
+			copy(field, e.data)
+			dir.Write(field)
+		} else {
+			binary.Write(&dir, binary.LittleEndian, dataOffset+uint32(extra.Len()))
+			extra.Write(e.data)
+		}
+	}
+	binary.Write(&dir, binary.LittleEndian, uint32(0)) // no next IFD
+
+	b.buf.Write(dir.Bytes())
+	b.buf.Write(extra.Bytes())
+	return offset
+}
+
+func u16(v uint16) []byte { return binary.LittleEndian.AppendUint16(nil, v) }
+func u32(v uint32) []byte { return binary.LittleEndian.AppendUint32(nil, v) }
+
+func rationals(values ...uint32) []byte {
+	var out []byte
+	for _, v := range values {
+		out = binary.LittleEndian.AppendUint32(out, v)
+	}
+	return out
+}
+
+func buildEXIFJPEG(t *testing.T) []byte {
+	t.Helper()
+
+	b := &tiffBuilder{}
+	b.buf.WriteString("II")
+	b.buf.Write(u16(42))
+	b.buf.Write(u32(8))
+
+	// IFD0 is written first so its offset is 8; sub-IFD pointers are
+	// patched once their offsets are known
+	ifd0 := []testEntry{
+		{0x010F, 2, 6, []byte("Canon\x00")},
+		{0x0112, 3, 1, u16(6)},
+		{tagExifIFD, 4, 1, u32(0)},
+		{tagGPSIFD, 4, 1, u32(0)},
+	}
+	b.writeIFD(ifd0)
+
+	exifOffset := b.writeIFD([]testEntry{
+		{0x829A, 5, 1, rationals(1, 250)},
+		{0x829D, 5, 1, rationals(28, 10)},
+	})
+	gpsOffset := b.writeIFD([]testEntry{
+		{tagGPSLatitudeRef, 2, 2, []byte("S\x00")},
+		{tagGPSLatitude, 5, 3, rationals(35, 1, 30, 1, 0, 1)},
+		{tagGPSLongitudeRef, 2, 2, []byte("E\x00")},
+		{tagGPSLongitude, 5, 3, rationals(139, 1, 45, 1, 0, 1)},
+	})
+
+	tiff := b.buf.Bytes()
+	// Entries are 12 bytes each after the 2-byte count at offset 8
+	binary.LittleEndian.PutUint32(tiff[8+2+2*12+8:], exifOffset)
+	binary.LittleEndian.PutUint32(tiff[8+2+3*12+8:], gpsOffset)
+
+	var img bytes.Buffer
+	if err := jpeg.Encode(&img, image.NewRGBA(image.Rect(0, 0, 4, 4)), nil); err != nil {
+		t.Fatal(err)
+	}
+
+	payload := append([]byte("Exif\x00\x00"), tiff...)
+	var out bytes.Buffer
+	out.Write(img.Bytes()[:2]) // SOI
+	out.Write([]byte{0xFF, 0xE1})
+	binary.Write(&out, binary.BigEndian, uint16(len(payload)+2))
+	out.Write(payload)
+	out.Write(img.Bytes()[2:])
+	return out.Bytes()
+}
+
+func TestReadEXIF(t *testing.T) {
+	data := buildEXIFJPEG(t)
+
+	// The synthesized file must still decode as a JPEG
+	if _, err := jpeg.Decode(bytes.NewReader(data)); err != nil {
+		t.Fatalf("Expected valid JPEG, got %v", err)
+	}
+
+	tags, err := ReadEXIF(data)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	expected := map[string]interface{}{
+		"Make":         "Canon",
+		"Orientation":  int64(6),
+		"ExposureTime": "1/250",
+		"FNumber":      2.8,
+	}
+	for name, want := range expected {
+		if tags[name] != want {
+			t.Errorf("Expected %s = %v, got %v", name, want, tags[name])
+		}
+	}
+
+	if lat, _ := tags["GPSLatitude"].(float64); math.Abs(lat+35.5) > 1e-9 {
+		t.Errorf("Expected latitude -35.5, got %v", tags["GPSLatitude"])
+	}
+	if lon, _ := tags["GPSLongitude"].(float64); math.Abs(lon-139.75) > 1e-9 {
+		t.Errorf("Expected longitude 139.75, got %v", tags["GPSLongitude"])
+	}
+}
+
+func TestReadEXIFWithoutMetadata(t *testing.T) {
+	var img bytes.Buffer
+	jpeg.Encode(&img, image.NewRGBA(image.Rect(0, 0, 4, 4)), nil)
+
+	if _, err := ReadEXIF(img.Bytes()); !errors.Is(err, ErrNoEXIF) {
+		t.Errorf("Expected ErrNoEXIF, got %v", err)
+	}
+	if _, err := ReadEXIF([]byte("not an image")); !errors.Is(err, ErrNoEXIF) {
+		t.Errorf("Expected ErrNoEXIF for non-JPEG data, got %v", err)
+	}
+}
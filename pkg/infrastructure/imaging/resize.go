@@ -0,0 +1,53 @@
+// Package imaging provides thumbnail scaling and EXIF parsing on top of the
+// standard library image packages
+package imaging
+
+import (
+	"image"
+	"image/draw"
+)
+
+// Thumbnail scales img down to width pixels wide, preserving the aspect
+// ratio, using an area-averaging (box) filter. Images already narrower than
+// width are returned unscaled.
+func Thumbnail(img image.Image, width int) image.Image {
+	bounds := img.Bounds()
+	sw, sh := bounds.Dx(), bounds.Dy()
+	if width <= 0 || sw <= width || sh == 0 {
+		return img
+	}
+
+	height := max((sh*width+sw/2)/sw, 1)
+
+	// Work on premultiplied RGBA so averaging handles transparency correctly
+	src := image.NewRGBA(image.Rect(0, 0, sw, sh))
+	draw.Draw(src, src.Bounds(), img, bounds.Min, draw.Src)
+
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		y0, y1 := y*sh/height, max((y+1)*sh/height, y*sh/height+1)
+		for x := 0; x < width; x++ {
+			x0, x1 := x*sw/width, max((x+1)*sw/width, x*sw/width+1)
+
+			var r, g, b, a, n uint64
+			for sy := y0; sy < y1; sy++ {
+				row := src.Pix[sy*src.Stride+x0*4 : sy*src.Stride+x1*4]
+				for i := 0; i < len(row); i += 4 {
+					r += uint64(row[i])
+					g += uint64(row[i+1])
+					b += uint64(row[i+2])
+					a += uint64(row[i+3])
+					n++
+				}
+			}
+
+			i := y*dst.Stride + x*4
+			dst.Pix[i] = uint8((r + n/2) / n)
+			dst.Pix[i+1] = uint8((g + n/2) / n)
+			dst.Pix[i+2] = uint8((b + n/2) / n)
+			dst.Pix[i+3] = uint8((a + n/2) / n)
+		}
+	}
+
+	return dst
+}
@@ -0,0 +1,593 @@
+// Package jsonschema implements the validation keywords of JSON Schema
+// (draft 7 and 2020-12) that matter for configuration files. Annotations
+// such as "format" and "description" are ignored, and $ref is limited to
+// references within the same document.
+package jsonschema
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// MaxErrors bounds the number of errors reported for one document
+const MaxErrors = 100
+
+// maxRefDepth bounds $ref expansion so self-referencing schemas terminate
+const maxRefDepth = 64
+
+// ValidationError describes one failed keyword. Path is a JSON Pointer
+// (RFC 6901) into the validated document; the document root is "".
+type ValidationError struct {
+	Path    string `json:"path"`
+	Keyword string `json:"keyword"`
+	Message string `json:"message"`
+}
+
+// Schema is a compiled JSON Schema
+type Schema struct {
+	root     interface{}
+	patterns map[string]*regexp.Regexp
+}
+
+// Compile parses a JSON Schema document, compiling its patterns and checking
+// that every $ref resolves
+func Compile(data []byte) (*Schema, error) {
+	root, err := Decode(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid schema JSON: %w", err)
+	}
+
+	s := &Schema{root: root, patterns: make(map[string]*regexp.Regexp)}
+	if err := s.prepare(root, "", map[string]bool{}); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Decode parses a JSON document keeping numbers as json.Number, which is the
+// representation Validate expects
+func Decode(data []byte) (interface{}, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+	if decoder.More() {
+		return nil, errors.New("unexpected data after top-level value")
+	}
+	return value, nil
+}
+
+// Keywords whose values are subschemas, by shape
+var (
+	schemaKeywords     = []string{"not", "if", "then", "else", "items", "additionalItems", "additionalProperties", "contains", "propertyNames"}
+	schemaListKeywords = []string{"allOf", "anyOf", "oneOf", "prefixItems", "items"}
+	schemaMapKeywords  = []string{"properties", "patternProperties", "$defs", "definitions"}
+)
+
+// prepare walks the schema compiling patterns and resolving references.
+// Reference targets are prepared too, once each, since they may live outside
+// the keywords prepare descends into.
+func (s *Schema) prepare(node interface{}, location string, seen map[string]bool) error {
+	n, ok := node.(map[string]interface{})
+	if !ok {
+		return nil // boolean schemas need no preparation
+	}
+
+	if v, ok := n["pattern"]; ok {
+		if err := s.compilePattern(v, location+"/pattern"); err != nil {
+			return err
+		}
+	}
+	if props, ok := n["patternProperties"].(map[string]interface{}); ok {
+		for pattern := range props {
+			if err := s.compilePattern(pattern, location+"/patternProperties"); err != nil {
+				return err
+			}
+		}
+	}
+	if v, ok := n["$ref"]; ok {
+		ref, isString := v.(string)
+		if !isString {
+			return fmt.Errorf("%s/$ref: must be a string", location)
+		}
+		target, err := s.resolve(ref)
+		if err != nil {
+			return fmt.Errorf("%s/$ref: %w", location, err)
+		}
+		if !seen[ref] {
+			seen[ref] = true
+			if err := s.prepare(target, ref[1:], seen); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, keyword := range schemaKeywords {
+		if sub, ok := n[keyword].(map[string]interface{}); ok {
+			if err := s.prepare(sub, location+"/"+keyword, seen); err != nil {
+				return err
+			}
+		}
+	}
+	for _, keyword := range schemaListKeywords {
+		list, _ := n[keyword].([]interface{})
+		for i, sub := range list {
+			if err := s.prepare(sub, location+"/"+keyword+"/"+strconv.Itoa(i), seen); err != nil {
+				return err
+			}
+		}
+	}
+	for _, keyword := range schemaMapKeywords {
+		subs, _ := n[keyword].(map[string]interface{})
+		for _, name := range sortedKeys(subs) {
+			if err := s.prepare(subs[name], location+"/"+keyword+"/"+escapePointer(name), seen); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (s *Schema) compilePattern(v interface{}, location string) error {
+	pattern, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("%s: must be a string", location)
+	}
+	if _, done := s.patterns[pattern]; done {
+		return nil
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return fmt.Errorf("%s: %v", location, err)
+	}
+	s.patterns[pattern] = re
+	return nil
+}
+
+// resolve looks up a same-document reference such as "#/$defs/port"
+func (s *Schema) resolve(ref string) (interface{}, error) {
+	if ref == "#" {
+		return s.root, nil
+	}
+	if !strings.HasPrefix(ref, "#/") {
+		return nil, fmt.Errorf("unsupported reference %q: only same-document references are supported", ref)
+	}
+
+	node := s.root
+	for _, token := range strings.Split(ref[2:], "/") {
+		token = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+		switch n := node.(type) {
+		case map[string]interface{}:
+			child, ok := n[token]
+			if !ok {
+				return nil, fmt.Errorf("unresolved reference %q", ref)
+			}
+			node = child
+		case []interface{}:
+			i, err := strconv.Atoi(token)
+			if err != nil || i < 0 || i >= len(n) {
+				return nil, fmt.Errorf("unresolved reference %q", ref)
+			}
+			node = n[i]
+		default:
+			return nil, fmt.Errorf("unresolved reference %q", ref)
+		}
+	}
+	return node, nil
+}
+
+// Validate checks a document decoded with Decode against the schema. It
+// returns nil when the document is valid and at most MaxErrors errors otherwise.
+func (s *Schema) Validate(document interface{}) []ValidationError {
+	v := &validator{schema: s}
+	v.validate(s.root, document, "", 0)
+	return v.errors
+}
+
+type validator struct {
+	schema *Schema
+	errors []ValidationError
+}
+
+func (v *validator) fail(path, keyword, format string, args ...interface{}) {
+	if len(v.errors) < MaxErrors {
+		v.errors = append(v.errors, ValidationError{Path: path, Keyword: keyword, Message: fmt.Sprintf(format, args...)})
+	}
+}
+
+// valid reports whether instance matches schema without recording errors
+func (v *validator) valid(schema, instance interface{}, path string, depth int) bool {
+	sub := &validator{schema: v.schema}
+	sub.validate(schema, instance, path, depth)
+	return len(sub.errors) == 0
+}
+
+func (v *validator) validate(schema, instance interface{}, path string, depth int) {
+	if len(v.errors) >= MaxErrors {
+		return
+	}
+
+	s, ok := schema.(map[string]interface{})
+	if !ok {
+		if b, isBool := schema.(bool); isBool && !b {
+			v.fail(path, "false", "no value is allowed here")
+		}
+		return
+	}
+
+	if ref, ok := s["$ref"].(string); ok {
+		if depth >= maxRefDepth {
+			v.fail(path, "$ref", "reference nesting exceeds %d levels", maxRefDepth)
+			return
+		}
+		target, _ := v.schema.resolve(ref) // checked by Compile
+		v.validate(target, instance, path, depth+1)
+	}
+
+	v.validateGeneric(s, instance, path)
+	v.validateCombinators(s, instance, path, depth)
+
+	switch value := instance.(type) {
+	case json.Number:
+		v.validateNumber(s, value, path)
+	case string:
+		v.validateString(s, value, path)
+	case []interface{}:
+		v.validateArray(s, value, path, depth)
+	case map[string]interface{}:
+		v.validateObject(s, value, path, depth)
+	}
+}
+
+func (v *validator) validateGeneric(s map[string]interface{}, instance interface{}, path string) {
+	if t, ok := s["type"]; ok {
+		var allowed []string
+		switch tv := t.(type) {
+		case string:
+			allowed = []string{tv}
+		case []interface{}:
+			for _, name := range tv {
+				if str, ok := name.(string); ok {
+					allowed = append(allowed, str)
+				}
+			}
+		}
+		actual := typeOf(instance)
+		matched := false
+		for _, name := range allowed {
+			if name == actual || (name == "number" && actual == "integer") {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			v.fail(path, "type", "expected %s, got %s", strings.Join(allowed, " or "), actual)
+		}
+	}
+
+	if enum, ok := s["enum"].([]interface{}); ok {
+		found := false
+		for _, candidate := range enum {
+			if equal(candidate, instance) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			v.fail(path, "enum", "value must be one of %s", compactJSON(enum))
+		}
+	}
+
+	if c, ok := s["const"]; ok && !equal(c, instance) {
+		v.fail(path, "const", "value must be %s", compactJSON(c))
+	}
+}
+
+func (v *validator) validateCombinators(s map[string]interface{}, instance interface{}, path string, depth int) {
+	if all, ok := s["allOf"].([]interface{}); ok {
+		for _, sub := range all {
+			v.validate(sub, instance, path, depth)
+		}
+	}
+
+	if any, ok := s["anyOf"].([]interface{}); ok {
+		matched := false
+		for _, sub := range any {
+			if v.valid(sub, instance, path, depth) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			v.fail(path, "anyOf", "value does not match any of the %d allowed schemas", len(any))
+		}
+	}
+
+	if one, ok := s["oneOf"].([]interface{}); ok {
+		matches := 0
+		for _, sub := range one {
+			if v.valid(sub, instance, path, depth) {
+				matches++
+			}
+		}
+		if matches != 1 {
+			v.fail(path, "oneOf", "value must match exactly one schema, matched %d", matches)
+		}
+	}
+
+	if not, ok := s["not"]; ok && v.valid(not, instance, path, depth) {
+		v.fail(path, "not", "value must not match the schema")
+	}
+
+	if cond, ok := s["if"]; ok {
+		if v.valid(cond, instance, path, depth) {
+			if then, ok := s["then"]; ok {
+				v.validate(then, instance, path, depth)
+			}
+		} else if els, ok := s["else"]; ok {
+			v.validate(els, instance, path, depth)
+		}
+	}
+}
+
+func (v *validator) validateNumber(s map[string]interface{}, n json.Number, path string) {
+	value, err := n.Float64()
+	if err != nil {
+		return
+	}
+
+	if limit, ok := number(s["minimum"]); ok && value < limit {
+		v.fail(path, "minimum", "must be >= %v", limit)
+	}
+	if limit, ok := number(s["maximum"]); ok && value > limit {
+		v.fail(path, "maximum", "must be <= %v", limit)
+	}
+	if limit, ok := number(s["exclusiveMinimum"]); ok && value <= limit {
+		v.fail(path, "exclusiveMinimum", "must be > %v", limit)
+	}
+	if limit, ok := number(s["exclusiveMaximum"]); ok && value >= limit {
+		v.fail(path, "exclusiveMaximum", "must be < %v", limit)
+	}
+	if divisor, ok := number(s["multipleOf"]); ok && divisor > 0 {
+		if q := value / divisor; math.Abs(q-math.Round(q)) > 1e-9 {
+			v.fail(path, "multipleOf", "must be a multiple of %v", divisor)
+		}
+	}
+}
+
+func (v *validator) validateString(s map[string]interface{}, str, path string) {
+	length := utf8.RuneCountInString(str)
+	if limit, ok := number(s["minLength"]); ok && float64(length) < limit {
+		v.fail(path, "minLength", "must be at least %v characters", limit)
+	}
+	if limit, ok := number(s["maxLength"]); ok && float64(length) > limit {
+		v.fail(path, "maxLength", "must be at most %v characters", limit)
+	}
+	if pattern, ok := s["pattern"].(string); ok && !v.schema.patterns[pattern].MatchString(str) {
+		v.fail(path, "pattern", "must match pattern %q", pattern)
+	}
+}
+
+func (v *validator) validateArray(s map[string]interface{}, items []interface{}, path string, depth int) {
+	if limit, ok := number(s["minItems"]); ok && float64(len(items)) < limit {
+		v.fail(path, "minItems", "must have at least %v items", limit)
+	}
+	if limit, ok := number(s["maxItems"]); ok && float64(len(items)) > limit {
+		v.fail(path, "maxItems", "must have at most %v items", limit)
+	}
+
+	if unique, _ := s["uniqueItems"].(bool); unique {
+	outer:
+		for i := range items {
+			for j := 0; j < i; j++ {
+				if equal(items[i], items[j]) {
+					v.fail(path, "uniqueItems", "items %d and %d are equal", j, i)
+					break outer
+				}
+			}
+		}
+	}
+
+	// Tuple validation: "prefixItems" (2020-12) or an "items" array (draft 7)
+	prefix, _ := s["prefixItems"].([]interface{})
+	rest, hasRest := s["items"]
+	if tuple, ok := rest.([]interface{}); ok {
+		prefix = tuple
+		rest, hasRest = s["additionalItems"]
+	}
+	for i, item := range items {
+		itemPath := path + "/" + strconv.Itoa(i)
+		if i < len(prefix) {
+			v.validate(prefix[i], item, itemPath, depth)
+		} else if hasRest {
+			v.validate(rest, item, itemPath, depth)
+		}
+	}
+
+	if contains, ok := s["contains"]; ok {
+		found := false
+		for i, item := range items {
+			if v.valid(contains, item, path+"/"+strconv.Itoa(i), depth) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			v.fail(path, "contains", "must contain at least one matching item")
+		}
+	}
+}
+
+func (v *validator) validateObject(s map[string]interface{}, obj map[string]interface{}, path string, depth int) {
+	if limit, ok := number(s["minProperties"]); ok && float64(len(obj)) < limit {
+		v.fail(path, "minProperties", "must have at least %v properties", limit)
+	}
+	if limit, ok := number(s["maxProperties"]); ok && float64(len(obj)) > limit {
+		v.fail(path, "maxProperties", "must have at most %v properties", limit)
+	}
+
+	if required, ok := s["required"].([]interface{}); ok {
+		for _, name := range required {
+			if key, ok := name.(string); ok {
+				if _, present := obj[key]; !present {
+					v.fail(path, "required", "missing required property %q", key)
+				}
+			}
+		}
+	}
+
+	if deps, ok := s["dependentRequired"].(map[string]interface{}); ok {
+		for _, key := range sortedKeys(deps) {
+			if _, present := obj[key]; !present {
+				continue
+			}
+			names, _ := deps[key].([]interface{})
+			for _, name := range names {
+				if dep, ok := name.(string); ok {
+					if _, present := obj[dep]; !present {
+						v.fail(path, "dependentRequired", "property %q requires property %q", key, dep)
+					}
+				}
+			}
+		}
+	}
+
+	properties, _ := s["properties"].(map[string]interface{})
+	patternProperties, _ := s["patternProperties"].(map[string]interface{})
+	additional, hasAdditional := s["additionalProperties"]
+	propertyNames, hasPropertyNames := s["propertyNames"]
+
+	for _, key := range sortedKeys(obj) {
+		value := obj[key]
+		propPath := path + "/" + escapePointer(key)
+
+		if hasPropertyNames && !v.valid(propertyNames, key, propPath, depth) {
+			v.fail(propPath, "propertyNames", "property name %q is not allowed", key)
+		}
+
+		matched := false
+		if sub, ok := properties[key]; ok {
+			matched = true
+			v.validate(sub, value, propPath, depth)
+		}
+		for _, pattern := range sortedKeys(patternProperties) {
+			if v.schema.patterns[pattern].MatchString(key) {
+				matched = true
+				v.validate(patternProperties[pattern], value, propPath, depth)
+			}
+		}
+
+		if !matched && hasAdditional {
+			if allowed, ok := additional.(bool); ok {
+				if !allowed {
+					v.fail(propPath, "additionalProperties", "property %q is not allowed", key)
+				}
+			} else {
+				v.validate(additional, value, propPath, depth)
+			}
+		}
+	}
+}
+
+// typeOf returns the JSON Schema type name of a decoded value
+func typeOf(value interface{}) string {
+	switch n := value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case json.Number:
+		if f, err := n.Float64(); err == nil && f == math.Trunc(f) && !math.IsInf(f, 0) {
+			return "integer"
+		}
+		return "number"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+// number converts a schema keyword value to float64
+func number(value interface{}) (float64, bool) {
+	n, ok := value.(json.Number)
+	if !ok {
+		return 0, false
+	}
+	f, err := n.Float64()
+	return f, err == nil
+}
+
+// equal compares decoded JSON values, treating 1 and 1.0 as equal
+func equal(a, b interface{}) bool {
+	switch av := a.(type) {
+	case json.Number:
+		bv, ok := b.(json.Number)
+		if !ok {
+			return false
+		}
+		af, aerr := av.Float64()
+		bf, berr := bv.Float64()
+		return aerr == nil && berr == nil && af == bf
+	case []interface{}:
+		bv, ok := b.([]interface{})
+		if !ok || len(av) != len(bv) {
+			return false
+		}
+		for i := range av {
+			if !equal(av[i], bv[i]) {
+				return false
+			}
+		}
+		return true
+	case map[string]interface{}:
+		bv, ok := b.(map[string]interface{})
+		if !ok || len(av) != len(bv) {
+			return false
+		}
+		for key, value := range av {
+			other, ok := bv[key]
+			if !ok || !equal(value, other) {
+				return false
+			}
+		}
+		return true
+	}
+	return a == b
+}
+
+// escapePointer escapes a property name for use as a JSON Pointer token
+func escapePointer(token string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(token, "~", "~0"), "/", "~1")
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func compactJSON(value interface{}) string {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(data)
+}
@@ -0,0 +1,130 @@
+package jsonschema
+
+import (
+	"strings"
+	"testing"
+)
+
+const configSchema = `{
+  "type": "object",
+  "required": ["name", "port"],
+  "additionalProperties": false,
+  "properties": {
+    "name": {"type": "string", "minLength": 1, "pattern": "^[a-z-]+$"},
+    "port": {"$ref": "#/$defs/port"},
+    "mode": {"enum": ["dev", "prod"]},
+    "tags": {"type": "array", "items": {"type": "string"}, "uniqueItems": true, "maxItems": 3},
+    "limits": {
+      "type": "object",
+      "patternProperties": {"^max_": {"type": "integer", "minimum": 0}},
+      "additionalProperties": false
+    },
+    "pattern": {"type": "boolean"}
+  },
+  "$defs": {
+    "port": {"type": "integer", "minimum": 1, "maximum": 65535}
+  }
+}`
+
+func TestValidate(t *testing.T) {
+	schema, err := Compile([]byte(configSchema))
+	if err != nil {
+		t.Fatalf("Expected schema to compile, got %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		document string
+		expected []string // "path keyword" pairs
+	}{
+		{"valid", `{"name": "api", "port": 8080, "mode": "prod", "tags": ["a", "b"], "limits": {"max_conns": 10}, "pattern": true}`, nil},
+		{"integer written as float", `{"name": "api", "port": 8080.0}`, nil},
+		{"missing required", `{"name": "api"}`, []string{" required"}},
+		{"wrong types", `{"name": 5, "port": "80"}`, []string{"/name type", "/port type"}},
+		{"ref bounds", `{"name": "api", "port": 70000}`, []string{"/port maximum"}},
+		{"enum", `{"name": "api", "port": 80, "mode": "test"}`, []string{"/mode enum"}},
+		{"pattern", `{"name": "API", "port": 80}`, []string{"/name pattern"}},
+		{"array items", `{"name": "api", "port": 80, "tags": ["a", 1, "a", "b"]}`, []string{"/tags maxItems", "/tags uniqueItems", "/tags/1 type"}},
+		{"pattern properties", `{"name": "api", "port": 80, "limits": {"max_x": -1, "min_x": 1}}`, []string{"/limits/max_x minimum", "/limits/min_x additionalProperties"}},
+		{"additional properties", `{"name": "api", "port": 80, "extra/key": 1}`, []string{"/extra~1key additionalProperties"}},
+		{"not an object", `[]`, []string{" type"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			document, err := Decode([]byte(tt.document))
+			if err != nil {
+				t.Fatalf("Expected document to decode, got %v", err)
+			}
+
+			var got []string
+			for _, e := range schema.Validate(document) {
+				got = append(got, e.Path+" "+e.Keyword)
+			}
+			if strings.Join(got, ",") != strings.Join(tt.expected, ",") {
+				t.Errorf("Expected errors %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestValidateCombinators(t *testing.T) {
+	tests := []struct {
+		name     string
+		schema   string
+		document string
+		valid    bool
+	}{
+		{"anyOf match", `{"anyOf": [{"type": "string"}, {"type": "integer"}]}`, `3`, true},
+		{"anyOf no match", `{"anyOf": [{"type": "string"}, {"type": "integer"}]}`, `true`, false},
+		{"oneOf both match", `{"oneOf": [{"type": "number"}, {"type": "integer"}]}`, `3`, false},
+		{"oneOf one match", `{"oneOf": [{"type": "number"}, {"type": "integer"}]}`, `3.5`, true},
+		{"not", `{"not": {"type": "null"}}`, `null`, false},
+		{"if then", `{"if": {"properties": {"tls": {"const": true}}}, "then": {"required": ["cert"]}}`, `{"tls": true}`, false},
+		{"if else", `{"if": {"properties": {"tls": {"const": true}}}, "then": {"required": ["cert"]}, "else": false}`, `{"tls": false}`, false},
+		{"false schema", `false`, `{}`, false},
+		{"recursive ref", `{"type": "object", "properties": {"child": {"$ref": "#"}}}`, `{"child": {"child": {}}}`, true},
+		{"recursive ref error", `{"type": "object", "properties": {"child": {"$ref": "#"}}}`, `{"child": {"child": 1}}`, false},
+		{"dependentRequired", `{"dependentRequired": {"cert": ["key"]}}`, `{"cert": "x"}`, false},
+		{"multipleOf", `{"multipleOf": 0.1}`, `0.3`, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schema, err := Compile([]byte(tt.schema))
+			if err != nil {
+				t.Fatalf("Expected schema to compile, got %v", err)
+			}
+			document, _ := Decode([]byte(tt.document))
+			errs := schema.Validate(document)
+			if (len(errs) == 0) != tt.valid {
+				t.Errorf("Expected valid=%v, got errors %v", tt.valid, errs)
+			}
+		})
+	}
+}
+
+func TestCompileErrors(t *testing.T) {
+	for _, schema := range []string{
+		`{"type": `,
+		`{"pattern": "("}`,
+		`{"properties": {"a": {"$ref": "#/$defs/missing"}}}`,
+		`{"$ref": "https://example.com/schema.json"}`,
+		`{} {}`,
+	} {
+		if _, err := Compile([]byte(schema)); err == nil {
+			t.Errorf("Expected compile error for %s", schema)
+		}
+	}
+}
+
+func TestSelfReferenceTerminates(t *testing.T) {
+	schema, err := Compile([]byte(`{"$defs": {"a": {"$ref": "#/$defs/a"}}, "$ref": "#/$defs/a"}`))
+	if err != nil {
+		t.Fatalf("Expected schema to compile, got %v", err)
+	}
+	errs := schema.Validate(map[string]interface{}{})
+	if len(errs) != 1 || errs[0].Keyword != "$ref" {
+		t.Errorf("Expected a single $ref error, got %v", errs)
+	}
+}
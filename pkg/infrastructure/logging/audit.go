@@ -0,0 +1,179 @@
+package logging
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// auditTailSize bounds how much of an existing audit file is read to resume
+// its sequence
+const auditTailSize = 64 * 1024
+
+// AuditRecord is one line of the audit log. Prev is the SHA-256 of the
+// previous line, so removing, reordering or editing records breaks the
+// chain, and Seq increases by one per record.
+type AuditRecord struct {
+	Seq   uint64                 `json:"seq"`
+	Time  time.Time              `json:"time"`
+	Event string                 `json:"event"`
+	Attrs map[string]interface{} `json:"attrs,omitempty"`
+	Prev  string                 `json:"prev"`
+}
+
+// AuditLog is an append-only sink for security-relevant events, kept
+// separate from the application log
+type AuditLog struct {
+	mu     sync.Mutex
+	w      io.Writer
+	closer io.Closer
+	seq    uint64
+	prev   string
+	now    func() time.Time
+}
+
+// newAuditLog creates an audit log continuing after the record with
+// sequence number seq and hash prev
+func newAuditLog(w io.Writer, closer io.Closer, seq uint64, prev string) *AuditLog {
+	return &AuditLog{w: w, closer: closer, seq: seq, prev: prev, now: time.Now}
+}
+
+// NewAuditLog creates an audit log writing a new chain to w
+func NewAuditLog(w io.Writer) *AuditLog {
+	return newAuditLog(w, nil, 0, "")
+}
+
+// OpenAuditFile opens path for appending, creating it with mode 0600, and
+// continues the chain of records already in it
+func OpenAuditFile(path string) (*AuditLog, error) {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o600)
+	if err != nil {
+		return nil, err
+	}
+
+	seq, prev, err := lastAuditRecord(path)
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("audit log %s: %w", path, err)
+	}
+	return newAuditLog(file, file, seq, prev), nil
+}
+
+// lastAuditRecord returns the sequence number and hash of the last record
+// in the file at path
+func lastAuditRecord(path string) (uint64, string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, "", err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return 0, "", err
+	}
+	offset := max(info.Size()-auditTailSize, 0)
+	tail := make([]byte, info.Size()-offset)
+	if _, err := file.ReadAt(tail, offset); err != nil && err != io.EOF {
+		return 0, "", err
+	}
+
+	tail = bytes.TrimRight(tail, "\n")
+	if len(tail) == 0 {
+		return 0, "", nil
+	}
+	line := tail[bytes.LastIndexByte(tail, '\n')+1:]
+	var record AuditRecord
+	if err := json.Unmarshal(line, &record); err != nil {
+		return 0, "", fmt.Errorf("unreadable last record: %w", err)
+	}
+	return record.Seq, auditHash(line), nil
+}
+
+// auditHash returns the chain hash of an encoded record
+func auditHash(line []byte) string {
+	sum := sha256.Sum256(line)
+	return hex.EncodeToString(sum[:])
+}
+
+// Record appends an event with slog-style key-value attributes. Write
+// errors are returned so callers can report them on the application log.
+func (a *AuditLog) Record(event string, args ...interface{}) error {
+	attrs := make(map[string]interface{}, len(args)/2)
+	for i := 0; i+1 < len(args); i += 2 {
+		key, ok := args[i].(string)
+		if !ok {
+			continue
+		}
+		value := args[i+1]
+		if err, ok := value.(error); ok {
+			value = err.Error()
+		}
+		attrs[key] = value
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	record := AuditRecord{
+		Seq:   a.seq + 1,
+		Time:  a.now().UTC(),
+		Event: event,
+		Attrs: attrs,
+		Prev:  a.prev,
+	}
+	line, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	if _, err := a.w.Write(append(line, '\n')); err != nil {
+		return err
+	}
+	a.seq = record.Seq
+	a.prev = auditHash(line)
+	return nil
+}
+
+// Close closes the underlying file or connection
+func (a *AuditLog) Close() error {
+	if a.closer == nil {
+		return nil
+	}
+	return a.closer.Close()
+}
+
+// ErrAuditChainBroken is returned by VerifyAuditLog for a tampered log
+var ErrAuditChainBroken = errors.New("audit log chain broken")
+
+// VerifyAuditLog checks that the records read from r form an unbroken
+// chain, returning the number of records
+func VerifyAuditLog(r io.Reader) (int, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), auditTailSize)
+
+	count := 0
+	var seq uint64
+	var prev string
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		var record AuditRecord
+		if err := json.Unmarshal(line, &record); err != nil {
+			return count, fmt.Errorf("%w: line %d: %v", ErrAuditChainBroken, count+1, err)
+		}
+		if count > 0 && (record.Seq != seq+1 || record.Prev != prev) {
+			return count, fmt.Errorf("%w: record %d does not follow record %d", ErrAuditChainBroken, record.Seq, seq)
+		}
+		seq = record.Seq
+		prev = auditHash(line)
+		count++
+	}
+	return count, scanner.Err()
+}
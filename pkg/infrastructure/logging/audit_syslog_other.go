@@ -0,0 +1,10 @@
+//go:build windows || plan9
+
+package logging
+
+import "errors"
+
+// OpenAuditSyslog is not supported on this platform
+func OpenAuditSyslog(facility, tag string) (*AuditLog, error) {
+	return nil, errors.New("syslog is not supported on this platform")
+}
@@ -0,0 +1,89 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestAuditLogFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+
+	audit, err := OpenAuditFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger := NewLoggerWithWriter(LevelError, "json", io.Discard)
+	logger.SetAuditLog(audit)
+	logger.ForRequest("req-1", "192.0.2.1", "/cat/").LogSecurityEvent("path_traversal", "/cat/..", "192.0.2.1", "curl", true)
+	logger.LogAuditEvent("admin_action", "method", "POST", "path", "/admin/reload")
+	audit.Close()
+
+	// Reopening continues the sequence and the hash chain
+	audit, err = OpenAuditFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	audit.Record("login", "user", "alice")
+	audit.Close()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n, err := VerifyAuditLog(bytes.NewReader(data)); err != nil || n != 3 {
+		t.Fatalf("VerifyAuditLog = %d, %v, expected 3 records", n, err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	var first, last AuditRecord
+	if err := json.Unmarshal([]byte(lines[0]), &first); err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal([]byte(lines[2]), &last); err != nil {
+		t.Fatal(err)
+	}
+	if first.Seq != 1 || first.Event != "path_traversal" || first.Attrs["request_id"] != "req-1" || first.Attrs["blocked"] != true {
+		t.Errorf("Unexpected first record %+v", first)
+	}
+	if last.Seq != 3 || last.Event != "login" {
+		t.Errorf("Unexpected last record %+v", last)
+	}
+
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0o600 {
+		t.Errorf("Expected audit log mode 0600, got %v (%v)", info.Mode().Perm(), err)
+	}
+}
+
+func TestVerifyAuditLogTampering(t *testing.T) {
+	var buf bytes.Buffer
+	audit := NewAuditLog(&buf)
+	for _, event := range []string{"auth_failed", "auth_failed", "admin_action"} {
+		if err := audit.Record(event, "remote_addr", "192.0.2.1"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	lines := strings.SplitAfter(buf.String(), "\n")[:3]
+
+	tests := []struct {
+		name     string
+		log      string
+		expected error
+	}{
+		{"intact", strings.Join(lines, ""), nil},
+		{"removed", lines[0] + lines[2], ErrAuditChainBroken},
+		{"reordered", lines[1] + lines[0] + lines[2], ErrAuditChainBroken},
+		{"edited", lines[0] + strings.Replace(lines[1], "192.0.2.1", "192.0.2.2", 1) + lines[2], ErrAuditChainBroken},
+		{"truncated head", lines[1] + lines[2], nil},
+	}
+	for _, tt := range tests {
+		if _, err := VerifyAuditLog(strings.NewReader(tt.log)); !errors.Is(err, tt.expected) {
+			t.Errorf("%s: VerifyAuditLog returned %v, expected %v", tt.name, err, tt.expected)
+		}
+	}
+}
@@ -0,0 +1,13 @@
+//go:build !linux
+
+package logging
+
+import (
+	"errors"
+	"io"
+)
+
+// OpenJournaldSink is only supported on Linux
+func OpenJournaldSink(tag string) (Sink, io.Closer, error) {
+	return nil, nil, errors.New("journald is only supported on Linux")
+}
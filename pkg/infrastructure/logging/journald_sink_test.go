@@ -0,0 +1,35 @@
+//go:build linux
+
+package logging
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestJournalFieldName(t *testing.T) {
+	tests := map[string]string{
+		"request_id":       "REQUEST_ID",
+		"file.owner.name":  "FILE_OWNER_NAME",
+		"_hidden":          "HIDDEN",
+		"2xx-count":        "XX_COUNT",
+		"___":              "",
+		"http.status_code": "HTTP_STATUS_CODE",
+	}
+	for key, expected := range tests {
+		if got := journalFieldName(key); got != expected {
+			t.Errorf("journalFieldName(%q) = %q, expected %q", key, got, expected)
+		}
+	}
+}
+
+func TestWriteJournalField(t *testing.T) {
+	var entry bytes.Buffer
+	writeJournalField(&entry, "MESSAGE", "listed")
+	writeJournalField(&entry, "ERROR", "line 1\nline 2")
+
+	expected := "MESSAGE=listed\nERROR\n\x0d\x00\x00\x00\x00\x00\x00\x00line 1\nline 2\n"
+	if entry.String() != expected {
+		t.Errorf("Expected entry %q, got %q", expected, entry.String())
+	}
+}
@@ -2,6 +2,7 @@ package logging
 
 import (
 	"context"
+	"io"
 	"log/slog"
 	"os"
 	"time"
@@ -9,9 +10,23 @@ import (
 
 // Logger wraps slog.Logger to provide domain-specific logging functionality
 type Logger struct {
-	logger *slog.Logger
+	logger         *slog.Logger
+	level          *slog.LevelVar
+	securityHook   SecurityEventHook
+	securityEvents *SecurityEventBuffer
+	fsErrorHook    FileSystemErrorHook
+	audit          *AuditLog
+	auditArgs      []interface{}
+	requestID      string // set by ForRequest
 }
 
+// SecurityEventHook is called for every event passed to LogSecurityEvent
+type SecurityEventHook func(event, remoteAddr string, blocked bool)
+
+// FileSystemErrorHook is called for every failed operation passed to
+// LogFileSystemOperation
+type FileSystemErrorHook func(operation string)
+
 // LogLevel represents logging levels
 type LogLevel int
 
@@ -22,39 +37,79 @@ const (
 	LevelError
 )
 
-// NewLogger creates a new logger with the specified configuration
-func NewLogger(level LogLevel, format string) *Logger {
-	var slogLevel slog.Level
-	switch level {
-	case LevelDebug:
-		slogLevel = slog.LevelDebug
-	case LevelInfo:
-		slogLevel = slog.LevelInfo
-	case LevelWarn:
-		slogLevel = slog.LevelWarn
-	case LevelError:
-		slogLevel = slog.LevelError
-	default:
-		slogLevel = slog.LevelInfo
+// Sink is a log output. It returns the handler writing records to the
+// output, given the logger's format and handler options, which carry the
+// logger's level.
+type Sink func(format string, opts *slog.HandlerOptions) slog.Handler
+
+// WriterSink writes records to w, one line each in the logger's format
+func WriterSink(w io.Writer) Sink {
+	return func(format string, opts *slog.HandlerOptions) slog.Handler {
+		return newFormatHandler(format, w, opts)
 	}
+}
+
+// NewLogger creates a logger writing every record to each of sinks, or to
+// stdout when there are none
+func NewLogger(level LogLevel, format string, sinks ...Sink) *Logger {
+	if len(sinks) == 0 {
+		sinks = []Sink{WriterSink(os.Stdout)}
+	}
+
+	levelVar := new(slog.LevelVar)
+	levelVar.Set(slogLevel(level))
 
 	opts := &slog.HandlerOptions{
-		Level: slogLevel,
+		Level: levelVar,
 	}
 
-	var handler slog.Handler
-	switch format {
-	case "json":
-		handler = slog.NewJSONHandler(os.Stdout, opts)
-	case "text":
-		handler = slog.NewTextHandler(os.Stdout, opts)
-	default:
-		handler = slog.NewJSONHandler(os.Stdout, opts)
+	handlers := make(multiHandler, len(sinks))
+	for i, sink := range sinks {
+		handlers[i] = sink(format, opts)
+	}
+	var handler slog.Handler = handlers
+	if len(handlers) == 1 {
+		handler = handlers[0]
 	}
 
 	return &Logger{
 		logger: slog.New(handler),
+		level:  levelVar,
+	}
+}
+
+// NewLoggerWithWriter creates a new logger that writes to w
+func NewLoggerWithWriter(level LogLevel, format string, w io.Writer) *Logger {
+	return NewLogger(level, format, WriterSink(w))
+}
+
+// newFormatHandler returns a JSON or text handler writing to w; unknown
+// formats use JSON
+func newFormatHandler(format string, w io.Writer, opts *slog.HandlerOptions) slog.Handler {
+	if format == "text" {
+		return slog.NewTextHandler(w, opts)
 	}
+	return slog.NewJSONHandler(w, opts)
+}
+
+// slogLevel converts a LogLevel to the corresponding slog.Level
+func slogLevel(level LogLevel) slog.Level {
+	switch level {
+	case LevelDebug:
+		return slog.LevelDebug
+	case LevelWarn:
+		return slog.LevelWarn
+	case LevelError:
+		return slog.LevelError
+	default:
+		return slog.LevelInfo
+	}
+}
+
+// SetLevel changes the level of this logger and every logger sharing its
+// output, including those derived with With or ForRequest
+func (l *Logger) SetLevel(level LogLevel) {
+	l.level.Set(slogLevel(level))
 }
 
 // NewDefaultLogger creates a logger with default settings (INFO level, JSON format)
@@ -85,32 +140,24 @@ func (l *Logger) Error(msg string, args ...interface{}) {
 // With returns a new logger with the provided key-value pairs added to the context
 func (l *Logger) With(args ...interface{}) *Logger {
 	return &Logger{
-		logger: l.logger.With(args...),
+		logger:         l.logger.With(args...),
+		level:          l.level,
+		securityHook:   l.securityHook,
+		securityEvents: l.securityEvents,
+		fsErrorHook:    l.fsErrorHook,
+		audit:          l.audit,
+		auditArgs:      append(l.auditArgs[:len(l.auditArgs):len(l.auditArgs)], args...),
+		requestID:      l.requestID,
 	}
 }
 
 // WithContext returns a new logger that includes context information
 func (l *Logger) WithContext(ctx context.Context) *Logger {
-	// Extract common context values
-	var contextArgs []interface{}
-
 	// Add request ID if available
-	if requestID := ctx.Value("request_id"); requestID != nil {
-		contextArgs = append(contextArgs, "request_id", requestID)
-	}
-
-	// Add user ID if available
-	if userID := ctx.Value("user_id"); userID != nil {
-		contextArgs = append(contextArgs, "user_id", userID)
-	}
-
-	// Add correlation ID if available
-	if correlationID := ctx.Value("correlation_id"); correlationID != nil {
-		contextArgs = append(contextArgs, "correlation_id", correlationID)
-	}
-
-	if len(contextArgs) > 0 {
-		return l.With(contextArgs...)
+	if requestID, ok := RequestIDFromContext(ctx); ok {
+		logger := l.With("request_id", requestID)
+		logger.requestID = requestID
+		return logger
 	}
 
 	return l
@@ -141,6 +188,10 @@ func (l *Logger) LogHTTPResponse(method, path string, statusCode int, duration t
 
 // LogFileSystemOperation logs filesystem operation information
 func (l *Logger) LogFileSystemOperation(operation, path string, success bool, duration time.Duration, size int64) {
+	if !success && l.fsErrorHook != nil {
+		l.fsErrorHook(operation)
+	}
+
 	level := "info"
 	if !success {
 		level = "error"
@@ -175,8 +226,42 @@ func (l *Logger) LogHealthCheck(component string, status string, duration time.D
 	)
 }
 
+// SetSecurityEventHook registers a hook notified of security events. Loggers
+// derived afterwards with With or ForRequest share the hook.
+func (l *Logger) SetSecurityEventHook(hook SecurityEventHook) {
+	l.securityHook = hook
+}
+
+// SetSecurityEventBuffer retains security events in events. Loggers
+// derived afterwards with With or ForRequest share the buffer.
+func (l *Logger) SetSecurityEventBuffer(events *SecurityEventBuffer) {
+	l.securityEvents = events
+}
+
+// SetFileSystemErrorHook registers a hook notified of failed filesystem
+// operations. Loggers derived afterwards with With or ForRequest share the
+// hook.
+func (l *Logger) SetFileSystemErrorHook(hook FileSystemErrorHook) {
+	l.fsErrorHook = hook
+}
+
 // LogSecurityEvent logs security-related events
 func (l *Logger) LogSecurityEvent(event, path, remoteAddr, userAgent string, blocked bool) {
+	if l.securityHook != nil {
+		l.securityHook(event, remoteAddr, blocked)
+	}
+	if l.securityEvents != nil {
+		l.securityEvents.Record(SecurityEvent{
+			Time:       time.Now(),
+			Event:      event,
+			Path:       path,
+			RemoteAddr: remoteAddr,
+			UserAgent:  userAgent,
+			Blocked:    blocked,
+			RequestID:  l.requestID,
+		})
+	}
+
 	level := "warn"
 	if blocked {
 		level = "error"
@@ -196,6 +281,37 @@ func (l *Logger) LogSecurityEvent(event, path, remoteAddr, userAgent string, blo
 	} else {
 		l.Warn("security event", args...)
 	}
+
+	l.recordAudit(event,
+		"path", path,
+		"remote_addr", remoteAddr,
+		"user_agent", userAgent,
+		"blocked", blocked,
+	)
+}
+
+// SetAuditLog routes security events and LogAuditEvent calls to audit.
+// Loggers derived afterwards with With or ForRequest share the audit log,
+// and their attributes are included in its records.
+func (l *Logger) SetAuditLog(audit *AuditLog) {
+	l.audit = audit
+}
+
+// LogAuditEvent records a security-relevant action, such as a login or an
+// admin request, in the audit log and logs it at info level
+func (l *Logger) LogAuditEvent(event string, args ...interface{}) {
+	l.Info("audit event", append([]interface{}{"audit_event", event}, args...)...)
+	l.recordAudit(event, args...)
+}
+
+// recordAudit appends an event to the audit log, if one is set
+func (l *Logger) recordAudit(event string, args ...interface{}) {
+	if l.audit == nil {
+		return
+	}
+	if err := l.audit.Record(event, append(l.auditArgs[:len(l.auditArgs):len(l.auditArgs)], args...)...); err != nil {
+		l.Error("failed to write audit record", "audit_event", event, "error", err)
+	}
 }
 
 // LogError logs an error with additional context
@@ -255,8 +371,7 @@ func (l *Logger) GetSlogLogger() *slog.Logger {
 
 // LogLevel returns the current log level
 func (l *Logger) LogLevel() slog.Level {
-	// This is a simplified implementation - in practice you might want to track this
-	return slog.LevelInfo
+	return l.level.Level()
 }
 
 // IsDebugEnabled returns true if debug logging is enabled
@@ -288,3 +403,44 @@ func (l *Logger) LogPerformance(operation string, duration time.Duration, succes
 		l.Warn("performance", args...)
 	}
 }
+
+// loggerContextKey is the context key under which a request-scoped logger is stored
+type loggerContextKey struct{}
+
+// ForRequest returns a logger annotated with the identifiers of an HTTP request
+func (l *Logger) ForRequest(requestID, clientIP, endpoint string) *Logger {
+	logger := l.With(
+		"request_id", requestID,
+		"client_ip", clientIP,
+		"endpoint", endpoint,
+	)
+	logger.requestID = requestID
+	return logger
+}
+
+// requestIDContextKey is the context key for the ID of an HTTP request
+type requestIDContextKey struct{}
+
+// NewRequestIDContext returns a copy of ctx that carries a request ID
+func NewRequestIDContext(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDContextKey{}, requestID)
+}
+
+// RequestIDFromContext returns the request ID stored by NewRequestIDContext
+func RequestIDFromContext(ctx context.Context) (string, bool) {
+	requestID, ok := ctx.Value(requestIDContextKey{}).(string)
+	return requestID, ok && requestID != ""
+}
+
+// NewContext returns a copy of ctx that carries the given logger
+func NewContext(ctx context.Context, logger *Logger) context.Context {
+	return context.WithValue(ctx, loggerContextKey{}, logger)
+}
+
+// FromContext returns the logger stored in ctx, or fallback if none is present
+func FromContext(ctx context.Context, fallback *Logger) *Logger {
+	if logger, ok := ctx.Value(loggerContextKey{}).(*Logger); ok && logger != nil {
+		return logger
+	}
+	return fallback
+}
@@ -0,0 +1,113 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log/slog"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFromContext(t *testing.T) {
+	fallback := NewDefaultLogger()
+	requestLogger := fallback.ForRequest("req-1", "127.0.0.1", "/ls")
+
+	t.Run("returns fallback when context has no logger", func(t *testing.T) {
+		if got := FromContext(context.Background(), fallback); got != fallback {
+			t.Errorf("Expected fallback logger, got %v", got)
+		}
+	})
+
+	t.Run("returns logger stored in context", func(t *testing.T) {
+		ctx := NewContext(context.Background(), requestLogger)
+		if got := FromContext(ctx, fallback); got != requestLogger {
+			t.Errorf("Expected request logger, got %v", got)
+		}
+	})
+}
+
+func TestWithContext(t *testing.T) {
+	var buf bytes.Buffer
+	logger := NewLoggerWithWriter(LevelInfo, "json", &buf)
+
+	logger.WithContext(context.Background()).Info("without id")
+	if strings.Contains(buf.String(), "request_id") {
+		t.Errorf("Expected no request ID without one in the context, got %q", buf.String())
+	}
+
+	buf.Reset()
+	ctx := NewRequestIDContext(context.Background(), "req-42")
+	logger.WithContext(ctx).Info("with id")
+	if !strings.Contains(buf.String(), `"request_id":"req-42"`) {
+		t.Errorf("Expected the request ID from the context, got %q", buf.String())
+	}
+}
+
+func TestSecurityEventHook(t *testing.T) {
+	logger := NewLoggerWithWriter(LevelError, "json", io.Discard)
+
+	var events []string
+	logger.SetSecurityEventHook(func(event, remoteAddr string, blocked bool) {
+		events = append(events, event+"@"+remoteAddr)
+	})
+
+	logger.ForRequest("req-1", "192.0.2.1", "/cat/").LogSecurityEvent("path_traversal", "/cat/..", "192.0.2.1", "curl", true)
+	if len(events) != 1 || events[0] != "path_traversal@192.0.2.1" {
+		t.Errorf("Expected hook to see the event from a derived logger, got %v", events)
+	}
+}
+
+func TestSetLevel(t *testing.T) {
+	var buf bytes.Buffer
+	logger := NewLoggerWithWriter(LevelInfo, "json", &buf)
+	requestLogger := logger.ForRequest("req-1", "127.0.0.1", "/ls")
+
+	requestLogger.Debug("hidden")
+	logger.SetLevel(LevelDebug)
+	requestLogger.Debug("shown")
+
+	if strings.Contains(buf.String(), "hidden") || !strings.Contains(buf.String(), "shown") {
+		t.Errorf("Expected the new level to apply to derived loggers, got %q", buf.String())
+	}
+	if logger.LogLevel() != slog.LevelDebug {
+		t.Errorf("Expected LogLevel to report debug, got %v", logger.LogLevel())
+	}
+}
+
+func TestNewLoggerSinks(t *testing.T) {
+	var jsonOut, textOut bytes.Buffer
+	logger := NewLogger(LevelInfo, "json", WriterSink(&jsonOut), func(format string, opts *slog.HandlerOptions) slog.Handler {
+		return slog.NewTextHandler(&textOut, opts)
+	})
+
+	logger.ForRequest("req-1", "127.0.0.1", "/ls").Info("listed", "entries", 3)
+	logger.Debug("hidden")
+
+	if !strings.Contains(jsonOut.String(), `"request_id":"req-1"`) || !strings.Contains(jsonOut.String(), `"entries":3`) {
+		t.Errorf("Expected the record in the first sink, got %q", jsonOut.String())
+	}
+	if !strings.Contains(textOut.String(), "request_id=req-1") || !strings.Contains(textOut.String(), "entries=3") {
+		t.Errorf("Expected the record in the second sink, got %q", textOut.String())
+	}
+	if strings.Contains(jsonOut.String()+textOut.String(), "hidden") {
+		t.Error("Expected every sink to share the logger's level")
+	}
+}
+
+func TestAttrFields(t *testing.T) {
+	fields := attrFields{}.withAttrs([]slog.Attr{slog.String("request_id", "req-1")}).withGroup("file")
+	r := slog.NewRecord(time.Now(), slog.LevelInfo, "read", 0)
+	r.AddAttrs(slog.Int("size", 42), slog.Group("owner", slog.String("name", "alice")), slog.Attr{})
+
+	var got []string
+	for _, field := range fields.record(r) {
+		got = append(got, field.key+"="+field.value.String())
+	}
+	expected := []string{"request_id=req-1", "file.size=42", "file.owner.name=alice"}
+	if !slices.Equal(got, expected) {
+		t.Errorf("Expected fields %v, got %v", expected, got)
+	}
+}
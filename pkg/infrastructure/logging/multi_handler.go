@@ -0,0 +1,118 @@
+package logging
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"slices"
+)
+
+// multiHandler fans records out to several handlers
+type multiHandler []slog.Handler
+
+// Enabled reports whether any handler handles records at level
+func (h multiHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	for _, handler := range h {
+		if handler.Enabled(ctx, level) {
+			return true
+		}
+	}
+	return false
+}
+
+// Handle passes a copy of r to every handler enabled for its level, so a
+// failing output does not keep the record from the others
+func (h multiHandler) Handle(ctx context.Context, r slog.Record) error {
+	var errs []error
+	for _, handler := range h {
+		if handler.Enabled(ctx, r.Level) {
+			if err := handler.Handle(ctx, r.Clone()); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (h multiHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	handlers := make(multiHandler, len(h))
+	for i, handler := range h {
+		handlers[i] = handler.WithAttrs(attrs)
+	}
+	return handlers
+}
+
+func (h multiHandler) WithGroup(name string) slog.Handler {
+	handlers := make(multiHandler, len(h))
+	for i, handler := range h {
+		handlers[i] = handler.WithGroup(name)
+	}
+	return handlers
+}
+
+// attrField is an attribute flattened to a dotted key, e.g. "user.id" for
+// an "id" attribute in a "user" group
+type attrField struct {
+	key   string
+	value slog.Value
+}
+
+// attrFields accumulates the attributes and groups of WithAttrs and
+// WithGroup for handlers of outputs that take flat key/value fields
+type attrFields struct {
+	group  string // open groups joined with "."
+	fields []attrField
+}
+
+func (f attrFields) withAttrs(attrs []slog.Attr) attrFields {
+	fields := slices.Clip(f.fields)
+	for _, attr := range attrs {
+		fields = appendAttrField(fields, f.group, attr)
+	}
+	return attrFields{group: f.group, fields: fields}
+}
+
+func (f attrFields) withGroup(name string) attrFields {
+	if name == "" {
+		return f
+	}
+	if f.group != "" {
+		name = f.group + "." + name
+	}
+	return attrFields{group: name, fields: f.fields}
+}
+
+// record returns the accumulated fields followed by the attributes of r
+func (f attrFields) record(r slog.Record) []attrField {
+	fields := slices.Clip(f.fields)
+	r.Attrs(func(attr slog.Attr) bool {
+		fields = appendAttrField(fields, f.group, attr)
+		return true
+	})
+	return fields
+}
+
+// appendAttrField appends attr below group, flattening nested groups and
+// dropping empty attributes as slog handlers do
+func appendAttrField(fields []attrField, group string, attr slog.Attr) []attrField {
+	attr.Value = attr.Value.Resolve()
+	if attr.Equal(slog.Attr{}) {
+		return fields
+	}
+
+	key := attr.Key
+	if group != "" {
+		if key == "" {
+			key = group
+		} else {
+			key = group + "." + key
+		}
+	}
+	if attr.Value.Kind() == slog.KindGroup {
+		for _, member := range attr.Value.Group() {
+			fields = appendAttrField(fields, key, member)
+		}
+		return fields
+	}
+	return append(fields, attrField{key: key, value: attr.Value})
+}
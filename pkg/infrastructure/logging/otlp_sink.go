@@ -0,0 +1,285 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"maps"
+	"math"
+	"net/http"
+	"os"
+	"slices"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// otlpBatchSize is the number of records sent in one export request
+	otlpBatchSize = 512
+	// otlpQueueSize bounds the records waiting for export; records beyond
+	// it are dropped rather than blocking the request path
+	otlpQueueSize = 4096
+	// otlpFlushInterval is how long records wait for a batch to fill
+	otlpFlushInterval = 2 * time.Second
+	// otlpTimeout bounds each export request
+	otlpTimeout = 10 * time.Second
+)
+
+// NewOTLPSink returns a sink exporting records in batches to an OTLP/HTTP
+// logs endpoint with the JSON encoding, e.g.
+// http://localhost:4318/v1/logs, with resource attributes such as
+// service.name. Records are queued and sent in the background; close the
+// returned closer to flush the queue when logging ends.
+func NewOTLPSink(endpoint string, resource map[string]string) (Sink, io.Closer) {
+	exporter := &otlpExporter{
+		endpoint: endpoint,
+		client:   &http.Client{Timeout: otlpTimeout},
+		queue:    make(chan otlpLogRecord, otlpQueueSize),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	for _, key := range slices.Sorted(maps.Keys(resource)) {
+		value := resource[key]
+		exporter.resource = append(exporter.resource, otlpKeyValue{Key: key, Value: otlpAnyValue{StringValue: &value}})
+	}
+	go exporter.run()
+
+	sink := func(format string, opts *slog.HandlerOptions) slog.Handler {
+		return &otlpHandler{exporter: exporter, level: opts.Level}
+	}
+	return sink, exporter
+}
+
+// otlpExporter sends queued records to the endpoint
+type otlpExporter struct {
+	endpoint string
+	client   *http.Client
+	resource []otlpKeyValue
+	queue    chan otlpLogRecord
+	stop     chan struct{}
+	done     chan struct{}
+	stopOnce sync.Once
+	dropped  atomic.Int64 // records dropped since the last report
+	failing  bool         // whether the last export failed, used by run only
+}
+
+// enqueue queues a record without blocking
+func (e *otlpExporter) enqueue(record otlpLogRecord) {
+	select {
+	case e.queue <- record:
+	default:
+		// The exporter cannot keep up; drops are reported on export
+		e.dropped.Add(1)
+	}
+}
+
+// run batches queued records until Close
+func (e *otlpExporter) run() {
+	defer close(e.done)
+	ticker := time.NewTicker(otlpFlushInterval)
+	defer ticker.Stop()
+
+	batch := make([]otlpLogRecord, 0, otlpBatchSize)
+	add := func(record otlpLogRecord) {
+		batch = append(batch, record)
+		if len(batch) == otlpBatchSize {
+			e.export(batch)
+			batch = batch[:0]
+		}
+	}
+
+	for {
+		select {
+		case record := <-e.queue:
+			add(record)
+		case <-ticker.C:
+			e.export(batch)
+			batch = batch[:0]
+		case <-e.stop:
+			for {
+				select {
+				case record := <-e.queue:
+					add(record)
+				default:
+					e.export(batch)
+					return
+				}
+			}
+		}
+	}
+}
+
+// export sends batch. Failures are reported on stderr when exporting
+// starts and stops failing, since the logger cannot log its own output's
+// errors.
+func (e *otlpExporter) export(batch []otlpLogRecord) {
+	if dropped := e.dropped.Swap(0); dropped > 0 {
+		fmt.Fprintf(os.Stderr, "otlp log export: dropped %d records, the queue was full\n", dropped)
+	}
+	if len(batch) == 0 {
+		return
+	}
+
+	err := e.post(otlpLogsRequest{ResourceLogs: []otlpResourceLogs{{
+		Resource:  otlpResource{Attributes: e.resource},
+		ScopeLogs: []otlpScopeLogs{{Scope: otlpScope{Name: "cat-server"}, LogRecords: batch}},
+	}}})
+	switch {
+	case err != nil && !e.failing:
+		fmt.Fprintf(os.Stderr, "otlp log export to %s failed: %v\n", e.endpoint, err)
+	case err == nil && e.failing:
+		fmt.Fprintf(os.Stderr, "otlp log export to %s recovered\n", e.endpoint)
+	}
+	e.failing = err != nil
+}
+
+// post sends one export request
+func (e *otlpExporter) post(request otlpLogsRequest) error {
+	body, err := json.Marshal(request)
+	if err != nil {
+		return err
+	}
+	resp, err := e.client.Post(e.endpoint, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return nil
+}
+
+// Close sends the queued records and stops the exporter
+func (e *otlpExporter) Close() error {
+	e.stopOnce.Do(func() { close(e.stop) })
+	<-e.done
+	return nil
+}
+
+// otlpHandler converts records to OTLP log records for the exporter
+type otlpHandler struct {
+	exporter *otlpExporter
+	level    slog.Leveler
+	fields   attrFields
+}
+
+func (h *otlpHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	minLevel := slog.LevelInfo
+	if h.level != nil {
+		minLevel = h.level.Level()
+	}
+	return level >= minLevel
+}
+
+func (h *otlpHandler) Handle(ctx context.Context, r slog.Record) error {
+	message := r.Message
+	record := otlpLogRecord{
+		TimeUnixNano:         strconv.FormatInt(r.Time.UnixNano(), 10),
+		ObservedTimeUnixNano: strconv.FormatInt(time.Now().UnixNano(), 10),
+		SeverityNumber:       otlpSeverity(r.Level),
+		SeverityText:         r.Level.String(),
+		Body:                 otlpAnyValue{StringValue: &message},
+	}
+	for _, field := range h.fields.record(r) {
+		record.Attributes = append(record.Attributes, otlpKeyValue{Key: field.key, Value: otlpValue(field.value)})
+	}
+	h.exporter.enqueue(record)
+	return nil
+}
+
+func (h *otlpHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &otlpHandler{exporter: h.exporter, level: h.level, fields: h.fields.withAttrs(attrs)}
+}
+
+func (h *otlpHandler) WithGroup(name string) slog.Handler {
+	return &otlpHandler{exporter: h.exporter, level: h.level, fields: h.fields.withGroup(name)}
+}
+
+// otlpSeverity maps a level to an OTLP severity number: DEBUG 5, INFO 9,
+// WARN 13 and ERROR 17, plus the offset of levels in between
+func otlpSeverity(level slog.Level) int {
+	switch {
+	case level >= slog.LevelError:
+		return min(17+int(level-slog.LevelError), 24)
+	case level >= slog.LevelWarn:
+		return 13 + int(level-slog.LevelWarn)
+	case level >= slog.LevelInfo:
+		return 9 + int(level-slog.LevelInfo)
+	}
+	return max(5+int(level-slog.LevelDebug), 1)
+}
+
+// otlpValue converts an attribute value, keeping numbers and booleans
+// typed
+func otlpValue(v slog.Value) otlpAnyValue {
+	switch v.Kind() {
+	case slog.KindBool:
+		b := v.Bool()
+		return otlpAnyValue{BoolValue: &b}
+	case slog.KindInt64:
+		i := strconv.FormatInt(v.Int64(), 10)
+		return otlpAnyValue{IntValue: &i}
+	case slog.KindUint64:
+		if u := v.Uint64(); u <= math.MaxInt64 {
+			i := strconv.FormatUint(u, 10)
+			return otlpAnyValue{IntValue: &i}
+		}
+	case slog.KindFloat64:
+		f := v.Float64()
+		if !math.IsNaN(f) && !math.IsInf(f, 0) {
+			return otlpAnyValue{DoubleValue: &f}
+		}
+	case slog.KindTime:
+		s := v.Time().Format(time.RFC3339Nano)
+		return otlpAnyValue{StringValue: &s}
+	}
+	s := v.String()
+	return otlpAnyValue{StringValue: &s}
+}
+
+// The OTLP/HTTP JSON encoding of an export logs request. 64-bit integers
+// are encoded as strings.
+type (
+	otlpLogsRequest struct {
+		ResourceLogs []otlpResourceLogs `json:"resourceLogs"`
+	}
+	otlpResourceLogs struct {
+		Resource  otlpResource    `json:"resource"`
+		ScopeLogs []otlpScopeLogs `json:"scopeLogs"`
+	}
+	otlpResource struct {
+		Attributes []otlpKeyValue `json:"attributes,omitempty"`
+	}
+	otlpScopeLogs struct {
+		Scope      otlpScope       `json:"scope"`
+		LogRecords []otlpLogRecord `json:"logRecords"`
+	}
+	otlpScope struct {
+		Name string `json:"name"`
+	}
+	otlpLogRecord struct {
+		TimeUnixNano         string         `json:"timeUnixNano"`
+		ObservedTimeUnixNano string         `json:"observedTimeUnixNano"`
+		SeverityNumber       int            `json:"severityNumber"`
+		SeverityText         string         `json:"severityText"`
+		Body                 otlpAnyValue   `json:"body"`
+		Attributes           []otlpKeyValue `json:"attributes,omitempty"`
+	}
+	otlpKeyValue struct {
+		Key   string       `json:"key"`
+		Value otlpAnyValue `json:"value"`
+	}
+	otlpAnyValue struct {
+		StringValue *string  `json:"stringValue,omitempty"`
+		BoolValue   *bool    `json:"boolValue,omitempty"`
+		IntValue    *string  `json:"intValue,omitempty"`
+		DoubleValue *float64 `json:"doubleValue,omitempty"`
+	}
+)
@@ -0,0 +1,88 @@
+package logging
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+func TestOTLPSink(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		requests []otlpLogsRequest
+	)
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var request otlpLogsRequest
+		if r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("Expected a JSON export, got %q", r.Header.Get("Content-Type"))
+		}
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			t.Errorf("Invalid export request: %v", err)
+		}
+		mu.Lock()
+		requests = append(requests, request)
+		mu.Unlock()
+	}))
+	defer collector.Close()
+
+	sink, closer := NewOTLPSink(collector.URL+"/v1/logs", map[string]string{"service.name": "cat-server"})
+	logger := NewLogger(LevelInfo, "json", sink)
+	logger.ForRequest("req-1", "127.0.0.1", "/ls").Warn("slow listing", "entries", 3, "cached", false)
+	logger.LogError(errors.New("boom"), "read failed")
+	if err := closer.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(requests) != 1 || len(requests[0].ResourceLogs) != 1 {
+		t.Fatalf("Expected one export request, got %+v", requests)
+	}
+	resourceLogs := requests[0].ResourceLogs[0]
+	if attrs := resourceLogs.Resource.Attributes; len(attrs) != 1 || attrs[0].Key != "service.name" || *attrs[0].Value.StringValue != "cat-server" {
+		t.Errorf("Unexpected resource attributes %+v", attrs)
+	}
+	records := resourceLogs.ScopeLogs[0].LogRecords
+	if len(records) != 2 {
+		t.Fatalf("Expected 2 records, got %d", len(records))
+	}
+
+	warn := records[0]
+	if *warn.Body.StringValue != "slow listing" || warn.SeverityNumber != 13 || warn.SeverityText != "WARN" {
+		t.Errorf("Unexpected record %+v", warn)
+	}
+	attrs := make(map[string]otlpAnyValue)
+	for _, attr := range warn.Attributes {
+		attrs[attr.Key] = attr.Value
+	}
+	if v := attrs["request_id"]; v.StringValue == nil || *v.StringValue != "req-1" {
+		t.Errorf("Expected the request ID attribute, got %+v", warn.Attributes)
+	}
+	if v := attrs["entries"]; v.IntValue == nil || *v.IntValue != "3" {
+		t.Errorf("Expected an integer entries attribute, got %+v", v)
+	}
+	if v := attrs["cached"]; v.BoolValue == nil || *v.BoolValue {
+		t.Errorf("Expected a boolean cached attribute, got %+v", v)
+	}
+	if records[1].SeverityNumber != 17 {
+		t.Errorf("Expected error severity 17, got %d", records[1].SeverityNumber)
+	}
+}
+
+func TestOTLPSeverity(t *testing.T) {
+	tests := []struct {
+		level    slog.Level
+		expected int
+	}{
+		{-8, 1}, {-4, 5}, {-2, 7}, {0, 9}, {4, 13}, {8, 17}, {12, 21}, {20, 24},
+	}
+	for _, tt := range tests {
+		if got := otlpSeverity(tt.level); got != tt.expected {
+			t.Errorf("otlpSeverity(%d) = %d, expected %d", tt.level, got, tt.expected)
+		}
+	}
+}
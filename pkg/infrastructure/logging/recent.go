@@ -0,0 +1,68 @@
+package logging
+
+import (
+	"bytes"
+	"sync"
+)
+
+// RecentLogBuffer is an io.Writer that retains the most recent log lines in
+// memory so they can be included in diagnostics
+type RecentLogBuffer struct {
+	mu       sync.Mutex
+	lines    [][]byte
+	next     int
+	full     bool
+	partial  []byte
+	capacity int
+}
+
+// NewRecentLogBuffer creates a buffer holding at most capacity lines
+func NewRecentLogBuffer(capacity int) *RecentLogBuffer {
+	if capacity <= 0 {
+		capacity = 1
+	}
+	return &RecentLogBuffer{
+		lines:    make([][]byte, capacity),
+		capacity: capacity,
+	}
+}
+
+// Write implements io.Writer, splitting input into lines
+func (b *RecentLogBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	data := append(b.partial, p...)
+	for {
+		i := bytes.IndexByte(data, '\n')
+		if i < 0 {
+			break
+		}
+		b.lines[b.next] = append([]byte(nil), data[:i+1]...)
+		b.next = (b.next + 1) % b.capacity
+		if b.next == 0 {
+			b.full = true
+		}
+		data = data[i+1:]
+	}
+	b.partial = append([]byte(nil), data...)
+
+	return len(p), nil
+}
+
+// Bytes returns the retained lines, oldest first
+func (b *RecentLogBuffer) Bytes() []byte {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var buf bytes.Buffer
+	if b.full {
+		for _, line := range b.lines[b.next:] {
+			buf.Write(line)
+		}
+	}
+	for _, line := range b.lines[:b.next] {
+		buf.Write(line)
+	}
+	return buf.Bytes()
+}
@@ -0,0 +1,70 @@
+package logging
+
+import (
+	"sync"
+	"time"
+)
+
+// SecurityEvent is an event passed to LogSecurityEvent
+type SecurityEvent struct {
+	Time       time.Time `json:"time"`
+	Event      string    `json:"event"`
+	Path       string    `json:"path"`
+	RemoteAddr string    `json:"remote_addr"`
+	UserAgent  string    `json:"user_agent"`
+	Blocked    bool      `json:"blocked"`
+	RequestID  string    `json:"request_id,omitempty"`
+}
+
+// SecurityEventBuffer retains the most recent security events in memory,
+// so they can be inspected without searching the logs
+type SecurityEventBuffer struct {
+	mu     sync.Mutex
+	events []SecurityEvent
+	next   int
+	full   bool
+}
+
+// NewSecurityEventBuffer creates a buffer holding at most capacity events
+func NewSecurityEventBuffer(capacity int) *SecurityEventBuffer {
+	if capacity <= 0 {
+		capacity = 1
+	}
+	return &SecurityEventBuffer{events: make([]SecurityEvent, capacity)}
+}
+
+// Capacity returns the number of events the buffer retains
+func (b *SecurityEventBuffer) Capacity() int {
+	return len(b.events)
+}
+
+// Record adds an event, evicting the oldest one when the buffer is full
+func (b *SecurityEventBuffer) Record(event SecurityEvent) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.events[b.next] = event
+	b.next = (b.next + 1) % len(b.events)
+	if b.next == 0 {
+		b.full = true
+	}
+}
+
+// Since returns the retained events at or after since, oldest first. A
+// zero since returns all of them.
+func (b *SecurityEventBuffer) Since(since time.Time) []SecurityEvent {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	retained := b.events[:b.next]
+	if b.full {
+		retained = append(append([]SecurityEvent(nil), b.events[b.next:]...), retained...)
+	}
+	events := []SecurityEvent{}
+	for _, event := range retained {
+		if !event.Time.Before(since) {
+			events = append(events, event)
+		}
+	}
+	return events
+}
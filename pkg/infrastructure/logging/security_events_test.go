@@ -0,0 +1,50 @@
+package logging
+
+import (
+	"io"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestSecurityEventBuffer(t *testing.T) {
+	start := time.Now()
+	b := NewSecurityEventBuffer(3)
+	for i, event := range []string{"a", "b", "c", "d"} {
+		b.Record(SecurityEvent{Time: start.Add(time.Duration(i) * time.Minute), Event: event})
+	}
+
+	tests := []struct {
+		since    time.Time
+		expected []string
+	}{
+		{time.Time{}, []string{"b", "c", "d"}},
+		{start.Add(2 * time.Minute), []string{"c", "d"}},
+		{start.Add(time.Hour), nil},
+	}
+	for _, tt := range tests {
+		events := b.Since(tt.since)
+		var names []string
+		for _, e := range events {
+			names = append(names, e.Event)
+		}
+		if !slices.Equal(names, tt.expected) {
+			t.Errorf("Since(%v) = %v, expected %v", tt.since, names, tt.expected)
+		}
+	}
+}
+
+func TestLogSecurityEventBuffer(t *testing.T) {
+	events := NewSecurityEventBuffer(10)
+	logger := NewLoggerWithWriter(LevelInfo, "json", io.Discard)
+	logger.SetSecurityEventBuffer(events)
+	logger.ForRequest("req-1", "10.0.0.1", "/ls").LogSecurityEvent("path_traversal", "../etc", "10.0.0.1", "curl", true)
+
+	recorded := events.Since(time.Time{})
+	if len(recorded) != 1 {
+		t.Fatalf("Expected 1 event, got %v", recorded)
+	}
+	if e := recorded[0]; e.Event != "path_traversal" || e.Path != "../etc" || !e.Blocked || e.RequestID != "req-1" || e.Time.IsZero() {
+		t.Errorf("Unexpected event %+v", e)
+	}
+}
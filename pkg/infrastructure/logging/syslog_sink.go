@@ -0,0 +1,92 @@
+//go:build !windows && !plan9
+
+package logging
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"log/syslog"
+	"strings"
+	"sync"
+)
+
+// OpenSyslogSink connects to the local syslog daemon and returns a sink
+// sending each record, formatted like the other outputs but without the
+// time syslog adds itself, at the severity of its level. Close the
+// returned closer when logging ends.
+func OpenSyslogSink(facility, tag string) (Sink, io.Closer, error) {
+	priority, ok := syslogFacilities[facility]
+	if !ok {
+		return nil, nil, fmt.Errorf("unknown syslog facility %q", facility)
+	}
+	writer, err := syslog.New(priority|syslog.LOG_INFO, tag)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	sink := func(format string, opts *slog.HandlerOptions) slog.Handler {
+		out := &syslogOutput{writer: writer}
+		lineOpts := *opts
+		lineOpts.ReplaceAttr = dropTime
+		return &syslogHandler{handler: newFormatHandler(format, &out.line, &lineOpts), out: out}
+	}
+	return sink, writer, nil
+}
+
+// dropTime removes the record time from formatted lines
+func dropTime(groups []string, attr slog.Attr) slog.Attr {
+	if len(groups) == 0 && attr.Key == slog.TimeKey {
+		return slog.Attr{}
+	}
+	return attr
+}
+
+// syslogOutput formats one record at a time into line and sends it
+type syslogOutput struct {
+	mu     sync.Mutex
+	line   bytes.Buffer
+	writer *syslog.Writer
+}
+
+// syslogHandler formats records with a JSON or text handler writing into
+// the shared output, which handlers derived with WithAttrs also use
+type syslogHandler struct {
+	handler slog.Handler
+	out     *syslogOutput
+}
+
+func (h *syslogHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.handler.Enabled(ctx, level)
+}
+
+func (h *syslogHandler) Handle(ctx context.Context, r slog.Record) error {
+	h.out.mu.Lock()
+	defer h.out.mu.Unlock()
+
+	h.out.line.Reset()
+	if err := h.handler.Handle(ctx, r); err != nil {
+		return err
+	}
+	line := strings.TrimSuffix(h.out.line.String(), "\n")
+
+	switch {
+	case r.Level >= slog.LevelError:
+		return h.out.writer.Err(line)
+	case r.Level >= slog.LevelWarn:
+		return h.out.writer.Warning(line)
+	case r.Level >= slog.LevelInfo:
+		return h.out.writer.Info(line)
+	}
+	return h.out.writer.Debug(line)
+}
+
+func (h *syslogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &syslogHandler{handler: h.handler.WithAttrs(attrs), out: h.out}
+}
+
+func (h *syslogHandler) WithGroup(name string) slog.Handler {
+	return &syslogHandler{handler: h.handler.WithGroup(name), out: h.out}
+}
@@ -0,0 +1,13 @@
+//go:build windows || plan9
+
+package logging
+
+import (
+	"errors"
+	"io"
+)
+
+// OpenSyslogSink is not supported on this platform
+func OpenSyslogSink(facility, tag string) (Sink, io.Closer, error) {
+	return nil, nil, errors.New("syslog is not supported on this platform")
+}
@@ -0,0 +1,62 @@
+// Package memguard refuses large allocations, such as reading a big file
+// into memory, while the heap is close to the process's memory limit
+package memguard
+
+import "runtime/metrics"
+
+// heapMetric counts the bytes of live and not yet swept heap objects. It is
+// read without stopping the world, unlike runtime.ReadMemStats.
+const heapMetric = "/memory/classes/heap/objects:bytes"
+
+// Guard refuses allocations of at least minBytes while the heap exceeds a
+// threshold. A nil Guard allows every allocation.
+type Guard struct {
+	limit     uint64
+	threshold uint64
+	minBytes  int64
+	heap      func() uint64
+}
+
+// New returns a guard for a memory limit of limit bytes, refusing large
+// allocations above percent of it
+func New(limit uint64, percent float64, minBytes int64) *Guard {
+	return &Guard{
+		limit:     limit,
+		threshold: uint64(float64(limit) * percent / 100),
+		minBytes:  minBytes,
+		heap:      heapBytes,
+	}
+}
+
+// Allow reports whether n bytes may be allocated
+func (g *Guard) Allow(n int64) bool {
+	return g == nil || n < g.minBytes || !g.Pressure()
+}
+
+// Pressure reports whether the heap exceeds the threshold, so that large
+// allocations are refused
+func (g *Guard) Pressure() bool {
+	return g != nil && g.heap() > g.threshold
+}
+
+// Heap returns the current heap size in bytes
+func (g *Guard) Heap() uint64 {
+	return g.heap()
+}
+
+// Limit returns the memory limit in bytes
+func (g *Guard) Limit() uint64 {
+	return g.limit
+}
+
+// Threshold returns the heap size in bytes above which large allocations
+// are refused
+func (g *Guard) Threshold() uint64 {
+	return g.threshold
+}
+
+func heapBytes() uint64 {
+	sample := []metrics.Sample{{Name: heapMetric}}
+	metrics.Read(sample)
+	return sample[0].Value.Uint64()
+}
@@ -0,0 +1,37 @@
+package memguard
+
+import "testing"
+
+func TestGuard(t *testing.T) {
+	g := New(1000, 90, 100)
+	heap := uint64(0)
+	g.heap = func() uint64 { return heap }
+
+	for _, tt := range []struct {
+		heap     uint64
+		size     int64
+		expected bool
+	}{
+		{0, 1 << 20, true},
+		{900, 1 << 20, true},
+		{901, 1 << 20, false},
+		{901, 100, false},
+		{901, 99, true},
+	} {
+		heap = tt.heap
+		if allowed := g.Allow(tt.size); allowed != tt.expected {
+			t.Errorf("Allow(%d) with a heap of %d = %v, expected %v", tt.size, tt.heap, allowed, tt.expected)
+		}
+	}
+
+	var none *Guard
+	if !none.Allow(1<<40) || none.Pressure() {
+		t.Error("Expected a nil guard to allow everything")
+	}
+}
+
+func TestHeapBytes(t *testing.T) {
+	if heap := heapBytes(); heap == 0 {
+		t.Error("Expected a non-empty heap")
+	}
+}
@@ -0,0 +1,104 @@
+package metrics
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// CacheMetrics collects observability data for a cache or index subsystem.
+// All methods are safe for concurrent use.
+type CacheMetrics struct {
+	name            string
+	entries         atomic.Int64
+	bytes           atomic.Int64
+	hits            atomic.Int64
+	misses          atomic.Int64
+	evictions       atomic.Int64
+	rebuilds        atomic.Int64
+	rebuildNanos    atomic.Int64
+	lastRebuildNano atomic.Int64
+	events          atomic.Int64
+}
+
+// CacheSnapshot is a point-in-time view of a cache's metrics
+type CacheSnapshot struct {
+	Name                string        `json:"name"`
+	Entries             int64         `json:"entries"`
+	Bytes               int64         `json:"bytes"`
+	Hits                int64         `json:"hits"`
+	Misses              int64         `json:"misses"`
+	HitRatio            float64       `json:"hitRatio"`
+	Evictions           int64         `json:"evictions"`
+	Rebuilds            int64         `json:"rebuilds"`
+	RebuildTotal        time.Duration `json:"rebuildTotal"`
+	LastRebuildDuration time.Duration `json:"lastRebuildDuration"`
+	Events              int64         `json:"events"`
+}
+
+// NewCacheMetrics creates a new CacheMetrics for the named subsystem
+func NewCacheMetrics(name string) *CacheMetrics {
+	return &CacheMetrics{name: name}
+}
+
+// Name returns the subsystem name
+func (c *CacheMetrics) Name() string {
+	return c.name
+}
+
+// RecordHit records a cache hit
+func (c *CacheMetrics) RecordHit() {
+	c.hits.Add(1)
+}
+
+// RecordMiss records a cache miss
+func (c *CacheMetrics) RecordMiss() {
+	c.misses.Add(1)
+}
+
+// RecordEviction records that n entries were evicted
+func (c *CacheMetrics) RecordEviction(n int) {
+	c.evictions.Add(int64(n))
+}
+
+// SetSize sets the current number of entries and their memory footprint in bytes
+func (c *CacheMetrics) SetSize(entries int, bytes int64) {
+	c.entries.Store(int64(entries))
+	c.bytes.Store(bytes)
+}
+
+// RecordRebuild records a full rebuild or refresh and how long it took
+func (c *CacheMetrics) RecordRebuild(duration time.Duration) {
+	c.rebuilds.Add(1)
+	c.rebuildNanos.Add(int64(duration))
+	c.lastRebuildNano.Store(int64(duration))
+}
+
+// RecordEvent records a filesystem change event delivered to the subsystem
+func (c *CacheMetrics) RecordEvent() {
+	c.events.Add(1)
+}
+
+// Snapshot returns the current metric values
+func (c *CacheMetrics) Snapshot() CacheSnapshot {
+	hits := c.hits.Load()
+	misses := c.misses.Load()
+
+	var hitRatio float64
+	if total := hits + misses; total > 0 {
+		hitRatio = float64(hits) / float64(total)
+	}
+
+	return CacheSnapshot{
+		Name:                c.name,
+		Entries:             c.entries.Load(),
+		Bytes:               c.bytes.Load(),
+		Hits:                hits,
+		Misses:              misses,
+		HitRatio:            hitRatio,
+		Evictions:           c.evictions.Load(),
+		Rebuilds:            c.rebuilds.Load(),
+		RebuildTotal:        time.Duration(c.rebuildNanos.Load()),
+		LastRebuildDuration: time.Duration(c.lastRebuildNano.Load()),
+		Events:              c.events.Load(),
+	}
+}
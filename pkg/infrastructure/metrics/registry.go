@@ -0,0 +1,201 @@
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Registry holds the HTTP request metrics, the bytes served per endpoint
+// and directory and the metrics of all cache and index subsystems, and
+// exports the uptime and runtime statistics
+type Registry struct {
+	mu          sync.RWMutex
+	caches      map[string]*CacheMetrics
+	requests    *RequestMetrics
+	endpoints   *UsageTracker
+	directories *UsageTracker
+	startTime   time.Time
+}
+
+// NewRegistry creates an empty Registry, measuring uptime from now
+func NewRegistry() *Registry {
+	return &Registry{
+		caches:      make(map[string]*CacheMetrics),
+		requests:    NewRequestMetrics(),
+		endpoints:   NewUsageTracker(0),
+		directories: NewUsageTracker(0),
+		startTime:   time.Now(),
+	}
+}
+
+// Endpoints returns the requests and bytes served per endpoint, e.g. /cat
+func (r *Registry) Endpoints() *UsageTracker {
+	return r.endpoints
+}
+
+// Directories returns the requests and bytes served per top-level
+// directory of the served tree, e.g. a mount name, or . for the files at
+// its root
+func (r *Registry) Directories() *UsageTracker {
+	return r.directories
+}
+
+// StartTime returns the time uptime is measured from
+func (r *Registry) StartTime() time.Time {
+	return r.startTime
+}
+
+// Requests returns the HTTP request metrics
+func (r *Registry) Requests() *RequestMetrics {
+	return r.requests
+}
+
+// Cache returns the metrics for the named subsystem, creating them if needed
+func (r *Registry) Cache(name string) *CacheMetrics {
+	r.mu.RLock()
+	c, ok := r.caches[name]
+	r.mu.RUnlock()
+	if ok {
+		return c
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if c, ok := r.caches[name]; ok {
+		return c
+	}
+	c = NewCacheMetrics(name)
+	r.caches[name] = c
+	return c
+}
+
+// CacheSnapshots returns snapshots of all registered subsystems sorted by name
+func (r *Registry) CacheSnapshots() []CacheSnapshot {
+	r.mu.RLock()
+	snapshots := make([]CacheSnapshot, 0, len(r.caches))
+	for _, c := range r.caches {
+		snapshots = append(snapshots, c.Snapshot())
+	}
+	r.mu.RUnlock()
+
+	sort.Slice(snapshots, func(i, j int) bool {
+		return snapshots[i].Name < snapshots[j].Name
+	})
+	return snapshots
+}
+
+// labelEscaper escapes label values in the Prometheus text format
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// WritePrometheus writes all metrics in the Prometheus text exposition format
+func (r *Registry) WritePrometheus(w io.Writer) error {
+	requests := r.requests.Snapshot()
+	// The runtime gauges match the system section of detailed /health output
+	rt := ReadRuntime()
+	var lastGC float64
+	if !rt.LastGC.IsZero() {
+		lastGC = float64(rt.LastGC.UnixNano()) / 1e9
+	}
+	for _, family := range []struct {
+		name  string
+		kind  string
+		help  string
+		value float64
+	}{
+		{"cat_server_http_requests_total", "counter", "Total HTTP requests served.", float64(requests.Requests)},
+		{"cat_server_http_request_errors_total", "counter", "Total HTTP requests answered with a 5xx status.", float64(requests.Errors)},
+		{"cat_server_http_request_duration_seconds_total", "counter", "Total time spent serving HTTP requests in seconds.", requests.TotalResponse.Seconds()},
+		{"cat_server_start_time_seconds", "gauge", "Start time of the server since the Unix epoch in seconds.", float64(r.startTime.UnixNano()) / 1e9},
+		{"cat_server_uptime_seconds", "gauge", "Time since the server started in seconds.", time.Since(r.startTime).Seconds()},
+		{"cat_server_memory_alloc_bytes", "gauge", "Bytes of allocated heap objects.", float64(rt.Alloc)},
+		{"cat_server_memory_alloc_bytes_total", "counter", "Total bytes allocated for heap objects.", float64(rt.TotalAlloc)},
+		{"cat_server_memory_sys_bytes", "gauge", "Bytes of memory obtained from the OS.", float64(rt.Sys)},
+		{"cat_server_memory_heap_objects", "gauge", "Number of allocated heap objects.", float64(rt.HeapObjects)},
+		{"cat_server_memory_heap_inuse_bytes", "gauge", "Bytes in in-use heap spans.", float64(rt.HeapInuse)},
+		{"cat_server_memory_stack_inuse_bytes", "gauge", "Bytes in stack spans.", float64(rt.StackInuse)},
+		{"cat_server_gc_cycles_total", "counter", "Total completed garbage collection cycles.", float64(rt.NumGC)},
+		{"cat_server_gc_pause_seconds_total", "counter", "Total garbage collection pause time in seconds.", rt.PauseTotal.Seconds()},
+		{"cat_server_gc_last_pause_seconds", "gauge", "Duration of the last garbage collection pause in seconds.", rt.LastPause.Seconds()},
+		{"cat_server_gc_last_time_seconds", "gauge", "Time of the last garbage collection since the Unix epoch in seconds, 0 before the first.", lastGC},
+		{"cat_server_gc_next_bytes", "gauge", "Heap size target of the next garbage collection cycle in bytes.", float64(rt.NextGC)},
+		{"cat_server_goroutines", "gauge", "Number of goroutines.", float64(rt.Goroutines)},
+		{"cat_server_cpus", "gauge", "Number of logical CPUs usable by the server.", float64(rt.NumCPU)},
+	} {
+		if _, err := fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n%s %g\n", family.name, family.help, family.name, family.kind, family.name, family.value); err != nil {
+			return err
+		}
+	}
+
+	for _, traffic := range []struct {
+		name    string
+		tracker *UsageTracker
+	}{
+		{"endpoint", r.endpoints},
+		{"directory", r.directories},
+	} {
+		usage := traffic.tracker.Snapshot()
+		for _, family := range []struct {
+			suffix string
+			help   string
+			value  func(UsageSnapshot) int64
+		}{
+			{"requests_total", "Total requests served per " + traffic.name + ".", func(s UsageSnapshot) int64 { return s.Requests }},
+			{"bytes_total", "Total response bytes served per " + traffic.name + ".", func(s UsageSnapshot) int64 { return s.Bytes }},
+		} {
+			name := "cat_server_" + traffic.name + "_" + family.suffix
+			if _, err := fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s counter\n", name, family.help, name); err != nil {
+				return err
+			}
+			for _, s := range usage {
+				if _, err := fmt.Fprintf(w, "%s{%s=\"%s\"} %d\n", name, traffic.name, labelEscaper.Replace(s.Key), family.value(s)); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	snapshots := r.CacheSnapshots()
+
+	families := []struct {
+		name  string
+		kind  string
+		help  string
+		value func(CacheSnapshot) float64
+	}{
+		{"cat_server_cache_entries", "gauge", "Number of entries held by the cache.",
+			func(s CacheSnapshot) float64 { return float64(s.Entries) }},
+		{"cat_server_cache_bytes", "gauge", "Approximate memory held by the cache in bytes.",
+			func(s CacheSnapshot) float64 { return float64(s.Bytes) }},
+		{"cat_server_cache_hits_total", "counter", "Total cache hits.",
+			func(s CacheSnapshot) float64 { return float64(s.Hits) }},
+		{"cat_server_cache_misses_total", "counter", "Total cache misses.",
+			func(s CacheSnapshot) float64 { return float64(s.Misses) }},
+		{"cat_server_cache_hit_ratio", "gauge", "Ratio of hits to lookups since startup.",
+			func(s CacheSnapshot) float64 { return s.HitRatio }},
+		{"cat_server_cache_evictions_total", "counter", "Total entries evicted from the cache.",
+			func(s CacheSnapshot) float64 { return float64(s.Evictions) }},
+		{"cat_server_cache_rebuilds_total", "counter", "Total cache or index rebuilds.",
+			func(s CacheSnapshot) float64 { return float64(s.Rebuilds) }},
+		{"cat_server_cache_rebuild_seconds_total", "counter", "Total time spent rebuilding in seconds.",
+			func(s CacheSnapshot) float64 { return s.RebuildTotal.Seconds() }},
+		{"cat_server_cache_events_total", "counter", "Total filesystem change events received.",
+			func(s CacheSnapshot) float64 { return float64(s.Events) }},
+	}
+
+	for _, family := range families {
+		if _, err := fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", family.name, family.help, family.name, family.kind); err != nil {
+			return err
+		}
+		for _, s := range snapshots {
+			if _, err := fmt.Fprintf(w, "%s{cache=%q} %g\n", family.name, s.Name, family.value(s)); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
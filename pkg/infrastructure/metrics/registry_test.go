@@ -0,0 +1,87 @@
+package metrics
+
+import (
+	"bytes"
+	"fmt"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCacheMetrics_Snapshot(t *testing.T) {
+	c := NewCacheMetrics("files")
+	c.RecordHit()
+	c.RecordHit()
+	c.RecordHit()
+	c.RecordMiss()
+	c.RecordEviction(2)
+	c.SetSize(10, 4096)
+	c.RecordRebuild(50 * time.Millisecond)
+	c.RecordEvent()
+
+	s := c.Snapshot()
+	if s.Hits != 3 || s.Misses != 1 {
+		t.Errorf("Expected 3 hits and 1 miss, got %d and %d", s.Hits, s.Misses)
+	}
+	if s.HitRatio != 0.75 {
+		t.Errorf("Expected hit ratio 0.75, got %v", s.HitRatio)
+	}
+	if s.Entries != 10 || s.Bytes != 4096 {
+		t.Errorf("Expected 10 entries and 4096 bytes, got %d and %d", s.Entries, s.Bytes)
+	}
+	if s.Evictions != 2 || s.Rebuilds != 1 || s.Events != 1 {
+		t.Errorf("Unexpected counters: %+v", s)
+	}
+	if s.LastRebuildDuration != 50*time.Millisecond {
+		t.Errorf("Expected last rebuild 50ms, got %v", s.LastRebuildDuration)
+	}
+}
+
+func TestRegistry_WritePrometheus(t *testing.T) {
+	r := NewRegistry()
+	if r.Cache("files") != r.Cache("files") {
+		t.Fatal("Expected Cache to return the same instance for the same name")
+	}
+	r.Cache("files").RecordHit()
+	r.Cache("listings").SetSize(3, 300)
+	r.Requests().Record(500, time.Second)
+	r.Endpoints().Record("/cat", 120)
+	r.Directories().Record(`say "hi"`, 64)
+
+	var buf bytes.Buffer
+	if err := r.WritePrometheus(&buf); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	out := buf.String()
+	for _, want := range []string{
+		"# TYPE cat_server_cache_hits_total counter",
+		`cat_server_cache_hits_total{cache="files"} 1`,
+		`cat_server_cache_entries{cache="listings"} 3`,
+		"cat_server_http_requests_total 1",
+		"cat_server_http_request_errors_total 1",
+		"# TYPE cat_server_uptime_seconds gauge",
+		"# TYPE cat_server_memory_heap_inuse_bytes gauge",
+		"# TYPE cat_server_gc_cycles_total counter",
+		"# TYPE cat_server_goroutines gauge",
+		fmt.Sprintf("cat_server_cpus %d\n", runtime.NumCPU()),
+		`cat_server_endpoint_bytes_total{endpoint="/cat"} 120`,
+		`cat_server_directory_requests_total{directory="say \"hi\""} 1`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Expected output to contain %q", want)
+		}
+	}
+}
+
+func TestReadRuntime(t *testing.T) {
+	runtime.GC()
+	rt := ReadRuntime()
+	if rt.NumGC == 0 || rt.LastGC.IsZero() || rt.Alloc == 0 || rt.Sys < rt.HeapInuse {
+		t.Errorf("Unexpected runtime snapshot after a GC: %+v", rt)
+	}
+	if rt.Goroutines < 1 || rt.NumCPU != runtime.NumCPU() {
+		t.Errorf("Expected goroutines and %d CPUs, got %+v", runtime.NumCPU(), rt)
+	}
+}
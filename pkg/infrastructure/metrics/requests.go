@@ -0,0 +1,63 @@
+package metrics
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// RequestMetrics counts HTTP requests, server errors and response times.
+// All methods are safe for concurrent use.
+type RequestMetrics struct {
+	requests     atomic.Int64
+	errors       atomic.Int64
+	durationNano atomic.Int64
+	lastNano     atomic.Int64 // Unix time of the last request
+}
+
+// RequestSnapshot is a point-in-time view of the request metrics
+type RequestSnapshot struct {
+	Requests        int64         `json:"requests"`
+	Errors          int64         `json:"errors"`
+	AverageResponse time.Duration `json:"averageResponse"`
+	TotalResponse   time.Duration `json:"totalResponse"`
+	SuccessRate     float64       `json:"successRate"` // percentage of requests without server errors
+	LastActivity    time.Time     `json:"lastActivity"`
+}
+
+// NewRequestMetrics creates an empty RequestMetrics
+func NewRequestMetrics() *RequestMetrics {
+	return &RequestMetrics{}
+}
+
+// Record records a request answered with status after duration. Responses
+// with a 5xx status count as errors.
+func (m *RequestMetrics) Record(status int, duration time.Duration) {
+	m.requests.Add(1)
+	if status >= 500 {
+		m.errors.Add(1)
+	}
+	m.durationNano.Add(int64(duration))
+	m.lastNano.Store(time.Now().UnixNano())
+}
+
+// Snapshot returns the current metric values
+func (m *RequestMetrics) Snapshot() RequestSnapshot {
+	requests := m.requests.Load()
+	errors := m.errors.Load()
+	total := time.Duration(m.durationNano.Load())
+
+	s := RequestSnapshot{
+		Requests:      requests,
+		Errors:        errors,
+		TotalResponse: total,
+		SuccessRate:   100,
+	}
+	if requests > 0 {
+		s.AverageResponse = total / time.Duration(requests)
+		s.SuccessRate = float64(requests-errors) / float64(requests) * 100
+	}
+	if last := m.lastNano.Load(); last > 0 {
+		s.LastActivity = time.Unix(0, last)
+	}
+	return s
+}
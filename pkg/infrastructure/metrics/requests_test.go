@@ -0,0 +1,32 @@
+package metrics
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRequestMetrics_Snapshot(t *testing.T) {
+	m := NewRequestMetrics()
+	if s := m.Snapshot(); s.Requests != 0 || s.SuccessRate != 100 || !s.LastActivity.IsZero() {
+		t.Errorf("Expected an empty snapshot with a 100%% success rate, got %+v", s)
+	}
+
+	m.Record(200, 10*time.Millisecond)
+	m.Record(404, 20*time.Millisecond)
+	m.Record(200, 30*time.Millisecond)
+	m.Record(503, 40*time.Millisecond)
+
+	s := m.Snapshot()
+	if s.Requests != 4 || s.Errors != 1 {
+		t.Errorf("Expected 4 requests and 1 error, got %d and %d", s.Requests, s.Errors)
+	}
+	if s.AverageResponse != 25*time.Millisecond {
+		t.Errorf("Expected average response 25ms, got %v", s.AverageResponse)
+	}
+	if s.SuccessRate != 75 {
+		t.Errorf("Expected success rate 75, got %v", s.SuccessRate)
+	}
+	if time.Since(s.LastActivity) > time.Minute {
+		t.Errorf("Expected recent last activity, got %v", s.LastActivity)
+	}
+}
@@ -0,0 +1,52 @@
+package metrics
+
+import (
+	"runtime"
+	"time"
+)
+
+// RuntimeSnapshot is a point-in-time view of the Go runtime's memory,
+// garbage collector and goroutines
+type RuntimeSnapshot struct {
+	Alloc       uint64 // bytes of allocated heap objects
+	TotalAlloc  uint64 // cumulative bytes allocated
+	Sys         uint64 // bytes obtained from the OS
+	HeapObjects uint64
+	HeapInuse   uint64
+	StackInuse  uint64
+
+	NumGC      uint32
+	PauseTotal time.Duration
+	LastPause  time.Duration
+	NextGC     uint64    // heap size target of the next cycle
+	LastGC     time.Time // zero before the first cycle
+
+	Goroutines int
+	NumCPU     int
+}
+
+// ReadRuntime reads the runtime statistics. It stops the world briefly,
+// like runtime.ReadMemStats.
+func ReadRuntime() RuntimeSnapshot {
+	var m runtime.MemStats
+	runtime.ReadMemStats(&m)
+
+	s := RuntimeSnapshot{
+		Alloc:       m.Alloc,
+		TotalAlloc:  m.TotalAlloc,
+		Sys:         m.Sys,
+		HeapObjects: m.HeapObjects,
+		HeapInuse:   m.HeapInuse,
+		StackInuse:  m.StackInuse,
+		NumGC:       m.NumGC,
+		PauseTotal:  time.Duration(m.PauseTotalNs),
+		NextGC:      m.NextGC,
+		Goroutines:  runtime.NumGoroutine(),
+		NumCPU:      runtime.NumCPU(),
+	}
+	if m.NumGC > 0 {
+		s.LastPause = time.Duration(m.PauseNs[(m.NumGC+255)%256])
+		s.LastGC = time.Unix(0, int64(m.LastGC))
+	}
+	return s
+}
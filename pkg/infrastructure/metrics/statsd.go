@@ -0,0 +1,130 @@
+package metrics
+
+import (
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// statsDMaxPacket keeps packets within a 1500 byte MTU after IP and UDP
+	// headers
+	statsDMaxPacket = 1432
+	// statsDFlushInterval is how long metrics wait for a packet to fill
+	statsDFlushInterval = time.Second
+)
+
+// StatsD sends metrics to a StatsD agent over UDP, with tags in the
+// DogStatsD format. Metrics are batched into packets, sent when a packet
+// fills and every second. Delivery is best effort: send errors are
+// ignored, so a missing agent never slows down requests. All methods are
+// safe for concurrent use.
+type StatsD struct {
+	conn     net.Conn
+	prefix   string
+	tags     []string
+	mu       sync.Mutex
+	buf      []byte
+	stop     chan struct{}
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+// NewStatsD creates a client sending to the agent at addr, e.g.
+// localhost:8125. prefix is prepended to every metric name, e.g.
+// cat_server., and tags such as env:prod are added to every metric.
+func NewStatsD(addr, prefix string, tags []string) (*StatsD, error) {
+	conn, err := net.Dial("udp", addr)
+	if err != nil {
+		return nil, err
+	}
+	s := &StatsD{
+		conn:   conn,
+		prefix: prefix,
+		tags:   tags,
+		buf:    make([]byte, 0, statsDMaxPacket),
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+	go s.run()
+	return s, nil
+}
+
+// Count adds value to the named counter
+func (s *StatsD) Count(name string, value int64, tags ...string) {
+	s.send(name, strconv.FormatInt(value, 10), "c", tags)
+}
+
+// Timing records a duration in the named timer, in milliseconds
+func (s *StatsD) Timing(name string, d time.Duration, tags ...string) {
+	s.send(name, strconv.FormatFloat(float64(d)/float64(time.Millisecond), 'f', -1, 64), "ms", tags)
+}
+
+// send queues one metric line, e.g. cat_server.http.requests:1|c|#route:/ls
+func (s *StatsD) send(name, value, kind string, tags []string) {
+	var line strings.Builder
+	line.WriteString(s.prefix)
+	line.WriteString(name)
+	line.WriteByte(':')
+	line.WriteString(value)
+	line.WriteByte('|')
+	line.WriteString(kind)
+	for i, tag := range append(s.tags[:len(s.tags):len(s.tags)], tags...) {
+		if i == 0 {
+			line.WriteString("|#")
+		} else {
+			line.WriteByte(',')
+		}
+		line.WriteString(tag)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.buf) > 0 && len(s.buf)+1+line.Len() > statsDMaxPacket {
+		s.flushLocked()
+	}
+	if len(s.buf) > 0 {
+		s.buf = append(s.buf, '\n')
+	}
+	s.buf = append(s.buf, line.String()...)
+}
+
+// run flushes the buffer every statsDFlushInterval until Close
+func (s *StatsD) run() {
+	defer close(s.done)
+	ticker := time.NewTicker(statsDFlushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			s.flush()
+		case <-s.stop:
+			s.flush()
+			return
+		}
+	}
+}
+
+// flush sends the buffered metrics
+func (s *StatsD) flush() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.flushLocked()
+}
+
+func (s *StatsD) flushLocked() {
+	if len(s.buf) == 0 {
+		return
+	}
+	s.conn.Write(s.buf)
+	s.buf = s.buf[:0]
+}
+
+// Close sends the buffered metrics and closes the connection
+func (s *StatsD) Close() error {
+	s.stopOnce.Do(func() { close(s.stop) })
+	<-s.done
+	return s.conn.Close()
+}
@@ -0,0 +1,70 @@
+package metrics
+
+import (
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStatsD(t *testing.T) {
+	agent, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer agent.Close()
+
+	statsd, err := NewStatsD(agent.LocalAddr().String(), "cat_server.", []string{"env:test"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	statsd.Count("http.requests", 1, "method:GET", "status:200")
+	statsd.Timing("http.request_duration", 1500*time.Microsecond)
+	if err := statsd.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	packet := make([]byte, statsDMaxPacket)
+	agent.SetReadDeadline(time.Now().Add(5 * time.Second))
+	n, _, err := agent.ReadFrom(packet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "cat_server.http.requests:1|c|#env:test,method:GET,status:200\n" +
+		"cat_server.http.request_duration:1.5|ms|#env:test"
+	if got := string(packet[:n]); got != expected {
+		t.Errorf("Expected packet %q, got %q", expected, got)
+	}
+}
+
+func TestStatsDPacketSize(t *testing.T) {
+	agent, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer agent.Close()
+
+	statsd, err := NewStatsD(agent.LocalAddr().String(), "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	name := strings.Repeat("m", 100)
+	for range 30 {
+		statsd.Count(name, 1)
+	}
+	statsd.Close()
+
+	agent.SetReadDeadline(time.Now().Add(5 * time.Second))
+	lines := 0
+	for lines < 30 {
+		packet := make([]byte, 65536)
+		n, _, err := agent.ReadFrom(packet)
+		if err != nil {
+			t.Fatalf("Expected 30 metrics, got %d: %v", lines, err)
+		}
+		if n > statsDMaxPacket {
+			t.Errorf("Expected packets of at most %d bytes, got %d", statsDMaxPacket, n)
+		}
+		lines += strings.Count(string(packet[:n]), "\n") + 1
+	}
+}
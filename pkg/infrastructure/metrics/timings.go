@@ -0,0 +1,76 @@
+package metrics
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Phase is the time a request spent in one named phase, e.g. read
+type Phase struct {
+	Name     string
+	Duration time.Duration
+}
+
+// Timings accumulates the time a request spends in named phases such as
+// validate, stat, read and encode. A nil *Timings discards everything, so
+// code timing its phases need not check whether anyone is listening. All
+// methods are safe for concurrent use.
+type Timings struct {
+	mu     sync.Mutex
+	phases []Phase
+}
+
+// NewTimings creates an empty Timings
+func NewTimings() *Timings {
+	return &Timings{}
+}
+
+// Add adds d to the named phase, in the order phases are first added
+func (t *Timings) Add(name string, d time.Duration) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for i := range t.phases {
+		if t.phases[i].Name == name {
+			t.phases[i].Duration += d
+			return
+		}
+	}
+	t.phases = append(t.phases, Phase{Name: name, Duration: d})
+}
+
+// Since adds the time since start to the named phase and returns the
+// current time, which starts the next phase
+func (t *Timings) Since(name string, start time.Time) time.Time {
+	now := time.Now()
+	t.Add(name, now.Sub(start))
+	return now
+}
+
+// Phases returns the recorded phases
+func (t *Timings) Phases() []Phase {
+	if t == nil {
+		return nil
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]Phase(nil), t.phases...)
+}
+
+// timingsContextKey is the context key for the Timings of a request
+type timingsContextKey struct{}
+
+// NewTimingsContext returns a copy of ctx that carries t
+func NewTimingsContext(ctx context.Context, t *Timings) context.Context {
+	return context.WithValue(ctx, timingsContextKey{}, t)
+}
+
+// TimingsFromContext returns the Timings stored by NewTimingsContext, or
+// nil when the request's phases are not timed
+func TimingsFromContext(ctx context.Context) *Timings {
+	t, _ := ctx.Value(timingsContextKey{}).(*Timings)
+	return t
+}
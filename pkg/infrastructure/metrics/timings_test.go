@@ -0,0 +1,32 @@
+package metrics
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestTimings(t *testing.T) {
+	timings := NewTimings()
+	timings.Add("stat", 2*time.Millisecond)
+	timings.Add("read", 5*time.Millisecond)
+	timings.Add("stat", 1*time.Millisecond)
+
+	expected := []Phase{{"stat", 3 * time.Millisecond}, {"read", 5 * time.Millisecond}}
+	if phases := timings.Phases(); !reflect.DeepEqual(phases, expected) {
+		t.Errorf("Expected %v, got %v", expected, phases)
+	}
+
+	ctx := NewTimingsContext(context.Background(), timings)
+	if TimingsFromContext(ctx) != timings {
+		t.Error("Expected the timings stored in the context")
+	}
+
+	// Untimed requests record nothing
+	var untimed *Timings = TimingsFromContext(context.Background())
+	untimed.Since("read", time.Now())
+	if untimed != nil || untimed.Phases() != nil {
+		t.Errorf("Expected no timings, got %v", untimed.Phases())
+	}
+}
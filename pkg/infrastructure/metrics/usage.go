@@ -0,0 +1,141 @@
+package metrics
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Usage limits
+const (
+	// MaxUsageKeys bounds the number of tracked keys; further keys are
+	// accounted under OverflowUsageKey
+	MaxUsageKeys     = 10000
+	OverflowUsageKey = "_other"
+)
+
+// UsageTracker accounts requests and bytes served per key (a user or client
+// address) and enforces an optional daily byte quota. Days start at midnight
+// UTC.
+type UsageTracker struct {
+	dailyByteQuota int64
+	now            func() time.Time
+
+	mu   sync.Mutex
+	keys map[string]*keyUsage
+}
+
+// keyUsage holds the counters of one key
+type keyUsage struct {
+	requests      int64
+	bytes         int64
+	day           time.Time
+	requestsToday int64
+	bytesToday    int64
+	lastSeen      time.Time
+}
+
+// UsageSnapshot is a point-in-time copy of a key's counters
+type UsageSnapshot struct {
+	Key           string    `json:"key"`
+	Requests      int64     `json:"requests"`
+	Bytes         int64     `json:"bytes"`
+	RequestsToday int64     `json:"requests_today"`
+	BytesToday    int64     `json:"bytes_today"`
+	LastSeen      time.Time `json:"last_seen"`
+}
+
+// NewUsageTracker creates a tracker. A dailyByteQuota of zero disables the quota.
+func NewUsageTracker(dailyByteQuota int64) *UsageTracker {
+	return &UsageTracker{
+		dailyByteQuota: dailyByteQuota,
+		now:            time.Now,
+		keys:           make(map[string]*keyUsage),
+	}
+}
+
+// DailyByteQuota returns the configured quota, zero when disabled
+func (u *UsageTracker) DailyByteQuota() int64 {
+	return u.dailyByteQuota
+}
+
+// today returns the start of the current UTC day
+func (u *UsageTracker) today() time.Time {
+	return u.now().UTC().Truncate(24 * time.Hour)
+}
+
+// usage returns the counters for key, rolling the daily counters over;
+// u.mu must be held
+func (u *UsageTracker) usage(key string, create bool) *keyUsage {
+	k, ok := u.keys[key]
+	if !ok {
+		if !create {
+			return nil
+		}
+		if len(u.keys) >= MaxUsageKeys && key != OverflowUsageKey {
+			return u.usage(OverflowUsageKey, true)
+		}
+		k = &keyUsage{}
+		u.keys[key] = k
+	}
+	if today := u.today(); !k.day.Equal(today) {
+		k.day = today
+		k.requestsToday = 0
+		k.bytesToday = 0
+	}
+	return k
+}
+
+// Record adds one request and the bytes served to key
+func (u *UsageTracker) Record(key string, bytes int64) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	k := u.usage(key, true)
+	k.requests++
+	k.bytes += bytes
+	k.requestsToday++
+	k.bytesToday += bytes
+	k.lastSeen = u.now()
+}
+
+// Remaining returns the bytes left in key's daily quota and when the quota
+// resets. ok is false when no quota is configured.
+func (u *UsageTracker) Remaining(key string) (remaining int64, reset time.Time, ok bool) {
+	if u.dailyByteQuota <= 0 {
+		return 0, time.Time{}, false
+	}
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	remaining = u.dailyByteQuota
+	if k := u.usage(key, false); k != nil {
+		remaining = max(u.dailyByteQuota-k.bytesToday, 0)
+	}
+	return remaining, u.today().Add(24 * time.Hour), true
+}
+
+// Snapshot returns the counters of all keys sorted by key
+func (u *UsageTracker) Snapshot() []UsageSnapshot {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	snapshots := make([]UsageSnapshot, 0, len(u.keys))
+	for key := range u.keys {
+		k := u.usage(key, false)
+		snapshots = append(snapshots, UsageSnapshot{
+			Key:           key,
+			Requests:      k.requests,
+			Bytes:         k.bytes,
+			RequestsToday: k.requestsToday,
+			BytesToday:    k.bytesToday,
+			LastSeen:      k.lastSeen,
+		})
+	}
+
+	sort.Slice(snapshots, func(i, j int) bool {
+		return snapshots[i].Key < snapshots[j].Key
+	})
+	return snapshots
+}
@@ -0,0 +1,70 @@
+package metrics
+
+import (
+	"testing"
+	"time"
+)
+
+func TestUsageTracker_Quota(t *testing.T) {
+	now := time.Date(2024, 3, 1, 23, 0, 0, 0, time.UTC)
+	usage := NewUsageTracker(1000)
+	usage.now = func() time.Time { return now }
+
+	if remaining, reset, ok := usage.Remaining("user:alice"); !ok || remaining != 1000 || !reset.Equal(time.Date(2024, 3, 2, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Expected full quota until midnight, got %d, %v, %v", remaining, reset, ok)
+	}
+
+	usage.Record("user:alice", 600)
+	usage.Record("user:alice", 600)
+	usage.Record("ip:192.0.2.1", 10)
+	if remaining, _, _ := usage.Remaining("user:alice"); remaining != 0 {
+		t.Errorf("Expected exhausted quota, got %d remaining", remaining)
+	}
+	if remaining, _, _ := usage.Remaining("ip:192.0.2.1"); remaining != 990 {
+		t.Errorf("Expected 990 bytes remaining, got %d", remaining)
+	}
+
+	// The daily counters reset at midnight UTC, the totals do not
+	now = now.Add(time.Hour)
+	if remaining, _, _ := usage.Remaining("user:alice"); remaining != 1000 {
+		t.Errorf("Expected quota to reset at midnight, got %d remaining", remaining)
+	}
+
+	snapshots := usage.Snapshot()
+	if len(snapshots) != 2 || snapshots[0].Key != "ip:192.0.2.1" || snapshots[1].Key != "user:alice" {
+		t.Fatalf("Expected snapshots sorted by key, got %+v", snapshots)
+	}
+	alice := snapshots[1]
+	if alice.Requests != 2 || alice.Bytes != 1200 || alice.RequestsToday != 0 || alice.BytesToday != 0 {
+		t.Errorf("Unexpected counters: %+v", alice)
+	}
+}
+
+func TestUsageTracker_NoQuota(t *testing.T) {
+	usage := NewUsageTracker(0)
+	usage.Record("user:alice", 1<<40)
+	if _, _, ok := usage.Remaining("user:alice"); ok {
+		t.Error("Expected no quota to be enforced")
+	}
+}
+
+func TestUsageTracker_Overflow(t *testing.T) {
+	usage := NewUsageTracker(0)
+	for i := 0; i < MaxUsageKeys; i++ {
+		usage.Record(string(rune('a'+i%26))+time.Duration(i).String(), 1)
+	}
+	usage.Record("late", 5)
+
+	snapshots := usage.Snapshot()
+	if len(snapshots) != MaxUsageKeys+1 {
+		t.Fatalf("Expected %d keys, got %d", MaxUsageKeys+1, len(snapshots))
+	}
+	for _, s := range snapshots {
+		if s.Key == "late" {
+			t.Fatal("Expected keys beyond the limit not to be tracked individually")
+		}
+		if s.Key == OverflowUsageKey && s.Bytes != 5 {
+			t.Errorf("Expected overflow key to hold 5 bytes, got %d", s.Bytes)
+		}
+	}
+}
@@ -0,0 +1,27 @@
+// Package sandbox confines the running process with Landlock and seccomp so
+// that a compromised handler can neither read outside the served directory
+// nor start programs or alter the system.
+package sandbox
+
+import "errors"
+
+// ErrUnsupported is returned when the platform, kernel or build cannot
+// apply the sandbox
+var ErrUnsupported = errors.New("sandbox: not supported")
+
+// Policy lists the paths the process may still use once sandboxed. Paths
+// may be files or directories; directory rules cover everything beneath.
+type Policy struct {
+	// ReadOnly paths may be read and, for directories, listed
+	ReadOnly []string
+	// ReadWrite directories may also have files created, written and removed
+	ReadWrite []string
+}
+
+// Status reports what Apply enforced
+type Status struct {
+	// LandlockABI is the Landlock ABI version in use
+	LandlockABI int
+	// DeniedSyscalls is the number of system calls blocked by seccomp
+	DeniedSyscalls int
+}
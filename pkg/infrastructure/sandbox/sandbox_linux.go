@@ -0,0 +1,261 @@
+package sandbox
+
+import (
+	"errors"
+	"fmt"
+	"syscall"
+	"unsafe"
+)
+
+// Landlock system calls are numbered the same on every architecture
+const (
+	sysLandlockCreateRuleset = 444
+	sysLandlockAddRule       = 445
+	sysLandlockRestrictSelf  = 446
+
+	landlockCreateRulesetVersion = 1
+	landlockRulePathBeneath      = 1
+
+	// oPath is O_PATH, which package syscall does not define
+	oPath = 0x200000
+)
+
+// Landlock filesystem access rights (linux/landlock.h)
+const (
+	accessExecute    = 1 << 0
+	accessWriteFile  = 1 << 1
+	accessReadFile   = 1 << 2
+	accessReadDir    = 1 << 3
+	accessRemoveDir  = 1 << 4
+	accessRemoveFile = 1 << 5
+	accessMakeChar   = 1 << 6
+	accessMakeDir    = 1 << 7
+	accessMakeReg    = 1 << 8
+	accessMakeSock   = 1 << 9
+	accessMakeFifo   = 1 << 10
+	accessMakeBlock  = 1 << 11
+	accessMakeSym    = 1 << 12
+	accessRefer      = 1 << 13 // ABI 2
+	accessTruncate   = 1 << 14 // ABI 3
+	accessIoctlDev   = 1 << 15 // ABI 5
+
+	// accessFile are the rights that apply to files rather than directories
+	accessFile = accessExecute | accessWriteFile | accessReadFile | accessTruncate | accessIoctlDev
+
+	accessRead      = accessReadFile | accessReadDir
+	accessReadWrite = accessRead | accessWriteFile | accessTruncate | accessMakeReg | accessMakeDir |
+		accessRemoveFile | accessRemoveDir
+)
+
+// seccomp constants (linux/seccomp.h, linux/filter.h)
+const (
+	prSetNoNewPrivs = 38
+
+	seccompSetModeFilter      = 1
+	seccompFilterFlagTsync    = 1
+	seccompRetKillProcess     = 0x80000000
+	seccompRetErrno           = 0x00050000
+	seccompRetAllow           = 0x7fff0000
+	seccompDataNrOffset       = 0
+	seccompDataArchOffset     = 4
+	bpfLdWAbs                 = 0x20
+	bpfJmpJeqK                = 0x15
+	bpfJmpJgeK                = 0x35
+	bpfRetK                   = 0x06
+	maxSeccompFilterJumpRange = 255
+)
+
+// commonDeniedSyscalls were added after syscall numbers were unified across
+// architectures: io_uring, which bypasses seccomp for the operations it
+// performs, and the new mount API
+var commonDeniedSyscalls = []uint32{
+	425, 426, 427, // io_uring_setup, io_uring_enter, io_uring_register
+	428, 429, 430, 431, 432, 433, 442, // open_tree, move_mount, fsopen, fsconfig, fsmount, fspick, mount_setattr
+}
+
+// Apply restricts the process for the rest of its life: Landlock limits
+// filesystem access to the paths in policy, and a seccomp filter makes
+// system calls for running programs, tracing processes, mounting, loading
+// kernel modules and similar administration fail with EPERM. Network access
+// is not restricted.
+//
+// Both restrictions cover every thread. Applying Landlock to all threads
+// needs syscall.AllThreadsSyscall, which is unavailable when cgo is linked,
+// so the server must be built with CGO_ENABLED=0.
+func Apply(policy Policy) (Status, error) {
+	if auditArch == 0 {
+		return Status{}, fmt.Errorf("%w: no seccomp system call table for this architecture", ErrUnsupported)
+	}
+
+	abi, err := landlockABI()
+	if err != nil {
+		return Status{}, err
+	}
+	ruleset, err := landlockRuleset(abi, policy)
+	if err != nil {
+		return Status{}, err
+	}
+	defer syscall.Close(ruleset)
+
+	if _, _, errno := syscall.AllThreadsSyscall(syscall.SYS_PRCTL, prSetNoNewPrivs, 1, 0); errno != 0 {
+		if errno == syscall.ENOTSUP {
+			return Status{}, fmt.Errorf("%w: build without cgo to sandbox all threads", ErrUnsupported)
+		}
+		return Status{}, fmt.Errorf("sandbox: setting no_new_privs: %w", errno)
+	}
+	if _, _, errno := syscall.AllThreadsSyscall(sysLandlockRestrictSelf, uintptr(ruleset), 0, 0); errno != 0 {
+		return Status{}, fmt.Errorf("sandbox: enforcing landlock ruleset: %w", errno)
+	}
+
+	denied := append(append([]uint32(nil), deniedSyscalls...), commonDeniedSyscalls...)
+	if err := installSeccomp(denied); err != nil {
+		return Status{}, err
+	}
+
+	return Status{LandlockABI: abi, DeniedSyscalls: len(denied)}, nil
+}
+
+// landlockABI returns the kernel's Landlock ABI version
+func landlockABI() (int, error) {
+	abi, _, errno := syscall.Syscall(sysLandlockCreateRuleset, 0, 0, landlockCreateRulesetVersion)
+	switch errno {
+	case 0:
+		return int(abi), nil
+	case syscall.ENOSYS, syscall.EOPNOTSUPP:
+		return 0, fmt.Errorf("%w: landlock is not available in this kernel", ErrUnsupported)
+	default:
+		return 0, fmt.Errorf("sandbox: querying landlock: %w", errno)
+	}
+}
+
+// handledAccess returns the filesystem rights known to a Landlock ABI, all
+// of which are denied unless a rule grants them
+func handledAccess(abi int) uint64 {
+	access := uint64(accessMakeSym<<1 - 1)
+	if abi >= 2 {
+		access |= accessRefer
+	}
+	if abi >= 3 {
+		access |= accessTruncate
+	}
+	if abi >= 5 {
+		access |= accessIoctlDev
+	}
+	return access
+}
+
+// landlockRuleset creates a ruleset granting the policy's paths
+func landlockRuleset(abi int, policy Policy) (int, error) {
+	handled := handledAccess(abi)
+	attr := struct{ handledAccessFS uint64 }{handled}
+	fd, _, errno := syscall.Syscall(sysLandlockCreateRuleset, uintptr(unsafe.Pointer(&attr)), unsafe.Sizeof(attr), 0)
+	if errno != 0 {
+		return -1, fmt.Errorf("sandbox: creating landlock ruleset: %w", errno)
+	}
+
+	rules := []struct {
+		paths  []string
+		access uint64
+	}{
+		{policy.ReadOnly, accessRead},
+		{policy.ReadWrite, accessReadWrite},
+	}
+	for _, rule := range rules {
+		for _, path := range rule.paths {
+			if err := addPathRule(int(fd), path, rule.access&handled); err != nil {
+				syscall.Close(int(fd))
+				return -1, err
+			}
+		}
+	}
+	return int(fd), nil
+}
+
+// addPathRule grants access beneath path
+func addPathRule(ruleset int, path string, access uint64) error {
+	fd, err := syscall.Open(path, oPath|syscall.O_CLOEXEC, 0)
+	if err != nil {
+		return fmt.Errorf("sandbox: opening %s: %w", path, err)
+	}
+	defer syscall.Close(fd)
+
+	var stat syscall.Stat_t
+	if err := syscall.Fstat(fd, &stat); err != nil {
+		return fmt.Errorf("sandbox: stat %s: %w", path, err)
+	}
+	if stat.Mode&syscall.S_IFMT != syscall.S_IFDIR {
+		access &= accessFile
+	}
+
+	// struct landlock_path_beneath_attr is packed: u64 allowed_access, s32 parent_fd
+	var attr [12]byte
+	*(*uint64)(unsafe.Pointer(&attr[0])) = access
+	*(*int32)(unsafe.Pointer(&attr[8])) = int32(fd)
+	if _, _, errno := syscall.Syscall6(sysLandlockAddRule, uintptr(ruleset), landlockRulePathBeneath,
+		uintptr(unsafe.Pointer(&attr[0])), 0, 0, 0); errno != 0 {
+		return fmt.Errorf("sandbox: adding landlock rule for %s: %w", path, errno)
+	}
+	return nil
+}
+
+// sockFilter is struct sock_filter
+type sockFilter struct {
+	code uint16
+	jt   uint8
+	jf   uint8
+	k    uint32
+}
+
+// sockFprog is struct sock_fprog
+type sockFprog struct {
+	len    uint16
+	filter *sockFilter
+}
+
+// seccompFilter builds a BPF program that kills the process on a foreign
+// architecture, fails the denied system calls with EPERM and allows the rest
+func seccompFilter(denied []uint32) ([]sockFilter, error) {
+	if len(denied)+1 > maxSeccompFilterJumpRange {
+		return nil, errors.New("sandbox: too many denied system calls")
+	}
+
+	// Every check jumps over the remaining checks and the allow return to
+	// reach the EPERM return
+	checks := make([]sockFilter, 0, len(denied)+1)
+	if syscallNrLimit != 0 {
+		checks = append(checks, sockFilter{code: bpfJmpJgeK, k: syscallNrLimit})
+	}
+	for _, nr := range denied {
+		checks = append(checks, sockFilter{code: bpfJmpJeqK, k: nr})
+	}
+	for i := range checks {
+		checks[i].jt = uint8(len(checks) - i)
+	}
+
+	filter := []sockFilter{
+		{code: bpfLdWAbs, k: seccompDataArchOffset},
+		{code: bpfJmpJeqK, jt: 1, k: auditArch},
+		{code: bpfRetK, k: seccompRetKillProcess},
+		{code: bpfLdWAbs, k: seccompDataNrOffset},
+	}
+	filter = append(filter, checks...)
+	filter = append(filter,
+		sockFilter{code: bpfRetK, k: seccompRetAllow},
+		sockFilter{code: bpfRetK, k: seccompRetErrno | uint32(syscall.EPERM)},
+	)
+	return filter, nil
+}
+
+// installSeccomp installs the filter on every thread
+func installSeccomp(denied []uint32) error {
+	filter, err := seccompFilter(denied)
+	if err != nil {
+		return err
+	}
+	prog := sockFprog{len: uint16(len(filter)), filter: &filter[0]}
+	if _, _, errno := syscall.Syscall(seccompSyscall, seccompSetModeFilter, seccompFilterFlagTsync,
+		uintptr(unsafe.Pointer(&prog))); errno != 0 {
+		return fmt.Errorf("sandbox: installing seccomp filter: %w", errno)
+	}
+	return nil
+}
@@ -0,0 +1,184 @@
+package sandbox
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"syscall"
+	"testing"
+)
+
+// runFilter evaluates the subset of classic BPF emitted by seccompFilter
+func runFilter(t *testing.T, filter []sockFilter, arch, nr uint32) uint32 {
+	t.Helper()
+
+	var acc uint32
+	for pc := 0; pc < len(filter); pc++ {
+		ins := filter[pc]
+		switch ins.code {
+		case bpfLdWAbs:
+			switch ins.k {
+			case seccompDataNrOffset:
+				acc = nr
+			case seccompDataArchOffset:
+				acc = arch
+			default:
+				t.Fatalf("unexpected load offset %d", ins.k)
+			}
+		case bpfJmpJeqK:
+			if acc == ins.k {
+				pc += int(ins.jt)
+			} else {
+				pc += int(ins.jf)
+			}
+		case bpfJmpJgeK:
+			if acc >= ins.k {
+				pc += int(ins.jt)
+			} else {
+				pc += int(ins.jf)
+			}
+		case bpfRetK:
+			return ins.k
+		default:
+			t.Fatalf("unexpected instruction %#x", ins.code)
+		}
+	}
+	t.Fatal("filter fell off the end")
+	return 0
+}
+
+func TestSeccompFilter(t *testing.T) {
+	if auditArch == 0 {
+		t.Skip("no seccomp table for this architecture")
+	}
+
+	denied := append(append([]uint32(nil), deniedSyscalls...), commonDeniedSyscalls...)
+	filter, err := seccompFilter(denied)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type filterTest struct {
+		name string
+		arch uint32
+		nr   uint32
+		want uint32
+	}
+	eperm := uint32(seccompRetErrno | uint32(syscall.EPERM))
+	tests := []filterTest{
+		{"read", auditArch, uint32(syscall.SYS_READ), seccompRetAllow},
+		{"openat", auditArch, uint32(syscall.SYS_OPENAT), seccompRetAllow},
+		{"execve", auditArch, uint32(syscall.SYS_EXECVE), eperm},
+		{"ptrace", auditArch, uint32(syscall.SYS_PTRACE), eperm},
+		{"io_uring_setup", auditArch, 425, eperm},
+		{"foreign architecture", 0x40000003, uint32(syscall.SYS_READ), seccompRetKillProcess},
+	}
+	for _, nr := range denied {
+		tests = append(tests, filterTest{"denied", auditArch, nr, eperm})
+	}
+	if syscallNrLimit != 0 {
+		tests = append(tests, filterTest{"above limit", auditArch, syscallNrLimit + uint32(syscall.SYS_READ), eperm})
+	}
+
+	for _, tt := range tests {
+		if got := runFilter(t, filter, tt.arch, tt.nr); got != tt.want {
+			t.Errorf("%s (%d): expected %#x, got %#x", tt.name, tt.nr, tt.want, got)
+		}
+	}
+}
+
+func TestHandledAccess(t *testing.T) {
+	tests := []struct {
+		abi  int
+		want uint64
+	}{
+		{1, 0x1fff},
+		{2, 0x3fff},
+		{3, 0x7fff},
+		{4, 0x7fff},
+		{5, 0xffff},
+	}
+	for _, tt := range tests {
+		if got := handledAccess(tt.abi); got != tt.want {
+			t.Errorf("handledAccess(%d) = %#x, expected %#x", tt.abi, got, tt.want)
+		}
+	}
+}
+
+// sandboxChildEnv makes the test binary act as the sandboxed child of
+// TestApply, since the sandbox cannot be lifted once applied
+const sandboxChildEnv = "CAT_SERVER_SANDBOX_TEST_DIR"
+
+func TestApply(t *testing.T) {
+	if dir := os.Getenv(sandboxChildEnv); dir != "" {
+		sandboxChild(dir)
+		return
+	}
+
+	dir := t.TempDir()
+	for _, sub := range []string{"served", "writable", "outside"} {
+		if err := os.Mkdir(filepath.Join(dir, sub), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, sub, "file.txt"), []byte("content\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cmd := exec.Command(os.Args[0], "-test.run=^TestApply$")
+	cmd.Env = append(os.Environ(), sandboxChildEnv+"="+dir)
+	out, err := cmd.CombinedOutput()
+	switch {
+	case cmd.ProcessState != nil && cmd.ProcessState.ExitCode() == 2:
+		t.Skipf("sandbox not supported: %s", out)
+	case err != nil:
+		t.Fatalf("sandboxed child failed: %v\n%s", err, out)
+	}
+}
+
+// sandboxChild applies the sandbox and checks what it allows, exiting with
+// 2 when unsupported and 1 on failure
+func sandboxChild(dir string) {
+	fail := func(format string, args ...any) {
+		fmt.Fprintf(os.Stderr, format+"\n", args...)
+		os.Exit(1)
+	}
+
+	_, err := Apply(Policy{
+		ReadOnly:  []string{filepath.Join(dir, "served")},
+		ReadWrite: []string{filepath.Join(dir, "writable")},
+	})
+	if errors.Is(err, ErrUnsupported) {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	if err != nil {
+		fail("Apply: %v", err)
+	}
+
+	if _, err := os.ReadFile(filepath.Join(dir, "served", "file.txt")); err != nil {
+		fail("reading served file: %v", err)
+	}
+	if _, err := os.ReadDir(filepath.Join(dir, "served")); err != nil {
+		fail("listing served directory: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "writable", "new.txt"), []byte("x"), 0600); err != nil {
+		fail("writing writable directory: %v", err)
+	}
+	if err := os.Remove(filepath.Join(dir, "writable", "new.txt")); err != nil {
+		fail("removing from writable directory: %v", err)
+	}
+
+	if _, err := os.ReadFile(filepath.Join(dir, "outside", "file.txt")); !errors.Is(err, os.ErrPermission) {
+		fail("expected reading outside the policy to be denied, got %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "served", "new.txt"), []byte("x"), 0600); !errors.Is(err, os.ErrPermission) {
+		fail("expected writing the read-only directory to be denied, got %v", err)
+	}
+	if err := syscall.Exec("/bin/true", []string{"true"}, nil); err != syscall.EPERM {
+		fail("expected exec to fail with EPERM, got %v", err)
+	}
+	os.Exit(0)
+}
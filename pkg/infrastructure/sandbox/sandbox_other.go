@@ -0,0 +1,8 @@
+//go:build !linux
+
+package sandbox
+
+// Apply is only implemented on Linux
+func Apply(policy Policy) (Status, error) {
+	return Status{}, ErrUnsupported
+}
@@ -0,0 +1,23 @@
+package sandbox
+
+const (
+	// auditArch is AUDIT_ARCH_X86_64
+	auditArch = 0xc000003e
+	// syscallNrLimit rejects x32 system calls, which set __X32_SYSCALL_BIT
+	syscallNrLimit = 0x40000000
+	seccompSyscall = 317
+)
+
+// deniedSyscalls are the amd64 numbers of the system calls the sandbox blocks
+var deniedSyscalls = []uint32{
+	57, 58, 59, 322, // fork, vfork, execve, execveat
+	101, 310, 311, // ptrace, process_vm_readv, process_vm_writev
+	165, 166, 155, 161, 272, 308, // mount, umount2, pivot_root, chroot, unshare, setns
+	169, 246, 320, // reboot, kexec_load, kexec_file_load
+	175, 313, 176, // init_module, finit_module, delete_module
+	321, 298, 323, // bpf, perf_event_open, userfaultfd
+	250, 248, 249, // keyctl, add_key, request_key
+	167, 168, 163, // swapon, swapoff, acct
+	164, 227, 170, 171, // settimeofday, clock_settime, sethostname, setdomainname
+	303, 304, 135, // name_to_handle_at, open_by_handle_at, personality
+}
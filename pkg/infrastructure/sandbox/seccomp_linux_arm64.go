@@ -0,0 +1,22 @@
+package sandbox
+
+const (
+	// auditArch is AUDIT_ARCH_AARCH64
+	auditArch      = 0xc00000b7
+	syscallNrLimit = 0
+	seccompSyscall = 277
+)
+
+// deniedSyscalls are the arm64 numbers of the system calls the sandbox blocks
+var deniedSyscalls = []uint32{
+	221, 281, // execve, execveat
+	117, 270, 271, // ptrace, process_vm_readv, process_vm_writev
+	40, 39, 41, 51, 97, 268, // mount, umount2, pivot_root, chroot, unshare, setns
+	142, 104, 294, // reboot, kexec_load, kexec_file_load
+	105, 273, 106, // init_module, finit_module, delete_module
+	280, 241, 282, // bpf, perf_event_open, userfaultfd
+	219, 217, 218, // keyctl, add_key, request_key
+	224, 225, 89, // swapon, swapoff, acct
+	170, 112, 161, 162, // settimeofday, clock_settime, sethostname, setdomainname
+	264, 265, 92, // name_to_handle_at, open_by_handle_at, personality
+}
@@ -0,0 +1,13 @@
+//go:build linux && !amd64 && !arm64
+
+package sandbox
+
+// Seccomp filters need per-architecture system call numbers; only amd64 and
+// arm64 are provided, and Apply reports ErrUnsupported elsewhere
+const (
+	auditArch      = 0
+	syscallNrLimit = 0
+	seccompSyscall = 0
+)
+
+var deniedSyscalls []uint32
@@ -0,0 +1,171 @@
+package signing
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Query parameter names used by signed URLs
+const (
+	ParamKeyID     = "kid"
+	ParamExpires   = "expires"
+	ParamSignature = "sig"
+)
+
+var (
+	// ErrMissingSignature is returned when a URL carries no signature parameters
+	ErrMissingSignature = errors.New("missing signature")
+	// ErrUnknownKey is returned when the signature references an unknown key ID
+	ErrUnknownKey = errors.New("unknown signing key")
+	// ErrExpired is returned when the URL expired beyond the allowed clock skew
+	ErrExpired = errors.New("signed url expired")
+	// ErrInvalidSignature is returned when the signature does not match
+	ErrInvalidSignature = errors.New("invalid signature")
+)
+
+// Signer creates and verifies HMAC-signed URLs. Multiple keys can be active at
+// once so secrets can be rotated without invalidating outstanding links: new
+// URLs are signed with the current key while verification accepts any key
+// still registered.
+type Signer struct {
+	mu         sync.RWMutex
+	keys       map[string][]byte
+	currentKID string
+	clockSkew  time.Duration
+	now        func() time.Time
+}
+
+// NewSigner creates a Signer that tolerates the given clock skew when
+// checking expiry
+func NewSigner(clockSkew time.Duration) *Signer {
+	return &Signer{
+		keys:      make(map[string][]byte),
+		clockSkew: clockSkew,
+		now:       time.Now,
+	}
+}
+
+// SetClock overrides the time source (useful for testing)
+func (s *Signer) SetClock(now func() time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.now = now
+}
+
+// AddKey registers a signing key. The first key added becomes current.
+func (s *Signer) AddKey(kid string, secret []byte) error {
+	if kid == "" {
+		return errors.New("key id cannot be empty")
+	}
+	if len(secret) == 0 {
+		return errors.New("signing secret cannot be empty")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.keys[kid] = append([]byte(nil), secret...)
+	if s.currentKID == "" {
+		s.currentKID = kid
+	}
+	return nil
+}
+
+// SetCurrentKey selects the key used to sign new URLs
+func (s *Signer) SetCurrentKey(kid string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.keys[kid]; !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownKey, kid)
+	}
+	s.currentKID = kid
+	return nil
+}
+
+// RemoveKey retires a key; URLs signed with it no longer verify
+func (s *Signer) RemoveKey(kid string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.keys, kid)
+	if s.currentKID == kid {
+		s.currentKID = ""
+	}
+}
+
+// Sign returns query with the parameters added that authorize access to path
+// until ttl has elapsed. The signature covers the other query parameters, so
+// a signed link cannot be reused with a different query.
+func (s *Signer) Sign(path string, query url.Values, ttl time.Duration) (url.Values, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.currentKID == "" {
+		return nil, errors.New("no current signing key")
+	}
+
+	expires := s.now().Add(ttl).Unix()
+	values := url.Values{}
+	for name, vals := range query {
+		values[name] = append([]string(nil), vals...)
+	}
+	values.Set(ParamKeyID, s.currentKID)
+	values.Set(ParamExpires, strconv.FormatInt(expires, 10))
+	values.Set(ParamSignature, s.signature(s.keys[s.currentKID], s.currentKID, path, values, expires))
+	return values, nil
+}
+
+// Verify checks that the signature parameters in query authorize path and
+// the rest of query
+func (s *Signer) Verify(path string, query url.Values) error {
+	kid := query.Get(ParamKeyID)
+	expiresStr := query.Get(ParamExpires)
+	sig := query.Get(ParamSignature)
+	if kid == "" || expiresStr == "" || sig == "" {
+		return ErrMissingSignature
+	}
+
+	expires, err := strconv.ParseInt(expiresStr, 10, 64)
+	if err != nil {
+		return fmt.Errorf("%w: bad expiry", ErrInvalidSignature)
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	secret, ok := s.keys[kid]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownKey, kid)
+	}
+
+	expected := s.signature(secret, kid, path, query, expires)
+	if !hmac.Equal([]byte(expected), []byte(sig)) {
+		return ErrInvalidSignature
+	}
+
+	if s.now().Add(-s.clockSkew).After(time.Unix(expires, 0)) {
+		return ErrExpired
+	}
+
+	return nil
+}
+
+// signature computes the MAC of kid, path, expires and the query parameters
+// other than the signature ones, in the sorted order of url.Values.Encode
+func (s *Signer) signature(secret []byte, kid, path string, query url.Values, expires int64) string {
+	signed := url.Values{}
+	for name, vals := range query {
+		if name != ParamKeyID && name != ParamExpires && name != ParamSignature {
+			signed[name] = vals
+		}
+	}
+
+	mac := hmac.New(sha256.New, secret)
+	fmt.Fprintf(mac, "%s\n%s\n%d\n%s", kid, path, expires, signed.Encode())
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,110 @@
+package signing
+
+import (
+	"errors"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestSigner_SignAndVerify(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	s := NewSigner(30 * time.Second)
+	s.SetClock(func() time.Time { return now })
+	if err := s.AddKey("k1", []byte("secret-one")); err != nil {
+		t.Fatalf("AddKey failed: %v", err)
+	}
+
+	query, err := s.Sign("/cat/report.txt", nil, time.Minute)
+	if err != nil {
+		t.Fatalf("Sign failed: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		path    string
+		at      time.Time
+		wantErr error
+	}{
+		{"valid", "/cat/report.txt", now, nil},
+		{"within clock skew", "/cat/report.txt", now.Add(80 * time.Second), nil},
+		{"expired beyond skew", "/cat/report.txt", now.Add(2 * time.Minute), ErrExpired},
+		{"different path", "/cat/other.txt", now, ErrInvalidSignature},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s.SetClock(func() time.Time { return tt.at })
+			err := s.Verify(tt.path, query)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Expected %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestSigner_KeyRotation(t *testing.T) {
+	s := NewSigner(0)
+	s.AddKey("old", []byte("old-secret"))
+	oldQuery, _ := s.Sign("/cat/a", nil, time.Hour)
+
+	s.AddKey("new", []byte("new-secret"))
+	if err := s.SetCurrentKey("new"); err != nil {
+		t.Fatalf("SetCurrentKey failed: %v", err)
+	}
+	newQuery, _ := s.Sign("/cat/a", nil, time.Hour)
+
+	if newQuery.Get(ParamKeyID) != "new" {
+		t.Errorf("Expected new URLs to use key 'new', got %q", newQuery.Get(ParamKeyID))
+	}
+	if err := s.Verify("/cat/a", oldQuery); err != nil {
+		t.Errorf("Expected old link to remain valid during rotation, got %v", err)
+	}
+
+	s.RemoveKey("old")
+	if err := s.Verify("/cat/a", oldQuery); !errors.Is(err, ErrUnknownKey) {
+		t.Errorf("Expected ErrUnknownKey after retiring key, got %v", err)
+	}
+	if err := s.Verify("/cat/a", newQuery); err != nil {
+		t.Errorf("Expected new link to verify, got %v", err)
+	}
+}
+
+func TestSigner_Query(t *testing.T) {
+	s := NewSigner(0)
+	s.AddKey("k1", []byte("secret-one"))
+
+	signed, err := s.Sign("/search/logs", url.Values{"q": {"error"}, "limit": {"10"}}, time.Hour)
+	if err != nil {
+		t.Fatalf("Sign failed: %v", err)
+	}
+	if signed.Get("q") != "error" {
+		t.Errorf("Expected the signed query to keep q, got %q", signed.Get("q"))
+	}
+
+	tests := []struct {
+		name    string
+		modify  func(url.Values)
+		wantErr error
+	}{
+		{"unchanged", func(url.Values) {}, nil},
+		{"changed value", func(q url.Values) { q.Set("q", "secret") }, ErrInvalidSignature},
+		{"added parameter", func(q url.Values) { q.Set("include_hidden", "true") }, ErrInvalidSignature},
+		{"removed parameter", func(q url.Values) { q.Del("limit") }, ErrInvalidSignature},
+		{"repeated parameter", func(q url.Values) { q.Add("q", "other") }, ErrInvalidSignature},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Round trip through the encoded form, as a request would
+			query, err := url.ParseQuery(signed.Encode())
+			if err != nil {
+				t.Fatal(err)
+			}
+			tt.modify(query)
+			if err := s.Verify("/search/logs", query); !errors.Is(err, tt.wantErr) {
+				t.Errorf("Expected %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,173 @@
+package watcher
+
+import (
+	"encoding/binary"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+)
+
+// notifyMask selects the inotify events of a watched directory: changes to
+// its entries and its own removal
+const notifyMask = syscall.IN_CREATE | syscall.IN_DELETE | syscall.IN_MODIFY | syscall.IN_ATTRIB |
+	syscall.IN_MOVED_FROM | syscall.IN_MOVED_TO | syscall.IN_DELETE_SELF | syscall.IN_MOVE_SELF |
+	syscall.IN_ONLYDIR | syscall.IN_DONT_FOLLOW
+
+// inotifyEventSize is the size of struct inotify_event before the name
+const inotifyEventSize = syscall.SizeofInotifyEvent
+
+// notifier watches every directory of a tree with one inotify instance
+type notifier struct {
+	root string
+	fd   int      // the inotify instance; File.Fd would make file blocking
+	file *os.File // fd, closed to stop reading
+
+	mu   sync.Mutex
+	dirs map[int32]string // watch descriptor to relative directory path
+}
+
+// NewNotify watches root with inotify. It fails when a directory cannot be
+// watched, e.g. because fs.inotify.max_user_watches is exhausted.
+func NewNotify(root string) (*Watcher, error) {
+	root = filepath.Clean(root)
+	fd, err := syscall.InotifyInit1(syscall.IN_CLOEXEC | syscall.IN_NONBLOCK)
+	if err != nil {
+		return nil, os.NewSyscallError("inotify_init1", err)
+	}
+	// A non-blocking descriptor is read through the runtime poller, so
+	// closing the file interrupts a pending read
+	n := &notifier{root: root, fd: fd, file: os.NewFile(uintptr(fd), "inotify"), dirs: make(map[int32]string)}
+	if err := n.addTree("."); err != nil {
+		n.file.Close()
+		return nil, err
+	}
+
+	done := make(chan struct{})
+	w := &Watcher{mode: ModeNotify}
+	w.close = func() error {
+		err := n.file.Close()
+		<-done
+		return err
+	}
+	go func() {
+		defer close(done)
+		n.read(w.emit)
+	}()
+	return w, nil
+}
+
+// addTree watches dir, relative to the root, and the directories below it
+func (n *notifier) addTree(dir string) error {
+	return filepath.WalkDir(filepath.Join(n.root, filepath.FromSlash(dir)), func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			// Unreadable directories are skipped, as by the repository
+			if p != n.root {
+				return nil
+			}
+			return err
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(n.root, p)
+		if err != nil {
+			return err
+		}
+		wd, err := syscall.InotifyAddWatch(n.fd, p, notifyMask)
+		if err != nil {
+			if errors.Is(err, syscall.ENOENT) || errors.Is(err, syscall.EACCES) {
+				return nil
+			}
+			return os.NewSyscallError("inotify_add_watch", err)
+		}
+		n.mu.Lock()
+		n.dirs[int32(wd)] = filepath.ToSlash(rel)
+		n.mu.Unlock()
+		return nil
+	})
+}
+
+// removeTree stops watching dir and the directories below it, which were
+// moved away
+func (n *notifier) removeTree(dir string) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	for wd, p := range n.dirs {
+		if below(p, dir) {
+			syscall.InotifyRmWatch(n.fd, uint32(wd))
+			delete(n.dirs, wd)
+		}
+	}
+}
+
+// read delivers events until the inotify instance is closed
+func (n *notifier) read(emit func(Event)) {
+	buf := make([]byte, 64*1024)
+	for {
+		count, err := n.file.Read(buf)
+		if err != nil {
+			return
+		}
+		for offset := 0; offset+inotifyEventSize <= count; {
+			wd := int32(binary.NativeEndian.Uint32(buf[offset:]))
+			mask := binary.NativeEndian.Uint32(buf[offset+4:])
+			nameLen := int(binary.NativeEndian.Uint32(buf[offset+12:]))
+			name := strings.TrimRight(string(buf[offset+inotifyEventSize:offset+inotifyEventSize+nameLen]), "\x00")
+			offset += inotifyEventSize + nameLen
+
+			n.handle(wd, mask, name, emit)
+		}
+	}
+}
+
+// handle translates an inotify event, keeping the watches in step with
+// the directories of the tree
+func (n *notifier) handle(wd int32, mask uint32, name string, emit func(Event)) {
+	if mask&syscall.IN_Q_OVERFLOW != 0 {
+		emit(Event{Path: ".", Op: Overflow})
+		return
+	}
+
+	n.mu.Lock()
+	dir, ok := n.dirs[wd]
+	if mask&syscall.IN_IGNORED != 0 {
+		delete(n.dirs, wd)
+	}
+	n.mu.Unlock()
+	if !ok {
+		return
+	}
+
+	p := dir
+	if name != "" {
+		p = joinPath(dir, name)
+	}
+	isDir := mask&syscall.IN_ISDIR != 0
+
+	switch {
+	case mask&(syscall.IN_CREATE|syscall.IN_MOVED_TO) != 0:
+		if isDir {
+			// Entries created before the watch was added are reported by
+			// listing the new directory, so a failure here only loses
+			// later changes below it
+			if err := n.addTree(p); err != nil {
+				emit(Event{Path: p, Op: Overflow})
+			}
+		}
+		emit(Event{Path: p, Op: Create})
+	case mask&(syscall.IN_DELETE|syscall.IN_MOVED_FROM) != 0:
+		if isDir && mask&syscall.IN_MOVED_FROM != 0 {
+			n.removeTree(p)
+		}
+		emit(Event{Path: p, Op: Remove})
+	case mask&(syscall.IN_MODIFY|syscall.IN_ATTRIB) != 0:
+		emit(Event{Path: p, Op: Write})
+	case mask&(syscall.IN_DELETE_SELF|syscall.IN_MOVE_SELF) != 0 && dir == ".":
+		// Other directories are reported by their parents
+		emit(Event{Path: ".", Op: Remove})
+	}
+}
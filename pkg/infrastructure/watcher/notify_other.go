@@ -0,0 +1,11 @@
+//go:build !linux
+
+package watcher
+
+import "errors"
+
+// NewNotify watches root with inotify. It is only implemented on Linux;
+// use NewPoll elsewhere.
+func NewNotify(root string) (*Watcher, error) {
+	return nil, errors.ErrUnsupported
+}
@@ -0,0 +1,104 @@
+package watcher
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// fileState is what polling compares to detect changes
+type fileState struct {
+	modTime time.Time
+	size    int64
+	isDir   bool
+}
+
+// NewPoll watches root by walking the tree every interval and comparing
+// modification times and sizes. Each walk stats every entry, so large
+// trees need a long interval.
+func NewPoll(root string, interval time.Duration) (*Watcher, error) {
+	previous, err := snapshot(root)
+	if err != nil {
+		return nil, err
+	}
+
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	w := &Watcher{mode: ModePoll}
+	w.close = func() error {
+		close(stop)
+		<-done
+		return nil
+	}
+
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+			}
+
+			current, err := snapshot(root)
+			if err != nil {
+				// The root itself is gone
+				current = map[string]fileState{}
+			}
+			diff(previous, current, w.emit)
+			previous = current
+		}
+	}()
+	return w, nil
+}
+
+// snapshot records the state of every entry below root, by slash-separated
+// relative path. Entries that cannot be read are left out.
+func snapshot(root string) (map[string]fileState, error) {
+	if _, err := os.Stat(root); err != nil {
+		return nil, err
+	}
+
+	states := make(map[string]fileState)
+	filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		states[filepath.ToSlash(rel)] = fileState{modTime: info.ModTime(), size: info.Size(), isDir: d.IsDir()}
+		return nil
+	})
+	return states, nil
+}
+
+// diff reports the changes from previous to current, removals first as
+// for a rename with inotify
+func diff(previous, current map[string]fileState, emit func(Event)) {
+	for p := range previous {
+		if _, ok := current[p]; !ok {
+			emit(Event{Path: p, Op: Remove})
+		}
+	}
+	for p, state := range current {
+		old, ok := previous[p]
+		switch {
+		case !ok:
+			emit(Event{Path: p, Op: Create})
+		case old.isDir != state.isDir:
+			emit(Event{Path: p, Op: Remove})
+			emit(Event{Path: p, Op: Create})
+		case !old.modTime.Equal(state.modTime) || old.size != state.size:
+			emit(Event{Path: p, Op: Write})
+		}
+	}
+}
@@ -0,0 +1,118 @@
+// Package watcher reports changes below a directory tree, with inotify on
+// Linux or by polling, e.g. on network filesystems where inotify misses
+// changes made by other hosts
+package watcher
+
+import (
+	"strings"
+	"sync"
+)
+
+// Op is the kind of a change
+type Op uint8
+
+const (
+	// Create is reported for new files and directories, including ones
+	// moved into the tree
+	Create Op = iota + 1
+	// Write is reported when a file's content or metadata changes
+	Write
+	// Remove is reported for deleted files and directories, including
+	// ones moved out of the tree or renamed
+	Remove
+	// Overflow is reported when changes were lost, so anything below the
+	// event's path may have changed
+	Overflow
+)
+
+// String returns the name of op, e.g. "create"
+func (op Op) String() string {
+	switch op {
+	case Create:
+		return "create"
+	case Write:
+		return "write"
+	case Remove:
+		return "remove"
+	case Overflow:
+		return "overflow"
+	}
+	return "unknown"
+}
+
+// Modes of watching
+const (
+	ModeNotify = "inotify"
+	ModePoll   = "poll"
+)
+
+// Event is a change below the watched directory
+type Event struct {
+	// Path is relative to the watched directory, slash-separated, and "."
+	// for the directory itself
+	Path string
+	Op   Op
+}
+
+// Watcher reports changes below a directory to its subscribers
+type Watcher struct {
+	mode  string
+	close func() error
+
+	mu          sync.Mutex
+	subscribers []func(Event)
+	closed      bool
+}
+
+// Mode returns how changes are detected, ModeNotify or ModePoll
+func (w *Watcher) Mode() string {
+	return w.mode
+}
+
+// Subscribe calls fn with every change from now on. fn is called from the
+// watching goroutine, one event at a time, so it should return quickly.
+func (w *Watcher) Subscribe(fn func(Event)) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.subscribers = append(w.subscribers, fn)
+}
+
+// emit delivers e to the subscribers
+func (w *Watcher) emit(e Event) {
+	w.mu.Lock()
+	subscribers := w.subscribers
+	closed := w.closed
+	w.mu.Unlock()
+
+	if closed {
+		return
+	}
+	for _, fn := range subscribers {
+		fn(e)
+	}
+}
+
+// Close stops watching. No events are delivered once it returns.
+func (w *Watcher) Close() error {
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		return nil
+	}
+	w.closed = true
+	w.mu.Unlock()
+	return w.close()
+}
+
+// joinPath joins a slash-separated relative directory and a name
+func joinPath(dir, name string) string {
+	if dir == "." {
+		return name
+	}
+	return dir + "/" + name
+}
+
+// below reports whether p is dir or lies below it
+func below(p, dir string) bool {
+	return dir == "." || p == dir || strings.HasPrefix(p, dir+"/")
+}
@@ -0,0 +1,129 @@
+package watcher
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+)
+
+// recorder collects the events of a watcher
+type recorder struct {
+	events chan Event
+}
+
+func newRecorder(w *Watcher) *recorder {
+	r := &recorder{events: make(chan Event, 100)}
+	w.Subscribe(func(e Event) { r.events <- e })
+	return r
+}
+
+// expect waits for an event on p with op, skipping others
+func (r *recorder) expect(t *testing.T, p string, op Op) {
+	t.Helper()
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case e := <-r.events:
+			if e.Path == p && e.Op == op {
+				return
+			}
+		case <-timeout:
+			t.Fatalf("Timed out waiting for %s of %q", op, p)
+		}
+	}
+}
+
+func TestWatchers(t *testing.T) {
+	watchers := []struct {
+		name string
+		new  func(root string) (*Watcher, error)
+	}{
+		{ModePoll, func(root string) (*Watcher, error) { return NewPoll(root, 10*time.Millisecond) }},
+	}
+	if runtime.GOOS == "linux" {
+		watchers = append(watchers, struct {
+			name string
+			new  func(root string) (*Watcher, error)
+		}{ModeNotify, NewNotify})
+	}
+
+	for _, tt := range watchers {
+		t.Run(tt.name, func(t *testing.T) {
+			root := t.TempDir()
+			if err := os.MkdirAll(filepath.Join(root, "logs", "old"), 0755); err != nil {
+				t.Fatal(err)
+			}
+			w, err := tt.new(root)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer w.Close()
+			if w.Mode() != tt.name {
+				t.Errorf("Expected mode %q, got %q", tt.name, w.Mode())
+			}
+			r := newRecorder(w)
+
+			write := func(name, content string) {
+				if err := os.WriteFile(filepath.Join(root, filepath.FromSlash(name)), []byte(content), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			write("logs/old/app.log", "a")
+			r.expect(t, "logs/old/app.log", Create)
+
+			write("logs/old/app.log", "ab")
+			r.expect(t, "logs/old/app.log", Write)
+
+			if err := os.Rename(filepath.Join(root, "logs", "old"), filepath.Join(root, "archive")); err != nil {
+				t.Fatal(err)
+			}
+			r.expect(t, "logs/old", Remove)
+			r.expect(t, "archive", Create)
+
+			// Directories moved or created later are watched too
+			write("archive/new.log", "a")
+			r.expect(t, "archive/new.log", Create)
+
+			if err := os.Remove(filepath.Join(root, "archive", "new.log")); err != nil {
+				t.Fatal(err)
+			}
+			r.expect(t, "archive/new.log", Remove)
+		})
+	}
+}
+
+func TestWatcherClose(t *testing.T) {
+	root := t.TempDir()
+	w, err := NewPoll(root, time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := newRecorder(w)
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Errorf("Expected closing twice to succeed, got %v", err)
+	}
+
+	os.WriteFile(filepath.Join(root, "late.txt"), nil, 0644)
+	time.Sleep(20 * time.Millisecond)
+	if len(r.events) != 0 {
+		t.Errorf("Expected no events after Close, got %v", <-r.events)
+	}
+}
+
+func TestNewWatcherMissingRoot(t *testing.T) {
+	missing := filepath.Join(t.TempDir(), "missing")
+	if _, err := NewPoll(missing, time.Second); err == nil {
+		t.Error("Expected polling a missing directory to fail")
+	}
+	if runtime.GOOS == "linux" {
+		if _, err := NewNotify(missing); err == nil {
+			t.Error("Expected watching a missing directory to fail")
+		}
+	}
+}
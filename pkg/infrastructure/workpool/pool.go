@@ -0,0 +1,79 @@
+// Package workpool runs CPU and I/O heavy tasks, such as scanning or
+// hashing files, on a fixed set of goroutines shared by all requests
+package workpool
+
+import "sync"
+
+// Pool runs tasks on a fixed number of goroutines. Submitting blocks while
+// every goroutine is busy, so concurrent heavy requests queue instead of
+// each starting goroutines of their own. A nil Pool runs tasks in the
+// submitting goroutine.
+type Pool struct {
+	tasks chan func()
+	size  int
+	wg    sync.WaitGroup
+}
+
+// New starts a pool of size goroutines
+func New(size int) *Pool {
+	p := &Pool{tasks: make(chan func()), size: size}
+	p.wg.Add(size)
+	for range size {
+		go func() {
+			defer p.wg.Done()
+			for task := range p.tasks {
+				task()
+			}
+		}()
+	}
+	return p
+}
+
+// Size returns the number of goroutines, 0 for a nil pool
+func (p *Pool) Size() int {
+	if p == nil {
+		return 0
+	}
+	return p.size
+}
+
+// Close stops the goroutines once the submitted tasks are done. Nothing
+// may be submitted afterwards.
+func (p *Pool) Close() {
+	if p == nil {
+		return
+	}
+	close(p.tasks)
+	p.wg.Wait()
+}
+
+// Group returns a batch of tasks to be waited for together. Tasks must not
+// submit to the pool themselves, since they would wait for goroutines
+// their own batch may be holding.
+func (p *Pool) Group() *Group {
+	return &Group{pool: p}
+}
+
+// Group is a batch of tasks submitted to a pool
+type Group struct {
+	pool *Pool
+	wg   sync.WaitGroup
+}
+
+// Go runs task on the pool, waiting for a free goroutine
+func (g *Group) Go(task func()) {
+	if g.pool == nil {
+		task()
+		return
+	}
+	g.wg.Add(1)
+	g.pool.tasks <- func() {
+		defer g.wg.Done()
+		task()
+	}
+}
+
+// Wait waits for the tasks of the group to finish
+func (g *Group) Wait() {
+	g.wg.Wait()
+}
@@ -0,0 +1,63 @@
+package workpool
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestPoolBoundsConcurrency(t *testing.T) {
+	for _, size := range []int{1, 3} {
+		p := New(size)
+		var running, peak, done atomic.Int32
+
+		// Concurrent requests share the pool
+		var requests sync.WaitGroup
+		for range 4 {
+			requests.Add(1)
+			go func() {
+				defer requests.Done()
+				g := p.Group()
+				for range 5 {
+					g.Go(func() {
+						n := running.Add(1)
+						for {
+							old := peak.Load()
+							if n <= old || peak.CompareAndSwap(old, n) {
+								break
+							}
+						}
+						time.Sleep(time.Millisecond)
+						running.Add(-1)
+						done.Add(1)
+					})
+				}
+				g.Wait()
+			}()
+		}
+		requests.Wait()
+		p.Close()
+
+		if done.Load() != 20 {
+			t.Errorf("size %d: expected 20 tasks done, got %d", size, done.Load())
+		}
+		if peak.Load() > int32(size) {
+			t.Errorf("size %d: expected at most %d tasks at once, got %d", size, size, peak.Load())
+		}
+	}
+}
+
+func TestNilPool(t *testing.T) {
+	var p *Pool
+	g := p.Group()
+	ran := 0
+	for range 3 {
+		g.Go(func() { ran++ })
+	}
+	g.Wait()
+	p.Close()
+	if ran != 3 || p.Size() != 0 {
+		t.Errorf("Expected a nil pool to run tasks inline, ran %d", ran)
+	}
+}
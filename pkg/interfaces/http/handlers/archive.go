@@ -0,0 +1,144 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	"github.com/sh05/cat-server/pkg/application/services"
+	cathttp "github.com/sh05/cat-server/pkg/infrastructure/http"
+	"github.com/sh05/cat-server/pkg/infrastructure/logging"
+	"github.com/sh05/cat-server/pkg/server"
+)
+
+// maxArchiveRequestBody limits the size of POST /archive request bodies
+const maxArchiveRequestBody = 1024 * 1024 // 1MB
+
+// ArchiveService collects files and streams them as an archive
+type ArchiveService interface {
+	CollectDirectory(ctx context.Context, request *services.ArchiveDirectoryRequest) ([]services.ArchiveEntry, error)
+	CollectFiles(ctx context.Context, paths []string) ([]services.ArchiveEntry, error)
+	WriteArchive(ctx context.Context, w io.Writer, format string, entries []services.ArchiveEntry) error
+}
+
+// ArchiveHandler serves directory downloads on /archive/{dir} and
+// multi-file downloads on POST /archive
+type ArchiveHandler struct {
+	archives      Scope[ArchiveService]
+	includeHidden bool
+	logger        *logging.Logger
+}
+
+// NewArchiveHandler creates the archive handlers
+func NewArchiveHandler(archives Scope[ArchiveService], includeHidden bool, logger *logging.Logger) *ArchiveHandler {
+	return &ArchiveHandler{archives: archives, includeHidden: includeHidden, logger: logger}
+}
+
+// Register registers the handler's routes
+func (h *ArchiveHandler) Register(mux *server.Registry) {
+	mux.HandleFunc("GET /archive/{dir...}", h.Directory)
+	mux.Describe("GET /archive/{dir...}", server.RouteDoc{
+		Summary:  "Download a directory as an archive",
+		Query:    []server.Param{{Name: "format", Description: "tar.gz or zip"}, {Name: "exclude", Description: "Glob to leave out, repeatable"}},
+		Produces: []string{"application/gzip", "application/zip"},
+	})
+	mux.HandleFunc("POST /archive", h.Files)
+	mux.Describe("POST /archive", server.RouteDoc{
+		Summary:  "Download selected files as a zip archive",
+		Body:     "application/json",
+		Produces: []string{"application/zip"},
+	})
+}
+
+// Directory streams a directory as an archive, e.g.
+// /archive/logs?format=zip&exclude=*.tmp
+func (h *ArchiveHandler) Directory(w http.ResponseWriter, r *http.Request) {
+	dir := strings.Trim(r.PathValue("dir"), "/")
+	if dir == "" {
+		dir = "."
+	}
+
+	query := r.URL.Query()
+	format, err := services.NormalizeArchiveFormat(query.Get("format"))
+	if err != nil {
+		cathttp.WriteProblemCode(w, r, cathttp.CodeUnsupportedArchiveFormat, "")
+		return
+	}
+
+	reqLogger := logging.FromContext(r.Context(), h.logger)
+	service := h.archives(r, reqLogger)
+
+	entries, err := service.CollectDirectory(r.Context(), &services.ArchiveDirectoryRequest{
+		Path:          dir,
+		IncludeHidden: h.includeHidden,
+		Exclude:       query["exclude"],
+	})
+	if err != nil {
+		reqLogger.LogError(err, "failed to collect archive entries", "path", dir)
+		writeError(w, r, err)
+		return
+	}
+
+	name := filepath.Base(dir)
+	if name == "." {
+		name = "files"
+	}
+
+	w.Header().Set("Content-Type", archiveContentType(format))
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", name+"."+format))
+
+	// Headers are already sent, so failures can only be logged
+	if err := service.WriteArchive(r.Context(), w, format, entries); err != nil {
+		reqLogger.LogError(err, "failed to stream archive", "path", dir)
+	}
+}
+
+// Files streams selected files as a zip archive. The body is either a JSON
+// array of paths or an object with a "files" array.
+func (h *ArchiveHandler) Files(w http.ResponseWriter, r *http.Request) {
+	var body json.RawMessage
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxArchiveRequestBody)).Decode(&body); err != nil {
+		cathttp.WriteProblem(w, r, http.StatusBadRequest, "Invalid JSON body")
+		return
+	}
+
+	var request struct {
+		Files []string `json:"files"`
+	}
+	if err := json.Unmarshal(body, &request.Files); err != nil {
+		if err := json.Unmarshal(body, &request); err != nil {
+			cathttp.WriteProblem(w, r, http.StatusBadRequest, "Body must be a JSON array of paths or {\"files\": [...]}")
+			return
+		}
+	}
+
+	reqLogger := logging.FromContext(r.Context(), h.logger)
+	service := h.archives(r, reqLogger)
+
+	entries, err := service.CollectFiles(r.Context(), request.Files)
+	if err != nil {
+		reqLogger.LogError(err, "failed to collect archive files", "count", len(request.Files))
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", archiveContentType(services.ArchiveFormatZip))
+	w.Header().Set("Content-Disposition", `attachment; filename="files.zip"`)
+
+	// Headers are already sent, so failures can only be logged
+	if err := service.WriteArchive(r.Context(), w, services.ArchiveFormatZip, entries); err != nil {
+		reqLogger.LogError(err, "failed to stream archive", "count", len(entries))
+	}
+}
+
+// archiveContentType returns the MIME type for an archive format
+func archiveContentType(format string) string {
+	if format == services.ArchiveFormatZip {
+		return "application/zip"
+	}
+	return "application/gzip"
+}
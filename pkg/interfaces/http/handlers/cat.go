@@ -0,0 +1,213 @@
+package handlers
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/sh05/cat-server/pkg/application/services"
+	cathttp "github.com/sh05/cat-server/pkg/infrastructure/http"
+	"github.com/sh05/cat-server/pkg/infrastructure/logging"
+	"github.com/sh05/cat-server/pkg/infrastructure/metrics"
+	"github.com/sh05/cat-server/pkg/server"
+)
+
+// ContentService reads file contents for /cat
+type ContentService interface {
+	ReadFile(ctx context.Context, request *services.ReadFileRequest) (*services.ReadFileResponse, error)
+	ConvertToJSON(ctx context.Context, filename string) ([]byte, error)
+	ExtractColumns(ctx context.Context, request *services.ExtractColumnsRequest, w io.Writer) error
+	OpenFile(ctx context.Context, filename string) (*services.FileStream, error)
+}
+
+// catMaxSize is the largest file /cat returns as JSON; raw=true streams
+// files up to the repository's maximum file size
+const catMaxSize = 10 * 1024 * 1024
+
+// CatHandler serves file contents on /cat/{filename}
+type CatHandler struct {
+	// files may differ per request, e.g. to redact secrets for some callers
+	files  Scope[ContentService]
+	logger *logging.Logger
+}
+
+// NewCatHandler creates the file content handler
+func NewCatHandler(files Scope[ContentService], logger *logging.Logger) *CatHandler {
+	return &CatHandler{files: files, logger: logger}
+}
+
+// Register registers the handler's routes
+func (h *CatHandler) Register(mux *server.Registry) {
+	mux.HandleFunc("GET /cat/{filename...}", h.Cat)
+	mux.Describe("GET /cat/{filename...}", server.RouteDoc{
+		Summary: "Read a file",
+		Query: []server.Param{
+			{Name: "transform", Description: "Comma-separated pipeline, e.g. sort,uniq"},
+			{Name: "expand_tabs", Description: "Tab stop width"},
+			{Name: "wrap", Description: "Wrap lines at this width"},
+			{Name: "to", Description: "Convert a config file, e.g. json"},
+			{Name: "columns", Description: "Stream these CSV/TSV columns, e.g. 1,3"},
+			{Name: "delimiter", Description: "Column delimiter"},
+			{Name: "raw", Description: "Stream the file as is, with range request support"},
+		},
+		Produces: []string{"application/json", "text/csv", "application/octet-stream"},
+	})
+}
+
+// CatFilename returns the file a /cat request names
+func CatFilename(r *http.Request) string {
+	return r.PathValue("filename")
+}
+
+// Cat serves a file's content as JSON, optionally transformed, converted
+// or cut into columns, or streams it as is
+func (h *CatHandler) Cat(w http.ResponseWriter, r *http.Request) {
+	filename := CatFilename(r)
+	if filename == "" {
+		cathttp.WriteProblem(w, r, http.StatusBadRequest, "Filename required")
+		return
+	}
+
+	reqLogger := logging.FromContext(r.Context(), h.logger)
+	files := h.files(r, reqLogger)
+
+	// Raw content is streamed, so files of any size are served in constant
+	// memory, e.g. /cat/big.log?raw=true
+	if raw := r.URL.Query().Get("raw"); raw != "" {
+		enabled, err := strconv.ParseBool(raw)
+		if err != nil {
+			cathttp.WriteProblem(w, r, http.StatusBadRequest, "Invalid raw value: must be true or false")
+			return
+		}
+		if enabled {
+			serveRaw(w, r, files, filename, reqLogger)
+			return
+		}
+	}
+
+	// cut-style column extraction streams the file instead of returning JSON
+	if columnSpec := r.URL.Query().Get("columns"); columnSpec != "" {
+		serveColumns(w, r, files, filename, columnSpec, reqLogger)
+		return
+	}
+
+	// Config formats can be served as JSON, e.g. /cat/app.yaml?to=json
+	switch to := r.URL.Query().Get("to"); to {
+	case "":
+	case "json":
+		converted, err := files.ConvertToJSON(r.Context(), filename)
+		if err != nil {
+			reqLogger.LogError(err, "failed to convert file", "filename", filename, "to", to)
+			code := CodeForError(err)
+			if code.Status == http.StatusUnprocessableEntity {
+				// Syntax errors point at the offending line, which helps the client
+				cathttp.WriteProblemCode(w, r, code, err.Error())
+				return
+			}
+			cathttp.WriteProblemCode(w, r, code, "")
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(converted)
+		return
+	default:
+		cathttp.WriteProblem(w, r, http.StatusBadRequest, "Unsupported conversion target")
+		return
+	}
+
+	// Optional coreutils-style pipeline, e.g. ?transform=sort,uniq
+	transforms, err := services.ParseTransforms(r.URL.Query().Get("transform"))
+	if err != nil {
+		writeBadRequest(w, r, err)
+		return
+	}
+
+	// Display formatting, e.g. ?expand_tabs=4&wrap=80
+	display, err := services.ParseDisplayOptions(r.URL.Query().Get("expand_tabs"), r.URL.Query().Get("wrap"))
+	if err != nil {
+		writeBadRequest(w, r, err)
+		return
+	}
+
+	request := &services.ReadFileRequest{
+		Filename:    filename,
+		MaxSize:     catMaxSize,
+		PreviewOnly: false,
+		Transforms:  transforms,
+		Display:     display,
+	}
+
+	fileContent, err := files.ReadFile(r.Context(), request)
+	if err != nil {
+		reqLogger.LogError(err, "failed to read file", "filename", filename)
+		if err.Error() == "file not found: "+filename {
+			cathttp.WriteProblemCode(w, r, cathttp.CodeFileNotFound, "File not found")
+		} else if code := CodeForError(err); code == cathttp.CodeFileTooLarge && len(transforms) == 0 && !display.Enabled() {
+			cathttp.WriteProblemCode(w, r, code, "File too large to return as JSON; raw=true streams files up to the maximum file size")
+		} else {
+			writeError(w, r, err)
+		}
+		return
+	}
+
+	encodeStart := time.Now()
+	writeJSON(w, r, http.StatusOK, fileContent)
+	metrics.TimingsFromContext(r.Context()).Since("encode", encodeStart)
+}
+
+// serveRaw streams a file's content as is. http.ServeContent answers
+// range and conditional requests and sets the content type from the
+// file's extension, or by sniffing when it has none. It is given the
+// repository's reader itself, usually an *os.File, so the server can send
+// it with sendfile.
+func serveRaw(w http.ResponseWriter, r *http.Request, files ContentService, filename string, reqLogger *logging.Logger) {
+	stream, err := files.OpenFile(r.Context(), filename)
+	if err != nil {
+		reqLogger.LogError(err, "failed to open file", "filename", filename)
+		writeError(w, r, err)
+		return
+	}
+	defer stream.Close()
+
+	http.ServeContent(w, r, stream.Name, stream.ModTime, stream.ReadSeekCloser)
+}
+
+// serveColumns streams selected columns of a CSV/TSV file, e.g.
+// /cat/export.csv?columns=1,3&delimiter=,
+func serveColumns(w http.ResponseWriter, r *http.Request, files ContentService, filename, columnSpec string, reqLogger *logging.Logger) {
+	columns, err := services.ParseColumns(columnSpec)
+	if err != nil {
+		writeBadRequest(w, r, err)
+		return
+	}
+
+	delimiter, err := services.ParseDelimiter(r.URL.Query().Get("delimiter"), filename)
+	if err != nil {
+		writeBadRequest(w, r, err)
+		return
+	}
+
+	request := &services.ExtractColumnsRequest{
+		Filename:  filename,
+		Columns:   columns,
+		Delimiter: delimiter,
+	}
+
+	contentType := "text/csv; charset=utf-8"
+	if delimiter == '\t' {
+		contentType = "text/tab-separated-values; charset=utf-8"
+	}
+	w.Header().Set("Content-Type", contentType)
+
+	// Errors before the first write still produce a proper status; later
+	// parse errors can only truncate the stream
+	tw := &trackingWriter{w: w}
+	if err := files.ExtractColumns(r.Context(), request, tw); err != nil {
+		reqLogger.LogError(err, "failed to extract columns", "filename", filename)
+		if !tw.written {
+			writeError(w, r, err)
+		}
+	}
+}
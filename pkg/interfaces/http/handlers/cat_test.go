@@ -0,0 +1,163 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sh05/cat-server/pkg/application/services"
+	"github.com/sh05/cat-server/pkg/domain/repositories"
+	"github.com/sh05/cat-server/pkg/infrastructure/logging"
+	"github.com/sh05/cat-server/pkg/server"
+)
+
+// fakeContentService serves files from a map
+type fakeContentService struct {
+	files    map[string]string
+	requests []*services.ReadFileRequest
+}
+
+func (f *fakeContentService) ReadFile(ctx context.Context, request *services.ReadFileRequest) (*services.ReadFileResponse, error) {
+	f.requests = append(f.requests, request)
+	content, ok := f.files[request.Filename]
+	if !ok {
+		return nil, repositories.NewFileSystemError("read", request.Filename, "file does not exist", repositories.ErrorNotFound)
+	}
+	return &services.ReadFileResponse{Filename: request.Filename, Content: content, Size: int64(len(content))}, nil
+}
+
+func (f *fakeContentService) ConvertToJSON(ctx context.Context, filename string) ([]byte, error) {
+	if _, ok := f.files[filename]; !ok {
+		return nil, repositories.NewFileSystemError("read", filename, "file does not exist", repositories.ErrorNotFound)
+	}
+	return nil, services.ErrUnsupportedConversion
+}
+
+func (f *fakeContentService) ExtractColumns(ctx context.Context, request *services.ExtractColumnsRequest, w io.Writer) error {
+	content, ok := f.files[request.Filename]
+	if !ok {
+		return repositories.NewFileSystemError("read", request.Filename, "file does not exist", repositories.ErrorNotFound)
+	}
+	_, err := io.WriteString(w, content)
+	return err
+}
+
+// stringStream is an in-memory services.FileStream body
+type stringStream struct {
+	*strings.Reader
+}
+
+func (stringStream) Close() error { return nil }
+
+func (f *fakeContentService) OpenFile(ctx context.Context, filename string) (*services.FileStream, error) {
+	content, ok := f.files[filename]
+	if !ok {
+		return nil, repositories.NewFileSystemError("open", filename, "file does not exist", repositories.ErrorNotFound)
+	}
+	return &services.FileStream{
+		ReadSeekCloser: stringStream{strings.NewReader(content)},
+		Name:           filename,
+		Size:           int64(len(content)),
+		ModTime:        time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+	}, nil
+}
+
+func TestCatHandler(t *testing.T) {
+	files := &fakeContentService{files: map[string]string{
+		"readme.txt": "hello\n",
+		"data.csv":   "a,b\n",
+	}}
+	mux := server.NewRegistry(false)
+	NewCatHandler(func(r *http.Request, l *logging.Logger) ContentService { return files }, testLogger()).Register(mux)
+
+	tests := []struct {
+		name        string
+		method      string
+		target      string
+		status      int
+		contentType string
+	}{
+		{"read", http.MethodGet, "/cat/readme.txt", http.StatusOK, "application/json"},
+		{"missing", http.MethodGet, "/cat/missing.txt", http.StatusNotFound, ""},
+		{"no filename", http.MethodGet, "/cat/", http.StatusBadRequest, ""},
+		{"wrong method", http.MethodPost, "/cat/readme.txt", http.StatusMethodNotAllowed, ""},
+		{"bad transform", http.MethodGet, "/cat/readme.txt?transform=nope", http.StatusBadRequest, ""},
+		{"bad conversion target", http.MethodGet, "/cat/readme.txt?to=xml", http.StatusBadRequest, ""},
+		{"unsupported conversion", http.MethodGet, "/cat/readme.txt?to=json", http.StatusUnsupportedMediaType, ""},
+		{"columns", http.MethodGet, "/cat/data.csv?columns=1", http.StatusOK, "text/csv; charset=utf-8"},
+		{"columns of missing file", http.MethodGet, "/cat/missing.csv?columns=1", http.StatusNotFound, ""},
+		{"raw", http.MethodGet, "/cat/readme.txt?raw=true", http.StatusOK, "text/plain; charset=utf-8"},
+		{"raw disabled", http.MethodGet, "/cat/readme.txt?raw=false", http.StatusOK, "application/json"},
+		{"raw of missing file", http.MethodGet, "/cat/missing.txt?raw=true", http.StatusNotFound, ""},
+		{"bad raw", http.MethodGet, "/cat/readme.txt?raw=maybe", http.StatusBadRequest, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			mux.ServeHTTP(w, httptest.NewRequest(tt.method, tt.target, nil))
+			if w.Code != tt.status {
+				t.Fatalf("Expected status %d, got %d: %s", tt.status, w.Code, w.Body.String())
+			}
+			if tt.contentType != "" && w.Header().Get("Content-Type") != tt.contentType {
+				t.Errorf("Expected Content-Type %q, got %q", tt.contentType, w.Header().Get("Content-Type"))
+			}
+		})
+	}
+
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/cat/readme.txt", nil))
+	var response services.ReadFileResponse
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatal(err)
+	}
+	if response.Content != "hello\n" {
+		t.Errorf("Expected content %q, got %q", "hello\n", response.Content)
+	}
+	last := files.requests[len(files.requests)-1]
+	if last.MaxSize != 10*1024*1024 || last.Filename != "readme.txt" {
+		t.Errorf("Unexpected read request %+v", last)
+	}
+}
+
+func TestCatHandlerRawRanges(t *testing.T) {
+	files := &fakeContentService{files: map[string]string{"app.log": "0123456789"}}
+	mux := server.NewRegistry(false)
+	NewCatHandler(func(r *http.Request, l *logging.Logger) ContentService { return files }, testLogger()).Register(mux)
+
+	tests := []struct {
+		name    string
+		header  string
+		value   string
+		status  int
+		content string
+	}{
+		{"whole file", "", "", http.StatusOK, "0123456789"},
+		{"range", "Range", "bytes=2-5", http.StatusPartialContent, "2345"},
+		{"suffix range", "Range", "bytes=-3", http.StatusPartialContent, "789"},
+		{"unsatisfiable range", "Range", "bytes=20-", http.StatusRequestedRangeNotSatisfiable, ""},
+		{"not modified", "If-Modified-Since", "Tue, 02 Jan 2024 00:00:00 GMT", http.StatusNotModified, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/cat/app.log?raw=true", nil)
+			if tt.header != "" {
+				req.Header.Set(tt.header, tt.value)
+			}
+			w := httptest.NewRecorder()
+			mux.ServeHTTP(w, req)
+			if w.Code != tt.status {
+				t.Fatalf("Expected status %d, got %d: %s", tt.status, w.Code, w.Body.String())
+			}
+			if tt.content != "" && w.Body.String() != tt.content {
+				t.Errorf("Expected content %q, got %q", tt.content, w.Body.String())
+			}
+		})
+	}
+}
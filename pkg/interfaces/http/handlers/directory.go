@@ -0,0 +1,334 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sh05/cat-server/pkg/application/services"
+	cathttp "github.com/sh05/cat-server/pkg/infrastructure/http"
+	"github.com/sh05/cat-server/pkg/infrastructure/logging"
+	"github.com/sh05/cat-server/pkg/infrastructure/metrics"
+	"github.com/sh05/cat-server/pkg/server"
+)
+
+// DirectoryService lists, compares and summarizes directories
+type DirectoryService interface {
+	ListDirectory(ctx context.Context, request *services.ListDirectoryRequest) (*services.ListDirectoryResponse, error)
+	CompareDirectories(ctx context.Context, request *services.CompareDirectoriesRequest) (*services.CompareDirectoriesResponse, error)
+	Manifest(ctx context.Context, request *services.ManifestRequest) (*services.ManifestResponse, error)
+	Report(ctx context.Context, request *services.ReportRequest) (*services.ReportResponse, error)
+	RecentFiles(ctx context.Context, request *services.RecentFilesRequest) (*services.RecentFilesResponse, error)
+	Audit(ctx context.Context, request *services.AuditRequest) (*services.AuditResponse, error)
+	DiskUsage(ctx context.Context, request *services.DiskUsageRequest) (*services.DiskUsageResponse, error)
+}
+
+// DirectoryHandler serves the directory endpoints: /ls, /diff-dir,
+// /manifest/, /report/, /du/, /recent and /audit/fs
+type DirectoryHandler struct {
+	directories Scope[DirectoryService]
+	// includeHidden is passed to the walking endpoints; /ls and /diff-dir
+	// never show hidden files
+	includeHidden bool
+	logger        *logging.Logger
+}
+
+// NewDirectoryHandler creates the directory handlers
+func NewDirectoryHandler(directories Scope[DirectoryService], includeHidden bool, logger *logging.Logger) *DirectoryHandler {
+	return &DirectoryHandler{directories: directories, includeHidden: includeHidden, logger: logger}
+}
+
+// Register registers the handler's routes. /ls/{path} lists subdirectories
+// such as mounts.
+func (h *DirectoryHandler) Register(mux *server.Registry) {
+	list := server.RouteDoc{
+		Summary:  "List a directory",
+		Query:    []server.Param{{Name: "path", Description: "Directory to list"}, {Name: "content_type", Description: "MIME filter, e.g. image/*"}},
+		Produces: []string{"application/json"},
+	}
+	mux.HandleFunc("GET /ls", h.List)
+	mux.Describe("GET /ls", list)
+	mux.HandleFunc("GET /ls/{path...}", h.List)
+	mux.Describe("GET /ls/{path...}", list)
+	mux.HandleFunc("GET /diff-dir", h.DiffDir)
+	mux.Describe("GET /diff-dir", server.RouteDoc{
+		Summary:  "Compare two directories",
+		Query:    []server.Param{{Name: "a", Required: true}, {Name: "b", Required: true}},
+		Produces: []string{"application/json"},
+	})
+	mux.HandleFunc("GET /manifest/{dir...}", h.Manifest)
+	mux.Describe("GET /manifest/{dir...}", server.RouteDoc{
+		Summary:  "SHA-256 manifest of a directory",
+		Query:    []server.Param{{Name: "format", Description: "text or json"}},
+		Produces: []string{"text/plain", "application/json"},
+	})
+	mux.HandleFunc("GET /report/{dir...}", h.Report)
+	mux.Describe("GET /report/{dir...}", server.RouteDoc{
+		Summary:  "Directory analytics",
+		Query:    []server.Param{{Name: "top", Description: "Number of largest files"}},
+		Produces: []string{"application/json"},
+	})
+	mux.HandleFunc("GET /du/{dir...}", h.DiskUsage)
+	mux.Describe("GET /du/{dir...}", server.RouteDoc{
+		Summary:  "Disk usage of a directory and its subdirectories",
+		Produces: []string{"application/json"},
+	})
+	mux.HandleFunc("GET /recent", h.Recent)
+	mux.Describe("GET /recent", server.RouteDoc{
+		Summary:  "Recently modified files",
+		Query:    []server.Param{{Name: "path"}, {Name: "limit"}},
+		Produces: []string{"application/json"},
+	})
+	mux.HandleFunc("GET /audit/fs", h.Audit)
+	mux.Describe("GET /audit/fs", server.RouteDoc{
+		Summary:  "Entries the server can see but not serve",
+		Query:    []server.Param{{Name: "path"}},
+		Produces: []string{"application/json"},
+	})
+}
+
+// List lists a directory
+func (h *DirectoryHandler) List(w http.ResponseWriter, r *http.Request) {
+	// Optional subdirectory, e.g. /ls/logs/ or ?path=logs.tar.gz!/ to
+	// look inside an archive
+	dirPath := r.PathValue("path")
+	if dirPath == "" {
+		dirPath = r.URL.Query().Get("path")
+	}
+	if dirPath == "" {
+		dirPath = "."
+	}
+
+	// Optional MIME filter, e.g. ?content_type=image/* sniffs each file
+	contentTypes, err := services.ParseContentTypeFilter(r.URL.Query().Get("content_type"))
+	if err != nil {
+		writeBadRequest(w, r, err)
+		return
+	}
+
+	request := &services.ListDirectoryRequest{
+		Path:          dirPath,
+		IncludeHidden: false,
+		SortBy:        "name",
+		SortOrder:     "asc",
+		FilterType:    "all",
+		ContentTypes:  contentTypes,
+	}
+	// Polling clients send back the ETag and get 304 until the directory
+	// changes
+	known, wildcard := parseIfNoneMatch(r.Header.Get("If-None-Match"))
+	request.KnownVersions = known
+
+	reqLogger := logging.FromContext(r.Context(), h.logger)
+	listing, err := h.directories(r, reqLogger).ListDirectory(r.Context(), request)
+	if err != nil {
+		reqLogger.LogError(err, "failed to list directory", "path", dirPath)
+		writeError(w, r, err)
+		return
+	}
+
+	if listing.ListingVersion != "" {
+		w.Header().Set("ETag", `"`+listing.ListingVersion+`"`)
+	}
+	if listing.NotModified || wildcard {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	encodeStart := time.Now()
+	writeJSON(w, r, http.StatusOK, listing)
+	metrics.TimingsFromContext(r.Context()).Since("encode", encodeStart)
+}
+
+// parseIfNoneMatch returns the entity tags of an If-None-Match header
+// without quotes or W/ prefixes, since the comparison is weak, and whether
+// it is "*"
+func parseIfNoneMatch(header string) (tags []string, wildcard bool) {
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" {
+			return nil, true
+		}
+		tag = strings.Trim(strings.TrimPrefix(tag, "W/"), `"`)
+		if tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	return tags, false
+}
+
+// DiffDir compares two directories, e.g. /diff-dir?a=v1&b=v2
+func (h *DirectoryHandler) DiffDir(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	request := &services.CompareDirectoriesRequest{
+		PathA:         query.Get("a"),
+		PathB:         query.Get("b"),
+		IncludeHidden: false,
+	}
+	if request.PathA == "" || request.PathB == "" {
+		cathttp.WriteProblem(w, r, http.StatusBadRequest, "Query parameters a and b are required")
+		return
+	}
+
+	reqLogger := logging.FromContext(r.Context(), h.logger)
+	diff, err := h.directories(r, reqLogger).CompareDirectories(r.Context(), request)
+	if err != nil {
+		reqLogger.LogError(err, "failed to compare directories", "a", request.PathA, "b", request.PathB)
+		writeError(w, r, err)
+		return
+	}
+
+	writeJSON(w, r, http.StatusOK, diff)
+}
+
+// Manifest serves a checksum manifest. The default output can be piped
+// straight into `sha256sum -c` from the directory root.
+func (h *DirectoryHandler) Manifest(w http.ResponseWriter, r *http.Request) {
+	dir := strings.Trim(r.PathValue("dir"), "/")
+	if dir == "" {
+		dir = "."
+	}
+
+	format := r.URL.Query().Get("format")
+	if format == "" && r.Header.Get("Accept") == "application/json" {
+		format = "json"
+	}
+	if format != "" && format != "json" && format != "text" {
+		cathttp.WriteProblem(w, r, http.StatusBadRequest, "Unsupported format")
+		return
+	}
+
+	request := &services.ManifestRequest{
+		Path:          dir,
+		IncludeHidden: h.includeHidden,
+	}
+
+	reqLogger := logging.FromContext(r.Context(), h.logger)
+	manifest, err := h.directories(r, reqLogger).Manifest(r.Context(), request)
+	if err != nil {
+		reqLogger.LogError(err, "failed to build manifest", "path", dir)
+		writeError(w, r, err)
+		return
+	}
+
+	if format == "json" {
+		writeJSON(w, r, http.StatusOK, manifest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	if err := manifest.WriteSHA256Sum(w); err != nil {
+		reqLogger.LogError(err, "failed to write manifest", "path", dir)
+	}
+}
+
+// Report serves directory analytics, e.g. /report/logs?top=20
+func (h *DirectoryHandler) Report(w http.ResponseWriter, r *http.Request) {
+	dir := strings.Trim(r.PathValue("dir"), "/")
+	if dir == "" {
+		dir = "."
+	}
+
+	request := &services.ReportRequest{
+		Path:          dir,
+		IncludeHidden: h.includeHidden,
+	}
+	if v := r.URL.Query().Get("top"); v != "" {
+		top, err := strconv.Atoi(v)
+		if err != nil || top <= 0 {
+			cathttp.WriteProblem(w, r, http.StatusBadRequest, "Invalid top")
+			return
+		}
+		request.TopN = top
+	}
+
+	reqLogger := logging.FromContext(r.Context(), h.logger)
+	report, err := h.directories(r, reqLogger).Report(r.Context(), request)
+	if err != nil {
+		reqLogger.LogError(err, "failed to build report", "path", dir)
+		writeError(w, r, err)
+		return
+	}
+
+	writeJSON(w, r, http.StatusOK, report)
+}
+
+// DiskUsage sums the file sizes below a directory, e.g. /du/logs
+func (h *DirectoryHandler) DiskUsage(w http.ResponseWriter, r *http.Request) {
+	dir := strings.Trim(r.PathValue("dir"), "/")
+	if dir == "" {
+		dir = "."
+	}
+
+	request := &services.DiskUsageRequest{
+		Path:          dir,
+		IncludeHidden: h.includeHidden,
+	}
+
+	reqLogger := logging.FromContext(r.Context(), h.logger)
+	usage, err := h.directories(r, reqLogger).DiskUsage(r.Context(), request)
+	if err != nil {
+		reqLogger.LogError(err, "failed to compute disk usage", "path", dir)
+		writeError(w, r, err)
+		return
+	}
+
+	writeJSON(w, r, http.StatusOK, usage)
+}
+
+// Recent lists recently modified files, e.g. /recent?path=logs&limit=50
+func (h *DirectoryHandler) Recent(w http.ResponseWriter, r *http.Request) {
+	dir := r.URL.Query().Get("path")
+	if dir == "" {
+		dir = "."
+	}
+
+	request := &services.RecentFilesRequest{
+		Path:          dir,
+		IncludeHidden: h.includeHidden,
+	}
+	if v := r.URL.Query().Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit <= 0 {
+			cathttp.WriteProblem(w, r, http.StatusBadRequest, "Invalid limit")
+			return
+		}
+		request.Limit = limit
+	}
+
+	reqLogger := logging.FromContext(r.Context(), h.logger)
+	recent, err := h.directories(r, reqLogger).RecentFiles(r.Context(), request)
+	if err != nil {
+		reqLogger.LogError(err, "failed to list recent files", "path", dir)
+		writeError(w, r, err)
+		return
+	}
+
+	writeJSON(w, r, http.StatusOK, recent)
+}
+
+// Audit reports entries the server can see but cannot serve, e.g.
+// /audit/fs?path=data
+func (h *DirectoryHandler) Audit(w http.ResponseWriter, r *http.Request) {
+	dir := r.URL.Query().Get("path")
+	if dir == "" {
+		dir = "."
+	}
+
+	request := &services.AuditRequest{
+		Path:          dir,
+		IncludeHidden: h.includeHidden,
+	}
+
+	reqLogger := logging.FromContext(r.Context(), h.logger)
+	audit, err := h.directories(r, reqLogger).Audit(r.Context(), request)
+	if err != nil {
+		reqLogger.LogError(err, "failed to audit directory", "path", dir)
+		writeError(w, r, err)
+		return
+	}
+
+	writeJSON(w, r, http.StatusOK, audit)
+}
@@ -0,0 +1,157 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"github.com/sh05/cat-server/pkg/application/services"
+	"github.com/sh05/cat-server/pkg/domain/repositories"
+	"github.com/sh05/cat-server/pkg/infrastructure/logging"
+	"github.com/sh05/cat-server/pkg/server"
+)
+
+// fakeDirectoryService records the requests it receives
+type fakeDirectoryService struct {
+	listed   []string
+	manifest *services.ManifestRequest
+}
+
+func (f *fakeDirectoryService) ListDirectory(ctx context.Context, request *services.ListDirectoryRequest) (*services.ListDirectoryResponse, error) {
+	f.listed = append(f.listed, request.Path)
+	if request.Path == "missing" {
+		return nil, repositories.NewFileSystemError("list", request.Path, "directory does not exist", repositories.ErrorNotFound)
+	}
+	if slices.Contains(request.KnownVersions, "v1") {
+		return &services.ListDirectoryResponse{Path: request.Path, ListingVersion: "v1", NotModified: true}, nil
+	}
+	return &services.ListDirectoryResponse{Path: request.Path, ListingVersion: "v1"}, nil
+}
+
+func (f *fakeDirectoryService) CompareDirectories(ctx context.Context, request *services.CompareDirectoriesRequest) (*services.CompareDirectoriesResponse, error) {
+	return &services.CompareDirectoriesResponse{}, nil
+}
+
+func (f *fakeDirectoryService) Manifest(ctx context.Context, request *services.ManifestRequest) (*services.ManifestResponse, error) {
+	f.manifest = request
+	return &services.ManifestResponse{}, nil
+}
+
+func (f *fakeDirectoryService) Report(ctx context.Context, request *services.ReportRequest) (*services.ReportResponse, error) {
+	return &services.ReportResponse{}, nil
+}
+
+func (f *fakeDirectoryService) RecentFiles(ctx context.Context, request *services.RecentFilesRequest) (*services.RecentFilesResponse, error) {
+	return &services.RecentFilesResponse{}, nil
+}
+
+func (f *fakeDirectoryService) DiskUsage(ctx context.Context, request *services.DiskUsageRequest) (*services.DiskUsageResponse, error) {
+	f.listed = append(f.listed, request.Path)
+	return &services.DiskUsageResponse{Path: request.Path}, nil
+}
+
+func (f *fakeDirectoryService) Audit(ctx context.Context, request *services.AuditRequest) (*services.AuditResponse, error) {
+	return &services.AuditResponse{}, nil
+}
+
+func TestDirectoryHandlerList(t *testing.T) {
+	tests := []struct {
+		target string
+		path   string
+		status int
+	}{
+		{"/ls", ".", http.StatusOK},
+		{"/ls?path=logs", "logs", http.StatusOK},
+		{"/ls/logs/", "logs/", http.StatusOK},
+		{"/ls/missing", "missing", http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		directories := &fakeDirectoryService{}
+		mux := server.NewRegistry(false)
+		NewDirectoryHandler(func(r *http.Request, l *logging.Logger) DirectoryService { return directories }, false, testLogger()).Register(mux)
+
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.target, nil))
+		if w.Code != tt.status {
+			t.Errorf("GET %s: expected status %d, got %d", tt.target, tt.status, w.Code)
+		}
+		if len(directories.listed) != 1 || directories.listed[0] != tt.path {
+			t.Errorf("GET %s: expected to list %q, got %v", tt.target, tt.path, directories.listed)
+		}
+	}
+}
+
+func TestDirectoryHandlerListNotModified(t *testing.T) {
+	tests := []struct {
+		ifNoneMatch string
+		status      int
+	}{
+		{"", http.StatusOK},
+		{`"v0"`, http.StatusOK},
+		{`"v1"`, http.StatusNotModified},
+		{`W/"v1"`, http.StatusNotModified},
+		{`"v0", "v1"`, http.StatusNotModified},
+		{"*", http.StatusNotModified},
+	}
+
+	for _, tt := range tests {
+		mux := server.NewRegistry(false)
+		NewDirectoryHandler(func(r *http.Request, l *logging.Logger) DirectoryService { return &fakeDirectoryService{} }, false, testLogger()).Register(mux)
+
+		r := httptest.NewRequest(http.MethodGet, "/ls", nil)
+		if tt.ifNoneMatch != "" {
+			r.Header.Set("If-None-Match", tt.ifNoneMatch)
+		}
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, r)
+		if w.Code != tt.status {
+			t.Errorf("If-None-Match %s: expected status %d, got %d", tt.ifNoneMatch, tt.status, w.Code)
+		}
+		if etag := w.Header().Get("ETag"); etag != `"v1"` {
+			t.Errorf("If-None-Match %s: expected ETag \"v1\", got %q", tt.ifNoneMatch, etag)
+		}
+		if tt.status == http.StatusNotModified && w.Body.Len() != 0 {
+			t.Errorf("If-None-Match %s: expected no body, got %q", tt.ifNoneMatch, w.Body.String())
+		}
+	}
+}
+
+func TestDirectoryHandlerValidation(t *testing.T) {
+	directories := &fakeDirectoryService{}
+	mux := server.NewRegistry(false)
+	NewDirectoryHandler(func(r *http.Request, l *logging.Logger) DirectoryService { return directories }, true, testLogger()).Register(mux)
+
+	tests := []struct {
+		method string
+		target string
+		status int
+	}{
+		{http.MethodPost, "/ls", http.StatusMethodNotAllowed},
+		{http.MethodGet, "/diff-dir?a=v1", http.StatusBadRequest},
+		{http.MethodGet, "/diff-dir?a=v1&b=v2", http.StatusOK},
+		{http.MethodGet, "/manifest/?format=xml", http.StatusBadRequest},
+		{http.MethodGet, "/manifest/logs/?format=json", http.StatusOK},
+		{http.MethodGet, "/report/?top=0", http.StatusBadRequest},
+		{http.MethodGet, "/recent?limit=x", http.StatusBadRequest},
+		{http.MethodGet, "/audit/fs", http.StatusOK},
+		{http.MethodGet, "/du/logs/", http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest(tt.method, tt.target, nil))
+		if w.Code != tt.status {
+			t.Errorf("%s %s: expected status %d, got %d", tt.method, tt.target, tt.status, w.Code)
+		}
+	}
+
+	if directories.manifest == nil || directories.manifest.Path != "logs" || !directories.manifest.IncludeHidden {
+		t.Errorf("Unexpected manifest request %+v", directories.manifest)
+	}
+	if !slices.Equal(directories.listed, []string{"logs"}) {
+		t.Errorf("Expected the disk usage of logs, got %v", directories.listed)
+	}
+}
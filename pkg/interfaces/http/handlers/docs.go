@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"embed"
+	"fmt"
+	"html/template"
+	"io/fs"
+	"net/http"
+
+	cathttp "github.com/sh05/cat-server/pkg/infrastructure/http"
+	"github.com/sh05/cat-server/pkg/infrastructure/logging"
+	"github.com/sh05/cat-server/pkg/security"
+	"github.com/sh05/cat-server/pkg/server"
+)
+
+// swaggerUIAssets holds the vendored Swagger UI, served under /docs/assets/
+// so the documentation works without network access
+//
+//go:embed swaggerui/swagger-ui-bundle.js swaggerui/swagger-ui.css
+var swaggerUIAssets embed.FS
+
+// DocsHandler serves the OpenAPI document generated from the registry on
+// /openapi.json and an interactive Swagger UI on /docs
+type DocsHandler struct {
+	mux     *server.Registry
+	title   string
+	version string
+	logger  *logging.Logger
+}
+
+// NewDocsHandler creates the API documentation handlers for mux
+func NewDocsHandler(mux *server.Registry, title, version string, logger *logging.Logger) *DocsHandler {
+	return &DocsHandler{mux: mux, title: title, version: version, logger: logger}
+}
+
+// Register registers the handler's routes
+func (h *DocsHandler) Register(mux *server.Registry) {
+	mux.HandleFunc("GET /openapi.json", h.OpenAPI)
+	mux.Describe("GET /openapi.json", server.RouteDoc{Summary: "OpenAPI document for this server", Produces: []string{"application/json"}})
+	mux.HandleFunc("GET /docs", h.Docs)
+	mux.Describe("GET /docs", server.RouteDoc{Summary: "Interactive API documentation", Produces: []string{"text/html"}})
+	mux.HandleFunc("GET /docs/assets/{file}", h.Asset)
+	mux.Describe("GET /docs/assets/{file}", server.RouteDoc{Summary: "Swagger UI scripts and styles used by /docs", Produces: []string{"text/javascript", "text/css"}})
+}
+
+// OpenAPI serves the OpenAPI document. It is built per request, so routes
+// registered after the handler, such as the login flow, are included.
+func (h *DocsHandler) OpenAPI(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, r, http.StatusOK, h.mux.OpenAPI(h.title, h.version))
+}
+
+var docsPage = template.Must(template.New("docs").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<link rel="stylesheet" href="docs/assets/swagger-ui.css">
+</head>
+<body>
+<div id="swagger-ui"></div>
+<script src="docs/assets/swagger-ui-bundle.js"></script>
+<script nonce="{{.Nonce}}">SwaggerUIBundle({url: "openapi.json", dom_id: "#swagger-ui", validatorUrl: null});</script>
+</body>
+</html>
+`))
+
+// Docs serves Swagger UI for the OpenAPI document. The page replaces the
+// default Content-Security-Policy with one allowing only the embedded
+// Swagger UI assets and its own inline script.
+func (h *DocsHandler) Docs(w http.ResponseWriter, r *http.Request) {
+	nonce, err := security.RandomToken()
+	if err != nil {
+		logging.FromContext(r.Context(), h.logger).LogError(err, "failed to generate script nonce")
+		cathttp.WriteProblem(w, r, http.StatusInternalServerError, "")
+		return
+	}
+
+	w.Header().Set("Content-Security-Policy", fmt.Sprintf(
+		"default-src 'none'; script-src 'self' 'nonce-%s'; style-src 'self'; img-src 'self' data:; connect-src 'self'; frame-ancestors 'none'",
+		nonce))
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	docsPage.Execute(w, struct{ Title, Nonce string }{h.title, nonce})
+}
+
+// Asset serves a Swagger UI asset embedded in the binary
+func (h *DocsHandler) Asset(w http.ResponseWriter, r *http.Request) {
+	name := "swaggerui/" + r.PathValue("file")
+	if _, err := fs.Stat(swaggerUIAssets, name); err != nil {
+		cathttp.WriteProblem(w, r, http.StatusNotFound, "")
+		return
+	}
+	w.Header().Set("Cache-Control", "public, max-age=86400")
+	http.ServeFileFS(w, r, swaggerUIAssets, name)
+}
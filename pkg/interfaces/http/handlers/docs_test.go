@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/sh05/cat-server/pkg/server"
+)
+
+func TestDocsHandler(t *testing.T) {
+	mux := server.NewRegistry(false)
+	NewDocsHandler(mux, "cat-server", "1.0.0", testLogger()).Register(mux)
+
+	t.Run("openapi", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %d", rec.Code)
+		}
+
+		var doc server.OpenAPI
+		if err := json.NewDecoder(rec.Body).Decode(&doc); err != nil {
+			t.Fatalf("invalid document: %v", err)
+		}
+		for _, path := range []string{"/openapi.json", "/docs", "/docs/assets/{file}"} {
+			if _, found := doc.Paths[path]["get"]; !found {
+				t.Errorf("expected GET %s in the document", path)
+			}
+		}
+	})
+
+	t.Run("docs", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/docs", nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %d", rec.Code)
+		}
+
+		csp := rec.Header().Get("Content-Security-Policy")
+		start := strings.Index(csp, "'nonce-")
+		if start < 0 {
+			t.Fatalf("expected a script nonce in %q", csp)
+		}
+		nonce := strings.TrimPrefix(strings.SplitN(csp[start:], "'", 3)[1], "nonce-")
+		if !strings.Contains(rec.Body.String(), `nonce="`+nonce+`"`) {
+			t.Errorf("expected the page script to carry nonce %q", nonce)
+		}
+		if strings.Contains(rec.Body.String(), "https://") || strings.Contains(csp, "https://") {
+			t.Errorf("expected the page to load nothing from other hosts, got %q: %s", csp, rec.Body)
+		}
+	})
+
+	t.Run("assets", func(t *testing.T) {
+		tests := []struct {
+			target      string
+			status      int
+			contentType string
+		}{
+			{"/docs/assets/swagger-ui-bundle.js", http.StatusOK, "text/javascript"},
+			{"/docs/assets/swagger-ui.css", http.StatusOK, "text/css"},
+			{"/docs/assets/LICENSE", http.StatusNotFound, ""},
+			{"/docs/assets/missing.js", http.StatusNotFound, ""},
+		}
+		for _, tt := range tests {
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.target, nil))
+			if rec.Code != tt.status {
+				t.Errorf("GET %s: expected %d, got %d", tt.target, tt.status, rec.Code)
+				continue
+			}
+			if ct := rec.Header().Get("Content-Type"); tt.contentType != "" && !strings.HasPrefix(ct, tt.contentType) {
+				t.Errorf("GET %s: expected %s, got %q", tt.target, tt.contentType, ct)
+			}
+		}
+	})
+}
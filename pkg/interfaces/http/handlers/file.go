@@ -0,0 +1,200 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/sh05/cat-server/pkg/application/services"
+	cathttp "github.com/sh05/cat-server/pkg/infrastructure/http"
+	"github.com/sh05/cat-server/pkg/infrastructure/logging"
+	"github.com/sh05/cat-server/pkg/server"
+)
+
+// FileService diffs, identifies, validates and dumps files
+type FileService interface {
+	DiffFiles(ctx context.Context, request *services.DiffFilesRequest) (*services.DiffFilesResponse, error)
+	DetectFileType(ctx context.Context, filename string) (*services.FileTypeResponse, error)
+	ValidateSchema(ctx context.Context, request *services.ValidateSchemaRequest) (*services.ValidateSchemaResponse, error)
+	HexDump(ctx context.Context, request *services.HexDumpRequest) (*services.HexDumpResponse, error)
+}
+
+// FileHandler serves the file inspection endpoints: /diff, /file/,
+// /validate/ and /hexdump/
+type FileHandler struct {
+	files  Scope[FileService]
+	logger *logging.Logger
+}
+
+// NewFileHandler creates the file inspection handlers
+func NewFileHandler(files Scope[FileService], logger *logging.Logger) *FileHandler {
+	return &FileHandler{files: files, logger: logger}
+}
+
+// Register registers the handler's routes
+func (h *FileHandler) Register(mux *server.Registry) {
+	mux.HandleFunc("GET /diff", h.Diff)
+	mux.Describe("GET /diff", server.RouteDoc{
+		Summary:  "Unified diff of two files",
+		Query:    []server.Param{{Name: "a", Required: true}, {Name: "b", Required: true}, {Name: "context", Description: "Context lines"}},
+		Produces: []string{"application/json", "text/x-diff"},
+	})
+	mux.HandleFunc("GET /file/{filename...}", h.FileType)
+	mux.Describe("GET /file/{filename...}", server.RouteDoc{Summary: "Detect a file's type", Produces: []string{"application/json"}})
+	mux.HandleFunc("POST /validate/{filename...}", h.Validate)
+	mux.Describe("POST /validate/{filename...}", server.RouteDoc{
+		Summary:  "Validate a file against the JSON Schema in the body",
+		Body:     "application/json",
+		Produces: []string{"application/json"},
+	})
+	mux.HandleFunc("GET /hexdump/{filename...}", h.HexDump)
+	mux.Describe("GET /hexdump/{filename...}", server.RouteDoc{
+		Summary:  "xxd-style hexdump",
+		Query:    []server.Param{{Name: "offset"}, {Name: "length"}},
+		Produces: []string{"text/plain"},
+	})
+}
+
+// Diff serves a unified diff of two files, e.g. /diff?a=old.txt&b=new.txt
+func (h *FileHandler) Diff(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	request := &services.DiffFilesRequest{
+		FilenameA:    query.Get("a"),
+		FilenameB:    query.Get("b"),
+		ContextLines: 3,
+	}
+	if request.FilenameA == "" || request.FilenameB == "" {
+		cathttp.WriteProblem(w, r, http.StatusBadRequest, "Query parameters a and b are required")
+		return
+	}
+
+	if v := query.Get("context"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			cathttp.WriteProblem(w, r, http.StatusBadRequest, "Invalid context parameter")
+			return
+		}
+		request.ContextLines = n
+	}
+
+	reqLogger := logging.FromContext(r.Context(), h.logger)
+	diff, err := h.files(r, reqLogger).DiffFiles(r.Context(), request)
+	if err != nil {
+		reqLogger.LogError(err, "failed to diff files", "a", request.FilenameA, "b", request.FilenameB)
+		writeError(w, r, err)
+		return
+	}
+
+	// Serve the raw patch to clients that ask for it
+	if accept := r.Header.Get("Accept"); accept == "text/x-diff" || accept == "text/plain" {
+		w.Header().Set("Content-Type", accept)
+		io.WriteString(w, diff.Diff)
+		return
+	}
+
+	writeJSON(w, r, http.StatusOK, diff)
+}
+
+// FileType detects a file's type
+func (h *FileHandler) FileType(w http.ResponseWriter, r *http.Request) {
+	filename := r.PathValue("filename")
+	if filename == "" {
+		cathttp.WriteProblem(w, r, http.StatusBadRequest, "Filename required")
+		return
+	}
+
+	reqLogger := logging.FromContext(r.Context(), h.logger)
+	fileType, err := h.files(r, reqLogger).DetectFileType(r.Context(), filename)
+	if err != nil {
+		reqLogger.LogError(err, "failed to detect file type", "filename", filename)
+		writeError(w, r, err)
+		return
+	}
+
+	writeJSON(w, r, http.StatusOK, fileType)
+}
+
+// Validate validates a file against a JSON Schema. The request body is the
+// schema; the target file may be JSON, YAML or TOML.
+func (h *FileHandler) Validate(w http.ResponseWriter, r *http.Request) {
+	filename := r.PathValue("filename")
+	if filename == "" {
+		cathttp.WriteProblem(w, r, http.StatusBadRequest, "Filename required")
+		return
+	}
+
+	schema, err := io.ReadAll(http.MaxBytesReader(w, r.Body, services.MaxSchemaSize))
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			cathttp.WriteProblem(w, r, http.StatusRequestEntityTooLarge, "Schema too large")
+			return
+		}
+		cathttp.WriteProblem(w, r, http.StatusBadRequest, "Failed to read schema")
+		return
+	}
+
+	reqLogger := logging.FromContext(r.Context(), h.logger)
+	response, err := h.files(r, reqLogger).ValidateSchema(r.Context(), &services.ValidateSchemaRequest{
+		Filename: filename,
+		Schema:   schema,
+	})
+	if err != nil {
+		reqLogger.LogError(err, "failed to validate file", "filename", filename)
+		code := CodeForError(err)
+		if errors.Is(err, services.ErrInvalidSchema) || code.Status == http.StatusUnprocessableEntity {
+			// Point the client at the broken schema or file
+			cathttp.WriteProblemCode(w, r, code, err.Error())
+			return
+		}
+		cathttp.WriteProblemCode(w, r, code, "")
+		return
+	}
+
+	writeJSON(w, r, http.StatusOK, response)
+}
+
+// HexDump serves an xxd-style hexdump, e.g.
+// /hexdump/image.bin?offset=512&length=64
+func (h *FileHandler) HexDump(w http.ResponseWriter, r *http.Request) {
+	filename := r.PathValue("filename")
+	if filename == "" {
+		cathttp.WriteProblem(w, r, http.StatusBadRequest, "Filename required")
+		return
+	}
+
+	request := &services.HexDumpRequest{Filename: filename}
+	query := r.URL.Query()
+	if v := query.Get("offset"); v != "" {
+		offset, err := strconv.ParseInt(v, 0, 64)
+		if err != nil {
+			cathttp.WriteProblem(w, r, http.StatusBadRequest, "Invalid offset")
+			return
+		}
+		request.Offset = offset
+	}
+	if v := query.Get("length"); v != "" {
+		length, err := strconv.ParseInt(v, 0, 64)
+		if err != nil {
+			cathttp.WriteProblem(w, r, http.StatusBadRequest, "Invalid length")
+			return
+		}
+		request.Length = length
+	}
+
+	reqLogger := logging.FromContext(r.Context(), h.logger)
+	dump, err := h.files(r, reqLogger).HexDump(r.Context(), request)
+	if err != nil {
+		reqLogger.LogError(err, "failed to dump file", "filename", filename)
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Header().Set("X-File-Size", strconv.FormatInt(dump.Size, 10))
+	if err := dump.WriteXXD(w); err != nil {
+		reqLogger.LogError(err, "failed to write hexdump", "filename", filename)
+	}
+}
@@ -0,0 +1,161 @@
+// Package handlers implements the cat-server HTTP endpoints on top of the
+// application services. Handlers depend on small service interfaces so they
+// can be tested with fakes; cmd/cat-server wires in the real services.
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+
+	"github.com/sh05/cat-server/pkg/application/services"
+	"github.com/sh05/cat-server/pkg/domain/repositories"
+	cathttp "github.com/sh05/cat-server/pkg/infrastructure/http"
+	"github.com/sh05/cat-server/pkg/infrastructure/logging"
+)
+
+// Scope returns the service handling a request, bound to the request-scoped
+// logger so service logs carry the request ID. Services with a WithLogger
+// method are scoped with
+//
+//	func(r *http.Request, logger *logging.Logger) handlers.FileService {
+//		return fileService.WithLogger(logger)
+//	}
+type Scope[T any] func(r *http.Request, logger *logging.Logger) T
+
+// errorCodes maps service errors to their codes in the error catalogue
+var errorCodes = []struct {
+	err  error
+	code cathttp.ErrorCode
+}{
+	{services.ErrInvalidPath, cathttp.CodeInvalidPath},
+	{services.ErrInvalidPattern, cathttp.CodeInvalidPattern},
+	{services.ErrUnsupportedArchiveFormat, cathttp.CodeUnsupportedArchiveFormat},
+	{services.ErrUnsupportedTransform, cathttp.CodeUnsupportedTransform},
+	{services.ErrInvalidColumns, cathttp.CodeInvalidColumns},
+	{services.ErrInvalidLogQuery, cathttp.CodeInvalidLogQuery},
+	{services.ErrInvalidDisplayOption, cathttp.CodeInvalidDisplayOption},
+	{services.ErrInvalidSchema, cathttp.CodeInvalidSchema},
+	{services.ErrInvalidContentTypeFilter, cathttp.CodeInvalidContentTypeFilter},
+	{services.ErrInvalidByteRange, cathttp.CodeInvalidByteRange},
+	{services.ErrRedacted, cathttp.CodeContentRedacted},
+	{services.ErrNotTextFile, cathttp.CodeNotTextFile},
+	{services.ErrUnsupportedConversion, cathttp.CodeUnsupportedConversion},
+	{services.ErrNotImage, cathttp.CodeNotImage},
+	{services.ErrConversionFailed, cathttp.CodeConversionFailed},
+	{services.ErrDiffTooComplex, cathttp.CodeDiffTooComplex},
+	{services.ErrImageTooLarge, cathttp.CodeImageTooLarge},
+	{services.ErrUnknownComponent, cathttp.CodeUnknownComponent},
+	{context.Canceled, cathttp.CodeRequestCanceled},
+}
+
+// CodeForError maps service and repository errors to their error codes.
+// Unknown errors get the generic code of 500 Internal Server Error.
+func CodeForError(err error) cathttp.ErrorCode {
+	for _, e := range errorCodes {
+		if errors.Is(err, e.err) {
+			return e.code
+		}
+	}
+
+	var fsErr *repositories.FileSystemError
+	if errors.As(err, &fsErr) {
+		switch fsErr.Code {
+		case repositories.ErrorNotFound:
+			return cathttp.CodeFileNotFound
+		case repositories.ErrorPathTraversal:
+			return cathttp.CodePathTraversal
+		case repositories.ErrorInvalidPath:
+			return cathttp.CodeInvalidPath
+		case repositories.ErrorPermissionDenied:
+			return cathttp.CodePermissionDenied
+		case repositories.ErrorFileTooLarge:
+			return cathttp.CodeFileTooLarge
+		case repositories.ErrorTimeout:
+			return cathttp.CodeFilesystemTimeout
+		case repositories.ErrorUnavailable:
+			return cathttp.CodeFilesystemUnavailable
+		case repositories.ErrorInsufficientMemory:
+			return cathttp.CodeMemoryPressure
+		}
+	}
+
+	return cathttp.CodeForStatus(http.StatusInternalServerError)
+}
+
+// StatusForError maps service and repository errors to HTTP status codes
+func StatusForError(err error) int {
+	return CodeForError(err).Status
+}
+
+// writeError answers r with err's error code and problem details without
+// the error message, which may reveal server paths
+func writeError(w http.ResponseWriter, r *http.Request, err error) {
+	cathttp.WriteProblemCode(w, r, CodeForError(err), "")
+}
+
+// writeBadRequest answers r with 400 Bad Request and the message of err, a
+// rejected parameter, under err's error code when it has one
+func writeBadRequest(w http.ResponseWriter, r *http.Request, err error) {
+	code := CodeForError(err)
+	if code.Status != http.StatusBadRequest {
+		code = cathttp.CodeForStatus(http.StatusBadRequest)
+	}
+	cathttp.WriteProblemCode(w, r, code, err.Error())
+}
+
+// maxPooledJSONBuffer bounds the buffers kept for reuse, so one large
+// listing does not pin its memory in the pool
+const maxPooledJSONBuffer = 1 << 20
+
+// jsonBuffer is a response buffer with an encoder writing to it, reused
+// across responses
+type jsonBuffer struct {
+	bytes.Buffer
+	encoder *json.Encoder
+}
+
+var jsonBuffers = sync.Pool{New: func() any {
+	b := &jsonBuffer{}
+	b.encoder = json.NewEncoder(&b.Buffer)
+	return b
+}}
+
+// writeJSON answers r with status and v encoded as JSON. The body is
+// encoded before anything is written, so a value that cannot be encoded
+// gets 500 Internal Server Error instead of a truncated body.
+func writeJSON(w http.ResponseWriter, r *http.Request, status int, v any) {
+	b := jsonBuffers.Get().(*jsonBuffer)
+	defer func() {
+		if b.Cap() <= maxPooledJSONBuffer {
+			b.Reset()
+			jsonBuffers.Put(b)
+		}
+	}()
+
+	if err := b.encoder.Encode(v); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Length", strconv.Itoa(b.Len()))
+	w.WriteHeader(status)
+	w.Write(b.Bytes())
+}
+
+// trackingWriter records whether any bytes have been written
+type trackingWriter struct {
+	w       io.Writer
+	written bool
+}
+
+func (t *trackingWriter) Write(p []byte) (int, error) {
+	t.written = true
+	return t.w.Write(p)
+}
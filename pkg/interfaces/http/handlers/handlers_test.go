@@ -0,0 +1,100 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/sh05/cat-server/pkg/application/services"
+	"github.com/sh05/cat-server/pkg/domain/repositories"
+	cathttp "github.com/sh05/cat-server/pkg/infrastructure/http"
+	"github.com/sh05/cat-server/pkg/infrastructure/logging"
+)
+
+// testLogger discards handler logs
+func testLogger() *logging.Logger {
+	return logging.NewLoggerWithWriter(logging.LevelError, "json", io.Discard)
+}
+
+func TestStatusForError(t *testing.T) {
+	tests := []struct {
+		err      error
+		expected int
+	}{
+		{services.ErrInvalidPath, http.StatusBadRequest},
+		{fmt.Errorf("reading: %w", services.ErrRedacted), http.StatusForbidden},
+		{services.ErrNotTextFile, http.StatusUnsupportedMediaType},
+		{services.ErrConversionFailed, http.StatusUnprocessableEntity},
+		{services.ErrDiffTooComplex, http.StatusRequestEntityTooLarge},
+		{fmt.Errorf("%w: %q", services.ErrUnknownComponent, "disk"), http.StatusNotFound},
+		{repositories.NewFileSystemError("read", "a.txt", "missing", repositories.ErrorNotFound), http.StatusNotFound},
+		{repositories.NewFileSystemError("read", "../a", "traversal", repositories.ErrorPathTraversal), http.StatusBadRequest},
+		{repositories.NewFileSystemError("read", "a.txt", "denied", repositories.ErrorPermissionDenied), http.StatusForbidden},
+		{repositories.NewFileSystemError("read", "a.bin", "too large", repositories.ErrorFileTooLarge), http.StatusRequestEntityTooLarge},
+		{fmt.Errorf("failed to scan directory: %w", context.Canceled), cathttp.StatusClientClosedRequest},
+		{errors.New("boom"), http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		if status := StatusForError(tt.err); status != tt.expected {
+			t.Errorf("StatusForError(%v) = %d, expected %d", tt.err, status, tt.expected)
+		}
+	}
+}
+
+func TestCodeForError(t *testing.T) {
+	tests := []struct {
+		err      error
+		expected cathttp.ErrorCode
+	}{
+		{fmt.Errorf("%w: %q", services.ErrInvalidColumns, "0"), cathttp.CodeInvalidColumns},
+		{services.ErrRedacted, cathttp.CodeContentRedacted},
+		{repositories.NewFileSystemError("read", "a.txt", "missing", repositories.ErrorNotFound), cathttp.CodeFileNotFound},
+		{repositories.NewFileSystemError("read", "../a", "traversal", repositories.ErrorPathTraversal), cathttp.CodePathTraversal},
+		{context.Canceled, cathttp.CodeRequestCanceled},
+		{repositories.NewFileSystemError("read", "a.txt", "timed out", repositories.ErrorTimeout), cathttp.CodeFilesystemTimeout},
+		{repositories.NewFileSystemError("read", "a.txt", "not responding", repositories.ErrorUnavailable), cathttp.CodeFilesystemUnavailable},
+		{repositories.NewFileSystemError("read", "a.bin", "not enough memory", repositories.ErrorInsufficientMemory), cathttp.CodeMemoryPressure},
+		{errors.New("boom"), cathttp.CodeForStatus(http.StatusInternalServerError)},
+	}
+
+	for _, tt := range tests {
+		if code := CodeForError(tt.err); code != tt.expected {
+			t.Errorf("CodeForError(%v) = %s, expected %s", tt.err, code, tt.expected)
+		}
+	}
+}
+
+func TestWriteJSON(t *testing.T) {
+	for _, tt := range []struct {
+		value  any
+		status int
+		body   string
+	}{
+		{map[string]int{"files": 2}, http.StatusOK, "{\"files\":2}\n"},
+		{map[string]bool{"ready": false}, http.StatusServiceUnavailable, "{\"ready\":false}\n"},
+		// Buffers are reset between responses
+		{[]string{"a"}, http.StatusOK, "[\"a\"]\n"},
+		{func() {}, http.StatusInternalServerError, ""},
+	} {
+		w := httptest.NewRecorder()
+		writeJSON(w, httptest.NewRequest(http.MethodGet, "/ls", nil), tt.status, tt.value)
+		if w.Code != tt.status {
+			t.Errorf("%T: expected status %d, got %d", tt.value, tt.status, w.Code)
+		}
+		if tt.body == "" {
+			continue
+		}
+		if w.Body.String() != tt.body || w.Header().Get("Content-Type") != "application/json" {
+			t.Errorf("%T: expected %q as JSON, got %q as %q", tt.value, tt.body, w.Body.String(), w.Header().Get("Content-Type"))
+		}
+		if length := w.Header().Get("Content-Length"); length != strconv.Itoa(len(tt.body)) {
+			t.Errorf("%T: expected Content-Length %d, got %q", tt.value, len(tt.body), length)
+		}
+	}
+}
@@ -0,0 +1,196 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"net/http"
+	"slices"
+
+	"github.com/sh05/cat-server/pkg/application/services"
+	cathttp "github.com/sh05/cat-server/pkg/infrastructure/http"
+	"github.com/sh05/cat-server/pkg/infrastructure/logging"
+	"github.com/sh05/cat-server/pkg/server"
+)
+
+// HealthChecker reports the server health and readiness
+type HealthChecker interface {
+	GetSystemHealth() (*services.HealthResponse, error)
+	GetDetailedHealth(ctx context.Context) (*services.HealthResponse, error)
+	CheckComponent(ctx context.Context, component string) (*services.ComponentHealth, error)
+	Readiness(ctx context.Context) *services.ReadinessResponse
+}
+
+// HealthHandler serves /health and the /healthz and /readyz probes
+type HealthHandler struct {
+	health HealthChecker
+	logger *logging.Logger
+}
+
+// NewHealthHandler creates the health check handler
+func NewHealthHandler(health HealthChecker, logger *logging.Logger) *HealthHandler {
+	return &HealthHandler{health: health, logger: logger}
+}
+
+// Register registers the handler's routes
+func (h *HealthHandler) Register(mux *server.Registry) {
+	mux.HandleFunc("GET /health", h.Health)
+	mux.Describe("GET /health", server.RouteDoc{Summary: "Server health", Produces: []string{"application/json", "text/html", "text/plain"}})
+	mux.HandleFunc("GET /healthz", h.Liveness)
+	mux.Describe("GET /healthz", server.RouteDoc{Summary: "Liveness probe: the process is running", Produces: []string{"text/plain"}})
+	mux.HandleFunc("GET /readyz", h.Readiness)
+	mux.Describe("GET /readyz", server.RouteDoc{Summary: "Readiness probe: 503 while starting, shutting down or when the base directory is inaccessible", Produces: []string{"application/json"}})
+}
+
+// RegisterDetailed registers the detailed health routes, which report
+// internals such as memory use and disk space, wrapped in guard, e.g. an
+// admin token check
+func (h *HealthHandler) RegisterDetailed(mux *server.Registry, guard func(http.HandlerFunc) http.HandlerFunc) {
+	mux.HandleFunc("GET /health/detailed", guard(h.Detailed))
+	mux.Describe("GET /health/detailed", server.RouteDoc{Summary: "Health of every component with system information and metrics", Produces: []string{"application/json", "text/html", "text/plain"}})
+	mux.HandleFunc("GET /health/components/{name}", guard(h.Component))
+	mux.Describe("GET /health/components/{name}", server.RouteDoc{Summary: "Health of one component: filesystem, memory, goroutines or caches", Produces: []string{"application/json", "text/html", "text/plain"}})
+}
+
+// Liveness answers 200 as long as the process can serve requests. It does
+// no checks, so a failing dependency does not get the process restarted.
+func (h *HealthHandler) Liveness(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain")
+	fmt.Fprintln(w, "ok")
+}
+
+// Readiness answers 200 when the server should receive traffic and 503
+// otherwise, with the result of each check
+func (h *HealthHandler) Readiness(w http.ResponseWriter, r *http.Request) {
+	readiness := h.health.Readiness(r.Context())
+	w.Header().Set("Cache-Control", "no-store")
+	status := http.StatusOK
+	if !readiness.Ready {
+		status = http.StatusServiceUnavailable
+	}
+	writeJSON(w, r, status, readiness)
+}
+
+// Health reports the server health as JSON, or as HTML or plain text when
+// the Accept header asks for them
+func (h *HealthHandler) Health(w http.ResponseWriter, r *http.Request) {
+	health, err := h.health.GetSystemHealth()
+	if err != nil {
+		logging.FromContext(r.Context(), h.logger).LogError(err, "health check failed")
+		cathttp.WriteProblem(w, r, http.StatusInternalServerError, "")
+		return
+	}
+
+	// Set content type based on Accept header
+	acceptHeader := r.Header.Get("Accept")
+	if acceptHeader == "text/html" {
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprintf(w, "<html><body><h1>Health Status: %s</h1><p>Uptime: %s</p><p>Version: %s</p><p>Mode: %s</p></body></html>",
+			health.Status, health.Uptime, health.Version, health.Mode)
+		return
+	} else if acceptHeader == "text/plain" {
+		w.Header().Set("Content-Type", "text/plain")
+		fmt.Fprintf(w, "Status: %s\nUptime: %s\nVersion: %s\nMode: %s\n",
+			health.Status, health.Uptime, health.Version, health.Mode)
+		return
+	}
+
+	writeJSON(w, r, http.StatusOK, health)
+}
+
+// Detailed reports the health of every component in the format the Accept
+// header asks for, like Health
+func (h *HealthHandler) Detailed(w http.ResponseWriter, r *http.Request) {
+	health, err := h.health.GetDetailedHealth(r.Context())
+	if err != nil {
+		logging.FromContext(r.Context(), h.logger).LogError(err, "detailed health check failed")
+		cathttp.WriteProblem(w, r, http.StatusInternalServerError, "")
+		return
+	}
+	w.Header().Set("Cache-Control", "no-store")
+
+	names := make([]string, 0, len(health.Components))
+	for name := range health.Components {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	switch r.Header.Get("Accept") {
+	case "text/html":
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprintf(w, "<html><body><h1>Health Status: %s</h1><p>Uptime: %s</p><p>Version: %s</p><p>Mode: %s</p>",
+			health.Status, health.Uptime, html.EscapeString(health.Version), health.Mode)
+		fmt.Fprint(w, "<table><tr><th>Component</th><th>Status</th><th>Message</th></tr>")
+		for _, name := range names {
+			component := health.Components[name]
+			fmt.Fprintf(w, "<tr><td>%s</td><td>%s</td><td>%s</td></tr>",
+				html.EscapeString(name), html.EscapeString(component.Status), html.EscapeString(component.Message))
+		}
+		fmt.Fprint(w, "</table></body></html>")
+	case "text/plain":
+		w.Header().Set("Content-Type", "text/plain")
+		fmt.Fprintf(w, "Status: %s\nUptime: %s\nVersion: %s\nMode: %s\n",
+			health.Status, health.Uptime, health.Version, health.Mode)
+		for _, name := range names {
+			component := health.Components[name]
+			fmt.Fprintf(w, "%s: %s", name, component.Status)
+			if component.Message != "" {
+				fmt.Fprintf(w, " (%s)", component.Message)
+			}
+			fmt.Fprintln(w)
+		}
+	default:
+		writeJSON(w, r, http.StatusOK, health)
+	}
+}
+
+// Component reports the health of the component named in the path, with
+// its details, in the format the Accept header asks for, like Health
+func (h *HealthHandler) Component(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+	component, err := h.health.CheckComponent(r.Context(), name)
+	if err != nil {
+		if code := CodeForError(err); code.Status != http.StatusInternalServerError {
+			cathttp.WriteProblemCode(w, r, code, err.Error())
+			return
+		}
+		logging.FromContext(r.Context(), h.logger).LogError(err, "component health check failed", "component", name)
+		cathttp.WriteProblem(w, r, http.StatusInternalServerError, "")
+		return
+	}
+	w.Header().Set("Cache-Control", "no-store")
+
+	details, _ := component.Details.(map[string]interface{})
+	keys := make([]string, 0, len(details))
+	for key := range details {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+
+	switch r.Header.Get("Accept") {
+	case "text/html":
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprintf(w, "<html><body><h1>%s: %s</h1><p>%s</p><p>Checked in %s</p>",
+			html.EscapeString(name), html.EscapeString(component.Status), html.EscapeString(component.Message), component.Duration)
+		if len(keys) > 0 {
+			fmt.Fprint(w, "<table>")
+			for _, key := range keys {
+				fmt.Fprintf(w, "<tr><th>%s</th><td>%s</td></tr>", html.EscapeString(key), html.EscapeString(fmt.Sprint(details[key])))
+			}
+			fmt.Fprint(w, "</table>")
+		}
+		fmt.Fprint(w, "</body></html>")
+	case "text/plain":
+		w.Header().Set("Content-Type", "text/plain")
+		fmt.Fprintf(w, "Component: %s\nStatus: %s\n", name, component.Status)
+		if component.Message != "" {
+			fmt.Fprintf(w, "Message: %s\n", component.Message)
+		}
+		fmt.Fprintf(w, "Duration: %s\n", component.Duration)
+		for _, key := range keys {
+			fmt.Fprintf(w, "%s: %v\n", key, details[key])
+		}
+	default:
+		writeJSON(w, r, http.StatusOK, component)
+	}
+}
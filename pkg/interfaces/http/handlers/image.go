@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	"github.com/sh05/cat-server/pkg/application/services"
+	cathttp "github.com/sh05/cat-server/pkg/infrastructure/http"
+	"github.com/sh05/cat-server/pkg/infrastructure/logging"
+	"github.com/sh05/cat-server/pkg/server"
+)
+
+// ImageService renders thumbnails and reads image metadata
+type ImageService interface {
+	Thumbnail(ctx context.Context, request *services.ThumbnailRequest) (*services.ThumbnailResponse, error)
+	Metadata(ctx context.Context, filename string) (*services.ImageMetadataResponse, error)
+}
+
+// ImageHandler serves /thumb/{filename} and /exif/{filename}
+type ImageHandler struct {
+	images Scope[ImageService]
+	logger *logging.Logger
+}
+
+// NewImageHandler creates the image handlers
+func NewImageHandler(images Scope[ImageService], logger *logging.Logger) *ImageHandler {
+	return &ImageHandler{images: images, logger: logger}
+}
+
+// Register registers the handler's routes
+func (h *ImageHandler) Register(mux *server.Registry) {
+	mux.HandleFunc("GET /thumb/{filename...}", h.Thumbnail)
+	mux.Describe("GET /thumb/{filename...}", server.RouteDoc{
+		Summary:  "Image thumbnail",
+		Query:    []server.Param{{Name: "w", Description: "Width in pixels"}},
+		Produces: []string{"image/png", "image/jpeg"},
+	})
+	mux.HandleFunc("GET /exif/{filename...}", h.EXIF)
+	mux.Describe("GET /exif/{filename...}", server.RouteDoc{Summary: "Image metadata", Produces: []string{"application/json"}})
+}
+
+// Thumbnail serves a scaled-down image, e.g. /thumb/photo.jpg?w=200
+func (h *ImageHandler) Thumbnail(w http.ResponseWriter, r *http.Request) {
+	filename := r.PathValue("filename")
+	if filename == "" {
+		cathttp.WriteProblem(w, r, http.StatusBadRequest, "Filename required")
+		return
+	}
+
+	request := &services.ThumbnailRequest{Filename: filename}
+	if v := r.URL.Query().Get("w"); v != "" {
+		width, err := strconv.Atoi(v)
+		if err != nil || width <= 0 {
+			cathttp.WriteProblem(w, r, http.StatusBadRequest, "Invalid width")
+			return
+		}
+		request.Width = width
+	}
+
+	reqLogger := logging.FromContext(r.Context(), h.logger)
+	response, err := h.images(r, reqLogger).Thumbnail(r.Context(), request)
+	if err != nil {
+		reqLogger.LogError(err, "failed to generate thumbnail", "filename", filename)
+		writeError(w, r, err)
+		return
+	}
+
+	cacheStatus := "MISS"
+	if response.Cached {
+		cacheStatus = "HIT"
+	}
+	w.Header().Set("Content-Type", response.ContentType)
+	w.Header().Set("Content-Length", strconv.Itoa(len(response.Data)))
+	w.Header().Set("X-Cache", cacheStatus)
+	w.Write(response.Data)
+}
+
+// EXIF serves an image's metadata
+func (h *ImageHandler) EXIF(w http.ResponseWriter, r *http.Request) {
+	filename := r.PathValue("filename")
+	if filename == "" {
+		cathttp.WriteProblem(w, r, http.StatusBadRequest, "Filename required")
+		return
+	}
+
+	reqLogger := logging.FromContext(r.Context(), h.logger)
+	response, err := h.images(r, reqLogger).Metadata(r.Context(), filename)
+	if err != nil {
+		reqLogger.LogError(err, "failed to read image metadata", "filename", filename)
+		writeError(w, r, err)
+		return
+	}
+
+	writeJSON(w, r, http.StatusOK, response)
+}
@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/sh05/cat-server/pkg/application/services"
+	cathttp "github.com/sh05/cat-server/pkg/infrastructure/http"
+	"github.com/sh05/cat-server/pkg/infrastructure/logging"
+	"github.com/sh05/cat-server/pkg/server"
+)
+
+// LogService queries structured log files
+type LogService interface {
+	QueryLogs(ctx context.Context, request *services.QueryLogsRequest) (*services.QueryLogsResponse, error)
+}
+
+// LogsHandler serves the structured log viewer on /logs/{filename}
+type LogsHandler struct {
+	logs   Scope[LogService]
+	logger *logging.Logger
+}
+
+// NewLogsHandler creates the log viewer handler
+func NewLogsHandler(logs Scope[LogService], logger *logging.Logger) *LogsHandler {
+	return &LogsHandler{logs: logs, logger: logger}
+}
+
+// Register registers the handler's routes
+func (h *LogsHandler) Register(mux *server.Registry) {
+	mux.HandleFunc("GET /logs/{filename...}", h.Logs)
+	mux.Describe("GET /logs/{filename...}", server.RouteDoc{
+		Summary:  "Query a structured log file",
+		Query:    []server.Param{{Name: "since", Description: "Duration or timestamp, e.g. 1h"}, {Name: "level"}, {Name: "limit"}},
+		Produces: []string{"application/json"},
+	})
+}
+
+// Logs queries a log file, e.g. /logs/app.log?since=1h&level=error&limit=100
+func (h *LogsHandler) Logs(w http.ResponseWriter, r *http.Request) {
+	filename := r.PathValue("filename")
+	if filename == "" {
+		cathttp.WriteProblem(w, r, http.StatusBadRequest, "Filename required")
+		return
+	}
+
+	query := r.URL.Query()
+	since, err := services.ParseSince(query.Get("since"), time.Now())
+	if err != nil {
+		writeBadRequest(w, r, err)
+		return
+	}
+
+	level, err := services.ParseLogLevel(query.Get("level"))
+	if err != nil {
+		writeBadRequest(w, r, err)
+		return
+	}
+
+	request := &services.QueryLogsRequest{
+		Filename: filename,
+		Since:    since,
+		MinLevel: level,
+	}
+	if v := query.Get("limit"); v != "" {
+		if request.Limit, err = strconv.Atoi(v); err != nil {
+			cathttp.WriteProblem(w, r, http.StatusBadRequest, "Invalid limit")
+			return
+		}
+	}
+
+	reqLogger := logging.FromContext(r.Context(), h.logger)
+	response, err := h.logs(r, reqLogger).QueryLogs(r.Context(), request)
+	if err != nil {
+		reqLogger.LogError(err, "failed to query logs", "filename", filename)
+		writeError(w, r, err)
+		return
+	}
+
+	writeJSON(w, r, http.StatusOK, response)
+}
@@ -0,0 +1,39 @@
+package handlers
+
+import (
+	"io"
+	"net/http"
+
+	"github.com/sh05/cat-server/pkg/infrastructure/logging"
+	"github.com/sh05/cat-server/pkg/server"
+)
+
+// MetricsWriter writes metrics in the Prometheus text format
+type MetricsWriter interface {
+	WritePrometheus(w io.Writer) error
+}
+
+// MetricsHandler serves /metrics
+type MetricsHandler struct {
+	metrics MetricsWriter
+	logger  *logging.Logger
+}
+
+// NewMetricsHandler creates the Prometheus metrics handler
+func NewMetricsHandler(metrics MetricsWriter, logger *logging.Logger) *MetricsHandler {
+	return &MetricsHandler{metrics: metrics, logger: logger}
+}
+
+// Register registers the handler's routes
+func (h *MetricsHandler) Register(mux *server.Registry) {
+	mux.HandleFunc("GET /metrics", h.Metrics)
+	mux.Describe("GET /metrics", server.RouteDoc{Summary: "Prometheus metrics", Produces: []string{"text/plain"}})
+}
+
+// Metrics writes the metrics registry
+func (h *MetricsHandler) Metrics(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	if err := h.metrics.WritePrometheus(w); err != nil {
+		logging.FromContext(r.Context(), h.logger).LogError(err, "failed to write metrics")
+	}
+}
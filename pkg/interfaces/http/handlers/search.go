@@ -0,0 +1,143 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	"github.com/sh05/cat-server/pkg/application/services"
+	cathttp "github.com/sh05/cat-server/pkg/infrastructure/http"
+	"github.com/sh05/cat-server/pkg/infrastructure/logging"
+	"github.com/sh05/cat-server/pkg/server"
+)
+
+// SearchService searches within one file or across files
+type SearchService interface {
+	Grep(ctx context.Context, request *services.GrepRequest) (*services.GrepResponse, error)
+	SearchFiles(ctx context.Context, request *services.SearchFilesRequest) (*services.SearchFilesResponse, error)
+}
+
+// SearchHandler serves /grep/{filename} and /search
+type SearchHandler struct {
+	search Scope[SearchService]
+	logger *logging.Logger
+}
+
+// NewSearchHandler creates the search handlers
+func NewSearchHandler(search Scope[SearchService], logger *logging.Logger) *SearchHandler {
+	return &SearchHandler{search: search, logger: logger}
+}
+
+// Register registers the handler's routes
+func (h *SearchHandler) Register(mux *server.Registry) {
+	mux.HandleFunc("GET /grep/{filename...}", h.Grep)
+	mux.Describe("GET /grep/{filename...}", server.RouteDoc{
+		Summary: "Search a file",
+		Query: []server.Param{
+			{Name: "pattern", Required: true, Description: "Regular expression"},
+			{Name: "context"}, {Name: "ignore_case"}, {Name: "max_matches"},
+		},
+		Produces: []string{"application/json"},
+	})
+	mux.HandleFunc("GET /search", h.Search)
+	mux.Describe("GET /search", server.RouteDoc{
+		Summary:  "Search across files",
+		Query:    []server.Param{{Name: "q", Required: true}, {Name: "glob"}, {Name: "ignore_case"}, {Name: "max_matches"}},
+		Produces: []string{"application/json"},
+	})
+}
+
+// Grep searches a single file, e.g. /grep/app.log?pattern=error&context=2
+func (h *SearchHandler) Grep(w http.ResponseWriter, r *http.Request) {
+	filename := r.PathValue("filename")
+	if filename == "" {
+		cathttp.WriteProblem(w, r, http.StatusBadRequest, "Filename required")
+		return
+	}
+
+	query := r.URL.Query()
+	request := &services.GrepRequest{
+		Filename:   filename,
+		Pattern:    query.Get("pattern"),
+		MaxMatches: services.DefaultMaxMatches,
+	}
+
+	if v := query.Get("context"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			cathttp.WriteProblem(w, r, http.StatusBadRequest, "Invalid context parameter")
+			return
+		}
+		request.ContextLines = n
+	}
+
+	if v := query.Get("ignore_case"); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			cathttp.WriteProblem(w, r, http.StatusBadRequest, "Invalid ignore_case parameter")
+			return
+		}
+		request.IgnoreCase = b
+	}
+
+	if v := query.Get("max_matches"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			cathttp.WriteProblem(w, r, http.StatusBadRequest, "Invalid max_matches parameter")
+			return
+		}
+		request.MaxMatches = n
+	}
+
+	reqLogger := logging.FromContext(r.Context(), h.logger)
+	result, err := h.search(r, reqLogger).Grep(r.Context(), request)
+	if err != nil {
+		reqLogger.LogError(err, "failed to grep file", "filename", filename)
+		writeError(w, r, err)
+		return
+	}
+
+	writeJSON(w, r, http.StatusOK, result)
+}
+
+// Search searches across files, e.g. /search?q=timeout&glob=*.log
+func (h *SearchHandler) Search(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	request := &services.SearchFilesRequest{
+		Query:      query.Get("q"),
+		Glob:       query.Get("glob"),
+		MaxMatches: services.DefaultMaxMatches,
+	}
+	if request.Query == "" {
+		cathttp.WriteProblem(w, r, http.StatusBadRequest, "Query parameter q is required")
+		return
+	}
+
+	if v := query.Get("ignore_case"); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			cathttp.WriteProblem(w, r, http.StatusBadRequest, "Invalid ignore_case parameter")
+			return
+		}
+		request.IgnoreCase = b
+	}
+
+	if v := query.Get("max_matches"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			cathttp.WriteProblem(w, r, http.StatusBadRequest, "Invalid max_matches parameter")
+			return
+		}
+		request.MaxMatches = n
+	}
+
+	reqLogger := logging.FromContext(r.Context(), h.logger)
+	result, err := h.search(r, reqLogger).SearchFiles(r.Context(), request)
+	if err != nil {
+		reqLogger.LogError(err, "failed to search files", "query", request.Query)
+		writeError(w, r, err)
+		return
+	}
+
+	writeJSON(w, r, http.StatusOK, result)
+}
@@ -0,0 +1,29 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/sh05/cat-server/internal/buildinfo"
+	"github.com/sh05/cat-server/pkg/server"
+)
+
+// VersionHandler serves /version
+type VersionHandler struct {
+	info buildinfo.Info
+}
+
+// NewVersionHandler creates the handler reporting info
+func NewVersionHandler(info buildinfo.Info) *VersionHandler {
+	return &VersionHandler{info: info}
+}
+
+// Register registers the handler's routes
+func (h *VersionHandler) Register(mux *server.Registry) {
+	mux.HandleFunc("GET /version", h.Version)
+	mux.Describe("GET /version", server.RouteDoc{Summary: "Build version, commit and date", Produces: []string{"application/json"}})
+}
+
+// Version writes the build information
+func (h *VersionHandler) Version(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, r, http.StatusOK, h.info)
+}
@@ -0,0 +1,125 @@
+package security
+
+import (
+	"net/netip"
+	"sync"
+	"time"
+)
+
+// BanList bans client addresses that trigger too many blocked security
+// events, in the manner of fail2ban: once an address records threshold
+// events within the sliding window it is banned for the cooldown.
+type BanList struct {
+	threshold int
+	window    time.Duration
+	cooldown  time.Duration
+	now       func() time.Time
+
+	mu        sync.Mutex
+	offenders map[netip.Addr]*offender
+	lastPrune time.Time
+}
+
+// offender tracks the recent events and ban expiry of one address
+type offender struct {
+	events      []time.Time
+	bannedUntil time.Time
+}
+
+// NewBanList creates a ban list. A threshold of zero or less disables banning.
+func NewBanList(threshold int, window, cooldown time.Duration) *BanList {
+	return &BanList{
+		threshold: threshold,
+		window:    window,
+		cooldown:  cooldown,
+		now:       time.Now,
+		offenders: make(map[netip.Addr]*offender),
+	}
+}
+
+// Enabled reports whether banning is active
+func (b *BanList) Enabled() bool {
+	return b.threshold > 0
+}
+
+// Record counts a blocked event for addr. It returns the ban expiry and true
+// when this event caused a new ban.
+func (b *BanList) Record(addr netip.Addr) (time.Time, bool) {
+	if !b.Enabled() || !addr.IsValid() {
+		return time.Time{}, false
+	}
+	addr = addr.Unmap()
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := b.now()
+	b.prune(now)
+
+	o := b.offenders[addr]
+	if o == nil {
+		o = &offender{}
+		b.offenders[addr] = o
+	}
+	if now.Before(o.bannedUntil) {
+		return time.Time{}, false
+	}
+
+	o.events = append(dropBefore(o.events, now.Add(-b.window)), now)
+	if len(o.events) < b.threshold {
+		return time.Time{}, false
+	}
+
+	o.events = nil
+	o.bannedUntil = now.Add(b.cooldown)
+	return o.bannedUntil, true
+}
+
+// Banned returns the ban expiry of addr and whether it is currently banned
+func (b *BanList) Banned(addr netip.Addr) (time.Time, bool) {
+	if !b.Enabled() || !addr.IsValid() {
+		return time.Time{}, false
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	o := b.offenders[addr.Unmap()]
+	if o == nil || !b.now().Before(o.bannedUntil) {
+		return time.Time{}, false
+	}
+	return o.bannedUntil, true
+}
+
+// Len returns the number of tracked addresses
+func (b *BanList) Len() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.offenders)
+}
+
+// prune forgets addresses with no recent events and no active ban, at most
+// once per window; b.mu must be held
+func (b *BanList) prune(now time.Time) {
+	if now.Sub(b.lastPrune) < b.window {
+		return
+	}
+	b.lastPrune = now
+
+	cutoff := now.Add(-b.window)
+	for addr, o := range b.offenders {
+		o.events = dropBefore(o.events, cutoff)
+		if len(o.events) == 0 && !now.Before(o.bannedUntil) {
+			delete(b.offenders, addr)
+		}
+	}
+}
+
+// dropBefore removes the leading events older than cutoff
+func dropBefore(events []time.Time, cutoff time.Time) []time.Time {
+	i := 0
+	for i < len(events) && !events[i].After(cutoff) {
+		i++
+	}
+	return events[i:]
+}
@@ -0,0 +1,70 @@
+package security
+
+import (
+	"net/netip"
+	"testing"
+	"time"
+)
+
+func TestBanList(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	bans := NewBanList(3, time.Minute, 10*time.Minute)
+	bans.now = func() time.Time { return now }
+
+	attacker := netip.MustParseAddr("198.51.100.7")
+	other := netip.MustParseAddr("198.51.100.8")
+
+	// Events spread over more than the window do not add up
+	bans.Record(attacker)
+	now = now.Add(50 * time.Second)
+	bans.Record(attacker)
+	now = now.Add(20 * time.Second)
+	if _, banned := bans.Record(attacker); banned {
+		t.Fatal("Expected no ban when the first event left the window")
+	}
+
+	now = now.Add(time.Second)
+	until, banned := bans.Record(attacker)
+	if !banned || !until.Equal(now.Add(10*time.Minute)) {
+		t.Fatalf("Expected ban until %v, got %v, %v", now.Add(10*time.Minute), until, banned)
+	}
+	if _, banned := bans.Banned(attacker); !banned {
+		t.Error("Expected attacker to be banned")
+	}
+	if _, banned := bans.Banned(netip.MustParseAddr("::ffff:198.51.100.7")); !banned {
+		t.Error("Expected IPv4-mapped form to be banned too")
+	}
+	if _, banned := bans.Banned(other); banned {
+		t.Error("Expected other address not to be banned")
+	}
+
+	// Events during a ban do not extend it
+	if _, banned := bans.Record(attacker); banned {
+		t.Error("Expected no new ban while banned")
+	}
+
+	now = now.Add(10 * time.Minute)
+	if _, banned := bans.Banned(attacker); banned {
+		t.Error("Expected ban to expire after the cooldown")
+	}
+
+	// Expired entries are pruned
+	now = now.Add(2 * time.Minute)
+	bans.Record(other)
+	if bans.Len() != 1 {
+		t.Errorf("Expected 1 tracked address after pruning, got %d", bans.Len())
+	}
+}
+
+func TestBanListDisabled(t *testing.T) {
+	bans := NewBanList(0, time.Minute, time.Minute)
+	addr := netip.MustParseAddr("192.0.2.1")
+	for i := 0; i < 10; i++ {
+		if _, banned := bans.Record(addr); banned {
+			t.Fatal("Expected disabled ban list never to ban")
+		}
+	}
+	if _, banned := bans.Banned(addr); banned {
+		t.Error("Expected disabled ban list never to report a ban")
+	}
+}
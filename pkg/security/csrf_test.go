@@ -0,0 +1,58 @@
+package security
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestValidCSRFToken(t *testing.T) {
+	token, err := NewCSRFToken()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name        string
+		cookie      string
+		header      string
+		contentType string
+		body        string
+		expected    bool
+	}{
+		{"header", token, token, "", "", true},
+		{"form field", token, "", "application/x-www-form-urlencoded", CSRFFormField + "=" + token, true},
+		{"multipart field", token, "", "multipart/form-data; boundary=b", "--b\r\nContent-Disposition: form-data; name=\"" + CSRFFormField + "\"\r\n\r\n" + token + "\r\n--b--\r\n", true},
+		{"mismatch", token, "other", "", "", false},
+		{"no cookie", "", token, "", "", false},
+		{"no token", token, "", "", "", false},
+		{"json body ignored", token, "", "application/json", `{"csrf_token":"` + token + `"}`, false},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest(http.MethodPost, "/upload", strings.NewReader(tt.body))
+		if tt.cookie != "" {
+			r.AddCookie(&http.Cookie{Name: CSRFCookieName, Value: tt.cookie})
+		}
+		if tt.header != "" {
+			r.Header.Set(CSRFHeaderName, tt.header)
+		}
+		if tt.contentType != "" {
+			r.Header.Set("Content-Type", tt.contentType)
+		}
+		if got := ValidCSRFToken(r); got != tt.expected {
+			t.Errorf("%s: ValidCSRFToken = %v, expected %v", tt.name, got, tt.expected)
+		}
+	}
+}
+
+func TestCSRFSafeMethod(t *testing.T) {
+	for method, expected := range map[string]bool{
+		http.MethodGet: true, http.MethodHead: true, http.MethodOptions: true,
+		http.MethodPost: false, http.MethodPut: false, http.MethodDelete: false, http.MethodPatch: false,
+	} {
+		if got := CSRFSafeMethod(method); got != expected {
+			t.Errorf("CSRFSafeMethod(%s) = %v, expected %v", method, got, expected)
+		}
+	}
+}
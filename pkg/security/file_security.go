@@ -0,0 +1,198 @@
+// Package security validates client-supplied file names and paths before
+// they reach the filesystem layer
+package security
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/sh05/cat-server/pkg/domain/valueobjects"
+)
+
+// Validation errors
+var (
+	ErrEmptyName        = errors.New("name is empty")
+	ErrNameTooLong      = errors.New("name is too long")
+	ErrInvalidCharacter = errors.New("name contains an invalid character")
+	ErrInvalidEncoding  = errors.New("name is not valid UTF-8")
+	ErrReservedName     = errors.New("name is reserved")
+	ErrAbsolutePath     = errors.New("path is absolute")
+	ErrPathTraversal    = errors.New("path traversal detected")
+)
+
+// Limits
+const (
+	MaxFilenameLength = 255
+	MaxPathLength     = 4096
+	// maxDecodeRounds bounds repeated URL decoding of nested encodings such as %25252e
+	maxDecodeRounds = 4
+)
+
+// lookalikes maps characters that some decoders and filesystems fold into
+// '.', '/' or '\' onto their ASCII forms
+var lookalikes = strings.NewReplacer(
+	"．", ".", // fullwidth full stop
+	"․", ".", // one dot leader
+	"﹒", ".", // small full stop
+	"／", "/", // fullwidth solidus
+	"∕", "/", // division slash
+	"⁄", "/", // fraction slash
+	"＼", "\\", // fullwidth reverse solidus
+	"∖", "\\", // set minus
+	"\xc0\xae", ".", // overlong UTF-8 encodings
+	"\xe0\x80\xae", ".",
+	"\xc0\xaf", "/",
+	"\xe0\x80\xaf", "/",
+	"\xc1\x9c", "\\",
+	"\xc1\x81", "\\",
+)
+
+// normalize undoes URL encoding (repeatedly, to catch double encoding),
+// folds look-alike characters and converts Windows separators to '/'
+func normalize(p string) string {
+	for i := 0; i < maxDecodeRounds && strings.Contains(p, "%"); i++ {
+		decoded, err := url.PathUnescape(p)
+		if err != nil || decoded == p {
+			break
+		}
+		p = decoded
+	}
+	p = lookalikes.Replace(p)
+	return strings.ReplaceAll(p, "\\", "/")
+}
+
+// IsPathTraversal reports whether a path contains a ".." segment once
+// URL-encoded, double-encoded, Unicode look-alike and Windows-style forms
+// are normalized
+func IsPathTraversal(p string) bool {
+	for _, segment := range strings.Split(normalize(p), "/") {
+		// Windows ignores trailing dots and spaces, so "..." and ".. " are ".."
+		if strings.HasPrefix(segment, "..") && strings.Trim(segment, ". ") == "" {
+			return true
+		}
+	}
+	return false
+}
+
+// isAbsolute reports whether a normalized path is rooted, has a drive
+// letter or is a UNC path
+func isAbsolute(p string) bool {
+	if strings.HasPrefix(p, "/") {
+		return true
+	}
+	return len(p) >= 2 && p[1] == ':' && ((p[0] >= 'a' && p[0] <= 'z') || (p[0] >= 'A' && p[0] <= 'Z'))
+}
+
+// ValidateFilename checks a single path component: it must be valid UTF-8,
+// at most MaxFilenameLength bytes, not "." or "..", and free of separators
+// (including encoded ones), NUL and other control characters
+func ValidateFilename(name string) error {
+	if name == "" {
+		return ErrEmptyName
+	}
+	if len(name) > MaxFilenameLength {
+		return fmt.Errorf("%w: %d bytes (max: %d)", ErrNameTooLong, len(name), MaxFilenameLength)
+	}
+	if !utf8.ValidString(name) {
+		return ErrInvalidEncoding
+	}
+	if name == "." || IsPathTraversal(name) {
+		return fmt.Errorf("%w: %q", ErrReservedName, name)
+	}
+	for _, r := range name {
+		if r == '/' || r == '\\' || unicode.IsControl(r) {
+			return fmt.Errorf("%w: %q", ErrInvalidCharacter, r)
+		}
+	}
+	if strings.Contains(normalize(name), "/") {
+		return fmt.Errorf("%w: encoded or look-alike separator", ErrInvalidCharacter)
+	}
+	return nil
+}
+
+// ValidateFilePath checks a relative path supplied by a client. Empty and
+// "." segments are allowed, so "logs/" and "./logs" are valid.
+func ValidateFilePath(p string) error {
+	if p == "" {
+		return ErrEmptyName
+	}
+	if len(p) > MaxPathLength {
+		return fmt.Errorf("%w: %d bytes (max: %d)", ErrNameTooLong, len(p), MaxPathLength)
+	}
+	if IsPathTraversal(p) {
+		return ErrPathTraversal
+	}
+
+	normalized := normalize(p)
+	if isAbsolute(normalized) {
+		return ErrAbsolutePath
+	}
+	for _, segment := range strings.Split(normalized, "/") {
+		if segment == "" || segment == "." {
+			continue
+		}
+		if err := ValidateFilename(segment); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// IsBinaryFile reports whether content, typically the first bytes of a
+// file, looks like binary data rather than text
+func IsBinaryFile(content []byte) bool {
+	return !valueobjects.DetectFileType(content).IsText()
+}
+
+// IsFileSizeValid reports whether size is within [0, maxSize]
+func IsFileSizeValid(size, maxSize int64) bool {
+	return size >= 0 && size <= maxSize
+}
+
+// SanitizeFilename turns an arbitrary string into a safe single path
+// component, e.g. for Content-Disposition headers. Separators and control
+// characters become '_', leading dots are dropped so the result is never
+// hidden or a traversal, and the result is truncated to MaxFilenameLength.
+func SanitizeFilename(name string) string {
+	name = strings.ToValidUTF8(normalize(name), "_")
+
+	var b strings.Builder
+	for _, r := range name {
+		if r == '/' || unicode.IsControl(r) {
+			r = '_'
+		}
+		if b.Len()+utf8.RuneLen(r) > MaxFilenameLength {
+			break
+		}
+		b.WriteRune(r)
+	}
+
+	sanitized := strings.TrimSpace(strings.TrimLeft(b.String(), ". "))
+	if sanitized == "" {
+		return "unnamed"
+	}
+	return sanitized
+}
+
+// FindTraversal checks the request path and the named query parameters for
+// path traversal and returns the first offending value
+func FindTraversal(r *http.Request, params ...string) (string, bool) {
+	if IsPathTraversal(r.URL.EscapedPath()) {
+		return r.URL.EscapedPath(), true
+	}
+
+	query := r.URL.Query()
+	for _, param := range params {
+		for _, value := range query[param] {
+			if IsPathTraversal(value) {
+				return value, true
+			}
+		}
+	}
+	return "", false
+}
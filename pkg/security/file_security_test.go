@@ -0,0 +1,177 @@
+package security
+
+import (
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestIsPathTraversal(t *testing.T) {
+	tests := []struct {
+		path     string
+		expected bool
+	}{
+		{"docs/readme.md", false},
+		{"..hidden/file", false},
+		{"file..txt", false},
+		{"...txt", false},
+		{"../etc/passwd", true},
+		{"docs/../../etc", true},
+		{"..", true},
+		{"%2e%2e/etc", true},
+		{"%2E%2E%2Fetc", true},
+		{"%252e%252e%252fetc", true},
+		{"%25252e%25252e/etc", true},
+		{"..%2fetc", true},
+		{"..\\windows\\system32", true},
+		{"docs\\..\\..\\boot.ini", true},
+		{"..%5cetc", true},
+		{"．．/etc", true},
+		{"．．／etc", true},
+		{"..∕etc", true},
+		{"%c0%ae%c0%ae/etc", true},
+		{"..%c0%afetc", true},
+		{".. /etc", true},
+		{"100%/..", true},
+	}
+
+	for _, tt := range tests {
+		if result := IsPathTraversal(tt.path); result != tt.expected {
+			t.Errorf("Expected IsPathTraversal(%q) = %v, got %v", tt.path, tt.expected, result)
+		}
+	}
+}
+
+func TestValidateFilename(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected error
+	}{
+		{"report.pdf", nil},
+		{"日本語.txt", nil},
+		{"100%.txt", nil},
+		{".env", nil},
+		{"", ErrEmptyName},
+		{strings.Repeat("a", 256), ErrNameTooLong},
+		{"bad\xffname", ErrInvalidEncoding},
+		{".", ErrReservedName},
+		{"..", ErrReservedName},
+		{"%2e%2e", ErrReservedName},
+		{"a/b", ErrInvalidCharacter},
+		{"a\\b", ErrInvalidCharacter},
+		{"a%2fb", ErrInvalidCharacter},
+		{"a／b", ErrInvalidCharacter},
+		{"nul\x00byte", ErrInvalidCharacter},
+		{"line\nbreak", ErrInvalidCharacter},
+	}
+
+	for _, tt := range tests {
+		err := ValidateFilename(tt.name)
+		if tt.expected == nil && err != nil {
+			t.Errorf("Expected %q to be valid, got %v", tt.name, err)
+		} else if tt.expected != nil && !errors.Is(err, tt.expected) {
+			t.Errorf("Expected %v for %q, got %v", tt.expected, tt.name, err)
+		}
+	}
+}
+
+func TestValidateFilePath(t *testing.T) {
+	tests := []struct {
+		path     string
+		expected error
+	}{
+		{"docs/readme.md", nil},
+		{"./logs/", nil},
+		{"archive.zip!/inner.txt", nil},
+		{"", ErrEmptyName},
+		{"/etc/passwd", ErrAbsolutePath},
+		{"%2fetc%2fpasswd", ErrAbsolutePath},
+		{"C:\\Windows\\win.ini", ErrAbsolutePath},
+		{"c:/boot.ini", ErrAbsolutePath},
+		{"\\\\server\\share", ErrAbsolutePath},
+		{"docs/../../etc", ErrPathTraversal},
+		{"%252e%252e/etc", ErrPathTraversal},
+		{"docs/\x00/x", ErrInvalidCharacter},
+		{strings.Repeat("a/", 2049), ErrNameTooLong},
+	}
+
+	for _, tt := range tests {
+		err := ValidateFilePath(tt.path)
+		if tt.expected == nil && err != nil {
+			t.Errorf("Expected %q to be valid, got %v", tt.path, err)
+		} else if tt.expected != nil && !errors.Is(err, tt.expected) {
+			t.Errorf("Expected %v for %q, got %v", tt.expected, tt.path, err)
+		}
+	}
+}
+
+func TestIsBinaryFile(t *testing.T) {
+	if IsBinaryFile([]byte("plain text\n")) {
+		t.Error("Expected text to not be binary")
+	}
+	if !IsBinaryFile([]byte("\x89PNG\r\n\x1a\n\x00\x00")) {
+		t.Error("Expected PNG header to be binary")
+	}
+	if !IsBinaryFile([]byte("abc\x00def")) {
+		t.Error("Expected NUL-containing data to be binary")
+	}
+}
+
+func TestIsFileSizeValid(t *testing.T) {
+	if !IsFileSizeValid(0, 10) || !IsFileSizeValid(10, 10) {
+		t.Error("Expected sizes within the limit to be valid")
+	}
+	if IsFileSizeValid(11, 10) || IsFileSizeValid(-1, 10) {
+		t.Error("Expected sizes outside the limit to be invalid")
+	}
+}
+
+func TestSanitizeFilename(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected string
+	}{
+		{"report.pdf", "report.pdf"},
+		{"../../etc/passwd", "_.._etc_passwd"},
+		{"..\\..\\boot.ini", "_.._boot.ini"},
+		{"%2e%2e%2fsecret", "_secret"},
+		{".hidden", "hidden"},
+		{"tab\tname", "tab_name"},
+		{"bad\xffbyte", "bad_byte"},
+		{"...", "unnamed"},
+		{"", "unnamed"},
+	}
+
+	for _, tt := range tests {
+		if result := SanitizeFilename(tt.name); result != tt.expected {
+			t.Errorf("Expected SanitizeFilename(%q) = %q, got %q", tt.name, tt.expected, result)
+		}
+	}
+
+	if long := SanitizeFilename(strings.Repeat("é", 200)); len(long) > MaxFilenameLength {
+		t.Errorf("Expected at most %d bytes, got %d", MaxFilenameLength, len(long))
+	}
+}
+
+func TestFindTraversal(t *testing.T) {
+	tests := []struct {
+		target   string
+		expected bool
+	}{
+		{"/cat/readme.md", false},
+		{"/cat/%2e%2e/etc/passwd", true},
+		{"/cat/%252e%252e%252fetc", true},
+		{"/ls?path=docs", false},
+		{"/ls?path=..%2Fetc", true},
+		{"/diff?a=x.txt&b=..\\y.txt", true},
+		{"/search?q=..", false},
+	}
+
+	for _, tt := range tests {
+		r := httptest.NewRequest("GET", tt.target, nil)
+		if _, found := FindTraversal(r, "path", "a", "b"); found != tt.expected {
+			t.Errorf("Expected FindTraversal(%q) = %v, got %v", tt.target, tt.expected, found)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package security
+
+import (
+	"crypto/tls"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestHeaderPolicyApply(t *testing.T) {
+	policy := HeaderPolicy{
+		ContentSecurityPolicy:     "default-src 'none'",
+		CrossOriginResourcePolicy: "same-origin",
+		HSTSMaxAge:                365 * 24 * time.Hour,
+		HSTSIncludeSubdomains:     true,
+	}
+
+	tests := []struct {
+		name   string
+		policy HeaderPolicy
+		tls    bool
+		want   map[string]string
+	}{
+		{
+			name:   "plain HTTP omits HSTS",
+			policy: policy,
+			want: map[string]string{
+				"X-Content-Type-Options":       "nosniff",
+				"Content-Security-Policy":      "default-src 'none'",
+				"Cross-Origin-Resource-Policy": "same-origin",
+				"Strict-Transport-Security":    "",
+			},
+		},
+		{
+			name:   "TLS adds HSTS",
+			policy: policy,
+			tls:    true,
+			want: map[string]string{
+				"Strict-Transport-Security": "max-age=31536000; includeSubDomains",
+			},
+		},
+		{
+			name: "empty policy",
+			tls:  true,
+			want: map[string]string{
+				"X-Content-Type-Options":       "nosniff",
+				"Content-Security-Policy":      "",
+				"Cross-Origin-Resource-Policy": "",
+				"Strict-Transport-Security":    "",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/list", nil)
+			if tt.tls {
+				r.TLS = &tls.ConnectionState{}
+			}
+			w := httptest.NewRecorder()
+			tt.policy.Apply(w, r)
+
+			for header, want := range tt.want {
+				if got := w.Header().Get(header); got != want {
+					t.Errorf("Expected %s %q, got %q", header, want, got)
+				}
+			}
+		})
+	}
+}
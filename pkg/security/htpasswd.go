@@ -0,0 +1,158 @@
+package security
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// DefaultHtpasswdCheckInterval is how often the file is checked for changes
+const DefaultHtpasswdCheckInterval = 2 * time.Second
+
+// dummyBcryptHash is compared against for unknown users so that the response
+// time does not reveal which user names exist
+var dummyBcryptHash = []byte("$2b$10$7EqJtq98hPqEX7fNZaFWoOdnY425Wkb4edrcOWX6VaPm/fjbrv8qC")
+
+// Htpasswd authenticates users against an Apache htpasswd file containing
+// bcrypt hashes. The file is re-read when its modification time or size
+// changes, checked at most once per check interval.
+type Htpasswd struct {
+	path          string
+	checkInterval time.Duration
+
+	mu        sync.RWMutex
+	users     map[string][]byte
+	modTime   time.Time
+	size      int64
+	lastCheck time.Time
+	skipped   []string
+}
+
+// LoadHtpasswd reads an htpasswd file. Lines with non-bcrypt hashes (MD5,
+// SHA1, crypt) are skipped and reported by Skipped.
+func LoadHtpasswd(path string) (*Htpasswd, error) {
+	h := &Htpasswd{path: path, checkInterval: DefaultHtpasswdCheckInterval}
+	if err := h.Reload(); err != nil {
+		return nil, err
+	}
+	return h, nil
+}
+
+// SetCheckInterval changes how often the file is checked for changes
+func (h *Htpasswd) SetCheckInterval(interval time.Duration) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.checkInterval = interval
+}
+
+// Reload re-reads the file. On error the previously loaded users are kept.
+func (h *Htpasswd) Reload() error {
+	info, err := os.Stat(h.path)
+	if err != nil {
+		return fmt.Errorf("failed to stat htpasswd file: %w", err)
+	}
+	users, skipped, err := parseHtpasswd(h.path)
+	if err != nil {
+		return err
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.users = users
+	h.skipped = skipped
+	h.modTime = info.ModTime()
+	h.size = info.Size()
+	h.lastCheck = time.Now()
+	return nil
+}
+
+// ReloadIfChanged reloads the file when the check interval has elapsed and
+// its modification time or size differs. It reports whether a reload happened.
+func (h *Htpasswd) ReloadIfChanged() (bool, error) {
+	h.mu.Lock()
+	if time.Since(h.lastCheck) < h.checkInterval {
+		h.mu.Unlock()
+		return false, nil
+	}
+	h.lastCheck = time.Now()
+	modTime, size := h.modTime, h.size
+	h.mu.Unlock()
+
+	info, err := os.Stat(h.path)
+	if err != nil {
+		return false, fmt.Errorf("failed to stat htpasswd file: %w", err)
+	}
+	if info.ModTime().Equal(modTime) && info.Size() == size {
+		return false, nil
+	}
+	if err := h.Reload(); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Authenticate reports whether the user exists and the password matches.
+// Unknown users are checked against a dummy hash to keep timing uniform.
+func (h *Htpasswd) Authenticate(user, password string) bool {
+	h.mu.RLock()
+	hash, ok := h.users[user]
+	h.mu.RUnlock()
+
+	if !ok {
+		bcrypt.CompareHashAndPassword(dummyBcryptHash, []byte(password))
+		return false
+	}
+	return bcrypt.CompareHashAndPassword(hash, []byte(password)) == nil
+}
+
+// Users returns the number of loaded users
+func (h *Htpasswd) Users() int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return len(h.users)
+}
+
+// Skipped returns the names of users whose hashes are not bcrypt
+func (h *Htpasswd) Skipped() []string {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return append([]string(nil), h.skipped...)
+}
+
+// parseHtpasswd reads "user:hash" lines, ignoring blank lines and comments
+func parseHtpasswd(path string) (map[string][]byte, []string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open htpasswd file: %w", err)
+	}
+	defer f.Close()
+
+	users := make(map[string][]byte)
+	var skipped []string
+	scanner := bufio.NewScanner(f)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		user, hash, ok := strings.Cut(line, ":")
+		if !ok || user == "" {
+			return nil, nil, fmt.Errorf("htpasswd line %d: expected user:hash", lineNo)
+		}
+		if _, err := bcrypt.Cost([]byte(hash)); err != nil {
+			skipped = append(skipped, user)
+			continue
+		}
+		users[user] = []byte(hash)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, nil, fmt.Errorf("failed to read htpasswd file: %w", err)
+	}
+	return users, skipped, nil
+}
@@ -0,0 +1,109 @@
+package security
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const (
+	aliceHash = "$2y$04$abcdefghijklmnopqrstuu9UGc9IJbl39mlDZvvYWvWECKJosLcIu" // alice-pw
+	bobHash   = "$2y$04$ABCDEFGHIJKLMNOPQRSTUuCT5SpqZl7hTU97JVxTyg716t.RWqmei" // bob-pw
+)
+
+func writeHtpasswd(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestHtpasswdAuthenticate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "users.htpasswd")
+	writeHtpasswd(t, path, "# users\nalice:"+aliceHash+"\n\nlegacy:$apr1$abcdefgh$0123456789abcdefghijkl\n")
+
+	h, err := LoadHtpasswd(path)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if h.Users() != 1 {
+		t.Errorf("Expected 1 user, got %d", h.Users())
+	}
+	if skipped := h.Skipped(); len(skipped) != 1 || skipped[0] != "legacy" {
+		t.Errorf("Expected legacy to be skipped, got %v", skipped)
+	}
+
+	if !h.Authenticate("alice", "alice-pw") {
+		t.Error("Expected alice to authenticate")
+	}
+	if h.Authenticate("alice", "wrong") {
+		t.Error("Expected wrong password to fail")
+	}
+	if h.Authenticate("legacy", "anything") || h.Authenticate("nobody", "alice-pw") {
+		t.Error("Expected unknown users to fail")
+	}
+}
+
+func TestHtpasswdHashVersions(t *testing.T) {
+	// Reference hashes produced by the system crypt(3) implementation
+	path := filepath.Join(t.TempDir(), "users.htpasswd")
+	writeHtpasswd(t, path, `2a:$2a$05$CCCCCCCCCCCCCCCCCCCCC.E5YPO9kmyuRGyh0XouQYb4YMJKvyOeW
+2b:$2b$04$abcdefghijklmnopqrstuughE8Ev8uGFaUgY2cNEySvxngrb/Jzdm
+2y:$2y$05$0123456789abcdefghijkebF.t51ZMQH79oSTz0w4T0A27Fx3IAyO
+lowcost:$2a$03$CCCCCCCCCCCCCCCCCCCCC.E5YPO9kmyuRGyh0XouQYb4YMJKvyOeW
+`)
+
+	h, err := LoadHtpasswd(path)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if skipped := h.Skipped(); len(skipped) != 1 || skipped[0] != "lowcost" {
+		t.Errorf("Expected the malformed hash to be skipped, got %v", skipped)
+	}
+	for user, password := range map[string]string{"2a": "U*U", "2b": "password", "2y": "secret"} {
+		if !h.Authenticate(user, password) {
+			t.Errorf("Expected %s to authenticate", user)
+		}
+		if h.Authenticate(user, "!"+password) {
+			t.Errorf("Expected a wrong password for %s to fail", user)
+		}
+	}
+}
+
+func TestHtpasswdReloadIfChanged(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "users.htpasswd")
+	writeHtpasswd(t, path, "alice:"+aliceHash+"\n")
+
+	h, err := LoadHtpasswd(path)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	h.SetCheckInterval(0)
+
+	if reloaded, err := h.ReloadIfChanged(); err != nil || reloaded {
+		t.Errorf("Expected no reload for an unchanged file, got %v, %v", reloaded, err)
+	}
+
+	writeHtpasswd(t, path, "alice:"+aliceHash+"\nbob:"+bobHash+"\n")
+	if reloaded, err := h.ReloadIfChanged(); err != nil || !reloaded {
+		t.Fatalf("Expected reload, got %v, %v", reloaded, err)
+	}
+	if !h.Authenticate("bob", "bob-pw") {
+		t.Error("Expected bob to authenticate after reload")
+	}
+
+	// A broken file keeps the previous users
+	writeHtpasswd(t, path, "not a valid line\n")
+	if _, err := h.ReloadIfChanged(); err == nil {
+		t.Error("Expected error for malformed file")
+	}
+	if !h.Authenticate("alice", "alice-pw") {
+		t.Error("Expected previous users to be kept")
+	}
+}
+
+func TestLoadHtpasswdMissing(t *testing.T) {
+	if _, err := LoadHtpasswd(filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Error("Expected error for missing file")
+	}
+}
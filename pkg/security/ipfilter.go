@@ -0,0 +1,158 @@
+package security
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+// ParsePrefixes parses CIDR blocks and bare IP addresses, which are treated
+// as single-address prefixes
+func ParsePrefixes(entries []string) ([]netip.Prefix, error) {
+	prefixes := make([]netip.Prefix, 0, len(entries))
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		if strings.Contains(entry, "/") {
+			prefix, err := netip.ParsePrefix(entry)
+			if err != nil {
+				return nil, fmt.Errorf("invalid CIDR %q: %w", entry, err)
+			}
+			prefixes = append(prefixes, prefix.Masked())
+			continue
+		}
+		addr, err := netip.ParseAddr(entry)
+		if err != nil {
+			return nil, fmt.Errorf("invalid IP address %q: %w", entry, err)
+		}
+		addr = addr.Unmap()
+		prefixes = append(prefixes, netip.PrefixFrom(addr, addr.BitLen()))
+	}
+	return prefixes, nil
+}
+
+// containsAddr reports whether any prefix contains addr
+func containsAddr(prefixes []netip.Prefix, addr netip.Addr) bool {
+	addr = addr.Unmap()
+	for _, prefix := range prefixes {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// IPFilter decides whether a client address may access the server. Denied
+// ranges take precedence; an empty allowlist allows every address that is
+// not denied.
+type IPFilter struct {
+	allowed []netip.Prefix
+	denied  []netip.Prefix
+}
+
+// NewIPFilter creates a filter from CIDR or IP address lists
+func NewIPFilter(allowed, denied []string) (*IPFilter, error) {
+	allowedPrefixes, err := ParsePrefixes(allowed)
+	if err != nil {
+		return nil, fmt.Errorf("allowed CIDRs: %w", err)
+	}
+	deniedPrefixes, err := ParsePrefixes(denied)
+	if err != nil {
+		return nil, fmt.Errorf("denied CIDRs: %w", err)
+	}
+	return &IPFilter{allowed: allowedPrefixes, denied: deniedPrefixes}, nil
+}
+
+// Enabled reports whether any rule is configured
+func (f *IPFilter) Enabled() bool {
+	return len(f.allowed) > 0 || len(f.denied) > 0
+}
+
+// Allowed reports whether addr passes the filter. An invalid address only
+// passes when no allowlist is configured.
+func (f *IPFilter) Allowed(addr netip.Addr) bool {
+	if !addr.IsValid() {
+		return len(f.allowed) == 0
+	}
+	if containsAddr(f.denied, addr) {
+		return false
+	}
+	return len(f.allowed) == 0 || containsAddr(f.allowed, addr)
+}
+
+// ClientIPResolver extracts the client address from a request. Forwarding
+// headers are only honoured when the direct peer is a trusted proxy, so
+// clients cannot spoof their address by sending X-Forwarded-For themselves.
+type ClientIPResolver struct {
+	trusted []netip.Prefix
+}
+
+// NewClientIPResolver creates a resolver trusting the given proxy ranges
+func NewClientIPResolver(trustedProxies []string) (*ClientIPResolver, error) {
+	trusted, err := ParsePrefixes(trustedProxies)
+	if err != nil {
+		return nil, fmt.Errorf("trusted proxies: %w", err)
+	}
+	return &ClientIPResolver{trusted: trusted}, nil
+}
+
+// ClientIP returns the client address. When the peer is trusted, the
+// X-Forwarded-For chain is walked from the right and the first address that
+// is not a trusted proxy is returned. The result is invalid when RemoteAddr
+// cannot be parsed.
+func (c *ClientIPResolver) ClientIP(r *http.Request) netip.Addr {
+	peer := remoteAddr(r.RemoteAddr)
+	if !peer.IsValid() || !containsAddr(c.trusted, peer) {
+		return peer
+	}
+
+	var chain []string
+	for _, header := range r.Header.Values("X-Forwarded-For") {
+		chain = append(chain, strings.Split(header, ",")...)
+	}
+
+	client := peer
+	for i := len(chain) - 1; i >= 0; i-- {
+		addr, err := netip.ParseAddr(strings.TrimSpace(chain[i]))
+		if err != nil {
+			// A malformed hop cannot be attributed; stop at the last good one
+			break
+		}
+		client = addr.Unmap()
+		if !containsAddr(c.trusted, client) {
+			break
+		}
+	}
+	return client
+}
+
+// remoteAddr parses a host:port or bare address
+func remoteAddr(s string) netip.Addr {
+	if host, _, err := net.SplitHostPort(s); err == nil {
+		s = host
+	}
+	addr, err := netip.ParseAddr(s)
+	if err != nil {
+		return netip.Addr{}
+	}
+	return addr.Unmap()
+}
+
+// clientIPContextKey is the context key for the resolved client address
+type clientIPContextKey struct{}
+
+// NewClientIPContext returns a context carrying the resolved client address
+func NewClientIPContext(ctx context.Context, addr netip.Addr) context.Context {
+	return context.WithValue(ctx, clientIPContextKey{}, addr)
+}
+
+// ClientIPFromContext returns the address stored by NewClientIPContext
+func ClientIPFromContext(ctx context.Context) (netip.Addr, bool) {
+	addr, ok := ctx.Value(clientIPContextKey{}).(netip.Addr)
+	return addr, ok && addr.IsValid()
+}
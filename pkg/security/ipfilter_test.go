@@ -0,0 +1,96 @@
+package security
+
+import (
+	"context"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+)
+
+func TestIPFilter(t *testing.T) {
+	filter, err := NewIPFilter([]string{"10.0.0.0/8", "192.168.1.5", "2001:db8::/32"}, []string{"10.1.0.0/16"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		addr     string
+		expected bool
+	}{
+		{"10.2.3.4", true},
+		{"10.1.2.3", false},
+		{"192.168.1.5", true},
+		{"192.168.1.6", false},
+		{"::ffff:10.2.3.4", true},
+		{"2001:db8::1", true},
+		{"2001:db9::1", false},
+	}
+
+	for _, tt := range tests {
+		if result := filter.Allowed(netip.MustParseAddr(tt.addr)); result != tt.expected {
+			t.Errorf("Expected Allowed(%s) = %v, got %v", tt.addr, tt.expected, result)
+		}
+	}
+	if filter.Allowed(netip.Addr{}) {
+		t.Error("Expected invalid address to be rejected with an allowlist")
+	}
+
+	denyOnly, _ := NewIPFilter(nil, []string{"203.0.113.0/24"})
+	if !denyOnly.Allowed(netip.MustParseAddr("198.51.100.1")) || denyOnly.Allowed(netip.MustParseAddr("203.0.113.9")) {
+		t.Error("Expected denylist-only filter to allow everything except denied ranges")
+	}
+
+	if _, err := NewIPFilter([]string{"10.0.0.0/33"}, nil); err == nil {
+		t.Error("Expected error for invalid CIDR")
+	}
+	if _, err := NewIPFilter(nil, []string{"not-an-ip"}); err == nil {
+		t.Error("Expected error for invalid address")
+	}
+}
+
+func TestClientIPResolver(t *testing.T) {
+	resolver, err := NewClientIPResolver([]string{"10.0.0.0/8", "127.0.0.1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name         string
+		remoteAddr   string
+		forwardedFor []string
+		expected     string
+	}{
+		{"direct client", "203.0.113.7:5000", nil, "203.0.113.7"},
+		{"untrusted peer ignores header", "203.0.113.7:5000", []string{"1.2.3.4"}, "203.0.113.7"},
+		{"trusted proxy", "10.0.0.2:443", []string{"198.51.100.9"}, "198.51.100.9"},
+		{"spoofed leftmost entry", "10.0.0.2:443", []string{"1.1.1.1, 198.51.100.9"}, "198.51.100.9"},
+		{"proxy chain", "127.0.0.1:80", []string{"198.51.100.9, 10.0.0.3", "10.0.0.2"}, "198.51.100.9"},
+		{"malformed hop", "10.0.0.2:443", []string{"garbage, 198.51.100.9"}, "198.51.100.9"},
+		{"only proxies", "10.0.0.2:443", []string{"10.0.0.3"}, "10.0.0.3"},
+		{"no header", "10.0.0.2:443", nil, "10.0.0.2"},
+		{"ipv6 peer", "[2001:db8::1]:443", nil, "2001:db8::1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/", nil)
+			r.RemoteAddr = tt.remoteAddr
+			for _, value := range tt.forwardedFor {
+				r.Header.Add("X-Forwarded-For", value)
+			}
+			if result := resolver.ClientIP(r); result.String() != tt.expected {
+				t.Errorf("Expected %s, got %s", tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestClientIPContext(t *testing.T) {
+	addr := netip.MustParseAddr("192.0.2.1")
+	if got, ok := ClientIPFromContext(NewClientIPContext(context.Background(), addr)); !ok || got != addr {
+		t.Errorf("Expected %s, got %s", addr, got)
+	}
+	if _, ok := ClientIPFromContext(context.Background()); ok {
+		t.Error("Expected no address in empty context")
+	}
+}
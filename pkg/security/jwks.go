@@ -0,0 +1,194 @@
+package security
+
+import (
+	"context"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/sh05/cat-server/pkg/infrastructure/cache"
+)
+
+// JWKS defaults
+const (
+	DefaultJWKSRefreshInterval = time.Hour
+	// jwksMinRefreshInterval limits refetches triggered by unknown key ids
+	jwksMinRefreshInterval = time.Minute
+	maxJWKSSize            = 1024 * 1024
+)
+
+// JWKS fetches and caches the RSA signing keys published at a JSON Web Key
+// Set URL. Keys are refreshed after the refresh interval, and on a lookup
+// for an unknown key id (at most once a minute) to pick up key rotation.
+type JWKS struct {
+	url             string
+	client          *http.Client
+	refreshInterval time.Duration
+	refreshes       cache.Group[struct{}]
+
+	mu          sync.Mutex
+	keys        map[string]*rsa.PublicKey
+	fetched     time.Time
+	lastAttempt time.Time
+	lastErr     error
+}
+
+// NewJWKS creates a key set for url. Keys are fetched on first use.
+func NewJWKS(url string) *JWKS {
+	return &JWKS{
+		url:             url,
+		client:          &http.Client{Timeout: 10 * time.Second},
+		refreshInterval: DefaultJWKSRefreshInterval,
+	}
+}
+
+// jsonWebKey is the subset of RFC 7517 fields needed for RSA keys
+type jsonWebKey struct {
+	KeyType string `json:"kty"`
+	KeyID   string `json:"kid"`
+	Use     string `json:"use"`
+	N       string `json:"n"`
+	E       string `json:"e"`
+}
+
+// Key returns the RSA key with the given id. An empty id is accepted when
+// the set contains exactly one key. A cached key is returned at once, even
+// when the set is due for a refresh, which then runs in the background.
+func (j *JWKS) Key(ctx context.Context, keyID string) (*rsa.PublicKey, error) {
+	j.mu.Lock()
+	key, ok := j.lookup(keyID)
+	due := time.Since(j.fetched) >= j.refreshInterval && time.Since(j.lastAttempt) >= jwksMinRefreshInterval
+	j.mu.Unlock()
+	if ok {
+		if due {
+			go j.refresh(context.WithoutCancel(ctx))
+		}
+		return key, nil
+	}
+
+	if err := j.refresh(ctx); err != nil {
+		return nil, err
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.keys == nil && j.lastErr != nil {
+		return nil, j.lastErr
+	}
+	if key, ok := j.lookup(keyID); ok {
+		return key, nil
+	}
+	return nil, fmt.Errorf("%w: kid %q", ErrUnknownKey, keyID)
+}
+
+// refresh fetches the key set unless a fetch was attempted within the last
+// minute, so an unreachable provider or a flood of unknown key ids is not
+// amplified. Concurrent callers share one fetch, made without holding j.mu
+// and detached from ctx so a cancelled request does not fail it for the
+// others; ctx only bounds how long the caller waits. Failed fetches keep
+// the previous keys.
+func (j *JWKS) refresh(ctx context.Context) error {
+	fetchCtx := context.WithoutCancel(ctx)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		j.refreshes.Do(j.url, func() (struct{}, error) {
+			j.mu.Lock()
+			if time.Since(j.lastAttempt) < jwksMinRefreshInterval {
+				j.mu.Unlock()
+				return struct{}{}, nil
+			}
+			j.lastAttempt = time.Now()
+			j.mu.Unlock()
+
+			keys, err := j.fetch(fetchCtx)
+
+			j.mu.Lock()
+			defer j.mu.Unlock()
+			j.lastErr = err
+			if err == nil {
+				j.keys = keys
+				j.fetched = time.Now()
+			}
+			return struct{}{}, err
+		})
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// lookup finds a cached key; j.mu must be held
+func (j *JWKS) lookup(keyID string) (*rsa.PublicKey, bool) {
+	if keyID == "" && len(j.keys) == 1 {
+		for _, key := range j.keys {
+			return key, true
+		}
+	}
+	key, ok := j.keys[keyID]
+	return key, ok
+}
+
+// fetch downloads the key set
+func (j *JWKS) fetch(ctx context.Context) (map[string]*rsa.PublicKey, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, j.url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create JWKS request: %w", err)
+	}
+	resp, err := j.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch JWKS: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch JWKS: %s", resp.Status)
+	}
+
+	var set struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxJWKSSize)).Decode(&set); err != nil {
+		return nil, fmt.Errorf("failed to decode JWKS: %w", err)
+	}
+
+	keys := make(map[string]*rsa.PublicKey)
+	for _, jwk := range set.Keys {
+		if jwk.KeyType != "RSA" || (jwk.Use != "" && jwk.Use != "sig") {
+			continue
+		}
+		key, err := jwk.rsaPublicKey()
+		if err != nil {
+			continue
+		}
+		keys[jwk.KeyID] = key
+	}
+	return keys, nil
+}
+
+// rsaPublicKey decodes the modulus and exponent of an RSA JWK
+func (k jsonWebKey) rsaPublicKey() (*rsa.PublicKey, error) {
+	n, err := base64.RawURLEncoding.DecodeString(k.N)
+	if err != nil {
+		return nil, err
+	}
+	e, err := base64.RawURLEncoding.DecodeString(k.E)
+	if err != nil {
+		return nil, err
+	}
+	exponent := new(big.Int).SetBytes(e)
+	if len(n) == 0 || !exponent.IsInt64() || exponent.Int64() < 3 || exponent.Int64() > 1<<31-1 {
+		return nil, fmt.Errorf("invalid RSA key %q", k.KeyID)
+	}
+	return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(exponent.Int64())}, nil
+}
@@ -0,0 +1,301 @@
+package security
+
+import (
+	"context"
+	"crypto"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/subtle"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// JWT errors
+var (
+	ErrInvalidToken         = errors.New("invalid token")
+	ErrUnsupportedAlgorithm = errors.New("unsupported token algorithm")
+	ErrInvalidSignature     = errors.New("invalid token signature")
+	ErrTokenExpired         = errors.New("token is expired")
+	ErrTokenNotYetValid     = errors.New("token is not valid yet")
+	ErrInvalidIssuer        = errors.New("invalid token issuer")
+	ErrInvalidAudience      = errors.New("invalid token audience")
+	ErrUnknownKey           = errors.New("unknown signing key")
+)
+
+// DefaultJWTLeeway is the clock skew tolerated for exp, nbf and iat
+const DefaultJWTLeeway = time.Minute
+
+// maxTokenLength bounds the size of a token accepted for parsing
+const maxTokenLength = 16 * 1024
+
+// JWTConfig configures a JWTVerifier. At least one of Secret (HS256),
+// PublicKey (RS256) or JWKS (RS256) must be set; Issuer and Audience are
+// only checked when non-empty.
+type JWTConfig struct {
+	Secret    []byte
+	PublicKey *rsa.PublicKey
+	JWKS      *JWKS
+	Issuer    string
+	Audience  string
+	Leeway    time.Duration
+}
+
+// JWTVerifier validates compact JWS tokens
+type JWTVerifier struct {
+	config JWTConfig
+	now    func() time.Time
+}
+
+// NewJWTVerifier creates a verifier from config
+func NewJWTVerifier(config JWTConfig) (*JWTVerifier, error) {
+	if len(config.Secret) == 0 && config.PublicKey == nil && config.JWKS == nil {
+		return nil, errors.New("jwt: a secret, public key or JWKS URL is required")
+	}
+	if config.Leeway == 0 {
+		config.Leeway = DefaultJWTLeeway
+	}
+	return &JWTVerifier{config: config, now: time.Now}, nil
+}
+
+// Claims holds the decoded claims of a verified token
+type Claims map[string]interface{}
+
+// Subject returns the "sub" claim
+func (c Claims) Subject() string {
+	s, _ := c["sub"].(string)
+	return s
+}
+
+// Issuer returns the "iss" claim
+func (c Claims) Issuer() string {
+	s, _ := c["iss"].(string)
+	return s
+}
+
+// Audience returns the "aud" claim, which may be a string or an array
+func (c Claims) Audience() []string {
+	switch aud := c["aud"].(type) {
+	case string:
+		return []string{aud}
+	case []interface{}:
+		audiences := make([]string, 0, len(aud))
+		for _, a := range aud {
+			if s, ok := a.(string); ok {
+				audiences = append(audiences, s)
+			}
+		}
+		return audiences
+	}
+	return nil
+}
+
+// HasScope reports whether the token grants scope, from the space-separated
+// "scope" claim (RFC 8693) or the "scp" claim, a string or an array
+func (c Claims) HasScope(scope string) bool {
+	for _, name := range []string{"scope", "scp"} {
+		switch granted := c[name].(type) {
+		case string:
+			for _, s := range strings.Fields(granted) {
+				if s == scope {
+					return true
+				}
+			}
+		case []interface{}:
+			for _, s := range granted {
+				if s == scope {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// time returns a NumericDate claim and whether it is present and valid
+func (c Claims) time(name string) (time.Time, bool, error) {
+	v, ok := c[name]
+	if !ok {
+		return time.Time{}, false, nil
+	}
+	n, ok := v.(float64)
+	if !ok {
+		return time.Time{}, false, fmt.Errorf("%w: %s is not a number", ErrInvalidToken, name)
+	}
+	return time.Unix(int64(n), 0), true, nil
+}
+
+// jwtHeader is the JOSE header of a token
+type jwtHeader struct {
+	Algorithm string `json:"alg"`
+	KeyID     string `json:"kid"`
+}
+
+// Verify checks the token signature and its exp, nbf, iat, iss and aud
+// claims, and returns the claims
+func (v *JWTVerifier) Verify(ctx context.Context, token string) (Claims, error) {
+	if len(token) > maxTokenLength {
+		return nil, fmt.Errorf("%w: token too long", ErrInvalidToken)
+	}
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("%w: expected three segments", ErrInvalidToken)
+	}
+
+	var header jwtHeader
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, err
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("%w: malformed signature", ErrInvalidToken)
+	}
+	if err := v.verifySignature(ctx, header, parts[0]+"."+parts[1], signature); err != nil {
+		return nil, err
+	}
+
+	var claims Claims
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, err
+	}
+	if err := v.validateClaims(claims); err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+// verifySignature checks the signature with the key matching the header's
+// algorithm. Each algorithm only uses its own key type, so an RSA public
+// key can never be used as an HMAC secret.
+func (v *JWTVerifier) verifySignature(ctx context.Context, header jwtHeader, signed string, signature []byte) error {
+	switch header.Algorithm {
+	case "HS256":
+		if len(v.config.Secret) == 0 {
+			return fmt.Errorf("%w: %s", ErrUnsupportedAlgorithm, header.Algorithm)
+		}
+		mac := hmac.New(sha256.New, v.config.Secret)
+		mac.Write([]byte(signed))
+		if subtle.ConstantTimeCompare(mac.Sum(nil), signature) != 1 {
+			return ErrInvalidSignature
+		}
+		return nil
+
+	case "RS256":
+		key, err := v.rsaKey(ctx, header.KeyID)
+		if err != nil {
+			return err
+		}
+		digest := sha256.Sum256([]byte(signed))
+		if err := rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], signature); err != nil {
+			return ErrInvalidSignature
+		}
+		return nil
+
+	default:
+		return fmt.Errorf("%w: %q", ErrUnsupportedAlgorithm, header.Algorithm)
+	}
+}
+
+// rsaKey returns the configured public key, or the JWKS key with the given id
+func (v *JWTVerifier) rsaKey(ctx context.Context, keyID string) (*rsa.PublicKey, error) {
+	if v.config.JWKS != nil && (keyID != "" || v.config.PublicKey == nil) {
+		return v.config.JWKS.Key(ctx, keyID)
+	}
+	if v.config.PublicKey == nil {
+		return nil, fmt.Errorf("%w: RS256", ErrUnsupportedAlgorithm)
+	}
+	return v.config.PublicKey, nil
+}
+
+// validateClaims checks the time-based claims and the issuer and audience
+func (v *JWTVerifier) validateClaims(claims Claims) error {
+	now := v.now()
+	leeway := v.config.Leeway
+
+	if exp, ok, err := claims.time("exp"); err != nil {
+		return err
+	} else if ok && !now.Before(exp.Add(leeway)) {
+		return ErrTokenExpired
+	}
+	if nbf, ok, err := claims.time("nbf"); err != nil {
+		return err
+	} else if ok && now.Add(leeway).Before(nbf) {
+		return ErrTokenNotYetValid
+	}
+	if iat, ok, err := claims.time("iat"); err != nil {
+		return err
+	} else if ok && now.Add(leeway).Before(iat) {
+		return ErrTokenNotYetValid
+	}
+
+	if v.config.Issuer != "" && claims.Issuer() != v.config.Issuer {
+		return fmt.Errorf("%w: %q", ErrInvalidIssuer, claims.Issuer())
+	}
+	if v.config.Audience != "" {
+		found := false
+		for _, aud := range claims.Audience() {
+			if aud == v.config.Audience {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("%w: %v", ErrInvalidAudience, claims.Audience())
+		}
+	}
+	return nil
+}
+
+// decodeSegment base64url-decodes a token segment into v
+func decodeSegment(segment string, v interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return fmt.Errorf("%w: malformed segment", ErrInvalidToken)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+	return nil
+}
+
+// ParseRSAPublicKeyPEM parses a PKIX ("PUBLIC KEY") or PKCS#1
+// ("RSA PUBLIC KEY") PEM-encoded RSA public key
+func ParseRSAPublicKeyPEM(data []byte) (*rsa.PublicKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM block found")
+	}
+
+	if block.Type == "RSA PUBLIC KEY" {
+		return x509.ParsePKCS1PublicKey(block.Bytes)
+	}
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	rsaKey, ok := key.(*rsa.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("unsupported public key type %T", key)
+	}
+	return rsaKey, nil
+}
+
+// claimsContextKey is the context key for verified token claims
+type claimsContextKey struct{}
+
+// NewClaimsContext returns a context carrying the claims
+func NewClaimsContext(ctx context.Context, claims Claims) context.Context {
+	return context.WithValue(ctx, claimsContextKey{}, claims)
+}
+
+// ClaimsFromContext returns the claims stored by NewClaimsContext
+func ClaimsFromContext(ctx context.Context) (Claims, bool) {
+	claims, ok := ctx.Value(claimsContextKey{}).(Claims)
+	return claims, ok
+}
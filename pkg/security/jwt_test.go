@@ -0,0 +1,318 @@
+package security
+
+import (
+	"context"
+	"crypto"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+var testSecret = []byte("test-secret")
+
+func encodeSegment(t *testing.T, v interface{}) string {
+	t.Helper()
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func signHS256(t *testing.T, header, claims map[string]interface{}) string {
+	t.Helper()
+	signed := encodeSegment(t, header) + "." + encodeSegment(t, claims)
+	mac := hmac.New(sha256.New, testSecret)
+	mac.Write([]byte(signed))
+	return signed + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func signRS256(t *testing.T, key *rsa.PrivateKey, kid string, claims map[string]interface{}) string {
+	t.Helper()
+	header := map[string]interface{}{"alg": "RS256", "typ": "JWT"}
+	if kid != "" {
+		header["kid"] = kid
+	}
+	signed := encodeSegment(t, header) + "." + encodeSegment(t, claims)
+	digest := sha256.Sum256([]byte(signed))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+// tamper replaces the claims segment of a token, keeping its signature
+func tamper(token, claims string) string {
+	parts := strings.Split(token, ".")
+	return parts[0] + "." + claims + "." + parts[2]
+}
+
+func TestJWTVerifierHS256(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	verifier, err := NewJWTVerifier(JWTConfig{Secret: testSecret, Issuer: "https://idp.example", Audience: "cat-server"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	verifier.now = func() time.Time { return now }
+
+	hs := map[string]interface{}{"alg": "HS256", "typ": "JWT"}
+	valid := map[string]interface{}{
+		"sub": "alice", "iss": "https://idp.example", "aud": []string{"other", "cat-server"},
+		"exp": now.Add(time.Hour).Unix(), "iat": now.Unix(),
+	}
+	with := func(key string, value interface{}) map[string]interface{} {
+		claims := make(map[string]interface{}, len(valid))
+		for k, v := range valid {
+			claims[k] = v
+		}
+		claims[key] = value
+		return claims
+	}
+
+	tests := []struct {
+		name     string
+		token    string
+		expected error
+	}{
+		{"valid", signHS256(t, hs, valid), nil},
+		{"string audience", signHS256(t, hs, with("aud", "cat-server")), nil},
+		{"within leeway", signHS256(t, hs, with("exp", now.Add(-30*time.Second).Unix())), nil},
+		{"expired", signHS256(t, hs, with("exp", now.Add(-time.Hour).Unix())), ErrTokenExpired},
+		{"not yet valid", signHS256(t, hs, with("nbf", now.Add(time.Hour).Unix())), ErrTokenNotYetValid},
+		{"wrong issuer", signHS256(t, hs, with("iss", "https://evil.example")), ErrInvalidIssuer},
+		{"wrong audience", signHS256(t, hs, with("aud", "someone-else")), ErrInvalidAudience},
+		{"bad exp type", signHS256(t, hs, with("exp", "tomorrow")), ErrInvalidToken},
+		{"alg none", encodeSegment(t, map[string]string{"alg": "none"}) + "." + encodeSegment(t, valid) + ".", ErrUnsupportedAlgorithm},
+		{"RS256 without key", signHS256(t, map[string]interface{}{"alg": "RS256"}, valid), ErrUnsupportedAlgorithm},
+		{"tampered", tamper(signHS256(t, hs, valid), encodeSegment(t, with("sub", "mallory"))), ErrInvalidSignature},
+		{"two segments", "abc.def", ErrInvalidToken},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims, err := verifier.Verify(context.Background(), tt.token)
+			if tt.expected == nil {
+				if err != nil {
+					t.Fatalf("Expected no error, got %v", err)
+				}
+				if claims.Subject() != "alice" {
+					t.Errorf("Expected subject alice, got %q", claims.Subject())
+				}
+			} else if !errors.Is(err, tt.expected) {
+				t.Errorf("Expected %v, got %v", tt.expected, err)
+			}
+		})
+	}
+}
+
+func TestJWTVerifierRS256(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	verifier, err := NewJWTVerifier(JWTConfig{PublicKey: &key.PublicKey})
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims := map[string]interface{}{"sub": "bob", "exp": time.Now().Add(time.Hour).Unix()}
+
+	if _, err := verifier.Verify(context.Background(), signRS256(t, key, "", claims)); err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+
+	other, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := verifier.Verify(context.Background(), signRS256(t, other, "", claims)); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("Expected ErrInvalidSignature, got %v", err)
+	}
+
+	// An HS256 token must not be accepted when only an RSA key is configured
+	forged := signHS256(t, map[string]interface{}{"alg": "HS256"}, claims)
+	if _, err := verifier.Verify(context.Background(), forged); !errors.Is(err, ErrUnsupportedAlgorithm) {
+		t.Errorf("Expected ErrUnsupportedAlgorithm, got %v", err)
+	}
+}
+
+func TestJWTVerifierJWKS(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var fetches atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches.Add(1)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"keys": []map[string]string{
+				{"kty": "EC", "kid": "ignored"},
+				{
+					"kty": "RSA", "kid": "key-1", "use": "sig",
+					"n": base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+					"e": base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+				},
+			},
+		})
+	}))
+	defer server.Close()
+
+	verifier, err := NewJWTVerifier(JWTConfig{JWKS: NewJWKS(server.URL)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims := map[string]interface{}{"sub": "carol"}
+
+	for i := 0; i < 3; i++ {
+		if _, err := verifier.Verify(context.Background(), signRS256(t, key, "key-1", claims)); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+	}
+	if _, err := verifier.Verify(context.Background(), signRS256(t, key, "key-2", claims)); !errors.Is(err, ErrUnknownKey) {
+		t.Errorf("Expected ErrUnknownKey, got %v", err)
+	}
+	if fetches.Load() != 1 {
+		t.Errorf("Expected 1 JWKS fetch, got %d", fetches.Load())
+	}
+}
+
+// blockingJWKSServer serves key as key-1 once release is closed
+func blockingJWKSServer(t *testing.T, key *rsa.PrivateKey, release <-chan struct{}, fetches *atomic.Int32) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches.Add(1)
+		<-release
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"keys": []map[string]string{{
+				"kty": "RSA", "kid": "key-1",
+				"n": base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+				"e": base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+			}},
+		})
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestJWKSConcurrentFetch(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	release := make(chan struct{})
+	var fetches atomic.Int32
+	jwks := NewJWKS(blockingJWKSServer(t, key, release, &fetches).URL)
+
+	// Callers share one fetch; one giving up does not fail it for the others
+	const callers = 8
+	errs := make(chan error, callers)
+	for range callers {
+		go func() {
+			_, err := jwks.Key(context.Background(), "key-1")
+			errs <- err
+		}()
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancelled := make(chan error, 1)
+	go func() {
+		_, err := jwks.Key(ctx, "key-1")
+		cancelled <- err
+	}()
+	cancel()
+	if err := <-cancelled; !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected the cancelled caller to stop waiting, got %v", err)
+	}
+
+	close(release)
+	for range callers {
+		if err := <-errs; err != nil {
+			t.Errorf("Expected the key, got %v", err)
+		}
+	}
+	if fetches.Load() != 1 {
+		t.Errorf("Expected 1 JWKS fetch, got %d", fetches.Load())
+	}
+}
+
+func TestJWKSRefreshDoesNotBlock(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	release := make(chan struct{})
+	var fetches atomic.Int32
+	jwks := NewJWKS(blockingJWKSServer(t, key, release, &fetches).URL)
+
+	// Prime the cache, then make it due for a refresh that hangs
+	go func() { release <- struct{}{} }()
+	if _, err := jwks.Key(context.Background(), "key-1"); err != nil {
+		t.Fatal(err)
+	}
+	jwks.mu.Lock()
+	jwks.refreshInterval = 0
+	jwks.lastAttempt = time.Time{}
+	jwks.mu.Unlock()
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := jwks.Key(context.Background(), "key-1")
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("Expected the cached key, got %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Expected the cached key while the refresh is pending")
+	}
+	close(release)
+}
+
+func TestNewJWTVerifierRequiresKey(t *testing.T) {
+	if _, err := NewJWTVerifier(JWTConfig{Issuer: "https://idp.example"}); err == nil {
+		t.Error("Expected error without a key source")
+	}
+}
+
+func TestClaimsContext(t *testing.T) {
+	ctx := NewClaimsContext(context.Background(), Claims{"sub": "alice"})
+	claims, ok := ClaimsFromContext(ctx)
+	if !ok || claims.Subject() != "alice" {
+		t.Errorf("Expected claims for alice, got %v", claims)
+	}
+	if _, ok := ClaimsFromContext(context.Background()); ok {
+		t.Error("Expected no claims in empty context")
+	}
+}
+
+func TestClaimsHasScope(t *testing.T) {
+	tests := []struct {
+		claims   Claims
+		expected bool
+	}{
+		{Claims{"scope": "openid secrets:read"}, true},
+		{Claims{"scope": "openid secrets:readonly"}, false},
+		{Claims{"scp": "secrets:read"}, true},
+		{Claims{"scp": []interface{}{"files", "secrets:read"}}, true},
+		{Claims{"scp": []interface{}{"files"}}, false},
+		{Claims{"sub": "alice"}, false},
+	}
+	for _, tt := range tests {
+		if got := tt.claims.HasScope("secrets:read"); got != tt.expected {
+			t.Errorf("HasScope for %v = %v, expected %v", tt.claims, got, tt.expected)
+		}
+	}
+}
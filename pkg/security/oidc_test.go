@@ -0,0 +1,121 @@
+package security
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeIDP is a minimal OpenID provider issuing ID tokens for one code
+type fakeIDP struct {
+	*httptest.Server
+	key   *rsa.PrivateKey
+	nonce string
+}
+
+func newFakeIDP(t *testing.T) *fakeIDP {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	idp := &fakeIDP{key: key}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 idp.URL,
+			"authorization_endpoint": idp.URL + "/authorize",
+			"token_endpoint":         idp.URL + "/token",
+			"jwks_uri":               idp.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"keys": []map[string]string{{
+				"kty": "RSA", "kid": "idp-key",
+				"n": base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+				"e": base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+			}},
+		})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		clientID, secret, ok := r.BasicAuth()
+		if !ok || clientID != "cat-server" || secret != "client-secret" || r.PostFormValue("code") != "good-code" {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]string{
+			"access_token": "opaque",
+			"id_token": signRS256(t, key, "idp-key", map[string]interface{}{
+				"iss": idp.URL, "aud": "cat-server", "sub": "alice", "email": "alice@example.com",
+				"nonce": idp.nonce, "exp": time.Now().Add(time.Hour).Unix(),
+			}),
+		})
+	})
+	idp.Server = httptest.NewServer(mux)
+	t.Cleanup(idp.Close)
+	return idp
+}
+
+func TestOIDCProviderFlow(t *testing.T) {
+	idp := newFakeIDP(t)
+	provider, err := NewOIDCProvider(OIDCConfig{
+		IssuerURL:    idp.URL,
+		ClientID:     "cat-server",
+		ClientSecret: "client-secret",
+		RedirectURL:  "https://files.example/auth/callback",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	loginURL, err := provider.AuthCodeURL(context.Background(), "state-1", "nonce-1")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	parsed, _ := url.Parse(loginURL)
+	query := parsed.Query()
+	if !strings.HasPrefix(loginURL, idp.URL+"/authorize?") || query.Get("state") != "state-1" ||
+		query.Get("nonce") != "nonce-1" || query.Get("response_type") != "code" || !strings.Contains(query.Get("scope"), "openid") {
+		t.Errorf("Unexpected login URL %s", loginURL)
+	}
+
+	idp.nonce = "nonce-1"
+	claims, err := provider.Exchange(context.Background(), "good-code", "nonce-1")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if claims.Subject() != "alice" || claims["email"] != "alice@example.com" {
+		t.Errorf("Unexpected claims %v", claims)
+	}
+
+	if _, err := provider.Exchange(context.Background(), "good-code", "other-nonce"); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("Expected nonce mismatch, got %v", err)
+	}
+	if _, err := provider.Exchange(context.Background(), "bad-code", "nonce-1"); !errors.Is(err, ErrOIDCExchange) {
+		t.Errorf("Expected ErrOIDCExchange, got %v", err)
+	}
+}
+
+func TestOIDCProviderDiscoveryFailure(t *testing.T) {
+	idp := newFakeIDP(t)
+	provider, err := NewOIDCProvider(OIDCConfig{IssuerURL: idp.URL + "/other", ClientID: "cat-server", RedirectURL: "https://files.example/cb"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := provider.AuthCodeURL(context.Background(), "s", "n"); err == nil {
+		t.Error("Expected discovery to fail for an unknown issuer")
+	}
+}
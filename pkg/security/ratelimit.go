@@ -0,0 +1,150 @@
+package security
+
+import (
+	"math"
+	"net/netip"
+	"sync"
+	"time"
+)
+
+// tokenBucket refills at rate tokens per second up to burst tokens
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// refill adds the tokens accrued since the last update
+func (b *tokenBucket) refill(now time.Time, rate float64, burst int) {
+	if elapsed := now.Sub(b.last).Seconds(); elapsed > 0 {
+		b.tokens = math.Min(float64(burst), b.tokens+elapsed*rate)
+	}
+	b.last = now
+}
+
+// wait returns how long until a token is available
+func (b *tokenBucket) wait(rate float64) time.Duration {
+	if b.tokens >= 1 {
+		return 0
+	}
+	return time.Duration((1 - b.tokens) / rate * float64(time.Second))
+}
+
+// untilFull returns how long until the bucket is full again
+func (b *tokenBucket) untilFull(rate float64, burst int) time.Duration {
+	return time.Duration((float64(burst) - b.tokens) / rate * float64(time.Second))
+}
+
+// RateLimitDecision describes the outcome of a rate limit check
+type RateLimitDecision struct {
+	Allowed    bool
+	Limit      int           // burst size of the client bucket
+	Remaining  int           // whole tokens left in the client bucket
+	Reset      time.Duration // until the client bucket is full
+	RetryAfter time.Duration // until the request may be retried when denied
+}
+
+// RateLimiter enforces token-bucket limits per client address and,
+// optionally, across all clients. A request only consumes tokens when both
+// buckets admit it.
+type RateLimiter struct {
+	rate        float64
+	burst       int
+	globalRate  float64
+	globalBurst int
+	now         func() time.Time
+
+	mu        sync.Mutex
+	clients   map[netip.Addr]*tokenBucket
+	global    *tokenBucket
+	lastPrune time.Time
+}
+
+// NewRateLimiter creates a limiter allowing rate requests per second with
+// bursts of burst per client. A globalRate of zero disables the global limit.
+func NewRateLimiter(rate float64, burst int, globalRate float64, globalBurst int) *RateLimiter {
+	l := &RateLimiter{
+		rate:        rate,
+		burst:       max(burst, 1),
+		globalRate:  globalRate,
+		globalBurst: max(globalBurst, 1),
+		now:         time.Now,
+		clients:     make(map[netip.Addr]*tokenBucket),
+	}
+	if globalRate > 0 {
+		l.global = &tokenBucket{tokens: float64(l.globalBurst), last: l.now()}
+	}
+	return l
+}
+
+// Allow checks and, if admitted, charges one request to addr. Requests with
+// an invalid address are only subject to the global limit.
+func (l *RateLimiter) Allow(addr netip.Addr) RateLimitDecision {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.prune(now)
+
+	var client *tokenBucket
+	if addr.IsValid() {
+		addr = addr.Unmap()
+		client = l.clients[addr]
+		if client == nil {
+			client = &tokenBucket{tokens: float64(l.burst), last: now}
+			l.clients[addr] = client
+		}
+		client.refill(now, l.rate, l.burst)
+	}
+	if l.global != nil {
+		l.global.refill(now, l.globalRate, l.globalBurst)
+	}
+
+	decision := RateLimitDecision{Limit: l.burst}
+	if client != nil {
+		decision.RetryAfter = client.wait(l.rate)
+	}
+	if l.global != nil {
+		decision.RetryAfter = max(decision.RetryAfter, l.global.wait(l.globalRate))
+	}
+
+	if decision.RetryAfter == 0 {
+		decision.Allowed = true
+		if client != nil {
+			client.tokens--
+		}
+		if l.global != nil {
+			l.global.tokens--
+		}
+	}
+
+	if client != nil {
+		decision.Remaining = int(client.tokens)
+		decision.Reset = client.untilFull(l.rate, l.burst)
+	} else {
+		decision.Remaining = l.burst
+	}
+	return decision
+}
+
+// Len returns the number of tracked client buckets
+func (l *RateLimiter) Len() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return len(l.clients)
+}
+
+// prune drops client buckets that have refilled completely, at most once
+// per refill period; l.mu must be held
+func (l *RateLimiter) prune(now time.Time) {
+	fullAfter := time.Duration(float64(l.burst) / l.rate * float64(time.Second))
+	if now.Sub(l.lastPrune) < fullAfter {
+		return
+	}
+	l.lastPrune = now
+
+	for addr, bucket := range l.clients {
+		if now.Sub(bucket.last) >= fullAfter {
+			delete(l.clients, addr)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package security
+
+import (
+	"net/netip"
+	"testing"
+	"time"
+)
+
+func TestRateLimiterPerClient(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	limiter := NewRateLimiter(2, 3, 0, 0)
+	limiter.now = func() time.Time { return now }
+
+	alice := netip.MustParseAddr("192.0.2.1")
+	bob := netip.MustParseAddr("192.0.2.2")
+
+	for i := 0; i < 3; i++ {
+		decision := limiter.Allow(alice)
+		if !decision.Allowed || decision.Remaining != 2-i || decision.Limit != 3 {
+			t.Fatalf("Request %d: unexpected decision %+v", i, decision)
+		}
+	}
+
+	decision := limiter.Allow(alice)
+	if decision.Allowed || decision.RetryAfter != 500*time.Millisecond {
+		t.Errorf("Expected denial with 500ms retry, got %+v", decision)
+	}
+	if !limiter.Allow(bob).Allowed {
+		t.Error("Expected other clients to have their own bucket")
+	}
+
+	now = now.Add(500 * time.Millisecond)
+	if decision := limiter.Allow(alice); !decision.Allowed || decision.Remaining != 0 {
+		t.Errorf("Expected one refilled token, got %+v", decision)
+	}
+
+	// Idle buckets are dropped once they would be full again
+	now = now.Add(2 * time.Second)
+	limiter.Allow(netip.MustParseAddr("192.0.2.3"))
+	if limiter.Len() != 1 {
+		t.Errorf("Expected idle buckets to be pruned, got %d", limiter.Len())
+	}
+}
+
+func TestRateLimiterGlobal(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	limiter := NewRateLimiter(100, 100, 1, 2)
+	limiter.now = func() time.Time { return now }
+
+	addrs := []string{"192.0.2.1", "192.0.2.2", "192.0.2.3"}
+	allowed := 0
+	for _, addr := range addrs {
+		if limiter.Allow(netip.MustParseAddr(addr)).Allowed {
+			allowed++
+		}
+	}
+	if allowed != 2 {
+		t.Errorf("Expected the global burst of 2 to be shared, got %d allowed", allowed)
+	}
+
+	// A request denied by the global limit does not consume the client token
+	decision := limiter.Allow(netip.MustParseAddr("192.0.2.3"))
+	if decision.Allowed || decision.Remaining != 100 || decision.RetryAfter != time.Second {
+		t.Errorf("Unexpected decision %+v", decision)
+	}
+
+	if !NewRateLimiter(1, 1, 0, 0).Allow(netip.Addr{}).Allowed {
+		t.Error("Expected requests without an address to pass without a global limit")
+	}
+}
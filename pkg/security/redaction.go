@@ -0,0 +1,72 @@
+package security
+
+import (
+	"path"
+	"regexp"
+	"strings"
+)
+
+// RedactionMask replaces redacted values
+const RedactionMask = "***"
+
+// assignmentLine matches "KEY=value", "export KEY=value", "key: value" and
+// "\"key\": \"value\"," lines, capturing the prefix up to the value, the key,
+// the value and any trailing comma
+var assignmentLine = regexp.MustCompile(`^(\s*(?:export\s+)?["']?([\w.\-]+)["']?\s*[:=]\s*)(.*?)(,?\s*)$`)
+
+// Redactor masks secrets in files whose names match its patterns, such as
+// .env files and PEM keys
+type Redactor struct {
+	files   []string
+	keys    *regexp.Regexp
+	pattern *regexp.Regexp
+}
+
+// NewRedactor creates a redactor for files matching the glob patterns, which
+// are matched against the base name and the full path. Values assigned to
+// keys matching keys are masked, as is any text matching pattern; either
+// may be nil.
+func NewRedactor(files []string, keys, pattern *regexp.Regexp) *Redactor {
+	return &Redactor{files: files, keys: keys, pattern: pattern}
+}
+
+// Matches reports whether the file's contents are redacted
+func (r *Redactor) Matches(filename string) bool {
+	filename = strings.TrimPrefix(path.Clean("/"+filename), "/")
+	base := path.Base(filename)
+	for _, glob := range r.files {
+		if ok, _ := path.Match(glob, base); ok {
+			return true
+		}
+		if ok, _ := path.Match(glob, filename); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Redact returns content with matches of the pattern and values of
+// matching keys replaced by RedactionMask. Quoted values keep their quotes,
+// e.g. SECRET_KEY="abc" becomes SECRET_KEY="***".
+func (r *Redactor) Redact(content string) string {
+	if r.pattern != nil {
+		content = r.pattern.ReplaceAllLiteralString(content, RedactionMask)
+	}
+	if r.keys == nil {
+		return content
+	}
+
+	lines := strings.Split(content, "\n")
+	for i, line := range lines {
+		m := assignmentLine.FindStringSubmatch(line)
+		if m == nil || m[3] == "" || !r.keys.MatchString(m[2]) {
+			continue
+		}
+		value := RedactionMask
+		if q := m[3][0]; (q == '"' || q == '\'') && len(m[3]) > 1 && m[3][len(m[3])-1] == q {
+			value = string(q) + RedactionMask + string(q)
+		}
+		lines[i] = m[1] + value + m[4]
+	}
+	return strings.Join(lines, "\n")
+}
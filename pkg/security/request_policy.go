@@ -0,0 +1,142 @@
+package security
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// Request policy actions
+const (
+	PolicyActionBlock = "block"
+	PolicyActionTag   = "tag"
+)
+
+// RequestRule is one entry of a request policy file. A rule matches when
+// every condition it sets matches:
+//
+//   - UserAgent and Referer are regular expressions matched against the
+//     request headers
+//   - RefererHosts lists the hosts allowed to link to the server; a request
+//     whose Referer names another host matches, one without a Referer does
+//     not, which blocks hotlinking while allowing direct downloads
+//   - PathPrefix limits the rule to request paths with that prefix
+type RequestRule struct {
+	Name         string   `json:"name"`
+	Action       string   `json:"action"`
+	UserAgent    string   `json:"user_agent"`
+	Referer      string   `json:"referer"`
+	RefererHosts []string `json:"referer_hosts"`
+	PathPrefix   string   `json:"path_prefix"`
+
+	userAgent *regexp.Regexp
+	referer   *regexp.Regexp
+}
+
+// RequestPolicy blocks or tags requests by their User-Agent and Referer
+// headers. Rules are evaluated in order; the first matching block rule
+// wins, and every matching tag rule before it is reported.
+type RequestPolicy struct {
+	Rules []*RequestRule `json:"rules"`
+}
+
+// LoadRequestPolicy reads a JSON request policy file of the form
+//
+//	{"rules": [{"name": "scrapers", "action": "block", "user_agent": "(?i)scrapy"}]}
+func LoadRequestPolicy(path string) (*RequestPolicy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var policy RequestPolicy
+	if err := json.Unmarshal(data, &policy); err != nil {
+		return nil, fmt.Errorf("request policy %s: %w", path, err)
+	}
+	if err := policy.compile(); err != nil {
+		return nil, fmt.Errorf("request policy %s: %w", path, err)
+	}
+	return &policy, nil
+}
+
+// compile validates the rules and compiles their patterns
+func (p *RequestPolicy) compile() error {
+	for i, rule := range p.Rules {
+		if rule.Name == "" {
+			return fmt.Errorf("rule %d: name is required", i+1)
+		}
+		if rule.Action != PolicyActionBlock && rule.Action != PolicyActionTag {
+			return fmt.Errorf("rule %q: action must be %q or %q", rule.Name, PolicyActionBlock, PolicyActionTag)
+		}
+		if rule.UserAgent == "" && rule.Referer == "" && len(rule.RefererHosts) == 0 {
+			return fmt.Errorf("rule %q: user_agent, referer or referer_hosts is required", rule.Name)
+		}
+
+		var err error
+		if rule.UserAgent != "" {
+			if rule.userAgent, err = regexp.Compile(rule.UserAgent); err != nil {
+				return fmt.Errorf("rule %q: user_agent: %w", rule.Name, err)
+			}
+		}
+		if rule.Referer != "" {
+			if rule.referer, err = regexp.Compile(rule.Referer); err != nil {
+				return fmt.Errorf("rule %q: referer: %w", rule.Name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// Evaluate returns the first block rule matching r, or nil, and the names
+// of the tag rules matched before it
+func (p *RequestPolicy) Evaluate(r *http.Request) (*RequestRule, []string) {
+	var tags []string
+	for _, rule := range p.Rules {
+		if !rule.matches(r) {
+			continue
+		}
+		if rule.Action == PolicyActionBlock {
+			return rule, tags
+		}
+		tags = append(tags, rule.Name)
+	}
+	return nil, tags
+}
+
+// matches reports whether every condition of the rule matches r
+func (rule *RequestRule) matches(r *http.Request) bool {
+	if rule.PathPrefix != "" && !strings.HasPrefix(r.URL.Path, rule.PathPrefix) {
+		return false
+	}
+	if rule.userAgent != nil && !rule.userAgent.MatchString(r.UserAgent()) {
+		return false
+	}
+	referer := r.Referer()
+	if rule.referer != nil && !rule.referer.MatchString(referer) {
+		return false
+	}
+	if len(rule.RefererHosts) > 0 && (referer == "" || refererHostAllowed(referer, rule.RefererHosts)) {
+		return false
+	}
+	return true
+}
+
+// refererHostAllowed reports whether the Referer names one of hosts or a
+// subdomain of one. Unparseable referers are not allowed.
+func refererHostAllowed(referer string, hosts []string) bool {
+	u, err := url.Parse(referer)
+	if err != nil {
+		return false
+	}
+	host := strings.ToLower(u.Hostname())
+	for _, allowed := range hosts {
+		allowed = strings.ToLower(allowed)
+		if host == allowed || strings.HasSuffix(host, "."+allowed) {
+			return true
+		}
+	}
+	return false
+}
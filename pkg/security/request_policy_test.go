@@ -0,0 +1,85 @@
+package security
+
+import (
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func writePolicy(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "policy.json")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestRequestPolicyEvaluate(t *testing.T) {
+	policy, err := LoadRequestPolicy(writePolicy(t, `{"rules": [
+		{"name": "bots", "action": "tag", "user_agent": "(?i)bot"},
+		{"name": "scrapers", "action": "block", "user_agent": "(?i)(scrapy|python-requests)"},
+		{"name": "hotlink", "action": "block", "path_prefix": "/cat/", "referer_hosts": ["example.com"]},
+		{"name": "search", "action": "tag", "referer": "^https://www\\.google\\."}
+	]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		path      string
+		userAgent string
+		referer   string
+		blockedBy string
+		tags      []string
+	}{
+		{"plain", "/cat/a.txt", "curl/8.0", "", "", nil},
+		{"scraper", "/ls", "Scrapy/2.11", "", "scrapers", nil},
+		{"tagged bot", "/ls", "Googlebot/2.1", "", "", []string{"bots"}},
+		{"tagged scraper bot", "/ls", "python-requests bot", "", "scrapers", []string{"bots"}},
+		{"own referer", "/cat/a.txt", "Mozilla/5.0", "https://example.com/page", "", nil},
+		{"subdomain referer", "/cat/a.txt", "Mozilla/5.0", "https://docs.Example.com/", "", nil},
+		{"hotlink", "/cat/a.txt", "Mozilla/5.0", "https://evil.test/", "hotlink", nil},
+		{"lookalike host", "/cat/a.txt", "Mozilla/5.0", "https://notexample.com/", "hotlink", nil},
+		{"hotlink outside prefix", "/ls", "Mozilla/5.0", "https://evil.test/", "", nil},
+		{"search referer", "/ls", "Mozilla/5.0", "https://www.google.com/", "", []string{"search"}},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest("GET", tt.path, nil)
+		r.Header.Set("User-Agent", tt.userAgent)
+		if tt.referer != "" {
+			r.Header.Set("Referer", tt.referer)
+		}
+		rule, tags := policy.Evaluate(r)
+		blockedBy := ""
+		if rule != nil {
+			blockedBy = rule.Name
+		}
+		if blockedBy != tt.blockedBy || !reflect.DeepEqual(tags, tt.tags) {
+			t.Errorf("%s: Evaluate = %q, %v, expected %q, %v", tt.name, blockedBy, tags, tt.blockedBy, tt.tags)
+		}
+	}
+}
+
+func TestLoadRequestPolicyErrors(t *testing.T) {
+	tests := []struct {
+		policy   string
+		expected string
+	}{
+		{`{"rules": [{"action": "block", "user_agent": "x"}]}`, "name is required"},
+		{`{"rules": [{"name": "a", "action": "drop", "user_agent": "x"}]}`, "action must be"},
+		{`{"rules": [{"name": "a", "action": "block"}]}`, "is required"},
+		{`{"rules": [{"name": "a", "action": "block", "user_agent": "("}]}`, "user_agent"},
+		{`{"rules": [`, "unexpected end"},
+	}
+	for _, tt := range tests {
+		_, err := LoadRequestPolicy(writePolicy(t, tt.policy))
+		if err == nil || !strings.Contains(err.Error(), tt.expected) {
+			t.Errorf("LoadRequestPolicy(%s) returned %v, expected error containing %q", tt.policy, err, tt.expected)
+		}
+	}
+}
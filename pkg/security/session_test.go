@@ -0,0 +1,53 @@
+package security
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSessionCodec(t *testing.T) {
+	codec, err := NewSessionCodec([]byte("0123456789abcdef"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type session struct {
+		Subject string `json:"sub"`
+	}
+	value, err := codec.Encode(session{Subject: "alice"}, time.Hour)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	var decoded session
+	if err := codec.Decode(value, &decoded); err != nil || decoded.Subject != "alice" {
+		t.Errorf("Expected alice, got %+v, %v", decoded, err)
+	}
+
+	encoded, signature, _ := strings.Cut(value, ".")
+	tampered := encoded[:len(encoded)-2] + "AA." + signature
+	if err := codec.Decode(tampered, &decoded); !errors.Is(err, ErrInvalidSession) {
+		t.Errorf("Expected ErrInvalidSession for tampered value, got %v", err)
+	}
+	if err := codec.Decode("garbage", &decoded); !errors.Is(err, ErrInvalidSession) {
+		t.Errorf("Expected ErrInvalidSession for garbage, got %v", err)
+	}
+
+	other, _ := NewSessionCodec([]byte("fedcba9876543210"))
+	if err := other.Decode(value, &decoded); !errors.Is(err, ErrInvalidSession) {
+		t.Errorf("Expected ErrInvalidSession for another key, got %v", err)
+	}
+
+	codec.now = func() time.Time { return time.Now().Add(2 * time.Hour) }
+	if err := codec.Decode(value, &decoded); !errors.Is(err, ErrSessionExpired) {
+		t.Errorf("Expected ErrSessionExpired, got %v", err)
+	}
+}
+
+func TestNewSessionCodecShortKey(t *testing.T) {
+	if _, err := NewSessionCodec([]byte("short")); err == nil {
+		t.Error("Expected error for short key")
+	}
+}
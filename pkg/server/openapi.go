@@ -0,0 +1,168 @@
+package server
+
+import (
+	"net/http"
+	"strings"
+
+	cathttp "github.com/sh05/cat-server/pkg/infrastructure/http"
+)
+
+// RouteDoc describes a route for the generated OpenAPI document
+type RouteDoc struct {
+	Summary string
+	Query   []Param
+	// Body is the media type of the request body, empty when there is none
+	Body string
+	// Produces lists the media types of successful responses
+	Produces []string
+}
+
+// Param describes a query parameter
+type Param struct {
+	Name        string
+	Description string
+	Required    bool
+}
+
+// Describe attaches documentation to a registered pattern. Patterns that
+// were not registered, e.g. mutating routes refused in read-only mode, are
+// ignored so the document only lists routes that exist.
+func (r *Registry) Describe(pattern string, doc RouteDoc) {
+	for _, route := range r.routes {
+		if route == pattern {
+			if r.docs == nil {
+				r.docs = make(map[string]RouteDoc)
+			}
+			r.docs[pattern] = doc
+			return
+		}
+	}
+}
+
+// OpenAPI is an OpenAPI 3.0 document
+type OpenAPI struct {
+	OpenAPI string                          `json:"openapi"`
+	Info    OpenAPIInfo                     `json:"info"`
+	Paths   map[string]map[string]Operation `json:"paths"`
+}
+
+// OpenAPIInfo holds the API title and version
+type OpenAPIInfo struct {
+	Title   string `json:"title"`
+	Version string `json:"version"`
+}
+
+// Operation is an OpenAPI operation
+type Operation struct {
+	Summary     string              `json:"summary,omitempty"`
+	Parameters  []Parameter         `json:"parameters,omitempty"`
+	RequestBody *RequestBody        `json:"requestBody,omitempty"`
+	Responses   map[string]Response `json:"responses"`
+}
+
+// Parameter is an OpenAPI path or query parameter
+type Parameter struct {
+	Name        string `json:"name"`
+	In          string `json:"in"`
+	Description string `json:"description,omitempty"`
+	Required    bool   `json:"required"`
+	Schema      Schema `json:"schema"`
+}
+
+// RequestBody is an OpenAPI request body
+type RequestBody struct {
+	Required bool                 `json:"required"`
+	Content  map[string]MediaType `json:"content"`
+}
+
+// Response is an OpenAPI response
+type Response struct {
+	Description string               `json:"description"`
+	Content     map[string]MediaType `json:"content,omitempty"`
+}
+
+// MediaType is an OpenAPI media type object
+type MediaType struct {
+	Schema Schema `json:"schema"`
+}
+
+// Schema is the subset of JSON Schema used by the generated document
+type Schema struct {
+	Type string `json:"type,omitempty"`
+}
+
+// OpenAPI builds an OpenAPI document from the registered routes. Every route
+// is listed, documented or not, so the document cannot drift from the mux.
+func (r *Registry) OpenAPI(title, version string) *OpenAPI {
+	doc := &OpenAPI{
+		OpenAPI: "3.0.3",
+		Info:    OpenAPIInfo{Title: title, Version: version},
+		Paths:   make(map[string]map[string]Operation),
+	}
+
+	for _, pattern := range r.routes {
+		method, path, params := parsePattern(pattern)
+		routeDoc := r.docs[pattern]
+
+		op := Operation{
+			Summary: routeDoc.Summary,
+			Responses: map[string]Response{"default": {
+				Description: "Error",
+				Content:     map[string]MediaType{cathttp.ProblemContentType: {Schema: Schema{Type: "object"}}},
+			}},
+		}
+		for _, name := range params {
+			op.Parameters = append(op.Parameters, Parameter{
+				Name: name, In: "path", Required: true, Schema: Schema{Type: "string"},
+			})
+		}
+		for _, param := range routeDoc.Query {
+			op.Parameters = append(op.Parameters, Parameter{
+				Name: param.Name, In: "query", Description: param.Description, Required: param.Required, Schema: Schema{Type: "string"},
+			})
+		}
+		if routeDoc.Body != "" {
+			op.RequestBody = &RequestBody{Required: true, Content: map[string]MediaType{routeDoc.Body: {}}}
+		}
+
+		ok := Response{Description: "OK"}
+		for _, mediaType := range routeDoc.Produces {
+			if ok.Content == nil {
+				ok.Content = make(map[string]MediaType)
+			}
+			ok.Content[mediaType] = MediaType{}
+		}
+		op.Responses["200"] = ok
+
+		if doc.Paths[path] == nil {
+			doc.Paths[path] = make(map[string]Operation)
+		}
+		doc.Paths[path][method] = op
+	}
+	return doc
+}
+
+// parsePattern splits a ServeMux pattern such as "GET /cat/{filename...}"
+// into the lower-case method, the OpenAPI path and its parameter names.
+// Patterns without a method are documented as GET.
+func parsePattern(pattern string) (method, path string, params []string) {
+	method, path = http.MethodGet, pattern
+	if m, p, found := strings.Cut(pattern, " "); found {
+		method, path = m, strings.TrimSpace(p)
+	}
+
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if !strings.HasPrefix(segment, "{") || !strings.HasSuffix(segment, "}") {
+			continue
+		}
+		name := strings.TrimSuffix(strings.Trim(segment, "{}"), "...")
+		if name == "$" {
+			segments[i] = ""
+			continue
+		}
+		params = append(params, name)
+		segments[i] = "{" + name + "}"
+	}
+	return strings.ToLower(method), strings.Join(segments, "/"), params
+}
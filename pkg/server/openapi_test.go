@@ -0,0 +1,77 @@
+package server
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestParsePattern(t *testing.T) {
+	tests := []struct {
+		pattern string
+		method  string
+		path    string
+		params  []string
+	}{
+		{"/health", "get", "/health", nil},
+		{"GET /ls", "get", "/ls", nil},
+		{"GET /cat/{filename...}", "get", "/cat/{filename}", []string{"filename"}},
+		{"POST /archive", "post", "/archive", nil},
+		{"GET /diff/{a}/{b}", "get", "/diff/{a}/{b}", []string{"a", "b"}},
+		{"GET /{$}", "get", "/", nil},
+	}
+
+	for _, tt := range tests {
+		method, path, params := parsePattern(tt.pattern)
+		if method != tt.method || path != tt.path || !reflect.DeepEqual(params, tt.params) {
+			t.Errorf("parsePattern(%q) = %q, %q, %v, expected %q, %q, %v",
+				tt.pattern, method, path, params, tt.method, tt.path, tt.params)
+		}
+	}
+}
+
+func TestOpenAPI(t *testing.T) {
+	ok := func(w http.ResponseWriter, r *http.Request) {}
+
+	registry := NewRegistry(true)
+	registry.HandleFunc("GET /cat/{filename...}", ok)
+	registry.Describe("GET /cat/{filename...}", RouteDoc{
+		Summary:  "Read a file",
+		Query:    []Param{{Name: "transform", Description: "Pipeline"}},
+		Produces: []string{"application/json"},
+	})
+	registry.HandleFunc("GET /health", ok)
+	registry.HandleMutatingFunc("POST /upload", ok)
+	registry.Describe("POST /upload", RouteDoc{Summary: "Upload a file", Body: "application/octet-stream"})
+
+	doc := registry.OpenAPI("cat-server", "1.0.0")
+	if doc.Info.Title != "cat-server" || doc.Info.Version != "1.0.0" {
+		t.Errorf("unexpected info %+v", doc.Info)
+	}
+	if _, found := doc.Paths["/upload"]; found {
+		t.Error("expected the refused mutating route to be left out")
+	}
+
+	cat, found := doc.Paths["/cat/{filename}"]["get"]
+	if !found {
+		t.Fatalf("expected GET /cat/{filename} in %v", doc.Paths)
+	}
+	if cat.Summary != "Read a file" {
+		t.Errorf("expected summary, got %q", cat.Summary)
+	}
+	expected := []Parameter{
+		{Name: "filename", In: "path", Required: true, Schema: Schema{Type: "string"}},
+		{Name: "transform", In: "query", Description: "Pipeline", Schema: Schema{Type: "string"}},
+	}
+	if !reflect.DeepEqual(cat.Parameters, expected) {
+		t.Errorf("expected parameters %+v, got %+v", expected, cat.Parameters)
+	}
+	if _, found := cat.Responses["200"].Content["application/json"]; !found {
+		t.Errorf("expected a JSON response, got %+v", cat.Responses["200"])
+	}
+
+	// Undocumented routes are still listed
+	if _, found := doc.Paths["/health"]["get"]; !found {
+		t.Error("expected the undocumented GET /health to be listed")
+	}
+}
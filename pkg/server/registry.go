@@ -0,0 +1,72 @@
+// Package server holds the HTTP handler registry shared by the cat-server
+// binary and programs embedding its handlers
+package server
+
+import (
+	"net/http"
+
+	cathttp "github.com/sh05/cat-server/pkg/infrastructure/http"
+)
+
+// Registry is an http.ServeMux that records registered patterns. Handlers
+// that may modify the served directory are registered separately so
+// read-only mode and CSRF protection can account for them.
+type Registry struct {
+	*http.ServeMux
+	routes   []string
+	readOnly bool
+	refused  []string
+	mutating []string
+	docs     map[string]RouteDoc
+}
+
+// NewRegistry creates an empty registry. A read-only registry refuses
+// handlers registered with HandleMutatingFunc.
+func NewRegistry(readOnly bool) *Registry {
+	return &Registry{ServeMux: http.NewServeMux(), readOnly: readOnly}
+}
+
+// ServeHTTP dispatches the request to the handler of the matching pattern.
+// Requests matching no pattern get problem details instead of the plain
+// text 404 and 405 responses of http.ServeMux.
+func (r *Registry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if _, pattern := r.ServeMux.Handler(req); pattern == "" {
+		cathttp.ServeProblems(r.ServeMux, w, req)
+		return
+	}
+	r.ServeMux.ServeHTTP(w, req)
+}
+
+// HandleFunc registers the handler for the given pattern and records it
+func (r *Registry) HandleFunc(pattern string, handler func(http.ResponseWriter, *http.Request)) {
+	r.routes = append(r.routes, pattern)
+	r.ServeMux.HandleFunc(pattern, handler)
+}
+
+// HandleMutatingFunc registers a handler that may modify the served
+// directory. In read-only mode the pattern is recorded as refused and left
+// unregistered, so the endpoint does not exist.
+func (r *Registry) HandleMutatingFunc(pattern string, handler func(http.ResponseWriter, *http.Request)) {
+	if r.readOnly {
+		r.refused = append(r.refused, pattern)
+		return
+	}
+	r.mutating = append(r.mutating, pattern)
+	r.HandleFunc(pattern, handler)
+}
+
+// Mutating returns the registered patterns that may modify the served
+// directory
+func (r *Registry) Mutating() []string {
+	return append([]string(nil), r.mutating...)
+}
+
+// Refused returns the mutating patterns not registered in read-only mode
+func (r *Registry) Refused() []string {
+	return append([]string(nil), r.refused...)
+}
+
+// Routes returns the registered patterns in registration order
+func (r *Registry) Routes() []string {
+	return append([]string(nil), r.routes...)
+}
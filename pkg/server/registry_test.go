@@ -0,0 +1,76 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestRegistry(t *testing.T) {
+	ok := func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusNoContent) }
+
+	tests := []struct {
+		name     string
+		readOnly bool
+		routes   []string
+		mutating []string
+		refused  []string
+	}{
+		{"read-write", false, []string{"/ls", "/upload"}, []string{"/upload"}, nil},
+		{"read-only", true, []string{"/ls"}, nil, []string{"/upload"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			registry := NewRegistry(tt.readOnly)
+			registry.HandleFunc("/ls", ok)
+			registry.HandleMutatingFunc("/upload", ok)
+
+			if !reflect.DeepEqual(registry.Routes(), tt.routes) {
+				t.Errorf("Expected routes %v, got %v", tt.routes, registry.Routes())
+			}
+			if !reflect.DeepEqual(registry.Mutating(), tt.mutating) {
+				t.Errorf("Expected mutating routes %v, got %v", tt.mutating, registry.Mutating())
+			}
+			if !reflect.DeepEqual(registry.Refused(), tt.refused) {
+				t.Errorf("Expected refused routes %v, got %v", tt.refused, registry.Refused())
+			}
+
+			w := httptest.NewRecorder()
+			registry.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/upload", nil))
+			expected := http.StatusNoContent
+			if tt.readOnly {
+				expected = http.StatusNotFound
+			}
+			if w.Code != expected {
+				t.Errorf("Expected status %d for /upload, got %d", expected, w.Code)
+			}
+		})
+	}
+}
+
+func TestRegistryProblems(t *testing.T) {
+	registry := NewRegistry(false)
+	registry.HandleFunc("GET /ls", func(w http.ResponseWriter, r *http.Request) {})
+
+	tests := []struct {
+		method string
+		target string
+		status int
+		allow  string
+	}{
+		{http.MethodGet, "/missing", http.StatusNotFound, ""},
+		{http.MethodPost, "/ls", http.StatusMethodNotAllowed, "GET, HEAD"},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		registry.ServeHTTP(w, httptest.NewRequest(tt.method, tt.target, nil))
+		if w.Code != tt.status || w.Header().Get("Allow") != tt.allow {
+			t.Errorf("%s %s: expected status %d and Allow %q, got %d and %q", tt.method, tt.target, tt.status, tt.allow, w.Code, w.Header().Get("Allow"))
+		}
+		if contentType := w.Header().Get("Content-Type"); contentType != "application/problem+json" {
+			t.Errorf("%s %s: expected problem details, got %q with body %q", tt.method, tt.target, contentType, w.Body.String())
+		}
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestDockerSecurityCompliance(t *testing.T) {
@@ -246,28 +247,60 @@ func testPackageSecurity(t *testing.T, imageName string) {
 		t.Logf("Package: %s", pkg)
 	}
 
-	// Test 2: Check for unnecessary packages that shouldn't be there
+	// Test 2: Check for unnecessary packages that shouldn't be there. The
+	// busybox applets (wget, nc, telnet, ...) are part of the base image, so
+	// this checks installed package names rather than commands on PATH.
+	cmd = exec.Command("docker", "run", "--rm", imageName, "apk", "info")
+	output, err = cmd.Output()
+	if err != nil {
+		t.Fatalf("Failed to list installed packages: %v", err)
+	}
+
+	installed := make(map[string]bool)
+	for _, name := range strings.Fields(string(output)) {
+		installed[name] = true
+	}
+
 	unnecessaryPackages := []string{
-		"gcc", "g++", "make", "python", "perl", "ruby",
-		"curl", "ssh", "telnet", "ftp", "nc", "netcat",
+		"gcc", "g++", "make", "python3", "perl", "ruby",
+		"curl", "wget", "openssh", "openssh-client", "busybox-extras",
+		"netcat-openbsd",
 	}
 
 	for _, pkg := range unnecessaryPackages {
-		cmd := exec.Command("docker", "run", "--rm", imageName, "which", pkg)
-		err := cmd.Run()
-		if err == nil {
-			t.Logf("WARNING: Unnecessary package '%s' found in container", pkg)
+		if installed[pkg] {
+			t.Errorf("Unnecessary package '%s' installed in container", pkg)
 		}
 	}
 
-	// Test 3: Verify essential packages are present
-	essentialPackages := []string{"wget"} // wget is needed for health checks
-	for _, pkg := range essentialPackages {
-		cmd := exec.Command("docker", "run", "--rm", imageName, "which", pkg)
-		err := cmd.Run()
-		if err != nil {
-			t.Errorf("Essential package '%s' not found", pkg)
+	// Test 3: Verify the health check works with no extra packages, both
+	// against a running server and without one
+	containerName := "cat-server-healthcheck-test"
+	cleanupTestContainer(containerName)
+
+	cmd = exec.Command("docker", "run", "-d", "--rm", "--name", containerName, imageName)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("Failed to start container: %v, output: %s", err, output)
+	}
+	defer cleanupTestContainer(containerName)
+
+	var healthOutput []byte
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		cmd = exec.Command("docker", "exec", containerName, "./cat-server", "healthcheck")
+		healthOutput, err = cmd.CombinedOutput()
+		if err == nil || time.Now().After(deadline) {
+			break
 		}
+		time.Sleep(500 * time.Millisecond)
+	}
+	if err != nil {
+		t.Errorf("healthcheck failed against a running server: %v, output: %s", err, healthOutput)
+	}
+
+	cmd = exec.Command("docker", "run", "--rm", imageName, "./cat-server", "healthcheck", "--url", "http://localhost:1/health")
+	if output, err := cmd.CombinedOutput(); err == nil {
+		t.Errorf("healthcheck succeeded without a server: %s", output)
 	}
 }
 